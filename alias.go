@@ -0,0 +1,162 @@
+package glib
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/httputil"
+	"github.com/go-chi/chi/v5"
+)
+
+// AliasOptions configures a route alias, see Router.Alias
+type AliasOptions struct {
+	// Redirect answers the requests to the old pattern with a 308 Permanent
+	// Redirect to the new one instead of serving the new route
+	Redirect bool
+
+	// Sunset is when the old pattern stops working, announced with the Sunset
+	// header (RFC 8594). Requests get 410 Gone once it has passed. Zero for none.
+	Sunset time.Time
+}
+
+// Alias serves the routes of newPattern at oldPattern during a transition, e.g.
+// after renaming /accounts/{id} to /organizations/{id}. Responses to the old
+// pattern have the Deprecation header and a Link header to the successor
+// version. Both patterns are relative to the router and must have the same
+// path parameters, the values of the old path being used for the new one. It
+// panics otherwise. The routes of newPattern can be registered after the alias.
+//
+// Example:
+//
+//	r.Get("/organizations/{id}", getOrganization)
+//	r.Alias("/accounts/{id}", "/organizations/{id}", glib.AliasOptions{
+//	    Sunset: time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC),
+//	})
+func (r *router) Alias(oldPattern, newPattern string, opts AliasOptions) *Route {
+	oldParams, newParams := patternParams(oldPattern), patternParams(newPattern)
+	if !slices.Equal(oldParams, newParams) {
+		panic(fmt.Sprintf("glib: alias %s of %s: path parameters %v don't match %v", oldPattern, newPattern, oldParams, newParams))
+	}
+
+	target := r.routePattern(newPattern)
+	handlers := sync.OnceValue(func() map[string]http.Handler {
+		for _, route := range r.chi.Routes() {
+			if route.Pattern == target {
+				return route.Handlers
+			}
+		}
+		return nil
+	})
+
+	return r.handle("", oldPattern, func(c *Ctx) error {
+		newPath := aliasPath(c, newPattern)
+		header := c.Response.Header()
+		if !opts.Sunset.IsZero() {
+			if !time.Now().Before(opts.Sunset) {
+				return errors.Gone(fmt.Sprintf("Moved to %s", c.withBasePath(newPath)), nil)
+			}
+			header.Set("Sunset", opts.Sunset.UTC().Format(http.TimeFormat))
+		}
+		header.Set("Deprecation", "true")
+		c.AddLink(httputil.Link{URL: c.withBasePath(newPath), Rel: "successor-version"})
+
+		if opts.Redirect {
+			if query := c.Request.URL.RawQuery; query != "" {
+				newPath += "?" + query
+			}
+			return c.Redirect(http.StatusPermanentRedirect, newPath)
+		}
+
+		routes := handlers()
+		handler := routes[c.Request.Method]
+		if handler == nil {
+			handler = routes["*"]
+		}
+		switch {
+		case len(routes) == 0:
+			return errors.NotFound(defaultMessage(r.config.NotFoundMessage, DefaultNotFoundMessage), nil)
+		case handler == nil:
+			return errors.MethodNotAllowed(defaultMessage(r.config.MethodNotAllowedMessage, DefaultMethodNotAllowedMessage), nil)
+		}
+
+		u := *c.Request.URL
+		u.Path, u.RawPath = newPath, ""
+		req := c.Request.WithContext(c.Context())
+		req.URL = &u
+		handler.ServeHTTP(c.Response, req)
+		return nil
+	})
+}
+
+// aliasPath returns the path of the request for the new pattern of an alias,
+// with the values of the path parameters of the request
+func aliasPath(c *Ctx, pattern string) string {
+	var b strings.Builder
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			if depth == 0 {
+				b.WriteString(pattern[start:i])
+				start = i + 1
+			}
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				name, _, _ := strings.Cut(pattern[start:i], ":")
+				b.WriteString(c.PathValue(name))
+				start = i + 1
+			}
+		case '*':
+			if depth == 0 {
+				b.WriteString(pattern[start:i])
+				b.WriteString(c.PathValue("*"))
+				start = i + 1
+			}
+		}
+	}
+	b.WriteString(pattern[start:])
+
+	// The path the router routed, relative to the sub-router it is mounted on
+	path := c.Request.URL.Path
+	routePath := path
+	if rctx := chi.RouteContext(c.Context()); rctx != nil && rctx.RoutePath != "" {
+		routePath = rctx.RoutePath
+	}
+	if len(routePath) > len(path) {
+		return b.String()
+	}
+	return path[:len(path)-len(routePath)] + b.String()
+}
+
+// patternParams returns the sorted names of the path parameters of a route
+// pattern, "*" for a trailing wildcard
+func patternParams(pattern string) []string {
+	var params []string
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				name, _, _ := strings.Cut(pattern[start:i], ":")
+				params = append(params, name)
+			}
+		case '*':
+			if depth == 0 {
+				params = append(params, "*")
+			}
+		}
+	}
+	slices.Sort(params)
+	return params
+}
@@ -0,0 +1,120 @@
+package glib
+
+import (
+	"cmp"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_Alias(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+
+	cases := []struct {
+		desc           string
+		old            string
+		new            string
+		opts           AliasOptions
+		method         string
+		path           string
+		expectCode     int
+		expectBody     string
+		expectLocation string
+		expectLink     string
+	}{
+		{
+			desc:       "serves the new route",
+			old:        "/accounts/{id}",
+			path:       "/v1/accounts/42?expand=owner",
+			expectCode: http.StatusOK,
+			expectBody: "organization 42 at /v1/organizations/42 owner",
+			expectLink: `</v1/organizations/42>; rel="successor-version"`,
+		},
+		{
+			desc:       "method not allowed on the new route",
+			old:        "/accounts/{id}",
+			method:     http.MethodDelete,
+			path:       "/v1/accounts/42",
+			expectCode: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:           "redirect",
+			old:            "/accounts/{id}",
+			opts:           AliasOptions{Redirect: true},
+			path:           "/v1/accounts/42?expand=owner",
+			expectCode:     http.StatusPermanentRedirect,
+			expectLocation: "/v1/organizations/42?expand=owner",
+			expectLink:     `</v1/organizations/42>; rel="successor-version"`,
+		},
+		{
+			desc:       "before sunset",
+			old:        "/accounts/{id}",
+			opts:       AliasOptions{Sunset: future},
+			path:       "/v1/accounts/42",
+			expectCode: http.StatusOK,
+			expectBody: "organization 42 at /v1/organizations/42 ",
+			expectLink: `</v1/organizations/42>; rel="successor-version"`,
+		},
+		{
+			desc:       "after sunset",
+			old:        "/accounts/{id}",
+			opts:       AliasOptions{Sunset: time.Now().Add(-time.Hour), Redirect: true},
+			path:       "/v1/accounts/42",
+			expectCode: http.StatusGone,
+		},
+		{
+			desc:       "parameters mapped by name",
+			old:        "/teams/{team}/accounts/{id}",
+			new:        "/organizations/{id}/teams/{team}",
+			path:       "/v1/teams/x/accounts/42",
+			expectCode: http.StatusOK,
+			expectBody: "member 42 of x",
+			expectLink: `</v1/organizations/42/teams/x>; rel="successor-version"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Route("/v1", func(r Router) {
+				r.Alias(tc.old, cmp.Or(tc.new, "/organizations/{id}"), tc.opts)
+				r.Get("/organizations/{id}", func(c *Ctx) error {
+					return c.SendString("organization " + c.PathValue("id") + " at " + c.Request.URL.Path + " " + c.Query("expand"))
+				})
+				r.Get("/organizations/{id}/teams/{team}", func(c *Ctx) error {
+					return c.SendString("member " + c.PathValue("id") + " of " + c.PathValue("team"))
+				})
+			})
+
+			req := httptest.NewRequest(cmp.Or(tc.method, http.MethodGet), tc.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectCode, w.Code)
+			if tc.expectBody != "" {
+				assert.Equal(t, tc.expectBody, w.Body.String())
+			}
+			assert.Equal(t, tc.expectLocation, w.Header().Get("Location"))
+			if tc.expectLink != "" {
+				assert.Equal(t, tc.expectLink, w.Header().Get("Link"))
+				assert.Equal(t, "true", w.Header().Get("Deprecation"))
+			}
+			if !tc.opts.Sunset.IsZero() && tc.expectCode != http.StatusGone {
+				assert.Equal(t, tc.opts.Sunset.UTC().Format(http.TimeFormat), w.Header().Get("Sunset"))
+			}
+		})
+	}
+
+	t.Run("mismatched parameters", func(t *testing.T) {
+		r := setupTestRouter()
+		assert.PanicsWithValue(t, "glib: alias /accounts/{id} of /organizations/{slug}: path parameters [id] don't match [slug]", func() {
+			r.Alias("/accounts/{id}", "/organizations/{slug}", AliasOptions{})
+		})
+		assert.Panics(t, func() {
+			r.Alias("/files/*", "/documents/{id}", AliasOptions{})
+		})
+	})
+}
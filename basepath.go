@@ -0,0 +1,112 @@
+package glib
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// basePathKey is the request context key of the base path stripped from the
+// request path, see Server.SetBasePath
+type basePathKey struct{}
+
+// SetBasePath serves the main listener under a path prefix, typically the path
+// a gateway forwards to the service (e.g. "/users-api"), so that routes are
+// registered without it. The prefix is stripped from the request paths before
+// routing, and re-added by Ctx.Path, Ctx.BaseURL and the helpers setting the
+// Location header (Ctx.Redirect, Ctx.AcceptedJob, the trailing slash redirects).
+// Requests outside the prefix get a 404. Also set by Config.BasePath or
+// BASE_PATH. It must be called before Listen.
+func (s *Server) SetBasePath(prefix string) {
+	s.basePath = cleanBasePath(prefix)
+}
+
+// BasePath returns the path prefix the server is served under, see SetBasePath
+func (s *Server) BasePath() string {
+	return s.basePath
+}
+
+// cleanBasePath returns the prefix with a leading slash and without a trailing
+// one, "" for the root
+func cleanBasePath(prefix string) string {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
+
+// stripBasePath wraps the handler of the main listener to strip the base path
+// from the request paths, the requests outside of it being answered by the 404
+// handler of the router
+func (s *Server) stripBasePath(next http.Handler) http.Handler {
+	prefix := s.basePath
+	if prefix == "" {
+		return next
+	}
+	notFound := http.NotFoundHandler()
+	if r, ok := s.router.(*router); ok {
+		notFound = r.wrapHandler(func(c *Ctx) error {
+			return errors.NotFound(defaultMessage(r.config.NotFoundMessage, DefaultNotFoundMessage), nil)
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path, ok := trimBasePath(req.URL.Path, prefix)
+		if !ok {
+			notFound.ServeHTTP(w, req)
+			return
+		}
+
+		req = req.WithContext(context.WithValue(req.Context(), basePathKey{}, prefix))
+		u := *req.URL
+		u.Path = path
+		u.RawPath, _ = trimBasePath(u.RawPath, prefix)
+		req.URL = &u
+		next.ServeHTTP(w, req)
+	})
+}
+
+// trimBasePath returns the path without the prefix, reporting false when it is
+// outside of it
+func trimBasePath(path, prefix string) (string, bool) {
+	if path == prefix {
+		return "/", true
+	}
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok || !strings.HasPrefix(rest, "/") {
+		return "", false
+	}
+	return rest, true
+}
+
+// BasePath returns the path prefix stripped from the request path before
+// routing, "" when the server has none, see Server.SetBasePath
+func (c *Ctx) BasePath() string {
+	prefix, _ := c.Context().Value(basePathKey{}).(string)
+	return prefix
+}
+
+// withBasePath adds the base path to the absolute paths of the application,
+// leaving the URLs with a host and the relative ones untouched
+func (c *Ctx) withBasePath(location string) string {
+	prefix := c.BasePath()
+	if prefix == "" || !strings.HasPrefix(location, "/") || strings.HasPrefix(location, "//") {
+		return location
+	}
+	return prefix + location
+}
+
+// publicRequest returns the request with its path as sent by the client, so
+// that relative redirects are resolved against it
+func (c *Ctx) publicRequest() *http.Request {
+	if c.BasePath() == "" {
+		return c.Request
+	}
+	req := *c.Request
+	req.URL = &url.URL{Path: c.Path(), RawQuery: c.Request.URL.RawQuery}
+	return &req
+}
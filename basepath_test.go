@@ -0,0 +1,214 @@
+package glib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azizndao/glib/jobs"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBasePathHandler returns the router served under the base path like the
+// main listener
+func newBasePathHandler(r Router, prefix string) http.Handler {
+	s := &Server{router: r}
+	s.SetBasePath(prefix)
+	return s.stripBasePath(r)
+}
+
+func TestServer_SetBasePath(t *testing.T) {
+	tests := []struct {
+		desc     string
+		prefix   string
+		expected string
+	}{
+		{desc: "clean", prefix: "/users-api", expected: "/users-api"},
+		{desc: "trailing slash", prefix: "/users-api/", expected: "/users-api"},
+		{desc: "no leading slash", prefix: "users-api", expected: "/users-api"},
+		{desc: "root", prefix: "/", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := &Server{}
+			s.SetBasePath(tt.prefix)
+			assert.Equal(t, tt.expected, s.BasePath())
+		})
+	}
+}
+
+func TestBasePath_Routing(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/users/{id}", func(c *Ctx) error {
+		return c.JSON(map[string]string{
+			"id":       c.PathValue("id"),
+			"path":     c.Path(),
+			"routed":   c.Request.URL.Path,
+			"base_url": c.BaseURL(),
+		})
+	})
+	r.Get("/", func(c *Ctx) error {
+		return c.SendString(c.Path())
+	})
+	h := newBasePathHandler(r, "/users-api/")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/users-api/users/5", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, map[string]string{
+		"id":       "5",
+		"path":     "/users-api/users/5",
+		"routed":   "/users/5",
+		"base_url": "http://example.com/users-api",
+	}, body)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users-api", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/users-api/", w.Body.String())
+}
+
+func TestBasePath_NotFound(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/users", func(c *Ctx) error { return c.SendString("users") })
+	h := newBasePathHandler(r, "/users-api")
+
+	tests := []struct {
+		desc string
+		path string
+	}{
+		{desc: "unknown route", path: "/users-api/missing"},
+		{desc: "route without the base path", path: "/users"},
+		{desc: "other prefix", path: "/orders-api/users"},
+		{desc: "prefix of a segment", path: "/users-apiv2/users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), DefaultNotFoundMessage)
+		})
+	}
+}
+
+func TestBasePath_Redirect(t *testing.T) {
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), RouterConfig{
+		TrailingSlashRedirect:  true,
+		CaseInsensitiveRouting: true,
+	})
+	r.Get("/absolute", func(c *Ctx) error {
+		return c.Redirect(http.StatusFound, "/login?next=1")
+	})
+	r.Get("/docs/relative", func(c *Ctx) error {
+		return c.Redirect(http.StatusFound, "../guide")
+	})
+	r.Get("/external", func(c *Ctx) error {
+		return c.Redirect(http.StatusFound, "https://example.com/login")
+	})
+	r.Get("/users", func(c *Ctx) error { return c.SendString("users") })
+	h := newBasePathHandler(r, "/users-api")
+
+	tests := []struct {
+		desc     string
+		path     string
+		status   int
+		location string
+	}{
+		{desc: "absolute path", path: "/users-api/absolute", status: http.StatusFound, location: "/users-api/login?next=1"},
+		{desc: "relative path", path: "/users-api/docs/relative", status: http.StatusFound, location: "/users-api/guide"},
+		{desc: "absolute URL", path: "/users-api/external", status: http.StatusFound, location: "https://example.com/login"},
+		{desc: "trailing slash", path: "/users-api/Users/?page=2", status: http.StatusMovedPermanently, location: "/users-api/Users?page=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestBasePath_StaticFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.css"), []byte("body{}"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>home</h1>"), 0o600))
+
+	r := setupTestRouter()
+	r.Handle("/static/*", http.StripPrefix("/static", http.FileServer(http.Dir(dir))))
+	r.Get("/download", func(c *Ctx) error {
+		return c.SendFile(filepath.Join(dir, "app.css"), true)
+	})
+	h := newBasePathHandler(r, "/users-api")
+
+	tests := []struct {
+		desc     string
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{desc: "file", path: "/users-api/static/app.css", status: http.StatusOK, body: "body{}"},
+		{desc: "directory index", path: "/users-api/static/", status: http.StatusOK, body: "<h1>home</h1>"},
+		// Relative to the public URL, /users-api/static/
+		{desc: "index redirect", path: "/users-api/static/index.html", status: http.StatusMovedPermanently, location: "./"},
+		{desc: "missing file", path: "/users-api/static/missing.css", status: http.StatusNotFound},
+		{desc: "send file", path: "/users-api/download", status: http.StatusOK, body: "body{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, w.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestBasePath_AcceptedJob(t *testing.T) {
+	manager := jobs.NewManager()
+	r := setupTestRouter()
+	r.Handle("/jobs/{id}", jobs.StatusHandler(manager))
+	r.Post("/exports", func(c *Ctx) error {
+		job, err := manager.Start(c.Context(), func(ctx context.Context, progress func(float64)) (any, error) {
+			return nil, nil
+		})
+		if err != nil {
+			return err
+		}
+		return c.AcceptedJob(job)
+	})
+	h := newBasePathHandler(r, "/users-api")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users-api/exports", nil))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var accepted jobs.Job
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &accepted))
+	location := w.Header().Get("Location")
+	assert.Equal(t, "/users-api/jobs/"+accepted.ID, location)
+	assert.Equal(t, location, accepted.URL)
+
+	manager.Wait()
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, location, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,208 @@
+package glib
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// bindSource is a request value source used by the binders, with its struct tag
+type bindSource struct {
+	tag    string
+	values func(c *Ctx, name string) []string
+}
+
+var (
+	pathSource = bindSource{tag: "path", values: func(c *Ctx, name string) []string {
+		if value := c.PathValue(name); value != "" {
+			return []string{value}
+		}
+		return nil
+	}}
+	querySource = bindSource{tag: "query", values: func(c *Ctx, name string) []string {
+		return c.Queries()[name]
+	}}
+	headerSource = bindSource{tag: "header", values: func(c *Ctx, name string) []string {
+		return c.Request.Header.Values(name)
+	}}
+)
+
+// BindQuery binds the query parameters into the fields of out tagged with `query`.
+// Supported field types are strings, booleans, numbers, time.Duration, time.Time
+// (RFC 3339), encoding.TextUnmarshaler implementations, pointers and slices of
+// them. Slices accept repeated or comma-separated parameters.
+// Returns a 400 Bad Request error when a value cannot be converted.
+//
+// Example:
+//
+//	type ListUsers struct {
+//	    Page   int      `query:"page"`
+//	    Status []string `query:"status"`
+//	}
+func (c *Ctx) BindQuery(out any) error {
+	return c.bindValues(out, querySource)
+}
+
+// BindRequest binds the whole request into out: the body is decoded with ParseBody
+// when present, then the fields tagged with `path`, `query` and `header` are set
+// from the path parameters, query parameters and request headers.
+//
+// Example:
+//
+//	type UpdateUser struct {
+//	    ID     int    `path:"id"`
+//	    DryRun bool   `query:"dry_run"`
+//	    Tenant string `header:"X-Tenant"`
+//	    Name   string `json:"name"`
+//	}
+func (c *Ctx) BindRequest(out any) error {
+	if hasBody(c.Request) {
+		if err := c.ParseBody(out); err != nil {
+			return err
+		}
+	}
+	return c.bindValues(out, pathSource, querySource, headerSource)
+}
+
+// hasBody reports whether the request may have a body to decode
+func hasBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+	return r.ContentLength != 0
+}
+
+func (c *Ctx) bindValues(out any, sources ...bindSource) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.InternalServerError("Server Error", fmt.Errorf("glib: bind target must be a pointer to a struct, got %T", out))
+	}
+	return c.bindStruct(v.Elem(), sources)
+}
+
+func (c *Ctx) bindStruct(v reflect.Value, sources []bindSource) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		field := v.Field(i)
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := c.bindStruct(field, sources); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		for _, source := range sources {
+			name, _, _ := strings.Cut(sf.Tag.Get(source.tag), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			values := source.values(c, name)
+			if len(values) == 0 {
+				continue
+			}
+			if err := setFieldValues(field, values); err != nil {
+				return errors.BadRequest(fmt.Sprintf("Invalid %s parameter %s", source.tag, name), err)
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// setFieldValues sets the field from the request values
+func setFieldValues(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) {
+		var items []string
+		for _, value := range values {
+			items = append(items, strings.Split(value, ",")...)
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setFieldValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setFieldValue(field, values[0])
+}
+
+func setFieldValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFieldValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	case field.Type() == timeType:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
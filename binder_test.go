@@ -0,0 +1,118 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindPaging struct {
+	Page  int `query:"page"`
+	Limit int `query:"limit"`
+}
+
+type bindListUsers struct {
+	bindPaging
+	Status  []string       `query:"status"`
+	Active  *bool          `query:"active"`
+	Timeout time.Duration  `query:"timeout"`
+	Since   time.Time      `query:"since"`
+	Score   float64        `query:"score"`
+	Level   slogLevelParam `query:"level"`
+	Ignored string
+}
+
+// slogLevelParam implements encoding.TextUnmarshaler, for testing
+type slogLevelParam string
+
+func (l *slogLevelParam) UnmarshalText(text []byte) error {
+	*l = slogLevelParam(strings.ToUpper(string(text)))
+	return nil
+}
+
+func TestCtx_BindQuery(t *testing.T) {
+	active := true
+	cases := []struct {
+		desc     string
+		query    string
+		expected bindListUsers
+		status   int
+	}{
+		{desc: "empty", query: "", expected: bindListUsers{}},
+		{
+			desc:  "all types",
+			query: "page=2&limit=10&status=active,banned&status=new&active=true&timeout=5s&since=2024-03-01T00:00:00Z&score=1.5&level=debug&Ignored=x",
+			expected: bindListUsers{
+				bindPaging: bindPaging{Page: 2, Limit: 10},
+				Status:     []string{"active", "banned", "new"},
+				Active:     &active,
+				Timeout:    5 * time.Second,
+				Since:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				Score:      1.5,
+				Level:      "DEBUG",
+			},
+		},
+		{desc: "invalid number", query: "page=abc", status: http.StatusBadRequest},
+		{desc: "invalid bool", query: "active=maybe", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var out bindListUsers
+			var bindErr error
+			r := setupTestRouter()
+			r.Get("/users", func(c *Ctx) error {
+				bindErr = c.BindQuery(&out)
+				return bindErr
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?"+tc.query, nil))
+
+			if tc.status != 0 {
+				assert.Equal(t, tc.status, w.Code)
+				return
+			}
+			require.NoError(t, bindErr)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestCtx_BindRequest(t *testing.T) {
+	type updateUser struct {
+		ID     int    `path:"id"`
+		DryRun bool   `query:"dry_run"`
+		Tenant string `header:"X-Tenant"`
+		Name   string `json:"name"`
+	}
+
+	var out updateUser
+	r := setupTestRouter()
+	r.Put("/users/{id}", func(c *Ctx) error {
+		return c.BindRequest(&out)
+	})
+	r.Delete("/users/{id}", func(c *Ctx) error {
+		return c.BindRequest(&out)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/users/42?"+url.Values{"dry_run": {"true"}}.Encode(), strings.NewReader(`{"name":"john","ID":7}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant", "acme")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, updateUser{ID: 42, DryRun: true, Tenant: "acme", Name: "john"}, out, "path parameters win over the body")
+
+	out = updateUser{}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/42", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "requests without body are accepted")
+	assert.Equal(t, updateUser{ID: 42}, out)
+}
@@ -0,0 +1,34 @@
+package glib
+
+import (
+	"github.com/azizndao/glib/httputil"
+)
+
+// Vary adds the given request header names to the Vary response header,
+// without duplicates. Use it whenever the response depends on a request
+// header so caches store one representation per header value.
+func (c *Ctx) Vary(headers ...string) *Ctx {
+	httputil.AddVary(c.header(), headers...)
+	return c
+}
+
+// CacheControl merges the given directives into the Cache-Control response header.
+// Later calls merge with earlier ones: a directive replaces the one with the same
+// name and "public" / "private" replace each other.
+//
+// Example:
+//
+//	c.CacheControl("public", "max-age=60")
+//	c.CacheControl("max-age=300") // Cache-Control: public, max-age=300
+func (c *Ctx) CacheControl(directives ...string) *Ctx {
+	httputil.MergeCacheControl(c.header(), directives...)
+	return c
+}
+
+// NoCache prevents caches from storing the response
+func (c *Ctx) NoCache() *Ctx {
+	header := c.header()
+	header.Del("Cache-Control")
+	httputil.MergeCacheControl(header, "no-store", "no-cache", "must-revalidate")
+	return c
+}
@@ -0,0 +1,78 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCtx_VaryAndCacheControl(t *testing.T) {
+	r := setupTestRouter()
+	r.UseHTTP(middleware.Compress(middleware.DefaultCompressConfig()))
+	r.Get("/users", func(c *Ctx) error {
+		c.CacheControl("public", "max-age=60")
+		c.Vary("Origin")
+		_ = c.Locale()
+		if c.Negotiate("application/json", "text/csv") == "text/csv" {
+			c.CacheControl("max-age=300")
+			return c.SendString(strings.Repeat("id,name\n", 200))
+		}
+		return c.JSON(map[string]string{"name": "john"})
+	})
+	r.Get("/private", func(c *Ctx) error {
+		c.CacheControl("public", "max-age=60")
+		c.NoCache()
+		return c.NoContent()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Accept", "application/json;q=0.5, text/csv")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, []string{"Accept-Encoding, Origin, Accept-Language, Accept"}, w.Header().Values("Vary"))
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private", nil))
+	assert.Equal(t, "no-store, no-cache, must-revalidate", w.Header().Get("Cache-Control"))
+}
+
+func TestCtx_Negotiate(t *testing.T) {
+	cases := []struct {
+		desc     string
+		accept   string
+		offers   []string
+		expected string
+	}{
+		{desc: "no accept header", accept: "", offers: []string{"application/json", "text/csv"}, expected: "application/json"},
+		{desc: "quality values", accept: "application/json;q=0.5, text/csv", offers: []string{"application/json", "text/csv"}, expected: "text/csv"},
+		{desc: "wildcard subtype", accept: "text/*", offers: []string{"application/json", "text/csv"}, expected: "text/csv"},
+		{desc: "specific range wins", accept: "*/*;q=0.9, application/json;q=0.1", offers: []string{"application/json", "text/csv"}, expected: "text/csv"},
+		{desc: "not acceptable", accept: "image/png", offers: []string{"application/json"}, expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Get("/", func(c *Ctx) error {
+				assert.Equal(t, tc.expected, c.Negotiate(tc.offers...))
+				return c.NoContent()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Package cachex provides in-memory caching of computed values.
+package cachex
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MemoConfig holds the options of a memoized value
+type MemoConfig struct {
+	// StaleWhileRevalidate is how long an expired value is still returned while
+	// it is reloaded in the background. Default: 0, callers wait for the reload.
+	StaleWhileRevalidate time.Duration
+}
+
+// Stats are the counters of a memoized value, e.g. to export as metrics
+type Stats struct {
+	// Hits is the number of calls to Get returning a fresh value
+	Hits uint64
+	// StaleHits is the number of calls to Get returning an expired value while
+	// it is reloaded, see MemoConfig.StaleWhileRevalidate
+	StaleHits uint64
+	// Misses is the number of calls to Get waiting for the value to be loaded
+	Misses uint64
+	// Loads is the number of calls to the load function
+	Loads uint64
+	// LoadErrors is the number of loads that failed
+	LoadErrors uint64
+}
+
+// Memoized is a value computed by a load function and cached for a TTL, safe
+// for concurrent use. Concurrent calls share a single load. Create it with Memo.
+type Memoized[T any] struct {
+	ttl   time.Duration
+	stale time.Duration
+	load  func(ctx context.Context) (T, error)
+	now   func() time.Time
+
+	mu         sync.Mutex
+	value      T
+	loadedAt   time.Time
+	valid      bool
+	generation uint64   // incremented by Invalidate
+	call       *call[T] // load in progress
+
+	hits, staleHits, misses, loads, loadErrors atomic.Uint64
+}
+
+// call is a load in progress, shared by the callers waiting for it
+type call[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Memo returns a value loaded by load and cached for ttl, typically stored in
+// a package variable or next to the Server for data needed by many requests
+// such as settings or feature flags. Errors are not cached.
+//
+// Example:
+//
+//	var settings = cachex.Memo(time.Minute, func(ctx context.Context) (Settings, error) {
+//	    return db.LoadSettings(ctx)
+//	}, cachex.MemoConfig{StaleWhileRevalidate: 10 * time.Second})
+//
+//	func handler(c *glib.Ctx) error {
+//	    s, err := settings.Get(c.Context())
+//	    ...
+//	}
+func Memo[T any](ttl time.Duration, load func(ctx context.Context) (T, error), config ...MemoConfig) *Memoized[T] {
+	var cfg MemoConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return &Memoized[T]{
+		ttl:   ttl,
+		stale: max(cfg.StaleWhileRevalidate, 0),
+		load:  load,
+		now:   time.Now,
+	}
+}
+
+// Get returns the cached value, loading it when it is missing or expired. An
+// expired value is returned as is within the stale-while-revalidate period,
+// and reloaded in the background. The load is shared by concurrent callers and
+// isn't canceled with ctx, each caller only stops waiting for it.
+func (m *Memoized[T]) Get(ctx context.Context) (T, error) {
+	m.mu.Lock()
+	if m.valid {
+		age := m.now().Sub(m.loadedAt)
+		if age < m.ttl {
+			value := m.value
+			m.mu.Unlock()
+			m.hits.Add(1)
+			return value, nil
+		}
+		if age < m.ttl+m.stale {
+			value := m.value
+			m.start(ctx)
+			m.mu.Unlock()
+			m.staleHits.Add(1)
+			return value, nil
+		}
+	}
+	c := m.start(ctx)
+	m.mu.Unlock()
+	m.misses.Add(1)
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Invalidate discards the cached value: the next call to Get loads it again,
+// without waiting for or using a load started before
+func (m *Memoized[T]) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var zero T
+	m.value, m.valid = zero, false
+	m.generation++
+	m.call = nil
+}
+
+// Stats returns the counters of the memoized value
+func (m *Memoized[T]) Stats() Stats {
+	return Stats{
+		Hits:       m.hits.Load(),
+		StaleHits:  m.staleHits.Load(),
+		Misses:     m.misses.Load(),
+		Loads:      m.loads.Load(),
+		LoadErrors: m.loadErrors.Load(),
+	}
+}
+
+// start returns the load in progress, or starts one. m.mu must be held.
+func (m *Memoized[T]) start(ctx context.Context) *call[T] {
+	if m.call != nil {
+		return m.call
+	}
+
+	c := &call[T]{done: make(chan struct{})}
+	m.call = c
+	generation := m.generation
+	m.loads.Add(1)
+
+	go func() {
+		defer close(c.done)
+		c.value, c.err = m.safeLoad(context.WithoutCancel(ctx))
+		if c.err != nil {
+			m.loadErrors.Add(1)
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.call == c {
+			m.call = nil
+		}
+		if c.err == nil && m.generation == generation {
+			m.value, m.loadedAt, m.valid = c.value, m.now(), true
+		}
+	}()
+	return c
+}
+
+// safeLoad calls the load function, turning panics into errors as there is no
+// caller to propagate them to
+func (m *Memoized[T]) safeLoad(ctx context.Context) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cachex: load panicked: %v", r)
+		}
+	}()
+	return m.load(ctx)
+}
@@ -0,0 +1,223 @@
+package cachex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock, safe for concurrent use
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newCounter(clock *fakeClock, ttl time.Duration, config ...MemoConfig) (*Memoized[int64], *atomic.Int64) {
+	var loads atomic.Int64
+	m := Memo(ttl, func(ctx context.Context) (int64, error) {
+		return loads.Add(1), nil
+	}, config...)
+	m.now = clock.Now
+	return m, &loads
+}
+
+func TestMemo_Get(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	m, _ := newCounter(clock, time.Minute)
+	ctx := t.Context()
+
+	value, err := m.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+
+	clock.Advance(59 * time.Second)
+	value, err = m.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value, "fresh value")
+
+	clock.Advance(time.Second)
+	value, err = m.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value, "expired value is reloaded")
+
+	m.Invalidate()
+	value, err = m.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), value, "invalidated value is reloaded")
+
+	assert.Equal(t, Stats{Hits: 1, Misses: 3, Loads: 3}, m.Stats())
+}
+
+func TestMemo_StaleWhileRevalidate(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	release := make(chan struct{})
+	var loads atomic.Int64
+	m := Memo(time.Minute, func(ctx context.Context) (int64, error) {
+		if n := loads.Add(1); n > 1 {
+			<-release
+			return n, nil
+		}
+		return 1, nil
+	}, MemoConfig{StaleWhileRevalidate: 10 * time.Second})
+	m.now = clock.Now
+	ctx := t.Context()
+
+	_, err := m.Get(ctx)
+	require.NoError(t, err)
+
+	clock.Advance(65 * time.Second)
+	for range 3 {
+		value, err := m.Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), value, "stale value while the reload is blocked")
+	}
+	close(release)
+
+	require.Eventually(t, func() bool {
+		value, err := m.Get(ctx)
+		return err == nil && value == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int64(2), loads.Load(), "a single background reload")
+
+	clock.Advance(80 * time.Second)
+	value, err := m.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), value, "past the stale period the caller waits")
+
+	stats := m.Stats()
+	assert.GreaterOrEqual(t, stats.StaleHits, uint64(3))
+	assert.Equal(t, uint64(3), stats.Loads)
+}
+
+func TestMemo_Errors(t *testing.T) {
+	fail := true
+	m := Memo(time.Minute, func(ctx context.Context) (string, error) {
+		if fail {
+			return "", errors.New("unavailable")
+		}
+		return "ok", nil
+	})
+	ctx := t.Context()
+
+	_, err := m.Get(ctx)
+	assert.EqualError(t, err, "unavailable")
+
+	fail = false
+	value, err := m.Get(ctx)
+	require.NoError(t, err, "errors are not cached")
+	assert.Equal(t, "ok", value)
+
+	panicking := Memo(time.Minute, func(ctx context.Context) (string, error) {
+		panic("boom")
+	})
+	_, err = panicking.Get(ctx)
+	assert.EqualError(t, err, "cachex: load panicked: boom")
+	assert.Equal(t, uint64(1), panicking.Stats().LoadErrors)
+}
+
+func TestMemo_ContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	m := Memo(time.Minute, func(ctx context.Context) (string, error) {
+		<-release
+		return "ok", ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := m.Get(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	value, err := m.Get(t.Context())
+	require.NoError(t, err, "the shared load isn't canceled with the first caller")
+	assert.Equal(t, "ok", value)
+}
+
+func TestMemo_InvalidateDuringLoad(t *testing.T) {
+	release := make(chan struct{})
+	var loads atomic.Int64
+	m := Memo(time.Minute, func(ctx context.Context) (int64, error) {
+		n := loads.Add(1)
+		if n == 1 {
+			<-release
+		}
+		return n, nil
+	})
+
+	done := make(chan int64)
+	go func() {
+		value, _ := m.Get(context.Background())
+		done <- value
+	}()
+	require.Eventually(t, func() bool { return loads.Load() == 1 }, time.Second, time.Millisecond)
+
+	m.Invalidate()
+	value, err := m.Get(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value, "loads started before Invalidate are not used")
+
+	close(release)
+	assert.Equal(t, int64(1), <-done)
+	value, err = m.Get(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value, "loads started before Invalidate are not stored")
+}
+
+func TestMemo_Concurrent(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	m, loads := newCounter(clock, time.Second, MemoConfig{StaleWhileRevalidate: time.Second})
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Go(func() {
+			for j := range 100 {
+				_, err := m.Get(context.Background())
+				assert.NoError(t, err)
+				if (i+j)%50 == 0 {
+					clock.Advance(700 * time.Millisecond)
+				}
+				if (i+j)%97 == 0 {
+					m.Invalidate()
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	stats := m.Stats()
+	assert.Equal(t, uint64(100*100), stats.Hits+stats.StaleHits+stats.Misses)
+	// Background reloads may still be running
+	assert.Eventually(t, func() bool { return uint64(loads.Load()) == m.Stats().Loads }, time.Second, time.Millisecond)
+	assert.Less(t, stats.Loads, uint64(100*100), "concurrent loads are shared")
+}
+
+func BenchmarkMemo_Get(b *testing.B) {
+	m := Memo(time.Hour, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	ctx := context.Background()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = m.Get(ctx)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package glib
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// foldCase lowercases s without changing its length: the letters whose lowercase
+// form is encoded on a different number of bytes (e.g. "İ") are kept as is, so
+// the offsets of the folded path match the original one
+func foldCase(s string) string {
+	var b strings.Builder
+	folded := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		lower := unicode.ToLower(r)
+		switch {
+		case r == utf8.RuneError || lower == r || utf8.RuneLen(lower) != size:
+			if folded {
+				b.WriteString(s[i : i+size])
+			}
+		default:
+			if !folded {
+				b.Grow(len(s))
+				b.WriteString(s[:i])
+				folded = true
+			}
+			b.WriteRune(lower)
+		}
+		i += size
+	}
+	if !folded {
+		return s
+	}
+	return b.String()
+}
+
+// foldPattern lowercases the static parts of a route pattern, leaving the
+// parameter names and regular expressions unchanged
+func foldPattern(pattern string) string {
+	var b strings.Builder
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			if depth == 0 {
+				b.WriteString(foldCase(pattern[start:i]))
+				start = i
+			}
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				b.WriteString(pattern[start : i+1])
+				start = i + 1
+			}
+		}
+	}
+	b.WriteString(foldCase(pattern[start:]))
+	return b.String()
+}
+
+// routePattern returns the pattern routes are registered with: the path
+// parameter shorthands are expanded (see RouterConfig.ParamPatterns) and the
+// static parts are lowercased with RouterConfig.CaseInsensitiveRouting
+func (r *router) routePattern(pattern string) string {
+	pattern = r.expandParams(pattern)
+	if !r.config.CaseInsensitiveRouting {
+		return pattern
+	}
+	return foldPattern(pattern)
+}
+
+// caseInsensitive is the chi middleware routing the requests of a router with
+// RouterConfig.CaseInsensitiveRouting. The path is lowercased to find the route,
+// then routed with the values of the path parameters taken from the original
+// path. With RouterConfig.TrailingSlashRedirect, requests with a trailing slash
+// are redirected to the route without it, keeping the original case.
+func (r *router) caseInsensitive(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rctx := chi.RouteContext(req.Context())
+		if rctx == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		path := rctx.RoutePath
+		mounted := path != ""
+		if !mounted {
+			path = req.URL.RawPath
+			if path == "" {
+				path = req.URL.Path
+			}
+		}
+		folded := foldCase(path)
+		pattern := r.chi.Find(chi.NewRouteContext(), req.Method, folded)
+
+		if pattern == "" && !mounted && r.config.TrailingSlashRedirect && len(path) > 1 && strings.HasSuffix(path, "/") {
+			if r.chi.Find(chi.NewRouteContext(), req.Method, strings.TrimSuffix(folded, "/")) != "" {
+				redirectWithoutSlash(w, req)
+				return
+			}
+		}
+
+		rctx.RoutePath = folded
+		if pattern != "" && folded != path {
+			rctx.RoutePath = restoreParams(r.chi, req.Method, path, folded, pattern)
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// restoreParams rebuilds the folded path matching pattern with the segments
+// matched by its parameters taken from the original path
+func restoreParams(routes chi.Routes, method, path, folded, pattern string) string {
+	rctx := chi.NewRouteContext()
+	routes.Find(rctx, method, folded)
+	var values []string
+	for i, key := range rctx.URLParams.Keys {
+		// Skip the wildcards of the mounts, only the trailing one is in the pattern
+		if key != "*" {
+			values = append(values, rctx.URLParams.Values[i])
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(path))
+	offset, depth := 0, 0
+	for i := 0; i < len(pattern) && offset < len(path); i++ {
+		switch c := pattern[i]; {
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth--; depth == 0 && len(values) > 0 {
+				end := min(offset+len(values[0]), len(path))
+				b.WriteString(path[offset:end])
+				offset, values = end, values[1:]
+			}
+		case depth > 0:
+		case c == '*' && i == len(pattern)-1:
+			b.WriteString(path[offset:])
+			offset = len(path)
+		default:
+			b.WriteByte(folded[offset])
+			offset++
+		}
+	}
+	b.WriteString(folded[offset:])
+	return b.String()
+}
+
+// redirectWithoutSlash redirects the request to its path without the trailing slash
+func redirectWithoutSlash(w http.ResponseWriter, req *http.Request) {
+	prefix, _ := req.Context().Value(basePathKey{}).(string)
+	target := prefix + strings.TrimSuffix(req.URL.EscapedPath(), "/")
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	code := http.StatusMovedPermanently
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		code = http.StatusPermanentRedirect
+	}
+	http.Redirect(w, req, target, code)
+}
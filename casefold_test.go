@@ -0,0 +1,108 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_CaseInsensitiveRouting(t *testing.T) {
+	newRouter := func(trailingSlashRedirect bool) Router {
+		r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), RouterConfig{
+			CaseInsensitiveRouting: true,
+			TrailingSlashRedirect:  trailingSlashRedirect,
+		})
+		params := func(c *Ctx) error {
+			return c.SendString(c.PathValue("id") + "|" + c.PathValue("name"))
+		}
+		r.Get("/Users/{id}", params)
+		r.Get("/users/{id}/Files/{name}", params)
+		r.Get("/café/{name}", params)
+		r.Get("/static/*", func(c *Ctx) error { return c.SendString(c.PathValue("*")) })
+		r.Route("/API/v1", func(r Router) {
+			r.Get("/Items/{id}", params)
+			r.Route("/Nested", func(r Router) {
+				r.Get("/{name}", params)
+			})
+		})
+		return r
+	}
+
+	tests := []struct {
+		desc                  string
+		path                  string
+		trailingSlashRedirect bool
+		expectCode            int
+		expectBody            string
+		expectLocation        string
+	}{
+		{desc: "mixed-case pattern", path: "/users/AbC", expectCode: http.StatusOK, expectBody: "AbC|"},
+		{desc: "mixed-case path", path: "/USERS/AbC", expectCode: http.StatusOK, expectBody: "AbC|"},
+		{desc: "param equal to a static segment", path: "/Users/USERS/files/Users", expectCode: http.StatusOK, expectBody: "USERS|Users"},
+		{desc: "unicode path", path: "/CAFÉ/Crème", expectCode: http.StatusOK, expectBody: "|Crème"},
+		{desc: "wildcard", path: "/Static/CSS/App.css", expectCode: http.StatusOK, expectBody: "CSS/App.css"},
+		{desc: "mounted sub-router", path: "/api/V1/ITEMS/X1", expectCode: http.StatusOK, expectBody: "X1|"},
+		{desc: "nested sub-router", path: "/Api/v1/nested/MiXeD", expectCode: http.StatusOK, expectBody: "|MiXeD"},
+		{desc: "not found", path: "/Unknown", expectCode: http.StatusNotFound},
+		{desc: "strict trailing slash", path: "/Users/AbC/", expectCode: http.StatusNotFound},
+		{
+			desc:                  "trailing slash redirected",
+			path:                  "/USERS/AbC/?page=2",
+			trailingSlashRedirect: true,
+			expectCode:            http.StatusMovedPermanently,
+			expectLocation:        "/USERS/AbC?page=2",
+		},
+		{desc: "trailing slash of unknown route", path: "/Unknown/", trailingSlashRedirect: true, expectCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			r := newRouter(test.trailingSlashRedirect)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			assert.Equal(t, test.expectCode, w.Code)
+			if test.expectBody != "" {
+				assert.Equal(t, test.expectBody, w.Body.String())
+			}
+			assert.Equal(t, test.expectLocation, w.Header().Get("Location"))
+		})
+	}
+
+	t.Run("case-sensitive by default", func(t *testing.T) {
+		r := setupTestRouter()
+		r.Get("/users/{id}", func(c *Ctx) error { return c.NoContent() })
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/Users/1", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestFoldCase(t *testing.T) {
+	tests := []struct {
+		desc   string
+		input  string
+		expect string
+	}{
+		{desc: "ascii", input: "/Users/ABC", expect: "/users/abc"},
+		{desc: "unchanged", input: "/users", expect: "/users"},
+		{desc: "unicode", input: "/ÉTÉ/Ωmega", expect: "/été/ωmega"},
+		{desc: "length changing letter kept", input: "/İstanbul", expect: "/İstanbul"},
+		{desc: "invalid utf-8 kept", input: "/A\xffB", expect: "/a\xffb"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			folded := foldCase(test.input)
+			assert.Equal(t, test.expect, folded)
+			assert.Len(t, folded, len(test.input))
+		})
+	}
+
+	assert.Equal(t, "/users/{userID:[A-Z]+}/files", foldPattern("/Users/{userID:[A-Z]+}/Files"))
+}
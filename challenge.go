@@ -0,0 +1,53 @@
+package glib
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/azizndao/glib/httputil"
+)
+
+// SetChallenge registers the WWW-Authenticate challenge of the request, sent
+// with the 401 Unauthorized error response rendered for it, typically by an
+// authentication middleware rejecting the credentials. Challenges with an
+// "insufficient_scope" error (RFC 6750) are sent with 403 Forbidden responses
+// too. A challenge replaces the one registered with the same scheme, so that
+// several schemes can be offered.
+//
+// Example:
+//
+//	claims, err := verify(token)
+//	if err != nil {
+//	    c.SetChallenge(httputil.BearerChallenge("api", httputil.ErrInvalidToken, "The access token expired"))
+//	    return errors.Unauthorized("Invalid token", err)
+//	}
+func (c *Ctx) SetChallenge(ch httputil.Challenge) *Ctx {
+	rw, ok := c.Response.(*responseWriter)
+	if !ok {
+		httputil.AddChallenge(c.header(), ch)
+		return c
+	}
+
+	for i, existing := range rw.challenges {
+		if strings.EqualFold(existing.Scheme, ch.Scheme) {
+			rw.challenges[i] = ch
+			return c
+		}
+	}
+	rw.challenges = append(rw.challenges, ch)
+	return c
+}
+
+// writeChallenges adds the challenges registered with SetChallenge to an error
+// response with the given status
+func (c *Ctx) writeChallenges(status int) {
+	rw, ok := c.Response.(*responseWriter)
+	if !ok || (status != http.StatusUnauthorized && status != http.StatusForbidden) {
+		return
+	}
+	for _, ch := range rw.challenges {
+		if status == http.StatusUnauthorized || ch.Params["error"] == httputil.ErrInsufficientScope {
+			httputil.AddChallenge(c.header(), ch)
+		}
+	}
+}
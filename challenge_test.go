@@ -0,0 +1,88 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/httputil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCtx_SetChallenge(t *testing.T) {
+	r := setupTestRouter()
+	// An authentication middleware offering Basic and Bearer, the handlers refining the challenges
+	r.Use(func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			c.SetChallenge(httputil.Challenge{Scheme: "Basic", Realm: "api"})
+			c.SetChallenge(httputil.BearerChallenge("api", "", ""))
+			if c.Get("Authorization") == "" {
+				return errors.Unauthorized("Missing credentials", nil)
+			}
+			return next(c)
+		}
+	})
+	r.Get("/expired", func(c *Ctx) error {
+		c.SetChallenge(httputil.BearerChallenge("api", httputil.ErrInvalidToken, "The access token expired"))
+		return errors.Unauthorized("Invalid token", nil)
+	})
+	r.Get("/admin", func(c *Ctx) error {
+		c.SetChallenge(httputil.Challenge{
+			Scheme: "Bearer",
+			Realm:  "api",
+			Params: map[string]string{"error": httputil.ErrInsufficientScope, "scope": "admin"},
+		})
+		return errors.Forbidden("Insufficient scope", nil)
+	})
+	r.Get("/banned", func(c *Ctx) error {
+		return errors.Forbidden("Banned", nil)
+	})
+	r.Get("/missing", func(c *Ctx) error {
+		return errors.NotFound("Not found", nil)
+	})
+
+	tests := []struct {
+		desc       string
+		path       string
+		authorized bool
+		status     int
+		expected   []string
+	}{
+		{
+			desc:     "missing credentials",
+			path:     "/expired",
+			status:   http.StatusUnauthorized,
+			expected: []string{`Basic realm="api"`, `Bearer realm="api"`},
+		},
+		{
+			desc:       "replaced challenge",
+			path:       "/expired",
+			authorized: true,
+			status:     http.StatusUnauthorized,
+			expected:   []string{`Basic realm="api"`, `Bearer realm="api", error="invalid_token", error_description="The access token expired"`},
+		},
+		{
+			desc:       "insufficient scope",
+			path:       "/admin",
+			authorized: true,
+			status:     http.StatusForbidden,
+			expected:   []string{`Bearer realm="api", error="insufficient_scope", scope="admin"`},
+		},
+		{desc: "forbidden", path: "/banned", authorized: true, status: http.StatusForbidden},
+		{desc: "other errors", path: "/missing", authorized: true, status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorized {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.expected, w.Header().Values("WWW-Authenticate"))
+		})
+	}
+}
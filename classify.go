@@ -0,0 +1,62 @@
+package glib
+
+import (
+	"net/http"
+	"strings"
+)
+
+// IsWebSocket reports whether the request is a WebSocket handshake: its
+// Connection header contains the "upgrade" token and its Upgrade header the
+// "websocket" protocol, case-insensitively
+func (c *Ctx) IsWebSocket() bool {
+	return headerHasToken(c.Request.Header, "Connection", "upgrade") &&
+		headerHasToken(c.Request.Header, "Upgrade", "websocket")
+}
+
+// IsAJAX reports whether the request was sent by XMLHttpRequest, as indicated
+// by the X-Requested-With header set by most JavaScript libraries
+func (c *Ctx) IsAJAX() bool {
+	return strings.EqualFold(c.Get("X-Requested-With"), "XMLHttpRequest")
+}
+
+// IsJSON reports whether the request body is JSON according to its Content-Type,
+// see RouterConfig.JSONMediaTypes
+func (c *Ctx) IsJSON() bool {
+	contentType := c.ContentType()
+	return contentType != "" && c.isJSONMediaType(contentType)
+}
+
+// WantsJSON reports whether the client should get JSON rather than HTML: true
+// unless the Accept header, with its quality values, prefers text/html to
+// application/json. Adds Accept to the Vary response header.
+func (c *Ctx) WantsJSON() bool {
+	if c.Get("Accept") == "" {
+		c.Vary("Accept")
+		return true
+	}
+	return c.Negotiate(MIMEApplicationJSON, "text/html") != "text/html"
+}
+
+// IsIdempotent reports whether the request method is idempotent (RFC 9110
+// section 9.2.2): GET, HEAD, OPTIONS, TRACE, PUT and DELETE
+func (c *Ctx) IsIdempotent() bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// headerHasToken reports whether the comma-separated values of the header
+// contain the token, case-insensitively
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for part := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
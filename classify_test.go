@@ -0,0 +1,110 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCtx_RequestClassification(t *testing.T) {
+	tests := []struct {
+		desc    string
+		method  string
+		headers map[string][]string
+		check   func(c *Ctx) bool
+		expect  bool
+	}{
+		{desc: "websocket", headers: map[string][]string{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}}, check: (*Ctx).IsWebSocket, expect: true},
+		{desc: "websocket with connection tokens", headers: map[string][]string{"Connection": {"keep-alive, UPGRADE"}, "Upgrade": {"WebSocket"}}, check: (*Ctx).IsWebSocket, expect: true},
+		{desc: "websocket with repeated headers", headers: map[string][]string{"Connection": {"keep-alive", "upgrade"}, "Upgrade": {"h2c", "websocket"}}, check: (*Ctx).IsWebSocket, expect: true},
+		{desc: "websocket without upgrade token", headers: map[string][]string{"Connection": {"keep-alive"}, "Upgrade": {"websocket"}}, check: (*Ctx).IsWebSocket, expect: false},
+		{desc: "websocket partial token", headers: map[string][]string{"Connection": {"upgrades"}, "Upgrade": {"websocket"}}, check: (*Ctx).IsWebSocket, expect: false},
+		{desc: "other upgrade", headers: map[string][]string{"Connection": {"Upgrade"}, "Upgrade": {"h2c"}}, check: (*Ctx).IsWebSocket, expect: false},
+		{desc: "ajax", headers: map[string][]string{"X-Requested-With": {"xmlhttprequest"}}, check: (*Ctx).IsAJAX, expect: true},
+		{desc: "not ajax", check: (*Ctx).IsAJAX, expect: false},
+		{desc: "json body", headers: map[string][]string{"Content-Type": {"application/json; charset=utf-8"}}, check: (*Ctx).IsJSON, expect: true},
+		{desc: "json suffix body", headers: map[string][]string{"Content-Type": {"application/vnd.api+json"}}, check: (*Ctx).IsJSON, expect: true},
+		{desc: "form body", headers: map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}}, check: (*Ctx).IsJSON, expect: false},
+		{desc: "no content type", check: (*Ctx).IsJSON, expect: false},
+		{desc: "wants json without accept", check: (*Ctx).WantsJSON, expect: true},
+		{desc: "wants json", headers: map[string][]string{"Accept": {"application/json"}}, check: (*Ctx).WantsJSON, expect: true},
+		{desc: "wants json with wildcard", headers: map[string][]string{"Accept": {"*/*"}}, check: (*Ctx).WantsJSON, expect: true},
+		{desc: "browser", headers: map[string][]string{"Accept": {"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"}}, check: (*Ctx).WantsJSON, expect: false},
+		{desc: "json preferred by quality", headers: map[string][]string{"Accept": {"text/html;q=0.5, application/json"}}, check: (*Ctx).WantsJSON, expect: true},
+		{desc: "html preferred by quality", headers: map[string][]string{"Accept": {"text/html, application/json;q=0.5"}}, check: (*Ctx).WantsJSON, expect: false},
+		{desc: "get is idempotent", method: http.MethodGet, check: (*Ctx).IsIdempotent, expect: true},
+		{desc: "put is idempotent", method: http.MethodPut, check: (*Ctx).IsIdempotent, expect: true},
+		{desc: "delete is idempotent", method: http.MethodDelete, check: (*Ctx).IsIdempotent, expect: true},
+		{desc: "post is not idempotent", method: http.MethodPost, check: (*Ctx).IsIdempotent, expect: false},
+		{desc: "patch is not idempotent", method: http.MethodPatch, check: (*Ctx).IsIdempotent, expect: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			for name, values := range test.headers {
+				req.Header[name] = values
+			}
+			c := newCtx(httptest.NewRecorder(), req, nil, nil)
+
+			assert.Equal(t, test.expect, test.check(c))
+		})
+	}
+}
+
+func TestRouter_ErrorPage(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/users/{id}", func(c *Ctx) error {
+		return errors.NotFound("User <"+c.PathValue("id")+"> not found", nil)
+	})
+
+	t.Run("html for browsers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/5", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<h1>404 Not Found</h1>")
+		assert.Contains(t, w.Body.String(), "<p>User &lt;5&gt; not found</p>")
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	})
+
+	t.Run("json for API clients", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/5", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"code":404,"data":"User <5> not found"}`, w.Body.String())
+	})
+
+	t.Run("json only", func(t *testing.T) {
+		config := DefaultRouterOptions()
+		config.JSONErrors = true
+		r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+		r.Get("/users/{id}", func(c *Ctx) error {
+			return errors.NotFound("User not found", nil)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/users/5", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"code":404,"data":"User not found"}`, w.Body.String())
+		assert.Empty(t, w.Header().Get("Vary"))
+	})
+}
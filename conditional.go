@@ -0,0 +1,153 @@
+package glib
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// Unless applies the middleware to the requests for which skip returns false.
+// Errors keep the name of the wrapped middleware as origin.
+//
+// Example:
+//
+//	r.Use(glib.Unless(RequireAuth, func(c *glib.Ctx) bool {
+//	    return c.Request.URL.Path == "/health"
+//	}))
+func Unless(mw Middleware, skip func(c *Ctx) bool) Middleware {
+	name := middlewareName(mw)
+	return func(next HandleFunc) HandleFunc {
+		h := mw(next)
+		return func(c *Ctx) error {
+			if skip(c) {
+				return next(c)
+			}
+			return errors.FromMiddleware(name, h(c))
+		}
+	}
+}
+
+// OnlyMethods applies the middleware to the requests having one of the methods
+//
+// Example:
+//
+//	r.Use(glib.OnlyMethods(CSRF, "POST", "PUT", "PATCH", "DELETE"))
+func OnlyMethods(mw Middleware, methods ...string) Middleware {
+	allowed := make(map[string]struct{}, len(methods))
+	for _, method := range methods {
+		allowed[strings.ToUpper(method)] = struct{}{}
+	}
+	return Unless(mw, func(c *Ctx) bool {
+		_, ok := allowed[c.Request.Method]
+		return !ok
+	})
+}
+
+// OnlyPaths applies the middleware to the requests whose path matches one of
+// the patterns. Patterns follow the chi syntax: "{name}" matches a path
+// segment, "{name:regexp}" a segment matching the regular expression, and a
+// trailing "*" the rest of the path. Panics if a regular expression is invalid.
+//
+// Example:
+//
+//	r.Use(glib.OnlyPaths(RequireAuth, "/api/*", "/users/{id}/settings"))
+func OnlyPaths(mw Middleware, patterns ...string) Middleware {
+	matchers := make([]pathMatcher, len(patterns))
+	for i, pattern := range patterns {
+		matchers[i] = newPathMatcher(pattern)
+	}
+	return Unless(mw, func(c *Ctx) bool {
+		path := c.Request.URL.Path
+		for _, m := range matchers {
+			if m.match(path) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Chain composes middlewares into one, applied in the order given like with
+// Router.Use. Errors keep the name of the middleware returning them as origin.
+//
+// Example:
+//
+//	secured := glib.Chain(RequireAuth, RequireRole("admin"), AuditLog)
+//	r.With(secured).Delete("/users/{id}", deleteUser)
+func Chain(mws ...Middleware) Middleware {
+	names := make([]string, len(mws))
+	for i, mw := range mws {
+		names[i] = middlewareName(mw)
+	}
+	return func(next HandleFunc) HandleFunc {
+		h := next
+		for i := len(mws) - 1; i >= 0; i-- {
+			wrapped, name := mws[i](h), names[i]
+			h = func(c *Ctx) error {
+				return errors.FromMiddleware(name, wrapped(c))
+			}
+		}
+		return h
+	}
+}
+
+// pathSegment is a segment of a path pattern: a static value, a parameter
+// (optionally constrained by a regular expression) or the trailing wildcard
+type pathSegment struct {
+	static   string
+	param    bool
+	re       *regexp.Regexp
+	wildcard bool
+}
+
+// pathMatcher matches request paths against a chi route pattern
+type pathMatcher []pathSegment
+
+func newPathMatcher(pattern string) pathMatcher {
+	var m pathMatcher
+	for segment := range strings.SplitSeq(strings.TrimPrefix(pattern, "/"), "/") {
+		switch {
+		case segment == "*":
+			m = append(m, pathSegment{wildcard: true})
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			ps := pathSegment{param: true}
+			if _, expr, ok := strings.Cut(segment[1:len(segment)-1], ":"); ok {
+				ps.re = regexp.MustCompile("^(?:" + expr + ")$")
+			}
+			m = append(m, ps)
+		default:
+			m = append(m, pathSegment{static: segment})
+		}
+	}
+	return m
+}
+
+// match reports whether the path matches the pattern, without allocating
+func (m pathMatcher) match(path string) bool {
+	path = strings.TrimPrefix(path, "/")
+	for i, ps := range m {
+		if ps.wildcard {
+			return true
+		}
+
+		segment, rest, found := strings.Cut(path, "/")
+		switch {
+		case ps.param:
+			if segment == "" || (ps.re != nil && !ps.re.MatchString(segment)) {
+				return false
+			}
+		case segment != ps.static:
+			return false
+		}
+
+		if !found {
+			return i == len(m)-1
+		}
+		path = rest
+		if i == len(m)-1 {
+			return false
+		}
+	}
+	return false
+}
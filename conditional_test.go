@@ -0,0 +1,162 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordMiddleware records its name before and after calling the next handler
+func recordMiddleware(name string, calls *[]string) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			*calls = append(*calls, name)
+			err := next(c)
+			*calls = append(*calls, "/"+name)
+			return err
+		}
+	}
+}
+
+func TestConditionalMiddlewares(t *testing.T) {
+	tests := []struct {
+		desc        string
+		middleware  func(calls *[]string) Middleware
+		method      string
+		path        string
+		expectCalls []string
+	}{
+		{
+			desc: "chain order",
+			middleware: func(calls *[]string) Middleware {
+				return Chain(recordMiddleware("logger", calls), recordMiddleware("auth", calls))
+			},
+			method:      http.MethodGet,
+			path:        "/api/users",
+			expectCalls: []string{"logger", "auth", "handler", "/auth", "/logger"},
+		},
+		{
+			desc: "unless skipped",
+			middleware: func(calls *[]string) Middleware {
+				return Unless(recordMiddleware("auth", calls), func(c *Ctx) bool { return c.Path() == "/health" })
+			},
+			method:      http.MethodGet,
+			path:        "/health",
+			expectCalls: []string{"handler"},
+		},
+		{
+			desc: "unless applied",
+			middleware: func(calls *[]string) Middleware {
+				return Unless(recordMiddleware("auth", calls), func(c *Ctx) bool { return c.Path() == "/health" })
+			},
+			method:      http.MethodGet,
+			path:        "/api/users",
+			expectCalls: []string{"auth", "handler", "/auth"},
+		},
+		{
+			desc: "only methods skipped",
+			middleware: func(calls *[]string) Middleware {
+				return OnlyMethods(recordMiddleware("csrf", calls), "post", "PUT")
+			},
+			method:      http.MethodGet,
+			path:        "/api/users",
+			expectCalls: []string{"handler"},
+		},
+		{
+			desc: "only methods applied",
+			middleware: func(calls *[]string) Middleware {
+				return OnlyMethods(recordMiddleware("csrf", calls), "post", "PUT")
+			},
+			method:      http.MethodPost,
+			path:        "/api/users",
+			expectCalls: []string{"csrf", "handler", "/csrf"},
+		},
+		{
+			desc: "only paths within chain",
+			middleware: func(calls *[]string) Middleware {
+				return Chain(
+					recordMiddleware("logger", calls),
+					OnlyPaths(recordMiddleware("auth", calls), "/api/*"),
+				)
+			},
+			method:      http.MethodGet,
+			path:        "/health",
+			expectCalls: []string{"logger", "handler", "/logger"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var calls []string
+			r := setupTestRouter()
+			r.Use(test.middleware(&calls))
+			r.HandleFunc("/*", func(c *Ctx) error {
+				calls = append(calls, "handler")
+				return c.NoContent()
+			})
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(test.method, test.path, nil))
+			assert.Equal(t, test.expectCalls, calls)
+		})
+	}
+}
+
+func TestChain_ErrorOrigin(t *testing.T) {
+	var origin *errors.OriginError
+	config := DefaultRouterOptions()
+	config.ErrorHandler = func(c *Ctx, err error) {
+		origin = errors.OriginOf(err)
+	}
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	var calls []string
+	r.Use(Chain(recordMiddleware("logger", &calls), OnlyPaths(requireToken, "/api/*")))
+	r.Get("/api/users", func(c *Ctx) error { return c.NoContent() })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	require.NotNil(t, origin)
+	assert.Equal(t, "middleware glib.requireToken", origin.Origin())
+}
+
+func TestPathMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		expect  bool
+	}{
+		{pattern: "/", path: "/", expect: true},
+		{pattern: "/", path: "/users", expect: false},
+		{pattern: "/users", path: "/users", expect: true},
+		{pattern: "/users", path: "/users/", expect: false},
+		{pattern: "/users/", path: "/users/", expect: true},
+		{pattern: "/users", path: "/users/1", expect: false},
+		{pattern: "/users/{id}", path: "/users/1", expect: true},
+		{pattern: "/users/{id}", path: "/users/", expect: false},
+		{pattern: "/users/{id}/posts", path: "/users/1/posts", expect: true},
+		{pattern: "/users/{id:[0-9]+}", path: "/users/42", expect: true},
+		{pattern: "/users/{id:[0-9]+}", path: "/users/abc", expect: false},
+		{pattern: "/api/*", path: "/api/", expect: true},
+		{pattern: "/api/*", path: "/api/v1/users", expect: true},
+		{pattern: "/api/*", path: "/api", expect: false},
+		{pattern: "/api/*", path: "/apis/v1", expect: false},
+		{pattern: "/*", path: "/anything/else", expect: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.path, func(t *testing.T) {
+			assert.Equal(t, test.expect, newPathMatcher(test.pattern).match(test.path))
+		})
+	}
+
+	m := newPathMatcher("/users/{id}/files/*")
+	allocs := testing.AllocsPerRun(100, func() { m.match("/users/1/files/avatar.png") })
+	assert.Zero(t, allocs)
+}
@@ -0,0 +1,163 @@
+package glib
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/azizndao/glib/util"
+)
+
+// Cookie name prefixes restricting the attributes of the cookies, see RFC 6265bis
+// section 4.1.3
+const (
+	CookiePrefixSecure = "__Secure-"
+	CookiePrefixHost   = "__Host-"
+)
+
+// ErrInvalidCookie is returned when a cookie breaks the requirements of its name
+// prefix or of its SameSite mode, browsers rejecting such cookies
+var ErrInvalidCookie = errors.New("glib: invalid cookie")
+
+// CookiePolicy holds the default attributes of the cookies set by
+// Ctx.SetCookie, Ctx.SetCookieValue and Ctx.ClearCookie, the attributes set by
+// the caller taking precedence
+type CookiePolicy struct {
+	// Secure sets the Secure attribute on all the cookies, which are sent over
+	// HTTPS only. Cookies are also Secure when the request is served over HTTPS.
+	Secure bool
+
+	// SameSite is the SameSite attribute of the cookies without one.
+	// Default: http.SameSiteLaxMode
+	SameSite http.SameSite
+
+	// Domain is the Domain attribute of the cookies without one, e.g.
+	// "example.com" to share them with the subdomains. Never applied to the
+	// __Host- cookies.
+	Domain string
+
+	// Path is the Path attribute of the cookies without one. Default: "/"
+	Path string
+
+	// NamePrefix is added to the names of the cookies set, cleared and read by
+	// Ctx, typically CookiePrefixHost to bind them to the host over HTTPS. The
+	// names with a cookie prefix are kept as is.
+	NamePrefix string
+}
+
+// LoadCookiePolicy loads CookiePolicy from environment variables
+// Environment variables:
+//   - COOKIE_SECURE (bool): set the Secure attribute on all the cookies (default: true, false when IS_DEBUG=true)
+//   - COOKIE_SAMESITE (string): lax, strict or none (default: lax)
+//   - COOKIE_DOMAIN (string): Domain attribute of the cookies (default: none, the host of the request)
+//   - COOKIE_PATH (string): Path attribute of the cookies (default: /)
+//   - COOKIE_PREFIX (string): __Host- or __Secure-, added to the cookie names (default: none)
+func LoadCookiePolicy() CookiePolicy {
+	env := struct {
+		Secure     bool   `env:"COOKIE_SECURE"`
+		SameSite   string `env:"COOKIE_SAMESITE" default:"lax" oneof:"lax,strict,none"`
+		Domain     string `env:"COOKIE_DOMAIN"`
+		Path       string `env:"COOKIE_PATH" default:"/"`
+		NamePrefix string `env:"COOKIE_PREFIX" oneof:"__Host-,__Secure-"`
+	}{Secure: !util.GetEnvBool("IS_DEBUG", false)}
+	loadEnv(&env)
+
+	sameSite := map[string]http.SameSite{
+		"lax":    http.SameSiteLaxMode,
+		"strict": http.SameSiteStrictMode,
+		"none":   http.SameSiteNoneMode,
+	}
+	return CookiePolicy{
+		Secure:     env.Secure,
+		SameSite:   sameSite[env.SameSite],
+		Domain:     env.Domain,
+		Path:       env.Path,
+		NamePrefix: env.NamePrefix,
+	}
+}
+
+// name returns the name of a cookie with the name prefix, unless it already
+// has a cookie prefix
+func (p CookiePolicy) name(name string) string {
+	if strings.HasPrefix(name, CookiePrefixSecure) || strings.HasPrefix(name, CookiePrefixHost) {
+		return name
+	}
+	return p.NamePrefix + name
+}
+
+// apply returns a copy of the cookie with the policy defaults, and checks the
+// requirements of its name prefix. secure reports whether the request is
+// served over HTTPS.
+func (p CookiePolicy) apply(cookie *http.Cookie, secure bool) (*http.Cookie, error) {
+	applied := *cookie
+	applied.Name = p.name(cookie.Name)
+	host := strings.HasPrefix(applied.Name, CookiePrefixHost)
+
+	applied.Secure = applied.Secure || p.Secure || secure
+	if applied.SameSite == 0 {
+		applied.SameSite = p.SameSite
+		if applied.SameSite == 0 {
+			applied.SameSite = http.SameSiteLaxMode
+		}
+	}
+	if applied.Domain == "" && !host {
+		applied.Domain = p.Domain
+	}
+	if applied.Path == "" {
+		applied.Path = p.Path
+		if applied.Path == "" {
+			applied.Path = "/"
+		}
+	}
+
+	switch {
+	case (host || strings.HasPrefix(applied.Name, CookiePrefixSecure)) && !applied.Secure:
+		return nil, fmt.Errorf("%w: %s cookie must be Secure, set COOKIE_SECURE=true or serve it over HTTPS", ErrInvalidCookie, applied.Name)
+	case host && applied.Domain != "":
+		return nil, fmt.Errorf("%w: %s cookie can't have a Domain, got %q", ErrInvalidCookie, applied.Name, applied.Domain)
+	case host && applied.Path != "/":
+		return nil, fmt.Errorf("%w: %s cookie must have the path /, got %q", ErrInvalidCookie, applied.Name, applied.Path)
+	case applied.SameSite == http.SameSiteNoneMode && !applied.Secure:
+		return nil, fmt.Errorf("%w: %s cookie with SameSite=None must be Secure", ErrInvalidCookie, applied.Name)
+	}
+	return &applied, nil
+}
+
+// cookiePolicy returns the cookie policy of the router
+func (c *Ctx) cookiePolicy() CookiePolicy {
+	if c.config == nil {
+		return CookiePolicy{}
+	}
+	return c.config.Cookies
+}
+
+// setCookie adds the cookie to the response with the defaults of the cookie policy
+func (c *Ctx) setCookie(cookie *http.Cookie) error {
+	applied, err := c.cookiePolicy().apply(cookie, c.IsSecure())
+	if err != nil {
+		return err
+	}
+	if v := applied.String(); v != "" {
+		c.header().Add("Set-Cookie", v)
+	}
+	return nil
+}
+
+// SetCookieValue sets an HttpOnly cookie with the attributes of the cookie
+// policy. A zero maxAge sets a session cookie. Returns ErrInvalidCookie when
+// the cookie breaks the requirements of its name prefix.
+//
+// Example:
+//
+//	if err := c.SetCookieValue("session", token, 24*time.Hour); err != nil {
+//	    return err
+//	}
+func (c *Ctx) SetCookieValue(name, value string, maxAge time.Duration) error {
+	cookie := &http.Cookie{Name: name, Value: value, HttpOnly: true}
+	if maxAge > 0 {
+		cookie.MaxAge = int(maxAge.Seconds())
+	}
+	return c.setCookie(cookie)
+}
@@ -0,0 +1,162 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_SetCookieValue(t *testing.T) {
+	tests := []struct {
+		desc         string
+		policy       CookiePolicy
+		https        bool
+		name         string
+		expectCookie string
+		expectErr    string
+	}{
+		{
+			desc:         "defaults on http",
+			name:         "session",
+			expectCookie: "session=token; Path=/; Max-Age=3600; HttpOnly; SameSite=Lax",
+		},
+		{
+			desc:         "secure over https",
+			https:        true,
+			name:         "session",
+			expectCookie: "session=token; Path=/; Max-Age=3600; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			desc:         "policy attributes",
+			policy:       CookiePolicy{Secure: true, SameSite: http.SameSiteStrictMode, Domain: "example.com", Path: "/app"},
+			name:         "session",
+			expectCookie: "session=token; Path=/app; Domain=example.com; Max-Age=3600; HttpOnly; Secure; SameSite=Strict",
+		},
+		{
+			desc:         "host prefix added",
+			policy:       CookiePolicy{Secure: true, Domain: "example.com", NamePrefix: CookiePrefixHost},
+			name:         "session",
+			expectCookie: "__Host-session=token; Path=/; Max-Age=3600; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			desc:      "host prefix without secure",
+			name:      "__Host-session",
+			expectErr: "glib: invalid cookie: __Host-session cookie must be Secure, set COOKIE_SECURE=true or serve it over HTTPS",
+		},
+		{
+			desc:      "host prefix with path",
+			policy:    CookiePolicy{Secure: true, Path: "/app"},
+			name:      "__Host-session",
+			expectErr: `glib: invalid cookie: __Host-session cookie must have the path /, got "/app"`,
+		},
+		{
+			desc:      "secure prefix without secure",
+			policy:    CookiePolicy{NamePrefix: CookiePrefixSecure},
+			name:      "session",
+			expectErr: "glib: invalid cookie: __Secure-session cookie must be Secure, set COOKIE_SECURE=true or serve it over HTTPS",
+		},
+		{
+			desc:      "same site none without secure",
+			policy:    CookiePolicy{SameSite: http.SameSiteNoneMode},
+			name:      "session",
+			expectErr: "glib: invalid cookie: session cookie with SameSite=None must be Secure",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), RouterConfig{Cookies: tt.policy})
+			var err error
+			r.Get("/login", func(c *Ctx) error {
+				err = c.SetCookieValue(tt.name, "token", time.Hour)
+				return c.NoContent()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/login", nil)
+			if tt.https {
+				req.Header.Set("X-Forwarded-Proto", "https")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if tt.expectErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidCookie)
+				assert.EqualError(t, err, tt.expectErr)
+				assert.Empty(t, w.Header().Values("Set-Cookie"))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.expectCookie}, w.Header().Values("Set-Cookie"))
+		})
+	}
+}
+
+func TestCtx_CookiePolicy(t *testing.T) {
+	policy := CookiePolicy{Secure: true, Domain: "example.com", NamePrefix: CookiePrefixSecure}
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), RouterConfig{Cookies: policy})
+	r.Get("/cookies", func(c *Ctx) error {
+		c.SetCookie(&http.Cookie{Name: "theme", Value: c.GetCookieDefault("theme", "light"), Domain: "app.example.com"})
+		c.SetCookie(&http.Cookie{Name: "__Host-invalid", Value: "1", Secure: true, Domain: "example.com"})
+		c.ClearCookie("session")
+		return c.NoContent()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/cookies", nil)
+	req.AddCookie(&http.Cookie{Name: "__Secure-theme", Value: "dark"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, []string{
+		"__Secure-theme=dark; Path=/; Domain=app.example.com; Secure; SameSite=Lax",
+		"__Secure-session=; Path=/; Domain=example.com; Max-Age=0; HttpOnly; Secure; SameSite=Lax",
+	}, w.Header().Values("Set-Cookie"), "the caller attributes take precedence, invalid cookies are dropped")
+}
+
+func TestLoadCookiePolicy(t *testing.T) {
+	tests := []struct {
+		desc   string
+		env    map[string]string
+		expect CookiePolicy
+	}{
+		{
+			desc:   "production defaults",
+			expect: CookiePolicy{Secure: true, SameSite: http.SameSiteLaxMode, Path: "/"},
+		},
+		{
+			desc:   "debug",
+			env:    map[string]string{"IS_DEBUG": "true"},
+			expect: CookiePolicy{SameSite: http.SameSiteLaxMode, Path: "/"},
+		},
+		{
+			desc: "configured",
+			env: map[string]string{
+				"IS_DEBUG":        "true",
+				"COOKIE_SECURE":   "true",
+				"COOKIE_SAMESITE": "Strict",
+				"COOKIE_DOMAIN":   "example.com",
+				"COOKIE_PREFIX":   "__secure-",
+			},
+			expect: CookiePolicy{Secure: true, SameSite: http.SameSiteStrictMode, Domain: "example.com", Path: "/", NamePrefix: CookiePrefixSecure},
+		},
+		{
+			desc:   "invalid same site",
+			env:    map[string]string{"COOKIE_SAMESITE": "sometimes"},
+			expect: CookiePolicy{Secure: true, SameSite: http.SameSiteLaxMode, Path: "/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for _, key := range []string{"IS_DEBUG", "COOKIE_SECURE", "COOKIE_SAMESITE", "COOKIE_DOMAIN", "COOKIE_PATH", "COOKIE_PREFIX"} {
+				t.Setenv(key, tt.env[key])
+			}
+			assert.Equal(t, tt.expect, LoadCookiePolicy())
+		})
+	}
+}
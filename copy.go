@@ -0,0 +1,78 @@
+package glib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ErrDetached is returned by the response methods of a Ctx copy (see Ctx.Copy)
+var ErrDetached = errors.New("glib: cannot write the response of a detached Ctx copy")
+
+// Copy returns a detached copy of the Ctx that can be used after the handler
+// returns, e.g. in a goroutine doing background work. The original Ctx must not
+// be used once the handler returns since its Request and ResponseWriter are
+// recycled by the server and chi.
+//
+// The copy is read-only and holds a snapshot of the request:
+//   - cloned headers, URL, form values and path parameters
+//   - the context values (request ID, user...), without the cancellation of the
+//     request context which ends with the request
+//   - the request body, only if it was read before copying (Ctx.Body, ParseBody...)
+//
+// The copy has no Response: the methods sending a response return ErrDetached
+// and the methods setting response headers panic with ErrDetached.
+//
+// Example:
+//
+//	detached := c.Copy()
+//	go func() {
+//	    audit.Log(detached.Context(), detached.GetRequestID(), detached.PathValue("id"))
+//	}()
+func (c *Ctx) Copy() *Ctx {
+	ctx := context.WithoutCancel(c.Context())
+
+	// chi recycles its route context at the end of the request, snapshot the path parameters
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		snapshot := chi.NewRouteContext()
+		snapshot.RoutePath = rctx.RoutePath
+		snapshot.RouteMethod = rctx.RouteMethod
+		snapshot.RoutePatterns = slices.Clone(rctx.RoutePatterns)
+		snapshot.URLParams.Keys = slices.Clone(rctx.URLParams.Keys)
+		snapshot.URLParams.Values = slices.Clone(rctx.URLParams.Values)
+		ctx = context.WithValue(ctx, chi.RouteCtxKey, snapshot)
+	}
+
+	req := c.Request.Clone(ctx)
+	body := bytes.Clone(c.body)
+	if len(body) > 0 {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	} else {
+		req.Body = http.NoBody
+	}
+
+	return &Ctx{
+		Request:    req,
+		statusCode: c.statusCode,
+		body:       body,
+		bodyRead:   true,
+		logger:     c.logger,
+		validator:  c.validator,
+		config:     c.config,
+		services:   c.services,
+		scoped:     c.scoped,
+	}
+}
+
+// header returns the response headers, panicking with ErrDetached on a Ctx copy
+func (c *Ctx) header() http.Header {
+	if c.Response == nil {
+		panic(ErrDetached)
+	}
+	return c.Response.Header()
+}
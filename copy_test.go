@@ -0,0 +1,84 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/middleware"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_Copy(t *testing.T) {
+	r := setupTestRouter()
+	r.UseHTTP(chimiddleware.RequestID)
+
+	copies := make(chan *Ctx, 1)
+	r.Post("/users/{id}", func(c *Ctx) error {
+		if _, err := c.Body(); err != nil {
+			return err
+		}
+		c.SetUser("john")
+		copies <- c.Copy()
+		return c.SendString("ok")
+	})
+	r.Post("/posts/{slug}", func(c *Ctx) error {
+		return c.SendString(c.PathValue("slug"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/42?expand=true", strings.NewReader(`{"name":"john"}`))
+	req.Header.Set("X-Custom", "value")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	detached := <-copies
+
+	// Another request recycles the route context and request of the first one
+	other := httptest.NewRecorder()
+	r.ServeHTTP(other, httptest.NewRequest(http.MethodPost, "/posts/hello", nil))
+	req.Header.Set("X-Custom", "changed")
+
+	t.Run("request snapshot", func(t *testing.T) {
+		assert.Equal(t, "42", detached.PathValue("id"))
+		assert.Equal(t, "true", detached.Query("expand"))
+		assert.Equal(t, "value", detached.Get("X-Custom"))
+		assert.NotEmpty(t, chimiddleware.GetReqID(detached.Context()))
+		assert.Equal(t, "john", middleware.UserFromContext(detached.Context()))
+		assert.NoError(t, detached.Context().Err(), "the copy outlives the request context")
+
+		body, err := detached.Body()
+		require.NoError(t, err)
+		assert.Equal(t, `{"name":"john"}`, string(body))
+
+		var payload map[string]string
+		require.NoError(t, detached.ParseBody(&payload))
+		assert.Equal(t, "john", payload["name"])
+	})
+
+	t.Run("writes fail", func(t *testing.T) {
+		writes := map[string]func() error{
+			"JSON":       func() error { return detached.JSON(map[string]string{"a": "b"}) },
+			"SendString": func() error { return detached.SendString("text") },
+			"HTML":       func() error { return detached.HTML([]byte("<p>")) },
+			"NoContent":  detached.NoContent,
+			"Created":    func() error { return detached.Created(nil) },
+			"Redirect":   func() error { return detached.Redirect(http.StatusFound, "/") },
+			"SSE":        func() error { return detached.SSE("event", "data") },
+			"JSONFields": func() error { return detached.JSONFields(map[string]string{"a": "b"}, []string{"a"}) },
+			"JSONPage":   func() error { return JSONPage(detached, []int{1}, 1, Page{Page: 1, Limit: 10}) },
+		}
+		for name, write := range writes {
+			assert.ErrorIs(t, write(), ErrDetached, name)
+		}
+
+		assert.PanicsWithValue(t, ErrDetached, func() { detached.Set("X-Custom", "value") })
+		assert.PanicsWithValue(t, ErrDetached, func() { detached.SetCookie(&http.Cookie{Name: "session", Value: "1"}) })
+		assert.PanicsWithValue(t, ErrDetached, func() { detached.Vary("Accept") })
+	})
+
+	t.Run("other requests are not affected", func(t *testing.T) {
+		assert.Equal(t, "hello", other.Body.String())
+		assert.Empty(t, other.Header().Values("Set-Cookie"))
+	})
+}
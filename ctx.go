@@ -15,6 +15,9 @@ import (
 	"time"
 
 	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/httputil"
+	"github.com/azizndao/glib/jobs"
+	"github.com/azizndao/glib/middleware"
 	"github.com/azizndao/glib/slog"
 	"github.com/azizndao/glib/validation"
 	"github.com/go-chi/chi/v5"
@@ -27,8 +30,17 @@ type Ctx struct {
 	statusCode int
 	body       []byte                // Cached request body
 	bodyRead   bool                  // Track if body has been read
+	query      url.Values            // Cached query parameters, parsed from queryRaw
+	queryRaw   string                // Raw query the cached parameters were parsed from
+	queryRead  bool                  // Track if the query has been parsed
 	logger     *slog.Logger          // Logger instance for logging within routes and middleware
 	validator  *validation.Validator // Validator instance for request validation
+	config     *RouterConfig         // Configuration of the router handling the request
+	services   *services             // Values provided to the router, see Provide
+	scoped     map[any]any           // Values provided for the request, see ProvideScoped
+	temp       *tempFiles            // Temporary files removed after the response, see TempFile
+	sse        *sseStream            // SSE stream tracked by the server, see SSE
+	traceIndex int                   // Index+1 of the trace entry of the running middleware, see Named
 }
 
 // newCtx creates a new Context from request and response
@@ -39,9 +51,50 @@ func newCtx(w http.ResponseWriter, r *http.Request, logger *slog.Logger, validat
 		statusCode: http.StatusOK, // Default to 200
 		logger:     logger,
 		validator:  validator,
+		config:     &RouterConfig{},
 	}
 }
 
+// ctxBinding holds the fields of a Ctx bound to the router and the request of
+// the middleware or handler running, the Ctx being shared by all of them
+type ctxBinding struct {
+	request   *http.Request
+	response  http.ResponseWriter
+	logger    *slog.Logger
+	validator *validation.Validator
+	config    *RouterConfig
+	services  *services
+	scoped    map[any]any
+}
+
+// binding returns the current binding of the Ctx
+func (c *Ctx) binding() ctxBinding {
+	return ctxBinding{
+		request:   c.Request,
+		response:  c.Response,
+		logger:    c.logger,
+		validator: c.validator,
+		config:    c.config,
+		services:  c.services,
+		scoped:    c.scoped,
+	}
+}
+
+// bind binds the Ctx to a router and a request. The cached body is dropped when
+// the request body was replaced, e.g. by a decompressing middleware.
+func (c *Ctx) bind(b ctxBinding) {
+	if c.bodyRead && b.request.Body != c.Request.Body {
+		c.body, c.bodyRead = nil, false
+	}
+	c.Request = b.request
+	c.Response = b.response
+	c.logger = b.logger
+	c.validator = b.validator
+	c.config = b.config
+	c.services = b.services
+	c.scoped = b.scoped
+}
+
 func (c *Ctx) Context() context.Context {
 	return c.Request.Context()
 }
@@ -66,8 +119,12 @@ func (c *Ctx) Value(key any) any {
 	return c.Request.Context().Value(key)
 }
 
-// Logger returns the logger instance for logging within routes and middleware
+// Logger returns the request logger, including the request ID and the fields
+// added with slog.With, for logging within routes and middleware
 func (c *Ctx) Logger() *slog.Logger {
+	if logger, ok := slog.ContextLogger(c.Context()); ok {
+		return logger
+	}
 	return c.logger
 }
 
@@ -82,12 +139,27 @@ func (c *Ctx) GetValue(key any) any {
 }
 
 // ParseBody parses the request body into the given struct
-// Validates that Content-Type is application/json before parsing
+// The decoder is selected by Content-Type: JSON media types (see RouterConfig.JSONMediaTypes)
+// and requests without Content-Type are decoded as JSON, other media types use the
+// decoders registered with RouterConfig.RegisterDecoder. Returns a 415 Unsupported
+// Media Type error when no decoder matches.
+//
+// Malformed JSON is reported with a 400 Bad Request error whose data is a
+// JSONError locating the problem in the body.
 func (c *Ctx) ParseBody(out any) error {
-	// Validate Content-Type
+	defer c.timePhase("parse", "Request body parsing", time.Now())
+
+	// Select the decoder from Content-Type
+	decode := func(data []byte, out any) error { return unmarshalJSON(c.config.JSON, data, out) }
+	invalidMessage := "Invalid JSON"
 	contentType := c.ContentType()
-	if contentType != "" && !strings.HasPrefix(strings.ToLower(contentType), "application/json") {
-		return errors.BadRequest("Invalid Content-Type", fmt.Errorf("expected application/json, got %s", contentType))
+	if contentType != "" && !c.isJSONMediaType(contentType) {
+		fn, ok := c.decoderFor(contentType)
+		if !ok {
+			return errors.UnsupportedMediaType("Unsupported Content-Type", fmt.Errorf("no decoder registered for %s", contentType))
+		}
+		decode = fn
+		invalidMessage = "Invalid request body"
 	}
 
 	body, err := c.Body()
@@ -99,8 +171,11 @@ func (c *Ctx) ParseBody(out any) error {
 		return errors.BadRequest("Empty request body", nil)
 	}
 
-	if err := json.Unmarshal(body, out); err != nil {
-		return errors.BadRequest("Invalid JSON", err)
+	if err := decode(body, out); err != nil {
+		if jsonErr := newJSONError(body, err); jsonErr != nil {
+			return errors.BadRequest(jsonErr, err)
+		}
+		return errors.BadRequest(invalidMessage, err)
 	}
 
 	return nil
@@ -108,13 +183,50 @@ func (c *Ctx) ParseBody(out any) error {
 
 // ValidateBody parses and validates the request body in one call
 func (c *Ctx) ValidateBody(out any) error {
+	return c.ValidateBodyCtx(out, nil)
+}
+
+// ValidateBodyCtx is like ValidateBody with values of the request passed to the
+// validation rules, see ValidateCtx
+//
+// Example:
+//
+//	type DeleteProject struct {
+//	    Reason string `json:"reason" validate:"required_if_ctx=force true"`
+//	}
+//
+//	var req DeleteProject
+//	if err := c.ValidateBodyCtx(&req, map[string]any{"force": c.QueryBool("force")}); err != nil {
+//	    return err
+//	}
+func (c *Ctx) ValidateBodyCtx(out any, values validation.Values) error {
 	if err := c.ParseBody(out); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			if _, isJSON := apiErr.Data.(*JSONError); isJSON || apiErr.Code == http.StatusUnsupportedMediaType {
+				return err
+			}
+		}
 		return errors.BadRequest("Invalid request body", err)
 	}
 
-	// Get locale from Accept-Language header
-	locale := c.getLocaleFromHeader()
-	return c.validator.Validate(out, locale)
+	return c.ValidateCtx(out, values)
+}
+
+// Validate validates v with the validator of the router, e.g. a resource
+// modified by Ctx.ApplyMergePatch, reporting the errors in the locale of the
+// Accept-Language header
+func (c *Ctx) Validate(v any) error {
+	return c.ValidateCtx(v, nil)
+}
+
+// ValidateCtx is like Validate with values of the request passed to the
+// validation rules, such as query flags: the conditional rules required_if_ctx
+// and required_unless_ctx make a field required depending on them, and the
+// rules registered with validation.Validator.RegisterRule get them with
+// validation.ValuesFrom. Use QueryValues to pass query parameters as is.
+func (c *Ctx) ValidateCtx(v any, values validation.Values) error {
+	defer c.timePhase("validate", "Validation", time.Now())
+	return c.validator.ValidateCtx(validation.WithValues(c.Context(), values), v, c.Locale())
 }
 
 // ValidateBody is a generic helper to parse and validate the request body
@@ -152,6 +264,50 @@ func (c *Ctx) getLocaleFromHeader() string {
 	return "en"
 }
 
+// Locale returns the locale negotiated from the Accept-Language header
+// and adds Accept-Language to the Vary response header
+func (c *Ctx) Locale() string {
+	c.Vary("Accept-Language")
+	return c.getLocaleFromHeader()
+}
+
+// T translates the given message key using the request locale and the message catalog.
+// Args are key-value pairs filling `{name}` placeholders, a "count" argument selects the plural form.
+// Returns the key itself if no catalog is configured or the key is unknown.
+func (c *Ctx) T(key string, args ...any) string {
+	if c.config.MessageCatalog == nil {
+		return key
+	}
+	return c.config.MessageCatalog.Translate(c.Locale(), key, args...)
+}
+
+// localize resolves translatable messages (errors.T markers) contained in API error data
+func (c *Ctx) localize(data any) any {
+	catalog := c.config.MessageCatalog
+	if catalog == nil {
+		return data
+	}
+
+	switch d := data.(type) {
+	case errors.Message:
+		return catalog.Message(c.Locale(), d)
+	case []errors.Detail:
+		details := make([]errors.Detail, len(d))
+		for i, detail := range d {
+			details[i] = errors.Detail{Field: detail.Field, Message: c.localize(detail.Message)}
+		}
+		return details
+	case map[string]any:
+		localized := make(map[string]any, len(d))
+		for key, value := range d {
+			localized[key] = c.localize(value)
+		}
+		return localized
+	default:
+		return data
+	}
+}
+
 // Body gets the raw request body as bytes
 // The body is cached after the first read, so this method can be called multiple times
 func (c *Ctx) Body() ([]byte, error) {
@@ -172,12 +328,17 @@ func (c *Ctx) Body() ([]byte, error) {
 
 // FormValue gets a form value by key
 func (c *Ctx) FormValue(key string) string {
-	return c.Request.FormValue(key)
+	value := c.Request.FormValue(key)
+	c.trackMultipartForm()
+	return value
 }
 
-// FormFile gets a file from multipart form
+// FormFile gets a file from multipart form. Parts spilled to disk are removed
+// after the response, see KeepFile.
 func (c *Ctx) FormFile(key string) (multipart.File, *multipart.FileHeader, error) {
-	return c.Request.FormFile(key)
+	file, header, err := c.Request.FormFile(key)
+	c.trackMultipartForm()
+	return file, header, err
 }
 
 // PathValue gets a path parameter by key
@@ -186,9 +347,42 @@ func (c *Ctx) PathValue(key string) string {
 	return chi.URLParam(c.Request, key)
 }
 
+// Wildcard returns the decoded remainder of the path matched by the trailing
+// "*" of a catch-all route, e.g. "docs/readme.md" for "/files/docs/readme.md"
+// with the route "/files/*", relative to the route wherever the router is
+// mounted. Returns an empty string for routes without wildcard.
+//
+// Encoded slashes are decoded too: "/files/a%2Fb" and "/files/a/b" give the
+// same "a/b". PathValue("*") returns the remainder still escaped when the path
+// has encoded characters, to tell them apart.
+func (c *Ctx) Wildcard() string {
+	value := c.PathValue("*")
+	if c.Request.URL.RawPath == "" {
+		// Routed on the decoded path
+		return value
+	}
+	if decoded, err := url.PathUnescape(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// Queries returns the query parameters, parsed once and cached like the body.
+// The returned values are shared by the Query methods and must not be modified:
+// copy them first (e.g. with maps.Clone) to build another query.
+func (c *Ctx) Queries() url.Values {
+	raw := c.Request.URL.RawQuery
+	if !c.queryRead || c.queryRaw != raw {
+		c.query, _ = url.ParseQuery(raw)
+		c.queryRaw = raw
+		c.queryRead = true
+	}
+	return c.query
+}
+
 // Query gets a query parameter by key
 func (c *Ctx) Query(key string) string {
-	return c.Request.URL.Query().Get(key)
+	return c.Queries().Get(key)
 }
 
 // QueryInt gets a query parameter as int
@@ -244,7 +438,7 @@ func (c *Ctx) QueryDefault(key, defaultValue string) string {
 
 // QueryAll gets all values for a query parameter key
 func (c *Ctx) QueryAll(key string) []string {
-	return c.Request.URL.Query()[key]
+	return c.Queries()[key]
 }
 
 // QueryArray is an alias for QueryAll for convenience
@@ -252,6 +446,19 @@ func (c *Ctx) QueryArray(key string) []string {
 	return c.QueryAll(key)
 }
 
+// QueryValues returns the given query parameters as validation values, e.g.
+// for the conditional rules of ValidateCtx: required_if_ctx=force true matches
+// "?force=true". Missing parameters are left out.
+func (c *Ctx) QueryValues(keys ...string) validation.Values {
+	values := make(validation.Values, len(keys))
+	for _, key := range keys {
+		if c.Queries().Has(key) {
+			values[key] = c.Query(key)
+		}
+	}
+	return values
+}
+
 // PathInt gets a path parameter as int
 func (c *Ctx) PathInt(key string) (int, error) {
 	value := c.PathValue(key)
@@ -287,7 +494,7 @@ func (c *Ctx) Get(key string) string {
 // SetHeaders sets multiple headers at once
 func (c *Ctx) SetHeaders(headers map[string]string) *Ctx {
 	for key, value := range headers {
-		c.Response.Header().Set(key, value)
+		c.header().Set(key, value)
 	}
 	return c
 }
@@ -319,6 +526,33 @@ func (c *Ctx) BearerToken() string {
 	return ""
 }
 
+// BasicAuth returns the username and password of the Basic Authorization
+// header, see http.Request.BasicAuth
+func (c *Ctx) BasicAuth() (user, pass string, ok bool) {
+	return c.Request.BasicAuth()
+}
+
+// RequireBasicAuth checks the Basic Authorization credentials with verify and
+// returns a 401 Unauthorized error with a WWW-Authenticate challenge for the
+// realm when they are missing or rejected. Use util.SecureCompare in verify to
+// compare secrets in constant time.
+//
+// Example:
+//
+//	if err := c.RequireBasicAuth("admin", func(user, pass string) bool {
+//	    return util.SecureCompare(user, "admin") && util.SecureCompare(pass, adminPassword)
+//	}); err != nil {
+//	    return err
+//	}
+func (c *Ctx) RequireBasicAuth(realm string, verify func(user, pass string) bool) error {
+	if user, pass, ok := c.BasicAuth(); ok && verify(user, pass) {
+		return nil
+	}
+
+	c.SetChallenge(httputil.Challenge{Scheme: "Basic", Realm: realm, Params: map[string]string{"charset": "UTF-8"}})
+	return errors.Unauthorized("Invalid credentials", nil)
+}
+
 // ContentType gets the Content-Type header
 func (c *Ctx) ContentType() string {
 	return c.Get("Content-Type")
@@ -349,6 +583,13 @@ func (c *Ctx) IP() string {
 	return host
 }
 
+// ClientIPNet returns the parsed client IP, resolved like the IPFilter middleware
+// from the forwarding headers of RouterConfig.TrustedProxies only (see
+// middleware.TrustedProxies.ClientIP), or nil if no valid IP is found
+func (c *Ctx) ClientIPNet() net.IP {
+	return c.config.TrustedProxies.ClientIP(c.Request)
+}
+
 func (c *Ctx) UserAgent() string {
 	return c.Request.UserAgent()
 }
@@ -357,13 +598,16 @@ func (c *Ctx) Method() string {
 	return c.Request.Method
 }
 
+// Path returns the request path as sent by the client, including the base path
+// of the server (see Server.SetBasePath), while Request.URL.Path is the path
+// the router routed
 func (c *Ctx) Path() string {
-	return c.Request.URL.Path
+	return c.BasePath() + c.Request.URL.Path
 }
 
-// BaseURL gets the base URL (scheme + host)
+// BaseURL gets the base URL (scheme + host + base path of the server)
 func (c *Ctx) BaseURL() string {
-	return fmt.Sprintf("%s://%s", c.Scheme(), c.Host())
+	return fmt.Sprintf("%s://%s%s", c.Scheme(), c.Host(), c.BasePath())
 }
 
 // URL gets the full request URL
@@ -391,49 +635,87 @@ func (c *Ctx) Host() string {
 }
 
 func (c *Ctx) Set(key, value string) *Ctx {
-	c.Response.Header().Set(key, value)
+	c.header().Set(key, value)
 	return c
 }
 
+// GetCookie gets a cookie of the request, named with the name prefix of the
+// cookie policy
 func (c *Ctx) GetCookie(name string) (*http.Cookie, error) {
-	return c.Request.Cookie(name)
+	return c.Request.Cookie(c.cookiePolicy().name(name))
 }
 
 // GetCookieDefault gets a cookie value with a default fallback
 func (c *Ctx) GetCookieDefault(name, defaultValue string) string {
-	cookie, err := c.Request.Cookie(name)
+	cookie, err := c.GetCookie(name)
 	if err != nil {
 		return defaultValue
 	}
 	return cookie.Value
 }
 
+// SetCookie adds a cookie to the response, with the defaults of the cookie
+// policy for the attributes it doesn't set (see CookiePolicy). A cookie
+// breaking the requirements of its name prefix is not set, the error being
+// logged; use SetCookieValue to get it.
 func (c *Ctx) SetCookie(cookie *http.Cookie) *Ctx {
-	http.SetCookie(c.Response, cookie)
+	if err := c.setCookie(cookie); err != nil {
+		if logger := c.Logger(); logger != nil {
+			logger.ErrorCtx(c.Context(), err, "cookie", cookie.Name)
+		}
+	}
 	return c
 }
 
-// ClearCookie clears a cookie by setting it to expire
+// ClearCookie clears a cookie by setting it to expire, with the attributes of
+// the cookie policy
 func (c *Ctx) ClearCookie(name string) *Ctx {
 	cookie := &http.Cookie{
 		Name:     name,
 		Value:    "",
 		MaxAge:   -1,
-		Path:     "/",
 		HttpOnly: true,
-		Secure:   c.IsSecure(),
-		SameSite: http.SameSiteLaxMode,
 	}
 	return c.SetCookie(cookie)
 }
 
-// NoContent sends a 204 No Content response
+// NoContent sends a 204 No Content response, without Content-Length as
+// required by RFC 9110 section 8.6
 func (c *Ctx) NoContent() error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+	c.statusCode = http.StatusNoContent
+	c.header().Del("Content-Length")
 	c.Response.WriteHeader(http.StatusNoContent)
 	return nil
 }
 
+// Deleted sends a 204 No Content response, typically after a DELETE
+func (c *Ctx) Deleted() error {
+	return c.NoContent()
+}
+
+// NotModified sends a 304 Not Modified response. The headers describing the
+// representation are removed (RFC 9110 section 15.4.5), those guiding caches
+// (ETag, Cache-Control, Vary, Expires...) are kept.
+func (c *Ctx) NotModified() error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+	header := c.header()
+	for _, key := range []string{"Content-Type", "Content-Length", "Content-Encoding", "Content-Language", "Content-Range", "Transfer-Encoding"} {
+		header.Del(key)
+	}
+	c.statusCode = http.StatusNotModified
+	c.Response.WriteHeader(http.StatusNotModified)
+	return nil
+}
+
 func (c *Ctx) End() error {
+	if c.Response == nil {
+		return ErrDetached
+	}
 	c.Response.WriteHeader(c.statusCode)
 	return nil
 }
@@ -453,6 +735,21 @@ func (c *Ctx) Created(data any) error {
 	return c.End()
 }
 
+// OK sends a 200 OK JSON response
+func (c *Ctx) OK(data any) error {
+	c.statusCode = http.StatusOK
+	return c.JSON(data)
+}
+
+// Updated sends a 200 OK JSON response with the updated resource, or a 204 No
+// Content response when data is nil, typically after a PUT or PATCH
+func (c *Ctx) Updated(data any) error {
+	if data == nil {
+		return c.NoContent()
+	}
+	return c.OK(data)
+}
+
 // Accepted sends a 202 Accepted response with optional data
 func (c *Ctx) Accepted(data any) error {
 	c.statusCode = http.StatusAccepted
@@ -462,63 +759,161 @@ func (c *Ctx) Accepted(data any) error {
 	return c.End()
 }
 
-// JSON sends a JSON response
+// AcceptedJob sends a 202 Accepted response for a job started in the
+// background, with the Location header set to its status URL and the job as
+// the body, see jobs.Manager.
+func (c *Ctx) AcceptedJob(job *jobs.Job) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+	if c.BasePath() != "" {
+		public := *job
+		public.URL = c.withBasePath(job.URL)
+		job = &public
+	}
+	c.Set("Location", job.URL)
+	return c.Accepted(job)
+}
+
+// JSON sends a JSON response. A json.RawMessage is sent as is, see JSONBytes,
+// except an empty one sent as null like encoding/json does.
 func (c *Ctx) JSON(data any) error {
-	c.Set("Content-Type", "application/json; charset=utf-8")
-	c.Response.WriteHeader(c.statusCode)
-	return json.NewEncoder(c.Response).Encode(data)
+	if raw, ok := data.(json.RawMessage); ok {
+		if len(raw) == 0 {
+			raw = json.RawMessage("null")
+		}
+		return c.JSONBytes(raw)
+	}
+	return c.writeJSON("application/json; charset=utf-8", data)
+}
+
+// JSONBytes sends pre-marshaled JSON as is, with the stored status code and the
+// Content-Length header. The bytes are not copied nor re-encoded, so the router
+// JSON conventions (RouterConfig.JSON) don't apply. In debug mode
+// (RouterConfig.Debug) invalid JSON is rejected with an error.
+//
+// Example:
+//
+//	if cached, ok := cache.Get(key); ok {
+//	    return c.JSONBytes(cached)
+//	}
+func (c *Ctx) JSONBytes(b []byte) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+	if c.config.Debug && !json.Valid(b) {
+		return fmt.Errorf("glib: JSONBytes called with invalid JSON: %.64q", b)
+	}
+
+	return c.writeBody("application/json; charset=utf-8", b)
+}
+
+// writeJSON sends a JSON response with the given content type, following the
+// router JSON conventions (RouterConfig.JSON)
+func (c *Ctx) writeJSON(contentType string, data any) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+
+	encoded, err := marshalJSON(c.config.JSON, data)
+	if err != nil {
+		return err
+	}
+
+	return c.writeBody(contentType, append(encoded, '\n'))
 }
 
 // XML sends an XML response
 func (c *Ctx) XML(data any) error {
-	c.Set("Content-Type", "application/xml; charset=utf-8")
-	c.Response.WriteHeader(c.statusCode)
-	_, err := c.Response.Write([]byte(fmt.Sprintf("%v", data)))
-	return err
+	if c.Response == nil {
+		return ErrDetached
+	}
+	return c.writeBody("application/xml; charset=utf-8", []byte(fmt.Sprintf("%v", data)))
 }
 
 // SendString sends a plain text response
 func (c *Ctx) SendString(text string) error {
-	c.Set("Content-Type", "text/plain; charset=utf-8")
-	c.Response.WriteHeader(c.statusCode)
-	_, err := c.Response.Write([]byte(text))
-	return err
+	if c.Response == nil {
+		return ErrDetached
+	}
+	return c.writeBody("text/plain; charset=utf-8", []byte(text))
 }
 
 func (c *Ctx) HTML(data []byte) error {
-	c.Set("Content-Type", "text/html; charset=utf-8")
+	if c.Response == nil {
+		return ErrDetached
+	}
+	return c.writeBody("text/html; charset=utf-8", data)
+}
+
+// writeBody sends a buffered body with the stored status code, its content type
+// and its Content-Length unless the handler set one or a Transfer-Encoding, so
+// that small responses aren't chunked. The body of
+// statuses that can't have one (1xx, 204 No Content and 304 Not Modified) is
+// dropped with a debug log naming the route, as writing it would break the
+// HTTP framing of the connection. HEAD responses are handled by responseWriter.
+func (c *Ctx) writeBody(contentType string, body []byte) error {
+	header := c.header()
+	if !bodyAllowed(c.statusCode) {
+		header.Del("Content-Length")
+		if len(body) > 0 {
+			if logger := c.Logger(); logger != nil {
+				logger.DebugContext(c.Context(), "response body dropped: the status doesn't allow a body",
+					"status", c.statusCode, "route", c.Request.Method+" "+c.RoutePattern(), "size", len(body))
+			}
+		}
+		c.Response.WriteHeader(c.statusCode)
+		return nil
+	}
+
+	header.Set("Content-Type", contentType)
+	if header.Get("Content-Length") == "" && header.Get("Transfer-Encoding") == "" {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
 	c.Response.WriteHeader(c.statusCode)
-	_, err := c.Response.Write(data)
+	_, err := c.Response.Write(body)
 	return err
 }
 
+// bodyAllowed reports whether a response with the status can have a body
+// (RFC 9110 section 6.4.1)
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
 // Stream sends a streaming response with a custom writer function
 func (c *Ctx) Stream(callback func(w io.Writer) error) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
 	c.Response.WriteHeader(c.statusCode)
 	return callback(c.Response)
 }
 
 // SSE sends a Server-Sent Event
+//
+// The stream is tracked by the server: when Shutdown times out, a final
+// SSEShutdownEvent is sent and the connection is closed, so that the client
+// reconnects to another server and the handler returns (its context is
+// canceled). SSE then returns ErrStreamClosed.
 func (c *Ctx) SSE(event, data string) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+
 	c.Set("Content-Type", "text/event-stream")
 	c.Set("Cache-Control", "no-cache")
 	c.Set("Connection", "keep-alive")
 
-	if event != "" {
-		if _, err := fmt.Fprintf(c.Response, "event: %s\n", event); err != nil {
-			return err
+	return c.sseStream().send(func(w io.Writer) error {
+		if event != "" {
+			if _, err := fmt.Fprintf(w, "event: %s\n", event); err != nil {
+				return err
+			}
 		}
-	}
-
-	if _, err := fmt.Fprintf(c.Response, "data: %s\n\n", data); err != nil {
+		_, err := fmt.Fprintf(w, "data: %s\n\n", data)
 		return err
-	}
-
-	if flusher, ok := c.Response.(http.Flusher); ok {
-		flusher.Flush()
-	}
-
-	return nil
+	})
 }
 
 func (c *Ctx) File(file string) error {
@@ -526,7 +921,16 @@ func (c *Ctx) File(file string) error {
 }
 
 // SendFile sends a file as response with optional download (Content-Disposition: attachment)
+//
+// Precompressed sidecar files next to the file (style.css.br, style.css.gz)
+// are sent instead when the client accepts their content coding, with the
+// Content-Type of the file and their own ETag. Sidecars older than the file
+// are ignored, and byte ranges are only served for the uncompressed file.
 func (c *Ctx) SendFile(file string, download bool) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+
 	f, err := os.Open(file)
 	if err != nil {
 		return err
@@ -543,6 +947,10 @@ func (c *Ctx) SendFile(file string, download bool) error {
 		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", stat.Name()))
 	}
 
+	if c.sendPrecompressed(file, stat) {
+		return nil
+	}
+
 	// Note: ServeContent handles its own status code
 	http.ServeContent(c.Response, c.Request, file, stat.ModTime(), f)
 	return nil
@@ -550,6 +958,10 @@ func (c *Ctx) SendFile(file string, download bool) error {
 
 // Download sends a file with Content-Disposition: attachment
 func (c *Ctx) Download(file string, filename ...string) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+
 	f, err := os.Open(file)
 	if err != nil {
 		return err
@@ -572,14 +984,22 @@ func (c *Ctx) Download(file string, filename ...string) error {
 	return nil
 }
 
+// Redirect redirects the request to url. Absolute paths are prefixed with the
+// base path of the server, see Server.SetBasePath.
 func (c *Ctx) Redirect(status int, url string) error {
-	http.Redirect(c.Response, c.Request, url, status)
+	if c.Response == nil {
+		return ErrDetached
+	}
+	http.Redirect(c.Response, c.publicRequest(), c.withBasePath(url), status)
 	return nil
 }
 
-// ParseMultipartForm parses a multipart form with the given max memory
+// ParseMultipartForm parses a multipart form with the given max memory. Parts
+// spilled to disk are removed after the response, see KeepFile.
 func (c *Ctx) ParseMultipartForm(maxMemory int64) error {
-	return c.Request.ParseMultipartForm(maxMemory)
+	err := c.Request.ParseMultipartForm(maxMemory)
+	c.trackMultipartForm()
+	return err
 }
 
 // MultipartForm returns the parsed multipart form
@@ -598,7 +1018,7 @@ func (c *Ctx) Bind(out any) error {
 	contentType := strings.ToLower(c.ContentType())
 
 	switch {
-	case strings.HasPrefix(contentType, "application/json"):
+	case c.isJSONMediaType(contentType):
 		return c.ParseBody(out)
 	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"),
 		strings.HasPrefix(contentType, "multipart/form-data"):
@@ -609,7 +1029,7 @@ func (c *Ctx) Bind(out any) error {
 		// For production, consider using a struct tag-based form decoder library
 		return errors.New("Form binding not fully implemented - use ParseBody for JSON")
 	default:
-		// Try JSON as fallback
+		// JSON or a registered decoder
 		return c.ParseBody(out)
 	}
 }
@@ -638,6 +1058,93 @@ func (c *Ctx) Accepts(contentType string) bool {
 	return strings.Contains(accept, contentType) || strings.Contains(accept, "*/*")
 }
 
+// Negotiate returns the offered content type preferred by the client according to
+// the Accept header and its quality values, adding Accept to the Vary response header.
+// Returns the first offer if the request has no Accept header, or an empty
+// string if none of the offers is acceptable.
+//
+// Example:
+//
+//	switch c.Negotiate("application/json", "text/csv") {
+//	case "text/csv":
+//	    return writeCSV(c, users)
+//	case "":
+//	    return errors.NotAcceptable("Not acceptable", nil)
+//	}
+//	return c.JSON(users)
+func (c *Ctx) Negotiate(offers ...string) string {
+	c.Vary("Accept")
+
+	if len(offers) == 0 {
+		return ""
+	}
+	accept := c.Get("Accept")
+	if accept == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(ranges, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// mediaRange is a media range of an Accept header
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses the media ranges of an Accept header
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaType)), "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific media range matching the offer
+func acceptQuality(ranges []mediaRange, offer string) float64 {
+	typ, subtype, _ := strings.Cut(strings.ToLower(offer), "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
 // IsSuccess checks if the status code is in the 2xx range
 func (c *Ctx) IsSuccess() bool {
 	return c.statusCode >= 200 && c.statusCode < 300
@@ -653,6 +1160,13 @@ func (c *Ctx) IsServerError() bool {
 	return c.statusCode >= 500 && c.statusCode < 600
 }
 
+// SetUser stores the authenticated user in the request context so it is
+// included in error reports
+func (c *Ctx) SetUser(user any) *Ctx {
+	c.Request = c.Request.WithContext(middleware.WithUser(c.Context(), user))
+	return c
+}
+
 // GetRequestID gets the request ID from X-Request-ID header
 func (c *Ctx) GetRequestID() string {
 	return c.Get("X-Request-ID")
@@ -662,3 +1176,22 @@ func (c *Ctx) GetRequestID() string {
 func (c *Ctx) SetRequestID(id string) *Ctx {
 	return c.Set("X-Request-ID", id)
 }
+
+// CorrelationID returns the ID correlating the logs and errors of the request:
+// its request ID (see middleware.RequestID), else the trace ID of its
+// traceparent header, else an ID generated once for the request. It is logged
+// as request_id and sent in the 5xx error responses, so users can quote it to
+// support.
+func (c *Ctx) CorrelationID() string {
+	if id := middleware.CorrelationID(c.Context()); id != "" {
+		return id
+	}
+	rw, ok := c.Response.(*responseWriter)
+	if !ok {
+		return middleware.NewTraceID()
+	}
+	if rw.correlationID == "" {
+		rw.correlationID = middleware.NewTraceID()
+	}
+	return rw.correlationID
+}
@@ -0,0 +1,526 @@
+package glib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	stdslog "log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/jobs"
+	"github.com/azizndao/glib/middleware"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/util"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_ClientIPNet(t *testing.T) {
+	proxies, err := middleware.ParseTrustedProxies([]string{"192.0.2.0/24"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc     string
+		proxies  middleware.TrustedProxies
+		remoteIP string
+		expectIP string
+	}{
+		{desc: "header of a trusted proxy", proxies: proxies, remoteIP: "192.0.2.1", expectIP: "203.0.113.9"},
+		{desc: "spoofed header", proxies: proxies, remoteIP: "198.51.100.7", expectIP: "198.51.100.7"},
+		{desc: "no trusted proxies", remoteIP: "192.0.2.1", expectIP: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), RouterConfig{TrustedProxies: tt.proxies})
+			var ip net.IP
+			r.Get("/ip", func(c *Ctx) error {
+				ip = c.ClientIPNet()
+				return c.NoContent()
+			})
+
+			req := httptest.NewRequest("GET", "/ip", nil)
+			req.RemoteAddr = net.JoinHostPort(tt.remoteIP, "1234")
+			req.Header.Set("X-Real-IP", "203.0.113.9")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expectIP, ip.String())
+		})
+	}
+}
+
+func TestNew_ClientIPBehindStack(t *testing.T) {
+	tests := []struct {
+		desc           string
+		trustedProxies string
+		expectCode     int
+		expectBody     string
+	}{
+		{desc: "forged header of an untrusted peer", expectCode: http.StatusForbidden},
+		{desc: "header of a trusted proxy", trustedProxies: "198.51.100.7", expectCode: http.StatusOK, expectBody: "admin ip=10.8.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.trustedProxies)
+			previous := stdslog.Default()
+			t.Cleanup(func() { stdslog.SetDefault(previous) })
+
+			s := New(Config{DisableDotEnv: true, Logger: stdslog.New(stdslog.DiscardHandler)})
+			s.Router().Group(func(r Router) {
+				r.UseHTTP(middleware.IPFilter(middleware.IPFilterConfig{Allow: []string{"10.8.0.0/16"}}))
+				r.Get("/admin", func(c *Ctx) error {
+					return c.SendString("admin ip=" + c.ClientIPNet().String())
+				})
+			})
+
+			req := httptest.NewRequest("GET", "/admin", nil)
+			req.RemoteAddr = "198.51.100.7:1234"
+			req.Header.Set("X-Real-IP", "10.8.0.1")
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			if tt.expectBody != "" {
+				assert.Equal(t, tt.expectBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCtx_JSONBytes(t *testing.T) {
+	tests := []struct {
+		desc       string
+		debug      bool
+		handler    HandleFunc
+		expectCode int
+		expectBody string
+	}{
+		{
+			desc:       "bytes sent as is",
+			handler:    func(c *Ctx) error { return c.Status(http.StatusAccepted).JSONBytes([]byte(`{"id":1}`)) },
+			expectCode: http.StatusAccepted,
+			expectBody: `{"id":1}`,
+		},
+		{
+			desc:       "raw message passed through by JSON",
+			handler:    func(c *Ctx) error { return c.JSON(json.RawMessage(`{"html":"<b>"}`)) },
+			expectCode: http.StatusOK,
+			expectBody: `{"html":"<b>"}`,
+		},
+		{
+			desc:       "nil raw message sent as null",
+			handler:    func(c *Ctx) error { return c.JSON(json.RawMessage(nil)) },
+			expectCode: http.StatusOK,
+			expectBody: `null`,
+		},
+		{
+			desc:       "invalid JSON not checked outside debug mode",
+			handler:    func(c *Ctx) error { return c.JSONBytes([]byte(`{"id":`)) },
+			expectCode: http.StatusOK,
+			expectBody: `{"id":`,
+		},
+		{
+			desc:       "invalid JSON rejected in debug mode",
+			debug:      true,
+			handler:    func(c *Ctx) error { return c.JSONBytes([]byte(`{"id":`)) },
+			expectCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			config := DefaultRouterOptions()
+			config.Debug = tt.debug
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+			r.Get("/", tt.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			if tt.expectBody != "" {
+				assert.Equal(t, tt.expectBody, w.Body.String())
+				assert.Equal(t, strconv.Itoa(len(tt.expectBody)), w.Header().Get("Content-Length"))
+			}
+		})
+	}
+}
+
+func TestCtx_StatusResponses(t *testing.T) {
+	tests := []struct {
+		desc       string
+		handler    HandleFunc
+		expectCode int
+		expectBody string
+	}{
+		{desc: "OK", handler: func(c *Ctx) error { return c.OK(map[string]int{"id": 1}) }, expectCode: http.StatusOK, expectBody: `{"id":1}` + "\n"},
+		{desc: "Updated with data", handler: func(c *Ctx) error { return c.Updated(map[string]int{"id": 1}) }, expectCode: http.StatusOK, expectBody: `{"id":1}` + "\n"},
+		{desc: "Updated without data", handler: func(c *Ctx) error { return c.Updated(nil) }, expectCode: http.StatusNoContent},
+		{desc: "Deleted", handler: func(c *Ctx) error { return c.Deleted() }, expectCode: http.StatusNoContent},
+		{desc: "NotModified", handler: func(c *Ctx) error { return c.NotModified() }, expectCode: http.StatusNotModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Get("/", func(c *Ctx) error {
+				// Headers set before are kept, the representation ones are dropped when bodyless
+				c.Set("ETag", `"v1"`).Set("Content-Type", "application/json")
+				if tt.expectBody == "" {
+					c.Set("Content-Length", "42")
+				}
+				err := tt.handler(c)
+				assert.Equal(t, tt.expectCode, c.statusCode, "status recorded for logging")
+				return err
+			})
+
+			server := httptest.NewServer(r)
+			defer server.Close()
+			resp, err := http.Get(server.URL)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.expectCode, resp.StatusCode)
+			assert.Equal(t, `"v1"`, resp.Header.Get("ETag"))
+			assert.Equal(t, tt.expectBody, string(body))
+			if tt.expectBody == "" {
+				assert.Empty(t, resp.Header.Get("Content-Length"))
+			}
+			if tt.expectCode == http.StatusNotModified {
+				assert.Empty(t, resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// discardResponseWriter is a ResponseWriter keeping nothing, for benchmarks
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+func TestCtx_Queries(t *testing.T) {
+	c := newCtx(httptest.NewRecorder(), httptest.NewRequest("GET", "/?page=2&tag=a&tag=b", nil), nil, nil)
+
+	assert.Equal(t, "2", c.Query("page"))
+	assert.Equal(t, []string{"a", "b"}, c.QueryAll("tag"))
+	assert.Equal(t, url.Values{"page": {"2"}, "tag": {"a", "b"}}, c.Queries())
+
+	t.Run("parsed once", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(10, func() {
+			_ = c.Query("page")
+			_ = c.QueryAll("tag")
+		})
+		assert.Zero(t, allocs)
+	})
+
+	t.Run("reparsed when the query changes", func(t *testing.T) {
+		c.Request.URL.RawQuery = "page=3"
+		assert.Equal(t, "3", c.Query("page"))
+		assert.Empty(t, c.QueryAll("tag"))
+	})
+}
+
+func BenchmarkCtx_Query(b *testing.B) {
+	req := httptest.NewRequest("GET", "/search?q=shoes&page=2&limit=20&sort=price&order=asc&brand=acme&color=red&size=42", nil)
+	keys := []string{"q", "page", "limit", "sort", "order", "brand", "color", "size"}
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			c := newCtx(nil, req, nil, nil)
+			for _, key := range keys {
+				_ = c.Query(key)
+			}
+		}
+	})
+
+	b.Run("parsed on each call", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for _, key := range keys {
+				_ = req.URL.Query().Get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkCtx_JSONBytes(b *testing.B) {
+	payload := []byte(`{"id":1,"name":"Jane","roles":["admin","editor"],"active":true}`)
+	w := &discardResponseWriter{header: http.Header{}}
+	c := newCtx(w, httptest.NewRequest("GET", "/", nil), nil, nil)
+
+	b.Run("JSONBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = c.JSONBytes(payload)
+		}
+	})
+
+	b.Run("JSON", func(b *testing.B) {
+		var data map[string]any
+		_ = json.Unmarshal(payload, &data)
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = c.JSON(data)
+		}
+	})
+}
+
+func TestCtx_RequireBasicAuth(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/admin", func(c *Ctx) error {
+		if err := c.RequireBasicAuth(`Admin "area"`, func(user, pass string) bool {
+			return util.SecureCompare(user, "admin") && util.SecureCompare(pass, "s3cret")
+		}); err != nil {
+			return err
+		}
+		user, _, _ := c.BasicAuth()
+		return c.SendString("hello " + user)
+	})
+
+	cases := []struct {
+		desc       string
+		user, pass string
+		noAuth     bool
+		expectCode int
+	}{
+		{desc: "valid credentials", user: "admin", pass: "s3cret", expectCode: http.StatusOK},
+		{desc: "wrong password", user: "admin", pass: "s3cre", expectCode: http.StatusUnauthorized},
+		{desc: "wrong user", user: "root", pass: "s3cret", expectCode: http.StatusUnauthorized},
+		{desc: "missing credentials", noAuth: true, expectCode: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if !tc.noAuth {
+				req.SetBasicAuth(tc.user, tc.pass)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectCode, w.Code)
+			if tc.expectCode == http.StatusOK {
+				assert.Equal(t, "hello admin", w.Body.String())
+				assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+				return
+			}
+
+			assert.Equal(t, `Basic realm="Admin \"area\"", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+			var body map[string]any
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, float64(http.StatusUnauthorized), body["code"])
+		})
+	}
+}
+
+func TestCtx_BodylessStatuses(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(stdslog.NewJSONHandler(&logs, &stdslog.HandlerOptions{Level: stdslog.LevelDebug}))
+	r := Default(logger, validation.New(validation.DefaultValidatorConfig()))
+	r.Get("/no-content", func(c *Ctx) error {
+		return c.Status(http.StatusNoContent).SendString("dropped")
+	})
+	r.Get("/not-modified", func(c *Ctx) error {
+		return c.Status(http.StatusNotModified).JSON(map[string]int{"id": 1})
+	})
+	r.Get("/html", func(c *Ctx) error {
+		return c.HTML([]byte("<p>hi</p>"))
+	})
+	r.Get("/json", func(c *Ctx) error {
+		return c.JSON(map[string]int{"id": 1})
+	})
+	r.Get("/text", func(c *Ctx) error {
+		return c.SendString("hello")
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	cases := []struct {
+		desc          string
+		path          string
+		expectCode    int
+		expectBody    string
+		expectLength  int64
+		expectType    string
+		expectDropLog bool
+	}{
+		{desc: "204 drops the body", path: "/no-content", expectCode: http.StatusNoContent, expectLength: 0, expectDropLog: true},
+		{desc: "304 drops the body", path: "/not-modified", expectCode: http.StatusNotModified, expectLength: 0, expectDropLog: true},
+		{desc: "HTML has a Content-Length", path: "/html", expectCode: http.StatusOK, expectBody: "<p>hi</p>", expectLength: 9, expectType: "text/html; charset=utf-8"},
+		{desc: "JSON has a Content-Length", path: "/json", expectCode: http.StatusOK, expectBody: `{"id":1}` + "\n", expectLength: 9, expectType: "application/json; charset=utf-8"},
+		{desc: "text has a Content-Length", path: "/text", expectCode: http.StatusOK, expectBody: "hello", expectLength: 5, expectType: "text/plain; charset=utf-8"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			logs.Reset()
+			resp, err := http.Get(server.URL + tc.path)
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectCode, resp.StatusCode)
+			assert.Equal(t, tc.expectBody, string(body))
+			assert.Equal(t, tc.expectLength, resp.ContentLength)
+			assert.Empty(t, resp.TransferEncoding)
+			assert.Equal(t, tc.expectType, resp.Header.Get("Content-Type"))
+			if tc.expectDropLog {
+				assert.Contains(t, logs.String(), "response body dropped")
+				assert.Contains(t, logs.String(), `"route":"GET `+tc.path+`"`)
+			} else {
+				assert.NotContains(t, logs.String(), "response body dropped")
+			}
+		})
+	}
+}
+
+func TestCtx_AcceptedJob(t *testing.T) {
+	manager := jobs.NewManager()
+	r := setupTestRouter()
+	r.Handle("/jobs/{id}", jobs.StatusHandler(manager))
+	r.Post("/exports", func(c *Ctx) error {
+		job, err := manager.Start(c.Context(), func(ctx context.Context, progress func(float64)) (any, error) {
+			return map[string]string{"url": "/files/export.csv"}, nil
+		})
+		if err != nil {
+			return err
+		}
+		return c.AcceptedJob(job)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/exports", nil))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var accepted jobs.Job
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &accepted))
+	assert.Equal(t, jobs.Pending, accepted.Status)
+	location := w.Header().Get("Location")
+	assert.Equal(t, "/jobs/"+accepted.ID, location)
+
+	manager.Wait()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, location, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var done jobs.Job
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &done))
+	assert.Equal(t, jobs.Succeeded, done.Status)
+	assert.JSONEq(t, `{"url":"/files/export.csv"}`, string(done.Result))
+}
+
+func TestCtx_ValidateBodyCtx(t *testing.T) {
+	type deleteProject struct {
+		Reason string `json:"reason" validate:"required_if_ctx=force true"`
+		Ticket string `json:"ticket" validate:"required_if_ctx=env prod"`
+	}
+	r := setupTestRouter()
+	r.Post("/projects/delete", func(c *Ctx) error {
+		var req deleteProject
+		if err := c.ValidateBodyCtx(&req, map[string]any{"force": c.QueryBool("force")}); err != nil {
+			return err
+		}
+		return c.NoContent()
+	})
+	r.Post("/projects/archive", func(c *Ctx) error {
+		var req deleteProject
+		if err := c.ParseBody(&req); err != nil {
+			return err
+		}
+		return c.ValidateCtx(&req, c.QueryValues("env", "force"))
+	})
+
+	tests := []struct {
+		desc     string
+		target   string
+		status   int
+		expected map[string]any
+	}{
+		{desc: "not forced", target: "/projects/delete", status: http.StatusNoContent},
+		{desc: "forced", target: "/projects/delete?force=1", status: http.StatusUnprocessableEntity, expected: map[string]any{"reason": "reason is a required field"}},
+		{desc: "query values", target: "/projects/archive?env=prod&force=true", status: http.StatusUnprocessableEntity, expected: map[string]any{
+			"reason": "reason is a required field",
+			"ticket": "ticket is a required field",
+		}},
+		{desc: "query values not matched", target: "/projects/archive?env=staging", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, bytes.NewBufferString(`{}`)))
+			require.Equal(t, tt.status, w.Code)
+			if tt.expected == nil {
+				return
+			}
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expected, body["data"])
+		})
+	}
+}
+
+func TestCtx_CorrelationID(t *testing.T) {
+	tests := []struct {
+		desc     string
+		stack    bool
+		headers  map[string]string
+		expected string
+	}{
+		{desc: "request ID", stack: true, headers: map[string]string{"X-Request-Id": "req-1"}, expected: "req-1"},
+		{desc: "trace ID", stack: true, headers: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, expected: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{desc: "generated", headers: map[string]string{"X-Request-Id": "ignored without the middleware"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var logs bytes.Buffer
+			r := Default(slog.New(stdslog.NewJSONHandler(&logs, nil)), validation.New(validation.DefaultValidatorConfig()))
+			if tt.stack {
+				r.UseHTTP(middleware.RequestID())
+			}
+			var id string
+			r.Get("/fail", func(c *Ctx) error {
+				id = c.CorrelationID()
+				assert.Equal(t, id, c.CorrelationID(), "the ID is stable")
+				c.Logger().Info("loading")
+				return errors.New("db down")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			r.ServeHTTP(w, req)
+
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, id, body["request_id"], "server errors carry the ID")
+			assert.Contains(t, logs.String(), `"request_id":"`+id+`"`)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package glib
+
+import (
+	"mime"
+	"strings"
+)
+
+// DecodeFunc decodes a request body into out
+type DecodeFunc func(data []byte, out any) error
+
+// DecoderRegistry registers request body decoders by media type. It is
+// implemented by RouterConfig and Config, see the decoder/yaml and
+// decoder/msgpack packages.
+type DecoderRegistry interface {
+	RegisterDecoder(mediaType string, fn DecodeFunc)
+}
+
+// RegisterDecoder registers the decoder used by Ctx.ParseBody for requests with
+// the given media type. A media type starting with "+" matches a structured
+// syntax suffix (e.g. "+yaml"). JSON media types (see JSONMediaTypes) are always
+// decoded as JSON.
+//
+// Example:
+//
+//	config := glib.DefaultRouterOptions()
+//	config.RegisterDecoder("application/x-protobuf", decodeProto)
+func (rc *RouterConfig) RegisterDecoder(mediaType string, fn DecodeFunc) {
+	if rc.Decoders == nil {
+		rc.Decoders = make(map[string]DecodeFunc)
+	}
+	rc.Decoders[strings.ToLower(mediaType)] = fn
+}
+
+// RegisterDecoder registers a request body decoder on the server router, see RouterConfig.RegisterDecoder
+func (c *Config) RegisterDecoder(mediaType string, fn DecodeFunc) {
+	if c.Decoders == nil {
+		c.Decoders = make(map[string]DecodeFunc)
+	}
+	c.Decoders[strings.ToLower(mediaType)] = fn
+}
+
+// decoderFor returns the decoder registered for the content type
+func (c *Ctx) decoderFor(contentType string) (DecodeFunc, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	if fn, ok := c.config.Decoders[mediaType]; ok {
+		return fn, true
+	}
+	for registered, fn := range c.config.Decoders {
+		if strings.HasPrefix(registered, "+") && strings.HasSuffix(mediaType, registered) {
+			return fn, true
+		}
+	}
+	return nil, false
+}
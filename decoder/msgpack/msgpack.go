@@ -0,0 +1,36 @@
+// Package msgpack provides a MessagePack request body decoder for glib.
+//
+// It lives in its own package so that applications that don't decode
+// MessagePack don't depend on a MessagePack library.
+//
+// Example:
+//
+//	config := glib.Config{}
+//	msgpack.Register(&config)
+//	server := glib.New(config)
+package msgpack
+
+import (
+	"bytes"
+
+	"github.com/azizndao/glib"
+	msgpackv5 "github.com/vmihailenco/msgpack/v5"
+)
+
+// MediaTypes are the media types registered by Register
+var MediaTypes = []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack", "+msgpack"}
+
+// Register registers Decode for the MessagePack media types
+func Register(registry glib.DecoderRegistry) {
+	for _, mediaType := range MediaTypes {
+		registry.RegisterDecoder(mediaType, Decode)
+	}
+}
+
+// Decode decodes a MessagePack payload into out, honoring the `json` struct tags
+// of request types
+func Decode(data []byte, out any) error {
+	dec := msgpackv5.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(out)
+}
@@ -0,0 +1,34 @@
+package msgpack
+
+import (
+	"testing"
+
+	"github.com/azizndao/glib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	msgpackv5 "github.com/vmihailenco/msgpack/v5"
+)
+
+func TestDecode(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	data, err := msgpackv5.Marshal(map[string]any{"name": "john", "age": 30})
+	require.NoError(t, err)
+
+	var out user
+	require.NoError(t, Decode(data, &out))
+	assert.Equal(t, user{Name: "john", Age: 30}, out)
+
+	assert.Error(t, Decode([]byte{0xc1}, &out))
+}
+
+func TestRegister(t *testing.T) {
+	config := glib.Config{}
+	Register(&config)
+	for _, mediaType := range MediaTypes {
+		assert.Contains(t, config.Decoders, mediaType)
+	}
+}
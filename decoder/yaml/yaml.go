@@ -0,0 +1,85 @@
+// Package yaml provides a YAML request body decoder for glib.
+//
+// It lives in its own package so that applications that don't decode YAML
+// don't depend on a YAML library.
+//
+// Example:
+//
+//	config := glib.Config{}
+//	yaml.Register(&config)
+//	server := glib.New(config)
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/azizndao/glib"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// MediaTypes are the media types registered by Register
+var MediaTypes = []string{"application/yaml", "application/x-yaml", "text/yaml", "+yaml"}
+
+// Register registers Decode for the YAML media types
+func Register(registry glib.DecoderRegistry) {
+	for _, mediaType := range MediaTypes {
+		registry.RegisterDecoder(mediaType, Decode)
+	}
+}
+
+// Decode decodes a YAML document into out. The document is converted to JSON
+// first so that the `json` struct tags of request types are honored.
+func Decode(data []byte, out any) error {
+	var doc any
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	doc, err := jsonCompatible(doc)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, out)
+}
+
+// jsonCompatible converts the maps with non-string keys decoded by yaml.v3
+func jsonCompatible(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			converted, err := jsonCompatible(value)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			converted, err := jsonCompatible(value)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(key)] = converted
+		}
+		return m, nil
+	case []any:
+		for i, value := range v {
+			converted, err := jsonCompatible(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
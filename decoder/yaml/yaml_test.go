@@ -0,0 +1,34 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/azizndao/glib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	type config struct {
+		Name    string            `json:"name"`
+		Port    int               `json:"port"`
+		Tags    []string          `json:"tags"`
+		Labels  map[string]string `json:"labels"`
+		Enabled bool              `json:"is_enabled"`
+	}
+
+	var out config
+	err := Decode([]byte("name: api\nport: 8080\ntags: [a, b]\nlabels:\n  1: one\nis_enabled: true\n"), &out)
+	require.NoError(t, err)
+	assert.Equal(t, config{Name: "api", Port: 8080, Tags: []string{"a", "b"}, Labels: map[string]string{"1": "one"}, Enabled: true}, out)
+
+	assert.Error(t, Decode([]byte("name: [unclosed"), &out))
+}
+
+func TestRegister(t *testing.T) {
+	config := glib.DefaultRouterOptions()
+	Register(&config)
+	for _, mediaType := range MediaTypes {
+		assert.Contains(t, config.Decoders, mediaType)
+	}
+}
@@ -0,0 +1,88 @@
+package glib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeKeyValue decodes "key=value" lines, for testing
+func decodeKeyValue(data []byte, out any) error {
+	m := make(map[string]string)
+	for line := range strings.SplitSeq(strings.TrimSpace(string(data)), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("invalid line %q", line)
+		}
+		m[key] = value
+	}
+	encoded, _ := json.Marshal(m)
+	return json.Unmarshal(encoded, out)
+}
+
+func TestCtx_ParseBody_Decoders(t *testing.T) {
+	type user struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	config := DefaultRouterOptions()
+	config.RegisterDecoder("Text/X-KeyValue", decodeKeyValue)
+	config.RegisterDecoder("+kv", decodeKeyValue)
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	r.Post("/parse", func(c *Ctx) error {
+		var u user
+		if err := c.ParseBody(&u); err != nil {
+			return err
+		}
+		return c.SendString(u.Name)
+	})
+	r.Post("/validate", func(c *Ctx) error {
+		var u user
+		if err := c.ValidateBody(&u); err != nil {
+			return err
+		}
+		return c.SendString(u.Name)
+	})
+
+	cases := []struct {
+		desc        string
+		path        string
+		contentType string
+		body        string
+		expected    int
+		response    string
+	}{
+		{desc: "json", path: "/parse", contentType: "application/json", body: `{"name":"john"}`, expected: http.StatusOK, response: "john"},
+		{desc: "no content type", path: "/parse", body: `{"name":"john"}`, expected: http.StatusOK, response: "john"},
+		{desc: "registered decoder", path: "/parse", contentType: "text/x-keyvalue", body: "name=john", expected: http.StatusOK, response: "john"},
+		{desc: "charset parameter", path: "/parse", contentType: "text/x-keyvalue; charset=utf-8", body: "name=john", expected: http.StatusOK, response: "john"},
+		{desc: "suffix", path: "/parse", contentType: "application/vnd.user+kv", body: "name=john", expected: http.StatusOK, response: "john"},
+		{desc: "decoder error", path: "/parse", contentType: "text/x-keyvalue", body: "john", expected: http.StatusBadRequest},
+		{desc: "unknown type", path: "/parse", contentType: "application/xml", body: "<name>john</name>", expected: http.StatusUnsupportedMediaType},
+		{desc: "validation after decode", path: "/validate", contentType: "text/x-keyvalue", body: "name=", expected: http.StatusUnprocessableEntity},
+		{desc: "unknown type with validation", path: "/validate", contentType: "application/xml", body: "<name>john</name>", expected: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+			if tc.response != "" {
+				assert.Equal(t, tc.response, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package glib
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+)
+
+//go:embed docs.html
+var docsTemplateSource string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsTemplateSource))
+
+// docsRoute is a route prepared for the docs template
+type docsRoute struct {
+	Method      string
+	Pattern     string
+	Description string
+	Params      []string
+	Query       []string
+	Examples    []docsExample
+}
+
+// docsExample holds the pretty-printed JSON payloads of a route example
+type docsExample struct {
+	Request  string
+	Response string
+}
+
+// EnableDocs registers a GET endpoint at the given path rendering an HTML page
+// listing all routes, with their description, parameters and examples (see Route).
+// The page is only served when explicitly enabled.
+//
+// Example:
+//
+//	server.EnableDocs("/docs")
+func (s *Server) EnableDocs(path string) {
+	s.router.Get(path, func(c *Ctx) error {
+		routes := s.router.RouteList()
+		data := struct {
+			Title  string
+			Routes []docsRoute
+		}{Title: "API Documentation", Routes: make([]docsRoute, 0, len(routes))}
+
+		for _, route := range routes {
+			if route.Pattern == path {
+				continue
+			}
+			doc := docsRoute{
+				Method:      route.Method,
+				Pattern:     route.Pattern,
+				Description: route.Description,
+				Params:      route.Params(),
+				Query:       route.Query,
+			}
+			for _, example := range route.Examples {
+				doc.Examples = append(doc.Examples, docsExample{
+					Request:  prettyJSON(example.Request),
+					Response: prettyJSON(example.Response),
+				})
+			}
+			data.Routes = append(data.Routes, doc)
+		}
+
+		var buf bytes.Buffer
+		if err := docsTemplate.Execute(&buf, data); err != nil {
+			return err
+		}
+		return c.HTML(buf.Bytes())
+	})
+}
+
+// prettyJSON returns the indented JSON encoding of v, or an empty string for nil
+func prettyJSON(v any) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
@@ -0,0 +1,132 @@
+package glib
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/azizndao/glib/middleware"
+	"github.com/azizndao/glib/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// DefaultEchoBodySize is the maximum number of body bytes returned by the echo endpoint (64KB)
+const DefaultEchoBodySize = 64 * middleware.KB
+
+// Echo is the request as received by the server, returned by the echo endpoint
+type Echo struct {
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	Proto      string `json:"proto"`
+	Host       string `json:"host"`
+	RemoteAddr string `json:"remote_addr"`
+	// ClientIP is the client IP resolved from the proxy headers (see Ctx.ClientIPNet)
+	ClientIP string `json:"client_ip"`
+	// Locale is the locale negotiated from the Accept-Language header
+	Locale string `json:"locale"`
+	// Headers are the request headers, sensitive values being redacted
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body,omitempty"`
+	// BodyTruncated is true when the body was larger than DefaultEchoBodySize
+	BodyTruncated bool `json:"body_truncated,omitempty"`
+	// Match is the routing result for the path given in the match query parameter
+	Match *RouteMatch `json:"match,omitempty"`
+}
+
+// RouteMatch is the result of matching a method and path against the routes of a router
+type RouteMatch struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Matched bool              `json:"matched"`
+	Pattern string            `json:"pattern,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+// MatchRoute returns the route of the router, including its sub-routers, that
+// handles the method and path, with the path parameters it extracts
+//
+// Example:
+//
+//	match := glib.MatchRoute(r, "GET", "/users/5")
+//	// match.Pattern == "/users/{id}", match.Params["id"] == "5"
+func MatchRoute(r Router, method, path string) RouteMatch {
+	path, _, _ = strings.Cut(path, "?")
+	match := RouteMatch{Method: method, Path: path}
+
+	rctx := chi.NewRouteContext()
+	match.Pattern = r.Find(rctx, method, path)
+	match.Matched = match.Pattern != ""
+	if !match.Matched {
+		return match
+	}
+
+	for i, key := range rctx.URLParams.Keys {
+		if key == "*" && !strings.HasSuffix(match.Pattern, "*") {
+			// Wildcard of the mounts leading to the route
+			continue
+		}
+		if match.Params == nil {
+			match.Params = make(map[string]string, len(rctx.URLParams.Keys))
+		}
+		match.Params[key] = rctx.URLParams.Values[i]
+	}
+	return match
+}
+
+// EnableEcho registers an endpoint at the given path, for all methods, returning
+// the request as received: method, URL, headers (Authorization, Cookie and other
+// sensitive headers are redacted), body, resolved client IP and negotiated locale.
+// The match query parameter reports which route handles a path, and the method
+// query parameter the method to match (default: GET).
+//
+// The endpoint is only registered in debug mode (IS_DEBUG=true) as it exposes
+// the request headers.
+//
+// Example:
+//
+//	server.EnableEcho("/debug/echo")
+//	// GET /debug/echo?match=/users/5&method=DELETE
+func (s *Server) EnableEcho(path string) {
+	if !util.GetEnvBool("IS_DEBUG", false) {
+		return
+	}
+
+	s.router.HandleFunc(path, func(c *Ctx) error {
+		echo := Echo{
+			Method:     c.Request.Method,
+			URL:        c.Request.URL.String(),
+			Proto:      c.Request.Proto,
+			Host:       c.Request.Host,
+			RemoteAddr: c.Request.RemoteAddr,
+			Locale:     c.Locale(),
+			Headers:    middleware.SanitizeHeaders(c.Request.Header),
+		}
+		if ip := c.ClientIPNet(); ip != nil {
+			echo.ClientIP = ip.String()
+		}
+
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, DefaultEchoBodySize+1))
+			if err != nil {
+				return err
+			}
+			if len(body) > DefaultEchoBodySize {
+				body = body[:DefaultEchoBodySize]
+				echo.BodyTruncated = true
+			}
+			echo.Body = string(body)
+		}
+
+		if target := c.Query("match"); target != "" {
+			method := strings.ToUpper(c.Query("method"))
+			if method == "" {
+				method = http.MethodGet
+			}
+			match := MatchRoute(s.router, method, target)
+			echo.Match = &match
+		}
+
+		c.NoCache()
+		return c.JSON(echo)
+	})
+}
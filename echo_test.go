@@ -0,0 +1,84 @@
+package glib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchRoute(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/health", func(c *Ctx) error { return c.NoContent() })
+	r.Route("/users", func(r Router) {
+		r.Get("/{id}", func(c *Ctx) error { return c.NoContent() })
+	})
+
+	tests := []struct {
+		desc    string
+		method  string
+		path    string
+		matched bool
+		pattern string
+		params  map[string]string
+	}{
+		{desc: "static route", method: "GET", path: "/health", matched: true, pattern: "/health"},
+		{desc: "sub-router with params", method: "GET", path: "/users/5?x=1", matched: true, pattern: "/users/{id}", params: map[string]string{"id": "5"}},
+		{desc: "wrong method", method: "DELETE", path: "/users/5"},
+		{desc: "unknown path", method: "GET", path: "/missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			match := MatchRoute(r, tt.method, tt.path)
+			assert.Equal(t, tt.matched, match.Matched)
+			assert.Equal(t, tt.pattern, match.Pattern)
+			assert.Equal(t, tt.params, match.Params)
+		})
+	}
+}
+
+func TestEnableEcho(t *testing.T) {
+	t.Run("disabled outside debug mode", func(t *testing.T) {
+		t.Setenv("IS_DEBUG", "false")
+		s := &Server{router: setupTestRouter()}
+		s.EnableEcho("/debug/echo")
+
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest("GET", "/debug/echo", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("echoes the request", func(t *testing.T) {
+		t.Setenv("IS_DEBUG", "true")
+		s := &Server{router: setupTestRouter()}
+		s.router.Get("/users/{id}", func(c *Ctx) error { return c.NoContent() })
+		s.EnableEcho("/debug/echo")
+
+		req := httptest.NewRequest("POST", "/debug/echo?match=/users/5", strings.NewReader(`{"name":"x"}`))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Cookie", "session=secret")
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		req.Header.Set("X-Custom", "value")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var echo Echo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &echo))
+		assert.Equal(t, "POST", echo.Method)
+		assert.Equal(t, "/debug/echo?match=/users/5", echo.URL)
+		assert.Equal(t, `{"name":"x"}`, echo.Body)
+		assert.Equal(t, "192.0.2.1", echo.ClientIP, "forwarding headers of an untrusted peer are ignored")
+		assert.Equal(t, "value", echo.Headers["X-Custom"])
+		assert.NotContains(t, w.Body.String(), "secret", "sensitive headers are redacted")
+		require.NotNil(t, echo.Match)
+		assert.True(t, echo.Match.Matched)
+		assert.Equal(t, "/users/{id}", echo.Match.Pattern)
+		assert.Equal(t, "5", echo.Match.Params["id"])
+	})
+}
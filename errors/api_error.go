@@ -1,13 +1,21 @@
 // Package errors provides a standardized way to represent errors in HTTP handlers.
 package errors
 
-import "fmt"
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
 
 // ApiError represents an error returned by a handler
 type ApiError struct {
-	Code     int   `json:"code"`
-	Data     any   `json:"data,omitempty"`
-	internal error `json:"-"`
+	Code int `json:"code"`
+	Data any `json:"data,omitempty"`
+	// RequestID is the ID users can quote to support, sent with the server
+	// errors so they can be found in the logs
+	RequestID string `json:"request_id,omitempty"`
+	internal  error  `json:"-"`
 }
 
 // NewApi creates a new Error with the given code, data, and internal error
@@ -27,3 +35,86 @@ func (e *ApiError) Error() string {
 
 	return fmt.Sprintf("%d: %s", e.Code, e.Data)
 }
+
+// Unwrap returns the internal error so errors.Is and errors.As can inspect it
+func (e *ApiError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.internal
+}
+
+// fieldNames are the names of the serialized fields of ApiError
+type fieldNames struct {
+	code    string
+	data    string
+	message string
+}
+
+var defaultFieldNames = fieldNames{code: "code", data: "data"}
+
+// apiFieldNames holds the names configured with SetFieldNames and SetMessageField
+var apiFieldNames atomic.Pointer[fieldNames]
+
+func currentFieldNames() fieldNames {
+	if names := apiFieldNames.Load(); names != nil {
+		return *names
+	}
+	return defaultFieldNames
+}
+
+// SetFieldNames renames the "code" and "data" fields of the serialized
+// ApiErrors, e.g. to match the casing of the success envelopes. An empty name
+// keeps the default one. Renamed fields are serialized in alphabetical order.
+// Call it at startup, before serving requests.
+//
+//	errors.SetFieldNames("status", "details")
+//	// {"details":{"email":"is required"},"status":400}
+func SetFieldNames(code, data string) {
+	names := currentFieldNames()
+	names.code, names.data = cmp.Or(code, defaultFieldNames.code), cmp.Or(data, defaultFieldNames.data)
+	apiFieldNames.Store(&names)
+}
+
+// SetMessageField adds a top-level string field with the given name to the
+// serialized ApiErrors whose Data is a string, holding the same text. An
+// empty name removes it (default). Call it at startup, before serving requests.
+//
+//	errors.SetMessageField("message")
+//	// {"code":404,"data":"User not found","message":"User not found"}
+func SetMessageField(name string) {
+	names := currentFieldNames()
+	names.message = name
+	apiFieldNames.Store(&names)
+}
+
+// Body returns the value serialized for the error, holding the configured
+// fields. It has no MarshalJSON method, so encoders applying their own
+// conventions (e.g. time formats) still apply them to Data.
+func Body(e *ApiError) any {
+	names := currentFieldNames()
+	if names == defaultFieldNames {
+		return struct {
+			Code      int    `json:"code"`
+			Data      any    `json:"data,omitempty"`
+			RequestID string `json:"request_id,omitempty"`
+		}{e.Code, e.Data, e.RequestID}
+	}
+
+	body := map[string]any{names.code: e.Code}
+	if e.Data != nil {
+		body[names.data] = e.Data
+	}
+	if message, ok := e.Data.(string); ok && names.message != "" {
+		body[names.message] = message
+	}
+	if e.RequestID != "" {
+		body["request_id"] = e.RequestID
+	}
+	return body
+}
+
+// MarshalJSON encodes the error with the configured field names, see SetFieldNames
+func (e *ApiError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Body(e))
+}
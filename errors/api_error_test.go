@@ -0,0 +1,62 @@
+package errors
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// assertGolden compares got with the content of testdata/<name>.golden
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(got))
+}
+
+func TestApiError_FieldNames(t *testing.T) {
+	errs := map[string]*ApiError{
+		"message": NotFound("User not found", nil),
+		"details": UnprocessableEntity(map[string]string{"email": "is required"}, nil),
+		"empty":   NewApi(500, nil, nil),
+	}
+
+	cases := []struct {
+		desc      string
+		configure func()
+	}{
+		{desc: "default", configure: func() {}},
+		{desc: "renamed", configure: func() { SetFieldNames("status", "details") }},
+		{desc: "message_field", configure: func() { SetMessageField("message") }},
+		{desc: "renamed_message_field", configure: func() {
+			SetFieldNames("status", "")
+			SetMessageField("error")
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.configure()
+			t.Cleanup(func() { apiFieldNames.Store(nil) })
+
+			var got []byte
+			for _, name := range []string{"message", "details", "empty"} {
+				encoded, err := json.Marshal(errs[name])
+				require.NoError(t, err)
+				got = append(append(got, encoded...), '\n')
+			}
+			assertGolden(t, "api_error_"+tc.desc, got)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+)
+
+// Detail describes a single problem accumulated by a Collector
+type Detail struct {
+	Field   string `json:"field,omitempty"`
+	Message any    `json:"message"`
+}
+
+// Collector accumulates independent problems (body, path, business rules...)
+// so they can be returned to the client in a single response.
+//
+// Example:
+//
+//	errs := errors.Collect()
+//	if req.Name == "" {
+//	    errs.Add("name", fmt.Errorf("name is required"))
+//	}
+//	if !exists {
+//	    errs.AddApi(errors.NotFound("Unknown category", nil))
+//	}
+//	return errs.Err()
+type Collector struct {
+	details []Detail
+	errs    []error
+	seen    map[string]struct{}
+	fields  bool
+	// code is the status of the first ApiError recorded by AddApi that isn't a
+	// validation status (400 or 422)
+	code int
+}
+
+// Collect creates a new empty Collector
+func Collect() *Collector {
+	return &Collector{
+		seen: make(map[string]struct{}),
+	}
+}
+
+// Add records an error for the given field. An empty field name records a
+// general problem not bound to a specific field. Nil errors are ignored.
+func (c *Collector) Add(field string, err error) *Collector {
+	if err == nil {
+		return c
+	}
+
+	var message any = err.Error()
+	if apiErr, ok := err.(*ApiError); ok {
+		message = apiErr.Data
+	}
+
+	if c.add(Detail{Field: field, Message: message}, err) && field != "" {
+		c.fields = true
+	}
+	return c
+}
+
+// AddApi records an ApiError. Its Data is used as the detail message, and its
+// status is the one of the Result unless it is 400 Bad Request or 422
+// Unprocessable Entity, the first one recorded winning (e.g. a 404 Not Found).
+func (c *Collector) AddApi(err *ApiError) *Collector {
+	if err == nil {
+		return c
+	}
+	if c.code == 0 && err.Code != 0 && err.Code != http.StatusBadRequest && err.Code != http.StatusUnprocessableEntity {
+		c.code = err.Code
+	}
+
+	message := err.Data
+	if message == nil {
+		message = http.StatusText(err.Code)
+	}
+
+	var reason error = err
+	if err.internal != nil {
+		reason = err.internal
+	}
+	c.add(Detail{Message: message}, reason)
+	return c
+}
+
+// add appends the detail unless an identical one was already recorded.
+// Returns true if the detail was added.
+func (c *Collector) add(detail Detail, err error) bool {
+	key := detail.Field + "\x00" + Reason{reason: detail.Message}.Error()
+	if _, ok := c.seen[key]; ok {
+		return false
+	}
+	c.seen[key] = struct{}{}
+	c.details = append(c.details, detail)
+	c.errs = append(c.errs, err)
+	return true
+}
+
+// Len returns the number of distinct problems recorded
+func (c *Collector) Len() int {
+	return len(c.details)
+}
+
+// HasErrors reports whether at least one problem was recorded
+func (c *Collector) HasErrors() bool {
+	return len(c.details) > 0
+}
+
+// Details returns the recorded problems in insertion order
+func (c *Collector) Details() []Detail {
+	return c.details
+}
+
+// Result merges the recorded problems into a single ApiError whose Data is
+// the details array. The status is the one of the first ApiError recorded by
+// AddApi with another status than 400 or 422, otherwise 422 Unprocessable Entity
+// when at least one problem is bound to a field, and 400 Bad Request otherwise.
+// The internal error joins all recorded errors so errors.Is and errors.As
+// work across the aggregate.
+//
+// Returns nil if no problem was recorded.
+func (c *Collector) Result() *ApiError {
+	if !c.HasErrors() {
+		return nil
+	}
+
+	code := http.StatusBadRequest
+	if c.code != 0 {
+		code = c.code
+	} else if c.fields {
+		code = http.StatusUnprocessableEntity
+	}
+
+	return &ApiError{
+		Code:     code,
+		Data:     c.details,
+		internal: stderrors.Join(c.errs...),
+	}
+}
+
+// Err is like Result but returns an untyped nil when no problem was recorded,
+// so it can be returned directly from a handler.
+func (c *Collector) Err() error {
+	if result := c.Result(); result != nil {
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		c := Collect()
+		assert.False(t, c.HasErrors())
+		assert.Nil(t, c.Result())
+		assert.NoError(t, c.Err())
+	})
+
+	t.Run("field_errors_are_422", func(t *testing.T) {
+		c := Collect().
+			Add("name", fmt.Errorf("name is required")).
+			Add("email", fmt.Errorf("email is invalid")).
+			Add("age", nil)
+
+		res := c.Result()
+		require.NotNil(t, res)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		assert.Equal(t, []Detail{
+			{Field: "name", Message: "name is required"},
+			{Field: "email", Message: "email is invalid"},
+		}, res.Data)
+	})
+
+	t.Run("general_errors_are_400", func(t *testing.T) {
+		c := Collect().
+			AddApi(BadRequest("Invalid cursor", nil)).
+			Add("", fmt.Errorf("unknown filter"))
+
+		res := c.Result()
+		require.NotNil(t, res)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Equal(t, []Detail{
+			{Message: "Invalid cursor"},
+			{Message: "unknown filter"},
+		}, res.Data)
+	})
+
+	t.Run("api_error_status_kept", func(t *testing.T) {
+		cases := []struct {
+			desc     string
+			collect  func() *Collector
+			expected int
+		}{
+			{
+				desc:     "not_found",
+				collect:  func() *Collector { return Collect().AddApi(NotFound("Unknown category", nil)) },
+				expected: http.StatusNotFound,
+			},
+			{
+				desc: "first_non_validation_status_wins",
+				collect: func() *Collector {
+					return Collect().
+						Add("name", fmt.Errorf("name is required")).
+						AddApi(UnprocessableEntity("Invalid state", nil)).
+						AddApi(Conflict(nil, nil)).
+						AddApi(NotFound("Unknown category", nil))
+				},
+				expected: http.StatusConflict,
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.desc, func(t *testing.T) {
+				assert.Equal(t, tc.expected, tc.collect().Result().Code)
+			})
+		}
+	})
+
+	t.Run("deduplicate_preserving_order", func(t *testing.T) {
+		c := Collect().
+			Add("b", fmt.Errorf("second")).
+			Add("a", fmt.Errorf("first")).
+			Add("b", fmt.Errorf("second")).
+			Add("", fmt.Errorf("second"))
+
+		assert.Equal(t, 3, c.Len())
+		assert.Equal(t, []Detail{
+			{Field: "b", Message: "second"},
+			{Field: "a", Message: "first"},
+			{Message: "second"},
+		}, c.Details())
+	})
+
+	t.Run("errors_is", func(t *testing.T) {
+		sentinel := fmt.Errorf("sentinel")
+		other := fmt.Errorf("other")
+		c := Collect().
+			Add("field", fmt.Errorf("wrapped: %w", sentinel)).
+			AddApi(BadRequest("bad", other))
+
+		err := c.Err()
+		assert.ErrorIs(t, err, sentinel)
+		assert.ErrorIs(t, err, other)
+
+		var apiErr *ApiError
+		require.True(t, stderrors.As(err, &apiErr))
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.Code)
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		res := Collect().Add("name", fmt.Errorf("required")).Result()
+		data, err := json.Marshal(res)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"code":422,"data":[{"field":"name","message":"required"}]}`, string(data))
+	})
+}
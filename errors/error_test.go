@@ -89,7 +89,7 @@ func TestErrors(t *testing.T) {
 		}{
 			{desc: "single", reasons: []error{fmt.Errorf("reason")}, expected: "reason"},
 			{desc: "many", reasons: []error{fmt.Errorf("err1"), nil, fmt.Errorf("err2")}, expected: "err1\n<nil>\nerr2"}, // nil should never happen to be in an error but we want extra safety
-			{desc: "empty_slice", reasons: []error{}, expected: "goyave.dev/goyave/util/errors.Error: the Error doesn't wrap any reason (empty reasons slice)"},
+			{desc: "empty_slice", reasons: []error{}, expected: "github.com/azizndao/glib/errors.Error: the Error doesn't wrap any reason (empty reasons slice)"},
 			{desc: "nil_slice", reasons: []error{nil}, expected: "<nil>"}, // This can should never happen but we want extra safety
 		}
 
@@ -112,22 +112,22 @@ func TestErrors(t *testing.T) {
 			err      *Error
 			desc     string
 		}{
-			{desc: "empty_slice", err: emptySliceErr, expected: regexp.MustCompile("^goyave.dev/goyave/util/errors.Error: the Error doesn't wrap any reason \\(empty reasons slice\\)\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:105\n")},
-			{desc: "nil_error_slice", err: New([]error{nil}).(*Error), expected: regexp.MustCompile("^goyave.dev/goyave/util/errors.Error: the Error doesn't wrap any reason \\(empty reasons slice\\)\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:116\n")},
-			{desc: "nil_any_slice", err: New([]any{nil}).(*Error), expected: regexp.MustCompile("^goyave.dev/goyave/util/errors.Error: the Error doesn't wrap any reason \\(empty reasons slice\\)\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:117\n")},
-			{desc: "single", err: New("err1").(*Error), expected: regexp.MustCompile("^err1\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:118\n")},
+			{desc: "empty_slice", err: emptySliceErr, expected: regexp.MustCompile("^github.com/azizndao/glib/errors.Error: the Error doesn't wrap any reason \\(empty reasons slice\\)\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:105\n")},
+			{desc: "nil_error_slice", err: New([]error{nil}).(*Error), expected: regexp.MustCompile("^github.com/azizndao/glib/errors.Error: the Error doesn't wrap any reason \\(empty reasons slice\\)\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:116\n")},
+			{desc: "nil_any_slice", err: New([]any{nil}).(*Error), expected: regexp.MustCompile("^github.com/azizndao/glib/errors.Error: the Error doesn't wrap any reason \\(empty reasons slice\\)\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:117\n")},
+			{desc: "single", err: New("err1").(*Error), expected: regexp.MustCompile("^err1\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:118\n")},
 			{
 				desc:     "many_any",
 				err:      New([]any{fmt.Errorf("err1"), "err2", nil, map[string]any{"key": "value"}, suberror}).(*Error), // nil should be excluded
-				expected: regexp.MustCompile("^err1\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:121\n([\\d\\S\\n\\t]*?)\n\nerr2\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:121\n([\\d\\S\\n\\t]*?)\n\nmap\\[key:value\\]\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:121\n([\\d\\S\\n\\t]*?)\n\nsuberror\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:108\n([\\d\\S\\n\\t]*?)$"),
+				expected: regexp.MustCompile("^err1\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:121\n([\\d\\S\\n\\t]*?)\n\nerr2\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:121\n([\\d\\S\\n\\t]*?)\n\nmap\\[key:value\\]\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:121\n([\\d\\S\\n\\t]*?)\n\nsuberror\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:108\n([\\d\\S\\n\\t]*?)$"),
 			},
 			{
 				desc:     "many_errors",
 				err:      New([]error{fmt.Errorf("err1"), nil, suberror}).(*Error), // nil should be excluded
-				expected: regexp.MustCompile("^err1\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:126\n([\\d\\S\\n\\t]*?)\n\nsuberror\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:108\n([\\d\\S\\n\\t]*?)$"),
+				expected: regexp.MustCompile("^err1\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:126\n([\\d\\S\\n\\t]*?)\n\nsuberror\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:108\n([\\d\\S\\n\\t]*?)$"),
 			},
-			{desc: "single_already_error", err: New([]error{suberror}).(*Error), expected: regexp.MustCompile("^suberror\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:108\n")},
-			{desc: "contains_nil", err: &Error{reasons: []error{nil, nil}, callers: suberror.(*Error).callers}, expected: regexp.MustCompile("^<nil>\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:108\n([\\d\\S\\n\\t]*?)\n\n<nil>\ngoyave\\.dev/goyave/v5/util/errors\\.TestErrors\\.func8\n\t(.*?)/goyave/util/errors/error_test\\.go:108\n([\\d\\S\\n\\t]*?)$")}, // Should never happen but we want extra safety
+			{desc: "single_already_error", err: New([]error{suberror}).(*Error), expected: regexp.MustCompile("^suberror\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:108\n")},
+			{desc: "contains_nil", err: &Error{reasons: []error{nil, nil}, callers: suberror.(*Error).callers}, expected: regexp.MustCompile("^<nil>\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:108\n([\\d\\S\\n\\t]*?)\n\n<nil>\ngithub\\.com/azizndao/glib/errors\\.TestErrors\\.func8\n\t(.*?)/errors/error_test\\.go:108\n([\\d\\S\\n\\t]*?)$")}, // Should never happen but we want extra safety
 		}
 
 		for _, c := range cases {
@@ -143,7 +143,7 @@ func TestErrors(t *testing.T) {
 			expected *regexp.Regexp
 			desc     string
 		}{
-			{desc: "OK", err: New("").(*Error), expected: regexp.MustCompile("/goyave/util/errors/error_test.go:146$")},
+			{desc: "OK", err: New("").(*Error), expected: regexp.MustCompile("/errors/error_test.go:146$")},
 			{desc: "unknown", err: NewSkip("", 5).(*Error), expected: regexp.MustCompile(`^\[unknown file line\]$`)}, // Skip more frames than necessary to have empty callers slice
 		}
 
@@ -167,7 +167,7 @@ func TestErrors(t *testing.T) {
 			expected    string
 			expectedErr bool
 		}{
-			{desc: "empty_slice", err: emptySliceErr, expected: "\"goyave.dev/goyave/util/errors.Error: the Error doesn't wrap any reason (empty reasons slice)\""},
+			{desc: "empty_slice", err: emptySliceErr, expected: "\"github.com/azizndao/glib/errors.Error: the Error doesn't wrap any reason (empty reasons slice)\""},
 			{desc: "single", err: New(fmt.Errorf("error message")).(*Error), expected: `"error message"`},
 			{desc: "single_marshaler", err: New(map[string]any{"key": "value"}).(*Error), expected: `{"key":"value"}`},
 			{desc: "many", err: New([]any{nil, "ah", map[string]any{"key": "value"}, fmt.Errorf("error message"), suberror, manySuberror}).(*Error), expected: `["ah",{"key":"value"},"error message","suberror",["suberror1","suberror2"]]`},
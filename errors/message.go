@@ -0,0 +1,34 @@
+package errors
+
+import "encoding/json"
+
+// Message is a translatable message marker. It is resolved at render time
+// against the server's message catalog using the request's locale.
+// When no translation is found, the key itself is used.
+type Message struct {
+	Key string
+	// Args are key-value pairs used to fill `{name}` placeholders in the
+	// translated message. A "count" argument selects the plural form.
+	Args []any
+}
+
+// T creates a translatable message marker to use as ApiError data
+//
+// Example:
+//
+//	return errors.NotFound(errors.T("user.not_found", "id", id), nil)
+//	return errors.Conflict(errors.T("cart.items_left", "count", n), nil)
+func T(key string, args ...any) Message {
+	return Message{Key: key, Args: args}
+}
+
+// String returns the message key
+func (m Message) String() string {
+	return m.Key
+}
+
+// MarshalJSON marshals the message key, used when the message could not be
+// resolved before rendering
+func (m Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Key)
+}
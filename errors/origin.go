@@ -0,0 +1,56 @@
+package errors
+
+import stderrors "errors"
+
+// OriginError tags an error with the layer returning it: a named middleware or
+// the route handler
+type OriginError struct {
+	// Middleware is the name of the middleware returning the error, empty for the handler
+	Middleware string
+	Err        error
+}
+
+// FromMiddleware tags err as returned by the named middleware.
+// Errors already tagged keep their origin. Returns nil if err is nil.
+func FromMiddleware(name string, err error) error {
+	if err == nil || OriginOf(err) != nil {
+		return err
+	}
+	return &OriginError{Middleware: name, Err: err}
+}
+
+// FromHandler tags err as returned by the route handler.
+// Errors already tagged keep their origin. Returns nil if err is nil.
+func FromHandler(err error) error {
+	if err == nil || OriginOf(err) != nil {
+		return err
+	}
+	return &OriginError{Err: err}
+}
+
+// OriginOf returns the origin tag of err, or nil if it has none
+func OriginOf(err error) *OriginError {
+	var origin *OriginError
+	if stderrors.As(err, &origin) {
+		return origin
+	}
+	return nil
+}
+
+// Error implements the error interface
+func (e *OriginError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the tagged error
+func (e *OriginError) Unwrap() error {
+	return e.Err
+}
+
+// Origin describes the origin of the error: "handler" or "middleware <name>"
+func (e *OriginError) Origin() string {
+	if e.Middleware == "" {
+		return "handler"
+	}
+	return "middleware " + e.Middleware
+}
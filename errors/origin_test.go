@@ -0,0 +1,42 @@
+package errors
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrigin(t *testing.T) {
+	cause := BadRequest("invalid", nil)
+
+	t.Run("middleware", func(t *testing.T) {
+		err := FromMiddleware("auth", cause)
+		origin := OriginOf(err)
+		require.NotNil(t, origin)
+		assert.Equal(t, "auth", origin.Middleware)
+		assert.Equal(t, "middleware auth", origin.Origin())
+		assert.True(t, stderrors.Is(err, cause))
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		origin := OriginOf(FromHandler(cause))
+		require.NotNil(t, origin)
+		assert.Equal(t, "handler", origin.Origin())
+	})
+
+	t.Run("first origin is kept", func(t *testing.T) {
+		err := FromHandler(FromMiddleware("auth", cause))
+		assert.Equal(t, "auth", OriginOf(err).Middleware)
+	})
+
+	t.Run("nil errors", func(t *testing.T) {
+		assert.Nil(t, FromMiddleware("auth", nil))
+		assert.Nil(t, FromHandler(nil))
+		assert.Nil(t, OriginOf(cause))
+
+		var typedNil *ApiError
+		assert.NotPanics(t, func() { FromHandler(typedNil) })
+	})
+}
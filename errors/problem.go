@@ -0,0 +1,36 @@
+package errors
+
+import "net/http"
+
+// Problem is an RFC 9457 problem details object, rendered with the
+// application/problem+json media type
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Data holds the error data that is not a plain message (e.g. validation errors)
+	Data any `json:"data,omitempty"`
+	// RequestID is the "request_id" extension member of the server errors
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewProblem maps an API error to a problem details object. String data becomes
+// the detail, any other data is kept in the "data" extension member.
+func NewProblem(code int, data any, instance string) Problem {
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(code),
+		Status:   code,
+		Instance: instance,
+	}
+
+	if detail, ok := data.(string); ok {
+		problem.Detail = detail
+	} else {
+		problem.Data = data
+	}
+
+	return problem
+}
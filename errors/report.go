@@ -0,0 +1,108 @@
+package errors
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Reporter sends errors to an error tracking service (Sentry, Rollbar, a webhook...).
+// meta contains request metadata such as the route pattern, request ID, user and
+// sanitized headers.
+//
+// Adapting a service takes a few lines, for example with sentry-go:
+//
+//	type sentryReporter struct{}
+//
+//	func (sentryReporter) Report(ctx context.Context, err error, meta map[string]any) {
+//	    hub := sentry.CurrentHub().Clone()
+//	    hub.Scope().SetContext("request", meta)
+//	    hub.CaptureException(err)
+//	}
+type Reporter interface {
+	Report(ctx context.Context, err error, meta map[string]any)
+}
+
+// ReporterFunc is an adapter allowing the use of ordinary functions as Reporter
+type ReporterFunc func(ctx context.Context, err error, meta map[string]any)
+
+// Report calls f(ctx, err, meta)
+func (f ReporterFunc) Report(ctx context.Context, err error, meta map[string]any) {
+	f(ctx, err, meta)
+}
+
+// DefaultReportQueueSize is the default size of the AsyncReporter queue
+const DefaultReportQueueSize = 100
+
+type report struct {
+	ctx  context.Context
+	err  error
+	meta map[string]any
+}
+
+// AsyncReporter forwards reports to a Reporter from a background goroutine so
+// reporting never blocks the request. Reports are dropped when the queue is full.
+type AsyncReporter struct {
+	reporter  Reporter
+	queue     chan report
+	dropped   atomic.Uint64
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewAsyncReporter starts a background goroutine forwarding reports to the given
+// Reporter through a queue of the given size (DefaultReportQueueSize if <= 0)
+func NewAsyncReporter(reporter Reporter, queueSize int) *AsyncReporter {
+	if queueSize <= 0 {
+		queueSize = DefaultReportQueueSize
+	}
+
+	a := &AsyncReporter{
+		reporter: reporter,
+		queue:    make(chan report, queueSize),
+		done:     make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncReporter) run() {
+	defer close(a.done)
+	for r := range a.queue {
+		a.reporter.Report(r.ctx, r.err, r.meta)
+	}
+}
+
+// Report queues the error without blocking. The report is dropped if the queue is full.
+// The context is detached from its cancellation so the report outlives the request.
+func (a *AsyncReporter) Report(ctx context.Context, err error, meta map[string]any) {
+	defer func() {
+		// Reporting after Close drops the report
+		if recover() != nil {
+			a.dropped.Add(1)
+		}
+	}()
+
+	select {
+	case a.queue <- report{ctx: context.WithoutCancel(ctx), err: err, meta: meta}:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of reports dropped because the queue was full
+func (a *AsyncReporter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Close stops accepting reports and waits until the queued ones are sent or the context is done
+func (a *AsyncReporter) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() { close(a.queue) })
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,48 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncReporter(t *testing.T) {
+	t.Run("forwards reports", func(t *testing.T) {
+		var mu sync.Mutex
+		var reported []string
+		reporter := NewAsyncReporter(ReporterFunc(func(ctx context.Context, err error, meta map[string]any) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, fmt.Sprintf("%s %s", err, meta["route"]))
+		}), 10)
+
+		reporter.Report(context.Background(), fmt.Errorf("boom"), map[string]any{"route": "/users"})
+		require.NoError(t, reporter.Close(context.Background()))
+
+		assert.Equal(t, []string{"boom /users"}, reported)
+		assert.Zero(t, reporter.Dropped())
+	})
+
+	t.Run("drops when the queue is full", func(t *testing.T) {
+		release := make(chan struct{})
+		reporter := NewAsyncReporter(ReporterFunc(func(ctx context.Context, err error, meta map[string]any) {
+			<-release
+		}), 1)
+
+		for range 5 {
+			reporter.Report(context.Background(), fmt.Errorf("boom"), nil)
+		}
+		// One report is being sent, one is queued, the others are dropped
+		assert.GreaterOrEqual(t, reporter.Dropped(), uint64(3))
+
+		close(release)
+		require.NoError(t, reporter.Close(context.Background()))
+
+		reporter.Report(context.Background(), fmt.Errorf("late"), nil)
+		assert.GreaterOrEqual(t, reporter.Dropped(), uint64(4))
+	})
+}
@@ -0,0 +1,233 @@
+package glib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// entityTag is a parsed entity-tag as defined by RFC 9110 section 8.8.3
+type entityTag struct {
+	weak   bool
+	opaque string // including the double quotes
+}
+
+// parseEntityTag parses a single entity-tag (`"xyz"` or `W/"xyz"`)
+func parseEntityTag(tag string) (entityTag, bool) {
+	tag = strings.TrimSpace(tag)
+	weak := false
+	if strings.HasPrefix(tag, "W/") {
+		weak = true
+		tag = tag[2:]
+	}
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' || strings.Contains(tag[1:len(tag)-1], `"`) {
+		return entityTag{}, false
+	}
+	return entityTag{weak: weak, opaque: tag}, true
+}
+
+// parseEntityTags parses a comma-separated If-Match / If-None-Match header value.
+// Returns wildcard=true if the value is "*". Malformed entries are ignored.
+func parseEntityTags(header string) (tags []entityTag, wildcard bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return nil, true
+	}
+
+	for len(header) > 0 {
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			break
+		}
+
+		// Find the closing quote so commas inside opaque tags don't split the value
+		start := strings.Index(header, `"`)
+		if start == -1 {
+			break
+		}
+		end := strings.Index(header[start+1:], `"`)
+		if end == -1 {
+			break
+		}
+		end += start + 2
+
+		if tag, ok := parseEntityTag(header[:end]); ok {
+			tags = append(tags, tag)
+		}
+		header = header[end:]
+	}
+	return tags, false
+}
+
+// strongMatch reports whether both tags are strong and have the same opaque value
+func strongMatch(a, b entityTag) bool {
+	return !a.weak && !b.weak && a.opaque == b.opaque
+}
+
+// weakMatch reports whether both tags have the same opaque value, regardless of weakness
+func weakMatch(a, b entityTag) bool {
+	return a.opaque == b.opaque
+}
+
+// ETagOf computes a deterministic strong entity-tag from the JSON representation of v,
+// the one sent by Ctx.JSONWithETag with the default JSONConfig
+func ETagOf(v any) (string, error) {
+	data, err := jsonRepresentation(JSONConfig{}, v)
+	if err != nil {
+		return "", err
+	}
+	return etagOfBytes(data), nil
+}
+
+// jsonRepresentation returns the JSON representation of v sent in the response
+// bodies, whose entity-tags are computed by ETagOf, SetEntityTag and JSONWithETag
+func jsonRepresentation(config JSONConfig, v any) ([]byte, error) {
+	data, err := marshalJSON(config, v)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// etagOfBytes computes a strong entity-tag from the given representation bytes
+func etagOfBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// SetEntityTag computes a deterministic strong entity-tag from the JSON
+// representation of v, the same as JSONWithETag, sets it as the ETag response
+// header and returns it. Use it on GET responses so clients can send it back
+// in If-Match.
+func (c *Ctx) SetEntityTag(v any) (string, error) {
+	data, err := jsonRepresentation(c.config.JSON, v)
+	if err != nil {
+		return "", err
+	}
+	tag := etagOfBytes(data)
+	c.Set("ETag", tag)
+	return tag, nil
+}
+
+// RequireIfMatch enforces optimistic concurrency control for unsafe methods (PUT, PATCH, DELETE)
+// using the If-Match request header, following RFC 9110 section 13.1.1:
+//   - missing header: 428 Precondition Required, unless RouterConfig.IfMatchOptional is set
+//   - "*": matches if the resource exists (currentETag is not empty)
+//   - otherwise the strong comparison is used: weak entity-tags never match
+//
+// Returns a 412 Precondition Failed error if no entity-tag matches currentETag.
+// currentETag is the entity-tag of the current representation, as returned by
+// SetEntityTag or ETagOf, or empty if the resource doesn't exist.
+func (c *Ctx) RequireIfMatch(currentETag string) error {
+	header := c.Get("If-Match")
+	if header == "" {
+		if c.config.IfMatchOptional {
+			return nil
+		}
+		return errors.PreconditionRequired("If-Match header is required", nil)
+	}
+
+	tags, anyTag := parseEntityTags(header)
+	if anyTag {
+		if currentETag == "" {
+			return errors.PreconditionFailed("Resource does not exist", nil)
+		}
+		return nil
+	}
+
+	current, ok := parseEntityTag(currentETag)
+	if ok {
+		for _, tag := range tags {
+			if strongMatch(tag, current) {
+				return nil
+			}
+		}
+	}
+
+	return errors.PreconditionFailed(
+		"Resource has been modified",
+		fmt.Errorf("If-Match %s does not match current entity-tag %s", header, currentETag),
+	)
+}
+
+// DefaultETagCacheControl is the Cache-Control header of the responses sent by
+// Ctx.JSONWithETag, unless RouterConfig.ETagCacheControl is set
+const DefaultETagCacheControl = "private"
+
+// JSONWithETag sends data as JSON with a strong ETag computed from the encoded
+// bytes, marshaling data once. GET and HEAD requests whose If-None-Match header
+// matches the ETag get a 304 Not Modified response without body instead.
+//
+// The ETag is computed from the uncompressed representation; the Compress
+// middleware turns it into a weak one when the response is compressed, which
+// still matches If-None-Match. Cache-Control is set to RouterConfig.ETagCacheControl
+// (default: private), unless the handler already set it.
+//
+// Example:
+//
+//	r.Get("/notifications", func(c *glib.Ctx) error {
+//	    return c.JSONWithETag(notifications.List(c.Context()))
+//	})
+func (c *Ctx) JSONWithETag(data any) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+
+	encoded, err := jsonRepresentation(c.config.JSON, data)
+	if err != nil {
+		return err
+	}
+	tag := etagOfBytes(encoded)
+
+	header := c.Response.Header()
+	header.Set("ETag", tag)
+	if header.Get("Cache-Control") == "" {
+		cacheControl := c.config.ETagCacheControl
+		if cacheControl == "" {
+			cacheControl = DefaultETagCacheControl
+		}
+		header.Set("Cache-Control", cacheControl)
+	}
+
+	if c.notModified(tag) {
+		return c.NotModified()
+	}
+
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(encoded)))
+	c.Response.WriteHeader(c.statusCode)
+	_, err = c.Response.Write(encoded)
+	return err
+}
+
+// notModified reports whether the If-None-Match header of a GET or HEAD request
+// matches the entity-tag, using the weak comparison (RFC 9110 section 13.1.2)
+func (c *Ctx) notModified(etag string) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	header := c.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	tags, anyTag := parseEntityTags(header)
+	if anyTag {
+		return true
+	}
+	current, ok := parseEntityTag(etag)
+	if !ok {
+		return false
+	}
+	for _, tag := range tags {
+		if weakMatch(tag, current) {
+			return true
+		}
+	}
+	return false
+}
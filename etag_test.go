@@ -0,0 +1,240 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/middleware"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEntityTags(t *testing.T) {
+	cases := []struct {
+		desc     string
+		header   string
+		expected []entityTag
+		wildcard bool
+	}{
+		{desc: "wildcard", header: " * ", wildcard: true},
+		{desc: "single strong", header: `"abc"`, expected: []entityTag{{opaque: `"abc"`}}},
+		{desc: "single weak", header: `W/"abc"`, expected: []entityTag{{weak: true, opaque: `"abc"`}}},
+		{desc: "list", header: `"a", W/"b" ,"c"`, expected: []entityTag{{opaque: `"a"`}, {weak: true, opaque: `"b"`}, {opaque: `"c"`}}},
+		{desc: "comma inside tag", header: `"a,b"`, expected: []entityTag{{opaque: `"a,b"`}}},
+		{desc: "unquoted ignored", header: `abc`, expected: nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tags, wildcard := parseEntityTags(tc.header)
+			assert.Equal(t, tc.wildcard, wildcard)
+			assert.Equal(t, tc.expected, tags)
+		})
+	}
+}
+
+func TestEntityTagComparison(t *testing.T) {
+	// RFC 9110 section 8.8.3.2 comparison table
+	cases := []struct {
+		a, b   string
+		strong bool
+		weak   bool
+	}{
+		{a: `W/"1"`, b: `W/"1"`, strong: false, weak: true},
+		{a: `W/"1"`, b: `W/"2"`, strong: false, weak: false},
+		{a: `W/"1"`, b: `"1"`, strong: false, weak: true},
+		{a: `"1"`, b: `"1"`, strong: true, weak: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			a, ok := parseEntityTag(tc.a)
+			require.True(t, ok)
+			b, ok := parseEntityTag(tc.b)
+			require.True(t, ok)
+			assert.Equal(t, tc.strong, strongMatch(a, b))
+			assert.Equal(t, tc.weak, weakMatch(a, b))
+		})
+	}
+}
+
+func TestCtx_RequireIfMatch(t *testing.T) {
+	resource := map[string]any{"id": 1, "name": "Alice"}
+	current, err := ETagOf(resource)
+	require.NoError(t, err)
+
+	cases := []struct {
+		desc     string
+		ifMatch  string
+		current  string
+		optional bool
+		expected int
+	}{
+		{desc: "missing header", ifMatch: "", current: current, expected: http.StatusPreconditionRequired},
+		{desc: "missing header optional", ifMatch: "", current: current, optional: true, expected: http.StatusNoContent},
+		{desc: "matching", ifMatch: current, current: current, expected: http.StatusNoContent},
+		{desc: "matching in list", ifMatch: `"other", ` + current, current: current, expected: http.StatusNoContent},
+		{desc: "mismatch", ifMatch: `"other"`, current: current, expected: http.StatusPreconditionFailed},
+		{desc: "weak never matches", ifMatch: "W/" + current, current: current, expected: http.StatusPreconditionFailed},
+		{desc: "wildcard existing", ifMatch: "*", current: current, expected: http.StatusNoContent},
+		{desc: "wildcard missing resource", ifMatch: "*", current: "", expected: http.StatusPreconditionFailed},
+		{desc: "tag on missing resource", ifMatch: current, current: "", expected: http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			opts := DefaultRouterOptions()
+			opts.IfMatchOptional = tc.optional
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), opts)
+			r.Put("/resource", func(c *Ctx) error {
+				if err := c.RequireIfMatch(tc.current); err != nil {
+					return err
+				}
+				return c.NoContent()
+			})
+
+			req := httptest.NewRequest("PUT", "/resource", nil)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
+
+func TestCtx_SetEntityTag(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/resource", func(c *Ctx) error {
+		data := map[string]any{"name": "Alice", "id": 1}
+		if _, err := c.SetEntityTag(data); err != nil {
+			return err
+		}
+		return c.JSON(data)
+	})
+
+	etags := make([]string, 2)
+	for i := range etags {
+		req := httptest.NewRequest("GET", "/resource", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		etags[i] = w.Header().Get("ETag")
+	}
+
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etags[0])
+	assert.Equal(t, etags[0], etags[1], "entity-tag must be deterministic")
+}
+
+func TestCtx_SetEntityTag_JSONWithETag(t *testing.T) {
+	data := map[string]any{"at": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "total": 1.5}
+
+	for _, config := range []JSONConfig{{}, {TimeFormat: "unix", NumbersAsStrings: true}} {
+		r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), RouterConfig{JSON: config})
+		var tag string
+		r.Get("/entity", func(c *Ctx) error {
+			var err error
+			tag, err = c.SetEntityTag(data)
+			return err
+		})
+		r.Get("/json", func(c *Ctx) error { return c.JSONWithETag(data) })
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/entity", nil))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json", nil))
+		assert.Equal(t, w.Header().Get("ETag"), tag, "%+v", config)
+
+		if config == (JSONConfig{}) {
+			expected, err := ETagOf(data)
+			require.NoError(t, err)
+			assert.Equal(t, expected, tag)
+		}
+	}
+}
+
+func TestCtx_JSONWithETag(t *testing.T) {
+	data := map[string]any{"unread": 3}
+	tag := etagOfBytes([]byte(`{"unread":3}` + "\n"))
+
+	cases := []struct {
+		desc         string
+		method       string
+		ifNoneMatch  string
+		cacheControl string
+		config       RouterConfig
+		expectCode   int
+		expectCache  string
+		expectBody   string
+	}{
+		{desc: "no If-None-Match", method: http.MethodGet, expectCode: http.StatusOK, expectCache: "private", expectBody: `{"unread":3}` + "\n"},
+		{desc: "matching tag", method: http.MethodGet, ifNoneMatch: tag, expectCode: http.StatusNotModified, expectCache: "private"},
+		{desc: "weak matching tag", method: http.MethodGet, ifNoneMatch: `"other", W/` + tag, expectCode: http.StatusNotModified, expectCache: "private"},
+		{desc: "wildcard", method: http.MethodHead, ifNoneMatch: "*", expectCode: http.StatusNotModified, expectCache: "private"},
+		{desc: "stale tag", method: http.MethodGet, ifNoneMatch: `"stale"`, expectCode: http.StatusOK, expectCache: "private", expectBody: `{"unread":3}` + "\n"},
+		{desc: "unsafe method ignores If-None-Match", method: http.MethodPost, ifNoneMatch: tag, expectCode: http.StatusOK, expectCache: "private", expectBody: `{"unread":3}` + "\n"},
+		{desc: "configured Cache-Control", method: http.MethodGet, config: RouterConfig{ETagCacheControl: "public, max-age=60"}, expectCode: http.StatusOK, expectCache: "public, max-age=60", expectBody: `{"unread":3}` + "\n"},
+		{desc: "Cache-Control set by handler", method: http.MethodGet, cacheControl: "no-cache", expectCode: http.StatusOK, expectCache: "no-cache", expectBody: `{"unread":3}` + "\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), tc.config)
+			r.MethodFunc(tc.method, "/notifications", func(c *Ctx) error {
+				if tc.cacheControl != "" {
+					c.Set("Cache-Control", tc.cacheControl)
+				}
+				return c.JSONWithETag(data)
+			})
+
+			req := httptest.NewRequest(tc.method, "/notifications", nil)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectCode, w.Code)
+			assert.Equal(t, tag, w.Header().Get("ETag"))
+			assert.Equal(t, tc.expectCache, w.Header().Get("Cache-Control"))
+			if tc.method != http.MethodHead {
+				assert.Equal(t, tc.expectBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCtx_JSONWithETag_Compress(t *testing.T) {
+	r := setupTestRouter()
+	r.UseHTTP(middleware.Compress(middleware.DefaultCompressConfig()))
+	r.Get("/items", func(c *Ctx) error {
+		return c.JSONWithETag([]string{strings.Repeat("item", 100)})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`), etag)
+
+	// The weak tag of the compressed response revalidates both representations
+	for _, encoding := range []string{"gzip", ""} {
+		req = httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/azizndao/glib"
 	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/middleware"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/locales/es"
 	"github.com/go-playground/locales/fr"
@@ -87,8 +88,11 @@ func main() {
 	// Use custom middleware globally
 	r.Use(loggingMiddleware)
 
+	// Health check endpoint, also enabled in the stack by ENABLE_HEARTBEAT=true
+	r.UseHTTP(middleware.Heartbeat())
+
 	// Use Chi middleware directly
-	r.UseHTTP(chimiddleware.Heartbeat("/ping"))
+	r.UseHTTP(chimiddleware.CleanPath)
 
 	// ====================
 	// BASIC ROUTES - All HTTP Methods
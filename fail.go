@@ -0,0 +1,123 @@
+package glib
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"net/http"
+	"runtime"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrorClassifier maps an error to a response status and public message.
+// It returns ok=false if it doesn't recognize the error so the next
+// classifier of the chain is tried.
+type ErrorClassifier func(err error) (status int, message string, ok bool)
+
+// DefaultErrorClassifiers returns the classifiers used by Ctx.Fail when
+// RouterConfig.ErrorClassifiers is nil:
+//   - validator.ValidationErrors: 422 Unprocessable Entity
+//   - sql.ErrNoRows: 404 Not Found
+//   - context.DeadlineExceeded: 504 Gateway Timeout
+func DefaultErrorClassifiers() []ErrorClassifier {
+	return []ErrorClassifier{
+		ClassifyError[validator.ValidationErrors](http.StatusUnprocessableEntity, "Validation failed"),
+		ClassifyErrorIs(sql.ErrNoRows, http.StatusNotFound, http.StatusText(http.StatusNotFound)),
+		ClassifyErrorIs(context.DeadlineExceeded, http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout)),
+	}
+}
+
+// ClassifyErrorIs returns a classifier matching errors wrapping target (errors.Is)
+func ClassifyErrorIs(target error, status int, message string) ErrorClassifier {
+	return func(err error) (int, string, bool) {
+		if stderrors.Is(err, target) {
+			return status, message, true
+		}
+		return 0, "", false
+	}
+}
+
+// ClassifyError returns a classifier matching errors wrapping an error of type E (errors.As)
+func ClassifyError[E error](status int, message string) ErrorClassifier {
+	return func(err error) (int, string, bool) {
+		var target E
+		if stderrors.As(err, &target) {
+			return status, message, true
+		}
+		return 0, "", false
+	}
+}
+
+// Error logs the internal error with the given attributes and the request
+// context, then returns an ApiError exposing only the public message.
+// 4xx errors are logged at Warn level, 5xx at Error level.
+//
+// Example:
+//
+//	user, err := repo.Find(ctx, id)
+//	if err != nil {
+//	    return c.Error(http.StatusInternalServerError, "Could not load user", err, "user_id", id)
+//	}
+func (c *Ctx) Error(status int, publicMsg string, internal error, attrs ...any) error {
+	return c.logError(3, status, publicMsg, internal, attrs...)
+}
+
+// logError implements Ctx.Error, skip being the number of frames to skip so the
+// log source is the handler calling Ctx.Error or Ctx.Fail
+func (c *Ctx) logError(skip int, status int, publicMsg string, internal error, attrs ...any) error {
+	if internal != nil {
+		attrs = append(attrs,
+			"status", status,
+			"method", c.Method(),
+			"path", c.Path(),
+		)
+		attrs = append(attrs, "request_id", c.CorrelationID())
+
+		var pcs [1]uintptr
+		runtime.Callers(skip, pcs[:])
+
+		if status >= http.StatusInternalServerError {
+			c.logger.ErrorWithSource(c.Context(), pcs[0], errors.NewSkip(internal, skip+1), attrs...)
+		} else {
+			c.logger.WarnWithSource(c.Context(), pcs[0], internal.Error(), attrs...)
+		}
+	}
+
+	return errors.NewApi(status, publicMsg, internal)
+}
+
+// Fail converts err to an ApiError, logging it with Ctx.Error. ApiErrors are
+// returned unchanged, other errors go through the classifier chain
+// (RouterConfig.ErrorClassifiers, DefaultErrorClassifiers if nil) and
+// default to 500 Internal Server Error.
+//
+// Example:
+//
+//	if err := repo.Delete(ctx, id); err != nil {
+//	    return c.Fail(err) // 404 for sql.ErrNoRows, 504 on timeout...
+//	}
+func (c *Ctx) Fail(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *errors.ApiError
+	if stderrors.As(err, &apiErr) && apiErr != nil {
+		return apiErr
+	}
+
+	classifiers := c.config.ErrorClassifiers
+	if classifiers == nil {
+		classifiers = DefaultErrorClassifiers()
+	}
+
+	for _, classify := range classifiers {
+		if status, message, ok := classify(err); ok {
+			return c.logError(3, status, message, err)
+		}
+	}
+
+	return c.logError(3, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), err)
+}
@@ -0,0 +1,109 @@
+package glib
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	stdslog "log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/middleware"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+var errPaymentRequired = stderrors.New("payment required")
+
+func TestCtx_Error(t *testing.T) {
+	cases := []struct {
+		desc     string
+		status   int
+		level    string
+		expected string
+	}{
+		{desc: "client error logged as warning", status: http.StatusConflict, level: `"level":"WARN"`, expected: `{"code":409,"data":"Could not load user"}`},
+		{desc: "server error logged as error", status: http.StatusInternalServerError, level: `"level":"ERROR"`, expected: `{"code":500,"data":"Could not load user","request_id":"req-1"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(stdslog.NewJSONHandler(&logs, &stdslog.HandlerOptions{AddSource: true}))
+			r := Default(logger, validation.New(validation.DefaultValidatorConfig()))
+			r.UseHTTP(middleware.RequestID())
+			r.Get("/users/{id}", func(c *Ctx) error {
+				return c.Error(tc.status, "Could not load user", fmt.Errorf("db: connection refused"), "user_id", 42)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+			req.Header.Set("X-Request-Id", "req-1")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.JSONEq(t, tc.expected, w.Body.String())
+			assert.NotContains(t, w.Body.String(), "connection refused")
+
+			assert.Contains(t, logs.String(), tc.level)
+			assert.Contains(t, logs.String(), `"msg":"db: connection refused"`)
+			assert.Contains(t, logs.String(), `"user_id":42`)
+			assert.Contains(t, logs.String(), `"request_id":"req-1"`)
+			assert.Contains(t, logs.String(), `"path":"/users/42"`)
+			assert.Contains(t, logs.String(), "fail_test.go", "the source is the handler")
+		})
+	}
+}
+
+func TestCtx_Fail(t *testing.T) {
+	config := DefaultRouterOptions()
+	config.ErrorClassifiers = append(
+		[]ErrorClassifier{ClassifyErrorIs(errPaymentRequired, http.StatusPaymentRequired, "Payment required")},
+		DefaultErrorClassifiers()...,
+	)
+
+	cases := []struct {
+		desc   string
+		err    error
+		status int
+	}{
+		{desc: "no rows", err: fmt.Errorf("find user: %w", sql.ErrNoRows), status: http.StatusNotFound},
+		{desc: "deadline", err: context.DeadlineExceeded, status: http.StatusGatewayTimeout},
+		{desc: "custom classifier", err: errPaymentRequired, status: http.StatusPaymentRequired},
+		{desc: "api error unchanged", err: errors.Conflict("Already exists", nil), status: http.StatusConflict},
+		{desc: "unknown", err: stderrors.New("boom"), status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+			r.Get("/", func(c *Ctx) error {
+				return c.Fail(tc.err)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+
+	t.Run("validation errors", func(t *testing.T) {
+		type input struct {
+			Name string `validate:"required"`
+		}
+		r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()))
+		r.Get("/", func(c *Ctx) error {
+			return c.Fail(validator.New().Struct(input{}))
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
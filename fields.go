@@ -0,0 +1,207 @@
+package glib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// fieldTree is a parsed sparse fieldset. An empty subtree selects the whole value.
+type fieldTree map[string]fieldTree
+
+// parseFields builds a field tree from dot-notation field paths
+// ("id", "profile.email"). Selecting a parent selects all its children.
+func parseFields(fields []string) fieldTree {
+	tree := fieldTree{}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		node := tree
+		parts := strings.Split(field, ".")
+		for i, part := range parts {
+			child, exists := node[part]
+			if exists && len(child) == 0 {
+				// Parent already fully selected
+				break
+			}
+			if i == len(parts)-1 {
+				node[part] = fieldTree{}
+				break
+			}
+			if !exists {
+				child = fieldTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// paths returns the full dot-notation paths of the tree leaves
+func (t fieldTree) paths(prefix string, out []string) []string {
+	for key, child := range t {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if len(child) == 0 {
+			out = append(out, path)
+			continue
+		}
+		out = child.paths(path, out)
+	}
+	return out
+}
+
+// filterJSON keeps only the selected fields of the marshaled JSON value.
+// Arrays are filtered element-wise and object key order is preserved.
+// Every matched field path is recorded in the matched set.
+func filterJSON(raw json.RawMessage, tree fieldTree, prefix string, matched map[string]struct{}) (json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return raw, nil
+	}
+
+	switch raw[0] {
+	case '{':
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+
+		buf := bytes.NewBuffer(make([]byte, 0, len(raw)))
+		buf.WriteByte('{')
+		first := true
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := token.(string)
+
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, err
+			}
+
+			subtree, selected := tree[key]
+			if !selected {
+				continue
+			}
+
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			if len(subtree) == 0 {
+				matched[path] = struct{}{}
+			} else if value, err = filterJSON(value, subtree, path, matched); err != nil {
+				return nil, err
+			}
+
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			encodedKey, _ := json.Marshal(key)
+			buf.Write(encodedKey)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+		return buf.Bytes(), nil
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+
+		buf := bytes.NewBuffer(make([]byte, 0, len(raw)))
+		buf.WriteByte('[')
+		for i, item := range items {
+			filtered, err := filterJSON(item, tree, prefix, matched)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(filtered)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	default:
+		// Scalars cannot be filtered further
+		return raw, nil
+	}
+}
+
+// JSONFields sends a JSON response containing only the given fields.
+// Fields use dot-notation for nested objects ("id", "profile.email") and arrays
+// of objects are filtered element-wise. An empty field list sends the whole data.
+//
+// Requested fields that don't exist in the data are ignored, unless
+// RouterConfig.StrictSparseFields is enabled in which case a 400 Bad Request
+// error listing the unknown fields is returned.
+func (c *Ctx) JSONFields(data any, fields []string) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+
+	tree := parseFields(fields)
+	if len(tree) == 0 {
+		return c.JSON(data)
+	}
+
+	raw, err := marshalJSON(c.config.JSON, data)
+	if err != nil {
+		return err
+	}
+
+	matched := make(map[string]struct{})
+	filtered, err := filterJSON(raw, tree, "", matched)
+	if err != nil {
+		return err
+	}
+
+	if c.config.StrictSparseFields {
+		var unknown []string
+		for _, path := range tree.paths("", nil) {
+			if _, ok := matched[path]; !ok {
+				unknown = append(unknown, path)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return errors.BadRequest(
+				map[string]any{"message": "Unknown fields requested", "fields": unknown},
+				fmt.Errorf("unknown sparse fields: %s", strings.Join(unknown, ", ")),
+			)
+		}
+	}
+
+	c.Set("Content-Type", "application/json; charset=utf-8")
+	c.Response.WriteHeader(c.statusCode)
+	filtered = append(filtered, '\n')
+	_, err = c.Response.Write(filtered)
+	return err
+}
+
+// JSONSparse sends a JSON response filtered by the comma-separated `fields`
+// query parameter (e.g. `?fields=id,name,profile.email`).
+// See JSONFields for the filtering rules.
+func (c *Ctx) JSONSparse(data any) error {
+	var fields []string
+	for _, value := range c.QueryAll("fields") {
+		fields = append(fields, strings.Split(value, ",")...)
+	}
+	return c.JSONFields(data, fields)
+}
@@ -0,0 +1,77 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type sparseProfile struct {
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+type sparseUser struct {
+	ID      int           `json:"id"`
+	Name    string        `json:"name"`
+	Profile sparseProfile `json:"profile"`
+	Tags    []string      `json:"tags"`
+}
+
+func TestCtx_JSONSparse(t *testing.T) {
+	users := []sparseUser{
+		{ID: 1, Name: "Alice", Profile: sparseProfile{Email: "alice@example.com", Phone: "1"}, Tags: []string{"a"}},
+		{ID: 2, Name: "Bob", Profile: sparseProfile{Email: "bob@example.com", Phone: "2"}, Tags: []string{"b"}},
+	}
+
+	cases := []struct {
+		desc     string
+		query    string
+		data     any
+		expected string
+	}{
+		{desc: "no fields", query: "", data: users[0], expected: `{"id":1,"name":"Alice","profile":{"email":"alice@example.com","phone":"1"},"tags":["a"]}`},
+		{desc: "top level", query: "?fields=id,name", data: users[0], expected: `{"id":1,"name":"Alice"}`},
+		{desc: "nested", query: "?fields=id,profile.email", data: users[0], expected: `{"id":1,"profile":{"email":"alice@example.com"}}`},
+		{desc: "parent wins", query: "?fields=profile.email,profile", data: users[0], expected: `{"profile":{"email":"alice@example.com","phone":"1"}}`},
+		{desc: "repeated param", query: "?fields=id&fields=tags", data: users[0], expected: `{"id":1,"tags":["a"]}`},
+		{desc: "array element-wise", query: "?fields=name,profile.phone", data: users, expected: `[{"name":"Alice","profile":{"phone":"1"}},{"name":"Bob","profile":{"phone":"2"}}]`},
+		{desc: "unknown ignored", query: "?fields=id,unknown", data: users[0], expected: `{"id":1}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Get("/users", func(c *Ctx) error {
+				return c.JSONSparse(tc.data)
+			})
+
+			req := httptest.NewRequest("GET", "/users"+tc.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.expected+"\n", w.Body.String())
+		})
+	}
+
+	t.Run("strict rejects unknown fields", func(t *testing.T) {
+		opts := DefaultRouterOptions()
+		opts.StrictSparseFields = true
+		r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), opts)
+		r.Get("/users", func(c *Ctx) error {
+			return c.JSONSparse(users)
+		})
+
+		req := httptest.NewRequest("GET", "/users?fields=id,profile.unknown,nope", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"code":400,"data":{"message":"Unknown fields requested","fields":["nope","profile.unknown"]}}`, w.Body.String())
+	})
+}
@@ -0,0 +1,209 @@
+package glib
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// FilterEq is the operator of the filters without one, e.g. filter[status]=active
+const FilterEq = "eq"
+
+// filterParam is the name of the filter query parameters, e.g. filter[status]
+const filterParam = "filter"
+
+// Filters are the filter expressions of a list request, by field and operator.
+// ?filter[status]=active&filter[created_at][gte]=2024-01-01 is parsed into
+//
+//	Filters{"status": {"eq": {"active"}}, "created_at": {"gte": {"2024-01-01"}}}
+//
+// They don't depend on a storage, it's up to the handler to translate them
+// into SQL conditions or to filter items in memory.
+type Filters map[string]map[string][]string
+
+// QueryFilters parses the filter[field] and filter[field][operator] query
+// parameters, the first one having the operator "eq". The allowed map lists
+// the operators accepted for each field, none meaning "eq" only. Operators are
+// free-form names, e.g. eq, ne, gt, gte, lt, lte, in or like. Returns a 400
+// Bad Request error keyed by the offending parameter when it is malformed or
+// its field or operator is not allowed. Empty values are ignored.
+//
+// Example:
+//
+//	filters, err := c.QueryFilters(map[string][]string{
+//	    "status":     {"eq", "in"},
+//	    "created_at": {"gte", "lt"},
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	since, err := filters.Time("created_at", "gte")
+func (c *Ctx) QueryFilters(allowed map[string][]string) (Filters, error) {
+	filters := Filters{}
+	query := c.Queries()
+	// Sorted, so the same parameter is reported for the same query
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		if !strings.HasPrefix(key, filterParam+"[") {
+			continue
+		}
+
+		field, operator, ok := parseFilterKey(key)
+		if !ok {
+			return nil, filterError(key, "invalid filter parameter")
+		}
+		operators, known := allowed[field]
+		if !known {
+			return nil, filterError(key, "unknown filter field")
+		}
+		if len(operators) == 0 {
+			operators = []string{FilterEq}
+		}
+		if !slices.Contains(operators, operator) {
+			return nil, filterError(key, "unsupported operator, expected one of "+strings.Join(operators, ", "))
+		}
+
+		for _, value := range query[key] {
+			if value == "" {
+				continue
+			}
+			if filters[field] == nil {
+				filters[field] = map[string][]string{}
+			}
+			filters[field][operator] = append(filters[field][operator], value)
+		}
+	}
+	return filters, nil
+}
+
+// parseFilterKey splits a filter[field] or filter[field][operator] key
+func parseFilterKey(key string) (field, operator string, ok bool) {
+	rest := strings.TrimPrefix(key, filterParam)
+	var parts []string
+	for rest != "" {
+		if rest[0] != '[' {
+			return "", "", false
+		}
+		end := strings.IndexByte(rest, ']')
+		if end < 2 {
+			return "", "", false
+		}
+		parts = append(parts, rest[1:end])
+		rest = rest[end+1:]
+	}
+
+	switch len(parts) {
+	case 1:
+		return parts[0], FilterEq, true
+	case 2:
+		return parts[0], parts[1], true
+	default:
+		return "", "", false
+	}
+}
+
+// filterError returns a 400 error keyed by the offending parameter
+func filterError(param, message string) error {
+	return errors.BadRequest(map[string]string{param: message}, nil)
+}
+
+// Has reports whether the filter is set
+func (f Filters) Has(field, operator string) bool {
+	return len(f[field][operator]) > 0
+}
+
+// Get returns the value of the filter, "" if not set. Use Strings for the
+// filters with several values.
+func (f Filters) Get(field, operator string) string {
+	if values := f[field][operator]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Strings returns the values of the filter, given as repeated parameters or
+// separated by commas, e.g. filter[status][in]=active,pending
+func (f Filters) Strings(field, operator string) []string {
+	var values []string
+	for _, value := range f[field][operator] {
+		for part := range strings.SplitSeq(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// Int returns the value of the filter as an integer, 0 if not set. Returns a
+// 400 Bad Request error when it is not an integer.
+func (f Filters) Int(field, operator string) (int64, error) {
+	value := f.Get(field, operator)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, f.invalid(field, operator, "an integer", err)
+	}
+	return n, nil
+}
+
+// Float returns the value of the filter as a number, 0 if not set. Returns a
+// 400 Bad Request error when it is not a number.
+func (f Filters) Float(field, operator string) (float64, error) {
+	value := f.Get(field, operator)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, f.invalid(field, operator, "a number", err)
+	}
+	return n, nil
+}
+
+// Bool returns the value of the filter as a boolean, false if not set.
+// Returns a 400 Bad Request error when it is not a boolean.
+func (f Filters) Bool(field, operator string) (bool, error) {
+	value := f.Get(field, operator)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, f.invalid(field, operator, "a boolean", err)
+	}
+	return b, nil
+}
+
+// Time returns the value of the filter as a time, the zero time if not set.
+// Dates (2024-01-01) and RFC 3339 times (2024-01-01T10:00:00Z) are accepted,
+// dates being midnight UTC. Returns a 400 Bad Request error otherwise.
+func (f Filters) Time(field, operator string) (time.Time, error) {
+	value := f.Get(field, operator)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, f.invalid(field, operator, "a date or an RFC 3339 time", err)
+	}
+	return t, nil
+}
+
+// invalid returns the 400 error of a filter value not having the expected type
+func (f Filters) invalid(field, operator, expected string, err error) error {
+	param := fmt.Sprintf("%s[%s]", filterParam, field)
+	if operator != FilterEq {
+		param += "[" + operator + "]"
+	}
+	return errors.BadRequest(map[string]string{param: "must be " + expected}, err)
+}
@@ -0,0 +1,112 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFilters = map[string][]string{
+	"status":     {"eq", "in"},
+	"archived":   nil,
+	"created_at": {"gte", "lt"},
+	"amount":     {"gt"},
+}
+
+func TestCtx_QueryFilters(t *testing.T) {
+	tests := []struct {
+		desc     string
+		query    string
+		expected Filters
+		err      map[string]string
+	}{
+		{desc: "no filters", query: "page=2", expected: Filters{}},
+		{
+			desc:  "operators",
+			query: "filter[status]=active&filter[created_at][gte]=2024-01-01&filter[created_at][lt]=2024-02-01&sort=name",
+			expected: Filters{
+				"status":     {"eq": {"active"}},
+				"created_at": {"gte": {"2024-01-01"}, "lt": {"2024-02-01"}},
+			},
+		},
+		{
+			desc:     "repeated values",
+			query:    "filter[status][in]=active&filter[status][in]=pending",
+			expected: Filters{"status": {"in": {"active", "pending"}}},
+		},
+		{desc: "empty values ignored", query: "filter[status]=&filter[archived]=true", expected: Filters{"archived": {"eq": {"true"}}}},
+		{desc: "unknown field", query: "filter[password]=secret", err: map[string]string{"filter[password]": "unknown filter field"}},
+		{desc: "eq only by default", query: "filter[archived][ne]=true", err: map[string]string{"filter[archived][ne]": "unsupported operator, expected one of eq"}},
+		{desc: "unsupported operator", query: "filter[amount][lt]=5", err: map[string]string{"filter[amount][lt]": "unsupported operator, expected one of gt"}},
+		{desc: "malformed", query: "filter[status=active", err: map[string]string{"filter[status": "invalid filter parameter"}},
+		{desc: "too deep", query: "filter[created_at][gte][x]=1", err: map[string]string{"filter[created_at][gte][x]": "invalid filter parameter"}},
+		{desc: "empty field", query: "filter[]=1", err: map[string]string{"filter[]": "invalid filter parameter"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := newCtx(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders?"+tt.query, nil), nil, nil)
+			filters, err := c.QueryFilters(testFilters)
+
+			if tt.err != nil {
+				var apiErr *errors.ApiError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, http.StatusBadRequest, apiErr.Code)
+				assert.Equal(t, tt.err, apiErr.Data)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, filters)
+		})
+	}
+}
+
+func TestFilters_Accessors(t *testing.T) {
+	filters := Filters{
+		"status":     {"in": {"active, pending", "archived"}},
+		"created_at": {"gte": {"2024-01-01"}, "lt": {"2024-02-01T10:00:00+01:00"}},
+		"amount":     {"gt": {"12.5"}, "eq": {"ten"}},
+		"archived":   {"eq": {"true"}},
+		"count":      {"eq": {"3"}},
+	}
+
+	assert.True(t, filters.Has("status", "in"))
+	assert.False(t, filters.Has("status", "eq"))
+	assert.Equal(t, "active, pending", filters.Get("status", "in"))
+	assert.Equal(t, []string{"active", "pending", "archived"}, filters.Strings("status", "in"))
+
+	since, err := filters.Time("created_at", "gte")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), since)
+	until, err := filters.Time("created_at", "lt")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC), until.UTC())
+	unset, err := filters.Time("updated_at", "gte")
+	require.NoError(t, err)
+	assert.True(t, unset.IsZero())
+
+	amount, err := filters.Float("amount", "gt")
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, amount)
+	count, err := filters.Int("count", "eq")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	archived, err := filters.Bool("archived", "eq")
+	require.NoError(t, err)
+	assert.True(t, archived)
+
+	_, err = filters.Int("amount", "eq")
+	var apiErr *errors.ApiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Code)
+	assert.Equal(t, map[string]string{"filter[amount]": "must be an integer"}, apiErr.Data)
+
+	_, err = filters.Time("status", "in")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, map[string]string{"filter[status][in]": "must be a date or an RFC 3339 time"}, apiErr.Data)
+}
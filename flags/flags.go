@@ -0,0 +1,79 @@
+// Package flags provides feature flags evaluated once per request, see
+// middleware.FeatureFlags, with a static in-memory provider and an interface
+// for feature management services.
+package flags
+
+import (
+	"context"
+	"maps"
+)
+
+// Variants returned for boolean flags
+const (
+	On  = "on"
+	Off = "off"
+)
+
+// Provider evaluates the feature flags for a key identifying the subject of the
+// request (user, tenant or client IP). Implement it to use a feature management
+// service, e.g. with the all-flags evaluation of its SDK.
+type Provider interface {
+	// Evaluate returns the variant of each flag for the key: On or Off for
+	// boolean flags, the variant name for multivariate ones
+	Evaluate(ctx context.Context, key string) (Result, error)
+}
+
+// Result is the variant of each flag evaluated for a request
+type Result map[string]string
+
+// Enabled reports whether the flag is set to a variant other than Off
+func (r Result) Enabled(name string) bool {
+	variant := r[name]
+	return variant != "" && variant != Off
+}
+
+type resultKey struct{}
+type keyKey struct{}
+
+// NewContext returns a copy of ctx carrying the flags evaluated for the request
+func NewContext(ctx context.Context, result Result) context.Context {
+	return context.WithValue(ctx, resultKey{}, result)
+}
+
+// FromContext returns a copy of the flags evaluated for the request, nil when
+// they weren't evaluated
+func FromContext(ctx context.Context) Result {
+	result, _ := ctx.Value(resultKey{}).(Result)
+	return maps.Clone(result)
+}
+
+// Enabled reports whether the flag is enabled for the request. The glib.Ctx
+// of handlers can be passed as ctx:
+//
+//	if flags.Enabled(c, "new-checkout") {
+//	    return newCheckout(c)
+//	}
+func Enabled(ctx context.Context, name string) bool {
+	result, _ := ctx.Value(resultKey{}).(Result)
+	return result.Enabled(name)
+}
+
+// Variant returns the variant of the flag for the request, an empty string
+// when the flag isn't defined
+func Variant(ctx context.Context, name string) string {
+	result, _ := ctx.Value(resultKey{}).(Result)
+	return result[name]
+}
+
+// WithKey returns a copy of ctx carrying the key the flags are evaluated for,
+// e.g. the user or tenant ID set by an authentication middleware running
+// before middleware.FeatureFlags. The client IP is used otherwise.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// KeyFromContext returns the key set with WithKey
+func KeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyKey{}).(string)
+	return key, ok && key != ""
+}
@@ -0,0 +1,131 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/azizndao/glib/util"
+)
+
+// Flag is a flag of the static provider
+type Flag struct {
+	// Rollout is the percentage of keys the flag is enabled for, from 0 to 100.
+	// Keys are hashed with the flag name so each key keeps its variant and
+	// each flag targets a different part of the keys.
+	Rollout float64 `json:"rollout"`
+
+	// Variants are the weights of the variants of a multivariate flag, by
+	// name. The keys the flag is enabled for are split between the variants in
+	// proportion of their weight. Empty for a boolean flag.
+	Variants map[string]float64 `json:"variants,omitempty"`
+}
+
+// Static is a provider of flags defined in memory, with percentage rollouts
+// hashed on the key. It is safe for concurrent use.
+type Static struct {
+	flags map[string]Flag
+}
+
+// NewStatic returns a provider of the given flags by name
+func NewStatic(flags map[string]Flag) *Static {
+	return &Static{flags: flags}
+}
+
+// LoadStatic loads a static provider from environment variables
+// Environment variables:
+//   - FEATURE_FLAGS_FILE (string): JSON file of the flags by name, e.g.
+//     {"new-checkout": {"rollout": 25}, "exp-a": {"rollout": 100, "variants": {"a": 50, "b": 50}}}
+//   - FEATURE_FLAGS (string): comma-separated boolean flags with an optional
+//     rollout percentage (default: 100), e.g. "new-checkout=25,dark-mode".
+//     They override the flags of the file.
+//
+// Returns nil when neither is set
+func LoadStatic() (*Static, error) {
+	path := util.GetEnv("FEATURE_FLAGS_FILE", "")
+	inline := util.GetEnvStringSlice("FEATURE_FLAGS", nil)
+	if path == "" && len(inline) == 0 {
+		return nil, nil
+	}
+
+	flags := make(map[string]Flag)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("flags: %w", err)
+		}
+		if err := json.Unmarshal(data, &flags); err != nil {
+			return nil, fmt.Errorf("flags: invalid file %s: %w", path, err)
+		}
+	}
+
+	for _, entry := range inline {
+		name, value, hasRollout := strings.Cut(entry, "=")
+		flag := Flag{Rollout: 100}
+		if hasRollout {
+			rollout, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("flags: invalid rollout of %s: %q", name, value)
+			}
+			flag.Rollout = rollout
+		}
+		flags[strings.TrimSpace(name)] = flag
+	}
+	return NewStatic(flags), nil
+}
+
+// Evaluate returns the variant of each flag for the key
+func (s *Static) Evaluate(_ context.Context, key string) (Result, error) {
+	result := make(Result, len(s.flags))
+	for name, flag := range s.flags {
+		result[name] = flag.variant(name, key)
+	}
+	return result, nil
+}
+
+// variant returns the variant of the flag for the key
+func (f Flag) variant(name, key string) string {
+	if bucket(name, key) >= f.Rollout {
+		return Off
+	}
+	if len(f.Variants) == 0 {
+		return On
+	}
+
+	names := make([]string, 0, len(f.Variants))
+	var total float64
+	for variant, weight := range f.Variants {
+		if weight > 0 {
+			names = append(names, variant)
+			total += weight
+		}
+	}
+	if len(names) == 0 {
+		return Off
+	}
+	slices.Sort(names)
+
+	// A second hash so the variants are independent from the rollout
+	point := bucket(name+"/variant", key) / 100 * total
+	for _, variant := range names {
+		if point < f.Variants[variant] {
+			return variant
+		}
+		point -= f.Variants[variant]
+	}
+	return names[len(names)-1]
+}
+
+// bucket returns the position of the key for the flag, in [0, 100)
+func bucket(name, key string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return float64(h.Sum32()%10000) / 100
+}
@@ -0,0 +1,122 @@
+package flags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatic_Evaluate(t *testing.T) {
+	provider := NewStatic(map[string]Flag{
+		"disabled":     {Rollout: 0},
+		"enabled":      {Rollout: 100},
+		"new-checkout": {Rollout: 25},
+		"exp-a":        {Rollout: 100, Variants: map[string]float64{"control": 50, "treatment": 50}},
+	})
+
+	counts := map[string]int{}
+	for i := range 10000 {
+		key := "user-" + strconv.Itoa(i)
+		result, err := provider.Evaluate(context.Background(), key)
+		require.NoError(t, err)
+
+		again, err := provider.Evaluate(context.Background(), key)
+		require.NoError(t, err)
+		require.Equal(t, result, again, "the variants of a key are stable")
+
+		assert.Equal(t, Off, result["disabled"])
+		assert.Equal(t, On, result["enabled"])
+		if result.Enabled("new-checkout") {
+			counts["new-checkout"]++
+		}
+		counts[result["exp-a"]]++
+	}
+
+	assert.InDelta(t, 2500, counts["new-checkout"], 250)
+	assert.InDelta(t, 5000, counts["control"], 300)
+	assert.InDelta(t, 5000, counts["treatment"], 300)
+}
+
+func TestContext(t *testing.T) {
+	ctx := NewContext(context.Background(), Result{"a": On, "b": Off, "exp": "treatment"})
+
+	assert.True(t, Enabled(ctx, "a"))
+	assert.False(t, Enabled(ctx, "b"))
+	assert.True(t, Enabled(ctx, "exp"))
+	assert.False(t, Enabled(ctx, "unknown"))
+	assert.Equal(t, "treatment", Variant(ctx, "exp"))
+	assert.Empty(t, Variant(ctx, "unknown"))
+	assert.False(t, Enabled(context.Background(), "a"), "flags not evaluated")
+
+	_, ok := KeyFromContext(ctx)
+	assert.False(t, ok)
+	key, ok := KeyFromContext(WithKey(ctx, "tenant-1"))
+	assert.True(t, ok)
+	assert.Equal(t, "tenant-1", key)
+}
+
+func TestLoadStatic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"dark-mode": {"rollout": 10}, "exp-a": {"rollout": 100, "variants": {"a": 1}}}`), 0o644))
+
+	cases := []struct {
+		desc        string
+		env         map[string]string
+		expected    map[string]Flag
+		expectError string
+	}{
+		{
+			desc: "disabled",
+		},
+		{
+			desc:     "inline",
+			env:      map[string]string{"FEATURE_FLAGS": "new-checkout=25, beta"},
+			expected: map[string]Flag{"new-checkout": {Rollout: 25}, "beta": {Rollout: 100}},
+		},
+		{
+			desc: "file overridden inline",
+			env:  map[string]string{"FEATURE_FLAGS_FILE": path, "FEATURE_FLAGS": "dark-mode"},
+			expected: map[string]Flag{
+				"dark-mode": {Rollout: 100},
+				"exp-a":     {Rollout: 100, Variants: map[string]float64{"a": 1}},
+			},
+		},
+		{
+			desc:        "invalid rollout",
+			env:         map[string]string{"FEATURE_FLAGS": "beta=half"},
+			expectError: `flags: invalid rollout of beta: "half"`,
+		},
+		{
+			desc:        "missing file",
+			env:         map[string]string{"FEATURE_FLAGS_FILE": filepath.Join(t.TempDir(), "missing.json")},
+			expectError: "no such file or directory",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv("FEATURE_FLAGS", "")
+			t.Setenv("FEATURE_FLAGS_FILE", "")
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			provider, err := LoadStatic()
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expected == nil {
+				assert.Nil(t, provider)
+				return
+			}
+			assert.Equal(t, tc.expected, provider.flags)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package glib
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// GateOptions configures the behavior of a closed Gate
+type GateOptions struct {
+	// RetryAfter is the value of the Retry-After header sent with 503 responses (default: 5s)
+	RetryAfter time.Duration
+
+	// Wait makes requests hitting a closed gate block up to this duration for the
+	// gate to open before responding with 503. Zero rejects immediately.
+	Wait time.Duration
+}
+
+// Gate guards routes depending on a heavy initialization (cache warm-up, migrations...).
+// Requests hitting a closed gate receive a 503 Service Unavailable with a Retry-After
+// header, optionally after waiting for the gate to open.
+//
+// Example:
+//
+//	cache := server.Gate("cache")
+//	go func() {
+//	    warmUpCache()
+//	    cache.Open()
+//	}()
+//	r.Gated(cache).Get("/search", searchHandler)
+type Gate struct {
+	name     string
+	options  GateOptions
+	open     chan struct{}
+	once     sync.Once
+	openedAt time.Time
+}
+
+// NewGate creates a new closed gate
+func NewGate(name string, options ...GateOptions) *Gate {
+	opts := GateOptions{RetryAfter: 5 * time.Second}
+	if len(options) > 0 {
+		opts = options[0]
+		if opts.RetryAfter <= 0 {
+			opts.RetryAfter = 5 * time.Second
+		}
+	}
+
+	return &Gate{
+		name:    name,
+		options: opts,
+		open:    make(chan struct{}),
+	}
+}
+
+// Name returns the name of the gate
+func (g *Gate) Name() string {
+	return g.name
+}
+
+// Open opens the gate and releases all waiting requests.
+// Calling Open more than once has no effect.
+func (g *Gate) Open() {
+	g.once.Do(func() {
+		g.openedAt = time.Now()
+		close(g.open)
+	})
+}
+
+// IsOpen reports whether the gate has been opened
+func (g *Gate) IsOpen() bool {
+	select {
+	case <-g.open:
+		return true
+	default:
+		return false
+	}
+}
+
+// OpenedAt returns the time the gate was opened, or the zero time if it is still closed
+func (g *Gate) OpenedAt() time.Time {
+	if !g.IsOpen() {
+		return time.Time{}
+	}
+	return g.openedAt
+}
+
+// Wait blocks until the gate is opened or the context is done
+func (g *Gate) Wait(ctx context.Context) error {
+	select {
+	case <-g.open:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Middleware returns a middleware rejecting requests while the gate is closed
+func (g *Gate) Middleware() Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			if g.IsOpen() {
+				return next(c)
+			}
+
+			if g.options.Wait > 0 {
+				ctx, cancel := context.WithTimeout(c.Context(), g.options.Wait)
+				err := g.Wait(ctx)
+				cancel()
+				if err == nil {
+					return next(c)
+				}
+			}
+
+			c.SetRetryAfter(g.options.RetryAfter)
+			return errors.ServiceUnavailable("Service is warming up", nil)
+		}
+	}
+}
+
+// Gated returns a router whose routes are guarded by the given gate
+func (r *router) Gated(gate *Gate) Router {
+	return r.With(gate.Middleware())
+}
+
+// Gate creates a new closed gate registered on the server so its status is
+// reported by the readiness endpoint
+func (s *Server) Gate(name string, options ...GateOptions) *Gate {
+	gate := NewGate(name, options...)
+
+	s.gatesMu.Lock()
+	s.gates = append(s.gates, gate)
+	s.gatesMu.Unlock()
+
+	return gate
+}
+
+// startupGate returns the gate opened once the server is ready to serve requests
+func (s *Server) startupGate() *Gate {
+	s.startupOnce.Do(func() {
+		s.startup = NewGate("startup", GateOptions{RetryAfter: time.Second})
+	})
+	return s.startup
+}
+
+// MarkReady lets the listeners serve requests. Until then, they are rejected
+// with 503 Service Unavailable and a Retry-After header. Listen calls it once
+// the routes are validated and the listeners bound, unless Config.ManualReady
+// is set. Calling MarkReady more than once has no effect.
+func (s *Server) MarkReady() {
+	s.startupGate().Open()
+}
+
+// WaitReady blocks until the server serves requests (see MarkReady) or the
+// context is done. Use it in tests to wait for a server started in a goroutine.
+//
+//	go server.Listen()
+//	require.NoError(t, server.WaitReady(ctx))
+func (s *Server) WaitReady(ctx context.Context) error {
+	return s.startupGate().Wait(ctx)
+}
+
+// gateStartup wraps the handler of a listener to reject requests until the
+// server is ready, the 503 being rendered like the errors of the router
+func (s *Server) gateStartup(next http.Handler) http.Handler {
+	gate := s.startupGate()
+	r, ok := s.router.(*router)
+	if !ok {
+		return next
+	}
+	unavailable := r.wrapHandler(func(c *Ctx) error {
+		c.SetRetryAfter(gate.options.RetryAfter)
+		return errors.ServiceUnavailable("Service is starting", nil)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if gate.IsOpen() {
+			next.ServeHTTP(w, req)
+			return
+		}
+		unavailable.ServeHTTP(w, req)
+	})
+}
+
+// Ready reports whether all the gates registered on the server are open
+func (s *Server) Ready() bool {
+	s.gatesMu.Lock()
+	defer s.gatesMu.Unlock()
+
+	for _, gate := range s.gates {
+		if !gate.IsOpen() {
+			return false
+		}
+	}
+	return true
+}
+
+// EnableReadiness registers a GET readiness endpoint at the given path reporting
+// the status of each gate registered on the server. It responds with 200 when
+// all gates are open, 503 otherwise.
+//
+// Example response:
+//
+//	{"ready": false, "gates": {"cache": "closed", "db": "open"}}
+func (s *Server) EnableReadiness(path string) {
+	s.router.Get(path, func(c *Ctx) error {
+		s.gatesMu.Lock()
+		gates := make(map[string]string, len(s.gates))
+		ready := true
+		for _, gate := range s.gates {
+			status := "open"
+			if !gate.IsOpen() {
+				status = "closed"
+				ready = false
+			}
+			gates[gate.Name()] = status
+		}
+		s.gatesMu.Unlock()
+
+		if !ready {
+			c.Status(http.StatusServiceUnavailable)
+		}
+		return c.JSON(map[string]any{
+			"ready": ready,
+			"gates": gates,
+		})
+	})
+}
@@ -0,0 +1,170 @@
+package glib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGate(t *testing.T) {
+	t.Run("closed gate rejects with 503", func(t *testing.T) {
+		gate := NewGate("cache", GateOptions{RetryAfter: 30 * time.Second})
+		r := setupTestRouter()
+		r.Gated(gate).Get("/search", func(c *Ctx) error {
+			return c.SendString("ok")
+		})
+		r.Get("/other", func(c *Ctx) error {
+			return c.SendString("ok")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/search", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/other", nil))
+		assert.Equal(t, http.StatusOK, w.Code, "routes outside the gate are not affected")
+
+		gate.Open()
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/search", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("open is idempotent and releases waiters", func(t *testing.T) {
+		gate := NewGate("db")
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, gate.Wait(t.Context()))
+			}()
+		}
+
+		gate.Open()
+		openedAt := gate.OpenedAt()
+		gate.Open()
+		wg.Wait()
+
+		assert.True(t, gate.IsOpen())
+		assert.Equal(t, openedAt, gate.OpenedAt())
+	})
+
+	t.Run("blocking wait", func(t *testing.T) {
+		gate := NewGate("cache", GateOptions{Wait: time.Second})
+		r := setupTestRouter()
+		r.Gated(gate).Get("/search", func(c *Ctx) error {
+			return c.SendString("ok")
+		})
+
+		time.AfterFunc(20*time.Millisecond, gate.Open)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/search", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("blocking wait times out", func(t *testing.T) {
+		gate := NewGate("cache", GateOptions{Wait: 10 * time.Millisecond})
+		r := setupTestRouter()
+		r.Gated(gate).Get("/search", func(c *Ctx) error {
+			return c.SendString("ok")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/search", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestServer_EnableReadiness(t *testing.T) {
+	s := &Server{router: setupTestRouter()}
+	cache := s.Gate("cache")
+	db := s.Gate("db")
+	db.Open()
+	s.EnableReadiness("/ready")
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, map[string]any{"ready": false, "gates": map[string]any{"cache": "closed", "db": "open"}}, resp)
+	assert.False(t, s.Ready())
+
+	cache.Open()
+
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, s.Ready())
+}
+
+func TestServer_StartupGate(t *testing.T) {
+	s := newListenerTestServer(t)
+	s.manualReady = true
+
+	done := make(chan error, 1)
+	go func() { done <- s.Listen() }()
+	defer func() {
+		require.NoError(t, s.Shutdown(t.Context()))
+		<-done
+	}()
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		var err error
+		resp, err = http.Get("http://" + s.Address() + "/")
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+	assert.Equal(t, "Service is starting", body["data"])
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.WaitReady(ctx), context.DeadlineExceeded)
+
+	s.MarkReady()
+	require.NoError(t, s.WaitReady(t.Context()))
+
+	resp, err := http.Get("http://" + s.Address() + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_ReadyOnListen(t *testing.T) {
+	s := newListenerTestServer(t)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Listen() }()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, s.WaitReady(ctx))
+
+	// Listeners are bound once ready
+	resp, err := http.Get("http://" + s.Address() + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, s.Shutdown(t.Context()))
+	assert.NoError(t, <-done)
+}
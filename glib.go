@@ -1,17 +1,22 @@
 package glib
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	gerrors "github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/i18n"
 	"github.com/azizndao/glib/middleware"
 	logger "github.com/azizndao/glib/slog"
 	"github.com/azizndao/glib/util"
@@ -22,8 +27,106 @@ type LocaleConfig = validation.LocaleConfig
 
 var Locale = validation.Locale
 
+var LazyLocale = validation.LazyLocale
+
 type Config struct {
 	Locales []LocaleConfig
+
+	// EnvFiles are the env files loaded into the environment by New, by
+	// decreasing priority: the first value found for a variable wins, and the
+	// variables already set in the environment are never overridden. Missing
+	// files are skipped, and $VAR references are expanded, e.g. .env.$APP_ENV.
+	// Default: util.DefaultEnvFiles() (.env.local, .env.$APP_ENV, .env)
+	EnvFiles []string
+
+	// DisableDotEnv disables the loading of EnvFiles, e.g. when the environment
+	// is managed by the application or in tests
+	DisableDotEnv bool
+
+	// MessageCatalog is a file system containing one JSON or TOML message file per
+	// locale (e.g. en.json, fr.toml), typically an embed.FS. It is used to translate
+	// errors.T markers in API error responses. See the i18n package for the file format.
+	// New panics when the catalog can't be loaded.
+	MessageCatalog fs.FS
+
+	// Logger replaces the logger created from environment variables (IS_DEBUG).
+	// Use it to plug a custom slog.Handler, e.g. slog.MultiHandler.
+	Logger *slog.Logger
+
+	// ErrorReporter receives 5xx errors and recovered panics with the request
+	// metadata. Reports are sent from a background goroutine through a bounded
+	// queue (ERROR_REPORT_QUEUE_SIZE, default: 100), extra reports are dropped.
+	ErrorReporter ErrorReporter
+
+	// Metrics receives the metrics published by the router and the middleware
+	// stack, e.g. to forward them to Prometheus
+	Metrics MetricsCollector
+
+	// Decoders are the request body decoders by media type, see RegisterDecoder
+	Decoders map[string]DecodeFunc
+
+	// ManualReady keeps rejecting requests with 503 after Listen started, until
+	// Server.MarkReady is called. Use it when routes are registered after Listen,
+	// e.g. from another goroutine.
+	ManualReady bool
+
+	// ReusePort binds the listeners with SO_REUSEPORT, so a new version of the
+	// server can listen on the same port before this one is shut down. Only
+	// supported on Linux, macOS and BSD. Also enabled by REUSE_PORT=true.
+	ReusePort bool
+
+	// MaxHeaderBytes is the maximum size of the request line and headers read by
+	// the server, see http.Server.MaxHeaderBytes. Larger requests get a plain
+	// 431 response from net/http; use middleware.HeaderLimit for lower limits
+	// answered with a JSON error naming the header. Also set by
+	// MAX_HEADER_BYTES. Default: http.DefaultMaxHeaderBytes (1 MB).
+	MaxHeaderBytes int
+
+	// BasePath is the path prefix the main listener is served under, e.g. the
+	// path a gateway forwards to the service, see Server.SetBasePath. Also set by
+	// BASE_PATH.
+	BasePath string
+
+	// StrictEnv makes New panic when environment variables have invalid values,
+	// listing all of them with their expected format. Otherwise they are logged as
+	// a warning and replaced by their default. Also enabled by CONFIG_STRICT=true.
+	StrictEnv bool
+
+	// CookiePolicy holds the default attributes of the cookies set by Ctx,
+	// replacing the policy loaded by LoadCookiePolicy from the COOKIE_
+	// variables
+	CookiePolicy *CookiePolicy
+
+	// StackProfile selects the middleware enabled by default in the stack and
+	// the rendering of errors, see middleware.StackProfile. Also set by
+	// STACK_PROFILE.
+	StackProfile middleware.StackProfile
+}
+
+// serverEnv holds the settings of New loaded from environment variables, see
+// .env.example
+type serverEnv struct {
+	Host            string        `env:"HOST" default:"localhost"`
+	Port            int           `env:"PORT" default:"8080"`
+	ReadTimeout     time.Duration `env:"READ_TIMEOUT" default:"10s"`
+	WriteTimeout    time.Duration `env:"WRITE_TIMEOUT" default:"10s"`
+	IdleTimeout     time.Duration `env:"IDLE_TIMEOUT" default:"120s"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"30s"`
+	MaxHeaderBytes  int           `env:"MAX_HEADER_BYTES"`
+	BasePath        string        `env:"BASE_PATH"`
+	ReusePort       bool          `env:"REUSE_PORT"`
+	StrictEnv       bool          `env:"CONFIG_STRICT"`
+
+	Debug                   bool   `env:"IS_DEBUG"`
+	ErrorMediaType          string `env:"ERROR_MEDIA_TYPE" default:"application/json"`
+	JSONErrors              bool   `env:"JSON_ERRORS"` // default: true with the api profile
+	NotFoundMessage         string `env:"NOT_FOUND_MESSAGE"`
+	MethodNotAllowedMessage string `env:"METHOD_NOT_ALLOWED_MESSAGE"`
+	MaxResponseBytes        int64  `env:"MAX_RESPONSE_BYTES"`
+	ServerTiming            bool   `env:"ENABLE_SERVER_TIMING"`
+	JSONTimeFormat          string `env:"JSON_TIME_FORMAT" default:"rfc3339"`
+	JSONNumbersAsStrings    bool   `env:"JSON_NUMBERS_AS_STRINGS"`
+	ErrorReportQueueSize    int    `env:"ERROR_REPORT_QUEUE_SIZE" default:"100"`
 }
 
 // Server represents the main glib HTTP server with integrated middleware and lifecycle management
@@ -33,6 +136,45 @@ type Server struct {
 	logger          *logger.Logger
 	shutdownTimeout time.Duration
 	Validator       *validation.Validator
+
+	gatesMu sync.Mutex
+	gates   []*Gate
+
+	reporter *gerrors.AsyncReporter
+
+	// logWriter writes the log lines in async mode (LOG_ASYNC), nil otherwise
+	logWriter *logger.AsyncWriter
+
+	// middlewares are the names of the middlewares enabled in the stack
+	middlewares []string
+
+	// envFiles are the env files loaded by New
+	envFiles []string
+
+	// routerConfig and stackConfig are used to build the routers of additional listeners
+	routerConfig RouterConfig
+	stackConfig  middleware.StackConfig
+
+	listenersMu sync.Mutex
+	listeners   []*listener
+
+	// basePath is the path prefix stripped from the requests of the main
+	// listener, see SetBasePath
+	basePath string
+
+	// streams are the long-lived connections closed when Shutdown times out
+	streams *streamTracker
+
+	// bound are the listeners of the served addresses, inherited the ones passed by
+	// the parent process of an upgrade (see ListenUpgradeable)
+	bound     []boundListener
+	inherited map[string]net.Listener
+	reusePort bool
+
+	// startup rejects requests until the server is ready, see MarkReady
+	startup     *Gate
+	startupOnce sync.Once
+	manualReady bool
 }
 
 // New creates a new Server with configuration loaded from environment variables
@@ -43,18 +185,33 @@ type Server struct {
 //     Pass validation.LocaleConfig for multi-language validation error messages
 //     Example: New(validation.Locale(fr.New(), fr_translations.RegisterDefaultTranslations))
 func New(config Config) *Server {
-	// Load server settings from env
-	host := util.GetEnv("HOST", "localhost")
-	port := util.GetEnvInt("PORT", 8080)
-	readTimeout := util.GetEnvDuration("READ_TIMEOUT", 10*time.Second)
-	writeTimeout := util.GetEnvDuration("WRITE_TIMEOUT", 10*time.Second)
-	idleTimeout := util.GetEnvDuration("IDLE_TIMEOUT", 120*time.Second)
-	shutdownTimeout := util.GetEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+	// Load the env files before any setting is read
+	var envFiles []string
+	var envFilesErr error
+	if !config.DisableDotEnv {
+		files := config.EnvFiles
+		if files == nil {
+			files = util.DefaultEnvFiles()
+		}
+		envFiles, envFilesErr = util.LoadEnvFiles(files...)
+	}
+
+	// Load server settings from env, the invalid values being reported below
+	profile := cmp.Or(config.StackProfile, middleware.LoadStackProfile())
+	env := serverEnv{
+		JSONErrors:              profile == middleware.ProfileAPI,
+		NotFoundMessage:         DefaultNotFoundMessage,
+		MethodNotAllowedMessage: DefaultMethodNotAllowedMessage,
+	}
+	loadEnv(&env)
 
-	// Create logger from environment configuration
-	logger := logger.Create()
+	// Create logger from environment configuration, unless one is provided
+	logger, logWriter := newLogger(config)
 
 	slog.SetDefault(logger.Logger)
+	if envFilesErr != nil {
+		logger.Warn("Invalid env file lines were ignored", "error", envFilesErr)
+	}
 
 	validatorConfig := validation.Config{
 		Logger:            logger,
@@ -64,34 +221,164 @@ func New(config Config) *Server {
 	}
 	validator := validation.New(validatorConfig)
 
+	routerConfig := DefaultRouterOptions()
+	routerConfig.Debug = env.Debug
+	routerConfig.ErrorMediaType = env.ErrorMediaType
+	routerConfig.JSONErrors = env.JSONErrors
+	routerConfig.NotFoundMessage = env.NotFoundMessage
+	routerConfig.MethodNotAllowedMessage = env.MethodNotAllowedMessage
+	routerConfig.Decoders = config.Decoders
+	routerConfig.MaxResponseBytes = env.MaxResponseBytes
+	routerConfig.ServerTiming = env.ServerTiming
+	routerConfig.Metrics = config.Metrics
+	routerConfig.TrustedProxies = middleware.LoadTrustedProxies()
+	if env.Debug {
+		routerConfig.Trace = LoadTraceConfig()
+	}
+	if config.CookiePolicy != nil {
+		routerConfig.Cookies = *config.CookiePolicy
+	} else {
+		routerConfig.Cookies = LoadCookiePolicy()
+	}
+	routerConfig.streams = &streamTracker{}
+	routerConfig.stats = newRouteStats()
+	routerConfig.JSON = JSONConfig{
+		TimeFormat:       env.JSONTimeFormat,
+		NumbersAsStrings: env.JSONNumbersAsStrings,
+	}
+
+	// Load the message catalog used to translate API errors
+	if config.MessageCatalog != nil {
+		catalog, err := i18n.Load(config.MessageCatalog)
+		if err != nil {
+			panic(fmt.Sprintf("glib: message catalog: %v", err))
+		}
+		for _, locale := range config.Locales {
+			if locale.Locale != nil {
+				catalog.RegisterPlurals(locale.Locale)
+			} else {
+				catalog.RegisterLazyPlurals(locale.Code, locale.Translator)
+			}
+		}
+		routerConfig.MessageCatalog = catalog
+	}
+
+	// Report errors without blocking requests
+	var reporter *gerrors.AsyncReporter
+	if config.ErrorReporter != nil {
+		reporter = gerrors.NewAsyncReporter(config.ErrorReporter, env.ErrorReportQueueSize)
+		routerConfig.ErrorReporter = reporter
+	}
+
 	// Create router with default options
-	r := Default(logger, validator)
+	r := Default(logger, validator, routerConfig)
 
 	// Build and apply middleware stack from environment variables
-	middlewareStack := middleware.Stack(logger.Logger)
-	r.UseHTTP(middlewareStack...)
+	stackConfig := middleware.StackConfig{Logger: logger.Logger, Metrics: config.Metrics, Profile: profile}
+	if logWriter != nil {
+		stackConfig.LogOutput = logWriter
+	}
+	if reporter != nil {
+		stackConfig.ErrorReporter = reporter
+	}
+	stack := middleware.StackEntries(stackConfig)
+	middlewareNames := make([]string, len(stack))
+	for i, entry := range stack {
+		middlewareNames[i] = entry.Name
+		r.UseHTTP(entry.Middleware)
+	}
 
 	// Create HTTP server
-	addr := fmt.Sprintf("%s:%d", host, port)
+	addr := fmt.Sprintf("%s:%d", env.Host, env.Port)
 	httpServer := &http.Server{
-		Addr:         addr,
-		Handler:      r,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
-		IdleTimeout:  idleTimeout,
+		Addr:           addr,
+		Handler:        r,
+		ReadTimeout:    env.ReadTimeout,
+		WriteTimeout:   env.WriteTimeout,
+		IdleTimeout:    env.IdleTimeout,
+		MaxHeaderBytes: cmp.Or(config.MaxHeaderBytes, env.MaxHeaderBytes),
+		ConnContext:    connContext,
 	}
 
 	server := &Server{
 		router:          r,
 		httpServer:      httpServer,
 		logger:          logger,
-		shutdownTimeout: shutdownTimeout,
+		shutdownTimeout: env.ShutdownTimeout,
 		Validator:       validator,
+		reporter:        reporter,
+		logWriter:       logWriter,
+		middlewares:     middlewareNames,
+		routerConfig:    routerConfig,
+		stackConfig:     stackConfig,
+		streams:         routerConfig.streams,
+		envFiles:        envFiles,
+		basePath:        cleanBasePath(cmp.Or(config.BasePath, env.BasePath)),
+		manualReady:     config.ManualReady,
+		reusePort:       config.ReusePort || env.ReusePort,
+	}
+
+	// Report the invalid environment values replaced by their default
+	if err := util.InvalidEnv(); err != nil {
+		if config.StrictEnv || env.StrictEnv {
+			// Flush the log lines written so far, e.g. the invalid env files
+			if logWriter != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), env.ShutdownTimeout)
+				_ = logWriter.Close(ctx)
+				cancel()
+			}
+			panic(fmt.Sprintf("glib: invalid environment values: %v", err))
+		}
+		logger.Warn("Invalid environment values replaced by their default", "error", err)
 	}
 
 	return server
 }
 
+// loadEnv loads the fields of the settings struct tagged with their environment
+// variable (see util.LoadEnv). Invalid values keep their default and are
+// reported by New. It panics on an invalid settings struct, e.g. a field of an
+// unsupported type.
+func loadEnv(settings any) {
+	err := util.LoadEnv("", settings)
+	var envErr *util.EnvError
+	if err != nil && !errors.As(err, &envErr) {
+		panic("glib: " + err.Error())
+	}
+}
+
+// newLogger returns the logger provided in the config or creates one from
+// environment variables, with the writer of its lines in async mode (LOG_ASYNC)
+func newLogger(config Config) (*logger.Logger, *logger.AsyncWriter) {
+	if config.Logger != nil {
+		return logger.CreateWithHandler(config.Logger.Handler()), nil
+	}
+
+	loggerConfig := logger.LoadLoggerConfig()
+	if loggerConfig.Async == nil {
+		return logger.Create(), nil
+	}
+	writer := logger.NewAsyncWriter(os.Stdout, *loggerConfig.Async)
+	return logger.CreateWithHandler(logger.NewHandler(loggerConfig.Debug, writer)), writer
+}
+
+// DroppedErrorReports returns the number of error reports dropped because the reporting queue was full
+func (s *Server) DroppedErrorReports() uint64 {
+	if s.reporter == nil {
+		return 0
+	}
+	return s.reporter.Dropped()
+}
+
+// DroppedLogLines returns the number of log lines dropped because the buffer of
+// the async mode (LOG_ASYNC) was full
+func (s *Server) DroppedLogLines() uint64 {
+	if s.logWriter == nil {
+		return 0
+	}
+	return s.logWriter.Dropped()
+}
+
 // Router returns the underlying router for advanced configuration
 func (s *Server) Router() Router {
 	return s.router
@@ -107,39 +394,64 @@ func (s *Server) Address() string {
 	return s.httpServer.Addr
 }
 
-// Listen starts the HTTP server
-// Returns an error if the server fails to start
+// Listen starts the HTTP server and the additional listeners (see AddListener)
+// Returns an error if a listener fails, once the other ones are shut down
 func (s *Server) Listen() error {
-	s.logger.InfoWithSource(context.Background(), 0, fmt.Sprintf("Starting server on %s", s.httpServer.Addr))
-	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return gerrors.Errorf("server failed to start: %w", err)
-	}
-
-	return nil
+	return s.serve("", "")
 }
 
-// ListenTLS starts the HTTPS server with TLS
+// ListenTLS starts the HTTPS server with TLS and the additional listeners,
+// which serve plain HTTP
 func (s *Server) ListenTLS(certFile, keyFile string) error {
-	s.logger.InfoWithSource(context.Background(), 0, fmt.Sprintf("Starting TLS server on %s", s.httpServer.Addr))
+	return s.serve(certFile, keyFile)
+}
 
-	if err := s.httpServer.ListenAndServeTLS(certFile, keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return gerrors.Errorf("TLS server failed to start: %w", err)
+// validateRoutes checks the routes for conflicts in debug mode (IS_DEBUG=true)
+func (s *Server) validateRoutes() error {
+	if !util.GetEnvBool("IS_DEBUG", false) {
+		return nil
+	}
+	for _, l := range s.allListeners() {
+		if err := l.router.Validate(); err != nil {
+			return gerrors.Errorf("invalid routes: %w", err)
+		}
 	}
-
 	return nil
 }
 
-// Shutdown gracefully shuts down the server without interrupting active connections
+// Shutdown gracefully shuts down the server without interrupting active connections.
+// When ctx expires first, the long-lived connections that don't end by themselves
+// (SSE streams and the connections registered with Ctx.TrackStream) are closed,
+// see ActiveStreams.
 func (s *Server) Shutdown(ctx context.Context) error {
-	s.logger.InfoWithSource(ctx, 0, "Shutting down server")
+	s.logger.InfoContext(ctx, "Shutting down server")
 
-	// Shutdown HTTP server
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		s.logger.ErrorWithSource(ctx, 0, gerrors.Errorf("server shutdown failed: %w", err))
+	// Shutdown HTTP servers, then wait for the hijacked connections they don't track
+	err := s.shutdownListeners(ctx)
+	if err == nil {
+		s.streams.wait(ctx)
+	}
+	if closed := s.streams.closeAll(); closed > 0 {
+		s.logger.WarnContext(ctx, "Closed the streams still open after the shutdown timeout", "streams", closed)
+	}
+	if err != nil {
+		s.logger.ErrorCtx(ctx, gerrors.Errorf("server shutdown failed: %w", err))
 		return err
 	}
 
-	s.logger.InfoWithSource(ctx, 0, "Server stopped")
+	// Flush pending error reports
+	if s.reporter != nil {
+		if err := s.reporter.Close(ctx); err != nil {
+			s.logger.WarnContext(ctx, "Pending error reports were not sent", "dropped", s.reporter.Dropped())
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Server stopped")
+
+	// Flush buffered log lines
+	if s.logWriter != nil {
+		return s.logWriter.Close(ctx)
+	}
 	return nil
 }
 
@@ -161,7 +473,7 @@ func (s *Server) ListenWithGracefulShutdown() error {
 	case err := <-serverErrors:
 		return gerrors.Errorf("server error: %w", err)
 	case sig := <-quit:
-		s.logger.InfoWithSource(context.Background(), 0, "Received shutdown signal",
+		s.logger.InfoContext(context.Background(), "Received shutdown signal",
 			"signal", sig.String(),
 		)
 
@@ -192,7 +504,7 @@ func (s *Server) ListenTLSWithGracefulShutdown(certFile, keyFile string) error {
 	case err := <-serverErrors:
 		return gerrors.Errorf("server error: %w", err)
 	case sig := <-quit:
-		s.logger.InfoWithSource(context.Background(), 0, "Received shutdown signal",
+		s.logger.InfoContext(context.Background(), "Received shutdown signal",
 			"signal", sig.String(),
 		)
 
@@ -0,0 +1,141 @@
+package glib
+
+import (
+	"net/http"
+)
+
+// Response wraps the result of a typed handler to control the status code and
+// the headers of the response. Return it as the response type of a handler:
+//
+//	glib.JSONHandler(func(c *glib.Ctx, req *CreateUser) (*glib.Response[User], error) {
+//	    user := service.Create(req)
+//	    return &glib.Response[User]{Status: http.StatusAccepted, Body: user}, nil
+//	})
+type Response[T any] struct {
+	// Status is the response status code. Default: 201 for POST requests, 200 otherwise
+	Status  int
+	Headers map[string]string
+	// Body is sent as JSON, a nil Body sends the status without body
+	Body *T
+}
+
+// typedResponse is implemented by Response to be detected by the typed handlers
+type typedResponse interface {
+	response() (status int, headers map[string]string, body any)
+}
+
+func (r Response[T]) response() (int, map[string]string, any) {
+	if r.Body == nil {
+		return r.Status, r.Headers, nil
+	}
+	return r.Status, r.Headers, r.Body
+}
+
+// JSONHandler adapts a typed function to a HandleFunc: the request body is
+// decoded and validated into Req (see Ctx.ValidateBody), then the result is sent
+// as JSON with 201 Created for POST requests and 200 OK otherwise. A nil result
+// sends 204 No Content. Return a Response to choose the status and headers.
+//
+// Example:
+//
+//	r.Post("/users", glib.JSONHandler(func(c *glib.Ctx, req *CreateUser) (*User, error) {
+//	    return service.Create(c, req)
+//	}))
+func JSONHandler[Req, Res any](fn func(c *Ctx, req *Req) (*Res, error)) HandleFunc {
+	return typedHandler(func(c *Ctx, req *Req) error { return c.ValidateBody(req) }, fn)
+}
+
+// QueryHandler is like JSONHandler but binds Req from the query parameters
+// (see Ctx.BindQuery) before validating it. Use it for GET endpoints.
+//
+// Example:
+//
+//	r.Get("/users", glib.QueryHandler(func(c *glib.Ctx, req *ListUsers) (*[]User, error) {
+//	    return service.List(c, req)
+//	}))
+func QueryHandler[Req, Res any](fn func(c *Ctx, req *Req) (*Res, error)) HandleFunc {
+	return typedHandler(func(c *Ctx, req *Req) error {
+		if err := c.BindQuery(req); err != nil {
+			return err
+		}
+		return c.Validate(req)
+	}, fn)
+}
+
+// Handler is like JSONHandler but binds Req from the whole request (path and
+// query parameters, headers and body, see Ctx.BindRequest) before validating it.
+//
+// Example:
+//
+//	r.Put("/users/{id}", glib.Handler(func(c *glib.Ctx, req *UpdateUser) (*User, error) {
+//	    return service.Update(c, req.ID, req)
+//	}))
+func Handler[Req, Res any](fn func(c *Ctx, req *Req) (*Res, error)) HandleFunc {
+	return typedHandler(bindRequest[Req], fn)
+}
+
+// NoContentHandler binds Req like Handler, calls fn and sends 204 No Content.
+//
+// Example:
+//
+//	r.Delete("/users/{id}", glib.NoContentHandler(func(c *glib.Ctx, req *UserID) error {
+//	    return service.Delete(c, req.ID)
+//	}))
+func NoContentHandler[Req any](fn func(c *Ctx, req *Req) error) HandleFunc {
+	return func(c *Ctx) error {
+		var req Req
+		if err := bindRequest(c, &req); err != nil {
+			return err
+		}
+		if err := fn(c, &req); err != nil {
+			return err
+		}
+		return c.NoContent()
+	}
+}
+
+func bindRequest[Req any](c *Ctx, req *Req) error {
+	if err := c.BindRequest(req); err != nil {
+		return err
+	}
+	return c.Validate(req)
+}
+
+// typedHandler binds the request, calls fn and writes its result
+func typedHandler[Req, Res any](bind func(c *Ctx, req *Req) error, fn func(c *Ctx, req *Req) (*Res, error)) HandleFunc {
+	return func(c *Ctx) error {
+		var req Req
+		if err := bind(c, &req); err != nil {
+			return err
+		}
+
+		res, err := fn(c, &req)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			return c.NoContent()
+		}
+
+		status := http.StatusOK
+		if c.Method() == http.MethodPost {
+			status = http.StatusCreated
+		}
+
+		var body any = res
+		if wrapped, ok := any(res).(typedResponse); ok {
+			var headers map[string]string
+			var code int
+			code, headers, body = wrapped.response()
+			if code != 0 {
+				status = code
+			}
+			c.SetHeaders(headers)
+		}
+
+		if body == nil {
+			return c.Status(status).End()
+		}
+		return c.Status(status).JSON(body)
+	}
+}
@@ -0,0 +1,98 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type handlerUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type handlerCreateUser struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type handlerGetUser struct {
+	ID     int    `path:"id"`
+	Fields string `query:"fields"`
+}
+
+type handlerSearch struct {
+	Q string `query:"q" validate:"required"`
+}
+
+func TestTypedHandlers(t *testing.T) {
+	r := setupTestRouter()
+	r.Post("/users", JSONHandler(func(c *Ctx, req *handlerCreateUser) (*handlerUser, error) {
+		return &handlerUser{ID: 1, Name: req.Name}, nil
+	}))
+	r.Put("/users", JSONHandler(func(c *Ctx, req *handlerCreateUser) (*Response[handlerUser], error) {
+		return &Response[handlerUser]{
+			Status:  http.StatusAccepted,
+			Headers: map[string]string{"Location": "/users/1"},
+			Body:    &handlerUser{ID: 1, Name: req.Name},
+		}, nil
+	}))
+	r.Get("/search", QueryHandler(func(c *Ctx, req *handlerSearch) (*[]handlerUser, error) {
+		return &[]handlerUser{{ID: 1, Name: req.Q}}, nil
+	}))
+	r.Get("/users/{id}", Handler(func(c *Ctx, req *handlerGetUser) (*handlerUser, error) {
+		if req.ID == 404 {
+			return nil, errors.NotFound("User not found", nil)
+		}
+		return &handlerUser{ID: req.ID, Name: req.Fields}, nil
+	}))
+	r.Get("/empty", Handler(func(c *Ctx, req *struct{}) (*handlerUser, error) {
+		return nil, nil
+	}))
+	r.Delete("/users/{id}", NoContentHandler(func(c *Ctx, req *handlerGetUser) error {
+		assert.Equal(t, 42, req.ID)
+		return nil
+	}))
+
+	cases := []struct {
+		desc     string
+		method   string
+		target   string
+		body     string
+		expected int
+		response string
+		headers  map[string]string
+	}{
+		{desc: "post creates", method: http.MethodPost, target: "/users", body: `{"name":"john"}`, expected: http.StatusCreated, response: `{"id":1,"name":"john"}`},
+		{desc: "validation", method: http.MethodPost, target: "/users", body: `{}`, expected: http.StatusUnprocessableEntity},
+		{desc: "response wrapper", method: http.MethodPut, target: "/users", body: `{"name":"john"}`, expected: http.StatusAccepted, response: `{"id":1,"name":"john"}`, headers: map[string]string{"Location": "/users/1"}},
+		{desc: "query handler", method: http.MethodGet, target: "/search?q=jo", expected: http.StatusOK, response: `[{"id":1,"name":"jo"}]`},
+		{desc: "query validation", method: http.MethodGet, target: "/search", expected: http.StatusUnprocessableEntity},
+		{desc: "composite binder", method: http.MethodGet, target: "/users/42?fields=name", expected: http.StatusOK, response: `{"id":42,"name":"name"}`},
+		{desc: "handler error", method: http.MethodGet, target: "/users/404", expected: http.StatusNotFound},
+		{desc: "nil result", method: http.MethodGet, target: "/empty", expected: http.StatusNoContent},
+		{desc: "no content handler", method: http.MethodDelete, target: "/users/42", expected: http.StatusNoContent},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+			if tc.response != "" {
+				assert.JSONEq(t, tc.response, w.Body.String())
+			}
+			for name, value := range tc.headers {
+				assert.Equal(t, value, w.Header().Get(name))
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package glib
+
+import "net/http"
+
+// headerDefaults is a layer of default response headers. Each router owns a
+// layer whose parent is the layer of the router it was derived from, so headers
+// set on a parent router after sub-routers were created are still inherited.
+type headerDefaults struct {
+	parent *headerDefaults
+	values map[string]string
+}
+
+// child creates a new empty layer inheriting from h
+func (h *headerDefaults) child() *headerDefaults {
+	return &headerDefaults{parent: h}
+}
+
+// set merges the given headers into the layer
+func (h *headerDefaults) set(headers map[string]string) {
+	if h.values == nil {
+		h.values = make(map[string]string, len(headers))
+	}
+	for key, value := range headers {
+		h.values[http.CanonicalHeaderKey(key)] = value
+	}
+}
+
+// resolve merges the default headers of h and its parents into headers.
+// Outer layers are merged first so inner layers override them, and an empty
+// value removes the header inherited from an outer layer.
+func (h *headerDefaults) resolve(headers map[string]string) {
+	if h == nil {
+		return
+	}
+	h.parent.resolve(headers)
+	for key, value := range h.values {
+		headers[key] = value
+	}
+}
+
+// apply writes the resolved default headers into the response headers of rw.
+// It is called by each router running for the request, so that the defaults of
+// an inner router override the ones of the outer routers, but only replaces
+// the headers that are missing or still hold the value of the defaults: the
+// headers set by a middleware are kept.
+func (h *headerDefaults) apply(rw *responseWriter) {
+	resolved := map[string]string{}
+	h.resolve(resolved)
+	if len(resolved) == 0 {
+		return
+	}
+	if rw.defaultHeaders == nil {
+		rw.defaultHeaders = make(map[string]string, len(resolved))
+	}
+
+	header := rw.Header()
+	for key, value := range resolved {
+		if header.Get(key) != rw.defaultHeaders[key] {
+			continue
+		}
+		if value == "" {
+			header.Del(key)
+		} else {
+			header.Set(key, value)
+		}
+		rw.defaultHeaders[key] = value
+	}
+}
+
+// SetDefaultHeaders sets headers added to every response of the routes of this
+// router and its sub-routers. They are set before the handler runs, so handlers
+// can still override them. Sub-routers inherit them and can override a header
+// or remove it by setting an empty value.
+//
+// Example:
+//
+//	r.SetDefaultHeaders(map[string]string{"X-Service": "users-api"})
+//	r.Route("/api", func(api glib.Router) {
+//	    api.SetDefaultHeaders(map[string]string{"Cache-Control": "no-store"})
+//	})
+func (r *router) SetDefaultHeaders(headers map[string]string) {
+	r.headers.set(headers)
+}
+
+// Headers returns an inline-Router adding the given default headers to its routes
+func (r *router) Headers(headers map[string]string) Router {
+	sub := r.derive(r.chi.With())
+	sub.headers.set(headers)
+	return sub
+}
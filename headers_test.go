@@ -0,0 +1,57 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_SetDefaultHeaders(t *testing.T) {
+	r := setupTestRouter()
+	handler := func(c *Ctx) error { return c.SendString("ok") }
+
+	r.Get("/", handler)
+	r.Get("/override", func(c *Ctx) error {
+		c.Set("X-Service", "custom")
+		return c.SendString("ok")
+	})
+	r.Route("/api", func(api Router) {
+		api.SetDefaultHeaders(map[string]string{"Cache-Control": "no-store"})
+		api.Get("/users", handler)
+		api.Headers(map[string]string{"Cache-Control": "", "X-Inline": "1"}).Get("/public", handler)
+	})
+	noStore := func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			c.Set("Cache-Control", "no-store")
+			return next(c)
+		}
+	}
+	r.With(noStore).Headers(map[string]string{"Cache-Control": "public, max-age=60"}).Get("/private", handler)
+	// Set after sub-routers are created, still inherited
+	r.SetDefaultHeaders(map[string]string{"X-Service": "users-api"})
+
+	cases := []struct {
+		desc     string
+		path     string
+		expected map[string]string
+	}{
+		{desc: "root route", path: "/", expected: map[string]string{"X-Service": "users-api", "Cache-Control": ""}},
+		{desc: "handler override", path: "/override", expected: map[string]string{"X-Service": "custom"}},
+		{desc: "inherited and merged", path: "/api/users", expected: map[string]string{"X-Service": "users-api", "Cache-Control": "no-store"}},
+		{desc: "inline removal", path: "/api/public", expected: map[string]string{"X-Service": "users-api", "Cache-Control": "", "X-Inline": "1"}},
+		{desc: "middleware header kept", path: "/private", expected: map[string]string{"Cache-Control": "no-store"}},
+		{desc: "not found", path: "/missing", expected: map[string]string{"X-Service": "users-api"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			for key, value := range tc.expected {
+				assert.Equal(t, value, w.Header().Get(key), key)
+			}
+		})
+	}
+}
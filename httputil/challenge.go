@@ -0,0 +1,127 @@
+package httputil
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Error codes of the Bearer challenges (RFC 6750, section 3.1)
+const (
+	// ErrInvalidRequest is the error of a request missing a parameter or
+	// carrying the token several times, answered with 400
+	ErrInvalidRequest = "invalid_request"
+	// ErrInvalidToken is the error of an expired, revoked or malformed token,
+	// answered with 401
+	ErrInvalidToken = "invalid_token"
+	// ErrInsufficientScope is the error of a token lacking the scope required by
+	// the request, answered with 403
+	ErrInsufficientScope = "insufficient_scope"
+)
+
+// Challenge is an authentication challenge of a WWW-Authenticate header
+// (RFC 9110, section 11.6.1), telling the client how to authenticate
+type Challenge struct {
+	// Scheme is the authentication scheme, e.g. "Basic" or "Bearer"
+	Scheme string
+	// Realm is the protection space, e.g. the name of the API
+	Realm string
+	// Params are the other parameters, e.g. "error" and "error_description" for
+	// Bearer (RFC 6750) or "charset" for Basic (RFC 7617)
+	Params map[string]string
+}
+
+// BearerChallenge returns a Bearer challenge (RFC 6750) for the realm, with the
+// error code (e.g. ErrInvalidToken) and description when code isn't empty
+//
+// Example:
+//
+//	// Bearer realm="api", error="invalid_token", error_description="The access token expired"
+//	httputil.BearerChallenge("api", httputil.ErrInvalidToken, "The access token expired")
+func BearerChallenge(realm, code, description string) Challenge {
+	ch := Challenge{Scheme: "Bearer", Realm: realm}
+	if code != "" {
+		ch.Params = map[string]string{"error": code}
+		if description != "" {
+			ch.Params["error_description"] = description
+		}
+	}
+	return ch
+}
+
+// String formats the challenge as a WWW-Authenticate header value: the scheme
+// followed by the realm, the error parameters of RFC 6750 and the other
+// parameters in alphabetical order. Values are quoted, parameters whose name
+// isn't a token are skipped.
+func (ch Challenge) String() string {
+	var b strings.Builder
+	b.WriteString(ch.Scheme)
+
+	names := make([]string, 0, len(ch.Params))
+	for name := range ch.Params {
+		if isToken(name) && !strings.EqualFold(name, "realm") {
+			names = append(names, name)
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if rank := challengeParamRank(a) - challengeParamRank(b); rank != 0 {
+			return rank
+		}
+		return strings.Compare(a, b)
+	})
+
+	sep := " "
+	if ch.Realm != "" {
+		writeChallengeParam(&b, sep, "realm", ch.Realm)
+		sep = ", "
+	}
+	for _, name := range names {
+		writeChallengeParam(&b, sep, strings.ToLower(name), ch.Params[name])
+		sep = ", "
+	}
+	return b.String()
+}
+
+// challengeParamRank returns the position of a parameter in a challenge, the
+// error parameters of RFC 6750 first
+func challengeParamRank(name string) int {
+	switch strings.ToLower(name) {
+	case "error":
+		return 1
+	case "error_description":
+		return 2
+	case "error_uri":
+		return 3
+	case "scope":
+		return 4
+	}
+	return 5
+}
+
+// writeChallengeParam writes a parameter of a challenge with its value as a
+// quoted string, control characters being dropped
+func writeChallengeParam(b *strings.Builder, sep, name, value string) {
+	b.WriteString(sep)
+	b.WriteString(name)
+	b.WriteString(`="`)
+	for _, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t' || (r >= 0x20 && r != 0x7f):
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
+
+// AddChallenge adds the challenges to the WWW-Authenticate header of h, one
+// header line per challenge
+func AddChallenge(h http.Header, challenges ...Challenge) {
+	for _, ch := range challenges {
+		if isToken(ch.Scheme) {
+			h.Add("WWW-Authenticate", ch.String())
+		}
+	}
+}
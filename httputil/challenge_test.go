@@ -0,0 +1,71 @@
+package httputil
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChallenge_String(t *testing.T) {
+	cases := []struct {
+		desc      string
+		challenge Challenge
+		expected  string
+	}{
+		{desc: "scheme only", challenge: Challenge{Scheme: "Bearer"}, expected: "Bearer"},
+		{desc: "realm", challenge: Challenge{Scheme: "Basic", Realm: "admin"}, expected: `Basic realm="admin"`},
+		{
+			desc:      "basic charset",
+			challenge: Challenge{Scheme: "Basic", Realm: `Admin "area"`, Params: map[string]string{"charset": "UTF-8"}},
+			expected:  `Basic realm="Admin \"area\"", charset="UTF-8"`,
+		},
+		{
+			desc:      "bearer error",
+			challenge: BearerChallenge("api", ErrInvalidToken, "The access token expired"),
+			expected:  `Bearer realm="api", error="invalid_token", error_description="The access token expired"`,
+		},
+		{
+			desc:      "bearer without error",
+			challenge: BearerChallenge("api", "", "ignored"),
+			expected:  `Bearer realm="api"`,
+		},
+		{
+			desc: "parameters order",
+			challenge: Challenge{Scheme: "Bearer", Realm: "api", Params: map[string]string{
+				"scope":             "read write",
+				"b":                 "2",
+				"a":                 "1",
+				"error_description": "Missing scope",
+				"Error":             ErrInsufficientScope,
+			}},
+			expected: `Bearer realm="api", error="insufficient_scope", error_description="Missing scope", scope="read write", a="1", b="2"`,
+		},
+		{
+			desc:      "escaped values",
+			challenge: Challenge{Scheme: "Bearer", Params: map[string]string{"error_description": "bad \\ \"token\"\r\n"}},
+			expected:  `Bearer error_description="bad \\ \"token\""`,
+		},
+		{
+			desc:      "invalid parameter names skipped",
+			challenge: Challenge{Scheme: "Bearer", Realm: "api", Params: map[string]string{"bad name": "x", "realm": "other"}},
+			expected:  `Bearer realm="api"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.challenge.String())
+		})
+	}
+}
+
+func TestAddChallenge(t *testing.T) {
+	h := http.Header{}
+	AddChallenge(h,
+		Challenge{Scheme: "Basic", Realm: "api"},
+		Challenge{Scheme: "bad scheme"},
+		BearerChallenge("api", ErrInvalidToken, ""),
+	)
+	assert.Equal(t, []string{`Basic realm="api"`, `Bearer realm="api", error="invalid_token"`}, h.Values("WWW-Authenticate"))
+}
@@ -0,0 +1,87 @@
+// Package httputil provides helpers to build and parse HTTP header values.
+package httputil
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AddVary adds the given header names to the Vary header of h, without
+// duplicates (case-insensitive) and merging all existing Vary lines into one.
+// Nothing is added if Vary is already "*".
+func AddVary(h http.Header, names ...string) {
+	existing := splitList(h.Values("Vary"))
+	if containsFold(existing, "*") {
+		return
+	}
+
+	var values []string
+	for _, name := range append(existing, names...) {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name == "" || containsFold(values, name) {
+			continue
+		}
+		values = append(values, name)
+	}
+
+	if len(values) > 0 {
+		h.Set("Vary", strings.Join(values, ", "))
+	}
+}
+
+// MergeCacheControl merges the given directives into the Cache-Control header of h.
+// A directive replaces an existing directive with the same name ("max-age=60"
+// replaces "max-age=10"), and "public" and "private" replace each other.
+func MergeCacheControl(h http.Header, directives ...string) {
+	current := splitList(h.Values("Cache-Control"))
+
+	for _, directive := range directives {
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+		name := directiveName(directive)
+
+		kept := current[:0]
+		for _, existing := range current {
+			existingName := directiveName(existing)
+			if existingName == name || (name == "public" && existingName == "private") || (name == "private" && existingName == "public") {
+				continue
+			}
+			kept = append(kept, existing)
+		}
+		current = append(kept, directive)
+	}
+
+	if len(current) > 0 {
+		h.Set("Cache-Control", strings.Join(current, ", "))
+	}
+}
+
+// directiveName returns the lowercase name of a Cache-Control directive
+func directiveName(directive string) string {
+	name, _, _ := strings.Cut(directive, "=")
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// splitList splits comma-separated header values into trimmed, non-empty elements
+func splitList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			if element = strings.TrimSpace(element); element != "" {
+				list = append(list, element)
+			}
+		}
+	}
+	return list
+}
+
+func containsFold(list []string, value string) bool {
+	for _, element := range list {
+		if strings.EqualFold(element, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package httputil
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddVary(t *testing.T) {
+	cases := []struct {
+		desc     string
+		existing []string
+		add      []string
+		expected string
+	}{
+		{desc: "empty", add: []string{"Accept"}, expected: "Accept"},
+		{desc: "append", existing: []string{"Accept"}, add: []string{"accept-language"}, expected: "Accept, Accept-Language"},
+		{desc: "no duplicates", existing: []string{"Accept-Encoding"}, add: []string{"accept-encoding", "Accept"}, expected: "Accept-Encoding, Accept"},
+		{desc: "merge lines", existing: []string{"Accept-Encoding", "Accept-Encoding, Origin"}, expected: "Accept-Encoding, Origin"},
+		{desc: "wildcard", existing: []string{"*"}, add: []string{"Accept"}, expected: "*"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			h := http.Header{}
+			for _, value := range tc.existing {
+				h.Add("Vary", value)
+			}
+			AddVary(h, tc.add...)
+			assert.Equal(t, []string{tc.expected}, h.Values("Vary"))
+		})
+	}
+}
+
+func TestMergeCacheControl(t *testing.T) {
+	cases := []struct {
+		desc     string
+		calls    [][]string
+		expected string
+	}{
+		{desc: "single call", calls: [][]string{{"public", "max-age=60"}}, expected: "public, max-age=60"},
+		{desc: "merge", calls: [][]string{{"public"}, {"max-age=60"}}, expected: "public, max-age=60"},
+		{desc: "replace same directive", calls: [][]string{{"public", "max-age=60"}, {"max-age=300"}}, expected: "public, max-age=300"},
+		{desc: "private replaces public", calls: [][]string{{"public", "max-age=60"}, {"private"}}, expected: "max-age=60, private"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			h := http.Header{}
+			for _, directives := range tc.calls {
+				MergeCacheControl(h, directives...)
+			}
+			assert.Equal(t, tc.expected, h.Get("Cache-Control"))
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package httputil
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Link is a link of a Link header (RFC 8288)
+type Link struct {
+	// URL is the target of the link
+	URL string
+	// Rel is the relation type, e.g. "next" or "monitor"
+	Rel string
+	// Params are the other target attributes, e.g. "title" or "type"
+	Params map[string]string
+}
+
+// relOrder is the position of the pagination relations in a Link header,
+// other relations follow in alphabetical order
+var relOrder = map[string]int{"first": 1, "prev": 2, "next": 3, "last": 4}
+
+// LinkHeader builds a Link header value with a link to base per relation, the
+// query parameters of the relation replacing the ones of base. Pagination
+// relations come first (first, prev, next, last), then the other ones in
+// alphabetical order.
+//
+// Example:
+//
+//	// <https://api.example.com/jobs/42?wait=5>; rel="monitor"
+//	httputil.LinkHeader(jobURL, map[string]url.Values{"monitor": {"wait": {"5"}}})
+func LinkHeader(base *url.URL, rels map[string]url.Values) string {
+	names := make([]string, 0, len(rels))
+	for rel := range rels {
+		names = append(names, rel)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if rank := relRank(a) - relRank(b); rank != 0 {
+			return rank
+		}
+		return strings.Compare(a, b)
+	})
+
+	links := make([]Link, len(names))
+	for i, rel := range names {
+		u := *base
+		query := base.Query()
+		for key, values := range rels[rel] {
+			query[key] = values
+		}
+		u.RawQuery = query.Encode()
+		links[i] = Link{URL: u.String(), Rel: rel}
+	}
+	return FormatLinks(links...)
+}
+
+// relRank returns the position of a relation in a Link header
+func relRank(rel string) int {
+	if rank, ok := relOrder[rel]; ok {
+		return rank
+	}
+	return len(relOrder) + 1
+}
+
+// FormatLinks formats links as a Link header value. URLs are escaped where
+// they aren't valid URI references and attribute values are quoted, so that
+// the header can't be broken by a URL or a title.
+func FormatLinks(links ...Link) string {
+	var b strings.Builder
+	for i, link := range links {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('<')
+		b.WriteString(escapeLinkURL(link.URL))
+		b.WriteByte('>')
+		if link.Rel != "" {
+			writeLinkParam(&b, "rel", link.Rel)
+		}
+		params := make([]string, 0, len(link.Params))
+		for name := range link.Params {
+			params = append(params, name)
+		}
+		slices.Sort(params)
+		for _, name := range params {
+			if isToken(name) && !strings.EqualFold(name, "rel") {
+				writeLinkParam(&b, strings.ToLower(name), link.Params[name])
+			}
+		}
+	}
+	return b.String()
+}
+
+// AddLink adds Link header values to h, merging all the Link lines into one.
+// Links already present with the same URL and relation are not duplicated.
+func AddLink(h http.Header, values ...string) {
+	var links []Link
+	for _, link := range ParseLink(append(h.Values("Link"), values...)...) {
+		if !slices.ContainsFunc(links, func(l Link) bool { return l.URL == link.URL && l.Rel == link.Rel }) {
+			links = append(links, link)
+		}
+	}
+	if len(links) > 0 {
+		h.Set("Link", FormatLinks(links...))
+	}
+}
+
+// ParseLink parses Link header values. Attribute names are lowercased and
+// only the first occurrence of an attribute is kept, per RFC 8288. Malformed
+// links are skipped.
+func ParseLink(values ...string) []Link {
+	var links []Link
+	for _, value := range values {
+		s := value
+		for {
+			s = strings.TrimLeft(s, " \t,")
+			if s == "" || s[0] != '<' {
+				break
+			}
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				break
+			}
+			link := Link{URL: s[1:end]}
+			s = s[end+1:]
+
+			for {
+				s = strings.TrimLeft(s, " \t")
+				if s == "" || s[0] != ';' {
+					break
+				}
+				var name, param string
+				name, param, s = parseLinkParam(s[1:])
+				if name == "" {
+					continue
+				}
+				switch {
+				case name == "rel":
+					if link.Rel == "" {
+						link.Rel = param
+					}
+				default:
+					if link.Params == nil {
+						link.Params = map[string]string{}
+					}
+					if _, ok := link.Params[name]; !ok {
+						link.Params[name] = param
+					}
+				}
+			}
+			links = append(links, link)
+
+			// Skip to the next link
+			if i := strings.IndexByte(s, ','); i >= 0 {
+				s = s[i+1:]
+			} else {
+				break
+			}
+		}
+	}
+	return links
+}
+
+// parseLinkParam parses a `name=value` or `name="value"` link attribute,
+// returning the lowercased name, the unquoted value and the rest of s
+func parseLinkParam(s string) (name, value, rest string) {
+	s = strings.TrimLeft(s, " \t")
+	i := strings.IndexAny(s, "=;,")
+	if i < 0 {
+		return strings.ToLower(strings.TrimSpace(s)), "", ""
+	}
+	name = strings.ToLower(strings.TrimSpace(s[:i]))
+	if s[i] != '=' {
+		return name, "", s[i:]
+	}
+
+	s = strings.TrimLeft(s[i+1:], " \t")
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexAny(s, ";,")
+		if end < 0 {
+			end = len(s)
+		}
+		return name, strings.TrimSpace(s[:end]), s[end:]
+	}
+
+	var b strings.Builder
+	for j := 1; j < len(s); j++ {
+		switch c := s[j]; c {
+		case '\\':
+			if j+1 < len(s) {
+				j++
+				b.WriteByte(s[j])
+			}
+		case '"':
+			return name, b.String(), s[j+1:]
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return name, b.String(), ""
+}
+
+// writeLinkParam writes a link attribute with its value as a quoted string
+func writeLinkParam(b *strings.Builder, name, value string) {
+	b.WriteString("; ")
+	b.WriteString(name)
+	b.WriteString(`="`)
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(value[i])
+	}
+	b.WriteByte('"')
+}
+
+// escapeLinkURL percent-encodes the characters that aren't allowed in a URI
+// reference, such as spaces and angle brackets
+func escapeLinkURL(s string) string {
+	var b strings.Builder
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"<>\^`+"`{|}", c) >= 0 {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isToken reports whether s is a valid token (RFC 9110), e.g. an attribute name
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package httputil
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkHeader(t *testing.T) {
+	base, err := url.Parse("https://api.example.com/items?sort=name&page=2")
+	require.NoError(t, err)
+
+	cases := []struct {
+		desc     string
+		rels     map[string]url.Values
+		expected string
+	}{
+		{
+			desc: "pagination order",
+			rels: map[string]url.Values{
+				"last":  {"page": {"9"}},
+				"next":  {"page": {"3"}},
+				"first": {"page": {"1"}},
+				"prev":  {"page": {"1"}},
+			},
+			expected: `<https://api.example.com/items?page=1&sort=name>; rel="first", ` +
+				`<https://api.example.com/items?page=1&sort=name>; rel="prev", ` +
+				`<https://api.example.com/items?page=3&sort=name>; rel="next", ` +
+				`<https://api.example.com/items?page=9&sort=name>; rel="last"`,
+		},
+		{
+			desc:     "other relations follow",
+			rels:     map[string]url.Values{"monitor": {}, "next": {"cursor": {"a b&c"}}},
+			expected: `<https://api.example.com/items?cursor=a+b%26c&page=2&sort=name>; rel="next", <https://api.example.com/items?page=2&sort=name>; rel="monitor"`,
+		},
+		{
+			desc:     "none",
+			expected: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, LinkHeader(base, tc.rels))
+		})
+	}
+}
+
+func TestFormatLinks(t *testing.T) {
+	cases := []struct {
+		desc     string
+		links    []Link
+		expected string
+	}{
+		{
+			desc:     "attributes are quoted and sorted",
+			links:    []Link{{URL: "/jobs/1", Rel: "monitor", Params: map[string]string{"type": "application/json", "Title": `the "job"`}}},
+			expected: `</jobs/1>; rel="monitor"; title="the \"job\""; type="application/json"`,
+		},
+		{
+			desc:     "URL is escaped",
+			links:    []Link{{URL: "/a b/<c>", Rel: "next"}},
+			expected: `</a%20b/%3Cc%3E>; rel="next"`,
+		},
+		{
+			desc:     "invalid attribute names are skipped",
+			links:    []Link{{URL: "/", Rel: "self", Params: map[string]string{"a b": "x", "rel": "other"}}},
+			expected: `</>; rel="self"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, FormatLinks(tc.links...))
+		})
+	}
+}
+
+func TestParseLink(t *testing.T) {
+	cases := []struct {
+		desc     string
+		values   []string
+		expected []Link
+	}{
+		{
+			desc:   "several links and lines",
+			values: []string{`</a>; rel="first", </b>; rel=next`, `</c>;rel="last"`},
+			expected: []Link{
+				{URL: "/a", Rel: "first"},
+				{URL: "/b", Rel: "next"},
+				{URL: "/c", Rel: "last"},
+			},
+		},
+		{
+			desc:   "quoted values with separators",
+			values: []string{`</jobs/1>; REL="monitor"; title="a, \"b\"; c"; title="ignored", </d>; rel="next"`},
+			expected: []Link{
+				{URL: "/jobs/1", Rel: "monitor", Params: map[string]string{"title": `a, "b"; c`}},
+				{URL: "/d", Rel: "next"},
+			},
+		},
+		{
+			desc:     "malformed",
+			values:   []string{`/a; rel="next"`, `</b; rel="next"`},
+			expected: nil,
+		},
+		{
+			desc:     "round trip",
+			values:   []string{FormatLinks(Link{URL: "/x?q=1,2", Rel: "next", Params: map[string]string{"title": `a\b`}})},
+			expected: []Link{{URL: "/x?q=1,2", Rel: "next", Params: map[string]string{"title": `a\b`}}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseLink(tc.values...))
+		})
+	}
+}
+
+func TestAddLink(t *testing.T) {
+	h := http.Header{}
+	h.Add("Link", `</docs>; rel="help"`)
+	h.Add("Link", `</items?page=2>; rel="next"`)
+
+	AddLink(h, `</items?page=2>; rel="next", </items?page=9>; rel="last"`)
+
+	assert.Equal(t, []string{`</docs>; rel="help", </items?page=2>; rel="next", </items?page=9>; rel="last"`}, h.Values("Link"))
+}
@@ -0,0 +1,77 @@
+package httputil
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the rate limit headers of the IETF draft (draft-ietf-httpapi-ratelimit-headers),
+// sent alongside the X-RateLimit-* headers
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+)
+
+// FormatRetryAfter formats a delay as a Retry-After value in delta-seconds
+// (RFC 9110 section 10.2.3), rounded up so that clients don't retry too early.
+// Negative delays are formatted as "0".
+func FormatRetryAfter(d time.Duration) string {
+	if d <= 0 {
+		return "0"
+	}
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
+
+// FormatRetryAt formats a time as a Retry-After value in the HTTP-date form
+func FormatRetryAt(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
+
+// ParseRetryAfter parses a Retry-After value, in delta-seconds or in the
+// HTTP-date form, into the delay from now, zero for dates in the past.
+// Reports false when the value is missing or invalid.
+//
+// Example:
+//
+//	if delay, ok := httputil.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+//	    time.Sleep(delay)
+//	}
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 || seconds > math.MaxInt64/int64(time.Second) {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(at.Sub(now), 0), true
+}
+
+// RateLimit is the state of the rate limit of a client
+type RateLimit struct {
+	// Limit is the maximum number of requests in the window
+	Limit int
+	// Remaining is the number of requests left in the window
+	Remaining int
+	// Reset is the time left until the window ends
+	Reset time.Duration
+}
+
+// SetRateLimit sets the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers of the IETF draft, the reset being in seconds
+func SetRateLimit(h http.Header, limit RateLimit) {
+	h.Set(RateLimitLimitHeader, strconv.Itoa(limit.Limit))
+	h.Set(RateLimitRemainingHeader, strconv.Itoa(max(limit.Remaining, 0)))
+	h.Set(RateLimitResetHeader, FormatRetryAfter(limit.Reset))
+}
@@ -0,0 +1,66 @@
+package httputil
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRetryAfter(t *testing.T) {
+	cases := []struct {
+		desc     string
+		delay    time.Duration
+		expected string
+	}{
+		{desc: "seconds", delay: 30 * time.Second, expected: "30"},
+		{desc: "rounded up", delay: 1200 * time.Millisecond, expected: "2"},
+		{desc: "zero", expected: "0"},
+		{desc: "negative", delay: -time.Second, expected: "0"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, FormatRetryAfter(tc.delay))
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{desc: "delta seconds", value: "120", expected: 2 * time.Minute, ok: true},
+		{desc: "zero", value: "0", ok: true},
+		{desc: "http date", value: "Sat, 01 Mar 2025 12:05:00 GMT", expected: 5 * time.Minute, ok: true},
+		{desc: "formatted date", value: FormatRetryAt(now.Add(time.Hour).In(time.FixedZone("CET", 3600))), expected: time.Hour, ok: true},
+		{desc: "date in the past", value: "Sat, 01 Mar 2025 11:00:00 GMT", ok: true},
+		{desc: "missing", value: ""},
+		{desc: "negative", value: "-5"},
+		{desc: "fraction", value: "1.5"},
+		{desc: "invalid date", value: "tomorrow"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			delay, ok := ParseRetryAfter(tc.value, now)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, delay)
+		})
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	h := http.Header{}
+	SetRateLimit(h, RateLimit{Limit: 100, Remaining: -1, Reset: 90500 * time.Millisecond})
+
+	assert.Equal(t, "100", h.Get("RateLimit-Limit"))
+	assert.Equal(t, "0", h.Get("RateLimit-Remaining"))
+	assert.Equal(t, "91", h.Get("RateLimit-Reset"))
+}
@@ -0,0 +1,371 @@
+// Package i18n provides a message catalog used to localize API error messages.
+//
+// Catalogs are loaded from a file system containing one JSON or TOML file per
+// locale, named after the locale (e.g. en.json, fr.toml, pt-BR.json). Nested
+// objects are flattened using dot-separated keys, and objects whose keys are
+// all plural categories (zero, one, two, few, many, other) define plural forms:
+//
+//	{
+//	    "user": {
+//	        "not_found": "User {id} not found"
+//	    },
+//	    "cart.items_left": {
+//	        "one": "{count} item left",
+//	        "other": "{count} items left"
+//	    }
+//	}
+//
+// The same catalog in TOML, where tables are objects:
+//
+//	[user]
+//	not_found = "User {id} not found"
+//
+//	["cart.items_left"]
+//	one = "{count} item left"
+//	other = "{count} items left"
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/go-playground/locales"
+)
+
+// DefaultLocale is the locale used when a message is not available in the requested one
+const DefaultLocale = "en"
+
+var pluralCategories = map[string]locales.PluralRule{
+	"zero":  locales.PluralRuleZero,
+	"one":   locales.PluralRuleOne,
+	"two":   locales.PluralRuleTwo,
+	"few":   locales.PluralRuleFew,
+	"many":  locales.PluralRuleMany,
+	"other": locales.PluralRuleOther,
+}
+
+// entry is a single catalog message, either a plain text or a set of plural forms
+type entry struct {
+	text  string
+	forms map[locales.PluralRule]string
+}
+
+// Catalog holds translated messages per locale
+type Catalog struct {
+	messages map[string]map[string]entry
+	plurals  map[string]locales.Translator
+	// lazyPlurals construct the plural rules of a locale on first use
+	lazyPlurals map[string]func() locales.Translator
+	fallback    string
+}
+
+// New creates an empty catalog
+func New() *Catalog {
+	return &Catalog{
+		messages:    make(map[string]map[string]entry),
+		plurals:     make(map[string]locales.Translator),
+		lazyPlurals: make(map[string]func() locales.Translator),
+		fallback:    DefaultLocale,
+	}
+}
+
+// Load creates a catalog from all the JSON and TOML files found in the given
+// file system. Other files are rejected with an error, except the hidden ones
+// (e.g. .gitkeep), so that a catalog in another format isn't silently ignored.
+func Load(fsys fs.FS) (*Catalog, error) {
+	c := New()
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		add := c.AddJSON
+		switch path.Ext(p) {
+		case ".json":
+		case ".toml":
+			add = c.AddTOML
+		default:
+			return errors.Errorf("i18n: unsupported catalog file %q, only JSON and TOML files are supported", p)
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		locale := strings.TrimSuffix(path.Base(p), path.Ext(p))
+		if err := add(locale, data); err != nil {
+			return errors.Errorf("i18n: invalid catalog file %q: %w", p, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New(err)
+	}
+
+	return c, nil
+}
+
+// AddJSON adds the messages of the given JSON document to the locale.
+// Existing keys are overridden.
+func (c *Catalog) AddJSON(locale string, data []byte) error {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return c.add(locale, raw)
+}
+
+// AddTOML adds the messages of the given TOML document to the locale, where
+// tables are the objects of JSON documents. Existing keys are overridden.
+func (c *Catalog) AddTOML(locale string, data []byte) error {
+	raw, err := decodeTOML(data)
+	if err != nil {
+		return err
+	}
+	return c.add(locale, raw)
+}
+
+// add adds the messages of a decoded document to the locale
+func (c *Catalog) add(locale string, raw map[string]any) error {
+	locale = normalizeLocale(locale)
+	messages, ok := c.messages[locale]
+	if !ok {
+		messages = make(map[string]entry)
+		c.messages[locale] = messages
+	}
+
+	return flatten("", raw, messages)
+}
+
+// Add adds a single plain message to the locale
+func (c *Catalog) Add(locale, key, text string) {
+	locale = normalizeLocale(locale)
+	if _, ok := c.messages[locale]; !ok {
+		c.messages[locale] = make(map[string]entry)
+	}
+	c.messages[locale][key] = entry{text: text}
+}
+
+// RegisterPlurals registers the plural rules of the given locale translator.
+// Locales without registered rules use the English rules (one/other).
+func (c *Catalog) RegisterPlurals(translator locales.Translator) {
+	c.plurals[normalizeLocale(translator.Locale())] = translator
+}
+
+// RegisterLazyPlurals registers the plural rules of the locale, whose
+// translator is constructed by load on first use, once
+func (c *Catalog) RegisterLazyPlurals(locale string, load func() locales.Translator) {
+	c.lazyPlurals[normalizeLocale(locale)] = sync.OnceValue(load)
+}
+
+// SetFallback sets the locale used when a message is missing from the requested locale
+func (c *Catalog) SetFallback(locale string) {
+	c.fallback = normalizeLocale(locale)
+}
+
+// Locales returns the sorted list of locales present in the catalog
+func (c *Catalog) Locales() []string {
+	list := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		list = append(list, locale)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Translate returns the message for the given key in the given locale.
+// The lookup tries the exact locale, then its base language (fr-CA → fr), then
+// the fallback locale. Unresolved keys fall back to the key itself.
+//
+// Args are key-value pairs filling `{name}` placeholders. A numeric "count"
+// argument selects the plural form.
+func (c *Catalog) Translate(locale, key string, args ...any) string {
+	locale = normalizeLocale(locale)
+	e, resolvedLocale, ok := c.lookup(locale, key)
+	if !ok {
+		return key
+	}
+
+	params := pairs(args)
+	text := e.text
+	if e.forms != nil {
+		text = e.forms[c.pluralRule(resolvedLocale, params["count"])]
+		if text == "" {
+			text = e.forms[locales.PluralRuleOther]
+		}
+	}
+
+	return interpolate(text, params)
+}
+
+// Message resolves an errors.Message marker
+func (c *Catalog) Message(locale string, m errors.Message) string {
+	return c.Translate(locale, m.Key, m.Args...)
+}
+
+func (c *Catalog) lookup(locale, key string) (entry, string, bool) {
+	candidates := []string{locale}
+	if idx := strings.Index(locale, "-"); idx != -1 {
+		candidates = append(candidates, locale[:idx])
+	}
+	candidates = append(candidates, c.fallback)
+
+	for _, candidate := range candidates {
+		if e, ok := c.messages[candidate][key]; ok {
+			return e, candidate, true
+		}
+	}
+	return entry{}, "", false
+}
+
+func (c *Catalog) pluralRule(locale string, count any) locales.PluralRule {
+	num, ok := toFloat(count)
+	if !ok {
+		return locales.PluralRuleOther
+	}
+
+	translator, ok := c.pluralTranslator(locale)
+	if !ok {
+		if idx := strings.Index(locale, "-"); idx != -1 {
+			translator, ok = c.pluralTranslator(locale[:idx])
+		}
+	}
+	if !ok {
+		if num == 1 {
+			return locales.PluralRuleOne
+		}
+		return locales.PluralRuleOther
+	}
+
+	return translator.CardinalPluralRule(num, 0)
+}
+
+// pluralTranslator returns the translator holding the plural rules of the locale
+func (c *Catalog) pluralTranslator(locale string) (locales.Translator, bool) {
+	if translator, ok := c.plurals[locale]; ok {
+		return translator, true
+	}
+	if load, ok := c.lazyPlurals[locale]; ok {
+		return load(), true
+	}
+	return nil, false
+}
+
+func flatten(prefix string, raw map[string]any, out map[string]entry) error {
+	for key, value := range raw {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case string:
+			out[fullKey] = entry{text: v}
+		case map[string]any:
+			if forms, ok := pluralForms(v); ok {
+				out[fullKey] = entry{forms: forms}
+				continue
+			}
+			if err := flatten(fullKey, v, out); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported value for key %q: expected string or object", fullKey)
+		}
+	}
+	return nil
+}
+
+// pluralForms returns the plural forms if all the keys of the object are plural categories
+func pluralForms(obj map[string]any) (map[locales.PluralRule]string, bool) {
+	if len(obj) == 0 {
+		return nil, false
+	}
+
+	forms := make(map[locales.PluralRule]string, len(obj))
+	for key, value := range obj {
+		rule, ok := pluralCategories[key]
+		if !ok {
+			return nil, false
+		}
+		text, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		forms[rule] = text
+	}
+	return forms, true
+}
+
+func pairs(args []any) map[string]any {
+	params := make(map[string]any, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		if key, ok := args[i].(string); ok {
+			params[key] = args[i+1]
+		}
+	}
+	return params
+}
+
+func interpolate(text string, params map[string]any) string {
+	if len(params) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+
+	replacements := make([]string, 0, len(params)*2)
+	for key, value := range params {
+		replacements = append(replacements, "{"+key+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(text)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
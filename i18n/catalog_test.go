@@ -0,0 +1,123 @@
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/fr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCatalog(t *testing.T) *Catalog {
+	fsys := fstest.MapFS{
+		"en.json": {Data: []byte(`{
+			"user": {"not_found": "User {id} not found"},
+			"cart.items_left": {"one": "{count} item left", "other": "{count} items left"},
+			"only_en": "Only in English"
+		}`)},
+		"locales/fr.json": {Data: []byte(`{
+			"user": {"not_found": "Utilisateur {id} introuvable"},
+			"cart.items_left": {"one": "{count} article restant", "other": "{count} articles restants"}
+		}`)},
+		".gitkeep":  {},
+		".git/HEAD": {Data: []byte("ignored")},
+	}
+
+	c, err := Load(fsys)
+	require.NoError(t, err)
+	return c
+}
+
+func TestCatalog(t *testing.T) {
+	c := testCatalog(t)
+
+	t.Run("locales", func(t *testing.T) {
+		assert.Equal(t, []string{"en", "fr"}, c.Locales())
+	})
+
+	t.Run("translate", func(t *testing.T) {
+		cases := []struct {
+			desc     string
+			locale   string
+			key      string
+			args     []any
+			expected string
+		}{
+			{desc: "nested_key", locale: "en", key: "user.not_found", args: []any{"id", 5}, expected: "User 5 not found"},
+			{desc: "other_locale", locale: "fr", key: "user.not_found", args: []any{"id", 5}, expected: "Utilisateur 5 introuvable"},
+			{desc: "region_falls_back_to_language", locale: "fr-CA", key: "user.not_found", args: []any{"id", 5}, expected: "Utilisateur 5 introuvable"},
+			{desc: "missing_falls_back_to_default_locale", locale: "fr", key: "only_en", expected: "Only in English"},
+			{desc: "unknown_locale", locale: "de", key: "only_en", expected: "Only in English"},
+			{desc: "unknown_key", locale: "fr", key: "unknown.key", expected: "unknown.key"},
+			{desc: "plural_one", locale: "en", key: "cart.items_left", args: []any{"count", 1}, expected: "1 item left"},
+			{desc: "plural_other", locale: "en", key: "cart.items_left", args: []any{"count", 3}, expected: "3 items left"},
+			{desc: "plural_without_count", locale: "en", key: "cart.items_left", expected: "{count} items left"},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.desc, func(t *testing.T) {
+				assert.Equal(t, tc.expected, c.Translate(tc.locale, tc.key, tc.args...))
+			})
+		}
+	})
+
+	t.Run("registered_plural_rules", func(t *testing.T) {
+		// French uses the singular form for 0, English doesn't
+		assert.Equal(t, "0 articles restants", c.Translate("fr", "cart.items_left", "count", 0))
+		c.RegisterPlurals(fr.New())
+		assert.Equal(t, "0 article restant", c.Translate("fr", "cart.items_left", "count", 0))
+	})
+
+	t.Run("lazy_plural_rules", func(t *testing.T) {
+		c := New()
+		loads := 0
+		c.RegisterLazyPlurals("fr_CA", func() locales.Translator {
+			loads++
+			return fr.New()
+		})
+		assert.Equal(t, 0, loads)
+		assert.Equal(t, locales.PluralRuleOne, c.pluralRule("fr-ca", 0))
+		assert.Equal(t, locales.PluralRuleOther, c.pluralRule("fr-ca", 2))
+		assert.Equal(t, 1, loads)
+	})
+
+	t.Run("message", func(t *testing.T) {
+		assert.Equal(t, "User 7 not found", c.Message("en", errors.T("user.not_found", "id", 7)))
+	})
+
+	t.Run("invalid_file", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"en.json": {Data: []byte(`{"key": 1}`)}})
+		assert.Error(t, err)
+	})
+
+	t.Run("toml_file", func(t *testing.T) {
+		c, err := Load(fstest.MapFS{
+			"en.json": {Data: []byte(`{"only_en": "Only in English"}`)},
+			"pt_BR.toml": {Data: []byte(`
+				[user]
+				not_found = "Usuário {id} não encontrado"
+
+				["cart.items_left"]
+				one = "{count} item restante"
+				other = "{count} itens restantes"
+			`)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"en", "pt-br"}, c.Locales())
+		assert.Equal(t, "Usuário 5 não encontrado", c.Translate("pt-BR", "user.not_found", "id", 5))
+		assert.Equal(t, "3 itens restantes", c.Translate("pt-BR", "cart.items_left", "count", 3))
+	})
+
+	t.Run("invalid_toml_file", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"fr.toml": {Data: []byte(`key = 1`)}})
+		assert.ErrorContains(t, err, `invalid catalog file "fr.toml"`)
+	})
+
+	t.Run("unsupported_file", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"fr.yaml": {Data: []byte(`key: valeur`)}})
+		assert.ErrorContains(t, err, `unsupported catalog file "fr.yaml"`)
+	})
+}
@@ -0,0 +1,401 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// decodeTOML decodes the TOML document of a catalog into nested maps. Only the
+// subset used by catalogs is supported: tables, dotted keys, the four kinds of
+// strings and inline tables. Other values (numbers, booleans, arrays...) aren't
+// messages and are rejected, like in JSON catalogs.
+func decodeTOML(data []byte) (map[string]any, error) {
+	p := &tomlParser{src: string(data), line: 1}
+	root := map[string]any{}
+	if err := p.parse(root); err != nil {
+		return nil, fmt.Errorf("toml: line %d: %w", p.line, err)
+	}
+	return root, nil
+}
+
+// tomlParser reads a TOML document, tracking the line for the error messages
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) parse(root map[string]any) error {
+	table := root
+	headers := map[string]bool{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.src) {
+			return nil
+		}
+
+		if p.src[p.pos] == '[' {
+			if strings.HasPrefix(p.src[p.pos:], "[[") {
+				return fmt.Errorf("arrays of tables are not supported")
+			}
+			p.pos++
+			path, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+			if !p.consume(']') {
+				return fmt.Errorf("expected ] after table name")
+			}
+			name := strings.Join(path, ".")
+			if headers[name] {
+				return fmt.Errorf("table %q defined twice", name)
+			}
+			headers[name] = true
+			if table, err = subTable(root, path); err != nil {
+				return err
+			}
+		} else {
+			path, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+			if !p.consume('=') {
+				return fmt.Errorf("expected = after key %q", strings.Join(path, "."))
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			if err := setKey(table, path, value); err != nil {
+				return err
+			}
+		}
+
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// subTable returns the table at path, creating the missing ones
+func subTable(table map[string]any, path []string) (map[string]any, error) {
+	for i, key := range path {
+		value, ok := table[key]
+		if !ok {
+			value = map[string]any{}
+			table[key] = value
+		}
+		next, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("key %q is not a table", strings.Join(path[:i+1], "."))
+		}
+		table = next
+	}
+	return table, nil
+}
+
+// setKey sets the value of a dotted key, which must not be defined yet
+func setKey(table map[string]any, path []string, value any) error {
+	table, err := subTable(table, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	key := path[len(path)-1]
+	if _, ok := table[key]; ok {
+		return fmt.Errorf("key %q defined twice", strings.Join(path, "."))
+	}
+	table[key] = value
+	return nil
+}
+
+// skipBlank skips the whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// skipSpace skips the whitespace within a line
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if end := strings.IndexByte(p.src[p.pos:], '\n'); end >= 0 {
+		p.pos += end
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+// consume skips the whitespace and c, reporting whether c was found
+func (p *tomlParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// endOfLine checks that nothing but a comment follows a key or a table
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '#' {
+		p.skipComment()
+	}
+	if p.pos < len(p.src) && p.src[p.pos] != '\n' && !strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		return fmt.Errorf("unexpected %q after value", p.src[p.pos:p.pos+1])
+	}
+	return nil
+}
+
+// parseKey parses a dotted key made of bare and quoted keys
+func (p *tomlParser) parseKey() ([]string, error) {
+	var path []string
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, fmt.Errorf("expected a key")
+		}
+
+		var key string
+		var err error
+		switch p.src[p.pos] {
+		case '"':
+			key, err = p.parseBasicString()
+		case '\'':
+			key, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for p.pos < len(p.src) && isBareKeyChar(p.src[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("expected a key, got %q", p.src[p.pos:p.pos+1])
+			}
+			key = p.src[start:p.pos]
+		}
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, key)
+
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != '.' {
+			return path, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseValue parses a string or an inline table
+func (p *tomlParser) parseValue() (any, error) {
+	p.skipSpace()
+	rest := p.src[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		return p.parseMultilineString(`"""`)
+	case strings.HasPrefix(rest, "'''"):
+		return p.parseMultilineString("'''")
+	case strings.HasPrefix(rest, `"`):
+		return p.parseBasicString()
+	case strings.HasPrefix(rest, "'"):
+		return p.parseLiteralString()
+	case strings.HasPrefix(rest, "{"):
+		return p.parseInlineTable()
+	default:
+		return nil, fmt.Errorf("unsupported value, expected a string or an inline table")
+	}
+}
+
+// parseInlineTable parses an inline table, e.g. { one = "...", other = "..." }
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	p.pos++
+	table := map[string]any{}
+	if p.consume('}') {
+		return table, nil
+	}
+	for {
+		path, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume('=') {
+			return nil, fmt.Errorf("expected = after key %q", strings.Join(path, "."))
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := setKey(table, path, value); err != nil {
+			return nil, err
+		}
+		if p.consume('}') {
+			return table, nil
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("expected , or } in inline table")
+		}
+	}
+}
+
+// parseLiteralString parses a 'literal string', without escapes
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// parseBasicString parses a "basic string" with escapes
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\n':
+			return "", fmt.Errorf("unterminated string")
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// parseMultilineString parses a multi-line basic or literal string, delimited
+// by three quotes. A newline following the opening delimiter is trimmed.
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.pos += len(delim)
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.pos += 2
+		p.line++
+	} else if strings.HasPrefix(p.src[p.pos:], "\n") {
+		p.pos++
+		p.line++
+	}
+
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		if strings.HasPrefix(p.src[p.pos:], delim) {
+			// up to two quotes can precede the closing delimiter
+			end := p.pos + len(delim)
+			for i := 0; i < 2 && end < len(p.src) && p.src[end] == delim[0]; i++ {
+				b.WriteByte(delim[0])
+				end++
+			}
+			p.pos = end
+			return b.String(), nil
+		}
+
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && delim == `"""`:
+			if p.trimLineEnding() {
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// trimLineEnding skips a line ending backslash with the whitespace and newlines
+// following it, reporting whether there was one
+func (p *tomlParser) trimLineEnding() bool {
+	i := p.pos + 1
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t' || p.src[i] == '\r') {
+		i++
+	}
+	if i >= len(p.src) || p.src[i] != '\n' {
+		return false
+	}
+	p.pos = i
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		if p.src[p.pos] == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+	return true
+}
+
+// parseEscape parses the escape sequence at the current position
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	if p.pos+1 >= len(p.src) {
+		return fmt.Errorf("unterminated string")
+	}
+	c := p.src[p.pos+1]
+	p.pos += 2
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return fmt.Errorf("invalid escape \\%c", c)
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf("invalid escape \\%c%s", c, p.src[p.pos:p.pos+size])
+		}
+		b.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return fmt.Errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
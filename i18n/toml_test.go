@@ -0,0 +1,69 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected map[string]any
+		err      string
+	}{
+		{
+			desc:     "keys_and_comments",
+			input:    "# messages\ntitle = \"Hello\" # greeting\n\n'quoted key' = 'literal \\n'\n",
+			expected: map[string]any{"title": "Hello", "quoted key": `literal \n`},
+		},
+		{
+			desc:     "tables_and_dotted_keys",
+			input:    "[user]\nnot_found = \"User {id} not found\"\n\n[user.errors]\nauth.denied = \"Denied\"\n",
+			expected: map[string]any{"user": map[string]any{"not_found": "User {id} not found", "errors": map[string]any{"auth": map[string]any{"denied": "Denied"}}}},
+		},
+		{
+			desc:     "quoted_table_name",
+			input:    "[\"cart.items_left\"]\r\none = \"{count} item left\"\r\nother = \"{count} items left\"\r\n",
+			expected: map[string]any{"cart.items_left": map[string]any{"one": "{count} item left", "other": "{count} items left"}},
+		},
+		{
+			desc:     "inline_table",
+			input:    `items = { one = "{count} item", other = "{count} items" }`,
+			expected: map[string]any{"items": map[string]any{"one": "{count} item", "other": "{count} items"}},
+		},
+		{
+			desc:     "escapes",
+			input:    `text = "tab\t quote\" slash\\ \u00e9\U0001F600"`,
+			expected: map[string]any{"text": "tab\t quote\" slash\\ é😀"},
+		},
+		{
+			desc:     "multiline_strings",
+			input:    "basic = \"\"\"\nfirst \\\n    second\nthird\"\"\"\nliteral = '''\nraw \\n\n'''\nquoted = \"\"\"say \"hi\"\"\"\"\n",
+			expected: map[string]any{"basic": "first second\nthird", "literal": "raw \\n\n", "quoted": `say "hi"`},
+		},
+		{desc: "number", input: "title = 1", err: "toml: line 1: unsupported value"},
+		{desc: "duplicate_key", input: "a = \"x\"\na = \"y\"", err: `toml: line 2: key "a" defined twice`},
+		{desc: "duplicate_table", input: "[a]\n[a]", err: `toml: line 2: table "a" defined twice`},
+		{desc: "key_redefined_as_table", input: "a = \"x\"\n[a]", err: `toml: line 2: key "a" is not a table`},
+		{desc: "array_of_tables", input: "[[a]]", err: "arrays of tables are not supported"},
+		{desc: "unterminated_string", input: "a = \"x\nb = \"y\"", err: "toml: line 1: unterminated string"},
+		{desc: "invalid_escape", input: `a = "\x"`, err: `invalid escape \x`},
+		{desc: "trailing_characters", input: `a = "x" b`, err: `unexpected "b" after value`},
+		{desc: "missing_equal", input: `a "x"`, err: `expected = after key "a"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			doc, err := decodeTOML([]byte(tc.input))
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, doc)
+		})
+	}
+}
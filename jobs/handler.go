@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+// StatusHandler serves the status of the jobs of the manager, with the job ID
+// in the {id} path parameter. It answers 200 OK with the Job, including its
+// result once it succeeded or its error once it failed, and 404 Not Found for
+// unknown or expired jobs. Responses are not cacheable.
+//
+// Example:
+//
+//	r.Handle("/jobs/{id}", jobs.StatusHandler(manager))
+func StatusHandler(manager *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		job, err := manager.Get(r.Context(), chi.URLParam(r, "id"))
+		switch {
+		case stderrors.Is(err, ErrNotFound):
+			writeJSON(w, http.StatusNotFound, errors.NotFound("Job not found", nil))
+		case err != nil:
+			manager.logger.ErrorContext(r.Context(), "Failed to get job", "error", err)
+			writeJSON(w, http.StatusInternalServerError, errors.InternalServerError(http.StatusText(http.StatusInternalServerError), err))
+		default:
+			writeJSON(w, http.StatusOK, job)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
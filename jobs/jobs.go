@@ -0,0 +1,238 @@
+// Package jobs runs long operations in the background, exposed to clients with
+// the 202 Accepted and polling pattern: the handler starting a job answers
+// with Ctx.AcceptedJob, and clients poll the status endpoint served by
+// StatusHandler until the job is done.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// Status is the state of a job
+type Status string
+
+const (
+	// Pending jobs are not started yet
+	Pending Status = "pending"
+	// Running jobs are in progress
+	Running Status = "running"
+	// Succeeded jobs are done, with a result
+	Succeeded Status = "succeeded"
+	// Failed jobs are done, with an error
+	Failed Status = "failed"
+)
+
+// Done reports whether the job with this status is finished
+func (s Status) Done() bool {
+	return s == Succeeded || s == Failed
+}
+
+// Job is the state of a background job, as returned by the status endpoint.
+// The result is stored as JSON so that jobs can be kept by persistent stores.
+type Job struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	// Progress is the completion of the job, from 0 to 1
+	Progress float64 `json:"progress"`
+	// Result is the JSON value returned by the job when it succeeded
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error is the error of the job when it failed. Errors other than API
+	// errors are reported as a 500 without their message.
+	Error *errors.ApiError `json:"error,omitempty"`
+	// URL is the path of the status endpoint of the job
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Func is the function of a job. It reports its progress, from 0 to 1, with
+// progress, and returns a result marshaled as JSON.
+type Func func(ctx context.Context, progress func(float64)) (any, error)
+
+// Config holds the configuration of a Manager
+type Config struct {
+	// Store keeps the state of the jobs. Default: NewMemoryStore()
+	Store Store
+
+	// TTL is how long a job is kept after it was last updated. Default: 24h
+	TTL time.Duration
+
+	// StatusPath is the path the StatusHandler is mounted at, the jobs status
+	// URLs being StatusPath/{id}. Default: /jobs
+	StatusPath string
+
+	// Logger logs the jobs that failed to be saved or panicked (default: slog.Default())
+	Logger *slog.Logger
+}
+
+// DefaultConfig returns the default configuration of a Manager
+func DefaultConfig() Config {
+	return Config{
+		TTL:        24 * time.Hour,
+		StatusPath: "/jobs",
+	}
+}
+
+// Manager runs jobs and tracks their state in a store
+type Manager struct {
+	store      Store
+	ttl        time.Duration
+	statusPath string
+	logger     *slog.Logger
+	now        func() time.Time
+	wg         sync.WaitGroup
+}
+
+// NewManager creates a job manager
+//
+// Example:
+//
+//	manager := jobs.NewManager()
+//	r.Handle("/jobs/{id}", jobs.StatusHandler(manager))
+//	r.Post("/exports", func(c *glib.Ctx) error {
+//	    job, err := manager.Start(c.Context(), func(ctx context.Context, progress func(float64)) (any, error) {
+//	        return export(ctx, progress)
+//	    })
+//	    if err != nil {
+//	        return err
+//	    }
+//	    return c.AcceptedJob(job)
+//	})
+func NewManager(config ...Config) *Manager {
+	cfg := DefaultConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultConfig().TTL
+	}
+	if cfg.StatusPath == "" {
+		cfg.StatusPath = DefaultConfig().StatusPath
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &Manager{
+		store:      cfg.Store,
+		ttl:        cfg.TTL,
+		statusPath: cfg.StatusPath,
+		logger:     cfg.Logger,
+		now:        time.Now,
+	}
+}
+
+// Start saves a pending job and runs fn in the background. The job isn't
+// canceled with ctx, typically the context of the request starting it, but
+// keeps its values.
+func (m *Manager) Start(ctx context.Context, fn Func) (*Job, error) {
+	now := m.now()
+	job := &Job{
+		ID:        rand.Text(),
+		Status:    Pending,
+		CreatedAt: now,
+	}
+	job.URL = path.Join(m.statusPath, job.ID)
+	if err := m.save(ctx, job); err != nil {
+		return nil, fmt.Errorf("jobs: failed to save job: %w", err)
+	}
+
+	started := *job
+	m.wg.Go(func() {
+		m.run(context.WithoutCancel(ctx), &started, fn)
+	})
+	return job, nil
+}
+
+// Get returns the job with the given ID, or ErrNotFound when it doesn't
+// exist or expired
+func (m *Manager) Get(ctx context.Context, id string) (*Job, error) {
+	return m.store.Get(ctx, id)
+}
+
+// Wait waits for the jobs started by the manager to finish, e.g. on shutdown
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// run runs the job and saves its state as it progresses
+func (m *Manager) run(ctx context.Context, job *Job, fn Func) {
+	var mu sync.Mutex
+	update := func(change func(job *Job)) {
+		mu.Lock()
+		defer mu.Unlock()
+		change(job)
+		if err := m.save(ctx, job); err != nil {
+			m.logger.ErrorContext(ctx, "Failed to save job", "job_id", job.ID, "status", job.Status, "error", err)
+		}
+	}
+
+	update(func(job *Job) { job.Status = Running })
+	result, err := safeRun(ctx, fn, func(progress float64) {
+		update(func(job *Job) {
+			if !job.Status.Done() {
+				job.Progress = min(max(progress, 0), 1)
+			}
+		})
+	})
+
+	var encoded []byte
+	if err == nil {
+		encoded, err = json.Marshal(result)
+	}
+	if err != nil {
+		m.logger.WarnContext(ctx, "Job failed", "job_id", job.ID, "error", err)
+	}
+
+	update(func(job *Job) {
+		if err != nil {
+			job.Status = Failed
+			job.Error = jobError(err)
+			return
+		}
+		job.Status, job.Progress, job.Result = Succeeded, 1, encoded
+	})
+}
+
+// save stores the job, refreshing its expiration
+func (m *Manager) save(ctx context.Context, job *Job) error {
+	job.UpdatedAt = m.now()
+	job.ExpiresAt = job.UpdatedAt.Add(m.ttl)
+	return m.store.Save(ctx, job, m.ttl)
+}
+
+// safeRun calls the job function, turning panics into errors as there is no
+// request to fail
+func safeRun(ctx context.Context, fn Func, progress func(float64)) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobs: job panicked: %v", r)
+		}
+	}()
+	return fn(ctx, progress)
+}
+
+// jobError returns the error reported to clients for the error of a job:
+// API errors as is, a 500 hiding the message otherwise
+func jobError(err error) *errors.ApiError {
+	var apiErr *errors.ApiError
+	if stderrors.As(err, &apiErr) && apiErr != nil {
+		return apiErr
+	}
+	return errors.InternalServerError(http.StatusText(http.StatusInternalServerError), err)
+}
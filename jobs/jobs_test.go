@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Start(t *testing.T) {
+	tests := []struct {
+		desc     string
+		fn       Func
+		status   Status
+		result   string
+		errCode  int
+		errData  any
+		progress float64
+	}{
+		{
+			desc: "succeeded",
+			fn: func(ctx context.Context, progress func(float64)) (any, error) {
+				progress(0.5)
+				return map[string]int{"rows": 42}, nil
+			},
+			status:   Succeeded,
+			result:   `{"rows":42}`,
+			progress: 1,
+		},
+		{
+			desc: "failed with an API error",
+			fn: func(ctx context.Context, progress func(float64)) (any, error) {
+				progress(0.25)
+				return nil, errors.UnprocessableEntity("Invalid export range", nil)
+			},
+			status:   Failed,
+			errCode:  http.StatusUnprocessableEntity,
+			errData:  "Invalid export range",
+			progress: 0.25,
+		},
+		{
+			desc: "failed with another error",
+			fn: func(ctx context.Context, progress func(float64)) (any, error) {
+				return nil, stderrors.New("connection refused")
+			},
+			status:  Failed,
+			errCode: http.StatusInternalServerError,
+			errData: "Internal Server Error",
+		},
+		{
+			desc: "panicked",
+			fn: func(ctx context.Context, progress func(float64)) (any, error) {
+				panic("boom")
+			},
+			status:  Failed,
+			errCode: http.StatusInternalServerError,
+			errData: "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			manager := NewManager()
+			ctx, cancel := context.WithCancel(t.Context())
+			job, err := manager.Start(ctx, tt.fn)
+			require.NoError(t, err)
+			cancel() // the job outlives the request
+
+			assert.Equal(t, Pending, job.Status)
+			assert.Equal(t, "/jobs/"+job.ID, job.URL)
+
+			manager.Wait()
+			done, err := manager.Get(t.Context(), job.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, done.Status)
+			assert.Equal(t, tt.progress, done.Progress)
+			if tt.result != "" {
+				assert.JSONEq(t, tt.result, string(done.Result))
+			}
+			if tt.errCode != 0 {
+				require.NotNil(t, done.Error)
+				assert.Equal(t, tt.errCode, done.Error.Code)
+				assert.Equal(t, tt.errData, done.Error.Data)
+			} else {
+				assert.Nil(t, done.Error)
+			}
+		})
+	}
+}
+
+func TestManager_TTL(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	store := NewMemoryStore()
+	store.now = clock
+	manager := NewManager(Config{Store: store, TTL: time.Hour, StatusPath: "/api/jobs"})
+	manager.now = clock
+
+	job, err := manager.Start(t.Context(), func(ctx context.Context, progress func(float64)) (any, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	manager.Wait()
+	assert.Equal(t, "/api/jobs/"+job.ID, job.URL)
+
+	now = now.Add(59 * time.Minute)
+	_, err = manager.Get(t.Context(), job.ID)
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = manager.Get(t.Context(), job.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStatusHandler(t *testing.T) {
+	manager := NewManager()
+	release := make(chan struct{})
+	job, err := manager.Start(t.Context(), func(ctx context.Context, progress func(float64)) (any, error) {
+		progress(0.5)
+		<-release
+		return []string{"a"}, nil
+	})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Handle("/jobs/{id}", StatusHandler(manager))
+	get := func(path string) (int, Job) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		var body Job
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	require.Eventually(t, func() bool {
+		_, body := get(job.URL)
+		return body.Progress == 0.5
+	}, time.Second, time.Millisecond)
+	code, body := get(job.URL)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, Running, body.Status)
+	assert.Empty(t, body.Result)
+
+	close(release)
+	manager.Wait()
+	code, body = get(job.URL)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, Succeeded, body.Status)
+	assert.JSONEq(t, `["a"]`, string(body.Result))
+
+	code, _ = get("/jobs/unknown")
+	assert.Equal(t, http.StatusNotFound, code)
+}
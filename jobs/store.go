@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by stores for jobs that don't exist or expired
+var ErrNotFound = errors.New("jobs: job not found")
+
+// Store keeps the state of jobs. Implement it with a database or Redis so that
+// the jobs survive restarts and are shared by the instances of the server: a
+// job is a JSON document, saved with its TTL on each update.
+type Store interface {
+	// Save creates or replaces the job, expiring after ttl
+	Save(ctx context.Context, job *Job, ttl time.Duration) error
+
+	// Get returns a copy of the job, or ErrNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+}
+
+// MemoryStore is an in-memory Store, for a single instance. Jobs are lost on
+// restart.
+type MemoryStore struct {
+	mu        sync.Mutex
+	jobs      map[string]Job
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an in-memory job store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs: make(map[string]Job),
+		now:  time.Now,
+	}
+}
+
+// Save implements Store. Expired jobs are removed at most once a minute.
+func (s *MemoryStore) Save(_ context.Context, job *Job, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= time.Minute {
+		s.lastSweep = now
+		for id, existing := range s.jobs {
+			if !now.Before(existing.ExpiresAt) {
+				delete(s.jobs, id)
+			}
+		}
+	}
+
+	stored := *job
+	stored.ExpiresAt = now.Add(ttl)
+	s.jobs[job.ID] = stored
+	return nil
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !s.now().Before(job.ExpiresAt) {
+		delete(s.jobs, id)
+		return nil, ErrNotFound
+	}
+	return &job, nil
+}
@@ -0,0 +1,672 @@
+package glib
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time formats for JSONConfig.TimeFormat. Any other value is used as a time layout
+// (e.g. time.DateTime).
+const (
+	// TimeFormatRFC3339 encodes times as RFC 3339 strings, like encoding/json (default)
+	TimeFormatRFC3339 = "rfc3339"
+	// TimeFormatUnix encodes times as epoch seconds
+	TimeFormatUnix = "unix"
+	// TimeFormatUnixMilli encodes times as epoch milliseconds
+	TimeFormatUnixMilli = "unixmilli"
+)
+
+// JSONConfig holds the encoding conventions applied by Ctx.JSON to all responses
+// and accepted by Ctx.ParseBody, so structs don't need custom types to follow the
+// API contract.
+type JSONConfig struct {
+	// TimeFormat is the encoding of time.Time values: TimeFormatRFC3339 (default),
+	// TimeFormatUnix, TimeFormatUnixMilli or a custom time layout.
+	TimeFormat string
+
+	// NumbersAsStrings encodes float32 and float64 values, and the fields tagged
+	// with the "decimal" json option (`json:"price,decimal"`), as JSON strings.
+	NumbersAsStrings bool
+}
+
+// isDefault reports whether the config leaves the encoding/json behavior unchanged
+func (j JSONConfig) isDefault() bool {
+	return j.timeDefault() && !j.NumbersAsStrings
+}
+
+func (j JSONConfig) timeDefault() bool {
+	return j.TimeFormat == "" || j.TimeFormat == TimeFormatRFC3339
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+	// jsonCodecs caches a codec per JSONConfig
+	jsonCodecs sync.Map
+	// jsonFieldsCache caches the encoded fields of struct types
+	jsonFieldsCache sync.Map
+)
+
+// marshalJSON encodes v following the JSON conventions of the config
+func marshalJSON(config JSONConfig, v any) ([]byte, error) {
+	if config.isDefault() {
+		return json.Marshal(v)
+	}
+
+	var buf bytes.Buffer
+	if err := codecFor(config).encode(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalJSON decodes data into out, accepting the JSON conventions of the config
+// for time.Time and float fields. Values using the encoding/json conventions are
+// still accepted.
+func unmarshalJSON(config JSONConfig, data []byte, out any) error {
+	codec := codecFor(config)
+	t := reflect.TypeOf(out)
+	if config.isDefault() || t == nil || !codec.needsConversion(t) {
+		return json.Unmarshal(data, out)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid data after top-level value")
+	}
+
+	generic, err := codec.convertDecoded(t, generic)
+	if err != nil {
+		return err
+	}
+	if data, err = json.Marshal(generic); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// jsonEncodeFunc writes the JSON encoding of v to buf
+type jsonEncodeFunc func(buf *bytes.Buffer, v reflect.Value) error
+
+// jsonCodec encodes and decodes values following a JSONConfig, caching the
+// encoder of each type
+type jsonCodec struct {
+	config   JSONConfig
+	encoders sync.Map // reflect.Type -> jsonEncodeFunc
+	converts sync.Map // reflect.Type -> bool
+}
+
+func codecFor(config JSONConfig) *jsonCodec {
+	if codec, ok := jsonCodecs.Load(config); ok {
+		return codec.(*jsonCodec)
+	}
+	codec, _ := jsonCodecs.LoadOrStore(config, &jsonCodec{config: config})
+	return codec.(*jsonCodec)
+}
+
+func (c *jsonCodec) encode(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	return c.encoderFor(v.Type())(buf, v)
+}
+
+func (c *jsonCodec) encoderFor(t reflect.Type) jsonEncodeFunc {
+	if enc, ok := c.encoders.Load(t); ok {
+		return enc.(jsonEncodeFunc)
+	}
+	enc := c.newEncoder(t)
+	c.encoders.Store(t, enc)
+	return enc
+}
+
+func (c *jsonCodec) newEncoder(t reflect.Type) jsonEncodeFunc {
+	if !c.needsConversion(t) {
+		return encodeStdJSON
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == timeType {
+			return c.encodeTime
+		}
+		return c.newStructEncoder(t)
+	case reflect.Float32, reflect.Float64:
+		return encodeQuotedJSON
+	case reflect.Pointer:
+		return c.encodePointer
+	case reflect.Interface:
+		return c.encodeInterface
+	case reflect.Slice, reflect.Array:
+		return c.encodeArray
+	case reflect.Map:
+		return c.encodeMap
+	default:
+		return encodeStdJSON
+	}
+}
+
+// needsConversion reports whether values of type t may be encoded differently
+// than encoding/json would. Other types are delegated to encoding/json.
+func (c *jsonCodec) needsConversion(t reflect.Type) bool {
+	if needs, ok := c.converts.Load(t); ok {
+		return needs.(bool)
+	}
+	needs := c.typeNeedsConversion(t, make(map[reflect.Type]bool))
+	c.converts.Store(t, needs)
+	return needs
+}
+
+func (c *jsonCodec) typeNeedsConversion(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if t == timeType {
+		return !c.config.timeDefault()
+	}
+	if visiting[t] {
+		// Recursive types are decided by their other fields
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if t.Elem() != timeType && t.Implements(marshalerType) {
+			return false
+		}
+		return c.typeNeedsConversion(t.Elem(), visiting)
+	case reflect.Interface:
+		// The dynamic value may need a conversion
+		return true
+	}
+
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return c.config.NumbersAsStrings
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return c.typeNeedsConversion(t.Elem(), visiting)
+	case reflect.Struct:
+		for _, field := range cachedJSONFields(t) {
+			if (field.decimal && c.config.NumbersAsStrings) || c.typeNeedsConversion(field.typ, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *jsonCodec) encodeTime(buf *bytes.Buffer, v reflect.Value) error {
+	t := v.Interface().(time.Time)
+
+	switch c.config.TimeFormat {
+	case TimeFormatUnix:
+		buf.WriteString(strconv.FormatInt(t.Unix(), 10))
+	case TimeFormatUnixMilli:
+		buf.WriteString(strconv.FormatInt(t.UnixMilli(), 10))
+	default:
+		data, err := json.Marshal(t.Format(c.config.TimeFormat))
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+func (c *jsonCodec) encodePointer(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+	return c.encode(buf, v.Elem())
+}
+
+func (c *jsonCodec) encodeInterface(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+	return c.encode(buf, v.Elem())
+}
+
+func (c *jsonCodec) encodeArray(buf *bytes.Buffer, v reflect.Value) error {
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	buf.WriteByte('[')
+	for i := range v.Len() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := c.encode(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func (c *jsonCodec) encodeMap(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := jsonMapKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	// Sort keys like encoding/json
+	slices.SortFunc(entries, func(a, b entry) int { return strings.Compare(a.key, b.key) })
+
+	buf.WriteByte('{')
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := c.encode(buf, e.value); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// jsonMapKey resolves the JSON object key of a map key, like encoding/json
+func jsonMapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: k.Type()}
+}
+
+func (c *jsonCodec) newStructEncoder(t reflect.Type) jsonEncodeFunc {
+	fields := cachedJSONFields(t)
+
+	return func(buf *bytes.Buffer, v reflect.Value) error {
+		buf.WriteByte('{')
+		first := true
+		for _, field := range fields {
+			fv, ok := jsonFieldByIndex(v, field.index)
+			if !ok || (field.omitEmpty && isEmptyJSONValue(fv)) || (field.omitZero && isZeroJSONValue(fv)) {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			buf.Write(field.key)
+
+			start := buf.Len()
+			if err := c.encode(buf, fv); err != nil {
+				return err
+			}
+			if field.quoted || (field.decimal && c.config.NumbersAsStrings) {
+				quoteJSONScalar(buf, start, fv.Kind() == reflect.String && field.quoted)
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+}
+
+// quoteJSONScalar quotes the number or boolean encoded in buf from start.
+// When force is true, the value is quoted whatever it is (",string" option on strings).
+func quoteJSONScalar(buf *bytes.Buffer, start int, force bool) {
+	encoded := buf.Bytes()[start:]
+	if len(encoded) == 0 {
+		return
+	}
+	if !force && encoded[0] != '-' && (encoded[0] < '0' || encoded[0] > '9') && encoded[0] != 't' && encoded[0] != 'f' {
+		return
+	}
+
+	quoted, _ := json.Marshal(string(encoded))
+	buf.Truncate(start)
+	buf.Write(quoted)
+}
+
+// encodeStdJSON encodes v with encoding/json
+func encodeStdJSON(buf *bytes.Buffer, v reflect.Value) error {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// encodeQuotedJSON encodes v with encoding/json as a JSON string
+func encodeQuotedJSON(buf *bytes.Buffer, v reflect.Value) error {
+	start := buf.Len()
+	if err := encodeStdJSON(buf, v); err != nil {
+		return err
+	}
+	quoteJSONScalar(buf, start, false)
+	return nil
+}
+
+// convertDecoded converts a value decoded as `any` (with json.Number) so that it
+// can be decoded into type t by encoding/json
+func (c *jsonCodec) convertDecoded(t reflect.Type, g any) (any, error) {
+	if g == nil || !c.needsConversion(t) {
+		return g, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return c.convertDecoded(t.Elem(), g)
+	case reflect.Float32, reflect.Float64:
+		if s, ok := g.(string); ok {
+			if _, err := strconv.ParseFloat(s, t.Bits()); err != nil {
+				return nil, fmt.Errorf("invalid number %q", s)
+			}
+			return json.Number(s), nil
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := g.([]any); ok {
+			for i, item := range items {
+				converted, err := c.convertDecoded(t.Elem(), item)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = converted
+			}
+		}
+	case reflect.Map:
+		if m, ok := g.(map[string]any); ok {
+			for key, value := range m {
+				converted, err := c.convertDecoded(t.Elem(), value)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = converted
+			}
+		}
+	case reflect.Struct:
+		if t == timeType {
+			return c.convertDecodedTime(g)
+		}
+		if m, ok := g.(map[string]any); ok {
+			for _, field := range cachedJSONFields(t) {
+				if field.quoted {
+					continue
+				}
+				key, ok := jsonObjectKey(m, field.name)
+				if !ok {
+					continue
+				}
+				converted, err := c.convertDecoded(field.typ, m[key])
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %w", field.name, err)
+				}
+				m[key] = converted
+			}
+		}
+	}
+	return g, nil
+}
+
+// convertDecodedTime converts a time following the configured format to RFC 3339
+func (c *jsonCodec) convertDecodedTime(g any) (any, error) {
+	switch c.config.TimeFormat {
+	case TimeFormatUnix, TimeFormatUnixMilli:
+		n, ok := g.(json.Number)
+		if !ok {
+			return g, nil
+		}
+		epoch, err := n.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %s", n)
+		}
+		if c.config.TimeFormat == TimeFormatUnix {
+			return time.Unix(epoch, 0).UTC().Format(time.RFC3339Nano), nil
+		}
+		return time.UnixMilli(epoch).UTC().Format(time.RFC3339Nano), nil
+	default:
+		s, ok := g.(string)
+		if !ok {
+			return g, nil
+		}
+		if _, err := time.Parse(time.RFC3339, s); err == nil {
+			return s, nil
+		}
+		t, err := time.Parse(c.config.TimeFormat, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q, expected format %s", s, c.config.TimeFormat)
+		}
+		return t.Format(time.RFC3339Nano), nil
+	}
+}
+
+// jsonObjectKey finds the key of the field in the decoded object, preferring an
+// exact match and falling back to a case-insensitive match like encoding/json
+func jsonObjectKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// jsonField is an encoded struct field, resolved following the encoding/json rules
+type jsonField struct {
+	name      string
+	key       []byte // encoded `"name":`
+	index     []int
+	typ       reflect.Type
+	tagged    bool
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+	decimal   bool
+}
+
+func cachedJSONFields(t reflect.Type) []jsonField {
+	if fields, ok := jsonFieldsCache.Load(t); ok {
+		return fields.([]jsonField)
+	}
+	fields, _ := jsonFieldsCache.LoadOrStore(t, resolveJSONFields(t))
+	return fields.([]jsonField)
+}
+
+// resolveJSONFields lists the fields of the struct type encoded by encoding/json,
+// including the fields promoted from embedded structs
+func resolveJSONFields(t reflect.Type) []jsonField {
+	var candidates []jsonField
+	collectJSONFields(t, nil, map[reflect.Type]bool{t: true}, &candidates)
+
+	// The shallowest field wins, tagged fields win ties, remaining ties are dropped
+	byName := make(map[string][]jsonField)
+	for _, field := range candidates {
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	var fields []jsonField
+	for _, field := range candidates {
+		dominant, ok := dominantJSONField(byName[field.name])
+		if ok && slices.Equal(dominant.index, field.index) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func dominantJSONField(fields []jsonField) (jsonField, bool) {
+	depth := len(fields[0].index)
+	var shallowest []jsonField
+	for _, field := range fields {
+		switch {
+		case len(field.index) < depth:
+			depth = len(field.index)
+			shallowest = []jsonField{field}
+		case len(field.index) == depth:
+			shallowest = append(shallowest, field)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+
+	var tagged []jsonField
+	for _, field := range shallowest {
+		if field.tagged {
+			tagged = append(tagged, field)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+func collectJSONFields(t reflect.Type, index []int, visited map[reflect.Type]bool, out *[]jsonField) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldIndex := append(slices.Clone(index), i)
+
+		if sf.Anonymous {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if !sf.IsExported() && ft.Kind() != reflect.Struct {
+				continue
+			}
+			if name == "" && ft.Kind() == reflect.Struct {
+				if !visited[ft] {
+					visited[ft] = true
+					collectJSONFields(ft, fieldIndex, visited, out)
+					delete(visited, ft)
+				}
+				continue
+			}
+		} else if !sf.IsExported() {
+			continue
+		}
+
+		field := jsonField{name: name, index: fieldIndex, typ: sf.Type, tagged: name != ""}
+		if field.name == "" {
+			field.name = sf.Name
+		}
+		for opt := range strings.SplitSeq(opts, ",") {
+			switch opt {
+			case "omitempty":
+				field.omitEmpty = true
+			case "omitzero":
+				field.omitZero = true
+			case "string":
+				switch sf.Type.Kind() {
+				case reflect.Bool, reflect.String,
+					reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+					reflect.Float32, reflect.Float64:
+					field.quoted = true
+				}
+			case "decimal":
+				field.decimal = true
+			}
+		}
+		key, _ := json.Marshal(field.name)
+		field.key = append(key, ':')
+
+		*out = append(*out, field)
+	}
+}
+
+// jsonFieldByIndex returns the nested field, or false if it is promoted through a nil pointer
+func jsonFieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+func isZeroJSONValue(v reflect.Value) bool {
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return true
+		}
+		return z.IsZero()
+	}
+	return v.IsZero()
+}
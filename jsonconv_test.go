@@ -0,0 +1,146 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonConvAudit struct {
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type jsonConvOrder struct {
+	ID        int64              `json:"id"`
+	Total     float64            `json:"total"`
+	Cents     int64              `json:"cents,decimal"`
+	Quantity  int                `json:"quantity,string"`
+	ShippedAt *time.Time         `json:"shipped_at,omitempty"`
+	Rates     map[string]float64 `json:"rates,omitempty"`
+	Lines     []jsonConvLine     `json:"lines"`
+	Extra     any                `json:"extra,omitempty"`
+	internal  string
+	jsonConvAudit
+}
+
+type jsonConvLine struct {
+	Price float32 `json:"price"`
+}
+
+func TestMarshalJSON(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	order := jsonConvOrder{
+		ID:            1,
+		Total:         19.9,
+		Cents:         1990,
+		Quantity:      2,
+		ShippedAt:     &at,
+		Rates:         map[string]float64{"vat": 0.2},
+		Lines:         []jsonConvLine{{Price: 9.95}},
+		Extra:         map[string]any{"at": at},
+		internal:      "hidden",
+		jsonConvAudit: jsonConvAudit{CreatedBy: "john", CreatedAt: at},
+	}
+
+	cases := []struct {
+		desc     string
+		config   JSONConfig
+		expected string
+	}{
+		{
+			desc:     "default",
+			config:   JSONConfig{},
+			expected: `{"id":1,"total":19.9,"cents":1990,"quantity":"2","shipped_at":"2024-03-01T12:30:00Z","rates":{"vat":0.2},"lines":[{"price":9.95}],"extra":{"at":"2024-03-01T12:30:00Z"},"created_by":"john","created_at":"2024-03-01T12:30:00Z"}`,
+		},
+		{
+			desc:     "epoch millis",
+			config:   JSONConfig{TimeFormat: TimeFormatUnixMilli},
+			expected: `{"id":1,"total":19.9,"cents":1990,"quantity":"2","shipped_at":1709296200000,"rates":{"vat":0.2},"lines":[{"price":9.95}],"extra":{"at":1709296200000},"created_by":"john","created_at":1709296200000}`,
+		},
+		{
+			desc:     "epoch seconds",
+			config:   JSONConfig{TimeFormat: TimeFormatUnix},
+			expected: `{"id":1,"total":19.9,"cents":1990,"quantity":"2","shipped_at":1709296200,"rates":{"vat":0.2},"lines":[{"price":9.95}],"extra":{"at":1709296200},"created_by":"john","created_at":1709296200}`,
+		},
+		{
+			desc:     "custom layout",
+			config:   JSONConfig{TimeFormat: time.DateTime},
+			expected: `{"id":1,"total":19.9,"cents":1990,"quantity":"2","shipped_at":"2024-03-01 12:30:00","rates":{"vat":0.2},"lines":[{"price":9.95}],"extra":{"at":"2024-03-01 12:30:00"},"created_by":"john","created_at":"2024-03-01 12:30:00"}`,
+		},
+		{
+			desc:     "numbers as strings",
+			config:   JSONConfig{NumbersAsStrings: true},
+			expected: `{"id":1,"total":"19.9","cents":"1990","quantity":"2","shipped_at":"2024-03-01T12:30:00Z","rates":{"vat":"0.2"},"lines":[{"price":"9.95"}],"extra":{"at":"2024-03-01T12:30:00Z"},"created_by":"john","created_at":"2024-03-01T12:30:00Z"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			data, err := marshalJSON(tc.config, order)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(data))
+		})
+	}
+
+	t.Run("omitempty and nil values", func(t *testing.T) {
+		data, err := marshalJSON(JSONConfig{TimeFormat: TimeFormatUnix, NumbersAsStrings: true}, &jsonConvOrder{})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":0,"total":"0","cents":"0","quantity":"0","lines":null,"created_by":"","created_at":-62135596800}`, string(data))
+	})
+}
+
+func TestCtx_JSONConventions(t *testing.T) {
+	config := DefaultRouterOptions()
+	config.JSON = JSONConfig{TimeFormat: TimeFormatUnixMilli, NumbersAsStrings: true}
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	r.Post("/orders", func(c *Ctx) error {
+		var order jsonConvOrder
+		if err := c.ParseBody(&order); err != nil {
+			return err
+		}
+		return c.JSON(order)
+	})
+
+	cases := []struct {
+		desc     string
+		body     string
+		expected int
+		response string
+	}{
+		{
+			desc:     "configured conventions",
+			body:     `{"id":1,"total":"19.9","created_at":1709296200000,"shipped_at":1709296200000,"lines":[{"price":"9.95"}]}`,
+			expected: http.StatusOK,
+			response: `{"id":1,"total":"19.9","cents":"0","quantity":"0","shipped_at":1709296200000,"lines":[{"price":"9.95"}],"created_by":"","created_at":1709296200000}`,
+		},
+		{
+			desc:     "encoding/json conventions",
+			body:     `{"id":1,"total":19.9,"created_at":"2024-03-01T12:30:00Z","lines":[]}`,
+			expected: http.StatusOK,
+			response: `{"id":1,"total":"19.9","cents":"0","quantity":"0","lines":[],"created_by":"","created_at":1709296200000}`,
+		},
+		{desc: "invalid number", body: `{"total":"abc"}`, expected: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+			if tc.response != "" {
+				assert.JSONEq(t, tc.response, w.Body.String())
+			}
+		})
+	}
+}
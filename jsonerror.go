@@ -0,0 +1,100 @@
+package glib
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"reflect"
+)
+
+// JSONError describes why a JSON request body could not be decoded. It is the
+// data of the 400 Bad Request error returned by Ctx.ParseBody, so clients can
+// locate the problem:
+//
+//	{"message": "Invalid JSON", "field": "age", "expected": "number", "got": "string", "offset": 57, "line": 3, "column": 12}
+type JSONError struct {
+	Message string `json:"message"`
+
+	// Field is the path of the field having the wrong type (e.g. "address.zip"),
+	// empty for syntax errors
+	Field string `json:"field,omitempty"`
+
+	// Expected is the JSON type of the field: string, number, boolean, array or object
+	Expected string `json:"expected,omitempty"`
+
+	// Got is the JSON type of the value sent by the client
+	Got string `json:"got,omitempty"`
+
+	// Offset is the byte offset of the error in the body, Line and Column its
+	// position (starting at 1)
+	Offset int64 `json:"offset"`
+	Line   int   `json:"line"`
+	Column int   `json:"column"`
+}
+
+// newJSONError translates the syntax and type errors of encoding/json into a
+// JSONError positioned in body. Returns nil for other errors.
+func newJSONError(body []byte, err error) *JSONError {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		jsonErr   *JSONError
+	)
+	switch {
+	case stderrors.As(err, &syntaxErr):
+		jsonErr = &JSONError{Offset: syntaxErr.Offset}
+	case stderrors.As(err, &typeErr):
+		jsonErr = &JSONError{
+			Field:    typeErr.Field,
+			Expected: jsonTypeOf(typeErr.Type),
+			Got:      typeErr.Value,
+			Offset:   typeErr.Offset,
+		}
+	case stderrors.Is(err, io.ErrUnexpectedEOF):
+		// Truncated body decoded with a json.Decoder
+		jsonErr = &JSONError{Offset: int64(len(body))}
+	default:
+		return nil
+	}
+
+	jsonErr.Message = "Invalid JSON"
+	jsonErr.Line, jsonErr.Column = position(body, jsonErr.Offset)
+	return jsonErr
+}
+
+// position returns the line and column, starting at 1, of the last byte read
+// when encoding/json reports an error at offset
+func position(data []byte, offset int64) (line, column int) {
+	index := max(0, min(offset-1, int64(len(data))))
+	before := data[:index]
+	line = bytes.Count(before, []byte{'\n'}) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// jsonTypeOf returns the JSON type the values of the Go type are decoded from
+func jsonTypeOf(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return t.String()
+	}
+}
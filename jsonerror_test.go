@@ -0,0 +1,79 @@
+package glib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_ParseBody_JSONError(t *testing.T) {
+	type address struct {
+		Zip int `json:"zip"`
+	}
+	type user struct {
+		Name    string  `json:"name"`
+		Age     int     `json:"age"`
+		Active  bool    `json:"active"`
+		Address address `json:"address"`
+	}
+
+	tests := []struct {
+		desc   string
+		body   string
+		expect JSONError
+	}{
+		{
+			desc:   "syntax error",
+			body:   "{\n  \"name\": \"joe\",\n  \"age\": x\n}",
+			expect: JSONError{Message: "Invalid JSON", Offset: 29, Line: 3, Column: 10},
+		},
+		{
+			desc: "type error",
+			body: `{"name": "joe", "age": "42"}`,
+			expect: JSONError{
+				Message: "Invalid JSON", Field: "age", Expected: "number", Got: "string",
+				Offset: 27, Line: 1, Column: 27,
+			},
+		},
+		{
+			desc: "nested type error",
+			body: `{"address": {"zip": true}}`,
+			expect: JSONError{
+				Message: "Invalid JSON", Field: "address.zip", Expected: "number", Got: "bool",
+				Offset: 24, Line: 1, Column: 24,
+			},
+		},
+		{
+			desc:   "truncated body",
+			body:   "{\"name\": \"joe\",\n\"age\": 4",
+			expect: JSONError{Message: "Invalid JSON", Offset: 24, Line: 2, Column: 8},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Post("/users", func(c *Ctx) error {
+				var u user
+				return c.ValidateBody(&u)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp struct {
+				Data JSONError `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, test.expect, resp.Data)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package glib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	gerrors "github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/middleware"
+)
+
+// listener is an HTTP server of the Server with its router
+type listener struct {
+	server *http.Server
+	router Router
+}
+
+// boundListener is a bound network listener with the address it was bound to
+type boundListener struct {
+	addr     string
+	listener net.Listener
+}
+
+// AddListener serves the router on an additional address, sharing the lifecycle
+// of the server: it is started by Listen and stopped by Shutdown, and when a
+// listener fails the other ones are shut down. Additional listeners serve plain
+// HTTP with the timeouts and header size limit of the main server. It must be
+// called before Listen.
+//
+// Use NewRouter to create a router with the server configuration and middleware
+// stack, e.g. for an internal admin API:
+//
+//	admin := server.NewRouter()
+//	admin.Get("/metrics", metricsHandler)
+//	server.AddListener(":9090", admin)
+func (s *Server) AddListener(addr string, r Router) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+
+	s.listeners = append(s.listeners, &listener{
+		server: &http.Server{
+			Addr:           addr,
+			Handler:        r,
+			ReadTimeout:    s.httpServer.ReadTimeout,
+			WriteTimeout:   s.httpServer.WriteTimeout,
+			IdleTimeout:    s.httpServer.IdleTimeout,
+			MaxHeaderBytes: s.httpServer.MaxHeaderBytes,
+			ConnContext:    connContext,
+		},
+		router: r,
+	})
+}
+
+// NewRouter returns a router sharing the logger, validator and router options of
+// the server, with its own middleware stack built from environment variables
+func (s *Server) NewRouter() Router {
+	r := Default(s.logger, s.Validator, s.routerConfig)
+	for _, entry := range middleware.StackEntries(s.stackConfig) {
+		r.UseHTTP(entry.Middleware)
+	}
+	return r
+}
+
+// Addresses returns the address of each listener, the main one first
+func (s *Server) Addresses() []string {
+	listeners := s.allListeners()
+	addrs := make([]string, len(listeners))
+	for i, l := range listeners {
+		addrs[i] = l.server.Addr
+	}
+	return addrs
+}
+
+// allListeners returns the main listener followed by the additional ones
+func (s *Server) allListeners() []*listener {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+
+	listeners := make([]*listener, 0, len(s.listeners)+1)
+	listeners = append(listeners, &listener{server: s.httpServer, router: s.router})
+	return append(listeners, s.listeners...)
+}
+
+// serve starts all listeners, the main one with TLS when a certificate is given,
+// and returns the first fatal error once all listeners stopped. Requests are
+// served once all listeners are bound, see MarkReady.
+func (s *Server) serve(certFile, keyFile string) error {
+	if err := s.validateRoutes(); err != nil {
+		return err
+	}
+
+	listeners := s.allListeners()
+	bound := make([]boundListener, 0, len(listeners))
+	for i, l := range listeners {
+		addr := listenAddr(l.server.Addr, i == 0 && certFile != "")
+		ln, err := s.bind(addr)
+		if err != nil {
+			for _, b := range bound {
+				_ = b.listener.Close()
+			}
+			return gerrors.Errorf("server failed to start: %w", err)
+		}
+		bound = append(bound, boundListener{addr: addr, listener: ln})
+		if i == 0 {
+			l.server.Handler = s.stripBasePath(l.server.Handler)
+		}
+		l.server.Handler = s.gateStartup(l.server.Handler)
+	}
+
+	s.listenersMu.Lock()
+	s.bound = bound
+	s.listenersMu.Unlock()
+
+	s.started()
+	if !s.manualReady {
+		s.MarkReady()
+	}
+
+	errs := make(chan error, len(listeners))
+	for i, l := range listeners {
+		tls := i == 0 && certFile != ""
+		go func() {
+			errs <- s.serveListener(l.server, bound[i].listener, tls, certFile, keyFile)
+		}()
+	}
+
+	var first error
+	for range listeners {
+		err := <-errs
+		if err == nil || first != nil {
+			continue
+		}
+		first = err
+
+		// Stop the other listeners
+		ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+		_ = s.shutdownListeners(ctx)
+		cancel()
+	}
+	return first
+}
+
+// listenAddr returns the address to bind, ":http" or ":https" when empty like net/http
+func listenAddr(addr string, tls bool) string {
+	switch {
+	case addr != "":
+		return addr
+	case tls:
+		return ":https"
+	default:
+		return ":http"
+	}
+}
+
+func (s *Server) serveListener(server *http.Server, ln net.Listener, tls bool, certFile, keyFile string) error {
+	if tls {
+		s.logger.InfoContext(context.Background(), fmt.Sprintf("Starting TLS server on %s", server.Addr))
+		if err := server.ServeTLS(ln, certFile, keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return gerrors.Errorf("TLS server failed to start: %w", err)
+		}
+		return nil
+	}
+
+	s.logger.InfoContext(context.Background(), fmt.Sprintf("Starting server on %s", server.Addr))
+	if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return gerrors.Errorf("server failed to start: %w", err)
+	}
+	return nil
+}
+
+// shutdownListeners gracefully shuts down all listeners and returns the first error
+func (s *Server) shutdownListeners(ctx context.Context) error {
+	var first error
+	for _, l := range s.allListeners() {
+		if err := l.server.Shutdown(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
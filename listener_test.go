@@ -0,0 +1,121 @@
+package glib
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freeAddr returns a local address with a free port
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func newListenerTestServer(t *testing.T) *Server {
+	t.Setenv("IS_DEBUG", "false")
+	r := setupTestRouter()
+	r.Get("/", func(c *Ctx) error { return c.SendString("public") })
+	return &Server{
+		router:          r,
+		httpServer:      &http.Server{Addr: freeAddr(t), Handler: r},
+		logger:          slog.DiscardLogger(),
+		shutdownTimeout: time.Second,
+	}
+}
+
+func TestServer_AddListener(t *testing.T) {
+	s := newListenerTestServer(t)
+	admin := setupTestRouter()
+	admin.Get("/", func(c *Ctx) error { return c.SendString("admin") })
+	adminAddr := freeAddr(t)
+	s.AddListener(adminAddr, admin)
+
+	assert.Equal(t, []string{s.Address(), adminAddr}, s.Addresses())
+
+	done := make(chan error, 1)
+	go func() { done <- s.Listen() }()
+
+	get := func(addr string) string {
+		var resp *http.Response
+		require.Eventually(t, func() bool {
+			var err error
+			resp, err = http.Get("http://" + addr + "/")
+			return err == nil
+		}, 2*time.Second, 10*time.Millisecond)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	assert.Equal(t, "public", get(s.Address()))
+	assert.Equal(t, "admin", get(adminAddr))
+
+	require.NoError(t, s.Shutdown(context.Background()))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Listen did not return after Shutdown")
+	}
+}
+
+func TestServer_AddListenerFailure(t *testing.T) {
+	s := newListenerTestServer(t)
+	s.AddListener("127.0.0.1:-1", setupTestRouter())
+
+	done := make(chan error, 1)
+	go func() { done <- s.Listen() }()
+
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "server failed to start")
+	case <-time.After(2 * time.Second):
+		t.Fatal("the other listeners were not shut down")
+	}
+}
+
+func TestServer_MaxHeaderBytes(t *testing.T) {
+	s := newListenerTestServer(t)
+	s.httpServer.MaxHeaderBytes = 1 << 10
+	admin := setupTestRouter()
+	admin.Get("/", func(c *Ctx) error { return c.SendString("admin") })
+	adminAddr := freeAddr(t)
+	s.AddListener(adminAddr, admin)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Listen() }()
+	defer func() {
+		require.NoError(t, s.Shutdown(context.Background()))
+		<-done
+	}()
+
+	get := func(addr string, cookieSize int) int {
+		req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/", nil)
+		require.NoError(t, err)
+		req.Header.Set("Cookie", "session="+strings.Repeat("a", cookieSize))
+		var resp *http.Response
+		require.Eventually(t, func() bool {
+			resp, err = http.DefaultClient.Do(req)
+			return err == nil
+		}, 2*time.Second, 10*time.Millisecond)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// net/http allows a few KB on top of MaxHeaderBytes
+	for _, addr := range []string{s.Address(), adminAddr} {
+		assert.Equal(t, http.StatusOK, get(addr, 100), addr)
+		assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, get(addr, 16<<10), addr)
+	}
+}
@@ -0,0 +1,42 @@
+package glib
+
+import (
+	"mime"
+	"strings"
+)
+
+// Common media types
+const (
+	MIMEApplicationJSON = "application/json"
+	MIMEProblemJSON     = "application/problem+json"
+	MIMEJSONAPI         = "application/vnd.api+json"
+)
+
+// DefaultJSONMediaTypes returns the request media types accepted by Ctx.ParseBody
+// when RouterConfig.JSONMediaTypes is nil: application/json and every media type
+// using the +json structured syntax suffix (RFC 6839), such as application/vnd.api+json.
+func DefaultJSONMediaTypes() []string {
+	return []string{MIMEApplicationJSON, "+json"}
+}
+
+// isJSONMediaType reports whether the content type matches one of the registered
+// JSON media types. Entries starting with "+" match a structured syntax suffix.
+func (c *Ctx) isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	mediaTypes := c.config.JSONMediaTypes
+	if mediaTypes == nil {
+		mediaTypes = DefaultJSONMediaTypes()
+	}
+
+	for _, registered := range mediaTypes {
+		registered = strings.ToLower(registered)
+		if registered == mediaType || (strings.HasPrefix(registered, "+") && strings.HasSuffix(mediaType, registered)) {
+			return true
+		}
+	}
+	return false
+}
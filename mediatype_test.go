@@ -0,0 +1,87 @@
+package glib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_ParseBody_MediaTypes(t *testing.T) {
+	cases := []struct {
+		desc        string
+		mediaTypes  []string
+		contentType string
+		expected    int
+	}{
+		{desc: "application/json", contentType: "application/json; charset=utf-8", expected: http.StatusOK},
+		{desc: "json:api", contentType: MIMEJSONAPI, expected: http.StatusOK},
+		{desc: "+json suffix", contentType: "application/merge-patch+json", expected: http.StatusOK},
+		{desc: "not json", contentType: "text/plain", expected: http.StatusUnsupportedMediaType},
+		{desc: "malformed", contentType: "application/", expected: http.StatusUnsupportedMediaType},
+		{desc: "custom registry", mediaTypes: []string{MIMEJSONAPI}, contentType: MIMEJSONAPI, expected: http.StatusOK},
+		{desc: "custom registry rejects suffix", mediaTypes: []string{MIMEJSONAPI}, contentType: "application/merge-patch+json", expected: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := DefaultRouterOptions()
+			config.JSONMediaTypes = tc.mediaTypes
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+			r.Post("/", func(c *Ctx) error {
+				var body map[string]any
+				if err := c.ParseBody(&body); err != nil {
+					return err
+				}
+				return c.JSON(body)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"john"}`))
+			req.Header.Set("Content-Type", tc.contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
+
+func TestRouter_ErrorMediaType(t *testing.T) {
+	config := DefaultRouterOptions()
+	config.ErrorMediaType = MIMEProblemJSON
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	r.Get("/users/{id}", func(c *Ctx) error {
+		return errors.NotFound("User not found", nil)
+	})
+	r.Get("/validate", func(c *Ctx) error {
+		return errors.UnprocessableEntity(map[string]string{"email": "required"}, nil)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+
+	var problem map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, map[string]any{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   float64(404),
+		"detail":   "User not found",
+		"instance": "/users/42",
+	}, problem)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/validate", nil))
+	problem = nil
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, map[string]any{"email": "required"}, problem["data"])
+	assert.NotContains(t, problem, "detail")
+}
@@ -0,0 +1,36 @@
+package glib
+
+import "github.com/azizndao/glib/middleware"
+
+// Metric increments a business counter (e.g. orders_created) of the metrics
+// collector of the router, without depending on a metrics library. With the
+// RequestMetrics middleware, the counter is buffered and published after the
+// response, otherwise it is published immediately. Labels are key-value pairs,
+// capped by middleware.MetricLabelLimits: never use unbounded values such as
+// user IDs as labels. Does nothing when no collector is configured.
+//
+// Example:
+//
+//	c.Metric("orders_created", 1, "plan", order.Plan)
+func (c *Ctx) Metric(name string, value float64, labels ...string) *Ctx {
+	if scope := middleware.MetricsScopeFrom(c.Context()); scope != nil {
+		scope.Counter(name, value, labels...)
+		return c
+	}
+	if c.config.Metrics != nil {
+		labels, _ = middleware.DefaultMetricLabelLimits().Apply(labels)
+		c.config.Metrics.Counter(name, value, labels...)
+	}
+	return c
+}
+
+// AddMetricLabel adds a label (e.g. plan=pro) to the request metrics published
+// by the RequestMetrics middleware, for this request only. The method, route
+// and status labels can't be overridden, and labels beyond the limits of the
+// middleware are dropped. Does nothing without the middleware.
+func (c *Ctx) AddMetricLabel(key, value string) *Ctx {
+	if scope := middleware.MetricsScopeFrom(c.Context()); scope != nil {
+		scope.AddLabel(key, value)
+	}
+	return c
+}
@@ -0,0 +1,47 @@
+package glib
+
+import (
+	"github.com/azizndao/glib/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/middleware"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCtx_Metric(t *testing.T) {
+	metrics := &counterMetrics{counters: map[string][]string{}}
+	config := DefaultRouterOptions()
+	config.Metrics = metrics
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	r.Post("/orders", func(c *Ctx) error {
+		c.Metric("orders_created", 1, "plan", "pro", "orphan")
+		return c.AddMetricLabel("plan", "pro").NoContent()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"plan", "pro"}, metrics.counters["orders_created"], "published immediately without the middleware")
+	assert.NotContains(t, metrics.counters, "http_requests_total")
+
+	t.Run("buffered by the middleware", func(t *testing.T) {
+		metrics := &counterMetrics{counters: map[string][]string{}}
+		r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()))
+		r.UseHTTP(middleware.RequestMetrics(middleware.RequestMetricsConfig{Metrics: metrics}))
+		r.Post("/orders/{id}", func(c *Ctx) error {
+			c.Metric("orders_created", 1, "plan", "pro")
+			assert.NotContains(t, metrics.counters, "orders_created", "the metric is published after the response")
+			return c.AddMetricLabel("plan", "pro").NoContent()
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/1", nil))
+
+		assert.Equal(t, []string{"plan", "pro"}, metrics.counters["orders_created"])
+		assert.Equal(t, []string{"method", "POST", "route", "/orders/{id}", "status", "204", "plan", "pro"}, metrics.counters["http_requests_total"])
+	})
+}
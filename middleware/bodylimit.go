@@ -1,9 +1,5 @@
 package middleware
 
-import (
-	"github.com/azizndao/glib/util"
-)
-
 // Common size constants for convenience
 const (
 	KB = 1024
@@ -18,7 +14,7 @@ const (
 type BodyLimitConfig struct {
 	// MaxSize is the maximum allowed size of request body in bytes
 	// Default: 4MB (DefaultBodyLimit)
-	MaxSize int64
+	MaxSize int64 `env:"BODY_LIMIT"`
 }
 
 // DefaultBodyLimitConfig returns default configuration for body limit
@@ -33,20 +29,11 @@ func DefaultBodyLimitConfig() BodyLimitConfig {
 // Returns default config if BODY_LIMIT is not set
 // Falls back to DefaultBodyLimitConfig if set but invalid
 func LoadBodyLimitConfig() *BodyLimitConfig {
-	size := util.GetEnvInt64("BODY_LIMIT", -1)
-	if size == -1 {
-		// Not set, return default
-		cfg := DefaultBodyLimitConfig()
-		return &cfg
-	}
-
-	if size <= 0 {
-		// Invalid value, return default
-		cfg := DefaultBodyLimitConfig()
-		return &cfg
-	}
-
-	return &BodyLimitConfig{
-		MaxSize: size,
+	cfg := DefaultBodyLimitConfig()
+	loadEnv(&cfg)
+	if cfg.MaxSize <= 0 {
+		// Invalid value, use the default
+		cfg = DefaultBodyLimitConfig()
 	}
+	return &cfg
 }
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/util"
+)
+
+// ChaosConfig holds configuration for the Chaos middleware
+type ChaosConfig struct {
+	// LatencyProbability is the probability (0 to 1) of delaying a request
+	LatencyProbability float64 `env:"CHAOS_LATENCY_PROBABILITY"`
+
+	// MinLatency and MaxLatency bound the injected delay
+	// Default: 100ms to 1s
+	MinLatency time.Duration `env:"CHAOS_LATENCY_MIN"`
+	MaxLatency time.Duration `env:"CHAOS_LATENCY_MAX"`
+
+	// ErrorProbability is the probability (0 to 1) of failing a request
+	ErrorProbability float64 `env:"CHAOS_ERROR_PROBABILITY"`
+
+	// ErrorStatusCodes are the status codes injected errors are picked from
+	// Default: 500, 502, 503
+	ErrorStatusCodes []int
+
+	// Paths restricts fault injection to matching request paths. Patterns use
+	// path.Match syntax and a trailing "/*" matches the whole subtree.
+	// Empty targets every path.
+	Paths []string `env:"CHAOS_PATHS"`
+
+	// Production must be set when running in production: chaos is never
+	// activated when it is true
+	Production bool
+}
+
+// DefaultChaosConfig returns default configuration for fault injection.
+// Probabilities are zero so nothing is injected until they are tuned.
+func DefaultChaosConfig() ChaosConfig {
+	return ChaosConfig{
+		MinLatency:       100 * time.Millisecond,
+		MaxLatency:       time.Second,
+		ErrorStatusCodes: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable},
+	}
+}
+
+// LoadChaosConfig loads ChaosConfig from environment variables
+// Environment variables:
+//   - CHAOS_ENABLED (bool): enable fault injection (default: false)
+//   - IS_PRODUCTION (bool): marks the environment as production, chaos is then refused (default: false)
+//   - CHAOS_LATENCY_PROBABILITY (float): probability of injecting latency
+//   - CHAOS_LATENCY_MIN, CHAOS_LATENCY_MAX (duration): bounds of the injected latency
+//   - CHAOS_ERROR_PROBABILITY (float): probability of injecting an error
+//   - CHAOS_ERROR_CODES (comma-separated ints): status codes of injected errors
+//   - CHAOS_PATHS (comma-separated): targeted path patterns
+//
+// Returns nil if chaos is not enabled or the environment is production
+func LoadChaosConfig() *ChaosConfig {
+	if !chaosAllowed() {
+		return nil
+	}
+
+	cfg := DefaultChaosConfig()
+	loadEnv(&cfg)
+
+	if codes := util.GetEnvStringSlice("CHAOS_ERROR_CODES", nil); len(codes) > 0 {
+		cfg.ErrorStatusCodes = cfg.ErrorStatusCodes[:0:0]
+		for _, code := range codes {
+			if status, err := strconv.Atoi(code); err == nil && status >= 400 && status <= 599 {
+				cfg.ErrorStatusCodes = append(cfg.ErrorStatusCodes, status)
+			}
+		}
+	}
+
+	return &cfg
+}
+
+// chaosAllowed reports whether the environment allows fault injection
+func chaosAllowed() bool {
+	return util.GetEnvBool("CHAOS_ENABLED", false) && !util.GetEnvBool("IS_PRODUCTION", false)
+}
+
+// ChaosInjector injects latency and errors into requests. Its configuration
+// can be replaced at runtime with Update, e.g. from an admin endpoint.
+type ChaosInjector struct {
+	config atomic.Pointer[ChaosConfig]
+	active bool
+	random func() float64
+}
+
+// NewChaosInjector creates a fault injector. It only activates when CHAOS_ENABLED=true,
+// IS_PRODUCTION is not set and config.Production is false, otherwise its
+// middleware is a no-op.
+func NewChaosInjector(config ChaosConfig) *ChaosInjector {
+	c := &ChaosInjector{
+		active: chaosAllowed() && !config.Production,
+		random: rand.Float64,
+	}
+	c.config.Store(&config)
+	return c
+}
+
+// Active reports whether the injector passed the environment guard
+func (c *ChaosInjector) Active() bool {
+	return c.active
+}
+
+// Config returns the current configuration
+func (c *ChaosInjector) Config() ChaosConfig {
+	return *c.config.Load()
+}
+
+// Update atomically replaces the configuration. Setting Production has no
+// effect once the injector is created.
+func (c *ChaosInjector) Update(config ChaosConfig) {
+	c.config.Store(&config)
+}
+
+// Middleware returns the fault injection middleware
+func (c *ChaosInjector) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !c.active {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := c.config.Load()
+			if !matchAnyPath(cfg.Paths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.LatencyProbability > 0 && c.random() < cfg.LatencyProbability {
+				delay := cfg.MinLatency
+				if cfg.MaxLatency > cfg.MinLatency {
+					delay += time.Duration(c.random() * float64(cfg.MaxLatency-cfg.MinLatency))
+				}
+
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+
+			if cfg.ErrorProbability > 0 && len(cfg.ErrorStatusCodes) > 0 && c.random() < cfg.ErrorProbability {
+				status := cfg.ErrorStatusCodes[int(c.random()*float64(len(cfg.ErrorStatusCodes)))%len(cfg.ErrorStatusCodes)]
+				w.Header().Set("X-Chaos-Injected", "true")
+				writeError(w, errors.NewApi(status, http.StatusText(status), nil))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Chaos injects latency and errors for resilience testing in non-production
+// environments. See NewChaosInjector to tune the configuration at runtime.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.Chaos(middleware.ChaosConfig{
+//	    ErrorProbability: 0.05,
+//	    ErrorStatusCodes: []int{503},
+//	    Paths:            []string{"/api/*"},
+//	}))
+func Chaos(config ChaosConfig) func(http.Handler) http.Handler {
+	return NewChaosInjector(config).Middleware()
+}
+
+// matchAnyPath reports whether p matches one of the patterns. An empty list matches everything.
+func matchAnyPath(patterns []string, p string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matchPath(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches p against a path.Match pattern, a trailing "/*" matching the whole subtree
+func matchPath(pattern, p string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	matched, _ := path.Match(pattern, p)
+	return matched
+}
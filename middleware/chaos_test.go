@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChaos(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("refuses to activate without CHAOS_ENABLED", func(t *testing.T) {
+		injector := NewChaosInjector(ChaosConfig{ErrorProbability: 1, ErrorStatusCodes: []int{503}})
+		assert.False(t, injector.Active())
+
+		w := httptest.NewRecorder()
+		injector.Middleware()(ok).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("refuses to activate in production", func(t *testing.T) {
+		t.Setenv("CHAOS_ENABLED", "true")
+		assert.False(t, NewChaosInjector(ChaosConfig{Production: true}).Active())
+
+		t.Setenv("IS_PRODUCTION", "true")
+		assert.False(t, NewChaosInjector(ChaosConfig{}).Active())
+		assert.Nil(t, LoadChaosConfig())
+	})
+
+	t.Run("injects errors on targeted paths", func(t *testing.T) {
+		t.Setenv("CHAOS_ENABLED", "true")
+		injector := NewChaosInjector(ChaosConfig{ErrorProbability: 1, ErrorStatusCodes: []int{503}, Paths: []string{"/api/*"}})
+		handler := injector.Middleware()(ok)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "true", w.Header().Get("X-Chaos-Injected"))
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		injector.Update(ChaosConfig{})
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("injects latency", func(t *testing.T) {
+		t.Setenv("CHAOS_ENABLED", "true")
+		handler := Chaos(ChaosConfig{LatencyProbability: 1, MinLatency: 20 * time.Millisecond, MaxLatency: 20 * time.Millisecond})(ok)
+
+		start := time.Now()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -1,9 +1,17 @@
 package middleware
 
 import (
+	"bufio"
 	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
 
+	"github.com/azizndao/glib/httputil"
 	"github.com/azizndao/glib/util"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // CompressConfig holds configuration for the Compress middleware
@@ -15,6 +23,10 @@ type CompressConfig struct {
 	// 9 = best compression
 	// Default: gzip.DefaultCompression (-1)
 	Level int
+
+	// Types are the compressed content types, e.g. "application/json" or
+	// "text/*". Default: chi's compressible types (text, JSON, JavaScript...)
+	Types []string
 }
 
 // DefaultCompressConfig returns default compression configuration
@@ -35,3 +47,64 @@ func LoadCompressConfig() *CompressConfig {
 	cfg := DefaultCompressConfig()
 	return &cfg
 }
+
+// Compress compresses response bodies for clients accepting gzip or deflate,
+// using chi's compressor, for the content types of the config and the given
+// ones. All responses get "Vary: Accept-Encoding" since their
+// representation depends on it, without duplicating the entry added by chi
+// when a response is compressed. Strong ETags of compressed responses, computed
+// from the uncompressed representation, are turned into weak ones.
+func Compress(config CompressConfig, types ...string) func(http.Handler) http.Handler {
+	compress := middleware.Compress(config.Level, append(slices.Clip(config.Types), types...)...)
+
+	return func(next http.Handler) http.Handler {
+		compressed := compress(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			httputil.AddVary(w.Header(), "Accept-Encoding")
+			compressed.ServeHTTP(&varyWriter{ResponseWriter: w}, r)
+		})
+	}
+}
+
+// varyWriter merges duplicated Vary entries before the headers are sent
+type varyWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *varyWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		header := w.Header()
+		httputil.AddVary(header)
+		if etag := header.Get("ETag"); header.Get("Content-Encoding") != "" && strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *varyWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *varyWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *varyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("middleware: the response writer does not support hijacking")
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController
+func (w *varyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"hash/maphash"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/util"
+	"github.com/go-chi/httprate"
+)
+
+// KeyFunc extracts the client key of a request. It is the same type as the rate
+// limiter's key functions so they can be shared, e.g. to key by API token.
+type KeyFunc = httprate.KeyFunc
+
+// KeyByRealIP keys requests by client IP, honoring the True-Client-IP,
+// X-Real-IP and X-Forwarded-For headers of any peer. Clients can forge them to
+// get new keys, only use it behind a proxy overwriting them, see KeyByClientIP.
+var KeyByRealIP KeyFunc = httprate.KeyByRealIP
+
+// KeyByClientIP returns a KeyFunc keying requests by client IP, only honoring
+// the forwarding headers of the trusted proxies (see TrustedProxies.ClientIP).
+// IPv6 addresses are keyed by their /64 prefix, like KeyByRealIP.
+//
+// It is the default key of the middlewares, with the proxies of TRUSTED_PROXIES
+// (see LoadTrustedProxies).
+func KeyByClientIP(proxies TrustedProxies) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		ip := proxies.ClientIP(r)
+		if ip == nil {
+			return r.RemoteAddr, nil
+		}
+		if ip.To4() == nil {
+			ip = ip.Mask(net.CIDRMask(64, 128))
+		}
+		return ip.String(), nil
+	}
+}
+
+// ConcurrencyLimitReason is the reason reported in the body of requests rejected
+// by ConcurrencyPerClient, so clients can tell them apart from rate limiting
+const ConcurrencyLimitReason = "concurrency_limit"
+
+// concurrencyShards is the number of shards of the per-client counters
+const concurrencyShards = 32
+
+// ConcurrencyConfig holds configuration for the ConcurrencyPerClient middleware
+type ConcurrencyConfig struct {
+	// Max is the maximum number of simultaneous in-flight requests per client
+	// Default: 10
+	Max int `env:"CONCURRENCY_LIMIT_MAX"`
+
+	// KeyFunc extracts the client key. Default: KeyByClientIP with TRUSTED_PROXIES
+	KeyFunc KeyFunc
+}
+
+// DefaultConcurrencyConfig returns default configuration for per-client concurrency limiting
+func DefaultConcurrencyConfig() ConcurrencyConfig {
+	return ConcurrencyConfig{
+		Max:     10,
+		KeyFunc: KeyByClientIP(LoadTrustedProxies()),
+	}
+}
+
+// LoadConcurrencyConfig loads ConcurrencyConfig from environment variables
+// Environment variables:
+//   - ENABLE_CONCURRENCY_LIMIT (bool): enable/disable per-client concurrency limiting (default: false)
+//   - CONCURRENCY_LIMIT_MAX (int): max in-flight requests per client (default: 10)
+//
+// Returns nil if ENABLE_CONCURRENCY_LIMIT=false
+func LoadConcurrencyConfig() *ConcurrencyConfig {
+	if !util.GetEnvBool("ENABLE_CONCURRENCY_LIMIT", false) {
+		return nil
+	}
+
+	cfg := DefaultConcurrencyConfig()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// ConcurrencyPerClient limits the number of simultaneous in-flight requests per
+// client, independently from the request rate. Requests above the limit are
+// rejected with 429 Too Many Requests and the reason "concurrency_limit".
+func ConcurrencyPerClient(config ...ConcurrencyConfig) func(http.Handler) http.Handler {
+	cfg := DefaultConcurrencyConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = DefaultConcurrencyConfig().Max
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = KeyByClientIP(LoadTrustedProxies())
+	}
+
+	counters := newConcurrencyCounters()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := cfg.KeyFunc(r)
+			if err != nil {
+				writeError(w, errors.InternalServerError(http.StatusText(http.StatusInternalServerError), err))
+				return
+			}
+
+			if !counters.acquire(key, cfg.Max) {
+				writeError(w, errors.TooManyRequests(map[string]any{
+					"message": "Too many concurrent requests",
+					"reason":  ConcurrencyLimitReason,
+				}, nil))
+				return
+			}
+			defer counters.release(key)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// concurrencyCounters counts in-flight requests per key in a sharded map.
+// Keys are removed when their count drops to zero so memory stays bounded
+// by the number of clients with in-flight requests.
+type concurrencyCounters struct {
+	seed   maphash.Seed
+	shards [concurrencyShards]concurrencyShard
+}
+
+type concurrencyShard struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newConcurrencyCounters() *concurrencyCounters {
+	c := &concurrencyCounters{seed: maphash.MakeSeed()}
+	for i := range c.shards {
+		c.shards[i].counts = make(map[string]int)
+	}
+	return c
+}
+
+func (c *concurrencyCounters) shard(key string) *concurrencyShard {
+	return &c.shards[maphash.String(c.seed, key)%concurrencyShards]
+}
+
+// acquire increments the count of the key if it is below max
+func (c *concurrencyCounters) acquire(key string, max int) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts[key] >= max {
+		return false
+	}
+	s.counts[key]++
+	return true
+}
+
+// release decrements the count of the key, removing it when it reaches zero
+func (c *concurrencyCounters) release(key string) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts[key] <= 1 {
+		delete(s.counts, key)
+		return
+	}
+	s.counts[key]--
+}
+
+// len returns the number of keys with in-flight requests
+func (c *concurrencyCounters) len() int {
+	n := 0
+	for i := range c.shards {
+		c.shards[i].mu.Lock()
+		n += len(c.shards[i].counts)
+		c.shards[i].mu.Unlock()
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyPerClient(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	handler := ConcurrencyPerClient(ConcurrencyConfig{Max: 2})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	done := make(chan struct{})
+	for range 2 {
+		go func() {
+			request("/slow", "10.0.0.1")
+			done <- struct{}{}
+		}()
+	}
+	<-started
+	<-started
+
+	w := request("/fast", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	var body struct {
+		Data map[string]string `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, ConcurrencyLimitReason, body.Data["reason"])
+
+	assert.Equal(t, http.StatusOK, request("/fast", "10.0.0.2").Code, "other clients are not limited")
+
+	close(release)
+	<-done
+	<-done
+
+	assert.Equal(t, http.StatusOK, request("/fast", "10.0.0.1").Code)
+}
+
+func TestConcurrencyCounters(t *testing.T) {
+	counters := newConcurrencyCounters()
+
+	assert.True(t, counters.acquire("a", 1))
+	assert.False(t, counters.acquire("a", 1))
+	assert.True(t, counters.acquire("b", 1))
+	assert.Equal(t, 2, counters.len())
+
+	counters.release("a")
+	counters.release("b")
+	assert.Zero(t, counters.len(), "zero-count entries are removed")
+}
+
+func TestKeyByClientIP(t *testing.T) {
+	tests := []struct {
+		desc       string
+		proxies    []string
+		remoteAddr string
+		realIP     string
+		expectKey  string
+	}{
+		{desc: "remote address", remoteAddr: "203.0.113.9:1234", expectKey: "203.0.113.9"},
+		{desc: "forged header of an untrusted peer", remoteAddr: "203.0.113.9:1234", realIP: "10.0.0.1", expectKey: "203.0.113.9"},
+		{desc: "header of a trusted proxy", proxies: []string{"203.0.113.0/24"}, remoteAddr: "203.0.113.9:1234", realIP: "10.0.0.1", expectKey: "10.0.0.1"},
+		{desc: "IPv6 keyed by prefix", remoteAddr: "[2001:db8:1:2:3:4:5:6]:1234", expectKey: "2001:db8:1:2::"},
+		{desc: "invalid remote address", remoteAddr: "pipe", expectKey: "pipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			proxies, err := ParseTrustedProxies(tt.proxies)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			key, err := KeyByClientIP(proxies)(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectKey, key)
+		})
+	}
+
+	t.Run("default key trusts TRUSTED_PROXIES only", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.9:1234"
+		req.Header.Set("X-Real-IP", "10.0.0.1")
+
+		key, err := DefaultConcurrencyConfig().KeyFunc(req)
+		require.NoError(t, err)
+		assert.Equal(t, "203.0.113.9", key)
+
+		t.Setenv("TRUSTED_PROXIES", "203.0.113.9")
+		key, err = DefaultConfig().KeyFunc(req)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.1", key)
+	})
+}
@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/util"
+	"github.com/azizndao/glib/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// ContractViolation describes how a JSON response differs from its declared contract
+type ContractViolation struct {
+	// Missing lists fields required by the contract but absent from the response
+	Missing []string `json:"missing,omitempty"`
+	// Unknown lists fields present in the response but not declared by the contract
+	Unknown []string `json:"unknown,omitempty"`
+	// Invalid holds the validator errors of the decoded response
+	Invalid any `json:"invalid,omitempty"`
+}
+
+// empty reports whether the response matches the contract
+func (v ContractViolation) empty() bool {
+	return len(v.Missing) == 0 && len(v.Unknown) == 0 && v.Invalid == nil
+}
+
+// ResponseContractConfig holds configuration for the ResponseContract middleware
+type ResponseContractConfig struct {
+	// Logger receives the contract violations. Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// ResponseContract checks outgoing JSON responses against the struct declared for
+// their route and logs a warning describing the differences. It is a debug tool:
+// it only activates when IS_DEBUG=true and is a no-op otherwise.
+//
+// Contracts are keyed by route pattern ("/users/{id}") or method and route
+// pattern ("GET /users/{id}") and map to an example value of the expected
+// response type (a struct, a pointer to a struct or a slice of structs).
+// Fields without omitempty are required, and `validate` tags are checked with
+// the validation package.
+//
+// Responses are buffered while the middleware is active. A response flushed by
+// its handler, e.g. a stream, is sent as is from the first flush and not checked.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.ResponseContract(map[string]any{
+//	    "GET /users/{id}": User{},
+//	    "GET /users":      []User{},
+//	}))
+func ResponseContract(contracts map[string]any, config ...ResponseContractConfig) func(http.Handler) http.Handler {
+	var cfg ResponseContractConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !util.GetEnvBool("IS_DEBUG", false) || len(contracts) == 0 {
+			return next
+		}
+		logger := cfg.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+
+		validator := validation.New(validation.DefaultValidatorConfig())
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if rec.flushed {
+				return
+			}
+
+			pattern := ""
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				pattern = rctx.RoutePattern()
+			}
+
+			contract, ok := contracts[r.Method+" "+pattern]
+			if !ok {
+				contract, ok = contracts[pattern]
+			}
+
+			if ok && rec.status < 300 && strings.Contains(w.Header().Get("Content-Type"), "json") {
+				if violation := checkContract(validator, contract, rec.body.Bytes()); !violation.empty() {
+					logger.WarnContext(r.Context(), "response does not match its contract",
+						"method", r.Method,
+						"route", pattern,
+						"contract", reflect.TypeOf(contract).String(),
+						"diff", violation,
+					)
+				}
+			}
+
+			w.WriteHeader(rec.status)
+			_, _ = w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// checkContract compares the JSON body with the contract type
+func checkContract(validator *validation.Validator, contract any, body []byte) ContractViolation {
+	var violation ContractViolation
+
+	t := reflect.TypeOf(contract)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			violation.Invalid = "response is not a JSON array"
+			return violation
+		}
+		for _, item := range items {
+			v := checkContract(validator, reflect.Zero(t.Elem()).Interface(), item)
+			violation.Missing = appendUnique(violation.Missing, v.Missing...)
+			violation.Unknown = appendUnique(violation.Unknown, v.Unknown...)
+			if violation.Invalid == nil {
+				violation.Invalid = v.Invalid
+			}
+		}
+		return violation
+	}
+
+	if t.Kind() != reflect.Struct {
+		return violation
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		violation.Invalid = "response is not a JSON object"
+		return violation
+	}
+
+	declared := make(map[string]bool)
+	collectJSONFields(t, declared)
+
+	for name, required := range declared {
+		if _, ok := fields[name]; !ok && required {
+			violation.Missing = append(violation.Missing, name)
+		}
+	}
+	for name := range fields {
+		if _, ok := declared[name]; !ok {
+			violation.Unknown = append(violation.Unknown, name)
+		}
+	}
+	slices.Sort(violation.Missing)
+	slices.Sort(violation.Unknown)
+
+	value := reflect.New(t)
+	if err := json.Unmarshal(body, value.Interface()); err != nil {
+		violation.Invalid = err.Error()
+		return violation
+	}
+	if err := validator.Validate(value.Interface(), "en"); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			violation.Invalid = apiErr.Data
+		} else {
+			violation.Invalid = err.Error()
+		}
+	}
+
+	return violation
+}
+
+// collectJSONFields records the JSON names of the struct fields, with whether they are required
+func collectJSONFields(t reflect.Type, fields map[string]bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectJSONFields(embedded, fields)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = !slices.Contains(strings.Split(options, ","), "omitempty") &&
+			!slices.Contains(strings.Split(options, ","), "omitzero")
+	}
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// bufferedResponse holds the response until the contract has been checked, or
+// until it is flushed
+type bufferedResponse struct {
+	http.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	flushed bool
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.flushed {
+		b.ResponseWriter.WriteHeader(status)
+		return
+	}
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	if b.flushed {
+		return b.ResponseWriter.Write(data)
+	}
+	return b.body.Write(data)
+}
+
+// Flush sends the buffered response and the next writes as is
+func (b *bufferedResponse) Flush() {
+	if !b.flushed {
+		b.flushed = true
+		b.ResponseWriter.WriteHeader(b.status)
+		_, _ = b.ResponseWriter.Write(b.body.Bytes())
+		b.body.Reset()
+	}
+	_ = http.NewResponseController(b.ResponseWriter).Flush()
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type contractUser struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email,omitempty"`
+}
+
+func TestResponseContract(t *testing.T) {
+	newRouter := func() chi.Router {
+		r := chi.NewRouter()
+		r.Use(ResponseContract(map[string]any{
+			"GET /users/{id}": contractUser{},
+			"/users":          []contractUser{},
+		}))
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"","role":"admin"}`))
+		})
+		r.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"id":1,"name":"john"}]`))
+		})
+		return r
+	}
+
+	captureLogs := func(t *testing.T) *bytes.Buffer {
+		var logs bytes.Buffer
+		previous := slog.Default()
+		slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+		t.Cleanup(func() { slog.SetDefault(previous) })
+		return &logs
+	}
+
+	t.Run("disabled outside debug mode", func(t *testing.T) {
+		logs := captureLogs(t)
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+
+		assert.Equal(t, `{"name":"","role":"admin"}`, w.Body.String())
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("logs the diff in debug mode", func(t *testing.T) {
+		t.Setenv("IS_DEBUG", "true")
+		logs := captureLogs(t)
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"name":"","role":"admin"}`, w.Body.String(), "the response is passed through")
+		assert.Contains(t, logs.String(), `"route":"/users/{id}"`)
+		assert.Contains(t, logs.String(), `"missing":["id"]`)
+		assert.Contains(t, logs.String(), `"unknown":["role"]`)
+		assert.Contains(t, logs.String(), `"invalid":{"name":`)
+	})
+
+	t.Run("configured logger", func(t *testing.T) {
+		t.Setenv("IS_DEBUG", "true")
+		var logs bytes.Buffer
+		r := chi.NewRouter()
+		r.Use(ResponseContract(map[string]any{"/users/{id}": contractUser{}}, ResponseContractConfig{
+			Logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+		}))
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"john","role":"admin"}`))
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+		assert.Contains(t, logs.String(), `"unknown":["role"]`)
+	})
+
+	t.Run("flushed response sent as is", func(t *testing.T) {
+		t.Setenv("IS_DEBUG", "true")
+		logs := captureLogs(t)
+		r := chi.NewRouter()
+		r.Use(ResponseContract(map[string]any{"/users": []contractUser{}}))
+		r.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`[{"role":"admin"}`))
+			assert.NoError(t, http.NewResponseController(w).Flush())
+			w.Write([]byte(`]`))
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.True(t, w.Flushed)
+		assert.Equal(t, `[{"role":"admin"}]`, w.Body.String())
+		assert.Empty(t, logs.String(), "flushed responses aren't checked")
+	})
+
+	t.Run("matching response", func(t *testing.T) {
+		t.Setenv("IS_DEBUG", "true")
+		logs := captureLogs(t)
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+
+		assert.Equal(t, `[{"id":1,"name":"john"}]`, w.Body.String())
+		assert.Empty(t, logs.String())
+	})
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/util"
+)
+
+// DefaultDeadlineHeader is the default header carrying the remaining deadline budget in milliseconds
+const DefaultDeadlineHeader = "X-Request-Timeout-Ms"
+
+// DeadlineExhaustedReason is the reason reported in the body of requests whose
+// deadline budget expired before they arrived, so callers can tell them apart
+// from server slowness
+const DeadlineExhaustedReason = "deadline_exhausted"
+
+// DeadlineConfig holds configuration for the DeadlineFromHeader middleware
+type DeadlineConfig struct {
+	// Header is the request header carrying the remaining budget in milliseconds
+	// Default: X-Request-Timeout-Ms
+	Header string `env:"DEADLINE_HEADER"`
+
+	// Max caps the budget accepted from callers, 0 means no cap
+	Max time.Duration `env:"DEADLINE_MAX"`
+}
+
+// DefaultDeadlineConfig returns default configuration for deadline propagation
+func DefaultDeadlineConfig() DeadlineConfig {
+	return DeadlineConfig{
+		Header: DefaultDeadlineHeader,
+		Max:    DefaultTimeout,
+	}
+}
+
+// LoadDeadlineConfig loads DeadlineConfig from environment variables
+// Environment variables:
+//   - ENABLE_DEADLINE_HEADER (bool): enable/disable deadline propagation (default: false)
+//   - DEADLINE_HEADER (string): header carrying the budget in milliseconds (default: X-Request-Timeout-Ms)
+//   - DEADLINE_MAX (duration): maximum accepted budget, 0 for no cap (default: 30s)
+//
+// Returns nil if ENABLE_DEADLINE_HEADER=false
+func LoadDeadlineConfig() *DeadlineConfig {
+	if !util.GetEnvBool("ENABLE_DEADLINE_HEADER", false) {
+		return nil
+	}
+
+	cfg := DefaultDeadlineConfig()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// maxDeadlineMs is the largest budget in milliseconds held by a time.Duration
+const maxDeadlineMs = int64(math.MaxInt64 / time.Millisecond)
+
+// DeadlineFromHeader applies the deadline budget sent by the caller in a header
+// (X-Request-Timeout-Ms by default) to the request context. The sooner of the
+// budget and any existing deadline applies. Requests arriving with an exhausted
+// budget are rejected immediately with 504 Gateway Timeout and the reason
+// "deadline_exhausted". Requests without a valid header are not changed.
+//
+// Use DeadlineTransport to propagate the remaining budget to downstream services.
+func DeadlineFromHeader(config ...DeadlineConfig) func(http.Handler) http.Handler {
+	cfg := DefaultDeadlineConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = DefaultDeadlineHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := strings.TrimSpace(r.Header.Get(cfg.Header))
+			if value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ms, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if ms <= 0 {
+				writeError(w, errors.GatewayTimeout(map[string]any{
+					"message": "Request deadline budget exhausted",
+					"reason":  DeadlineExhaustedReason,
+				}, nil))
+				return
+			}
+
+			// Clamp the budget before converting it, so that it can't overflow
+			budget := time.Duration(min(ms, maxDeadlineMs)) * time.Millisecond
+			if cfg.Max > 0 && budget > cfg.Max {
+				budget = cfg.Max
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// DeadlineTransport is an http.RoundTripper sending the remaining budget of the
+// request context deadline to downstream services in a header, to be read by
+// DeadlineFromHeader. Requests whose deadline already passed fail with
+// context.DeadlineExceeded without being sent.
+//
+// Example:
+//
+//	client := &http.Client{Transport: &middleware.DeadlineTransport{}}
+//	req, _ := http.NewRequestWithContext(c.Context(), "GET", url, nil)
+//	resp, err := client.Do(req)
+type DeadlineTransport struct {
+	// Base is the transport sending the requests. Default: http.DefaultTransport
+	Base http.RoundTripper
+
+	// Header is the header carrying the budget in milliseconds. Default: X-Request-Timeout-Ms
+	Header string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *DeadlineTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	deadline, ok := r.Context().Deadline()
+	if !ok {
+		return base.RoundTrip(r)
+	}
+
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining <= 0 {
+		return nil, context.DeadlineExceeded
+	}
+
+	header := t.Header
+	if header == "" {
+		header = DefaultDeadlineHeader
+	}
+
+	// RoundTrippers must not modify the request
+	r = r.Clone(r.Context())
+	r.Header.Set(header, strconv.FormatInt(remaining, 10))
+	return base.RoundTrip(r)
+}
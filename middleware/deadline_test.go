@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadlineFromHeader(t *testing.T) {
+	tests := []struct {
+		desc        string
+		header      string
+		max         time.Duration
+		parent      time.Duration
+		expectCode  int
+		expectUntil time.Duration // 0 means no deadline expected
+	}{
+		{desc: "no header", expectCode: http.StatusOK},
+		{desc: "invalid header", header: "soon", expectCode: http.StatusOK},
+		{desc: "budget applied", header: "2000", expectCode: http.StatusOK, expectUntil: 2 * time.Second},
+		{desc: "budget capped", header: "60000", max: time.Second, expectCode: http.StatusOK, expectUntil: time.Second},
+		{desc: "sooner existing deadline wins", header: "5000", parent: time.Second, expectCode: http.StatusOK, expectUntil: time.Second},
+		{desc: "overflowing budget", header: "9223372036854775807", expectCode: http.StatusOK, expectUntil: time.Duration(maxDeadlineMs) * time.Millisecond},
+		{desc: "overflowing budget capped", header: "9223372036854775807", max: time.Second, expectCode: http.StatusOK, expectUntil: time.Second},
+		{desc: "exhausted budget", header: "0", expectCode: http.StatusGatewayTimeout},
+		{desc: "negative budget", header: "-20", expectCode: http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var deadline time.Time
+			var hasDeadline bool
+			handler := DeadlineFromHeader(DeadlineConfig{Max: tt.max})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, hasDeadline = r.Context().Deadline()
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(DefaultDeadlineHeader, tt.header)
+			}
+			if tt.parent > 0 {
+				ctx, cancel := context.WithTimeout(req.Context(), tt.parent)
+				defer cancel()
+				req = req.WithContext(ctx)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			if tt.expectCode == http.StatusGatewayTimeout {
+				var body struct {
+					Data map[string]string `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, DeadlineExhaustedReason, body.Data["reason"])
+				return
+			}
+
+			assert.Equal(t, tt.expectUntil > 0, hasDeadline)
+			if tt.expectUntil > 0 {
+				assert.WithinDuration(t, time.Now().Add(tt.expectUntil), deadline, 200*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestDeadlineTransport(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(DefaultDeadlineHeader)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &DeadlineTransport{}}
+
+	t.Run("propagates remaining budget", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 2*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		ms, err := strconv.Atoi(received)
+		require.NoError(t, err)
+		assert.Greater(t, ms, 1000)
+		assert.LessOrEqual(t, ms, 2000)
+		assert.Empty(t, req.Header.Get(DefaultDeadlineHeader), "the original request is not modified")
+	})
+
+	t.Run("no deadline", func(t *testing.T) {
+		received = ""
+		req, err := http.NewRequestWithContext(t.Context(), "GET", server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Empty(t, received)
+	})
+
+	t.Run("expired deadline", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(t.Context(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	apierrors "github.com/azizndao/glib/errors"
+)
+
+// ErrRawBodyNotKept is returned by RawBody when the request body was
+// decompressed without KeepRawBody
+var ErrRawBodyNotKept = errors.New("middleware: raw body of the decompressed request was not kept")
+
+// ErrRawBodyTooLarge is returned by RawBody when the compressed body exceeds
+// DecompressConfig.MaxRawBodySize
+var ErrRawBodyTooLarge = errors.New("middleware: raw body of the decompressed request is too large")
+
+// ErrDecompressedBodyTooLarge is returned when reading a decompressed body
+// exceeding DecompressConfig.MaxSize
+var ErrDecompressedBodyTooLarge = errors.New("middleware: decompressed request body is too large")
+
+// DecompressConfig holds configuration for the Decompress middleware
+type DecompressConfig struct {
+	// MaxSize is the maximum size of a decompressed body in bytes, protecting
+	// against decompression bombs. Default: 4MB (DefaultBodyLimit)
+	MaxSize int64
+
+	// MaxRawBodySize is the maximum size in bytes of the compressed body kept
+	// for the routes needing the bytes as received, see KeepRawBody.
+	// Default: 1MB
+	MaxRawBodySize int64
+}
+
+// DefaultDecompressConfig returns default configuration for request decompression
+func DefaultDecompressConfig() DecompressConfig {
+	return DecompressConfig{
+		MaxSize:        int64(DefaultBodyLimit),
+		MaxRawBodySize: int64(MB),
+	}
+}
+
+// rawBodyKey is the context key of the raw body of a decompressed request
+type rawBodyKey struct{}
+
+// rawBody keeps the compressed bytes of a request body, up to max bytes
+type rawBody struct {
+	keep      bool
+	max       int64
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *rawBody) Write(p []byte) (int, error) {
+	if room := b.max - int64(b.buf.Len()); int64(len(p)) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// Decompress decompresses the request bodies sent with a gzip or deflate
+// Content-Encoding, removing the Content-Encoding and Content-Length headers
+// so that the handlers read the decoded body. Other encodings are rejected
+// with 415 Unsupported Media Type. Reading more than MaxSize decompressed bytes
+// fails with ErrDecompressedBodyTooLarge.
+//
+// The compressed bytes are dropped, unless KeepRawBody is called before the
+// body is read, e.g. by glib for the routes marked with Meta("raw-body", true)
+// to verify the signature of a webhook computed over the bytes as sent.
+//
+// Example:
+//
+//	r.Use(middleware.Decompress())
+func Decompress(config ...DecompressConfig) func(http.Handler) http.Handler {
+	cfg := DefaultDecompressConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	defaults := DefaultDecompressConfig()
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaults.MaxSize
+	}
+	if cfg.MaxRawBodySize <= 0 {
+		cfg.MaxRawBodySize = defaults.MaxRawBodySize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var newReader func(io.Reader) (io.ReadCloser, error)
+			switch encoding {
+			case "gzip", "x-gzip":
+				newReader = func(src io.Reader) (io.ReadCloser, error) { return gzip.NewReader(src) }
+			case "deflate":
+				newReader = zlib.NewReader
+			default:
+				writeError(w, apierrors.UnsupportedMediaType(map[string]any{
+					"message":             fmt.Sprintf("Unsupported Content-Encoding %q", encoding),
+					"supported_encodings": []string{"gzip", "deflate"},
+				}, nil))
+				return
+			}
+
+			raw := &rawBody{max: cfg.MaxRawBodySize}
+			r = r.WithContext(context.WithValue(r.Context(), rawBodyKey{}, raw))
+			r.Body = &decompressReader{body: r.Body, raw: raw, newReader: newReader, remaining: cfg.MaxSize}
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// KeepRawBody makes Decompress keep the compressed bytes of the request body,
+// returned by RawBody. It must be called before the body is read, and reports
+// whether the body is decompressed.
+func KeepRawBody(r *http.Request) bool {
+	raw, ok := r.Context().Value(rawBodyKey{}).(*rawBody)
+	if ok {
+		raw.keep = true
+	}
+	return ok
+}
+
+// RawBody returns the compressed bytes read from the body of a request
+// decompressed by Decompress, and whether it was decompressed. The body must
+// have been read entirely. Returns ErrRawBodyNotKept without KeepRawBody, and
+// ErrRawBodyTooLarge when the body exceeds MaxRawBodySize.
+func RawBody(r *http.Request) ([]byte, bool, error) {
+	raw, ok := r.Context().Value(rawBodyKey{}).(*rawBody)
+	switch {
+	case !ok:
+		return nil, false, nil
+	case !raw.keep:
+		return nil, true, ErrRawBodyNotKept
+	case raw.truncated:
+		return nil, true, ErrRawBodyTooLarge
+	}
+	return raw.buf.Bytes(), true, nil
+}
+
+// decompressReader decompresses a request body, the decompressor being created
+// on the first read so that KeepRawBody can be called before
+type decompressReader struct {
+	body      io.ReadCloser
+	raw       *rawBody
+	newReader func(io.Reader) (io.ReadCloser, error)
+	reader    io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		var src io.Reader = d.body
+		if d.raw.keep {
+			src = io.TeeReader(d.body, d.raw)
+		}
+		d.reader, d.err = d.newReader(src)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	if int64(len(p)) > d.remaining+1 {
+		p = p[:d.remaining+1]
+	}
+	n, err := d.reader.Read(p)
+	if int64(n) > d.remaining {
+		d.err = ErrDecompressedBodyTooLarge
+		return int(d.remaining), d.err
+	}
+	d.remaining -= int64(n)
+	if err == io.EOF && d.raw.keep {
+		// Keep the bytes after the compressed stream, e.g. a trailing newline
+		_, _ = io.Copy(d.raw, d.body)
+	}
+	return n, err
+}
+
+func (d *decompressReader) Close() error {
+	if d.reader != nil {
+		_ = d.reader.Close()
+	}
+	return d.body.Close()
+}
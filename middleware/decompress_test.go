@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompress(t *testing.T) {
+	payload := `{"event":"order.paid","id":42}`
+	var gzipped, deflated bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, _ = gz.Write([]byte(payload))
+	require.NoError(t, gz.Close())
+	zw := zlib.NewWriter(&deflated)
+	_, _ = zw.Write([]byte(payload))
+	require.NoError(t, zw.Close())
+
+	cases := []struct {
+		desc          string
+		config        DecompressConfig
+		encoding      string
+		body          []byte
+		keep          bool
+		expectCode    int
+		expectBody    string
+		expectReadErr error
+		expectRaw     []byte
+		expectRawErr  error
+	}{
+		{
+			desc:       "uncompressed",
+			body:       []byte(payload),
+			expectCode: http.StatusOK,
+			expectBody: payload,
+		},
+		{
+			desc:         "gzip",
+			encoding:     "gzip",
+			body:         gzipped.Bytes(),
+			expectCode:   http.StatusOK,
+			expectBody:   payload,
+			expectRawErr: ErrRawBodyNotKept,
+		},
+		{
+			desc:       "gzip with raw body kept",
+			encoding:   "GZIP",
+			body:       gzipped.Bytes(),
+			keep:       true,
+			expectCode: http.StatusOK,
+			expectBody: payload,
+			expectRaw:  gzipped.Bytes(),
+		},
+		{
+			desc:       "deflate with raw body kept",
+			encoding:   "deflate",
+			body:       deflated.Bytes(),
+			keep:       true,
+			expectCode: http.StatusOK,
+			expectBody: payload,
+			expectRaw:  deflated.Bytes(),
+		},
+		{
+			desc:         "raw body over the limit",
+			config:       DecompressConfig{MaxRawBodySize: 8},
+			encoding:     "gzip",
+			body:         gzipped.Bytes(),
+			keep:         true,
+			expectCode:   http.StatusOK,
+			expectBody:   payload,
+			expectRawErr: ErrRawBodyTooLarge,
+		},
+		{
+			desc:          "decompressed body over the limit",
+			config:        DecompressConfig{MaxSize: 10},
+			encoding:      "gzip",
+			body:          gzipped.Bytes(),
+			expectCode:    http.StatusOK,
+			expectBody:    payload[:10],
+			expectReadErr: ErrDecompressedBodyTooLarge,
+			expectRawErr:  ErrRawBodyNotKept,
+		},
+		{
+			desc:       "unsupported encoding",
+			encoding:   "br",
+			body:       []byte(payload),
+			expectCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var body []byte
+			var readErr, rawErr error
+			var raw []byte
+			handler := Decompress(tc.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.keep {
+					assert.True(t, KeepRawBody(r))
+				}
+				assert.Empty(t, r.Header.Get("Content-Encoding"))
+				body, readErr = io.ReadAll(r.Body)
+				raw, _, rawErr = RawBody(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(tc.body))
+			if tc.encoding != "" {
+				req.Header.Set("Content-Encoding", tc.encoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectCode, w.Code)
+			if tc.expectCode != http.StatusOK {
+				assert.Contains(t, w.Body.String(), "Unsupported Content-Encoding")
+				return
+			}
+			assert.Equal(t, tc.expectBody, string(body))
+			assert.ErrorIs(t, readErr, tc.expectReadErr)
+			if tc.expectRawErr != nil {
+				assert.ErrorIs(t, rawErr, tc.expectRawErr)
+				return
+			}
+			require.NoError(t, rawErr)
+			assert.Equal(t, tc.expectRaw, raw)
+		})
+	}
+}
+
+func TestDecompress_InvalidBody(t *testing.T) {
+	var readErr error
+	handler := Decompress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader("this body is not gzipped"))
+	req.Header.Set("Content-Encoding", "gzip")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.ErrorIs(t, readErr, gzip.ErrHeader)
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// DedupeStore records the keys of the requests already processed by Dedupe,
+// shared between the instances of a service with a Redis implementation
+type DedupeStore interface {
+	// Add records the key for ttl, reporting whether it was absent. It must be
+	// atomic, e.g. SET key 1 NX PX ttl with Redis, so that only one of two
+	// simultaneous deliveries is processed.
+	Add(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Remove forgets the key, so that the redelivery of a request whose
+	// processing failed is processed
+	Remove(ctx context.Context, key string) error
+}
+
+// MemoryDedupeStore is an in-memory DedupeStore, for a single instance
+type MemoryDedupeStore struct {
+	mu        sync.Mutex
+	keys      map[string]time.Time
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryDedupeStore creates an in-memory dedupe store
+func NewMemoryDedupeStore() *MemoryDedupeStore {
+	return &MemoryDedupeStore{
+		keys: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+// Add implements DedupeStore. Expired keys are removed at most once a minute.
+func (s *MemoryDedupeStore) Add(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= time.Minute {
+		s.lastSweep = now
+		for k, expiresAt := range s.keys {
+			if !now.Before(expiresAt) {
+				delete(s.keys, k)
+			}
+		}
+	}
+
+	if expiresAt, ok := s.keys[key]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	s.keys[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Remove implements DedupeStore
+func (s *MemoryDedupeStore) Remove(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key)
+	return nil
+}
+
+// DedupeConfig holds configuration for the Dedupe middleware
+type DedupeConfig struct {
+	// Store records the keys of the processed requests. Default: a
+	// MemoryDedupeStore, use a shared store with several instances.
+	Store DedupeStore
+
+	// TTL is how long a request is remembered, at least the redelivery period
+	// of the provider (default: 24h)
+	TTL time.Duration
+
+	// Header is the header identifying the event, e.g. "X-Event-ID". When empty
+	// or missing from the request, the key is the hash of the body.
+	Header string
+
+	// OnDuplicate responds to the duplicate requests. Default: 200 OK with
+	// {"status":"duplicate"}, so that the provider stops redelivering.
+	OnDuplicate func(w http.ResponseWriter, r *http.Request, key string)
+
+	// Logger logs the store errors. Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// DefaultDedupeConfig returns default configuration for request deduplication
+func DefaultDedupeConfig() DedupeConfig {
+	return DedupeConfig{
+		TTL: 24 * time.Hour,
+	}
+}
+
+// Dedupe drops the redeliveries of a request, typically the events sent by a
+// webhook provider, identified by the Header of the event or the hash of the
+// body. The first delivery is processed, the next ones within the TTL get the
+// OnDuplicate response without reaching the handler, including the ones
+// received while the first is processed. When the handler fails (5xx or
+// panic), the key is removed so that the next redelivery is processed.
+//
+// Store errors are logged and the request is processed, a duplicate being
+// better than a lost event.
+//
+// Example:
+//
+//	r.With(middleware.Dedupe(middleware.DedupeConfig{
+//	    Header: "X-Event-ID",
+//	    Store:  redisDedupeStore,
+//	})).Post("/webhooks/stripe", handleStripeEvent)
+func Dedupe(config DedupeConfig) func(http.Handler) http.Handler {
+	defaults := DefaultDedupeConfig()
+	if config.Store == nil {
+		config.Store = NewMemoryDedupeStore()
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+	if config.OnDuplicate == nil {
+		config.OnDuplicate = duplicateResponse
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := dedupeID(r, config.Header)
+			if err != nil {
+				writeError(w, errors.BadRequest("Invalid request body", err))
+				return
+			}
+			key := "dedupe:" + r.URL.Path + ":" + id
+
+			added, err := config.Store.Add(r.Context(), key, config.TTL)
+			if err != nil {
+				logger.WarnContext(r.Context(), "dedupe store failed, processing the request",
+					"key", key,
+					"error", err,
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !added {
+				config.OnDuplicate(w, r, key)
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			processed := false
+			defer func() {
+				if processed && ww.Status() < http.StatusInternalServerError {
+					return
+				}
+				if err := config.Store.Remove(context.WithoutCancel(r.Context()), key); err != nil {
+					logger.WarnContext(r.Context(), "dedupe store failed to remove the key of a failed request",
+						"key", key,
+						"error", err,
+					)
+				}
+			}()
+			next.ServeHTTP(ww, r)
+			processed = true
+		})
+	}
+}
+
+// dedupeID returns the value of the header identifying the request, or the
+// hash of its body, which is restored for the handler
+func dedupeID(r *http.Request, header string) (string, error) {
+	if header != "" {
+		if id := r.Header.Get(header); id != "" {
+			return id, nil
+		}
+	}
+
+	hash := sha256.New()
+	if r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// duplicateResponse is the default response to the duplicate requests
+func duplicateResponse(w http.ResponseWriter, _ *http.Request, _ string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "duplicate"})
+}
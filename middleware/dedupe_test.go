@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingDedupeStore is a DedupeStore whose calls fail
+type failingDedupeStore struct{}
+
+func (failingDedupeStore) Add(context.Context, string, time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (failingDedupeStore) Remove(context.Context, string) error {
+	return errors.New("connection refused")
+}
+
+func TestDedupe(t *testing.T) {
+	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cases := []struct {
+		desc        string
+		config      DedupeConfig
+		first       func() *http.Request
+		second      func() *http.Request
+		expectCalls int
+	}{
+		{
+			desc:        "same event ID",
+			config:      DedupeConfig{Header: "X-Event-ID"},
+			first:       func() *http.Request { return eventRequest("/webhooks", "evt_1", `{"n":1}`) },
+			second:      func() *http.Request { return eventRequest("/webhooks", "evt_1", `{"n":2}`) },
+			expectCalls: 1,
+		},
+		{
+			desc:        "different event IDs",
+			config:      DedupeConfig{Header: "X-Event-ID"},
+			first:       func() *http.Request { return eventRequest("/webhooks", "evt_1", `{"n":1}`) },
+			second:      func() *http.Request { return eventRequest("/webhooks", "evt_2", `{"n":1}`) },
+			expectCalls: 2,
+		},
+		{
+			desc:        "same body without header",
+			config:      DedupeConfig{Header: "X-Event-ID"},
+			first:       func() *http.Request { return eventRequest("/webhooks", "", `{"n":1}`) },
+			second:      func() *http.Request { return eventRequest("/webhooks", "", `{"n":1}`) },
+			expectCalls: 1,
+		},
+		{
+			desc:        "different bodies",
+			first:       func() *http.Request { return eventRequest("/webhooks", "", `{"n":1}`) },
+			second:      func() *http.Request { return eventRequest("/webhooks", "", `{"n":2}`) },
+			expectCalls: 2,
+		},
+		{
+			desc:        "same event ID on another path",
+			config:      DedupeConfig{Header: "X-Event-ID"},
+			first:       func() *http.Request { return eventRequest("/webhooks/a", "evt_1", `{"n":1}`) },
+			second:      func() *http.Request { return eventRequest("/webhooks/b", "evt_1", `{"n":1}`) },
+			expectCalls: 2,
+		},
+		{
+			desc:        "store failure processes the request",
+			config:      DedupeConfig{Header: "X-Event-ID", Store: failingDedupeStore{}},
+			first:       func() *http.Request { return eventRequest("/webhooks", "evt_1", `{"n":1}`) },
+			second:      func() *http.Request { return eventRequest("/webhooks", "evt_1", `{"n":1}`) },
+			expectCalls: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var bodies []string
+			tc.config.Logger = discard
+			handler := Dedupe(tc.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				w.WriteHeader(http.StatusAccepted)
+			}))
+
+			handler.ServeHTTP(httptest.NewRecorder(), tc.first())
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tc.second())
+
+			assert.Len(t, bodies, tc.expectCalls)
+			assert.NotEmpty(t, bodies[0], "the body is restored for the handler")
+			if tc.expectCalls == 1 {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.JSONEq(t, `{"status":"duplicate"}`, w.Body.String())
+			} else {
+				assert.Equal(t, http.StatusAccepted, w.Code)
+			}
+		})
+	}
+}
+
+func TestDedupe_Concurrent(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := Dedupe(DedupeConfig{Header: "X-Event-ID"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	var duplicates atomic.Int32
+	for range 10 {
+		wg.Go(func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, eventRequest("/webhooks", "evt_1", ""))
+			if strings.Contains(w.Body.String(), "duplicate") {
+				duplicates.Add(1)
+			}
+		})
+	}
+	require.Eventually(t, func() bool { return duplicates.Load() == 9 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestDedupe_Failure(t *testing.T) {
+	cases := []struct {
+		desc    string
+		handler http.HandlerFunc
+	}{
+		{
+			desc:    "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+		},
+		{
+			desc:    "panic",
+			handler: func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			store := NewMemoryDedupeStore()
+			handler := Recovery(RecoveryConfig{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})(
+				Dedupe(DedupeConfig{Header: "X-Event-ID", Store: store})(tc.handler))
+
+			handler.ServeHTTP(httptest.NewRecorder(), eventRequest("/webhooks", "evt_1", ""))
+
+			added, err := store.Add(context.Background(), "dedupe:/webhooks:evt_1", time.Hour)
+			require.NoError(t, err)
+			assert.True(t, added, "the key of the failed request is removed for the redelivery")
+		})
+	}
+}
+
+func TestDedupe_OnDuplicate(t *testing.T) {
+	handler := Dedupe(DedupeConfig{
+		Header: "X-Event-ID",
+		OnDuplicate: func(w http.ResponseWriter, r *http.Request, key string) {
+			w.Header().Set("X-Duplicate-Key", key)
+			w.WriteHeader(http.StatusConflict)
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), eventRequest("/webhooks", "evt_1", ""))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, eventRequest("/webhooks", "evt_1", ""))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "dedupe:/webhooks:evt_1", w.Header().Get("X-Duplicate-Key"))
+}
+
+func TestMemoryDedupeStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryDedupeStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	added, _ := store.Add(ctx, "a", time.Minute)
+	assert.True(t, added)
+	added, _ = store.Add(ctx, "a", time.Minute)
+	assert.False(t, added)
+
+	now = now.Add(time.Minute)
+	added, _ = store.Add(ctx, "a", time.Minute)
+	assert.True(t, added, "expired keys are added again")
+
+	require.NoError(t, store.Remove(ctx, "a"))
+	added, _ = store.Add(ctx, "a", time.Minute)
+	assert.True(t, added)
+}
+
+func eventRequest(path, id, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if id != "" {
+		req.Header.Set("X-Event-ID", id)
+	}
+	return req
+}
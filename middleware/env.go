@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/azizndao/glib/util"
+)
+
+// loadEnv loads the fields of the config tagged with their environment
+// variable (see util.LoadEnv). Invalid values keep their default and are
+// reported at startup by util.InvalidEnv. It panics on an invalid config
+// struct, e.g. a field of an unsupported type.
+func loadEnv(config any) {
+	err := util.LoadEnv("", config)
+	var envErr *util.EnvError
+	if err != nil && !errors.As(err, &envErr) {
+		panic("middleware: " + err.Error())
+	}
+}
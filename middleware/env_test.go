@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEnv(t *testing.T) {
+	t.Run("invalid value keeps the default", func(t *testing.T) {
+		t.Setenv("TEST_LOAD_ENV_PORT", "abc")
+		cfg := struct {
+			Port int `env:"TEST_LOAD_ENV_PORT" default:"8080"`
+		}{}
+		assert.NotPanics(t, func() { loadEnv(&cfg) })
+		assert.Equal(t, 8080, cfg.Port)
+	})
+
+	t.Run("unsupported field type panics", func(t *testing.T) {
+		cfg := struct {
+			Limits map[string]int `env:"TEST_LOAD_ENV_LIMITS"`
+		}{}
+		assert.PanicsWithValue(t, "middleware: util.LoadEnv: field Limits (TEST_LOAD_ENV_LIMITS): unsupported type map[string]int", func() { loadEnv(&cfg) })
+	})
+}
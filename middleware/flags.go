@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/azizndao/glib/flags"
+	"github.com/go-chi/httplog/v3"
+)
+
+// FeatureFlagsConfig holds configuration for the FeatureFlags middleware
+type FeatureFlagsConfig struct {
+	// KeyFunc extracts the key the flags are evaluated for when no key was set
+	// with flags.WithKey. Default: KeyByClientIP with TRUSTED_PROXIES
+	KeyFunc KeyFunc
+
+	// Logger logs the evaluation errors. Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// DefaultFeatureFlagsConfig returns default configuration for feature flags
+func DefaultFeatureFlagsConfig() FeatureFlagsConfig {
+	return FeatureFlagsConfig{
+		KeyFunc: KeyByClientIP(LoadTrustedProxies()),
+	}
+}
+
+// FeatureFlags evaluates the flags of the provider once per request, for the
+// key set with flags.WithKey by a previous middleware (user, tenant...) or the
+// client IP, and stores them in the request context for flags.Enabled and
+// flags.Variant. The variants are added to the access log in the "flags"
+// group. When the evaluation fails, the error is logged and all flags are off.
+//
+// Example:
+//
+//	provider, err := flags.LoadStatic()
+//	...
+//	r.UseHTTP(middleware.FeatureFlags(provider))
+func FeatureFlags(provider flags.Provider, config ...FeatureFlagsConfig) func(http.Handler) http.Handler {
+	cfg := DefaultFeatureFlagsConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = KeyByClientIP(LoadTrustedProxies())
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			key, ok := flags.KeyFromContext(ctx)
+			if !ok {
+				var err error
+				if key, err = cfg.KeyFunc(r); err != nil {
+					logger.WarnContext(ctx, "Failed to get the feature flags key", "error", err)
+				}
+			}
+
+			result, err := provider.Evaluate(ctx, key)
+			if err != nil {
+				logger.WarnContext(ctx, "Failed to evaluate feature flags", "key", key, "error", err)
+				result = flags.Result{}
+			}
+
+			names := slices.Sorted(maps.Keys(result))
+			attrs := make([]any, len(names))
+			for i, name := range names {
+				attrs[i] = slog.String(name, result[name])
+			}
+			httplog.SetAttrs(ctx, slog.Group("flags", attrs...))
+
+			next.ServeHTTP(w, r.WithContext(flags.NewContext(ctx, result)))
+		})
+	}
+}
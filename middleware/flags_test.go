@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/flags"
+	"github.com/go-chi/httplog/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// keyProvider enables the "beta" flag for the "tenant-1" and "10.0.0.1" keys
+type keyProvider struct{}
+
+func (keyProvider) Evaluate(_ context.Context, key string) (flags.Result, error) {
+	if key == "fail" {
+		return nil, errors.New("provider unavailable")
+	}
+	if key == "tenant-1" || key == "10.0.0.1" {
+		return flags.Result{"beta": flags.On, "exp": "treatment"}, nil
+	}
+	return flags.Result{"beta": flags.Off, "exp": "control"}, nil
+}
+
+func TestFeatureFlags(t *testing.T) {
+	cases := []struct {
+		desc          string
+		ip            string
+		key           string
+		expectBeta    bool
+		expectVariant string
+	}{
+		{desc: "keyed by client IP", ip: "10.0.0.1", expectBeta: true, expectVariant: "treatment"},
+		{desc: "other client IP", ip: "10.0.0.2", expectVariant: "control"},
+		{desc: "keyed by context", ip: "10.0.0.2", key: "tenant-1", expectBeta: true, expectVariant: "treatment"},
+		{desc: "provider error", ip: "10.0.0.1", key: "fail"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+			var beta bool
+			var variant string
+			handler := FeatureFlags(keyProvider{}, FeatureFlagsConfig{Logger: logger})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				beta = flags.Enabled(r.Context(), "beta")
+				variant = flags.Variant(r.Context(), "exp")
+			}))
+			if tc.key != "" {
+				inner := handler
+				handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					inner.ServeHTTP(w, r.WithContext(flags.WithKey(r.Context(), tc.key)))
+				})
+			}
+			handler = httplog.RequestLogger(logger, &httplog.Options{})(handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.ip + ":1234"
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.expectBeta, beta)
+			assert.Equal(t, tc.expectVariant, variant)
+			if tc.key == "fail" {
+				assert.Contains(t, logs.String(), "Failed to evaluate feature flags")
+				return
+			}
+			assert.Contains(t, logs.String(), `"flags":{"beta":"`)
+			assert.Contains(t, logs.String(), `"exp":"`+tc.expectVariant+`"`)
+		})
+	}
+}
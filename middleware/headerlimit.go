@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/util"
+)
+
+// HeaderLimitConfig holds configuration for the HeaderLimit middleware. Limits
+// of 0 are not checked.
+type HeaderLimitConfig struct {
+	// MaxBytes is the maximum size of all the request headers, counted like
+	// net/http as "Name: value\r\n" per value. Default: 64KB
+	MaxBytes int `env:"HEADER_LIMIT_MAX_BYTES"`
+
+	// MaxFieldBytes is the maximum size of a single header, all its values
+	// included. Default: 16KB
+	MaxFieldBytes int `env:"HEADER_LIMIT_MAX_FIELD_BYTES"`
+
+	// MaxCount is the maximum number of header values. Default: 100
+	MaxCount int `env:"HEADER_LIMIT_MAX_COUNT"`
+}
+
+// DefaultHeaderLimitConfig returns default configuration for request header limits
+func DefaultHeaderLimitConfig() HeaderLimitConfig {
+	return HeaderLimitConfig{
+		MaxBytes:      64 << 10,
+		MaxFieldBytes: 16 << 10,
+		MaxCount:      100,
+	}
+}
+
+// LoadHeaderLimitConfig loads HeaderLimitConfig from environment variables
+// Environment variables:
+//   - ENABLE_HEADER_LIMIT (bool): enable/disable request header limits (default: false)
+//   - HEADER_LIMIT_MAX_BYTES (int): maximum size of all the headers (default: 65536)
+//   - HEADER_LIMIT_MAX_FIELD_BYTES (int): maximum size of a header (default: 16384)
+//   - HEADER_LIMIT_MAX_COUNT (int): maximum number of header values (default: 100)
+//
+// Returns nil if ENABLE_HEADER_LIMIT=false
+func LoadHeaderLimitConfig() *HeaderLimitConfig {
+	if !util.GetEnvBool("ENABLE_HEADER_LIMIT", false) {
+		return nil
+	}
+
+	cfg := DefaultHeaderLimitConfig()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// HeaderLimit rejects requests whose headers exceed the limits with a 431
+// Request Header Fields Too Large JSON error naming the header at fault: the
+// header over MaxFieldBytes, or the largest one when all the headers are over
+// MaxBytes. Header values are never included in the response.
+//
+// It is a softer limit than the server's MaxHeaderBytes (see
+// glib.Config.MaxHeaderBytes), whose requests are refused by net/http before
+// reaching the handlers with a plain text response.
+func HeaderLimit(config HeaderLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := checkHeaderLimits(r.Header, config); err != nil {
+				writeError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checkHeaderLimits returns the error of the first limit exceeded by the headers
+func checkHeaderLimits(header http.Header, config HeaderLimitConfig) *errors.ApiError {
+	total, count := 0, 0
+	largest, largestSize := "", 0
+	for name, values := range header {
+		size := 0
+		for _, value := range values {
+			// "Name: value\r\n"
+			size += len(name) + len(value) + 4
+		}
+		if config.MaxFieldBytes > 0 && size > config.MaxFieldBytes {
+			return errors.RequestHeaderFieldsTooLarge(fmt.Sprintf("Request header %s is too large", name), nil)
+		}
+		if size > largestSize || (size == largestSize && name < largest) {
+			largest, largestSize = name, size
+		}
+		total += size
+		count += len(values)
+	}
+
+	if config.MaxCount > 0 && count > config.MaxCount {
+		return errors.RequestHeaderFieldsTooLarge("Too many request headers", nil)
+	}
+	if config.MaxBytes > 0 && total > config.MaxBytes {
+		return errors.RequestHeaderFieldsTooLarge(fmt.Sprintf("Request headers are too large, the largest is %s", largest), nil)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderLimit(t *testing.T) {
+	handler := HeaderLimit(HeaderLimitConfig{MaxBytes: 1024, MaxFieldBytes: 512, MaxCount: 10})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		desc    string
+		header  http.Header
+		code    int
+		message string
+	}{
+		{
+			desc:   "within the limits",
+			header: http.Header{"Cookie": {"session=abc"}, "Accept": {"application/json"}},
+			code:   http.StatusOK,
+		},
+		{
+			desc:    "field too large",
+			header:  http.Header{"Cookie": {"session=" + strings.Repeat("s", 600)}},
+			code:    http.StatusRequestHeaderFieldsTooLarge,
+			message: "Request header Cookie is too large",
+		},
+		{
+			desc:    "field too large with several values",
+			header:  http.Header{"X-Forwarded-For": {strings.Repeat("1", 300), strings.Repeat("2", 300)}},
+			code:    http.StatusRequestHeaderFieldsTooLarge,
+			message: "Request header X-Forwarded-For is too large",
+		},
+		{
+			desc: "headers too large",
+			header: http.Header{
+				"Cookie":        {strings.Repeat("c", 400)},
+				"Authorization": {strings.Repeat("a", 450)},
+				"X-Trace":       {strings.Repeat("t", 300)},
+			},
+			code:    http.StatusRequestHeaderFieldsTooLarge,
+			message: "Request headers are too large, the largest is Authorization",
+		},
+		{
+			desc: "too many headers",
+			header: func() http.Header {
+				h := http.Header{}
+				for i := range 11 {
+					h.Set(fmt.Sprintf("X-Custom-%d", i), "v")
+				}
+				return h
+			}(),
+			code:    http.StatusRequestHeaderFieldsTooLarge,
+			message: "Too many request headers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header = tt.header
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.message == "" {
+				return
+			}
+			var body struct {
+				Data string `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.message, body.Data)
+			assert.NotContains(t, w.Body.String(), "ssss", "header values are not reported")
+		})
+	}
+}
+
+func TestLoadHeaderLimitConfig(t *testing.T) {
+	t.Setenv("ENABLE_HEADER_LIMIT", "false")
+	assert.Nil(t, LoadHeaderLimitConfig())
+
+	t.Setenv("ENABLE_HEADER_LIMIT", "true")
+	t.Setenv("HEADER_LIMIT_MAX_COUNT", "50")
+	cfg := LoadHeaderLimitConfig()
+	require.NotNil(t, cfg)
+	assert.Equal(t, 50, cfg.MaxCount)
+	assert.Equal(t, DefaultHeaderLimitConfig().MaxBytes, cfg.MaxBytes)
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/azizndao/glib/util"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// HeaderLintRule is a check of the HeaderLint middleware
+type HeaderLintRule struct {
+	// Name identifies the finding in the logs, e.g. "cookie-insecure"
+	Name string
+
+	// Check returns a description of the problem of the response headers, or an
+	// empty string when there is none
+	Check func(r *http.Request, status int, header http.Header) string
+}
+
+var (
+	headerLintMu    sync.RWMutex
+	headerLintRules = []HeaderLintRule{
+		{Name: "cookie-insecure", Check: lintCookies},
+		{Name: "text-charset", Check: lintCharset},
+		{Name: "cors-credentials-wildcard", Check: lintCORSCredentials},
+		{Name: "auth-cache-control", Check: lintAuthCacheControl},
+	}
+)
+
+// RegisterHeaderLintRule adds a rule to the checks of the HeaderLint
+// middleware, e.g. for the headers required by the API contract. A rule with
+// the name of a registered one replaces it.
+//
+// Example:
+//
+//	middleware.RegisterHeaderLintRule(middleware.HeaderLintRule{
+//	    Name: "api-version",
+//	    Check: func(r *http.Request, status int, header http.Header) string {
+//	        if header.Get("API-Version") == "" {
+//	            return "API-Version header is missing"
+//	        }
+//	        return ""
+//	    },
+//	})
+func RegisterHeaderLintRule(rule HeaderLintRule) {
+	headerLintMu.Lock()
+	defer headerLintMu.Unlock()
+	if i := slices.IndexFunc(headerLintRules, func(r HeaderLintRule) bool { return r.Name == rule.Name }); i >= 0 {
+		headerLintRules[i] = rule
+		return
+	}
+	headerLintRules = append(headerLintRules, rule)
+}
+
+// HeaderLintRules returns the registered rules of the HeaderLint middleware,
+// starting with the built-in ones
+func HeaderLintRules() []HeaderLintRule {
+	headerLintMu.RLock()
+	defer headerLintMu.RUnlock()
+	return slices.Clone(headerLintRules)
+}
+
+// HeaderLintConfig holds configuration for the HeaderLint middleware
+type HeaderLintConfig struct {
+	// Logger logs the findings (default: slog.Default())
+	Logger *slog.Logger
+
+	// Rules are the checks run on each response. Default: HeaderLintRules(),
+	// including the rules registered later
+	Rules []HeaderLintRule
+}
+
+// LoadHeaderLintConfig loads HeaderLintConfig from environment variables
+// Environment variables:
+//   - IS_DEBUG (bool): the audit only runs in debug mode
+//   - ENABLE_HEADER_LINT (bool): enable/disable the audit in debug mode (default: true)
+//
+// Returns nil if IS_DEBUG=false or ENABLE_HEADER_LINT=false
+func LoadHeaderLintConfig() *HeaderLintConfig {
+	if !util.GetEnvBool("IS_DEBUG", false) || !util.GetEnvBool("ENABLE_HEADER_LINT", true) {
+		return nil
+	}
+	return &HeaderLintConfig{}
+}
+
+// HeaderLint audits the response headers once the handler returned and warns
+// about common mistakes:
+//   - cookie-insecure: Set-Cookie without Secure or HttpOnly on HTTPS
+//   - text-charset: text content type without charset
+//   - cors-credentials-wildcard: Access-Control-Allow-Origin "*" with credentials,
+//     rejected by browsers
+//   - auth-cache-control: JSON response to an authenticated request without
+//     Cache-Control, which shared caches may store
+//
+// Each finding is logged once per route, so a mistake doesn't flood the logs.
+// More rules can be added with RegisterHeaderLintRule.
+//
+// DEVELOPMENT ONLY: the Stack adds it when IS_DEBUG=true.
+func HeaderLint(config ...HeaderLintConfig) func(http.Handler) http.Handler {
+	var cfg HeaderLintConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	var reported sync.Map // route + "\x00" + rule name
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			rules := cfg.Rules
+			if rules == nil {
+				rules = HeaderLintRules()
+			}
+			route := orPath(routePattern(r), r)
+			for _, rule := range rules {
+				message := rule.Check(r, status, ww.Header())
+				if message == "" {
+					continue
+				}
+				if _, seen := reported.LoadOrStore(route+"\x00"+rule.Name, struct{}{}); seen {
+					continue
+				}
+				logger.WarnContext(r.Context(), "Response header issue: "+message,
+					"rule", rule.Name,
+					"method", r.Method,
+					"route", route,
+				)
+			}
+		})
+	}
+}
+
+// lintCookies reports the cookies set on HTTPS without Secure or HttpOnly
+func lintCookies(r *http.Request, status int, header http.Header) string {
+	if r.TLS == nil && !strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		return ""
+	}
+	var problems []string
+	for _, line := range header.Values("Set-Cookie") {
+		cookie, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+		var missing []string
+		if !cookie.Secure {
+			missing = append(missing, "Secure")
+		}
+		if !cookie.HttpOnly {
+			missing = append(missing, "HttpOnly")
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("%s without %s", cookie.Name, strings.Join(missing, " and ")))
+		}
+	}
+	if len(problems) == 0 {
+		return ""
+	}
+	return "cookie set on HTTPS: " + strings.Join(problems, ", ")
+}
+
+// lintCharset reports the text content types without charset
+func lintCharset(r *http.Request, status int, header http.Header) string {
+	contentType := header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "text/") || params["charset"] != "" {
+		return ""
+	}
+	return fmt.Sprintf("Content-Type %q has no charset", contentType)
+}
+
+// lintCORSCredentials reports the wildcard origin allowed with credentials
+func lintCORSCredentials(r *http.Request, status int, header http.Header) string {
+	if header.Get("Access-Control-Allow-Origin") != "*" || !strings.EqualFold(header.Get("Access-Control-Allow-Credentials"), "true") {
+		return ""
+	}
+	return `Access-Control-Allow-Origin "*" with credentials is rejected by browsers`
+}
+
+// lintAuthCacheControl reports the JSON responses to authenticated requests
+// without Cache-Control
+func lintAuthCacheControl(r *http.Request, status int, header http.Header) string {
+	if r.Header.Get("Authorization") == "" || status >= http.StatusMultipleChoices || header.Get("Cache-Control") != "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return ""
+	}
+	return "JSON response to an authenticated request has no Cache-Control"
+}
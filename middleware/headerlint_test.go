@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintFindings returns the rules of the findings logged by HeaderLint
+func lintFindings(t *testing.T, logs *bytes.Buffer) []string {
+	t.Helper()
+	var rules []string
+	for line := range strings.Lines(logs.String()) {
+		var entry struct {
+			Rule string `json:"rule"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		rules = append(rules, entry.Rule)
+	}
+	return rules
+}
+
+func TestHeaderLint(t *testing.T) {
+	tests := []struct {
+		desc          string
+		setup         func(req *http.Request)
+		handler       http.HandlerFunc
+		expectFinding string
+	}{
+		{
+			desc:  "cookie without Secure on HTTPS",
+			setup: func(req *http.Request) { req.TLS = &tls.ConnectionState{} },
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", HttpOnly: true})
+			},
+			expectFinding: "cookie-insecure",
+		},
+		{
+			desc:  "cookie without HttpOnly behind a TLS proxy",
+			setup: func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "https") },
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Secure: true})
+			},
+			expectFinding: "cookie-insecure",
+		},
+		{
+			desc: "cookie on HTTP",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+			},
+		},
+		{
+			desc:  "secure cookie",
+			setup: func(req *http.Request) { req.TLS = &tls.ConnectionState{} },
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Secure: true, HttpOnly: true})
+			},
+		},
+		{
+			desc: "text without charset",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+			},
+			expectFinding: "text-charset",
+		},
+		{
+			desc: "text with charset",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			},
+		},
+		{
+			desc: "wildcard origin with credentials",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			},
+			expectFinding: "cors-credentials-wildcard",
+		},
+		{
+			desc:  "authenticated JSON without Cache-Control",
+			setup: func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") },
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+			},
+			expectFinding: "auth-cache-control",
+		},
+		{
+			desc:  "authenticated JSON with Cache-Control",
+			setup: func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") },
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Cache-Control", "private, no-store")
+			},
+		},
+		{
+			desc:  "authenticated JSON error",
+			setup: func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") },
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var logs bytes.Buffer
+			handler := HeaderLint(HeaderLintConfig{Logger: slog.New(slog.NewJSONHandler(&logs, nil))})(tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.expectFinding == "" {
+				assert.Empty(t, logs.String())
+			} else {
+				assert.Equal(t, []string{tt.expectFinding}, lintFindings(t, &logs))
+			}
+		})
+	}
+}
+
+func TestHeaderLint_OncePerRoute(t *testing.T) {
+	var logs bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(HeaderLint(HeaderLintConfig{Logger: slog.New(slog.NewJSONHandler(&logs, nil))}))
+	textHandler := func(w http.ResponseWriter, r *http.Request) { w.Header().Set("Content-Type", "text/plain") }
+	r.Get("/users/{id}", textHandler)
+	r.Get("/orders", textHandler)
+
+	for _, path := range []string{"/users/1", "/users/2", "/orders", "/orders"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, []string{"text-charset", "text-charset"}, lintFindings(t, &logs))
+	assert.Contains(t, logs.String(), `"route":"/users/{id}"`)
+	assert.Contains(t, logs.String(), `"route":"/orders"`)
+}
+
+func TestRegisterHeaderLintRule(t *testing.T) {
+	rules := HeaderLintRules()
+	t.Cleanup(func() { headerLintRules = rules })
+
+	RegisterHeaderLintRule(HeaderLintRule{
+		Name: "api-version",
+		Check: func(r *http.Request, status int, header http.Header) string {
+			if header.Get("API-Version") == "" {
+				return "API-Version header is missing"
+			}
+			return ""
+		},
+	})
+	// Replaces the built-in rule
+	RegisterHeaderLintRule(HeaderLintRule{
+		Name:  "text-charset",
+		Check: func(r *http.Request, status int, header http.Header) string { return "" },
+	})
+
+	var logs bytes.Buffer
+	handler := HeaderLint(HeaderLintConfig{Logger: slog.New(slog.NewJSONHandler(&logs, nil))})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"api-version"}, lintFindings(t, &logs))
+	assert.Contains(t, logs.String(), "Response header issue: API-Version header is missing")
+	assert.Equal(t, len(rules)+1, len(HeaderLintRules()))
+}
+
+func TestStack_HeaderLint(t *testing.T) {
+	names := func() []string {
+		var names []string
+		for _, entry := range StackEntries(StackConfig{Logger: slog.New(slog.DiscardHandler)}) {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+
+	t.Setenv("IS_DEBUG", "false")
+	assert.False(t, slices.Contains(names(), "HeaderLint"))
+
+	t.Setenv("IS_DEBUG", "true")
+	assert.Equal(t, "HeaderLint", names()[len(names())-1])
+
+	t.Setenv("ENABLE_HEADER_LINT", "false")
+	assert.False(t, slices.Contains(names(), "HeaderLint"))
+}
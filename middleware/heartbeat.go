@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/azizndao/glib/util"
+)
+
+// DefaultHeartbeatPath is the default endpoint of the Heartbeat middleware
+const DefaultHeartbeatPath = "/ping"
+
+// HeartbeatConfig holds configuration for the Heartbeat middleware
+type HeartbeatConfig struct {
+	// Path is the endpoint answered by the heartbeat. Default: /ping
+	Path string `env:"HEARTBEAT_PATH"`
+
+	// Version is the build version included in the response, if set
+	Version string `env:"HEARTBEAT_VERSION"`
+
+	// Commit is the build commit included in the response, if set
+	Commit string `env:"HEARTBEAT_COMMIT"`
+}
+
+// DefaultHeartbeatConfig returns default configuration for the heartbeat
+func DefaultHeartbeatConfig() HeartbeatConfig {
+	return HeartbeatConfig{
+		Path: DefaultHeartbeatPath,
+	}
+}
+
+// LoadHeartbeatConfig loads HeartbeatConfig from environment variables
+// Environment variables:
+//   - ENABLE_HEARTBEAT (bool): enable/disable the heartbeat endpoint (default: false)
+//   - HEARTBEAT_PATH (string): endpoint of the heartbeat (default: /ping)
+//   - HEARTBEAT_VERSION (string): build version included in the response (default: none)
+//   - HEARTBEAT_COMMIT (string): build commit included in the response (default: the
+//     VCS revision stamped by go build, if any)
+//
+// Returns nil if ENABLE_HEARTBEAT=false
+func LoadHeartbeatConfig() *HeartbeatConfig {
+	if !util.GetEnvBool("ENABLE_HEARTBEAT", false) {
+		return nil
+	}
+
+	cfg := DefaultHeartbeatConfig()
+	cfg.Commit = vcsRevision()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// Heartbeat answers GET and HEAD requests to the heartbeat endpoint with a
+// constant 200 OK JSON response, for the health checks of load balancers and
+// orchestrators. The Stack places it before the logging middleware, and its
+// logger and metrics middleware skip the heartbeat path even when the heartbeat
+// is registered on the router, so these requests don't inflate the access logs
+// and request counts (unless LOG_HEARTBEAT=true).
+//
+// The response is {"status":"ok"}, with the version and commit of the build
+// when configured. It isn't cacheable.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.Heartbeat(middleware.HeartbeatConfig{
+//	    Path:    "/healthz",
+//	    Version: version, // set with -ldflags "-X main.version=..."
+//	}))
+func Heartbeat(config ...HeartbeatConfig) func(http.Handler) http.Handler {
+	cfg := DefaultHeartbeatConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if cfg.Path == "" {
+			cfg.Path = DefaultHeartbeatPath
+		}
+	}
+
+	body, _ := json.Marshal(struct {
+		Status  string `json:"status"`
+		Version string `json:"version,omitempty"`
+		Commit  string `json:"commit,omitempty"`
+	}{Status: "ok", Version: cfg.Version, Commit: cfg.Commit})
+	contentLength := strconv.Itoa(len(body))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != cfg.Path || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("Content-Type", "application/json")
+			header.Set("Content-Length", contentLength)
+			header.Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(body)
+			}
+		})
+	}
+}
+
+// skipPath bypasses mw for the requests to path, e.g. to exclude the heartbeat
+// from the access logs and metrics
+func skipPath(path string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == path {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// vcsRevision returns the VCS revision stamped in the binary by go build, or an
+// empty string
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeat(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		desc   string
+		config []HeartbeatConfig
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{
+			desc:   "default path",
+			method: http.MethodGet,
+			path:   "/ping",
+			code:   http.StatusOK,
+			body:   `{"status":"ok"}`,
+		},
+		{
+			desc:   "HEAD",
+			method: http.MethodHead,
+			path:   "/ping",
+			code:   http.StatusOK,
+		},
+		{
+			desc:   "other methods reach the router",
+			method: http.MethodPost,
+			path:   "/ping",
+			code:   http.StatusTeapot,
+		},
+		{
+			desc:   "other paths reach the router",
+			method: http.MethodGet,
+			path:   "/users",
+			code:   http.StatusTeapot,
+		},
+		{
+			desc:   "custom path with build info",
+			config: []HeartbeatConfig{{Path: "/healthz", Version: "1.4.2", Commit: "abc123"}},
+			method: http.MethodGet,
+			path:   "/healthz",
+			code:   http.StatusOK,
+			body:   `{"status":"ok","version":"1.4.2","commit":"abc123"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Heartbeat(tt.config...)(next).ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+			if tt.code == http.StatusOK {
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+				assert.NotEmpty(t, w.Header().Get("Content-Length"))
+			}
+		})
+	}
+}
+
+func TestLoadHeartbeatConfig(t *testing.T) {
+	t.Setenv("ENABLE_HEARTBEAT", "false")
+	assert.Nil(t, LoadHeartbeatConfig())
+
+	t.Setenv("ENABLE_HEARTBEAT", "true")
+	t.Setenv("HEARTBEAT_PATH", "/healthz")
+	t.Setenv("HEARTBEAT_VERSION", "1.4.2")
+	t.Setenv("HEARTBEAT_COMMIT", "abc123")
+	cfg := LoadHeartbeatConfig()
+	require.NotNil(t, cfg)
+	assert.Equal(t, HeartbeatConfig{Path: "/healthz", Version: "1.4.2", Commit: "abc123"}, *cfg)
+}
+
+func TestStack_HeartbeatNotLogged(t *testing.T) {
+	t.Setenv("IS_DEBUG", "false")
+	t.Setenv("ENABLE_HEARTBEAT", "true")
+
+	serve := func(t *testing.T, path string) string {
+		var logs bytes.Buffer
+		var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The heartbeat of the router
+			w.WriteHeader(http.StatusOK)
+		})
+		stack := StackWith(StackConfig{Logger: slog.New(slog.NewJSONHandler(&logs, nil))})
+		handler = stack.Handler(handler)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return logs.String()
+	}
+
+	t.Run("stack heartbeat", func(t *testing.T) {
+		assert.Empty(t, serve(t, "/ping"))
+	})
+
+	t.Run("router heartbeat", func(t *testing.T) {
+		t.Setenv("ENABLE_HEARTBEAT", "false")
+		assert.Empty(t, serve(t, "/ping"))
+	})
+
+	t.Run("LOG_HEARTBEAT", func(t *testing.T) {
+		t.Setenv("ENABLE_HEARTBEAT", "false")
+		t.Setenv("LOG_HEARTBEAT", "true")
+		assert.Contains(t, serve(t, "/ping"), "/ping")
+	})
+
+	t.Run("other requests are logged", func(t *testing.T) {
+		assert.Contains(t, serve(t, "/users"), "/users")
+	})
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/util"
+)
+
+// TrustedProxies are the CIDR ranges of the proxies whose forwarding headers
+// are trusted by ClientIP
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDR ranges (or single IPs) of trusted proxies
+func ParseTrustedProxies(values []string) (TrustedProxies, error) {
+	networks, err := parseCIDRs(values)
+	return TrustedProxies(networks), err
+}
+
+// LoadTrustedProxies loads the trusted proxies from environment variables
+// Environment variables:
+//   - TRUSTED_PROXIES ([]string): comma-separated IPs or CIDR ranges of the proxies (default: none)
+//
+// Invalid values make LoadTrustedProxies panic so misconfigurations fail at startup.
+func LoadTrustedProxies() TrustedProxies {
+	proxies, err := ParseTrustedProxies(util.GetEnvStringSlice("TRUSTED_PROXIES", nil))
+	if err != nil {
+		panic(fmt.Sprintf("middleware: TRUSTED_PROXIES: %v", err))
+	}
+	return proxies
+}
+
+// contains reports whether ip is a trusted proxy
+func (p TrustedProxies) contains(ip net.IP) bool {
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the parsed client IP of the request. The True-Client-IP,
+// X-Real-IP and X-Forwarded-For headers are only read when the remote address
+// is a trusted proxy, the client being the last address of X-Forwarded-For
+// that isn't a trusted proxy. Otherwise it is the remote address, as the
+// headers can be forged by clients. Returns nil if no valid IP is found.
+func (p TrustedProxies) ClientIP(r *http.Request) net.IP {
+	remote := remoteIP(r)
+	if remote == nil || !p.contains(remote) {
+		return remote
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("True-Client-IP"))); ip != nil {
+		return ip
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		entries := strings.Split(strings.Join(xff, ","), ",")
+		client := remote
+		for i := len(entries) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(entries[i]))
+			if ip == nil {
+				break
+			}
+			client = ip
+			if !p.contains(ip) {
+				break
+			}
+		}
+		return client
+	}
+	return remote
+}
+
+// remoteIP returns the parsed IP of the remote address of the request
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// ClientIP returns the parsed remote address of the request, without trusting
+// any forwarding header, see TrustedProxies.ClientIP
+func ClientIP(r *http.Request) net.IP {
+	return TrustedProxies(nil).ClientIP(r)
+}
+
+// IPFilterConfig holds configuration for the IPFilter middleware
+type IPFilterConfig struct {
+	// Allow lists the CIDR ranges (or single IPs) allowed. When empty, all IPs
+	// not denied are allowed.
+	Allow []string
+
+	// Deny lists the CIDR ranges (or single IPs) denied. Deny wins over Allow.
+	Deny []string
+
+	// TrustedProxies lists the CIDR ranges (or single IPs) of the proxies whose
+	// forwarding headers are trusted to resolve the client IP. When empty, the
+	// client IP is the remote address. See TrustedProxies.ClientIP.
+	TrustedProxies []string
+
+	// HideExistence responds with 404 Not Found instead of 403 Forbidden so the
+	// existence of the protected routes isn't revealed
+	HideExistence bool
+
+	// Logger logs denied requests. Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// IPFilter allows or denies requests based on the client IP (see
+// TrustedProxies.ClientIP).
+// Denied requests receive a 403 Forbidden, or 404 Not Found with HideExistence,
+// and are logged with the resolved IP.
+//
+// Invalid CIDR ranges make IPFilter panic so misconfigurations fail at startup.
+//
+// Example:
+//
+//	r.With(middleware.IPFilter(middleware.IPFilterConfig{
+//	    Allow:          []string{"10.8.0.0/16", "192.168.1.10"},
+//	    TrustedProxies: []string{"172.16.0.0/12"}, // the load balancers
+//	})).Route("/admin", adminRoutes)
+func IPFilter(config IPFilterConfig) func(http.Handler) http.Handler {
+	allow := mustParseCIDRs(config.Allow)
+	deny := mustParseCIDRs(config.Deny)
+	proxies := TrustedProxies(mustParseCIDRs(config.TrustedProxies))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := proxies.ClientIP(r)
+			if ipAllowed(ip, allow, deny) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger := config.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.WarnContext(r.Context(), "request denied by IP filter",
+				"ip", ip.String(),
+				"method", r.Method,
+				"path", r.URL.Path,
+			)
+
+			if config.HideExistence {
+				writeError(w, errors.NotFound(http.StatusText(http.StatusNotFound), nil))
+				return
+			}
+			writeError(w, errors.Forbidden(http.StatusText(http.StatusForbidden), nil))
+		})
+	}
+}
+
+// ipAllowed reports whether ip is allowed by the lists, deny winning over allow
+func ipAllowed(ip net.IP, allow, deny []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range deny {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	if len(allow) == 0 {
+		return true
+	}
+	for _, network := range allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// mustParseCIDRs parses CIDR ranges like parseCIDRs, panicking when one is invalid
+func mustParseCIDRs(values []string) []*net.IPNet {
+	networks, err := parseCIDRs(values)
+	if err != nil {
+		panic(fmt.Sprintf("middleware: %v in IP filter", err))
+	}
+	return networks
+}
+
+// parseCIDRs parses CIDR ranges, single IPs being converted to /32 or /128 ranges
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", value, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
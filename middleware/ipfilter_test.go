@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilter(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cases := []struct {
+		desc     string
+		config   IPFilterConfig
+		ip       string
+		expected int
+	}{
+		{desc: "allowed range", config: IPFilterConfig{Allow: []string{"10.8.0.0/16"}}, ip: "10.8.1.2", expected: http.StatusOK},
+		{desc: "outside allowed range", config: IPFilterConfig{Allow: []string{"10.8.0.0/16"}}, ip: "10.9.1.2", expected: http.StatusForbidden},
+		{desc: "single IP", config: IPFilterConfig{Allow: []string{"192.168.1.10"}}, ip: "192.168.1.10", expected: http.StatusOK},
+		{desc: "deny wins", config: IPFilterConfig{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.0.0.5"}}, ip: "10.0.0.5", expected: http.StatusForbidden},
+		{desc: "deny only", config: IPFilterConfig{Deny: []string{"203.0.113.0/24"}}, ip: "198.51.100.1", expected: http.StatusOK},
+		{desc: "ipv6", config: IPFilterConfig{Allow: []string{"2001:db8::/32"}}, ip: "2001:db8::1", expected: http.StatusOK},
+		{desc: "hide existence", config: IPFilterConfig{Allow: []string{"10.8.0.0/16"}, HideExistence: true}, ip: "1.2.3.4", expected: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.config.Logger = discard
+			req := httptest.NewRequest("GET", "/admin", nil)
+			req.RemoteAddr = net.JoinHostPort(tc.ip, "1234")
+			w := httptest.NewRecorder()
+			IPFilter(tc.config)(ok).ServeHTTP(w, req)
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+
+	t.Run("logs denied attempts with the resolved IP", func(t *testing.T) {
+		var logs bytes.Buffer
+		handler := IPFilter(IPFilterConfig{
+			Allow:          []string{"10.8.0.0/16"},
+			TrustedProxies: []string{"192.0.2.1", "10.8.0.1"},
+			Logger:         slog.New(slog.NewJSONHandler(&logs, nil)),
+		})(ok)
+
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.Header.Set("X-Forwarded-For", "198.51.100.7, 10.8.0.1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Contains(t, logs.String(), `"ip":"198.51.100.7"`)
+	})
+
+	t.Run("invalid CIDR fails fast", func(t *testing.T) {
+		assert.Panics(t, func() { IPFilter(IPFilterConfig{Allow: []string{"10.0.0.0/33"}}) })
+		assert.Panics(t, func() { IPFilter(IPFilterConfig{TrustedProxies: []string{"proxy"}}) })
+		assert.Panics(t, func() { IPFilter(IPFilterConfig{Deny: []string{"not-an-ip"}}) })
+	})
+}
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		desc    string
+		proxies TrustedProxies
+		remote  string
+		headers map[string]string
+		expect  string
+	}{
+		{
+			desc:    "untrusted remote address ignores the headers",
+			proxies: proxies,
+			remote:  "198.51.100.7",
+			headers: map[string]string{"X-Real-IP": "10.8.0.1", "X-Forwarded-For": "10.8.0.1"},
+			expect:  "198.51.100.7",
+		},
+		{
+			desc:    "no trusted proxies",
+			remote:  "192.0.2.1",
+			headers: map[string]string{"True-Client-IP": "10.8.0.1"},
+			expect:  "192.0.2.1",
+		},
+		{
+			desc:    "X-Real-IP from a trusted proxy",
+			proxies: proxies,
+			remote:  "192.0.2.1",
+			headers: map[string]string{"X-Real-IP": "203.0.113.9"},
+			expect:  "203.0.113.9",
+		},
+		{
+			desc:    "last untrusted X-Forwarded-For address",
+			proxies: proxies,
+			remote:  "10.0.0.2",
+			headers: map[string]string{"X-Forwarded-For": "10.8.0.1, 203.0.113.9, 10.0.0.3"},
+			expect:  "203.0.113.9",
+		},
+		{
+			desc:    "X-Forwarded-For of trusted proxies only",
+			proxies: proxies,
+			remote:  "10.0.0.2",
+			headers: map[string]string{"X-Forwarded-For": "10.0.0.4, 10.0.0.3"},
+			expect:  "10.0.0.4",
+		},
+		{
+			desc:    "invalid X-Forwarded-For address",
+			proxies: proxies,
+			remote:  "10.0.0.2",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.9, unknown, 10.0.0.3"},
+			expect:  "10.0.0.3",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = net.JoinHostPort(tc.remote, "1234")
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			assert.Equal(t, tc.expect, tc.proxies.ClientIP(req).String())
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package middleware
+
+import (
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/httputil"
+	"github.com/azizndao/glib/util"
+)
+
+// LoadShedConfig holds configuration for the LoadShed middleware.
+//
+// The load is the highest ratio among the configured signals: in-flight
+// requests / MaxInFlight, approximate p95 latency / MaxLatency and Probe().
+// Shedding starts when the load reaches 1 and stops once it falls below
+// LowWatermark, so it doesn't flap around the threshold.
+type LoadShedConfig struct {
+	// MaxInFlight is the number of concurrent requests considered as full load (0 disables the signal)
+	MaxInFlight int `env:"LOAD_SHED_MAX_IN_FLIGHT"`
+
+	// MaxLatency is the p95 latency considered as full load (0 disables the signal)
+	MaxLatency time.Duration `env:"LOAD_SHED_MAX_LATENCY"`
+
+	// Probe returns a custom load ratio, 1 meaning full load (nil disables the signal)
+	Probe func() float64
+
+	// LowWatermark is the load under which shedding stops (default: 0.8)
+	LowWatermark float64
+
+	// MinShedRate and MaxShedRate bound the fraction of rejected requests while shedding.
+	// The rate grows with the overload (1 - 1/load). Default: 0.1 and 0.9
+	MinShedRate float64
+	MaxShedRate float64
+
+	// Exempt lists critical path patterns never shed (health checks, payments...).
+	// Patterns use path.Match syntax and a trailing "/*" matches the whole subtree.
+	Exempt []string `env:"LOAD_SHED_EXEMPT"`
+
+	// IsCritical marks additional requests as never shed
+	IsCritical func(r *http.Request) bool
+
+	// RetryAfter is the value of the Retry-After header of rejected requests (default: 5s)
+	RetryAfter time.Duration `env:"LOAD_SHED_RETRY_AFTER"`
+
+	// Interval is the minimum delay between two evaluations of the load (default: 100ms)
+	Interval time.Duration
+
+	// Metrics receives the "loadshed_rate" gauge and "loadshed_rejected_total" counter
+	Metrics MetricsCollector
+}
+
+// DefaultLoadShedConfig returns default configuration for load shedding
+func DefaultLoadShedConfig() LoadShedConfig {
+	return LoadShedConfig{
+		LowWatermark: 0.8,
+		MinShedRate:  0.1,
+		MaxShedRate:  0.9,
+		RetryAfter:   5 * time.Second,
+		Interval:     100 * time.Millisecond,
+	}
+}
+
+// LoadLoadShedConfig loads LoadShedConfig from environment variables
+// Environment variables:
+//   - ENABLE_LOAD_SHED (bool): enable/disable load shedding (default: false)
+//   - LOAD_SHED_MAX_IN_FLIGHT (int): concurrent requests considered as full load
+//   - LOAD_SHED_MAX_LATENCY (duration): p95 latency considered as full load
+//   - LOAD_SHED_EXEMPT (comma-separated): path patterns never shed
+//   - LOAD_SHED_RETRY_AFTER (duration): Retry-After of rejected requests
+//
+// Returns nil if ENABLE_LOAD_SHED=false
+func LoadLoadShedConfig() *LoadShedConfig {
+	if !util.GetEnvBool("ENABLE_LOAD_SHED", false) {
+		return nil
+	}
+
+	cfg := DefaultLoadShedConfig()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// LoadShedder rejects a fraction of non-critical requests with 503 Service
+// Unavailable when the server is overloaded
+type LoadShedder struct {
+	config   LoadShedConfig
+	metrics  MetricsCollector
+	inFlight atomic.Int64
+	latency  quantileEstimator
+	shedRate atomic.Uint64 // math.Float64bits of the current shed rate
+	shedding bool
+	mu       sync.Mutex
+	lastEval time.Time
+	random   func() float64
+}
+
+// NewLoadShedder creates a load shedder from the given configuration
+func NewLoadShedder(config LoadShedConfig) *LoadShedder {
+	defaults := DefaultLoadShedConfig()
+	if config.LowWatermark <= 0 || config.LowWatermark >= 1 {
+		config.LowWatermark = defaults.LowWatermark
+	}
+	if config.MinShedRate <= 0 {
+		config.MinShedRate = defaults.MinShedRate
+	}
+	if config.MaxShedRate <= 0 || config.MaxShedRate > 1 {
+		config.MaxShedRate = defaults.MaxShedRate
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = defaults.RetryAfter
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+
+	return &LoadShedder{
+		config:  config,
+		metrics: metricsOrNoop(config.Metrics),
+		latency: quantileEstimator{quantile: 0.95},
+		random:  rand.Float64,
+	}
+}
+
+// ShedRate returns the fraction of non-critical requests currently rejected
+func (l *LoadShedder) ShedRate() float64 {
+	return math.Float64frombits(l.shedRate.Load())
+}
+
+// Load returns the current load ratio, 1 meaning full load
+func (l *LoadShedder) Load() float64 {
+	load := 0.0
+	if l.config.MaxInFlight > 0 {
+		load = max(load, float64(l.inFlight.Load())/float64(l.config.MaxInFlight))
+	}
+	if l.config.MaxLatency > 0 {
+		load = max(load, l.latency.value()/float64(l.config.MaxLatency))
+	}
+	if l.config.Probe != nil {
+		load = max(load, l.config.Probe())
+	}
+	return load
+}
+
+// evaluate updates the shed rate from the current load, at most once per Interval
+func (l *LoadShedder) evaluate(now time.Time) {
+	if !l.mu.TryLock() {
+		return
+	}
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastEval) < l.config.Interval {
+		return
+	}
+	l.lastEval = now
+
+	load := l.Load()
+	switch {
+	case load >= 1:
+		l.shedding = true
+	case load < l.config.LowWatermark:
+		l.shedding = false
+	}
+
+	rate := 0.0
+	if l.shedding {
+		rate = min(max(1-1/max(load, 1), l.config.MinShedRate), l.config.MaxShedRate)
+	}
+
+	if rate != l.ShedRate() {
+		l.shedRate.Store(math.Float64bits(rate))
+		l.metrics.Gauge("loadshed_rate", rate)
+	}
+}
+
+// critical reports whether the request must never be shed
+func (l *LoadShedder) critical(r *http.Request) bool {
+	if len(l.config.Exempt) > 0 && matchAnyPath(l.config.Exempt, r.URL.Path) {
+		return true
+	}
+	return l.config.IsCritical != nil && l.config.IsCritical(r)
+}
+
+// Middleware returns the load shedding middleware
+func (l *LoadShedder) Middleware() func(http.Handler) http.Handler {
+	retryAfter := httputil.FormatRetryAfter(l.config.RetryAfter)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			l.evaluate(start)
+
+			if rate := l.ShedRate(); rate > 0 && !l.critical(r) && l.random() < rate {
+				l.metrics.Counter("loadshed_rejected_total", 1)
+				w.Header().Set("Retry-After", retryAfter)
+				writeError(w, errors.ServiceUnavailable("Server is overloaded", nil))
+				return
+			}
+
+			l.inFlight.Add(1)
+			defer func() {
+				l.inFlight.Add(-1)
+				if l.config.MaxLatency > 0 {
+					l.latency.observe(float64(time.Since(start)))
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LoadShed rejects a fraction of non-critical requests with 503 Service Unavailable
+// and a Retry-After header when the server is overloaded. See LoadShedConfig.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.LoadShed(middleware.LoadShedConfig{
+//	    MaxInFlight: 500,
+//	    MaxLatency:  2 * time.Second,
+//	    Exempt:      []string{"/health", "/payments/*"},
+//	}))
+func LoadShed(config LoadShedConfig) func(http.Handler) http.Handler {
+	return NewLoadShedder(config).Middleware()
+}
+
+// quantileEstimator is a streaming estimate of a latency quantile. Each sample moves
+// the estimate up or down by a step proportional to the estimate, so it behaves
+// like an exponentially weighted moving quantile.
+type quantileEstimator struct {
+	mu       sync.Mutex
+	quantile float64
+	estimate float64
+}
+
+func (q *quantileEstimator) observe(sample float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.estimate == 0 {
+		q.estimate = sample
+		return
+	}
+
+	step := 0.05 * q.estimate
+	if sample > q.estimate {
+		q.estimate += step * q.quantile
+	} else {
+		q.estimate -= step * (1 - q.quantile)
+	}
+}
+
+func (q *quantileEstimator) value() float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.estimate
+}
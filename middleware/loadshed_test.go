@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedMetrics struct {
+	mu       sync.Mutex
+	gauges   map[string]float64
+	counters map[string]float64
+}
+
+func newRecordedMetrics() *recordedMetrics {
+	return &recordedMetrics{gauges: map[string]float64{}, counters: map[string]float64{}}
+}
+
+func (m *recordedMetrics) Counter(name string, value float64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += value
+}
+
+func (m *recordedMetrics) Gauge(name string, value float64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[name] = value
+}
+
+func (m *recordedMetrics) Observe(name string, value float64, labels ...string) {}
+
+func TestLoadShed(t *testing.T) {
+	load := 0.0
+	metrics := newRecordedMetrics()
+	shedder := NewLoadShedder(LoadShedConfig{
+		Probe:    func() float64 { return load },
+		Exempt:   []string{"/health"},
+		Interval: time.Nanosecond,
+		Metrics:  metrics,
+	})
+	shedder.random = func() float64 { return 0 } // always shed while the rate is positive
+
+	handler := shedder.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		time.Sleep(time.Microsecond)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/search").Code)
+	assert.Zero(t, shedder.ShedRate())
+
+	load = 2
+	w := serve("/search")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	assert.InDelta(t, 0.5, shedder.ShedRate(), 0.001)
+	assert.InDelta(t, 0.5, metrics.gauges["loadshed_rate"], 0.001)
+	assert.Equal(t, http.StatusOK, serve("/health").Code, "exempt routes are never shed")
+
+	load = 0.9
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/search").Code, "shedding continues above the low watermark")
+	assert.InDelta(t, 0.1, shedder.ShedRate(), 0.001)
+
+	load = 0.5
+	assert.Equal(t, http.StatusOK, serve("/search").Code)
+	assert.Zero(t, shedder.ShedRate())
+	assert.Zero(t, metrics.gauges["loadshed_rate"])
+	assert.Equal(t, float64(2), metrics.counters["loadshed_rejected_total"])
+}
+
+func TestLoadShed_InFlight(t *testing.T) {
+	shedder := NewLoadShedder(LoadShedConfig{MaxInFlight: 1, Interval: time.Nanosecond})
+	shedder.random = func() float64 { return 0 }
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := shedder.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	<-started
+	time.Sleep(time.Microsecond)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	close(release)
+}
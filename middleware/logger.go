@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/httplog/v3"
+)
+
+// LoggerConfig holds configuration for the structured request Logger middleware
+type LoggerConfig struct {
+	// Logger receives the request logs. Default: slog.Default()
+	Logger *slog.Logger
+
+	// ClientErrorLevel is the level of the 4xx responses, except 404 and 429
+	// (logged at info level). Default: slog.LevelWarn, see DefaultLoggerConfig
+	ClientErrorLevel slog.Level
+
+	// NotFoundLevel is the level of the 404 responses. Default: slog.LevelWarn
+	NotFoundLevel slog.Level
+
+	// StatusLevel returns the level of the log of a response, taking
+	// precedence over the levels of the config and of the routes. Its result
+	// is only honored when the logger is enabled at that level.
+	StatusLevel func(status int, r *http.Request) slog.Level
+}
+
+// DefaultLoggerConfig returns default configuration for request logging
+func DefaultLoggerConfig() LoggerConfig {
+	return LoggerConfig{
+		ClientErrorLevel: slog.LevelWarn,
+		NotFoundLevel:    slog.LevelWarn,
+	}
+}
+
+// LoadLoggerConfig loads LoggerConfig from environment variables
+// Environment variables:
+//   - LOGGER_4XX_LEVEL (string): debug, info, warn or error, level of the 4xx responses (default: warn)
+//   - LOGGER_404_LEVEL (string): level of the 404 responses (default: LOGGER_4XX_LEVEL)
+func LoadLoggerConfig() LoggerConfig {
+	cfg := DefaultLoggerConfig()
+	env := struct {
+		ClientErrorLevel string `env:"LOGGER_4XX_LEVEL" default:"warn" oneof:"debug,info,warn,error"`
+		NotFoundLevel    string `env:"LOGGER_404_LEVEL" oneof:"debug,info,warn,error"`
+	}{}
+	loadEnv(&env)
+	_ = cfg.ClientErrorLevel.UnmarshalText([]byte(env.ClientErrorLevel))
+	cfg.NotFoundLevel = cfg.ClientErrorLevel
+	if env.NotFoundLevel != "" {
+		_ = cfg.NotFoundLevel.UnmarshalText([]byte(env.NotFoundLevel))
+	}
+	return cfg
+}
+
+// logLevelKey is the context key of the logLevels of a request
+type logLevelKey struct{}
+
+// logLevels holds the response status and the level override of a request
+// logged by Logger
+type logLevels struct {
+	req         *http.Request
+	status      int
+	clientError *slog.Level
+}
+
+// SetClientErrorLevel overrides the level of the log of a 4xx response for
+// the request, e.g. to log the expected 404 of a route at info level. It is
+// called by glib for the routes with the "log-4xx" metadata, and reports
+// whether the request is logged by Logger.
+func SetClientErrorLevel(r *http.Request, level slog.Level) bool {
+	levels, ok := r.Context().Value(logLevelKey{}).(*logLevels)
+	if ok {
+		levels.clientError = &level
+	}
+	return ok
+}
+
+// Logger logs the requests with httplog in the structured format, the 5xx
+// responses at error level, the 4xx ones at ClientErrorLevel (NotFoundLevel for
+// 404, info for 429) or the level set for the route with SetClientErrorLevel,
+// and the others at info level. StatusLevel overrides all of them.
+//
+// Example:
+//
+//	cfg := middleware.LoadLoggerConfig()
+//	cfg.StatusLevel = func(status int, r *http.Request) slog.Level {
+//	    if status == http.StatusUnprocessableEntity {
+//	        return slog.LevelInfo
+//	    }
+//	    return middleware.DefaultStatusLevel(status, r)
+//	}
+//	r.Use(middleware.Logger(cfg))
+func Logger(config ...LoggerConfig) func(http.Handler) http.Handler {
+	cfg := DefaultLoggerConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	requestLogger := httplog.RequestLogger(
+		slog.New(&levelHandler{Handler: logger.Handler(), config: cfg}),
+		&httplog.Options{
+			// Record the status for levelHandler, called before the log is built
+			Skip: func(r *http.Request, status int) bool {
+				if levels, ok := r.Context().Value(logLevelKey{}).(*logLevels); ok {
+					levels.status = status
+				}
+				return false
+			},
+		},
+	)
+	return func(next http.Handler) http.Handler {
+		logged := requestLogger(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			levels := &logLevels{}
+			r = r.WithContext(context.WithValue(r.Context(), logLevelKey{}, levels))
+			levels.req = r
+			logged.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DefaultStatusLevel returns the level of the log of a response without
+// override: error for 5xx, warn for 4xx except info for 429, and info
+// otherwise
+func DefaultStatusLevel(status int, _ *http.Request) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status == http.StatusTooManyRequests:
+		return slog.LevelInfo
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// levelHandler changes the level of the request logs of httplog according to
+// the LoggerConfig and the route overrides
+type levelHandler struct {
+	slog.Handler
+	config LoggerConfig
+}
+
+// Enabled lets httplog build the logs whose level may be raised by StatusLevel
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.config.StatusLevel != nil || h.Handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	if levels, ok := ctx.Value(logLevelKey{}).(*logLevels); ok {
+		record.Level = h.level(levels, record.Level)
+	}
+	if !h.Handler.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// level returns the level of the log of the response
+func (h *levelHandler) level(levels *logLevels, level slog.Level) slog.Level {
+	status := levels.status
+	switch {
+	case h.config.StatusLevel != nil:
+		return h.config.StatusLevel(status, levels.req)
+	case status < http.StatusBadRequest || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests:
+		return level
+	case levels.clientError != nil:
+		return *levels.clientError
+	case status == http.StatusNotFound:
+		return h.config.NotFoundLevel
+	}
+	return h.config.ClientErrorLevel
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), config: h.config}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), config: h.config}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Levels(t *testing.T) {
+	cases := []struct {
+		desc         string
+		config       func(cfg *LoggerConfig)
+		routeLevel   string
+		loggerLevel  slog.Level
+		expectLevels map[int]string
+	}{
+		{
+			desc:         "defaults",
+			expectLevels: map[int]string{200: "INFO", 404: "WARN", 422: "WARN", 429: "INFO", 500: "ERROR"},
+		},
+		{
+			desc: "4xx and 404 levels",
+			config: func(cfg *LoggerConfig) {
+				cfg.ClientErrorLevel = slog.LevelInfo
+				cfg.NotFoundLevel = slog.LevelDebug
+			},
+			expectLevels: map[int]string{200: "INFO", 404: "DEBUG", 422: "INFO", 429: "INFO", 500: "ERROR"},
+		},
+		{
+			desc:         "route override",
+			routeLevel:   "info",
+			expectLevels: map[int]string{200: "INFO", 404: "INFO", 422: "INFO", 429: "INFO", 500: "ERROR"},
+		},
+		{
+			desc: "status level",
+			config: func(cfg *LoggerConfig) {
+				cfg.StatusLevel = func(status int, r *http.Request) slog.Level {
+					if status == http.StatusNotFound {
+						return slog.LevelDebug
+					}
+					return DefaultStatusLevel(status, r) + 4
+				}
+			},
+			routeLevel:   "info",
+			expectLevels: map[int]string{200: "WARN", 404: "DEBUG", 422: "ERROR", 429: "WARN", 500: "ERROR+4"},
+		},
+		{
+			desc: "demoted below the logger level",
+			config: func(cfg *LoggerConfig) {
+				cfg.NotFoundLevel = slog.LevelInfo
+			},
+			loggerLevel:  slog.LevelWarn,
+			expectLevels: map[int]string{200: "", 404: "", 422: "WARN", 429: "", 500: "ERROR"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for status, expectLevel := range tc.expectLevels {
+				var logs bytes.Buffer
+				level := tc.loggerLevel
+				if level == 0 {
+					level = slog.LevelDebug
+				}
+				cfg := DefaultLoggerConfig()
+				cfg.Logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: level}))
+				if tc.config != nil {
+					tc.config(&cfg)
+				}
+				handler := Logger(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if tc.routeLevel != "" {
+						var level slog.Level
+						require.NoError(t, level.UnmarshalText([]byte(tc.routeLevel)))
+						assert.True(t, SetClientErrorLevel(r, level))
+					}
+					w.WriteHeader(status)
+				}))
+
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+				if expectLevel == "" {
+					assert.Empty(t, logs.String(), "status %d", status)
+					continue
+				}
+				var record struct {
+					Level string `json:"level"`
+				}
+				require.NoError(t, json.Unmarshal(logs.Bytes(), &record), "status %d", status)
+				assert.Equal(t, expectLevel, record.Level, "status %d", status)
+			}
+		})
+	}
+}
+
+func TestLoadLoggerConfig(t *testing.T) {
+	cases := []struct {
+		desc   string
+		env    map[string]string
+		expect LoggerConfig
+	}{
+		{
+			desc:   "defaults",
+			expect: LoggerConfig{ClientErrorLevel: slog.LevelWarn, NotFoundLevel: slog.LevelWarn},
+		},
+		{
+			desc:   "4xx level applies to 404",
+			env:    map[string]string{"LOGGER_4XX_LEVEL": "info"},
+			expect: LoggerConfig{ClientErrorLevel: slog.LevelInfo, NotFoundLevel: slog.LevelInfo},
+		},
+		{
+			desc:   "404 level",
+			env:    map[string]string{"LOGGER_4XX_LEVEL": "info", "LOGGER_404_LEVEL": "debug"},
+			expect: LoggerConfig{ClientErrorLevel: slog.LevelInfo, NotFoundLevel: slog.LevelDebug},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for _, key := range []string{"LOGGER_4XX_LEVEL", "LOGGER_404_LEVEL"} {
+				t.Setenv(key, tc.env[key])
+			}
+			assert.Equal(t, tc.expect, LoadLoggerConfig())
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+// MetricsCollector receives the metrics published by glib middleware so they can be
+// forwarded to Prometheus, StatsD, OpenTelemetry... Labels are key-value pairs.
+//
+// Example:
+//
+//	collector.Gauge("loadshed_rate", 0.25)
+//	collector.Counter("loadshed_rejected_total", 1, "path", "/search")
+type MetricsCollector interface {
+	// Counter adds value to a counter
+	Counter(name string, value float64, labels ...string)
+
+	// Gauge sets the current value of a gauge
+	Gauge(name string, value float64, labels ...string)
+
+	// Observe records a value in a histogram, e.g. a duration in seconds
+	Observe(name string, value float64, labels ...string)
+}
+
+// noopMetrics discards all metrics
+type noopMetrics struct{}
+
+func (noopMetrics) Counter(string, float64, ...string) {}
+func (noopMetrics) Gauge(string, float64, ...string)   {}
+func (noopMetrics) Observe(string, float64, ...string) {}
+
+// metricsOrNoop returns the collector, or a collector discarding metrics if nil
+func metricsOrNoop(collector MetricsCollector) MetricsCollector {
+	if collector == nil {
+		return noopMetrics{}
+	}
+	return collector
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// PanicStormAllRoutes is the route reported to RecoveryConfig.OnPanicStorm
+	// for the process-wide alarm
+	PanicStormAllRoutes = "*"
+
+	// unmatchedRoute is the route of the panics and metrics of requests that
+	// didn't match any route, e.g. in a middleware, so that paths don't become keys
+	unmatchedRoute = "unmatched"
+
+	// panicBuckets is the number of buckets of the sliding window
+	panicBuckets = 10
+)
+
+// panicTracker counts the recovered panics per route in a sliding window and
+// raises an alarm when a route, or the whole process, panics too often
+type panicTracker struct {
+	window           time.Duration
+	cooldown         time.Duration
+	threshold        int
+	processThreshold int
+	onStorm          func(route string, count int, window time.Duration)
+	now              func() time.Time
+
+	mu      sync.Mutex
+	routes  map[string]*panicWindow
+	process panicWindow
+}
+
+// panicWindow is a sliding window of panic counts, approximated with buckets
+// of window/panicBuckets
+type panicWindow struct {
+	counts    [panicBuckets]int
+	epochs    [panicBuckets]int64
+	lastAlarm time.Time
+}
+
+func newPanicTracker(cfg RecoveryConfig) *panicTracker {
+	return &panicTracker{
+		window:           cfg.PanicStormWindow,
+		cooldown:         cfg.PanicStormCooldown,
+		threshold:        cfg.PanicStormThreshold,
+		processThreshold: cfg.PanicStormProcessThreshold,
+		onStorm:          cfg.OnPanicStorm,
+		now:              time.Now,
+		routes:           make(map[string]*panicWindow),
+	}
+}
+
+// record counts a panic of route, calling onStorm for each threshold reached
+// outside of its cooldown period
+func (t *panicTracker) record(route string) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	type alarm struct {
+		route string
+		count int
+	}
+	var alarms []alarm
+
+	t.mu.Lock()
+	now := t.now()
+	if t.threshold > 0 {
+		w, ok := t.routes[route]
+		if !ok {
+			w = &panicWindow{}
+			t.routes[route] = w
+		}
+		if count := w.add(now, t.window); t.fire(w, now, count, t.threshold) {
+			alarms = append(alarms, alarm{route, count})
+		}
+	}
+	if t.processThreshold > 0 {
+		if count := t.process.add(now, t.window); t.fire(&t.process, now, count, t.processThreshold) {
+			alarms = append(alarms, alarm{PanicStormAllRoutes, count})
+		}
+	}
+	t.mu.Unlock()
+
+	for _, a := range alarms {
+		t.onStorm(a.route, a.count, t.window)
+	}
+}
+
+// fire reports whether the count of w reached threshold outside of the
+// cooldown period of its last alarm, starting a new period if so
+func (t *panicTracker) fire(w *panicWindow, now time.Time, count, threshold int) bool {
+	if count < threshold || (!w.lastAlarm.IsZero() && now.Sub(w.lastAlarm) < t.cooldown) {
+		return false
+	}
+	w.lastAlarm = now
+	return true
+}
+
+// add counts a panic at now and returns the number of panics in the window
+func (w *panicWindow) add(now time.Time, window time.Duration) int {
+	width := max(int64(window)/panicBuckets, 1)
+	epoch := now.UnixNano() / width
+	i := epoch % panicBuckets
+	if w.epochs[i] != epoch {
+		w.epochs[i], w.counts[i] = epoch, 0
+	}
+	w.counts[i]++
+
+	count := 0
+	for j := range w.counts {
+		if epoch-w.epochs[j] < panicBuckets {
+			count += w.counts[j]
+		}
+	}
+	return count
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import "github.com/azizndao/glib/util"
+
+// StackProfile is a preset of the middleware stack for a kind of application,
+// selecting which middleware are enabled by default. The ENABLE_* variable of
+// a middleware, when set, still applies on top of the profile.
+//
+//	| Middleware      | default | api        | web | minimal |
+//	|-----------------|---------|------------|-----|---------|
+//	| RealIP          | on      | on         | on  | off     |
+//	| RequestID       | on      | on         | on  | off     |
+//	| Logger          | on      | on         | on  | on      |
+//	| Recovery        | on      | on         | on  | on      |
+//	| SecurityHeaders | off     | on (API)   | on  | off     |
+//	| CSRF            | off     | off        | on  | off     |
+//	| Compress        | on      | JSON, text | on  | off     |
+//	| BodyLimit       | on      | on         | on  | off     |
+//	| CORS            | on      | on         | off | off     |
+//
+// The other middleware are enabled by their own variables in every profile.
+// The api profile also renders errors as JSON whatever the Accept header of
+// the client (see glib.RouterConfig.JSONErrors), the web profile negotiates
+// HTML error pages. The package has no session middleware, applications of
+// the web profile add their own.
+type StackProfile string
+
+const (
+	// ProfileDefault is the stack of the ENABLE_* variables, without preset
+	ProfileDefault StackProfile = ""
+	// ProfileAPI is the stack of JSON APIs
+	ProfileAPI StackProfile = "api"
+	// ProfileWeb is the stack of server-rendered web applications
+	ProfileWeb StackProfile = "web"
+	// ProfileMinimal only recovers panics and logs requests
+	ProfileMinimal StackProfile = "minimal"
+)
+
+// stackProfiles are the middleware enabled or disabled by each profile,
+// differing from the default stack
+var stackProfiles = map[StackProfile]map[string]bool{
+	ProfileAPI: {
+		"SecurityHeaders": true,
+	},
+	ProfileWeb: {
+		"SecurityHeaders": true,
+		"CSRF":            true,
+		"CORS":            false,
+	},
+	ProfileMinimal: {
+		"RealIP":    false,
+		"RequestID": false,
+		"Compress":  false,
+		"BodyLimit": false,
+		"CORS":      false,
+	},
+}
+
+// apiCompressTypes are the content types compressed in the api profile
+var apiCompressTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/x-ndjson",
+	"text/plain",
+	"text/csv",
+}
+
+// LoadStackProfile loads the profile from the STACK_PROFILE environment
+// variable: api, web or minimal (default: none, see ProfileDefault)
+func LoadStackProfile() StackProfile {
+	return StackProfile(util.GetEnvOneOf("STACK_PROFILE", "", string(ProfileAPI), string(ProfileWeb), string(ProfileMinimal)))
+}
+
+// enabled reports whether the middleware is enabled in the profile: by its
+// ENABLE_* variable when set, else by the profile, else by default
+func (p StackProfile) enabled(name, env string, fallback bool) bool {
+	if preset, ok := stackProfiles[p][name]; ok {
+		fallback = preset
+	}
+	return util.GetEnvBool(env, fallback)
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stackNames returns the names of the middleware of the stack
+func stackNames(config StackConfig) []string {
+	config.Logger = slog.New(slog.DiscardHandler)
+	var names []string
+	for _, entry := range StackEntries(config) {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+func TestStackProfile(t *testing.T) {
+	cases := []struct {
+		desc     string
+		profile  StackProfile
+		env      map[string]string
+		expected []string
+	}{
+		{
+			desc:     "default",
+			expected: []string{"RealIP", "RequestID", "Logger", "Recovery", "Compress", "BodyLimit", "CORS"},
+		},
+		{
+			desc:     "api",
+			profile:  ProfileAPI,
+			expected: []string{"RealIP", "RequestID", "Logger", "Recovery", "SecurityHeaders", "Compress", "BodyLimit", "CORS"},
+		},
+		{
+			desc:     "web",
+			profile:  ProfileWeb,
+			expected: []string{"RealIP", "RequestID", "Logger", "Recovery", "SecurityHeaders", "Compress", "BodyLimit", "CSRF"},
+		},
+		{
+			desc:     "minimal",
+			profile:  ProfileMinimal,
+			expected: []string{"Logger", "Recovery"},
+		},
+		{
+			desc:     "from environment",
+			env:      map[string]string{"STACK_PROFILE": "Minimal"},
+			expected: []string{"Logger", "Recovery"},
+		},
+		{
+			desc:     "config over environment",
+			profile:  ProfileMinimal,
+			env:      map[string]string{"STACK_PROFILE": "web"},
+			expected: []string{"Logger", "Recovery"},
+		},
+		{
+			desc:     "overrides",
+			profile:  ProfileMinimal,
+			env:      map[string]string{"ENABLE_REQUEST_ID": "true", "ENABLE_RECOVERY": "false", "ENABLE_SECURITY_HEADERS": "true"},
+			expected: []string{"RequestID", "Logger", "SecurityHeaders"},
+		},
+		{
+			desc:     "web overrides",
+			profile:  ProfileWeb,
+			env:      map[string]string{"ENABLE_CSRF": "false", "ENABLE_CORS": "true", "ENABLE_BODY_LIMIT": "false"},
+			expected: []string{"RealIP", "RequestID", "Logger", "Recovery", "SecurityHeaders", "Compress", "CORS"},
+		},
+		{
+			desc:     "unknown profile",
+			env:      map[string]string{"STACK_PROFILE": "mobile"},
+			expected: []string{"RealIP", "RequestID", "Logger", "Recovery", "Compress", "BodyLimit", "CORS"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			assert.Equal(t, tc.expected, stackNames(StackConfig{Profile: tc.profile}))
+		})
+	}
+}
+
+func TestStackProfile_APICompression(t *testing.T) {
+	handler := func(contentType string) http.Handler {
+		var stack http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			_, _ = w.Write(make([]byte, 4096))
+		})
+		entries := StackEntries(StackConfig{Logger: slog.New(slog.DiscardHandler), Profile: ProfileAPI})
+		for i := len(entries) - 1; i >= 0; i-- {
+			stack = entries[i].Middleware(stack)
+		}
+		return stack
+	}
+
+	cases := []struct {
+		desc        string
+		contentType string
+		compressed  bool
+	}{
+		{desc: "json", contentType: "application/json", compressed: true},
+		{desc: "problem details", contentType: "application/problem+json", compressed: true},
+		{desc: "html", contentType: "text/html"},
+		{desc: "javascript", contentType: "application/javascript"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			handler(tc.contentType).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.compressed, w.Header().Get("Content-Encoding") == "gzip")
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		})
+	}
+}
@@ -1,25 +1,86 @@
 package middleware
 
 import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/httputil"
 	"github.com/azizndao/glib/util"
+	"github.com/go-chi/httprate"
 )
 
+const (
+	// RateLimitDryRunHeader is set on the requests over the limit in dry-run mode
+	RateLimitDryRunHeader = "X-RateLimit-DryRun"
+	// RateLimitWouldBlock is the value of the RateLimitDryRunHeader header
+	RateLimitWouldBlock = "would-block"
+	// RateLimitReason is the reason reported in the body of requests rejected by
+	// RateLimit, see ConcurrencyLimitReason
+	RateLimitReason = "rate_limit"
+)
+
+// LimitInfo is the rate limit state of a client whose request is over the limit
+type LimitInfo struct {
+	// Key is the client key, see Config.KeyFunc
+	Key string
+	// Limit is the maximum number of requests in the window
+	Limit int
+	// Current is the number of requests of the client in the window
+	Current int
+	// ResetAt is when the current window ends
+	ResetAt time.Time
+	// RetryAfter is the time left until ResetAt
+	RetryAfter time.Duration
+}
+
 // Config holds configuration for the RateLimit middleware
 type Config struct {
 	// Max is the maximum number of requests allowed in the time window
-	Max int
+	Max int `env:"RATE_LIMIT_MAX"`
 
 	// Window is the time window for rate limiting
-	Window time.Duration
+	Window time.Duration `env:"RATE_LIMIT_WINDOW"`
+
+	// KeyFunc extracts the client key, shareable with ConcurrencyPerClient
+	// Default: KeyByClientIP with TRUSTED_PROXIES
+	KeyFunc KeyFunc
+
+	// DryRun counts the requests and sets the rate limit headers without
+	// rejecting any: the requests over the limit get the "X-RateLimit-DryRun:
+	// would-block" header and are reported to OnWouldBlock. Use it to measure
+	// who would be blocked before enforcing new limits.
+	DryRun bool `env:"RATE_LIMIT_DRY_RUN"`
+
+	// StandardHeaders also sets the RateLimit-Limit, RateLimit-Remaining and
+	// RateLimit-Reset headers of the IETF draft, the reset being in seconds
+	// instead of the Unix time of X-RateLimit-Reset
+	StandardHeaders bool `env:"RATE_LIMIT_STANDARD_HEADERS"`
+
+	// OnWouldBlock is called in dry-run mode for each request over the limit, with
+	// its client key and the number of requests of the client in the window.
+	// Default: warn log
+	OnWouldBlock func(r *http.Request, key string, count int)
+
+	// Logger is used by the default OnWouldBlock (default: slog.Default())
+	Logger *slog.Logger
+
+	// OnLimit responds to the requests over the limit, e.g. with a custom
+	// message including info.RetryAfter. Default: 429 Too Many Requests with
+	// the limit and reset time in the error data.
+	OnLimit func(w http.ResponseWriter, r *http.Request, info LimitInfo)
 }
 
 // DefaultConfig returns default configuration for rate limiting
 func DefaultConfig() Config {
 	return Config{
-		Max:    100,
-		Window: time.Minute,
+		Max:     100,
+		Window:  time.Minute,
+		KeyFunc: KeyByClientIP(LoadTrustedProxies()),
 	}
 }
 
@@ -28,6 +89,8 @@ func DefaultConfig() Config {
 //   - ENABLE_RATE_LIMIT (bool): enable/disable rate limiting
 //   - RATE_LIMIT_MAX (int): max requests per window
 //   - RATE_LIMIT_WINDOW (duration): window duration
+//   - RATE_LIMIT_DRY_RUN (bool): count and report the requests over the limit without rejecting them (default: false)
+//   - RATE_LIMIT_STANDARD_HEADERS (bool): also set the RateLimit-* headers of the IETF draft (default: false)
 //
 // Returns nil if ENABLE_RATE_LIMIT=false, otherwise returns config
 func LoadRateLimitConfig() *Config {
@@ -36,8 +99,139 @@ func LoadRateLimitConfig() *Config {
 	}
 
 	cfg := DefaultConfig()
-	cfg.Max = util.GetEnvInt("RATE_LIMIT_MAX", cfg.Max)
-	cfg.Window = util.GetEnvDuration("RATE_LIMIT_WINDOW", cfg.Window)
+	loadEnv(&cfg)
 
 	return &cfg
 }
+
+// rateLimitErrKey is the request context key of the counter error of a dry-run request
+type rateLimitErrKey struct{}
+
+// RateLimit limits the number of requests per client in the time window,
+// rejecting the requests over the limit with 429 Too Many Requests and a
+// Retry-After header. The X-RateLimit-* headers are set on all responses, and
+// the RateLimit-* headers with Config.StandardHeaders.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.RateLimit(middleware.Config{
+//	    Max:    100,
+//	    Window: time.Minute,
+//	    DryRun: true, // observe before enforcing
+//	}))
+func RateLimit(config ...Config) func(http.Handler) http.Handler {
+	cfg := DefaultConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = DefaultConfig().Max
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultConfig().Window
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = KeyByClientIP(LoadTrustedProxies())
+	}
+	if cfg.OnLimit == nil {
+		cfg.OnLimit = rateLimited
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.DryRun && cfg.OnWouldBlock == nil {
+		cfg.OnWouldBlock = func(r *http.Request, key string, count int) {
+			logger.WarnContext(r.Context(), "Rate limit would block request",
+				"key", key,
+				"count", count,
+				"limit", cfg.Max,
+				"path", r.URL.Path,
+			)
+		}
+	}
+
+	// Counter errors are handled below, they don't block requests in dry-run mode
+	limiter := httprate.NewRateLimiter(cfg.Max, cfg.Window, httprate.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		if failed, ok := r.Context().Value(rateLimitErrKey{}).(*error); ok {
+			*failed = err
+		}
+	}))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := cfg.KeyFunc(r)
+			if err != nil {
+				if cfg.DryRun {
+					next.ServeHTTP(w, r)
+				} else {
+					writeError(w, errors.InternalServerError(http.StatusText(http.StatusInternalServerError), err))
+				}
+				return
+			}
+
+			var counterErr error
+			limited := limiter.OnLimit(w, r.WithContext(context.WithValue(r.Context(), rateLimitErrKey{}, &counterErr)), key)
+			resetAt := time.Now().UTC().Truncate(cfg.Window).Add(cfg.Window)
+			switch {
+			case counterErr != nil:
+				logger.WarnContext(r.Context(), "Rate limit counter failed", "key", key, "error", counterErr)
+				if !cfg.DryRun {
+					writeError(w, errors.InternalServerError(http.StatusText(http.StatusInternalServerError), counterErr))
+					return
+				}
+			case limited && cfg.DryRun:
+				// Requests over the limit are not counted by the limiter
+				_, rate, _ := limiter.Status(key)
+				window := time.Now().UTC().Truncate(cfg.Window)
+				_ = limiter.Counter().IncrementBy(key, window, 1)
+
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set(RateLimitDryRunHeader, RateLimitWouldBlock)
+				cfg.OnWouldBlock(r, key, int(math.Round(rate))+1)
+			case limited:
+				_, rate, _ := limiter.Status(key)
+				// httprate sends the window length, not the time left in the window
+				w.Header().Set("Retry-After", httputil.FormatRetryAfter(time.Until(resetAt)))
+				if cfg.StandardHeaders {
+					setStandardRateLimit(w.Header(), cfg.Max, resetAt)
+				}
+				cfg.OnLimit(w, r, LimitInfo{
+					Key:        key,
+					Limit:      cfg.Max,
+					Current:    int(math.Round(rate)),
+					ResetAt:    resetAt,
+					RetryAfter: time.Until(resetAt),
+				})
+				return
+			}
+			if cfg.StandardHeaders {
+				setStandardRateLimit(w.Header(), cfg.Max, resetAt)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setStandardRateLimit sets the RateLimit-* headers from the X-RateLimit-*
+// headers set by httprate
+func setStandardRateLimit(h http.Header, limit int, resetAt time.Time) {
+	remaining, _ := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	httputil.SetRateLimit(h, httputil.RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Until(resetAt),
+	})
+}
+
+// rateLimited is the default Config.OnLimit
+func rateLimited(w http.ResponseWriter, r *http.Request, info LimitInfo) {
+	writeError(w, errors.TooManyRequests(map[string]any{
+		"message":     "Rate-limited",
+		"reason":      RateLimitReason,
+		"limit":       info.Limit,
+		"reset_at":    info.ResetAt.Format(time.RFC3339),
+		"retry_after": int(math.Ceil(info.RetryAfter.Seconds())),
+	}, nil))
+}
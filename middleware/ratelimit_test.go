@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	type wouldBlock struct {
+		key   string
+		count int
+	}
+
+	tests := []struct {
+		desc          string
+		dryRun        bool
+		expectCodes   []int
+		expectDryRun  []string
+		expectBlocked []wouldBlock
+	}{
+		{
+			desc:         "enforced",
+			expectCodes:  []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			expectDryRun: []string{"", "", "", ""},
+		},
+		{
+			desc:          "dry run",
+			dryRun:        true,
+			expectCodes:   []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK},
+			expectDryRun:  []string{"", "", RateLimitWouldBlock, RateLimitWouldBlock},
+			expectBlocked: []wouldBlock{{key: "10.0.0.1", count: 3}, {key: "10.0.0.1", count: 4}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var blocked []wouldBlock
+			handler := RateLimit(Config{
+				Max:    2,
+				Window: time.Hour,
+				DryRun: test.dryRun,
+				OnWouldBlock: func(r *http.Request, key string, count int) {
+					blocked = append(blocked, wouldBlock{key: key, count: count})
+				},
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			for i, expectCode := range test.expectCodes {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				assert.Equal(t, expectCode, w.Code, "request %d", i)
+				assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+				assert.Equal(t, test.expectDryRun[i], w.Header().Get(RateLimitDryRunHeader), "request %d", i)
+			}
+			assert.Equal(t, test.expectBlocked, blocked)
+		})
+	}
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	tests := []struct {
+		desc            string
+		standardHeaders bool
+	}{
+		{desc: "legacy headers"},
+		{desc: "standard headers", standardHeaders: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			handler := RateLimit(Config{Max: 1, Window: time.Hour, StandardHeaders: test.standardHeaders})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			untilReset := time.Until(time.Now().UTC().Truncate(time.Hour).Add(time.Hour)).Seconds()
+
+			for i, expectCode := range []int{http.StatusOK, http.StatusTooManyRequests} {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"), "request %d", i)
+				assert.Equal(t, expectCode, w.Code, "request %d", i)
+				assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"), "request %d", i)
+				if test.standardHeaders {
+					assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"), "request %d", i)
+					assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"), "request %d", i)
+					reset, err := strconv.Atoi(w.Header().Get("RateLimit-Reset"))
+					require.NoError(t, err)
+					assert.InDelta(t, untilReset, reset, 2, "request %d", i)
+				} else {
+					assert.Empty(t, w.Header().Get("RateLimit-Limit"), "request %d", i)
+				}
+
+				if expectCode == http.StatusTooManyRequests {
+					retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+					require.NoError(t, err)
+					assert.InDelta(t, untilReset, retryAfter, 2, "the time left in the window")
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRateLimitConfig(t *testing.T) {
+	t.Setenv("ENABLE_RATE_LIMIT", "true")
+	t.Setenv("RATE_LIMIT_DRY_RUN", "true")
+
+	cfg := LoadRateLimitConfig()
+	if assert.NotNil(t, cfg) {
+		assert.True(t, cfg.DryRun)
+	}
+}
+
+func TestRateLimit_OnLimit(t *testing.T) {
+	request := func(handler http.Handler) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("default response", func(t *testing.T) {
+		handler := RateLimit(Config{Max: 1, Window: time.Hour})(ok)
+		require.Equal(t, http.StatusOK, request(handler).Code)
+
+		w := request(handler)
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		var body struct {
+			Data map[string]any `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, RateLimitReason, body.Data["reason"])
+		assert.Equal(t, float64(1), body.Data["limit"])
+		resetAt, err := time.Parse(time.RFC3339, body.Data["reset_at"].(string))
+		require.NoError(t, err)
+		assert.Equal(t, time.Now().UTC().Truncate(time.Hour).Add(time.Hour), resetAt)
+		assert.InDelta(t, time.Until(resetAt).Seconds(), body.Data["retry_after"], 2)
+	})
+
+	t.Run("custom response", func(t *testing.T) {
+		var got LimitInfo
+		handler := RateLimit(Config{
+			Max:    2,
+			Window: time.Hour,
+			OnLimit: func(w http.ResponseWriter, r *http.Request, info LimitInfo) {
+				got = info
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprintf(w, "retry in %s", info.RetryAfter.Round(time.Minute))
+			},
+		})(ok)
+		request(handler)
+		request(handler)
+
+		w := request(handler)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "retry in ")
+		assert.Equal(t, "10.0.0.1", got.Key)
+		assert.Equal(t, 2, got.Limit)
+		assert.Equal(t, 2, got.Current)
+		assert.WithinDuration(t, got.ResetAt, time.Now().Add(got.RetryAfter), time.Second)
+	})
+
+	t.Run("not called in dry-run mode", func(t *testing.T) {
+		called := false
+		handler := RateLimit(Config{
+			Max:          1,
+			Window:       time.Hour,
+			DryRun:       true,
+			OnWouldBlock: func(r *http.Request, key string, count int) {},
+			OnLimit: func(w http.ResponseWriter, r *http.Request, info LimitInfo) {
+				called = true
+			},
+		})(ok)
+		request(handler)
+
+		assert.Equal(t, http.StatusOK, request(handler).Code)
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RealIPConfig holds configuration for the RealIP middleware
+type RealIPConfig struct {
+	// TrustedProxies lists the CIDR ranges (or single IPs) of the proxies whose
+	// forwarding headers are trusted. Default: TRUSTED_PROXIES, see LoadTrustedProxies
+	TrustedProxies []string
+}
+
+// RealIP sets the remote address of the requests sent by a trusted proxy to the
+// client IP resolved from the forwarding headers (see TrustedProxies.ClientIP),
+// so that the next middlewares and the handlers see the client. The headers of
+// the other peers are ignored, as they can be forged by clients: without
+// trusted proxies, RealIP doesn't change any request.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.RealIP(middleware.RealIPConfig{
+//	    TrustedProxies: []string{"10.0.0.0/8"}, // the load balancers
+//	}))
+func RealIP(config ...RealIPConfig) func(http.Handler) http.Handler {
+	var proxies TrustedProxies
+	if len(config) > 0 && config[0].TrustedProxies != nil {
+		var err error
+		if proxies, err = ParseTrustedProxies(config[0].TrustedProxies); err != nil {
+			panic(fmt.Sprintf("middleware: %v in RealIP", err))
+		}
+	} else {
+		proxies = LoadTrustedProxies()
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(proxies) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if remote := remoteIP(r); remote != nil && proxies.contains(remote) {
+				r.RemoteAddr = proxies.ClientIP(r).String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	cases := []struct {
+		desc    string
+		config  []RealIPConfig
+		env     string
+		remote  string
+		headers map[string]string
+		expect  string
+	}{
+		{
+			desc:    "no trusted proxies",
+			remote:  "198.51.100.7:1234",
+			headers: map[string]string{"X-Real-IP": "10.8.0.1"},
+			expect:  "198.51.100.7:1234",
+		},
+		{
+			desc:    "untrusted peer",
+			config:  []RealIPConfig{{TrustedProxies: []string{"10.0.0.0/8"}}},
+			remote:  "198.51.100.7:1234",
+			headers: map[string]string{"X-Forwarded-For": "10.8.0.1"},
+			expect:  "198.51.100.7:1234",
+		},
+		{
+			desc:    "trusted proxy",
+			config:  []RealIPConfig{{TrustedProxies: []string{"10.0.0.0/8"}}},
+			remote:  "10.0.0.2:1234",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.9, 10.0.0.3"},
+			expect:  "203.0.113.9",
+		},
+		{
+			desc:    "trusted proxy of TRUSTED_PROXIES",
+			env:     "192.0.2.1",
+			remote:  "192.0.2.1:1234",
+			headers: map[string]string{"X-Real-IP": "203.0.113.9"},
+			expect:  "203.0.113.9",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tc.env)
+			var remote string
+			handler := RealIP(tc.config...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				remote = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remote
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.expect, remote)
+		})
+	}
+
+	t.Run("invalid trusted proxies fail fast", func(t *testing.T) {
+		assert.Panics(t, func() { RealIP(RealIPConfig{TrustedProxies: []string{"proxy"}}) })
+		t.Setenv("TRUSTED_PROXIES", "proxy")
+		assert.Panics(t, func() { RealIP() })
+	})
+}
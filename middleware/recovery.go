@@ -0,0 +1,340 @@
+package middleware
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/util"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// DefaultRecoveryDumpBodySize is the default maximum number of body bytes included in request dumps (4KB)
+const DefaultRecoveryDumpBodySize = 4 * KB
+
+// RedactedValue replaces the value of sensitive headers in request dumps
+const RedactedValue = "[REDACTED]"
+
+// sensitiveHeaders are always redacted from request dumps
+var sensitiveHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"Proxy-Authorization": {},
+	"Cookie":              {},
+	"Set-Cookie":          {},
+	"X-Api-Key":           {},
+}
+
+// RequestDump is a snapshot of the request attached to panic logs
+type RequestDump struct {
+	Method  string
+	Path    string
+	Query   string
+	Headers map[string]string
+	// Body contains the beginning of the request body, only if the handler
+	// already read it before panicking
+	Body string
+	// BodyTruncated is true when the body was larger than the dump limit
+	BodyTruncated bool
+}
+
+// LogValue implements slog.LogValuer
+func (d RequestDump) LogValue() slog.Value {
+	headers := make([]slog.Attr, 0, len(d.Headers))
+	for _, name := range sortedKeys(d.Headers) {
+		headers = append(headers, slog.String(name, d.Headers[name]))
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", d.Method),
+		slog.String("path", d.Path),
+		slog.String("query", d.Query),
+		slog.Any("headers", slog.GroupValue(headers...)),
+	}
+	if d.Body != "" {
+		attrs = append(attrs, slog.String("body", d.Body), slog.Bool("body_truncated", d.BodyTruncated))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// RecoveryConfig holds configuration for the Recovery middleware
+type RecoveryConfig struct {
+	// Logger is used to log recovered panics. Default: slog.Default()
+	Logger *slog.Logger
+
+	// DumpRequest attaches a sanitized dump of the request to the panic log
+	DumpRequest bool `env:"RECOVERY_DUMP_REQUEST"`
+
+	// MaxDumpBodySize is the maximum number of body bytes included in the dump
+	// Default: 4KB (DefaultRecoveryDumpBodySize)
+	MaxDumpBodySize int `env:"RECOVERY_DUMP_BODY_SIZE"`
+
+	// Redact is called with the request dump before it is logged so applications
+	// can scrub additional fields. Sensitive headers (Authorization, Cookie...)
+	// are already redacted.
+	Redact func(dump *RequestDump)
+
+	// Reporter receives recovered panics with the request metadata (see RequestMeta)
+	Reporter errors.Reporter
+
+	// PanicStormThreshold is the number of panics of a route within
+	// PanicStormWindow raising a panic storm alarm. 0 disables it. Default: 10
+	PanicStormThreshold int `env:"RECOVERY_PANIC_STORM_THRESHOLD"`
+
+	// PanicStormProcessThreshold is the number of panics of all the routes
+	// within PanicStormWindow raising a panic storm alarm, reported for the
+	// PanicStormAllRoutes route. 0 disables it. Default: 50
+	PanicStormProcessThreshold int `env:"RECOVERY_PANIC_STORM_PROCESS_THRESHOLD"`
+
+	// PanicStormWindow is the sliding window the panics are counted in. Default: 1m
+	PanicStormWindow time.Duration `env:"RECOVERY_PANIC_STORM_WINDOW"`
+
+	// PanicStormCooldown is the minimum time between two alarms of a route. Default: 5m
+	PanicStormCooldown time.Duration `env:"RECOVERY_PANIC_STORM_COOLDOWN"`
+
+	// OnPanicStorm is called when a route panics PanicStormThreshold times within
+	// the window, at most once per cooldown period, e.g. to page someone.
+	// Default: error log with the "panic storm" message
+	OnPanicStorm func(route string, count int, window time.Duration)
+
+	// OnAbort is called when the response is aborted with http.ErrAbortHandler,
+	// e.g. by a reverse proxy whose client disconnected, before the panic is
+	// propagated to the server. Aborts aren't counted as panics.
+	// Default: debug log "client aborted" with the route pattern and the bytes written
+	OnAbort func(r *http.Request, route string, bytesWritten int)
+
+	// Metrics receives the "panics_total" and "panic_storms_total" counters,
+	// labeled by route
+	Metrics MetricsCollector
+}
+
+// DefaultRecoveryConfig returns default configuration for panic recovery
+func DefaultRecoveryConfig() RecoveryConfig {
+	return RecoveryConfig{
+		MaxDumpBodySize:            DefaultRecoveryDumpBodySize,
+		PanicStormThreshold:        10,
+		PanicStormProcessThreshold: 50,
+		PanicStormWindow:           time.Minute,
+		PanicStormCooldown:         5 * time.Minute,
+	}
+}
+
+// LoadRecoveryConfig loads RecoveryConfig from environment variables
+// Environment variables:
+//   - ENABLE_RECOVERY (bool): enable/disable panic recovery (default: true)
+//   - RECOVERY_DUMP_REQUEST (bool): attach a sanitized request dump to panic logs (default: false)
+//   - RECOVERY_DUMP_BODY_SIZE (int): maximum body bytes in the dump (default: 4096)
+//   - RECOVERY_PANIC_STORM_THRESHOLD (int): panics of a route within the window raising an alarm, 0 to disable (default: 10)
+//   - RECOVERY_PANIC_STORM_PROCESS_THRESHOLD (int): panics of all routes within the window raising an alarm, 0 to disable (default: 50)
+//   - RECOVERY_PANIC_STORM_WINDOW (duration): sliding window the panics are counted in (default: 1m)
+//   - RECOVERY_PANIC_STORM_COOLDOWN (duration): minimum time between two alarms of a route (default: 5m)
+//
+// Returns nil if ENABLE_RECOVERY=false
+func LoadRecoveryConfig() *RecoveryConfig {
+	if !util.GetEnvBool("ENABLE_RECOVERY", true) {
+		return nil
+	}
+
+	cfg := DefaultRecoveryConfig()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// Recovery recovers from panics, logs them with their stack trace and responds
+// with a 500 Internal Server Error JSON body.
+// http.ErrAbortHandler panics are propagated so the server aborts the response,
+// without a stack trace nor an error log (see OnAbort): they are expected when
+// clients disconnect while a response is streamed.
+//
+// The panics are counted per route pattern: a route panicking on a large share
+// of its requests raises a panic storm alarm (see OnPanicStorm), as does the
+// whole process.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.Recovery(middleware.RecoveryConfig{
+//	    PanicStormThreshold: 20,
+//	    PanicStormWindow:    time.Minute,
+//	    PanicStormCooldown:  10 * time.Minute,
+//	    OnPanicStorm: func(route string, count int, window time.Duration) {
+//	        pager.Trigger(fmt.Sprintf("%s panicked %d times in %s", route, count, window))
+//	    },
+//	}))
+func Recovery(config ...RecoveryConfig) func(http.Handler) http.Handler {
+	cfg := DefaultRecoveryConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MaxDumpBodySize <= 0 {
+		cfg.MaxDumpBodySize = DefaultRecoveryDumpBodySize
+	}
+	if cfg.PanicStormWindow <= 0 {
+		cfg.PanicStormWindow = DefaultRecoveryConfig().PanicStormWindow
+	}
+	if cfg.PanicStormCooldown <= 0 {
+		cfg.PanicStormCooldown = DefaultRecoveryConfig().PanicStormCooldown
+	}
+	if cfg.OnPanicStorm == nil {
+		cfg.OnPanicStorm = func(route string, count int, window time.Duration) {
+			logger := cfg.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.Error("PANIC STORM: route is panicking repeatedly",
+				"alert", "panic_storm",
+				"route", route,
+				"count", count,
+				"window", window.String(),
+			)
+		}
+	}
+	if cfg.OnAbort == nil {
+		cfg.OnAbort = func(r *http.Request, route string, bytesWritten int) {
+			logger := cfg.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.DebugContext(r.Context(), "client aborted",
+				"route", cmp.Or(route, r.URL.Path),
+				"bytes_written", bytesWritten,
+			)
+		}
+	}
+	metrics := metricsOrNoop(cfg.Metrics)
+	onPanicStorm := cfg.OnPanicStorm
+	cfg.OnPanicStorm = func(route string, count int, window time.Duration) {
+		metrics.Counter("panic_storms_total", 1, "route", route)
+		onPanicStorm(route, count, window)
+	}
+	tracker := newPanicTracker(cfg)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var capture *bodyCapture
+			if cfg.DumpRequest && r.Body != nil && r.Body != http.NoBody {
+				// Only record what downstream handlers read themselves, the body
+				// is never consumed by the recovery middleware
+				capture = &bodyCapture{ReadCloser: r.Body, limit: cfg.MaxDumpBodySize}
+				r.Body = capture
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				if rvr == http.ErrAbortHandler {
+					cfg.OnAbort(r, routePattern(r), ww.BytesWritten())
+					panic(rvr)
+				}
+
+				logger := cfg.Logger
+				if logger == nil {
+					logger = slog.Default()
+				}
+
+				// The ID sent with the 500 response, for users to quote to support
+				requestID := CorrelationID(r.Context())
+				if requestID == "" {
+					requestID = NewTraceID()
+				}
+
+				attrs := []any{
+					slog.Any("panic", rvr),
+					slog.String("request_id", requestID),
+					slog.String("trace", string(debug.Stack())),
+				}
+				if cfg.DumpRequest {
+					dump := dumpRequest(r, capture)
+					if cfg.Redact != nil {
+						cfg.Redact(&dump)
+					}
+					attrs = append(attrs, slog.Any("request", dump))
+				}
+
+				logger.ErrorContext(r.Context(), fmt.Sprintf("panic: %v", rvr), attrs...)
+
+				route := routePattern(r)
+				metrics.Counter("panics_total", 1, "route", cmp.Or(route, unmatchedRoute))
+				tracker.record(route)
+
+				if cfg.Reporter != nil {
+					cfg.Reporter.Report(r.Context(), errors.New(rvr), RequestMeta(r))
+				}
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					err := errors.InternalServerError(http.StatusText(http.StatusInternalServerError), nil)
+					err.RequestID = requestID
+					writeError(w, err)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// dumpRequest builds a sanitized snapshot of the request
+func dumpRequest(r *http.Request, capture *bodyCapture) RequestDump {
+	dump := RequestDump{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   r.URL.RawQuery,
+		Headers: SanitizeHeaders(r.Header),
+	}
+	if capture != nil {
+		dump.Body = capture.buf.String()
+		dump.BodyTruncated = capture.truncated
+	}
+	return dump
+}
+
+// bodyCapture records up to limit bytes of the request body as it is read downstream
+type bodyCapture struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *bodyCapture) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		remaining := b.limit - b.buf.Len()
+		switch {
+		case remaining >= n:
+			b.buf.Write(p[:n])
+		case remaining > 0:
+			b.buf.Write(p[:remaining])
+			b.truncated = true
+		default:
+			b.truncated = true
+		}
+	}
+	return n, err
+}
+
+// writeError sends the given ApiError as a JSON response
+func writeError(w http.ResponseWriter, err *errors.ApiError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.Code)
+	_ = json.NewEncoder(w).Encode(err)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
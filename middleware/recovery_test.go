@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecovery(t *testing.T) {
+	t.Run("responds with 500", func(t *testing.T) {
+		var logs bytes.Buffer
+		handler := RequestID()(Recovery(RecoveryConfig{Logger: slog.New(slog.NewJSONHandler(&logs, nil))})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }),
+		))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-Id", "req-1")
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.JSONEq(t, `{"code":500,"data":"Internal Server Error","request_id":"req-1"}`, w.Body.String())
+		assert.Contains(t, logs.String(), `"panic":"boom"`)
+		assert.Contains(t, logs.String(), `"request_id":"req-1"`)
+		assert.NotContains(t, logs.String(), `"request"`)
+	})
+
+	t.Run("dumps sanitized request", func(t *testing.T) {
+		var logs bytes.Buffer
+		handler := Recovery(RecoveryConfig{
+			Logger:          slog.New(slog.NewJSONHandler(&logs, nil)),
+			DumpRequest:     true,
+			MaxDumpBodySize: 5,
+			Redact: func(dump *RequestDump) {
+				dump.Headers["X-Session"] = RedactedValue
+			},
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body)
+			panic("boom")
+		}))
+
+		req := httptest.NewRequest("POST", "/users?active=1", strings.NewReader("secret-body"))
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("X-Session", "abc")
+		req.Header.Set("X-Trace", "xyz")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		out := logs.String()
+		assert.Contains(t, out, `"method":"POST"`)
+		assert.Contains(t, out, `"query":"active=1"`)
+		assert.Contains(t, out, `"Authorization":"[REDACTED]"`)
+		assert.Contains(t, out, `"X-Session":"[REDACTED]"`)
+		assert.Contains(t, out, `"X-Trace":"xyz"`)
+		assert.Contains(t, out, `"body":"secre"`)
+		assert.Contains(t, out, `"body_truncated":true`)
+		assert.NotContains(t, out, "Bearer token")
+	})
+
+	t.Run("does not read unread body", func(t *testing.T) {
+		var logs bytes.Buffer
+		body := strings.NewReader("untouched")
+		handler := Recovery(RecoveryConfig{
+			Logger:      slog.New(slog.NewJSONHandler(&logs, nil)),
+			DumpRequest: true,
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", body))
+
+		assert.Equal(t, len("untouched"), body.Len())
+		assert.NotContains(t, logs.String(), `"body"`)
+	})
+
+	t.Run("propagates ErrAbortHandler", func(t *testing.T) {
+		handler := Recovery()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+	})
+}
+
+func TestRecovery_ClientAbort(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunk := bytes.Repeat([]byte("a"), 32*KB)
+		for r.Context().Err() == nil {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer backend.Close()
+	target, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorLog = log.New(io.Discard, "", 0)
+
+	var logs syncBuffer
+	metrics := newRecordedMetrics()
+	r := chi.NewRouter()
+	r.Use(Recovery(RecoveryConfig{
+		Logger:              slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Metrics:             metrics,
+		PanicStormThreshold: 1,
+	}))
+	r.Handle("/proxy/*", proxy)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/proxy/stream")
+	require.NoError(t, err)
+	_, err = resp.Body.Read(make([]byte, 1))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), `"msg":"client aborted"`)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, logs.String(), `"level":"DEBUG"`)
+	assert.Contains(t, logs.String(), `"route":"/proxy/*"`)
+	assert.NotContains(t, logs.String(), `"level":"ERROR"`, "no panic log nor panic storm alarm")
+	assert.NotContains(t, logs.String(), "trace")
+	assert.Zero(t, metrics.counters["panics_total"])
+}
+
+func TestRecovery_Reporter(t *testing.T) {
+	var reported error
+	var meta map[string]any
+	handler := Recovery(RecoveryConfig{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		Reporter: errors.ReporterFunc(func(ctx context.Context, err error, m map[string]any) {
+			reported = err
+			meta = m
+		}),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Cookie", "session=abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Error(t, reported)
+	assert.Equal(t, "boom", reported.Error())
+	assert.Equal(t, "/users", meta["path"])
+	assert.Equal(t, RedactedValue, meta["headers"].(map[string]string)["Cookie"])
+}
+
+func TestRecovery_PanicStorm(t *testing.T) {
+	type storm struct {
+		route string
+		count int
+	}
+	var storms []storm
+	metrics := newRecordedMetrics()
+
+	r := chi.NewRouter()
+	r.Use(Recovery(RecoveryConfig{
+		Logger:                     slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		PanicStormThreshold:        3,
+		PanicStormProcessThreshold: 5,
+		Metrics:                    metrics,
+		OnPanicStorm: func(route string, count int, window time.Duration) {
+			storms = append(storms, storm{route, count})
+			assert.Equal(t, time.Minute, window)
+		},
+	}))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	r.Get("/orders", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	for i := range 6 {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", fmt.Sprintf("/users/%d", i), nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+
+	// One alarm per cooldown period for the route, the process alarm once 5 panics are reached
+	assert.Equal(t, []storm{{"/users/{id}", 3}, {PanicStormAllRoutes, 5}}, storms)
+	assert.Equal(t, 7.0, metrics.counters["panics_total"])
+	assert.Equal(t, 2.0, metrics.counters["panic_storms_total"])
+}
+
+func TestRecovery_PanicStormDefaultLog(t *testing.T) {
+	var logs bytes.Buffer
+	handler := Recovery(RecoveryConfig{
+		Logger:              slog.New(slog.NewJSONHandler(&logs, nil)),
+		PanicStormThreshold: 1,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.Contains(t, logs.String(), `"msg":"PANIC STORM: route is panicking repeatedly"`)
+	assert.Contains(t, logs.String(), `"route":"unmatched"`)
+	assert.Contains(t, logs.String(), `"window":"1m0s"`)
+}
+
+func TestPanicTracker(t *testing.T) {
+	var alarms []int
+	now := time.Unix(1_700_000_000, 0)
+	tracker := newPanicTracker(RecoveryConfig{
+		PanicStormThreshold: 3,
+		PanicStormWindow:    time.Minute,
+		PanicStormCooldown:  5 * time.Minute,
+		OnPanicStorm:        func(route string, count int, window time.Duration) { alarms = append(alarms, count) },
+	})
+	tracker.now = func() time.Time { return now }
+
+	tests := []struct {
+		desc    string
+		advance time.Duration
+		panics  int
+		alarms  []int
+	}{
+		{desc: "below the threshold", panics: 2},
+		{desc: "panics leaving the window aren't counted", advance: 2 * time.Minute, panics: 2},
+		{desc: "threshold reached", advance: 10 * time.Second, panics: 1, alarms: []int{3}},
+		{desc: "no alarm during the cooldown", advance: time.Minute, panics: 5, alarms: []int{3}},
+		{desc: "alarm after the cooldown", advance: 5 * time.Minute, panics: 3, alarms: []int{3, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			for range tt.panics {
+				tracker.record("/users/{id}")
+			}
+			assert.Equal(t, tt.alarms, alarms)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type userContextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user, reported with
+// errors sent to the error reporter
+func WithUser(ctx context.Context, user any) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the user stored with WithUser, or nil
+func UserFromContext(ctx context.Context) any {
+	return ctx.Value(userContextKey{})
+}
+
+// RequestMeta assembles the request metadata sent with error reports:
+// method, path, route pattern, request ID, user (if set with WithUser) and
+// sanitized headers
+func RequestMeta(r *http.Request) map[string]any {
+	meta := map[string]any{
+		"method":  r.Method,
+		"path":    r.URL.Path,
+		"headers": SanitizeHeaders(r.Header),
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			meta["route"] = pattern
+		}
+	}
+	if id := CorrelationID(r.Context()); id != "" {
+		meta["request_id"] = id
+	}
+	if user := UserFromContext(r.Context()); user != nil {
+		meta["user"] = user
+	}
+	return meta
+}
+
+// SanitizeHeaders flattens the headers, replacing sensitive values with RedactedValue
+func SanitizeHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if _, sensitive := sensitiveHeaders[name]; sensitive {
+			headers[name] = RedactedValue
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/azizndao/glib/util"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// DefaultRequestIDHeader is the default header carrying the request ID
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// TraceparentHeader is the W3C Trace Context header carrying the trace ID
+const TraceparentHeader = "traceparent"
+
+// RequestIDConfig holds configuration for the RequestID middleware
+type RequestIDConfig struct {
+	// Header is the request header carrying the request ID
+	// Default: X-Request-Id
+	Header string `env:"REQUEST_ID_HEADER"`
+
+	// FromTraceparent uses the trace ID of the traceparent header as the request
+	// ID when the request has none, so the logs of all the services of a trace
+	// share the same ID
+	FromTraceparent bool `env:"REQUEST_ID_FROM_TRACEPARENT"`
+}
+
+// DefaultRequestIDConfig returns default configuration for request IDs
+func DefaultRequestIDConfig() RequestIDConfig {
+	return RequestIDConfig{
+		Header:          DefaultRequestIDHeader,
+		FromTraceparent: true,
+	}
+}
+
+// LoadRequestIDConfig loads RequestIDConfig from environment variables
+// Environment variables:
+//   - ENABLE_REQUEST_ID (bool): enable/disable request IDs (default: true)
+//   - REQUEST_ID_HEADER (string): header carrying the request ID (default: X-Request-Id)
+//   - REQUEST_ID_FROM_TRACEPARENT (bool): fall back to the traceparent trace ID (default: true)
+//
+// Returns nil if ENABLE_REQUEST_ID=false
+func LoadRequestIDConfig() *RequestIDConfig {
+	if !util.GetEnvBool("ENABLE_REQUEST_ID", true) {
+		return nil
+	}
+
+	cfg := DefaultRequestIDConfig()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// RequestID stores the ID of the request in its context, readable with
+// chi's middleware.GetReqID. The ID is the one sent by the caller in the
+// header, else the trace ID of a valid traceparent header (when
+// FromTraceparent is set), else a new random ID in the trace ID format.
+// The trace context of the traceparent header is stored too, see GetTraceID.
+//
+// Use CorrelationTransport to propagate both to downstream services.
+func RequestID(config ...RequestIDConfig) func(http.Handler) http.Handler {
+	cfg := DefaultRequestIDConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = DefaultRequestIDHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			trace, traced := parseTraceparent(r.Header.Get(TraceparentHeader))
+			if traced {
+				ctx = context.WithValue(ctx, traceContextKey{}, trace)
+			}
+
+			id := strings.TrimSpace(r.Header.Get(cfg.Header))
+			if id == "" && traced && cfg.FromTraceparent {
+				id = trace.traceID
+			}
+			if id == "" {
+				id = NewTraceID()
+			}
+
+			ctx = context.WithValue(ctx, middleware.RequestIDKey, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// traceContext is the trace context of a traceparent header
+type traceContext struct {
+	traceID string
+	flags   string
+}
+
+type traceContextKey struct{}
+
+// GetTraceID returns the trace ID of the traceparent header of the request,
+// stored by RequestID, or "" if it had none
+func GetTraceID(ctx context.Context) string {
+	trace, _ := ctx.Value(traceContextKey{}).(traceContext)
+	return trace.traceID
+}
+
+// CorrelationID returns the ID correlating the logs and errors of the request:
+// its request ID, else its trace ID, or "" if it has none
+func CorrelationID(ctx context.Context) string {
+	if id := middleware.GetReqID(ctx); id != "" {
+		return id
+	}
+	return GetTraceID(ctx)
+}
+
+// NewTraceID returns a random ID in the W3C trace ID format, 32 lowercase hex
+// characters
+func NewTraceID() string {
+	return randomHex(16)
+}
+
+// randomHex returns n random bytes encoded in lowercase hex, never all zeros
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	b[n-1] |= 1
+	return hex.EncodeToString(b)
+}
+
+// parseTraceparent parses a traceparent header of the form
+// "version-traceid-parentid-flags", e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func parseTraceparent(value string) (traceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return traceContext{}, false
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	// Version 00 has exactly 4 fields, later versions may add more
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return traceContext{}, false
+	}
+	if !isHex(traceID, 32) || !isHex(parentID, 16) || !isHex(flags, 2) {
+		return traceContext{}, false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return traceContext{}, false
+	}
+	return traceContext{traceID: traceID, flags: flags}, true
+}
+
+// isHex reports whether s has n lowercase hex characters
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// CorrelationTransport is an http.RoundTripper sending the correlation ID of
+// the request context (see CorrelationID) to downstream services in the
+// request ID header, and a traceparent header continuing the trace of the
+// incoming request. Without an incoming trace, the correlation ID is used as
+// the trace ID when it has the trace ID format, else a new trace is started.
+// Headers already set on the outgoing request are kept.
+//
+// Chain it with DeadlineTransport to propagate the deadline too:
+//
+//	client := &http.Client{Transport: &middleware.CorrelationTransport{
+//	    Base: &middleware.DeadlineTransport{},
+//	}}
+//	req, _ := http.NewRequestWithContext(c.Context(), "GET", url, nil)
+//	resp, err := client.Do(req)
+type CorrelationTransport struct {
+	// Base is the transport sending the requests. Default: http.DefaultTransport
+	Base http.RoundTripper
+
+	// Header is the header carrying the request ID. Default: X-Request-Id
+	Header string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *CorrelationTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	header := t.Header
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+
+	id := CorrelationID(r.Context())
+	setID := id != "" && r.Header.Get(header) == ""
+	setTrace := r.Header.Get(TraceparentHeader) == ""
+	if !setID && !setTrace {
+		return base.RoundTrip(r)
+	}
+
+	// RoundTrippers must not modify the request
+	r = r.Clone(r.Context())
+	if setID {
+		r.Header.Set(header, id)
+	}
+	if setTrace {
+		r.Header.Set(TraceparentHeader, outgoingTraceparent(r.Context(), id))
+	}
+	return base.RoundTrip(r)
+}
+
+// outgoingTraceparent returns the traceparent header of a downstream request,
+// with a new parent ID
+func outgoingTraceparent(ctx context.Context, id string) string {
+	trace, ok := ctx.Value(traceContextKey{}).(traceContext)
+	if !ok {
+		trace = traceContext{traceID: id, flags: "00"}
+		if !isHex(id, 32) || strings.Trim(id, "0") == "" {
+			trace.traceID = NewTraceID()
+		}
+	}
+	return "00-" + trace.traceID + "-" + randomHex(8) + "-" + trace.flags
+}
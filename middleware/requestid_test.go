@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+var traceIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestRequestID(t *testing.T) {
+	cases := []struct {
+		desc        string
+		config      RequestIDConfig
+		headers     map[string]string
+		expected    string
+		expectedTID string
+	}{
+		{
+			desc:     "from header",
+			config:   DefaultRequestIDConfig(),
+			headers:  map[string]string{"X-Request-Id": "req-1", TraceparentHeader: testTraceparent},
+			expected: "req-1",
+			// The trace is still available when the request has an ID
+			expectedTID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			desc:        "from traceparent",
+			config:      DefaultRequestIDConfig(),
+			headers:     map[string]string{TraceparentHeader: testTraceparent},
+			expected:    "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedTID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			desc:        "traceparent fallback disabled",
+			config:      RequestIDConfig{FromTraceparent: false},
+			headers:     map[string]string{TraceparentHeader: testTraceparent},
+			expectedTID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			desc:     "custom header",
+			config:   RequestIDConfig{Header: "X-Correlation-Id"},
+			headers:  map[string]string{"X-Correlation-Id": "corr-1", "X-Request-Id": "req-1"},
+			expected: "corr-1",
+		},
+		{
+			desc:    "invalid traceparent",
+			config:  DefaultRequestIDConfig(),
+			headers: map[string]string{TraceparentHeader: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		},
+		{
+			desc:   "generated",
+			config: DefaultRequestIDConfig(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var requestID, traceID string
+			handler := RequestID(tc.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestID = middleware.GetReqID(r.Context())
+				traceID = GetTraceID(r.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tc.expected == "" {
+				assert.Regexp(t, traceIDPattern, requestID)
+			} else {
+				assert.Equal(t, tc.expected, requestID)
+			}
+			assert.Equal(t, tc.expectedTID, traceID)
+		})
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	cases := []struct {
+		desc  string
+		value string
+		valid bool
+	}{
+		{desc: "valid", value: testTraceparent, valid: true},
+		{desc: "future version with more fields", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", valid: true},
+		{desc: "empty", value: ""},
+		{desc: "version 00 with more fields", value: testTraceparent + "-extra"},
+		{desc: "invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{desc: "uppercase", value: strings.ToUpper(testTraceparent)},
+		{desc: "short trace ID", value: "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01"},
+		{desc: "zero parent ID", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, valid := parseTraceparent(tc.value)
+			assert.Equal(t, tc.valid, valid)
+		})
+	}
+}
+
+// recordingTransport records the headers of the requests it receives
+type recordingTransport struct {
+	header http.Header
+}
+
+func (t *recordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.header = r.Header
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+}
+
+func TestCorrelationTransport(t *testing.T) {
+	cases := []struct {
+		desc            string
+		headers         map[string]string
+		outgoing        map[string]string
+		expectedID      string
+		expectedTraceID string
+	}{
+		{
+			desc:            "continues the incoming trace",
+			headers:         map[string]string{"X-Request-Id": "req-1", TraceparentHeader: testTraceparent},
+			expectedID:      "req-1",
+			expectedTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			desc:            "starts a trace with the request ID",
+			headers:         map[string]string{"X-Request-Id": "0af7651916cd43dd8448eb211c80319c"},
+			expectedID:      "0af7651916cd43dd8448eb211c80319c",
+			expectedTraceID: "0af7651916cd43dd8448eb211c80319c",
+		},
+		{
+			desc:       "starts a new trace",
+			headers:    map[string]string{"X-Request-Id": "req-1"},
+			expectedID: "req-1",
+		},
+		{
+			desc:            "keeps the outgoing headers",
+			headers:         map[string]string{"X-Request-Id": "req-1", TraceparentHeader: testTraceparent},
+			outgoing:        map[string]string{"X-Request-Id": "job-7", TraceparentHeader: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+			expectedID:      "job-7",
+			expectedTraceID: "0af7651916cd43dd8448eb211c80319c",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			base := &recordingTransport{}
+			client := &http.Client{Transport: &CorrelationTransport{Base: base}}
+
+			handler := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req, err := http.NewRequestWithContext(r.Context(), "GET", "http://downstream/", nil)
+				require.NoError(t, err)
+				for name, value := range tc.outgoing {
+					req.Header.Set(name, value)
+				}
+				resp, err := client.Do(req)
+				require.NoError(t, err)
+				resp.Body.Close()
+				assert.Len(t, req.Header, len(tc.outgoing), "the original request is not modified")
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.NotNil(t, base.header)
+			assert.Equal(t, tc.expectedID, base.header.Get("X-Request-Id"))
+			trace, ok := parseTraceparent(base.header.Get(TraceparentHeader))
+			require.True(t, ok, "a valid traceparent is sent")
+			if tc.expectedTraceID != "" {
+				assert.Equal(t, tc.expectedTraceID, trace.traceID)
+			}
+			assert.NotContains(t, base.header.Get(TraceparentHeader), "00f067aa0ba902b7", "the parent ID is renewed")
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/azizndao/glib/util"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// MetricLabelLimits caps the labels of the request metrics and of the metrics
+// recorded by handlers, so that a label fed from user input can't explode the
+// cardinality of the collector
+type MetricLabelLimits struct {
+	// MaxLabels is the maximum number of labels (key-value pairs) added to a
+	// metric, the extra ones being dropped (default: 8)
+	MaxLabels int `env:"METRICS_MAX_LABELS"`
+
+	// MaxValueLength is the maximum length in bytes of a label value, longer
+	// values being truncated (default: 64)
+	MaxValueLength int `env:"METRICS_MAX_LABEL_LENGTH"`
+}
+
+// DefaultMetricLabelLimits returns the default label limits
+func DefaultMetricLabelLimits() MetricLabelLimits {
+	return MetricLabelLimits{
+		MaxLabels:      8,
+		MaxValueLength: 64,
+	}
+}
+
+// Apply returns the labels within the limits, with the number of labels
+// dropped. Labels without a key, a trailing key without value and repeated
+// keys are dropped too.
+func (l MetricLabelLimits) Apply(labels []string) ([]string, int) {
+	l = l.withDefaults()
+	limited := make([]string, 0, min(len(labels), 2*l.MaxLabels))
+	dropped := len(labels) % 2
+	for i := 0; i+1 < len(labels); i += 2 {
+		key, value := labels[i], labels[i+1]
+		if key == "" || len(limited) == 2*l.MaxLabels || hasLabel(limited, key) {
+			dropped++
+			continue
+		}
+		limited = append(limited, key, l.truncate(value))
+	}
+	return limited, dropped
+}
+
+func (l MetricLabelLimits) withDefaults() MetricLabelLimits {
+	defaults := DefaultMetricLabelLimits()
+	if l.MaxLabels <= 0 {
+		l.MaxLabels = defaults.MaxLabels
+	}
+	if l.MaxValueLength <= 0 {
+		l.MaxValueLength = defaults.MaxValueLength
+	}
+	return l
+}
+
+// truncate cuts the value to MaxValueLength bytes, on a rune boundary
+func (l MetricLabelLimits) truncate(value string) string {
+	if len(value) <= l.MaxValueLength {
+		return value
+	}
+	end := l.MaxValueLength
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return value[:end]
+}
+
+func hasLabel(labels []string, key string) bool {
+	for i := 0; i < len(labels); i += 2 {
+		if labels[i] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// requestMetricLabels are the labels set by RequestMetrics, that handlers can't override
+var requestMetricLabels = []string{"method", "route", "status"}
+
+// RequestMetricsConfig holds configuration for the RequestMetrics middleware
+type RequestMetricsConfig struct {
+	// Metrics receives the request metrics and the metrics recorded by the handlers
+	Metrics MetricsCollector
+
+	// Limits caps the labels added by the handlers
+	Limits MetricLabelLimits
+}
+
+// DefaultRequestMetricsConfig returns default configuration for request metrics
+func DefaultRequestMetricsConfig() RequestMetricsConfig {
+	return RequestMetricsConfig{
+		Limits: DefaultMetricLabelLimits(),
+	}
+}
+
+// LoadRequestMetricsConfig loads RequestMetricsConfig from environment variables
+// Environment variables:
+//   - ENABLE_REQUEST_METRICS (bool): enable/disable request metrics when a metrics collector is configured (default: true)
+//   - METRICS_MAX_LABELS (int): maximum number of labels added by the handlers to a metric (default: 8)
+//   - METRICS_MAX_LABEL_LENGTH (int): maximum length of a label value added by the handlers (default: 64)
+//
+// Returns nil if ENABLE_REQUEST_METRICS=false
+func LoadRequestMetricsConfig() *RequestMetricsConfig {
+	if !util.GetEnvBool("ENABLE_REQUEST_METRICS", true) {
+		return nil
+	}
+
+	cfg := DefaultRequestMetricsConfig()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// metricsScopeKey is the context key of the MetricsScope of a request
+type metricsScopeKey struct{}
+
+// bufferedMetric is a counter recorded by a handler
+type bufferedMetric struct {
+	name   string
+	value  float64
+	labels []string
+}
+
+// MetricsScope buffers the metrics and labels recorded while handling a
+// request, published by RequestMetrics once the response is written
+type MetricsScope struct {
+	limits MetricLabelLimits
+
+	mu      sync.Mutex
+	labels  []string
+	metrics []bufferedMetric
+	dropped int
+}
+
+// MetricsScopeFrom returns the metrics scope of the request, nil when the
+// RequestMetrics middleware isn't used
+func MetricsScopeFrom(ctx context.Context) *MetricsScope {
+	scope, _ := ctx.Value(metricsScopeKey{}).(*MetricsScope)
+	return scope
+}
+
+// Counter buffers a counter increment, published after the response
+func (s *MetricsScope) Counter(name string, value float64, labels ...string) {
+	labels, dropped := s.limits.Apply(labels)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, bufferedMetric{name: name, value: value, labels: labels})
+	s.dropped += dropped
+}
+
+// AddLabel adds a label to the request metrics, replacing the value of a label
+// already added. The method, route and status labels can't be overridden.
+func (s *MetricsScope) AddLabel(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < len(s.labels); i += 2 {
+		if s.labels[i] == key {
+			s.labels[i+1] = s.limits.truncate(value)
+			return
+		}
+	}
+	if key == "" || hasLabel(requestMetricLabels, key) || len(s.labels) == 2*s.limits.MaxLabels {
+		s.dropped++
+		return
+	}
+	s.labels = append(s.labels, key, s.limits.truncate(value))
+}
+
+// RequestMetrics publishes the "http_requests_total" counter and the
+// "http_request_duration_seconds" histogram of each request, labeled by
+// method, route pattern and status, plus the labels added by the handler with
+// MetricsScope.AddLabel. The counters recorded by the handler with
+// MetricsScope.Counter are published after the response. Labels beyond the
+// limits are dropped and counted by "metric_labels_dropped_total".
+//
+// Example:
+//
+//	r.UseHTTP(middleware.RequestMetrics(middleware.RequestMetricsConfig{Metrics: collector}))
+func RequestMetrics(config ...RequestMetricsConfig) func(http.Handler) http.Handler {
+	cfg := DefaultRequestMetricsConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	cfg.Limits = cfg.Limits.withDefaults()
+	metrics := metricsOrNoop(cfg.Metrics)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			scope := &MetricsScope{limits: cfg.Limits}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), metricsScopeKey{}, scope)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := routePattern(r)
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			scope.mu.Lock()
+			defer scope.mu.Unlock()
+			labels := append([]string{"method", metricMethod(r.Method), "route", route, "status", strconv.Itoa(status)}, scope.labels...)
+			metrics.Counter("http_requests_total", 1, labels...)
+			metrics.Observe("http_request_duration_seconds", time.Since(start).Seconds(), labels...)
+			for _, m := range scope.metrics {
+				metrics.Counter(m.name, m.value, m.labels...)
+			}
+			if scope.dropped > 0 {
+				metrics.Counter("metric_labels_dropped_total", float64(scope.dropped))
+			}
+		})
+	}
+}
+
+// metricMethod returns the method label, unknown methods being grouped so that
+// clients can't create labels
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labeledMetric is a metric published with its labels
+type labeledMetric struct {
+	name   string
+	value  float64
+	labels []string
+}
+
+// labeledMetrics records the counters and observations with their labels
+type labeledMetrics struct {
+	mu           sync.Mutex
+	counters     []labeledMetric
+	observations []labeledMetric
+}
+
+func (m *labeledMetrics) Counter(name string, value float64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters = append(m.counters, labeledMetric{name, value, labels})
+}
+
+func (m *labeledMetrics) Gauge(string, float64, ...string) {}
+
+func (m *labeledMetrics) Observe(name string, value float64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observations = append(m.observations, labeledMetric{name, value, labels})
+}
+
+func TestMetricLabelLimits_Apply(t *testing.T) {
+	limits := MetricLabelLimits{MaxLabels: 2, MaxValueLength: 5}
+
+	tests := []struct {
+		desc     string
+		labels   []string
+		expected []string
+		dropped  int
+	}{
+		{desc: "within the limits", labels: []string{"plan", "pro"}, expected: []string{"plan", "pro"}},
+		{desc: "too many labels", labels: []string{"a", "1", "b", "2", "c", "3"}, expected: []string{"a", "1", "b", "2"}, dropped: 1},
+		{desc: "long value", labels: []string{"plan", "enterprise"}, expected: []string{"plan", "enter"}},
+		{desc: "rune boundary", labels: []string{"city", "Kraków"}, expected: []string{"city", "Krak"}},
+		{desc: "missing value", labels: []string{"plan", "pro", "region"}, expected: []string{"plan", "pro"}, dropped: 1},
+		{desc: "empty and repeated keys", labels: []string{"", "x", "plan", "pro", "plan", "free"}, expected: []string{"plan", "pro"}, dropped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			labels, dropped := limits.Apply(tt.labels)
+			assert.Equal(t, tt.expected, labels)
+			assert.Equal(t, tt.dropped, dropped)
+		})
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	metrics := &labeledMetrics{}
+	r := chi.NewRouter()
+	r.Use(RequestMetrics(RequestMetricsConfig{Metrics: metrics, Limits: MetricLabelLimits{MaxLabels: 1}}))
+	r.Post("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		scope := MetricsScopeFrom(r.Context())
+		require.NotNil(t, scope)
+		scope.AddLabel("plan", "free")
+		scope.AddLabel("plan", strings.Repeat("p", 100))
+		scope.AddLabel("status", "ok")
+		scope.AddLabel("region", "eu")
+		scope.Counter("orders_created", 2, "plan", "pro")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PURGE", "/unknown", nil))
+
+	labels := []string{"method", "POST", "route", "/orders/{id}", "status", "201", "plan", strings.Repeat("p", 64)}
+	assert.Equal(t, []labeledMetric{
+		{"http_requests_total", 1, labels},
+		{"orders_created", 2, []string{"plan", "pro"}},
+		{"metric_labels_dropped_total", 2, nil},
+		{"http_requests_total", 1, []string{"method", "OTHER", "route", "unmatched", "status", "405"}},
+	}, metrics.counters)
+	require.Len(t, metrics.observations, 2)
+	assert.Equal(t, "http_request_duration_seconds", metrics.observations[0].name)
+	assert.Equal(t, labels, metrics.observations[0].labels)
+}
+
+func TestLoadRequestMetricsConfig(t *testing.T) {
+	t.Setenv("METRICS_MAX_LABELS", "3")
+	cfg := LoadRequestMetricsConfig()
+	require.NotNil(t, cfg)
+	assert.Equal(t, MetricLabelLimits{MaxLabels: 3, MaxValueLength: 64}, cfg.Limits)
+
+	t.Setenv("ENABLE_REQUEST_METRICS", "false")
+	assert.Nil(t, LoadRequestMetricsConfig())
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/util"
+)
+
+// SecurityHeadersConfig holds configuration for the SecurityHeaders middleware
+type SecurityHeadersConfig struct {
+	// Headers are the headers set on every response, by name
+	Headers map[string]string
+}
+
+// APISecurityHeaders returns the security headers of JSON APIs: responses are
+// never rendered as documents, framed or sent with a referrer
+func APISecurityHeaders() SecurityHeadersConfig {
+	return SecurityHeadersConfig{Headers: map[string]string{
+		"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "DENY",
+		"Referrer-Policy":         "no-referrer",
+	}}
+}
+
+// WebSecurityHeaders returns the security headers of web applications,
+// allowing their pages to frame each other and load their own resources
+func WebSecurityHeaders() SecurityHeadersConfig {
+	return SecurityHeadersConfig{Headers: map[string]string{
+		"Content-Security-Policy": "default-src 'self'; frame-ancestors 'self'",
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "SAMEORIGIN",
+		"Referrer-Policy":         "strict-origin-when-cross-origin",
+	}}
+}
+
+// LoadSecurityHeadersConfig loads SecurityHeadersConfig from environment
+// variables, with the headers of the profile: WebSecurityHeaders for the web
+// profile, APISecurityHeaders otherwise
+// Environment variables:
+//   - ENABLE_SECURITY_HEADERS (bool): enable/disable the headers (default: true in the api and web profiles)
+//   - SECURITY_CSP (string): Content-Security-Policy header, "-" to omit it (default: from the profile)
+//
+// Returns nil if disabled
+func LoadSecurityHeadersConfig(profile StackProfile) *SecurityHeadersConfig {
+	if !profile.enabled("SecurityHeaders", "ENABLE_SECURITY_HEADERS", false) {
+		return nil
+	}
+
+	cfg := APISecurityHeaders()
+	if profile == ProfileWeb {
+		cfg = WebSecurityHeaders()
+	}
+	switch csp := util.GetEnv("SECURITY_CSP", ""); csp {
+	case "":
+	case "-":
+		delete(cfg.Headers, "Content-Security-Policy")
+	default:
+		cfg.Headers["Content-Security-Policy"] = csp
+	}
+
+	return &cfg
+}
+
+// SecurityHeaders sets the configured headers on every response. They are set
+// before the handler runs, so handlers can still override or remove them.
+func SecurityHeaders(config SecurityHeadersConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			for name, value := range config.Headers {
+				header.Set(name, value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFConfig holds configuration for the CSRF middleware
+type CSRFConfig struct {
+	// TrustedOrigins are the origins allowed to send cross-origin requests,
+	// e.g. "https://admin.example.com"
+	TrustedOrigins []string
+}
+
+// LoadCSRFConfig loads CSRFConfig from environment variables
+// Environment variables:
+//   - ENABLE_CSRF (bool): enable/disable cross-origin request rejection (default: true in the web profile)
+//   - CSRF_TRUSTED_ORIGINS (string): comma-separated origins allowed to send cross-origin requests
+//
+// Returns nil if disabled
+func LoadCSRFConfig(profile StackProfile) *CSRFConfig {
+	if !profile.enabled("CSRF", "ENABLE_CSRF", false) {
+		return nil
+	}
+
+	return &CSRFConfig{TrustedOrigins: util.GetEnvStringSlice("CSRF_TRUSTED_ORIGINS", nil)}
+}
+
+// CSRF rejects the cross-origin non-safe requests (POST, PUT, DELETE...) sent
+// by browsers, with 403 Forbidden, using http.CrossOriginProtection: the
+// Sec-Fetch-Site header, or the Origin header for older browsers, must match
+// the request host or a trusted origin. Requests without these headers, such
+// as the ones of non-browser clients, are allowed.
+//
+// It panics if a trusted origin is invalid.
+func CSRF(config CSRFConfig) func(http.Handler) http.Handler {
+	protection := http.NewCrossOriginProtection()
+	for _, origin := range config.TrustedOrigins {
+		if err := protection.AddTrustedOrigin(origin); err != nil {
+			panic(fmt.Sprintf("middleware: invalid CSRF trusted origin %q: %v", origin, err))
+		}
+	}
+	protection.SetDenyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errors.Forbidden("Cross-origin request rejected", nil))
+	}))
+
+	return protection.Handler
+}
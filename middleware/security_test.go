@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSecurityHeadersConfig(t *testing.T) {
+	assert.Nil(t, LoadSecurityHeadersConfig(ProfileDefault))
+	assert.Equal(t, APISecurityHeaders(), *LoadSecurityHeadersConfig(ProfileAPI))
+	assert.Equal(t, WebSecurityHeaders(), *LoadSecurityHeadersConfig(ProfileWeb))
+
+	t.Setenv("SECURITY_CSP", "-")
+	cfg := LoadSecurityHeadersConfig(ProfileAPI)
+	require.NotNil(t, cfg)
+	assert.NotContains(t, cfg.Headers, "Content-Security-Policy")
+
+	t.Setenv("ENABLE_SECURITY_HEADERS", "false")
+	assert.Nil(t, LoadSecurityHeadersConfig(ProfileWeb))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	handler := SecurityHeaders(APISecurityHeaders())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Del("X-Frame-Options")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", w.Header().Get("Content-Security-Policy"))
+	assert.Empty(t, w.Header().Get("X-Frame-Options"), "handlers can remove the headers")
+}
+
+func TestCSRF(t *testing.T) {
+	handler := CSRF(CSRFConfig{TrustedOrigins: []string{"https://admin.example.com"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+	)
+
+	cases := []struct {
+		desc     string
+		method   string
+		headers  map[string]string
+		expected int
+	}{
+		{desc: "same origin", method: http.MethodPost, headers: map[string]string{"Sec-Fetch-Site": "same-origin"}, expected: http.StatusNoContent},
+		{desc: "non-browser client", method: http.MethodPost, expected: http.StatusNoContent},
+		{desc: "cross-origin read", method: http.MethodGet, headers: map[string]string{"Sec-Fetch-Site": "cross-site"}, expected: http.StatusNoContent},
+		{desc: "cross-origin write", method: http.MethodPost, headers: map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, expected: http.StatusForbidden},
+		{desc: "trusted origin", method: http.MethodDelete, headers: map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://admin.example.com"}, expected: http.StatusNoContent},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "http://api.example.com/users/1", nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+			if tc.expected == http.StatusForbidden {
+				assert.JSONEq(t, `{"code":403,"data":"Cross-origin request rejected"}`, w.Body.String())
+			}
+		})
+	}
+
+	assert.Panics(t, func() { CSRF(CSRFConfig{TrustedOrigins: []string{"admin.example.com"}}) })
+}
@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azizndao/glib/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// SlowRequestInfo describes a request exceeding a SlowRequest threshold
+type SlowRequestInfo struct {
+	// Route is the route pattern of the request, or its path if it didn't match a route
+	Route     string
+	Method    string
+	Path      string
+	RequestID string
+	Duration  time.Duration
+
+	// Profile is the goroutine profile of the request (very slow requests only),
+	// limited to its goroutines when pprof labels are enabled
+	Profile []byte
+}
+
+// SlowRequestConfig holds configuration for the SlowRequest middleware
+type SlowRequestConfig struct {
+	// Threshold is the duration above which a request is slow (default: 1s)
+	Threshold time.Duration `env:"SLOW_REQUEST_THRESHOLD"`
+
+	// VerySlowThreshold is the duration above which a request still running is
+	// very slow, capturing a goroutine profile (0 disables it)
+	VerySlowThreshold time.Duration `env:"SLOW_REQUEST_VERY_SLOW_THRESHOLD"`
+
+	// Labels sets the pprof labels "route" and "request_id" on the request
+	// goroutine, so CPU profiles can be segmented by endpoint
+	Labels bool `env:"SLOW_REQUEST_PPROF_LABELS"`
+
+	// OnSlow is called when a slow request completes.
+	// Default: warn log with the route pattern, duration and request ID
+	OnSlow func(r *http.Request, info SlowRequestInfo)
+
+	// OnVerySlow is called with the goroutine profile when a request runs longer
+	// than VerySlowThreshold. It runs on another goroutine while the request may
+	// still be handled, and receives a copy of the request taken before it.
+	// Default: warn log including the profile
+	OnVerySlow func(r *http.Request, info SlowRequestInfo)
+
+	// Logger is used by the default callbacks (default: slog.Default())
+	Logger *slog.Logger
+
+	// Metrics receives the "slow_requests_total" counter, labeled by route
+	Metrics MetricsCollector
+}
+
+// DefaultSlowRequestConfig returns default configuration for slow request detection
+func DefaultSlowRequestConfig() SlowRequestConfig {
+	return SlowRequestConfig{
+		Threshold: time.Second,
+	}
+}
+
+// LoadSlowRequestConfig loads SlowRequestConfig from environment variables
+// Environment variables:
+//   - ENABLE_SLOW_REQUEST (bool): enable/disable slow request detection (default: false)
+//   - SLOW_REQUEST_THRESHOLD (duration): duration above which a request is slow (default: 1s)
+//   - SLOW_REQUEST_VERY_SLOW_THRESHOLD (duration): duration above which a goroutine profile is captured (default: 0, disabled)
+//   - SLOW_REQUEST_PPROF_LABELS (bool): set pprof labels on request goroutines (default: false)
+//
+// Returns nil if ENABLE_SLOW_REQUEST=false
+func LoadSlowRequestConfig() *SlowRequestConfig {
+	if !util.GetEnvBool("ENABLE_SLOW_REQUEST", false) {
+		return nil
+	}
+
+	cfg := DefaultSlowRequestConfig()
+	loadEnv(&cfg)
+
+	return &cfg
+}
+
+// SlowRequest reports requests taking longer than the threshold. Requests still
+// running after VerySlowThreshold also capture a goroutine profile, which only
+// contains the goroutines of the request when pprof labels are enabled.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.SlowRequest(middleware.SlowRequestConfig{
+//	    Threshold:         500 * time.Millisecond,
+//	    VerySlowThreshold: 5 * time.Second,
+//	    Labels:            true,
+//	}))
+func SlowRequest(config ...SlowRequestConfig) func(http.Handler) http.Handler {
+	cfg := DefaultSlowRequestConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = time.Second
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.OnSlow == nil {
+		cfg.OnSlow = func(r *http.Request, info SlowRequestInfo) {
+			logger.WarnContext(r.Context(), "Slow request",
+				"route", info.Route,
+				"duration", info.Duration,
+				"request_id", info.RequestID,
+			)
+		}
+	}
+	if cfg.OnVerySlow == nil {
+		cfg.OnVerySlow = func(r *http.Request, info SlowRequestInfo) {
+			logger.WarnContext(r.Context(), "Very slow request",
+				"route", info.Route,
+				"duration", info.Duration,
+				"request_id", info.RequestID,
+				"goroutines", string(info.Profile),
+			)
+		}
+	}
+	metrics := metricsOrNoop(cfg.Metrics)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestID := CorrelationID(r.Context())
+
+			var route string
+			if cfg.Labels {
+				route = findRoute(r)
+			}
+
+			if cfg.VerySlowThreshold > 0 {
+				// The timer may fire while the handler changes the request, it
+				// only reads values copied beforehand
+				snapshot := r.Clone(r.Context())
+				info := SlowRequestInfo{
+					Route:     orPath(route, r),
+					Method:    r.Method,
+					Path:      r.URL.Path,
+					RequestID: requestID,
+				}
+				timer := time.AfterFunc(cfg.VerySlowThreshold, func() {
+					info.Duration = time.Since(start)
+					info.Profile = goroutineProfile(cfg.Labels, requestID)
+					cfg.OnVerySlow(snapshot, info)
+				})
+				defer timer.Stop()
+			}
+
+			if cfg.Labels {
+				labels := pprof.Labels("route", orPath(route, r), "request_id", requestID)
+				pprof.Do(r.Context(), labels, func(ctx context.Context) {
+					next.ServeHTTP(w, r.WithContext(ctx))
+				})
+			} else {
+				next.ServeHTTP(w, r)
+			}
+
+			duration := time.Since(start)
+			if duration < cfg.Threshold {
+				return
+			}
+			routed := route
+			if routed == "" {
+				routed = routePattern(r)
+			}
+			routed = orPath(routed, r)
+			metrics.Counter("slow_requests_total", 1, "route", routed)
+			cfg.OnSlow(r, SlowRequestInfo{
+				Route:     routed,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				RequestID: requestID,
+				Duration:  duration,
+			})
+		})
+	}
+}
+
+// findRoute resolves the route pattern of the request before it is routed
+func findRoute(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return ""
+	}
+	return rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+}
+
+// routePattern returns the route pattern of a routed request
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+func orPath(route string, r *http.Request) string {
+	if route == "" {
+		return r.URL.Path
+	}
+	return route
+}
+
+// goroutineProfile returns the goroutine profile, limited to the goroutines
+// labeled with the request ID when labels are enabled
+func goroutineProfile(labels bool, requestID string) []byte {
+	if !labels || requestID == "" {
+		return labeledGoroutines("", "")
+	}
+	return labeledGoroutines("request_id", requestID)
+}
+
+// labeledGoroutines returns the goroutine profile, limited to the goroutines
+// having the pprof label unless key is empty
+func labeledGoroutines(key, value string) []byte {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+	if key == "" {
+		return buf.Bytes()
+	}
+
+	label := strconv.Quote(key) + ":" + strconv.Quote(value)
+	var filtered bytes.Buffer
+	for block := range strings.SplitSeq(buf.String(), "\n\n") {
+		if strings.Contains(block, "# labels: ") && strings.Contains(block, label) {
+			filtered.WriteString(block)
+			filtered.WriteString("\n\n")
+		}
+	}
+	return filtered.Bytes()
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowRequest(t *testing.T) {
+	var mu sync.Mutex
+	var slow []SlowRequestInfo
+	var verySlow []SlowRequestInfo
+	labels := map[string]string{}
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(SlowRequest(SlowRequestConfig{
+		Threshold:         20 * time.Millisecond,
+		VerySlowThreshold: 50 * time.Millisecond,
+		Labels:            true,
+		OnSlow: func(r *http.Request, info SlowRequestInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			slow = append(slow, info)
+		},
+		OnVerySlow: func(r *http.Request, info SlowRequestInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			verySlow = append(verySlow, info)
+		},
+	}))
+	r.Get("/fast", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/slow/{id}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.ForLabels(r.Context(), func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow/5", nil))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, slow, 1, "only the slow request is reported")
+	assert.Equal(t, "/slow/{id}", slow[0].Route)
+	assert.Equal(t, "GET", slow[0].Method)
+	assert.Equal(t, "/slow/5", slow[0].Path)
+	assert.NotEmpty(t, slow[0].RequestID)
+	assert.GreaterOrEqual(t, slow[0].Duration, 100*time.Millisecond)
+
+	require.Len(t, verySlow, 1)
+	assert.Equal(t, "/slow/{id}", verySlow[0].Route)
+	assert.Equal(t, "/slow/5", verySlow[0].Path)
+	assert.Contains(t, string(verySlow[0].Profile), slow[0].RequestID, "the profile contains the goroutines of the request")
+
+	assert.Equal(t, "/slow/{id}", labels["route"])
+	assert.Equal(t, slow[0].RequestID, labels["request_id"])
+}
@@ -1,90 +1,255 @@
 package middleware
 
 import (
-	"encoding/json"
+	"io"
+	"log"
 	"log/slog"
 	"net/http"
 
 	"github.com/azizndao/glib/errors"
-	"github.com/azizndao/glib/util"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-chi/httplog/v3"
-	"github.com/go-chi/httprate"
 )
 
 // Stack builds a middleware stack from environment variables.
-// Middleware are loaded and applied in this specific order:
-//  1. RealIP - Extract real client IP from proxy headers
-//  2. RequestID - Generate unique request IDs
-//  3. Recovery - Panic recovery (prevents crashes)
-//  4. Logger - Request/response logging
-//  5. Compress - GZIP/Deflate compression
-//  6. BodyLimit - Request body size limiting
-//  7. RateLimit - Rate limiting (if configured)
-//  8. CORS - Cross-origin resource sharing
-//  9. Validation - Request validation with i18n (if locales provided)
+// Middleware are loaded and applied in this specific order, the profile
+// (STACK_PROFILE, see StackProfile) selecting the ones enabled by default:
+//  1. RealIP - Client IP from the headers of the proxies of TRUSTED_PROXIES
+//  2. Heartbeat - Health check endpoint, not logged (if ENABLE_HEARTBEAT=true)
+//  3. Favicon - /favicon.ico short-circuit (if ENABLE_FAVICON=true)
+//  4. Robots - /robots.txt short-circuit (if ROBOTS_POLICY is set)
+//  5. RequestID - Request IDs, from the header, traceparent or generated
+//  6. Logger - Request/response logging
+//  7. RequestMetrics - Request counters and durations (if a metrics collector is configured)
+//  8. Recovery - Panic recovery (prevents crashes)
+//  9. SecurityHeaders - Security response headers (api and web profiles)
+//  10. HeaderLimit - Request header size and count limiting (if ENABLE_HEADER_LIMIT=true)
+//  11. SlowRequest - Slow request detection and pprof labels (if configured)
+//  12. Watchdog - Stack dump of requests running too long (if ENABLE_WATCHDOG=true, never in production)
+//  13. DeadlineFromHeader - Caller deadline budget (if configured)
+//  14. LoadShed - Load shedding (if configured)
+//  15. Chaos - Fault injection (if CHAOS_ENABLED=true, never in production)
+//  16. Compress - GZIP/Deflate compression
+//  17. BodyLimit - Request body size limiting
+//  18. ConcurrencyPerClient - Per-client in-flight request limiting (if configured)
+//  19. RateLimit - Rate limiting, or observe-only with RATE_LIMIT_DRY_RUN (if configured)
+//  20. CORS - Cross-origin resource sharing
+//  21. CSRF - Cross-origin request rejection (web profile)
+//  22. HeaderLint - Response header audit (if IS_DEBUG=true)
 //
 // Each middleware can be disabled via its corresponding ENABLE_* environment variable.
-// Pass StackConfig with validation locales and optional custom store.
 func Stack(logger *slog.Logger) chi.Middlewares {
-	middlewares := make([]func(http.Handler) http.Handler, 0)
+	return StackWith(StackConfig{Logger: logger})
+}
+
+// StackConfig holds the dependencies of the middleware stack that can't be loaded from environment variables
+type StackConfig struct {
+	// Logger is used by the logging and recovery middleware
+	Logger *slog.Logger
+
+	// ErrorReporter receives recovered panics
+	ErrorReporter errors.Reporter
+
+	// Metrics receives the metrics published by the middleware
+	Metrics MetricsCollector
+
+	// LogOutput is the destination of the access log lines in debug mode
+	// (default: os.Stdout), e.g. the slog.AsyncWriter shared with Logger
+	LogOutput io.Writer
+
+	// Profile selects the middleware enabled by default (default: STACK_PROFILE)
+	Profile StackProfile
+}
+
+// stackEnv holds the settings of the stack itself, the middleware loading their own
+type stackEnv struct {
+	// Debug logs the requests in the development format
+	Debug bool `env:"IS_DEBUG"`
+
+	// LogHeartbeat logs the requests of the heartbeat path
+	LogHeartbeat bool `env:"LOG_HEARTBEAT"`
+
+	// HeartbeatPath is skipped by the logging and metrics middleware
+	HeartbeatPath string `env:"HEARTBEAT_PATH"`
+}
+
+// NamedMiddleware is a middleware of the stack with its name
+type NamedMiddleware struct {
+	Name       string
+	Middleware func(http.Handler) http.Handler
+}
+
+// StackWith builds the middleware stack from environment variables, like Stack,
+// using the given dependencies
+func StackWith(config StackConfig) chi.Middlewares {
+	entries := StackEntries(config)
+	middlewares := make(chi.Middlewares, len(entries))
+	for i, entry := range entries {
+		middlewares[i] = entry.Middleware
+	}
+	return middlewares
+}
+
+// StackEntries builds the middleware stack like StackWith, reporting the name of
+// each enabled middleware
+func StackEntries(config StackConfig) []NamedMiddleware {
+	logger := config.Logger
+	profile := config.Profile
+	if profile == ProfileDefault {
+		profile = LoadStackProfile()
+	}
+	env := stackEnv{HeartbeatPath: DefaultHeartbeatPath}
+	loadEnv(&env)
+	middlewares := make([]NamedMiddleware, 0)
+	add := func(name string, mw func(http.Handler) http.Handler) {
+		middlewares = append(middlewares, NamedMiddleware{Name: name, Middleware: mw})
+	}
 
 	// Order matters! These middleware are applied in the order specified
 
-	// RealIP should be early to extract correct client IP
-	if util.GetEnvBool("ENABLE_REAL_IP", true) {
-		middlewares = append(middlewares, middleware.RealIP)
+	// RealIP should be early to extract correct client IP, only from the
+	// headers of trusted proxies as the others can be forged
+	if profile.enabled("RealIP", "ENABLE_REAL_IP", true) {
+		add("RealIP", RealIP())
+	}
+
+	// Heartbeat before logging, so health checks don't fill the logs and metrics
+	heartbeatCfg := LoadHeartbeatConfig()
+	if heartbeatCfg != nil {
+		add("Heartbeat", Heartbeat(*heartbeatCfg))
+	}
+	// The heartbeat path is also skipped when the heartbeat is registered on the router
+	notHeartbeat := func(mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+		if env.LogHeartbeat {
+			return mw
+		}
+		return skipPath(env.HeartbeatPath, mw)
+	}
+
+	// Favicon and robots.txt are answered before logging, so crawlers and
+	// browsers don't fill the logs
+	if favicon := LoadFaviconConfig(); favicon != nil {
+		add("Favicon", Favicon(favicon))
+	}
+	if robots := LoadRobotsConfig(); robots != nil {
+		add("Robots", Robots(*robots))
 	}
 
 	// RequestID early for logging
-	if util.GetEnvBool("ENABLE_REQUEST_ID", true) {
-		middlewares = append(middlewares, middleware.RequestID)
+	if requestID := LoadRequestIDConfig(); requestID != nil && profile.enabled("RequestID", "ENABLE_REQUEST_ID", true) {
+		add("RequestID", RequestID(*requestID))
 	}
 
-	// Logger after recovery and request ID
-	if util.GetEnvBool("ENABLE_LOGGER", true) {
-		if util.GetEnvBool("IS_DEBUG", false) {
-			middlewares = append(middlewares, middleware.Logger)
+	// Logger after the request ID and before recovery, which writes the 500
+	// response of a panic logged like any other response
+	if profile.enabled("Logger", "ENABLE_LOGGER", true) {
+		if env.Debug {
+			if config.LogOutput != nil {
+				add("Logger", notHeartbeat(middleware.RequestLogger(&middleware.DefaultLogFormatter{
+					Logger: log.New(config.LogOutput, "", log.LstdFlags),
+				})))
+			} else {
+				add("Logger", notHeartbeat(middleware.Logger))
+			}
 		} else {
-			middlewares = append(middlewares, httplog.RequestLogger(logger, &httplog.Options{}))
+			loggerCfg := LoadLoggerConfig()
+			loggerCfg.Logger = logger
+			add("Logger", notHeartbeat(Logger(loggerCfg)))
 		}
 	}
 
+	// Request metrics before recovery, so the panics are counted as 500 responses
+	if metricsCfg := LoadRequestMetricsConfig(); metricsCfg != nil && config.Metrics != nil {
+		metricsCfg.Metrics = config.Metrics
+		add("RequestMetrics", notHeartbeat(RequestMetrics(*metricsCfg)))
+	}
+
 	// Recovery should be early to catch panics from other middleware
-	if util.GetEnvBool("ENABLE_RECOVERY", true) {
-		middlewares = append(middlewares, middleware.Recoverer)
+	if recoveryCfg := LoadRecoveryConfig(); recoveryCfg != nil && profile.enabled("Recovery", "ENABLE_RECOVERY", true) {
+		recoveryCfg.Logger = logger
+		recoveryCfg.Reporter = config.ErrorReporter
+		recoveryCfg.Metrics = config.Metrics
+		add("Recovery", Recovery(*recoveryCfg))
+	}
+
+	// Security headers, set before any middleware can respond
+	if securityCfg := LoadSecurityHeadersConfig(profile); securityCfg != nil {
+		add("SecurityHeaders", SecurityHeaders(*securityCfg))
+	}
+
+	// Request header limits, before any work is done with the headers
+	if headerLimitCfg := LoadHeaderLimitConfig(); headerLimitCfg != nil {
+		add("HeaderLimit", HeaderLimit(*headerLimitCfg))
+	}
+
+	// Slow request detection, measuring the time spent in the rest of the stack
+	if slowCfg := LoadSlowRequestConfig(); slowCfg != nil {
+		slowCfg.Logger = logger
+		slowCfg.Metrics = config.Metrics
+		add("SlowRequest", notHeartbeat(SlowRequest(*slowCfg)))
+	}
+
+	// Stack dump of stuck requests, development only
+	if limit := LoadWatchdogLimit(); limit > 0 {
+		add("Watchdog", Watchdog(limit))
+	}
+
+	// Caller deadline budget, so that rejected and shed requests don't count against it
+	if deadlineCfg := LoadDeadlineConfig(); deadlineCfg != nil {
+		add("DeadlineFromHeader", DeadlineFromHeader(*deadlineCfg))
+	}
+
+	// Load shedding, before any work is done for the request
+	if loadShedCfg := LoadLoadShedConfig(); loadShedCfg != nil {
+		loadShedCfg.Metrics = config.Metrics
+		add("LoadShed", LoadShed(*loadShedCfg))
+	}
+
+	// Fault injection, only in non-production environments with CHAOS_ENABLED=true
+	if chaosCfg := LoadChaosConfig(); chaosCfg != nil {
+		add("Chaos", Chaos(*chaosCfg))
 	}
 
 	// Compression
-	if compressCfg := LoadCompressConfig(); compressCfg != nil {
-		middlewares = append(middlewares, middleware.Compress(compressCfg.Level))
+	if compressCfg := LoadCompressConfig(); compressCfg != nil && profile.enabled("Compress", "ENABLE_COMPRESS", true) {
+		if profile == ProfileAPI && compressCfg.Types == nil {
+			// APIs send JSON and text, never compress already compressed files
+			compressCfg.Types = apiCompressTypes
+		}
+		add("Compress", Compress(*compressCfg))
 	}
 
 	// Body limit
-	if bodyLimitCfg := LoadBodyLimitConfig(); bodyLimitCfg != nil {
-		middlewares = append(middlewares, middleware.RequestSize(bodyLimitCfg.MaxSize))
+	if bodyLimitCfg := LoadBodyLimitConfig(); bodyLimitCfg != nil && profile.enabled("BodyLimit", "ENABLE_BODY_LIMIT", true) {
+		add("BodyLimit", middleware.RequestSize(bodyLimitCfg.MaxSize))
+	}
+
+	// Per-client concurrency limiting (if enabled via env)
+	if concurrencyCfg := LoadConcurrencyConfig(); concurrencyCfg != nil {
+		add("ConcurrencyPerClient", ConcurrencyPerClient(*concurrencyCfg))
 	}
 
 	// Rate limiting (if enabled via env)
 	if rateLimitCfg := LoadRateLimitConfig(); rateLimitCfg != nil {
-		middlewares = append(middlewares, httprate.Limit(
-			rateLimitCfg.Max,
-			rateLimitCfg.Window,
-			httprate.WithKeyByRealIP(),
-			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
-				err := errors.NewApi(http.StatusTooManyRequests, "Rate-limited", nil)
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusTooManyRequests)
-				json.NewEncoder(w).Encode(err)
-			}),
-		))
+		rateLimitCfg.Logger = logger
+		add("RateLimit", RateLimit(*rateLimitCfg))
 	}
 
 	// CORS
-	if corsCfg := LoadCORSOptions(); corsCfg != nil {
-		middlewares = append(middlewares, cors.Handler(*corsCfg))
+	if corsCfg := LoadCORSOptions(); corsCfg != nil && profile.enabled("CORS", "ENABLE_CORS", true) {
+		add("CORS", cors.Handler(*corsCfg))
+	}
+
+	// Cross-origin request rejection, after the CORS preflight requests are answered
+	if csrfCfg := LoadCSRFConfig(profile); csrfCfg != nil {
+		add("CSRF", CSRF(*csrfCfg))
+	}
+
+	// Response header audit, development only, seeing the headers of the whole stack
+	if headerLintCfg := LoadHeaderLintConfig(); headerLintCfg != nil {
+		headerLintCfg.Logger = logger
+		add("HeaderLint", HeaderLint(*headerLintCfg))
 	}
 	return middlewares
 }
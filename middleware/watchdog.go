@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/azizndao/glib/util"
+)
+
+// DefaultWatchdogLimit is the default duration after which the Watchdog dumps a request
+const DefaultWatchdogLimit = 5 * time.Second
+
+// watchdogLabel is the pprof label identifying the goroutines of a watched request
+const watchdogLabel = "watchdog"
+
+// watchdogIDs numbers the requests watched by the Watchdog
+var watchdogIDs atomic.Uint64
+
+// LoadWatchdogLimit loads the Watchdog limit from environment variables
+// Environment variables:
+//   - ENABLE_WATCHDOG (bool): enable the watchdog, development only (default: false)
+//   - WATCHDOG_LIMIT (duration): duration after which a running request is dumped (default: 5s)
+//
+// Returns 0 if the watchdog is not enabled or IS_PRODUCTION=true
+func LoadWatchdogLimit() time.Duration {
+	if !util.GetEnvBool("ENABLE_WATCHDOG", false) || util.GetEnvBool("IS_PRODUCTION", false) {
+		return 0
+	}
+	return util.GetEnvDuration("WATCHDOG_LIMIT", DefaultWatchdogLimit)
+}
+
+// Watchdog logs the stacks of the goroutines of a request still running after
+// the limit, once per request, to find the handlers blocked on a forgotten
+// mutex or an external call without timeout. The request is not interrupted.
+//
+// DEVELOPMENT ONLY: dumping the goroutine profile stops the world. Requests
+// finishing under the limit only pay for a timer and a pprof label, set at the
+// start of the request so the goroutines it spawns are dumped too.
+//
+// Example:
+//
+//	if !production {
+//	    r.UseHTTP(middleware.Watchdog(2 * time.Second))
+//	}
+func Watchdog(limit time.Duration) func(http.Handler) http.Handler {
+	if limit <= 0 {
+		limit = DefaultWatchdogLimit
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := strconv.FormatUint(watchdogIDs.Add(1), 10)
+			start := time.Now()
+
+			timer := time.AfterFunc(limit, func() {
+				slog.Default().WarnContext(r.Context(), "Request exceeded the watchdog limit",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"request_id", CorrelationID(r.Context()),
+					"elapsed", time.Since(start),
+					"goroutines", string(labeledGoroutines(watchdogLabel, id)),
+				)
+			})
+			defer timer.Stop()
+
+			pprof.Do(r.Context(), pprof.Labels(watchdogLabel, id), func(ctx context.Context) {
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// blockedOnMutex waits for a mutex held by someone else, like a forgotten Unlock
+func blockedOnMutex(mu *sync.Mutex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	logs := &syncBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	t.Run("silent under the limit", func(t *testing.T) {
+		handler := Watchdog(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("dumps the stuck request once", func(t *testing.T) {
+		var mu sync.Mutex
+		mu.Lock()
+		time.AfterFunc(150*time.Millisecond, mu.Unlock)
+
+		handler := Watchdog(30 * time.Millisecond)(blockedOnMutex(&mu))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/stuck", nil))
+
+		out := logs.String()
+		assert.Equal(t, 1, strings.Count(out, "Request exceeded the watchdog limit"))
+		assert.Contains(t, out, "path=/stuck")
+		assert.Contains(t, out, "blockedOnMutex", "the stack of the handler is dumped")
+		assert.Contains(t, out, "sync.(*Mutex).Lock")
+	})
+
+	t.Run("disabled in production", func(t *testing.T) {
+		t.Setenv("ENABLE_WATCHDOG", "true")
+		assert.Equal(t, DefaultWatchdogLimit, LoadWatchdogLimit())
+
+		t.Setenv("IS_PRODUCTION", "true")
+		assert.Zero(t, LoadWatchdogLimit())
+	})
+}
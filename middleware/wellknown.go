@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/azizndao/glib/util"
+)
+
+//go:embed favicon.ico
+var defaultFavicon []byte
+
+const (
+	// FaviconCacheControl is the Cache-Control header of the favicon
+	FaviconCacheControl = "public, max-age=604800"
+	// RobotsCacheControl is the Cache-Control header of robots.txt
+	RobotsCacheControl = "public, max-age=86400"
+
+	// RobotsAllow allows all crawlers
+	RobotsAllow = "User-agent: *\nAllow: /\n"
+	// RobotsDeny disallows all crawlers
+	RobotsDeny = "User-agent: *\nDisallow: /\n"
+)
+
+// RobotsPolicy selects the content of robots.txt
+type RobotsPolicy string
+
+const (
+	RobotsPolicyAllow  RobotsPolicy = "allow"
+	RobotsPolicyDeny   RobotsPolicy = "deny"
+	RobotsPolicyCustom RobotsPolicy = "custom"
+)
+
+// LoadFaviconConfig loads the favicon from environment variables
+// Environment variable: ENABLE_FAVICON (bool): answer /favicon.ico with the default favicon (default: false)
+// Returns nil if ENABLE_FAVICON=false, otherwise returns the default favicon
+func LoadFaviconConfig() []byte {
+	if !util.GetEnvBool("ENABLE_FAVICON", false) {
+		return nil
+	}
+	return defaultFavicon
+}
+
+// LoadRobotsConfig loads the robots.txt content from environment variables
+// Environment variables:
+//   - ROBOTS_POLICY (string): "allow", "deny" or "custom" (default: disabled)
+//   - ROBOTS_CONTENT (string): content of robots.txt with ROBOTS_POLICY=custom
+//
+// Returns nil if ROBOTS_POLICY is not set, otherwise returns the content.
+// A custom policy without content denies all crawlers.
+func LoadRobotsConfig() *string {
+	var content string
+	switch policy := RobotsPolicy(strings.ToLower(util.GetEnv("ROBOTS_POLICY", ""))); policy {
+	case RobotsPolicyAllow:
+		content = RobotsAllow
+	case RobotsPolicyDeny:
+		content = RobotsDeny
+	case RobotsPolicyCustom:
+		content = strings.ReplaceAll(util.GetEnv("ROBOTS_CONTENT", RobotsDeny), `\n`, "\n")
+	default:
+		return nil
+	}
+	return &content
+}
+
+// Favicon answers GET and HEAD requests to /favicon.ico with the given icon,
+// or a default one if data is empty, before they reach the router and its
+// logs. Responses are cacheable for a week and support conditional requests.
+//
+// Example:
+//
+//	//go:embed static/favicon.ico
+//	var favicon []byte
+//
+//	r.UseHTTP(middleware.Favicon(favicon))
+func Favicon(data []byte) func(http.Handler) http.Handler {
+	if len(data) == 0 {
+		data = defaultFavicon
+	}
+	return wellKnown("/favicon.ico", "image/x-icon", FaviconCacheControl, data)
+}
+
+// Robots answers GET and HEAD requests to /robots.txt with the given content,
+// before they reach the router and its logs. An empty content denies all
+// crawlers, unless IS_PRODUCTION=true where it allows them. Responses are
+// cacheable for a day and support conditional requests.
+//
+// Example:
+//
+//	r.UseHTTP(middleware.Robots("User-agent: *\nDisallow: /admin/\n"))
+func Robots(content string) func(http.Handler) http.Handler {
+	if content == "" {
+		content = RobotsDeny
+		if util.GetEnvBool("IS_PRODUCTION", false) {
+			content = RobotsAllow
+		}
+	}
+	return wellKnown("/robots.txt", "text/plain; charset=utf-8", RobotsCacheControl, []byte(content))
+}
+
+// wellKnown serves a static resource at path, with a strong ETag computed from its content
+func wellKnown(path, contentType, cacheControl string, data []byte) func(http.Handler) http.Handler {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != path || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("Content-Type", contentType)
+			header.Set("Cache-Control", cacheControl)
+			header.Set("ETag", etag)
+			// ServeContent handles HEAD, If-None-Match and ranges
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+		})
+	}
+}
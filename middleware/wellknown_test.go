@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavicon(t *testing.T) {
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := Favicon(nil)(notFound)
+
+	t.Run("serves the default favicon", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/x-icon", w.Header().Get("Content-Type"))
+		assert.Equal(t, FaviconCacheControl, w.Header().Get("Cache-Control"))
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.Equal(t, defaultFavicon, w.Body.Bytes())
+	})
+
+	t.Run("HEAD", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/favicon.ico", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("conditional GET", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		req := httptest.NewRequest(http.MethodGet, "/favicon.ico", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("passes other requests", func(t *testing.T) {
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/favicon.png", nil),
+			httptest.NewRequest(http.MethodPost, "/favicon.ico", nil),
+		} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestRobots(t *testing.T) {
+	get := func(handler http.Handler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+		return w
+	}
+	next := http.NotFoundHandler()
+
+	t.Run("custom content", func(t *testing.T) {
+		w := get(Robots("User-agent: *\nDisallow: /admin/\n")(next))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "User-agent: *\nDisallow: /admin/\n", w.Body.String())
+	})
+
+	t.Run("denies by default outside production", func(t *testing.T) {
+		assert.Equal(t, RobotsDeny, get(Robots("")(next)).Body.String())
+
+		t.Setenv("IS_PRODUCTION", "true")
+		assert.Equal(t, RobotsAllow, get(Robots("")(next)).Body.String())
+	})
+
+	t.Run("policy from environment", func(t *testing.T) {
+		assert.Nil(t, LoadRobotsConfig())
+
+		t.Setenv("ROBOTS_POLICY", "allow")
+		require.NotNil(t, LoadRobotsConfig())
+		assert.Equal(t, RobotsAllow, *LoadRobotsConfig())
+
+		t.Setenv("ROBOTS_POLICY", "custom")
+		t.Setenv("ROBOTS_CONTENT", `User-agent: *\nDisallow: /private/`)
+		assert.Equal(t, "User-agent: *\nDisallow: /private/", *LoadRobotsConfig())
+	})
+}
@@ -0,0 +1,44 @@
+package glib
+
+import (
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// Named names a middleware in the origin of the errors it returns (see
+// errors.FromMiddleware) and in the request traces (see TraceConfig), instead
+// of the name derived from its function.
+//
+// Example:
+//
+//	r.Use(glib.Named("auth", RequireRole("admin")))
+func Named(name string, mw Middleware) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		h := mw(next)
+		return func(c *Ctx) error {
+			defer c.traceNamed(name)()
+			return errors.FromMiddleware(name, h(c))
+		}
+	}
+}
+
+// closureSuffix matches the suffixes of closure function names, e.g. ".func1.2"
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+(\.\d+)*$`)
+
+// middlewareName derives the name of a middleware from its function, e.g.
+// "main.RequireRole" for the closure returned by RequireRole
+func middlewareName(mw Middleware) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return closureSuffix.ReplaceAllString(name, "")
+}
@@ -0,0 +1,85 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireToken(next HandleFunc) HandleFunc {
+	return func(c *Ctx) error {
+		if c.Get("Authorization") == "" {
+			return errors.Unauthorized("Missing token", nil)
+		}
+		return next(c)
+	}
+}
+
+func TestRouter_ErrorOrigin(t *testing.T) {
+	tests := []struct {
+		desc         string
+		middleware   Middleware
+		header       string
+		expectCode   int
+		expectOrigin string
+	}{
+		{desc: "derived middleware name", middleware: requireToken, expectCode: http.StatusUnauthorized, expectOrigin: "middleware glib.requireToken"},
+		{desc: "named middleware", middleware: Named("auth", requireToken), expectCode: http.StatusUnauthorized, expectOrigin: "middleware auth"},
+		{desc: "handler", middleware: requireToken, header: "Bearer x", expectCode: http.StatusConflict, expectOrigin: "handler"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var origin *errors.OriginError
+			config := DefaultRouterOptions()
+			config.ErrorHandler = func(c *Ctx, err error) {
+				origin = errors.OriginOf(err)
+			}
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+			r.Use(tt.middleware)
+			r.Get("/", func(c *Ctx) error {
+				return errors.Conflict("Conflict", nil)
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			require.NotNil(t, origin)
+			assert.Equal(t, tt.expectOrigin, origin.Origin())
+		})
+	}
+}
+
+func TestRouter_ErrorAfterResponse(t *testing.T) {
+	r := setupTestRouter()
+	r.Use(func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			if err := next(c); err != nil {
+				return err
+			}
+			return errors.InternalServerError("Too late", nil)
+		}
+	})
+	r.Get("/", func(c *Ctx) error {
+		if err := c.SendString("done"); err != nil {
+			return err
+		}
+		return errors.BadRequest("Too late", nil)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "done", w.Body.String(), "errors returned after responding are not rendered")
+}
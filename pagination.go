@@ -0,0 +1,262 @@
+package glib
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/httputil"
+)
+
+// PageDefaults configures how Ctx.Pagination parses the pagination query parameters
+type PageDefaults struct {
+	// Limit is the page size used when the limit parameter is absent (default: 20)
+	Limit int
+	// MaxLimit is the largest accepted page size (default: 100)
+	MaxLimit int
+	// PageParam is the name of the page number query parameter (default: "page")
+	PageParam string
+	// LimitParam is the name of the page size query parameter (default: "limit")
+	LimitParam string
+	// CursorParam is the name of the cursor query parameter (default: "cursor")
+	CursorParam string
+}
+
+// DefaultPageDefaults returns the default pagination settings
+func DefaultPageDefaults() PageDefaults {
+	return PageDefaults{
+		Limit:       20,
+		MaxLimit:    100,
+		PageParam:   "page",
+		LimitParam:  "limit",
+		CursorParam: "cursor",
+	}
+}
+
+// Page holds the pagination parameters of a request
+type Page struct {
+	// Page is the 1-based page number (offset mode)
+	Page int
+	// Limit is the page size
+	Limit int
+	// Offset is the number of items to skip, computed from Page and Limit
+	Offset int
+	// Cursor is the decoded cursor (cursor mode), empty for the first page
+	Cursor string
+
+	defaults PageDefaults
+}
+
+// Paginated is the standard response envelope for paginated lists
+type Paginated[T any] struct {
+	Items      []T    `json:"items"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      *int   `json:"total,omitempty"` // offset mode only, including 0
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Pagination parses the page, limit and cursor query parameters.
+// Returns a 400 Bad Request error when a parameter is not a number, is out of
+// bounds (including a page whose offset overflows an int) or when the cursor
+// is malformed.
+//
+// Example:
+//
+//	page, err := c.Pagination()
+//	if err != nil {
+//	    return err
+//	}
+//	users, total := repo.List(page.Offset, page.Limit)
+//	return glib.JSONPage(c, users, total, page)
+func (c *Ctx) Pagination(defaults ...PageDefaults) (Page, error) {
+	d := DefaultPageDefaults()
+	if len(defaults) > 0 {
+		d = mergePageDefaults(d, defaults[0])
+	}
+
+	page := Page{Page: 1, Limit: d.Limit, defaults: d}
+
+	if value := c.Query(d.LimitParam); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > d.MaxLimit {
+			return Page{}, errors.BadRequest(
+				fmt.Sprintf("%s must be an integer between 1 and %d", d.LimitParam, d.MaxLimit), err,
+			)
+		}
+		page.Limit = limit
+	}
+
+	if value := c.Query(d.PageParam); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return Page{}, errors.BadRequest(fmt.Sprintf("%s must be a positive integer", d.PageParam), err)
+		}
+		if maxPage := math.MaxInt/page.Limit + 1; number > maxPage {
+			return Page{}, errors.BadRequest(fmt.Sprintf("%s must be an integer between 1 and %d", d.PageParam, maxPage), nil)
+		}
+		page.Page = number
+	}
+	page.Offset = (page.Page - 1) * page.Limit
+
+	if value := c.Query(d.CursorParam); value != "" {
+		cursor, err := DecodeCursor(value)
+		if err != nil {
+			return Page{}, errors.BadRequest(fmt.Sprintf("Invalid %s", d.CursorParam), err)
+		}
+		page.Cursor = cursor
+	}
+
+	return page, nil
+}
+
+func mergePageDefaults(base, override PageDefaults) PageDefaults {
+	if override.Limit > 0 {
+		base.Limit = override.Limit
+	}
+	if override.MaxLimit > 0 {
+		base.MaxLimit = override.MaxLimit
+	}
+	if override.PageParam != "" {
+		base.PageParam = override.PageParam
+	}
+	if override.LimitParam != "" {
+		base.LimitParam = override.LimitParam
+	}
+	if override.CursorParam != "" {
+		base.CursorParam = override.CursorParam
+	}
+	if base.Limit > base.MaxLimit {
+		base.Limit = base.MaxLimit
+	}
+	return base
+}
+
+// EncodeCursor encodes a cursor key into an opaque URL-safe string
+func EncodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// DecodeCursor decodes a cursor produced by EncodeCursor
+func DecodeCursor(cursor string) (string, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// JSONPage sends an offset-paginated list using the Paginated envelope.
+// It sets the X-Total-Count header and RFC 8288 Link headers (first, prev, next, last).
+func JSONPage[T any](c *Ctx, items []T, total int, page Page) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+
+	c.Set("X-Total-Count", strconv.Itoa(total))
+
+	d := page.defaults
+	if d.PageParam == "" {
+		d = DefaultPageDefaults()
+	}
+
+	lastPage := 1
+	if page.Limit > 0 && total > 0 {
+		lastPage = (total + page.Limit - 1) / page.Limit
+	}
+
+	rels := make(map[string]url.Values, 4)
+	pageLink := func(number int, rel string) {
+		rels[rel] = url.Values{
+			d.PageParam:  {strconv.Itoa(number)},
+			d.LimitParam: {strconv.Itoa(page.Limit)},
+		}
+	}
+	pageLink(1, "first")
+	if page.Page > 1 {
+		pageLink(min(page.Page-1, lastPage), "prev")
+	}
+	if page.Page < lastPage {
+		pageLink(page.Page+1, "next")
+	}
+	pageLink(lastPage, "last")
+	httputil.AddLink(c.header(), httputil.LinkHeader(c.linkBase(), rels))
+
+	return c.JSON(Paginated[T]{
+		Items: items,
+		Page:  page.Page,
+		Limit: page.Limit,
+		Total: &total,
+	})
+}
+
+// JSONCursorPage sends a cursor-paginated list using the Paginated envelope.
+// The next cursor is built from the key of the last item when the page is full,
+// and a rel=next Link header pointing to it is set.
+//
+// Example:
+//
+//	page, _ := c.Pagination()
+//	users := repo.ListAfter(page.Cursor, page.Limit)
+//	return glib.JSONCursorPage(c, users, page, func(u User) string { return u.ID })
+func JSONCursorPage[T any](c *Ctx, items []T, page Page, key func(T) string) error {
+	if c.Response == nil {
+		return ErrDetached
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+
+	d := page.defaults
+	if d.CursorParam == "" {
+		d = DefaultPageDefaults()
+	}
+
+	var next string
+	if len(items) > 0 && len(items) >= page.Limit {
+		next = EncodeCursor(key(items[len(items)-1]))
+		httputil.AddLink(c.header(), httputil.LinkHeader(c.linkBase(), map[string]url.Values{
+			"next": {
+				d.CursorParam: {next},
+				d.LimitParam:  {strconv.Itoa(page.Limit)},
+			},
+		}))
+	}
+
+	return c.JSON(Paginated[T]{
+		Items:      items,
+		Limit:      page.Limit,
+		NextCursor: next,
+	})
+}
+
+// linkBase returns the absolute URL of the request, the base of its pagination links
+func (c *Ctx) linkBase() *url.URL {
+	return &url.URL{
+		Scheme:   c.Scheme(),
+		Host:     c.Host(),
+		Path:     c.Path(),
+		RawQuery: c.Request.URL.RawQuery,
+	}
+}
+
+// AddLink adds links to the Link header of the response (RFC 8288), merged
+// with the links already set, e.g. by JSONPage
+//
+// Example:
+//
+//	c.AddLink(httputil.Link{URL: "/docs/orders", Rel: "describedby"})
+func (c *Ctx) AddLink(links ...httputil.Link) *Ctx {
+	if len(links) > 0 {
+		httputil.AddLink(c.header(), httputil.FormatLinks(links...))
+	}
+	return c
+}
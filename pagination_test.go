@@ -0,0 +1,178 @@
+package glib
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/azizndao/glib/httputil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_Pagination(t *testing.T) {
+	cases := []struct {
+		desc     string
+		query    string
+		defaults []PageDefaults
+		expected Page
+		status   int
+	}{
+		{desc: "defaults", query: "", expected: Page{Page: 1, Limit: 20, Offset: 0}},
+		{desc: "page and limit", query: "?page=3&limit=10", expected: Page{Page: 3, Limit: 10, Offset: 20}},
+		{desc: "custom defaults", query: "?p=2", defaults: []PageDefaults{{Limit: 5, PageParam: "p"}}, expected: Page{Page: 2, Limit: 5, Offset: 5}},
+		{desc: "cursor", query: "?cursor=" + EncodeCursor("user-42"), expected: Page{Page: 1, Limit: 20, Cursor: "user-42"}},
+		{desc: "limit not a number", query: "?limit=abc", status: http.StatusBadRequest},
+		{desc: "limit too large", query: "?limit=1000", status: http.StatusBadRequest},
+		{desc: "limit zero", query: "?limit=0", status: http.StatusBadRequest},
+		{desc: "negative page", query: "?page=-1", status: http.StatusBadRequest},
+		{desc: "largest page", query: "?page=" + strconv.Itoa(math.MaxInt/20+1), expected: Page{Page: math.MaxInt/20 + 1, Limit: 20, Offset: math.MaxInt / 20 * 20}},
+		{desc: "offset overflow", query: "?page=" + strconv.Itoa(math.MaxInt/20+2), status: http.StatusBadRequest},
+		{desc: "malformed cursor", query: "?cursor=!!!", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Get("/items", func(c *Ctx) error {
+				page, err := c.Pagination(tc.defaults...)
+				if err != nil {
+					return err
+				}
+				assert.Equal(t, tc.expected.Page, page.Page)
+				assert.Equal(t, tc.expected.Limit, page.Limit)
+				assert.Equal(t, tc.expected.Offset, page.Offset)
+				assert.Equal(t, tc.expected.Cursor, page.Cursor)
+				return c.NoContent()
+			})
+
+			req := httptest.NewRequest("GET", "/items"+tc.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if tc.status != 0 {
+				assert.Equal(t, tc.status, w.Code)
+			} else {
+				assert.Equal(t, http.StatusNoContent, w.Code)
+			}
+		})
+	}
+}
+
+func TestJSONPage(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/items", func(c *Ctx) error {
+		page, err := c.Pagination()
+		if err != nil {
+			return err
+		}
+		items := []int{}
+		for i := page.Offset; i < min(page.Offset+page.Limit, 25); i++ {
+			items = append(items, i)
+		}
+		return JSONPage(c, items, 25, page)
+	})
+
+	req := httptest.NewRequest("GET", "http://example.com/items?page=2&limit=10&sort=name", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "25", w.Header().Get("X-Total-Count"))
+	assert.Equal(t,
+		`<http://example.com/items?limit=10&page=1&sort=name>; rel="first", `+
+			`<http://example.com/items?limit=10&page=1&sort=name>; rel="prev", `+
+			`<http://example.com/items?limit=10&page=3&sort=name>; rel="next", `+
+			`<http://example.com/items?limit=10&page=3&sort=name>; rel="last"`,
+		w.Header().Get("Link"),
+	)
+
+	var resp Paginated[int]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	total := 25
+	assert.Equal(t, Paginated[int]{Items: []int{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, Page: 2, Limit: 10, Total: &total}, resp)
+}
+
+func TestJSONPage_Empty(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/items", func(c *Ctx) error {
+		page, err := c.Pagination()
+		if err != nil {
+			return err
+		}
+		return JSONPage[int](c, nil, 0, page)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/items", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"items":[],"page":1,"limit":20,"total":0}`, w.Body.String())
+}
+
+func TestJSONCursorPage(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	r := setupTestRouter()
+	r.Get("/items", func(c *Ctx) error {
+		page, err := c.Pagination(PageDefaults{Limit: 2})
+		if err != nil {
+			return err
+		}
+		start := 0
+		if page.Cursor != "" {
+			start, _ = strconv.Atoi(page.Cursor)
+		}
+		items := []item{}
+		for i := start + 1; i <= min(start+page.Limit, 3); i++ {
+			items = append(items, item{ID: i})
+		}
+		return JSONCursorPage(c, items, page, func(i item) string { return strconv.Itoa(i.ID) })
+	})
+
+	req := httptest.NewRequest("GET", "http://example.com/items", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var first Paginated[item]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.Equal(t, []item{{ID: 1}, {ID: 2}}, first.Items)
+	require.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, `<http://example.com/items?cursor=`+first.NextCursor+`&limit=2>; rel="next"`, w.Header().Get("Link"))
+
+	req = httptest.NewRequest("GET", "http://example.com/items?cursor="+first.NextCursor, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var second Paginated[item]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+	assert.Equal(t, []item{{ID: 3}}, second.Items)
+	assert.Empty(t, second.NextCursor)
+	assert.Empty(t, w.Header().Get("Link"))
+}
+
+func TestJSONPage_MergesLinks(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/items", func(c *Ctx) error {
+		c.Set("Link", `</docs/items>; rel="help"`)
+		page, err := c.Pagination()
+		if err != nil {
+			return err
+		}
+		return JSONPage(c, []int{1}, 1, page)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/items", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	links := httputil.ParseLink(w.Header().Values("Link")...)
+	require.Len(t, links, 3)
+	assert.Equal(t, httputil.Link{URL: "/docs/items", Rel: "help"}, links[0])
+	assert.Equal(t, httputil.Link{URL: "http://example.com/items?limit=20&page=1", Rel: "first"}, links[1])
+	assert.Equal(t, "last", links[2].Rel)
+}
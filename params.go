@@ -0,0 +1,96 @@
+package glib
+
+import "strings"
+
+// Vetted regular expressions of the common path parameters, see UUIDParam,
+// IntParam and SlugParam
+const (
+	// UUIDPattern matches a UUID in its canonical 8-4-4-4-12 hexadecimal form, in
+	// any case
+	UUIDPattern = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+	// IntPattern matches a non-negative integer
+	IntPattern = `[0-9]+`
+	// SlugPattern matches lowercase words of letters and digits separated by
+	// single hyphens, e.g. "hello-world-2"
+	SlugPattern = `[a-z0-9]+(?:-[a-z0-9]+)*`
+)
+
+// DefaultParamPatterns returns the path parameter shorthands available in all
+// routers: {id:uuid}, {id:int} and {name:slug}. RouterConfig.ParamPatterns adds
+// to them.
+func DefaultParamPatterns() map[string]string {
+	return map[string]string{
+		"uuid": UUIDPattern,
+		"int":  IntPattern,
+		"slug": SlugPattern,
+	}
+}
+
+// UUIDParam returns the pattern of a path parameter matching a UUID. Requests
+// whose parameter doesn't match get a 404 Not Found.
+//
+// Example:
+//
+//	r.Get("/users/"+glib.UUIDParam("id"), getUser) // /users/{id:[0-9a-fA-F]{8}-...}
+func UUIDParam(name string) string {
+	return param(name, UUIDPattern)
+}
+
+// IntParam returns the pattern of a path parameter matching a non-negative
+// integer, see UUIDParam
+func IntParam(name string) string {
+	return param(name, IntPattern)
+}
+
+// SlugParam returns the pattern of a path parameter matching a slug such as
+// "hello-world", see UUIDParam
+func SlugParam(name string) string {
+	return param(name, SlugPattern)
+}
+
+func param(name, pattern string) string {
+	return "{" + name + ":" + pattern + "}"
+}
+
+// expandParams rewrites the shorthands of the path parameters of pattern, such
+// as {id:uuid}, into their regular expression. Parameters whose constraint
+// isn't a registered shorthand are kept as is.
+func (r *router) expandParams(pattern string) string {
+	if !strings.Contains(pattern, ":") {
+		return pattern
+	}
+
+	var b strings.Builder
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			if depth == 0 {
+				b.WriteString(pattern[start:i])
+				start = i
+			}
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				name, constraint, ok := strings.Cut(pattern[start+1:i], ":")
+				if expanded, found := r.paramPattern(constraint); ok && found {
+					b.WriteString(param(name, expanded))
+				} else {
+					b.WriteString(pattern[start : i+1])
+				}
+				start = i + 1
+			}
+		}
+	}
+	b.WriteString(pattern[start:])
+	return b.String()
+}
+
+// paramPattern returns the regular expression of a path parameter shorthand
+func (r *router) paramPattern(name string) (string, bool) {
+	if pattern, ok := r.config.ParamPatterns[name]; ok {
+		return pattern, true
+	}
+	pattern, ok := DefaultParamPatterns()[name]
+	return pattern, ok
+}
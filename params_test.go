@@ -0,0 +1,97 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamHelpers(t *testing.T) {
+	r := setupTestRouter()
+	echo := func(c *Ctx) error { return c.SendString(c.PathValue("v")) }
+	r.Get("/uuid/"+UUIDParam("v"), echo)
+	r.Get("/int/"+IntParam("v"), echo)
+	r.Get("/slug/"+SlugParam("v"), echo)
+
+	tests := []struct {
+		desc       string
+		path       string
+		expectCode int
+	}{
+		{desc: "uuid", path: "/uuid/0b8e4f2a-6c1d-4e3b-9a7f-1d2c3b4a5e6f", expectCode: http.StatusOK},
+		{desc: "uppercase uuid", path: "/uuid/0B8E4F2A-6C1D-4E3B-9A7F-1D2C3B4A5E6F", expectCode: http.StatusOK},
+		{desc: "uuid without hyphens", path: "/uuid/0b8e4f2a6c1d4e3b9a7f1d2c3b4a5e6f", expectCode: http.StatusNotFound},
+		{desc: "uuid too long", path: "/uuid/0b8e4f2a-6c1d-4e3b-9a7f-1d2c3b4a5e6f0", expectCode: http.StatusNotFound},
+		{desc: "uuid with non-hex digit", path: "/uuid/0b8e4f2a-6c1d-4e3b-9a7f-1d2c3b4a5e6g", expectCode: http.StatusNotFound},
+		{desc: "int", path: "/int/42", expectCode: http.StatusOK},
+		{desc: "negative int", path: "/int/-42", expectCode: http.StatusNotFound},
+		{desc: "not an int", path: "/int/42a", expectCode: http.StatusNotFound},
+		{desc: "slug", path: "/slug/hello-world-2", expectCode: http.StatusOK},
+		{desc: "slug with uppercase", path: "/slug/Hello-World", expectCode: http.StatusNotFound},
+		{desc: "slug with double hyphen", path: "/slug/hello--world", expectCode: http.StatusNotFound},
+		{desc: "slug with trailing hyphen", path: "/slug/hello-", expectCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.expectCode, rec.Code)
+		})
+	}
+}
+
+func TestRouterConfig_ParamPatterns(t *testing.T) {
+	config := DefaultRouterOptions()
+	config.ParamPatterns = map[string]string{
+		"isbn": `[0-9]{13}`,
+		"int":  `[1-9][0-9]*`, // overrides the default
+	}
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	echo := func(c *Ctx) error { return c.SendString(c.PathValue("id")) }
+	r.Get("/users/{id:uuid}", echo)
+	r.Get("/books/{id:isbn}", echo)
+	r.Get("/tags/{id:[a-z]+}", echo)
+	r.Route("/orders/{id:int}", func(r Router) {
+		r.Get("/items/{item:slug}", func(c *Ctx) error { return c.SendString(c.PathValue("id") + "/" + c.PathValue("item")) })
+	})
+
+	tests := []struct {
+		desc       string
+		path       string
+		expectCode int
+		expectBody string
+	}{
+		{desc: "default shorthand", path: "/users/0b8e4f2a-6c1d-4e3b-9a7f-1d2c3b4a5e6f", expectCode: http.StatusOK, expectBody: "0b8e4f2a-6c1d-4e3b-9a7f-1d2c3b4a5e6f"},
+		{desc: "default shorthand mismatch", path: "/users/42", expectCode: http.StatusNotFound},
+		{desc: "custom shorthand", path: "/books/9780262033848", expectCode: http.StatusOK, expectBody: "9780262033848"},
+		{desc: "custom shorthand mismatch", path: "/books/978026203384", expectCode: http.StatusNotFound},
+		{desc: "regular expressions kept", path: "/tags/go", expectCode: http.StatusOK, expectBody: "go"},
+		{desc: "sub-router with overridden shorthand", path: "/orders/7/items/red-shoes", expectCode: http.StatusOK, expectBody: "7/red-shoes"},
+		{desc: "overridden shorthand mismatch", path: "/orders/0/items/red-shoes", expectCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.expectCode, rec.Code)
+			if tt.expectBody != "" {
+				assert.Equal(t, tt.expectBody, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("registered with the expanded pattern", func(t *testing.T) {
+		var patterns []string
+		for _, route := range r.RouteList() {
+			patterns = append(patterns, route.Pattern)
+		}
+		assert.Contains(t, patterns, "/books/{id:[0-9]{13}}")
+		assert.Contains(t, patterns, "/orders/{id:[1-9][0-9]*}/items/{item:"+SlugPattern+"}")
+	})
+}
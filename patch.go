@@ -0,0 +1,618 @@
+package glib
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/azizndao/glib/errors"
+)
+
+// Patch media types
+const (
+	// MIMEMergePatchJSON is the media type of JSON Merge Patch documents (RFC 7386)
+	MIMEMergePatchJSON = "application/merge-patch+json"
+	// MIMEJSONPatch is the media type of JSON Patch documents (RFC 6902)
+	MIMEJSONPatch = "application/json-patch+json"
+)
+
+// PatchError describes the operation of a JSON Patch that is invalid or can't be
+// applied. It is the data of the errors returned by Ctx.ApplyJSONPatch:
+//
+//	{"message": "Patch failed", "index": 1, "op": "test", "path": "/version", "reason": "value differs"}
+type PatchError struct {
+	Message string `json:"message"`
+	// Index is the position of the operation in the patch, starting at 0
+	Index  int    `json:"index"`
+	Op     string `json:"op,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Errors of the JSON Patch operations, reported as the reason of a PatchError
+var (
+	errPatchPathNotFound = stderrors.New("path not found")
+	errPatchTestFailed   = stderrors.New("value differs")
+	errPatchRemoveRoot   = stderrors.New("the root can't be removed")
+)
+
+// ApplyMergePatch applies the JSON Merge Patch (RFC 7386) of the request body
+// onto target, a pointer to the current state of the resource: the members of
+// the patch replace the ones of target, recursively for objects, null members
+// delete them (zeroing the field) and absent members are kept. Arrays are
+// replaced as a whole. The fields that don't appear in JSON, unexported or
+// tagged json:"-", are kept.
+//
+// The request Content-Type must be application/merge-patch+json, otherwise a
+// 415 Unsupported Media Type error is returned with the Accept-Patch header.
+// Malformed patches are reported with a 400 Bad Request error. The patched
+// target isn't validated, see Ctx.Validate.
+//
+// Example:
+//
+//	r.Patch("/users/{id}", func(c *glib.Ctx) error {
+//	    user, err := service.Get(c, c.PathValue("id"))
+//	    if err != nil {
+//	        return err
+//	    }
+//	    if err := c.ApplyMergePatch(user); err != nil {
+//	        return err
+//	    }
+//	    if err := c.Validate(user); err != nil {
+//	        return err
+//	    }
+//	    return c.OK(service.Save(c, user))
+//	})
+func (c *Ctx) ApplyMergePatch(target any) error {
+	body, err := c.patchBody(MIMEMergePatchJSON)
+	if err != nil {
+		return err
+	}
+
+	patch, err := decodeGeneric(body)
+	if err != nil {
+		if jsonErr := newJSONError(body, err); jsonErr != nil {
+			return errors.BadRequest(jsonErr, err)
+		}
+		return errors.BadRequest("Invalid JSON", err)
+	}
+
+	doc, err := c.patchTarget(target)
+	if err != nil {
+		return err
+	}
+	return c.patchResult(mergePatch(doc, patch), target)
+}
+
+// ApplyJSONPatch applies the JSON Patch (RFC 6902) of the request body onto
+// target, a pointer to the current state of the resource. All the operations
+// are validated before any is applied, and the patch is applied atomically:
+// target is only modified when all the operations succeed.
+//
+// The request Content-Type must be application/json-patch+json, otherwise a
+// 415 Unsupported Media Type error is returned with the Accept-Patch header.
+// Invalid operations are reported with a 400 Bad Request error, and operations
+// that can't be applied (missing path, failed test) with a 409 Conflict error,
+// their data being a PatchError. The patched target isn't validated, see
+// Ctx.Validate.
+func (c *Ctx) ApplyJSONPatch(target any) error {
+	body, err := c.patchBody(MIMEJSONPatch)
+	if err != nil {
+		return err
+	}
+
+	var ops []patchOperation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		if jsonErr := newJSONError(body, err); jsonErr != nil {
+			return errors.BadRequest(jsonErr, err)
+		}
+		return errors.BadRequest("Invalid JSON", err)
+	}
+	for i := range ops {
+		if err := ops[i].validate(); err != nil {
+			return errors.BadRequest(ops[i].error("Invalid patch operation", i, err), err)
+		}
+	}
+
+	doc, err := c.patchTarget(target)
+	if err != nil {
+		return err
+	}
+	for i, op := range ops {
+		if doc, err = op.apply(doc); err != nil {
+			return errors.Conflict(op.error("Patch failed", i, err), err)
+		}
+	}
+	return c.patchResult(doc, target)
+}
+
+// patchBody checks the Content-Type of a patch request and returns its body
+func (c *Ctx) patchBody(mediaType string) ([]byte, error) {
+	if got, _, err := mime.ParseMediaType(c.ContentType()); err != nil || got != mediaType {
+		c.Set("Accept-Patch", mediaType)
+		return nil, errors.UnsupportedMediaType("Unsupported Content-Type", fmt.Errorf("expected %s, got %q", mediaType, c.ContentType()))
+	}
+
+	body, err := c.Body()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, errors.BadRequest("Empty request body", nil)
+	}
+	return body, nil
+}
+
+// patchTarget returns the JSON document of the patched target
+func (c *Ctx) patchTarget(target any) (any, error) {
+	if v := reflect.ValueOf(target); v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, fmt.Errorf("glib: patch target must be a non-nil pointer, got %T", target)
+	}
+	data, err := marshalJSON(c.config.JSON, target)
+	if err != nil {
+		return nil, fmt.Errorf("glib: failed to encode patch target: %w", err)
+	}
+	return decodeGeneric(data)
+}
+
+// patchResult decodes the patched document into target. The document is decoded
+// into a zero value so that the deleted members are zeroed, and target is left
+// unchanged when the document doesn't fit its type. The struct fields that
+// don't appear in JSON are kept, see keepHidden.
+func (c *Ctx) patchResult(doc any, target any) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("glib: failed to encode patched document: %w", err)
+	}
+
+	v := reflect.ValueOf(target).Elem()
+	decoded := reflect.New(v.Type())
+	if err := unmarshalJSON(c.config.JSON, data, decoded.Interface()); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if stderrors.As(err, &typeErr) {
+			return errors.BadRequest(map[string]any{
+				"message":  "Invalid patch",
+				"field":    typeErr.Field,
+				"expected": jsonTypeOf(typeErr.Type),
+				"got":      typeErr.Value,
+			}, err)
+		}
+		return errors.BadRequest("Invalid patch", err)
+	}
+
+	patched := reflect.New(v.Type()).Elem()
+	patched.Set(v)
+	keepHidden(patched, decoded.Elem(), doc)
+	v.Set(patched)
+	return nil
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// keepHidden sets dst, a copy of the current value, to the decoded value of the
+// patched document while keeping the struct fields that don't appear in JSON:
+// unexported fields and fields tagged json:"-". Structs are walked along the
+// members of doc, the members deleted by the patch are zeroed with their hidden
+// fields. Pointed structs are copied so that the current value isn't modified.
+func keepHidden(dst, decoded reflect.Value, doc any) {
+	t := dst.Type()
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		if dst.CanSet() {
+			dst.Set(decoded)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() || decoded.IsNil() || t.Elem().Kind() != reflect.Struct {
+			dst.Set(decoded)
+			return
+		}
+		patched := reflect.New(t.Elem())
+		patched.Elem().Set(dst.Elem())
+		keepHidden(patched.Elem(), decoded.Elem(), doc)
+		dst.Set(patched)
+	case reflect.Struct:
+		members, _ := doc.(map[string]any)
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if field.Anonymous && name == "" {
+				// members of embedded structs are promoted into the same object,
+				// the exported fields of unexported ones can still be set
+				if field.Type.Kind() == reflect.Struct || dst.Field(i).CanSet() {
+					keepHidden(dst.Field(i), decoded.Field(i), doc)
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			member, ok := jsonMember(members, name)
+			if !ok {
+				dst.Field(i).Set(decoded.Field(i))
+				continue
+			}
+			keepHidden(dst.Field(i), decoded.Field(i), member)
+		}
+	default:
+		dst.Set(decoded)
+	}
+}
+
+// jsonMember returns the member of an object matching a field name, preferring
+// an exact match like encoding/json
+func jsonMember(members map[string]any, name string) (any, bool) {
+	if member, ok := members[name]; ok {
+		return member, true
+	}
+	for key, member := range members {
+		if strings.EqualFold(key, name) {
+			return member, true
+		}
+	}
+	return nil, false
+}
+
+// decodeGeneric decodes a JSON document, keeping the numbers as json.Number so
+// that large integers aren't rounded
+func decodeGeneric(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("invalid data after top-level value: %w", &json.SyntaxError{Offset: dec.InputOffset()})
+	}
+	return doc, nil
+}
+
+// mergePatch applies a JSON Merge Patch onto doc (RFC 7386, section 2)
+func mergePatch(doc, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := doc.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = mergePatch(object[name], value)
+		}
+	}
+	return object
+}
+
+// patchOperation is an operation of a JSON Patch. Path and From are pointers,
+// and Value is nil, when absent, to tell them from empty ones.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+
+	path, from []string
+	value      any
+}
+
+// validate checks the members of the operation and parses them
+func (op *patchOperation) validate() error {
+	switch op.Op {
+	case "add", "remove", "replace", "move", "copy", "test":
+	case "":
+		return stderrors.New(`missing "op" member`)
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	if op.Path == nil {
+		return stderrors.New(`missing "path" member`)
+	}
+	var err error
+	if op.path, err = parsePointer(*op.Path); err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return stderrors.New(`missing "value" member`)
+		}
+		if op.value, err = decodeGeneric(op.Value); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return stderrors.New(`missing "from" member`)
+		}
+		if op.from, err = parsePointer(*op.From); err != nil {
+			return fmt.Errorf("invalid from: %w", err)
+		}
+		if op.Op == "move" && strings.HasPrefix(*op.Path, *op.From+"/") {
+			return stderrors.New("a value can't be moved into one of its children")
+		}
+	}
+	return nil
+}
+
+// apply applies the operation onto doc and returns the patched document
+func (op *patchOperation) apply(doc any) (any, error) {
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, op.path, op.value)
+	case "remove":
+		doc, _, err := pointerRemove(doc, op.path)
+		return doc, err
+	case "replace":
+		if _, err := pointerGet(doc, op.path); err != nil {
+			return nil, err
+		}
+		if len(op.path) == 0 {
+			return op.value, nil
+		}
+		doc, _, err := pointerRemove(doc, op.path)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, op.path, op.value)
+	case "move":
+		doc, value, err := pointerRemove(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, op.path, value)
+	case "copy":
+		value, err := pointerGet(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, op.path, deepCopy(value))
+	default: // test
+		value, err := pointerGet(doc, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(value, op.value) {
+			return nil, errPatchTestFailed
+		}
+		return doc, nil
+	}
+}
+
+// error returns the PatchError of the operation at index
+func (op *patchOperation) error(message string, index int, err error) *PatchError {
+	patchErr := &PatchError{Message: message, Index: index, Op: op.Op, Reason: err.Error()}
+	if op.Path != nil {
+		patchErr.Path = *op.Path
+	}
+	return patchErr
+}
+
+// parsePointer parses a JSON Pointer (RFC 6901) into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%q doesn't start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("%q has an invalid escape sequence", pointer)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses the reference token of an array element. "-" refers to the
+// element after the last one, only valid when end is true.
+func arrayIndex(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, errPatchPathNotFound
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > length || (i == length && !end) {
+		return 0, errPatchPathNotFound
+	}
+	return i, nil
+}
+
+// pointerGet returns the value of doc at path
+func pointerGet(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, errPatchPathNotFound
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, errPatchPathNotFound
+		}
+	}
+	return doc, nil
+}
+
+// pointerUpdate calls fn with the container of the last token of path and
+// returns doc with the container returned by fn
+func pointerUpdate(doc any, path []string, fn func(container any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil, errPatchPathNotFound
+		}
+		updated, err := pointerUpdate(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[path[0]] = updated
+		return node, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := pointerUpdate(node[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = updated
+		return node, nil
+	default:
+		return nil, errPatchPathNotFound
+	}
+}
+
+// pointerAdd adds value to doc at path, inserting it in arrays
+func pointerAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return pointerUpdate(doc, path, func(container any, token string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			i, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		default:
+			return nil, errPatchPathNotFound
+		}
+	})
+}
+
+// pointerRemove removes the value of doc at path and returns it
+func pointerRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errPatchRemoveRoot
+	}
+	var removed any
+	doc, err := pointerUpdate(doc, path, func(container any, token string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, errPatchPathNotFound
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []any:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return append(node[:i], node[i+1:]...), nil
+		default:
+			return nil, errPatchPathNotFound
+		}
+	})
+	return doc, removed, err
+}
+
+// deepCopy copies a JSON document, so that a copied value isn't shared
+func deepCopy(doc any) any {
+	switch node := doc.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(node))
+		for name, value := range node {
+			copied[name] = deepCopy(value)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(node))
+		for i, value := range node {
+			copied[i] = deepCopy(value)
+		}
+		return copied
+	default:
+		return doc
+	}
+}
+
+// jsonEqual reports whether two JSON documents are equal, comparing numbers by
+// value (1 and 1.0 are equal)
+func jsonEqual(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == b {
+			return true
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,303 @@
+package glib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patchAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+	note string
+}
+
+type patchUser struct {
+	Name    string            `json:"name" validate:"required"`
+	Age     int               `json:"age"`
+	Tags    []string          `json:"tags"`
+	Address *patchAddress     `json:"address"`
+	Meta    map[string]string `json:"meta,omitempty"`
+	Secret  string            `json:"-"`
+	version int
+}
+
+func newPatchUser() patchUser {
+	return patchUser{
+		Name:    "Ada",
+		Age:     36,
+		Tags:    []string{"admin", "dev"},
+		Address: &patchAddress{City: "London", Zip: "N1", note: "home"},
+		Meta:    map[string]string{"team": "core"},
+		Secret:  "hash",
+		version: 3,
+	}
+}
+
+// servePatch applies the patch of the request body with apply, returning the
+// response and the patched user
+func servePatch(t *testing.T, contentType, body string, apply func(c *Ctx, user *patchUser) error) (*httptest.ResponseRecorder, patchUser) {
+	t.Helper()
+	user := newPatchUser()
+	r := setupTestRouter()
+	r.Patch("/users/1", func(c *Ctx) error {
+		if err := apply(c, &user); err != nil {
+			return err
+		}
+		if err := c.Validate(&user); err != nil {
+			return err
+		}
+		return c.OK(user)
+	})
+
+	req := httptest.NewRequest("PATCH", "/users/1", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec, user
+}
+
+func TestCtx_ApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		desc        string
+		contentType string
+		body        string
+		expectCode  int
+		expectUser  func(user *patchUser)
+		expectBody  string
+	}{
+		{
+			desc:       "absent members kept",
+			body:       `{"age":37}`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Age = 37 },
+		},
+		{
+			desc:       "nested objects merged",
+			body:       `{"address":{"zip":"EC1"}}`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Address.Zip = "EC1" },
+		},
+		{
+			desc:       "arrays replaced",
+			body:       `{"tags":["ops"]}`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Tags = []string{"ops"} },
+		},
+		{
+			desc:       "explicit nulls delete members",
+			body:       `{"address":{"zip":null},"meta":null,"tags":null}`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) {
+				user.Address.Zip = ""
+				user.Meta = nil
+				user.Tags = nil
+			},
+		},
+		{
+			desc:       "null object deleted",
+			body:       `{"address":null}`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Address = nil },
+		},
+		{
+			desc:       "fields hidden from JSON kept",
+			body:       `{"secret":"plain","version":9,"address":{"city":"Paris"}}`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Address.City = "Paris" },
+		},
+		{
+			desc:        "content type parameters accepted",
+			contentType: MIMEMergePatchJSON + "; charset=utf-8",
+			body:        `{"name":"Grace"}`,
+			expectCode:  http.StatusOK,
+			expectUser:  func(user *patchUser) { user.Name = "Grace" },
+		},
+		{
+			desc:        "other content type rejected",
+			contentType: MIMEApplicationJSON,
+			body:        `{"age":37}`,
+			expectCode:  http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:       "malformed patch",
+			body:       `{"age":`,
+			expectCode: http.StatusBadRequest,
+			expectBody: `"message":"Invalid JSON"`,
+		},
+		{
+			desc:       "empty patch",
+			body:       ``,
+			expectCode: http.StatusBadRequest,
+			expectBody: `"Empty request body"`,
+		},
+		{
+			desc:       "patched value of the wrong type",
+			body:       `{"age":"old"}`,
+			expectCode: http.StatusBadRequest,
+			expectBody: `{"expected":"number","field":"age","got":"string","message":"Invalid patch"}`,
+		},
+		{
+			desc:       "patched result validated",
+			body:       `{"name":null}`,
+			expectCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = MIMEMergePatchJSON
+			}
+			rec, user := servePatch(t, contentType, tt.body, func(c *Ctx, user *patchUser) error {
+				return c.ApplyMergePatch(user)
+			})
+
+			assert.Equal(t, tt.expectCode, rec.Code, rec.Body.String())
+			if tt.expectBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectBody)
+			}
+			if tt.expectUser != nil {
+				expected := newPatchUser()
+				tt.expectUser(&expected)
+				assert.Equal(t, expected, user)
+			}
+			if tt.expectCode == http.StatusUnsupportedMediaType {
+				assert.Equal(t, MIMEMergePatchJSON, rec.Header().Get("Accept-Patch"))
+				assert.Equal(t, newPatchUser(), user)
+			}
+		})
+	}
+}
+
+func TestCtx_ApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		desc       string
+		body       string
+		expectCode int
+		expectUser func(user *patchUser)
+		expectErr  *PatchError
+	}{
+		{
+			desc:       "replace and add",
+			body:       `[{"op":"replace","path":"/age","value":37},{"op":"add","path":"/address/zip","value":"EC1"}]`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Age, user.Address.Zip = 37, "EC1" },
+		},
+		{
+			desc:       "array insert, append and remove",
+			body:       `[{"op":"add","path":"/tags/0","value":"ops"},{"op":"add","path":"/tags/-","value":"qa"},{"op":"remove","path":"/tags/1"}]`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Tags = []string{"ops", "dev", "qa"} },
+		},
+		{
+			desc:       "whole array replaced",
+			body:       `[{"op":"replace","path":"/tags","value":["ops"]}]`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Tags = []string{"ops"} },
+		},
+		{
+			desc:       "explicit null value",
+			body:       `[{"op":"replace","path":"/address","value":null}]`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Address = nil },
+		},
+		{
+			desc:       "fields hidden from JSON kept",
+			body:       `[{"op":"add","path":"/version","value":9},{"op":"replace","path":"/address/city","value":"Paris"}]`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Address.City = "Paris" },
+		},
+		{
+			desc:       "move, copy and escaped keys",
+			body:       `[{"op":"copy","from":"/address/city","path":"/meta/home~1city"},{"op":"move","from":"/meta/team","path":"/meta/old~0team"}]`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Meta = map[string]string{"home/city": "London", "old~team": "core"} },
+		},
+		{
+			desc:       "successful test",
+			body:       `[{"op":"test","path":"/age","value":36.0},{"op":"test","path":"/tags","value":["admin","dev"]},{"op":"remove","path":"/meta"}]`,
+			expectCode: http.StatusOK,
+			expectUser: func(user *patchUser) { user.Meta = nil },
+		},
+		{
+			desc:       "failed test applies nothing",
+			body:       `[{"op":"replace","path":"/age","value":40},{"op":"test","path":"/name","value":"Grace"}]`,
+			expectCode: http.StatusConflict,
+			expectErr:  &PatchError{Message: "Patch failed", Index: 1, Op: "test", Path: "/name", Reason: "value differs"},
+		},
+		{
+			desc:       "missing path",
+			body:       `[{"op":"remove","path":"/tags/5"}]`,
+			expectCode: http.StatusConflict,
+			expectErr:  &PatchError{Message: "Patch failed", Op: "remove", Path: "/tags/5", Reason: "path not found"},
+		},
+		{
+			desc:       "unknown operation",
+			body:       `[{"op":"replace","path":"/age","value":1},{"op":"merge","path":"/age"}]`,
+			expectCode: http.StatusBadRequest,
+			expectErr:  &PatchError{Message: "Invalid patch operation", Index: 1, Op: "merge", Path: "/age", Reason: `unknown operation "merge"`},
+		},
+		{
+			desc:       "missing value",
+			body:       `[{"op":"add","path":"/age"}]`,
+			expectCode: http.StatusBadRequest,
+			expectErr:  &PatchError{Message: "Invalid patch operation", Op: "add", Path: "/age", Reason: `missing "value" member`},
+		},
+		{
+			desc:       "invalid pointer",
+			body:       `[{"op":"remove","path":"age"}]`,
+			expectCode: http.StatusBadRequest,
+			expectErr:  &PatchError{Message: "Invalid patch operation", Op: "remove", Path: "age", Reason: `invalid path: "age" doesn't start with /`},
+		},
+		{
+			desc:       "move into a child",
+			body:       `[{"op":"move","from":"/address","path":"/address/home"}]`,
+			expectCode: http.StatusBadRequest,
+			expectErr:  &PatchError{Message: "Invalid patch operation", Op: "move", Path: "/address/home", Reason: "a value can't be moved into one of its children"},
+		},
+		{
+			desc:       "not an array",
+			body:       `{"op":"remove","path":"/age"}`,
+			expectCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			rec, user := servePatch(t, MIMEJSONPatch, tt.body, func(c *Ctx, user *patchUser) error {
+				return c.ApplyJSONPatch(user)
+			})
+
+			assert.Equal(t, tt.expectCode, rec.Code, rec.Body.String())
+			expected := newPatchUser()
+			if tt.expectUser != nil {
+				tt.expectUser(&expected)
+			}
+			assert.Equal(t, expected, user)
+			if tt.expectErr != nil {
+				var body struct {
+					Data PatchError `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, *tt.expectErr, body.Data)
+			}
+		})
+	}
+
+	t.Run("content type", func(t *testing.T) {
+		rec, _ := servePatch(t, MIMEMergePatchJSON, `[]`, func(c *Ctx, user *patchUser) error {
+			return c.ApplyJSONPatch(user)
+		})
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		assert.Equal(t, MIMEJSONPatch, rec.Header().Get("Accept-Patch"))
+	})
+}
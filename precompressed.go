@@ -0,0 +1,154 @@
+package glib
+
+import (
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// precompressedEncodings are the content codings of precompressed sidecar files
+// with their file extension, in order of preference
+var precompressedEncodings = []struct {
+	coding, ext string
+}{
+	{coding: "br", ext: ".br"},
+	{coding: "gzip", ext: ".gz"},
+}
+
+// sendPrecompressed serves a precompressed sidecar of the file (file.br or
+// file.gz) when one exists and the client accepts its content coding, see
+// SendFile. Reports whether the response was served; when it wasn't, the ETag
+// of the uncompressed representation is set if the file has sidecars.
+func (c *Ctx) sendPrecompressed(file string, stat os.FileInfo) bool {
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+	if contentType == "" {
+		// The type can't be sniffed from compressed content
+		return false
+	}
+
+	var available []string
+	for _, encoding := range precompressedEncodings {
+		sidecar, err := os.Stat(file + encoding.ext)
+		// Sidecars older than the file are stale
+		if err == nil && sidecar.Mode().IsRegular() && !sidecar.ModTime().Before(stat.ModTime()) {
+			available = append(available, encoding.coding)
+		}
+	}
+	if len(available) == 0 {
+		return false
+	}
+
+	c.Vary("Accept-Encoding")
+	header := c.Response.Header()
+	coding := preferredEncoding(c.Get("Accept-Encoding"), available)
+	if coding == "" {
+		header.Set("ETag", fileETag(stat, ""))
+		return false
+	}
+
+	ext := ""
+	for _, encoding := range precompressedEncodings {
+		if encoding.coding == coding {
+			ext = encoding.ext
+		}
+	}
+	f, err := os.Open(file + ext)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	variant, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Encoding", coding)
+	// Each representation has its own validator
+	header.Set("ETag", fileETag(variant, coding))
+
+	// Byte ranges of the compressed representation are refused, the full
+	// representation is sent instead
+	req := c.Request.Clone(c.Context())
+	req.Header.Del("Range")
+	req.Header.Del("If-Range")
+
+	http.ServeContent(&precompressedWriter{ResponseWriter: c.Response, length: variant.Size()}, req, file, variant.ModTime(), f)
+	return true
+}
+
+// fileETag returns a strong ETag of a file representation computed from its
+// modification time and size
+func fileETag(stat os.FileInfo, coding string) string {
+	tag := strconv.FormatInt(stat.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(stat.Size(), 36)
+	if coding != "" {
+		tag += "-" + coding
+	}
+	return `"` + tag + `"`
+}
+
+// preferredEncoding returns the available content coding with the highest
+// quality in the Accept-Encoding header, the first one on ties, or an empty
+// string when none is acceptable
+func preferredEncoding(acceptEncoding string, available []string) string {
+	best, bestQ := "", 0.0
+	for _, coding := range available {
+		if q := encodingQuality(acceptEncoding, coding); q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// encodingQuality returns the quality of the content coding in the
+// Accept-Encoding header, matched exactly or by the "*" wildcard
+func encodingQuality(acceptEncoding, coding string) float64 {
+	q, wildcard := -1.0, 0.0
+	for part := range strings.SplitSeq(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+
+		switch name = strings.TrimSpace(name); {
+		case strings.EqualFold(name, coding):
+			q = quality
+		case name == "*":
+			wildcard = quality
+		}
+	}
+	if q >= 0 {
+		return q
+	}
+	return wildcard
+}
+
+// precompressedWriter sets the Content-Length of a precompressed response,
+// which http.ServeContent leaves unset when a Content-Encoding is set, and
+// advertises that byte ranges are not supported
+type precompressedWriter struct {
+	http.ResponseWriter
+	length int64
+}
+
+func (w *precompressedWriter) WriteHeader(code int) {
+	if code == http.StatusOK {
+		header := w.Header()
+		header.Set("Content-Length", strconv.FormatInt(w.length, 10))
+		header.Set("Accept-Ranges", "none")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController
+func (w *precompressedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
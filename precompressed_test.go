@@ -0,0 +1,170 @@
+package glib
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_SendFilePrecompressed(t *testing.T) {
+	dir := t.TempDir()
+	plain := []byte(strings.Repeat("body { color: red; }\n", 100))
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	br := []byte("fake brotli content")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "style.css"), plain, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "style.css.gz"), gz.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "style.css.br"), br, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.js"), plain, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.js.gz"), gz.Bytes(), 0o644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "app.js.gz"), old, old))
+
+	r := setupTestRouter()
+	sendFile := func(c *Ctx) error {
+		return c.File(filepath.Join(dir, c.PathValue("file")))
+	}
+	r.Get("/{file}", sendFile)
+	r.Head("/{file}", sendFile)
+
+	serve := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	cases := []struct {
+		desc     string
+		method   string
+		path     string
+		headers  map[string]string
+		status   int
+		encoding string
+		body     []byte
+	}{
+		{
+			desc:     "gzip",
+			path:     "/style.css",
+			headers:  map[string]string{"Accept-Encoding": "gzip"},
+			status:   http.StatusOK,
+			encoding: "gzip",
+			body:     gz.Bytes(),
+		},
+		{
+			desc:     "brotli preferred",
+			path:     "/style.css",
+			headers:  map[string]string{"Accept-Encoding": "gzip, deflate, br"},
+			status:   http.StatusOK,
+			encoding: "br",
+			body:     br,
+		},
+		{
+			desc:     "quality values",
+			path:     "/style.css",
+			headers:  map[string]string{"Accept-Encoding": "br;q=0.5, gzip"},
+			status:   http.StatusOK,
+			encoding: "gzip",
+			body:     gz.Bytes(),
+		},
+		{
+			desc:     "wildcard",
+			path:     "/style.css",
+			headers:  map[string]string{"Accept-Encoding": "*, br;q=0"},
+			status:   http.StatusOK,
+			encoding: "gzip",
+			body:     gz.Bytes(),
+		},
+		{
+			desc:   "identity",
+			path:   "/style.css",
+			status: http.StatusOK,
+			body:   plain,
+		},
+		{
+			desc:    "stale sidecar",
+			path:    "/app.js",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			status:  http.StatusOK,
+			body:    plain,
+		},
+		{
+			desc:     "range refused on compressed",
+			path:     "/style.css",
+			headers:  map[string]string{"Accept-Encoding": "gzip", "Range": "bytes=0-9"},
+			status:   http.StatusOK,
+			encoding: "gzip",
+			body:     gz.Bytes(),
+		},
+		{
+			desc:    "range on plain",
+			path:    "/style.css",
+			headers: map[string]string{"Range": "bytes=0-9"},
+			status:  http.StatusPartialContent,
+			body:    plain[:10],
+		},
+		{
+			desc:     "HEAD",
+			method:   http.MethodHead,
+			path:     "/style.css",
+			headers:  map[string]string{"Accept-Encoding": "gzip"},
+			status:   http.StatusOK,
+			encoding: "gzip",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			w := serve(cmp.Or(tc.method, http.MethodGet), tc.path, tc.headers)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.encoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, mime.TypeByExtension(filepath.Ext(tc.path)), w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.body, w.Body.Bytes())
+			if tc.encoding != "" {
+				size := map[string]int{"gzip": gz.Len(), "br": len(br)}[tc.encoding]
+				assert.Equal(t, strconv.Itoa(size), w.Header().Get("Content-Length"))
+				assert.Equal(t, "none", w.Header().Get("Accept-Ranges"))
+			}
+			if tc.path == "/style.css" {
+				assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			}
+		})
+	}
+
+	t.Run("validators per representation", func(t *testing.T) {
+		gzipETag := serve(http.MethodGet, "/style.css", map[string]string{"Accept-Encoding": "gzip"}).Header().Get("ETag")
+		brETag := serve(http.MethodGet, "/style.css", map[string]string{"Accept-Encoding": "br"}).Header().Get("ETag")
+		plainETag := serve(http.MethodGet, "/style.css", nil).Header().Get("ETag")
+		require.NotEmpty(t, gzipETag)
+		assert.NotEqual(t, gzipETag, brETag)
+		assert.NotEqual(t, gzipETag, plainETag)
+
+		w := serve(http.MethodGet, "/style.css", map[string]string{"Accept-Encoding": "gzip", "If-None-Match": gzipETag})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+
+		w = serve(http.MethodGet, "/style.css", map[string]string{"Accept-Encoding": "br", "If-None-Match": gzipETag})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, br, w.Body.Bytes())
+	})
+}
@@ -0,0 +1,72 @@
+package glib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/middleware"
+)
+
+// RawBody returns the request body exactly as received, before the Decompress
+// middleware, typically to verify the signature of a webhook computed over
+// the bytes sent by the provider. For a decompressed request, the route must
+// be marked with Meta(RawBodyMeta, true), the compressed bytes being kept up
+// to DecompressConfig.MaxRawBodySize. Otherwise it is the same as Body.
+//
+// Example:
+//
+//	r.Post("/webhooks/stripe", func(c *glib.Ctx) error {
+//	    payload, err := c.RawBody()
+//	    if err != nil {
+//	        return err
+//	    }
+//	    if !validSignature(payload, c.Get("Stripe-Signature")) {
+//	        return errors.Unauthorized("Invalid signature", nil)
+//	    }
+//	    ...
+//	}).Meta(glib.RawBodyMeta, true)
+func (c *Ctx) RawBody() ([]byte, error) {
+	// The compressed bytes are kept as the decompressed body is read
+	body, err := c.Body()
+	if err != nil {
+		return nil, err
+	}
+	raw, decompressed, err := middleware.RawBody(c.Request)
+	switch {
+	case !decompressed:
+		return body, nil
+	case err != nil:
+		return nil, fmt.Errorf("glib: raw body of %s %s, mark the route with Meta(glib.RawBodyMeta, true): %w",
+			c.Method(), c.Path(), err)
+	}
+	return raw, nil
+}
+
+// DecodeBase64Body returns the request body decoded from base64, for the
+// providers wrapping their payloads, accepting the standard and the URL
+// alphabets, with or without padding. Returns 400 Bad Request when the body
+// isn't valid base64.
+func (c *Ctx) DecodeBase64Body() ([]byte, error) {
+	body, err := c.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := bytes.TrimSpace(body)
+	encoding := base64.StdEncoding
+	if bytes.ContainsAny(encoded, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !bytes.HasSuffix(encoded, []byte("=")) && len(encoded)%4 != 0 {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+
+	decoded := make([]byte, encoding.DecodedLen(len(encoded)))
+	n, err := encoding.Decode(decoded, encoded)
+	if err != nil {
+		return nil, errors.BadRequest("Invalid base64 body", err)
+	}
+	return decoded[:n], nil
+}
@@ -0,0 +1,120 @@
+package glib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_RawBody(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write([]byte(`{"type":"charge.succeeded"}`))
+	require.NoError(t, gz.Close())
+
+	tests := []struct {
+		desc      string
+		keep      bool
+		encoding  string
+		body      []byte
+		expectRaw []byte
+		expectErr string
+	}{
+		{
+			desc:      "uncompressed",
+			body:      []byte(`{"type":"charge.succeeded"}`),
+			expectRaw: []byte(`{"type":"charge.succeeded"}`),
+		},
+		{
+			desc:      "compressed on a raw body route",
+			keep:      true,
+			encoding:  "gzip",
+			body:      compressed.Bytes(),
+			expectRaw: compressed.Bytes(),
+		},
+		{
+			desc:      "compressed without raw body metadata",
+			encoding:  "gzip",
+			body:      compressed.Bytes(),
+			expectErr: "glib: raw body of POST /webhooks, mark the route with Meta(glib.RawBodyMeta, true): middleware: raw body of the decompressed request was not kept",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			r.UseHTTP(middleware.Decompress())
+			var raw, body []byte
+			var err error
+			route := r.Post("/webhooks", func(c *Ctx) error {
+				raw, err = c.RawBody()
+				body, _ = c.Body()
+				return c.NoContent()
+			})
+			if tt.keep {
+				route.Meta(RawBodyMeta, true)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, `{"type":"charge.succeeded"}`, string(body), "the handler reads the decompressed body")
+			if tt.expectErr != "" {
+				assert.ErrorIs(t, err, middleware.ErrRawBodyNotKept)
+				assert.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectRaw, raw)
+		})
+	}
+}
+
+func TestCtx_DecodeBase64Body(t *testing.T) {
+	tests := []struct {
+		desc       string
+		body       string
+		expect     string
+		expectCode int
+	}{
+		{desc: "standard", body: "eyJpZCI6MX0+Pz8=", expect: `{"id":1}>??`},
+		{desc: "standard without padding", body: "eyJpZCI6MX0+Pz8", expect: `{"id":1}>??`},
+		{desc: "url", body: "eyJpZCI6MX0-Pz8=", expect: `{"id":1}>??`},
+		{desc: "url without padding", body: "eyJpZCI6MX0-Pz8", expect: `{"id":1}>??`},
+		{desc: "trailing newline", body: "eyJpZCI6MX0=\n", expect: `{"id":1}`},
+		{desc: "invalid", body: "not base64!", expectCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Post("/events", func(c *Ctx) error {
+				payload, err := c.DecodeBase64Body()
+				if err != nil {
+					return err
+				}
+				return c.SendString(string(payload))
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(tt.body)))
+
+			if tt.expectCode != 0 {
+				assert.Equal(t, tt.expectCode, w.Code)
+				return
+			}
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expect, w.Body.String())
+		})
+	}
+}
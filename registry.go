@@ -0,0 +1,343 @@
+package glib
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/go-chi/chi/v5"
+)
+
+// RouteConflictError describes two route registrations that conflict, with the
+// call site of each registration
+type RouteConflictError struct {
+	// Route and Source describe the conflicting registration
+	Route  string
+	Source string
+
+	// Existing and ExistingSource describe the registration it conflicts with
+	Existing       string
+	ExistingSource string
+
+	Reason string
+}
+
+// Error implements the error interface
+func (e *RouteConflictError) Error() string {
+	return fmt.Sprintf("glib: %s registered at %s conflicts with %s registered at %s (%s)",
+		e.Route, e.Source, e.Existing, e.ExistingSource, e.Reason)
+}
+
+// mountIDs generates unique mount identifiers across registries
+var mountIDs atomic.Int64
+
+// registration is a route or mount registered on a router
+type registration struct {
+	method  string
+	pattern string
+	source  string
+	mount   int64   // identifier of the mount, 0 for routes
+	within  []int64 // mounts the registration is nested in
+	mux     chi.Router
+}
+
+func (reg registration) String() string {
+	if reg.mount != 0 {
+		return "mount " + reg.pattern
+	}
+	return "route " + reg.method + " " + reg.pattern
+}
+
+// key returns the pattern without param names, routes differing only by param names being the same route
+func (reg registration) key() string {
+	var b strings.Builder
+	pattern := reg.pattern
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			break
+		}
+		b.WriteString(pattern[:start+1])
+		if _, rexp, ok := strings.Cut(pattern[start+1:start+end], ":"); ok {
+			b.WriteString(":" + rexp)
+		}
+		b.WriteByte('}')
+		pattern = pattern[start+end+1:]
+	}
+	b.WriteString(pattern)
+
+	if reg.mount != 0 {
+		return strings.TrimSuffix(strings.TrimSuffix(b.String(), "*"), "/")
+	}
+	return b.String()
+}
+
+func (reg registration) nestedIn(mount int64) bool {
+	for _, id := range reg.within {
+		if id == mount {
+			return true
+		}
+	}
+	return false
+}
+
+// conflict returns why the registrations conflict, or an empty string
+func (reg registration) conflict(other registration) string {
+	key, otherKey := reg.key(), other.key()
+
+	switch {
+	case reg.mount == 0 && other.mount == 0:
+		if key == otherKey && (reg.method == other.method || reg.method == "*" || other.method == "*") {
+			return "duplicate route"
+		}
+	case reg.mount != 0 && other.mount != 0 && key == otherKey:
+		return "duplicate mount"
+	case other.mount != 0 && underMount(key, otherKey) && !reg.nestedIn(other.mount):
+		return "overlaps the mounted sub-router"
+	case reg.mount != 0 && underMount(otherKey, key) && !other.nestedIn(reg.mount):
+		return "overlaps the mounted sub-router"
+	}
+	return ""
+}
+
+// underMount reports whether the pattern is routed to the mount with the given key
+func underMount(key, mountKey string) bool {
+	return mountKey == "" || key == mountKey || strings.HasPrefix(key, mountKey+"/")
+}
+
+// routeRegistry records the registrations of a router and its sub-routers to
+// detect conflicts, which chi either ignores (the last route wins) or reports
+// without the call site
+type routeRegistry struct {
+	mu            sync.Mutex
+	registrations []registration
+	conflicts     []error
+	parents       []registryLink
+	started       atomic.Bool // the server serving the routes started
+}
+
+// registryLink propagates registrations to the registry of the router a router is mounted on
+type registryLink struct {
+	registry *routeRegistry
+	prefix   string
+	within   []int64
+}
+
+// add records the registration and returns the conflict it creates, if any.
+// The registration is propagated to the routers this router is mounted on.
+func (rr *routeRegistry) add(reg registration) error {
+	rr.mu.Lock()
+	var conflict error
+	for _, existing := range rr.registrations {
+		if reason := reg.conflict(existing); reason != "" {
+			conflict = &RouteConflictError{
+				Route:          reg.String(),
+				Source:         reg.source,
+				Existing:       existing.String(),
+				ExistingSource: existing.source,
+				Reason:         reason,
+			}
+			rr.conflicts = append(rr.conflicts, conflict)
+			break
+		}
+	}
+	rr.registrations = append(rr.registrations, reg)
+	parents := rr.parents
+	rr.mu.Unlock()
+
+	for _, link := range parents {
+		_ = link.registry.add(link.rebase(reg))
+	}
+	return conflict
+}
+
+// rebase returns the registration as seen from the parent router
+func (link registryLink) rebase(reg registration) registration {
+	reg.pattern = link.prefix + reg.pattern
+	reg.within = append(append([]int64(nil), link.within...), reg.within...)
+	reg.mux = nil
+	return reg
+}
+
+// link propagates the registrations of the registry, past and future, to the parent
+func (rr *routeRegistry) link(parent registryLink) {
+	rr.mu.Lock()
+	rr.parents = append(rr.parents, parent)
+	registrations := append([]registration(nil), rr.registrations...)
+	rr.mu.Unlock()
+
+	for _, reg := range registrations {
+		_ = parent.registry.add(parent.rebase(reg))
+	}
+}
+
+// isStarted reports whether the server serving the router or a router it is mounted on started
+func (rr *routeRegistry) isStarted() bool {
+	if rr.started.Load() {
+		return true
+	}
+	rr.mu.Lock()
+	parents := rr.parents
+	rr.mu.Unlock()
+
+	for _, link := range parents {
+		if link.registry.isStarted() {
+			return true
+		}
+	}
+	return false
+}
+
+// firstRoute returns the first route or mount registered directly on the mux,
+// after which chi doesn't accept middlewares
+func (rr *routeRegistry) firstRoute(mux chi.Router) (registration, bool) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	for _, reg := range rr.registrations {
+		if reg.mux == mux {
+			return reg, true
+		}
+	}
+	return registration{}, false
+}
+
+func (rr *routeRegistry) validate() error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return errors.Join(rr.conflicts...)
+}
+
+// Validate checks the routes registered on the router and its sub-routers and
+// returns the conflicts found: duplicate method and pattern registrations (chi
+// silently keeps the last one) and routes overlapping a mounted sub-router.
+// Each conflict is a *RouteConflictError naming both call sites.
+//
+// It is called by Server.Listen in debug mode (IS_DEBUG=true), call it from a
+// test to check the routes in CI:
+//
+//	func TestRoutes(t *testing.T) {
+//	    require.NoError(t, newRouter().Validate())
+//	}
+func (r *router) Validate() error {
+	return r.registry.validate()
+}
+
+// register records a route registered on the router
+func (r *router) register(method, pattern, source string) {
+	reg := registration{
+		method:  method,
+		pattern: r.prefix + pattern,
+		source:  source,
+		within:  r.mounts,
+		mux:     r.chi,
+	}
+	r.warnIfStarted(reg)
+	_ = r.registry.add(reg)
+}
+
+// warnIfStarted warns about registrations made after the server started, which
+// usually come from tests or handlers adding routes on the fly
+func (r *router) warnIfStarted(reg registration) {
+	if r.logger != nil && r.registry.isStarted() {
+		r.logger.Warn("Route registered after the server started", "route", reg.String(), "source", reg.source)
+	}
+}
+
+// registerMount records a sub-router mounted on the router and returns the mount
+// identifier. It panics on duplicate mounts, like chi, naming both call sites.
+func (r *router) registerMount(pattern, source string) int64 {
+	id := mountIDs.Add(1)
+	reg := registration{
+		pattern: r.prefix + pattern,
+		source:  source,
+		mount:   id,
+		within:  r.mounts,
+		mux:     r.chi,
+	}
+	r.warnIfStarted(reg)
+	err := r.registry.add(reg)
+
+	var conflict *RouteConflictError
+	if errors.As(err, &conflict) && conflict.Reason == "duplicate mount" {
+		panic(err)
+	}
+	return id
+}
+
+// MiddlewareOrderError reports a middleware added to a router after its routes,
+// which chi can't apply to them. Use and UseHTTP panic with it.
+type MiddlewareOrderError struct {
+	// Source is the call site of the middleware
+	Source string
+
+	// Route and RouteSource describe the first route registered on the router
+	Route       string
+	RouteSource string
+}
+
+// Error implements the error interface
+func (e *MiddlewareOrderError) Error() string {
+	return fmt.Sprintf("glib: middleware added at %s after %s registered at %s, all middlewares must be added before the routes of a router",
+		e.Source, e.Route, e.RouteSource)
+}
+
+// deferredMiddlewares collects the middlewares added to a Route sub-router after
+// its routes, applied around the sub-router when it is mounted
+type deferredMiddlewares struct {
+	middlewares      []func(http.Handler) http.Handler
+	mounted          bool
+	notFound         bool // the sub-router defines its own NotFound handler
+	methodNotAllowed bool // the sub-router defines its own MethodNotAllowed handler
+}
+
+// deferredRouter serves a sub-router through its deferred middlewares, still
+// exposing its routes to chi for Walk, Match and Find
+type deferredRouter struct {
+	chi.Router
+	handler http.Handler
+}
+
+func (d *deferredRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	d.handler.ServeHTTP(w, req)
+}
+
+// useHTTP adds the middlewares to the router. Middlewares added after the routes
+// are deferred until the mount of Route sub-routers, and panic on other routers
+// like chi, naming both call sites.
+func (r *router) useHTTP(source string, middlewares []func(http.Handler) http.Handler) {
+	first, ok := r.registry.firstRoute(r.chi)
+	switch {
+	case !ok:
+		for _, mw := range middlewares {
+			r.chi.Use(mw)
+		}
+	case r.deferred != nil && !r.deferred.mounted:
+		r.deferred.middlewares = append(r.deferred.middlewares, middlewares...)
+	default:
+		panic(&MiddlewareOrderError{
+			Source:      source,
+			Route:       first.String(),
+			RouteSource: first.source,
+		})
+	}
+}
+
+// callSite returns the file:line of a caller, skip 0 being the caller of callSite
+func callSite(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	return slog.TrimSourcePath(file) + ":" + strconv.Itoa(line)
+}
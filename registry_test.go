@@ -0,0 +1,257 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Validate(t *testing.T) {
+	handler := func(c *Ctx) error { return c.NoContent() }
+
+	cases := []struct {
+		desc     string
+		setup    func(r Router)
+		expected string
+	}{
+		{
+			desc: "no conflicts",
+			setup: func(r Router) {
+				r.Get("/users", handler)
+				r.Post("/users", handler)
+				r.Route("/posts", func(r Router) {
+					r.Get("/", handler)
+					r.Get("/{id}", handler)
+				})
+			},
+		},
+		{
+			desc: "duplicate route",
+			setup: func(r Router) {
+				r.Get("/users/{id}", handler)
+				r.Get("/users/{userID}", handler)
+			},
+			expected: "route GET /users/{userID} registered at registry_test.go:",
+		},
+		{
+			desc: "duplicate route in sub-router",
+			setup: func(r Router) {
+				r.Get("/users/me", handler)
+				r.Route("/users", func(r Router) {
+					r.HandleFunc("/me", handler)
+				})
+			},
+			expected: "duplicate route",
+		},
+		{
+			desc: "route overlapping a mount",
+			setup: func(r Router) {
+				sub := setupTestRouter()
+				sub.Get("/users", handler)
+				r.Mount("/api", sub)
+				r.Get("/api/users", handler)
+			},
+			expected: "route GET /api/users registered at registry_test.go:",
+		},
+		{
+			desc: "route added to a mounted router",
+			setup: func(r Router) {
+				sub := setupTestRouter()
+				r.Mount("/api", sub)
+				r.Get("/api/health", handler)
+				sub.Get("/health", handler)
+			},
+			expected: "overlaps the mounted sub-router",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			tc.setup(r)
+
+			err := r.Validate()
+			if tc.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+
+			var conflict *RouteConflictError
+			require.ErrorAs(t, err, &conflict)
+			assert.Regexp(t, `^registry_test\.go:\d+$`, conflict.Source)
+			assert.Regexp(t, `^registry_test\.go:\d+$`, conflict.ExistingSource)
+		})
+	}
+}
+
+func TestRouter_RegistrationPanics(t *testing.T) {
+	t.Run("duplicate mount", func(t *testing.T) {
+		r := setupTestRouter()
+		r.Mount("/api", http.NotFoundHandler())
+		defer func() {
+			err, ok := recover().(error)
+			require.True(t, ok)
+			assert.Contains(t, err.Error(), "mount /api registered at registry_test.go:")
+			assert.Contains(t, err.Error(), "duplicate mount")
+		}()
+		r.Mount("/api", http.NotFoundHandler())
+	})
+
+}
+
+func TestRouter_MiddlewareOrder(t *testing.T) {
+	handler := func(c *Ctx) error { return c.SendString("ok") }
+	header := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	t.Run("middleware after routes panics", func(t *testing.T) {
+		r := setupTestRouter()
+		r.Get("/secret", handler)
+
+		deny := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+		}
+		var orderErr *MiddlewareOrderError
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				require.ErrorAs(t, err, &orderErr)
+			}()
+			r.UseHTTP(deny)
+		}()
+		assert.Regexp(t, `^glib: middleware added at registry_test\.go:\d+ after route GET /secret registered at registry_test\.go:\d+`, orderErr.Error())
+
+		assert.Panics(t, func() {
+			r.Use(func(next HandleFunc) HandleFunc { return next })
+		})
+		g := r.Group(func(r Router) {
+			r.Get("/admin", handler)
+		})
+		assert.Panics(t, func() { g.UseHTTP(deny) }, "on groups too")
+	})
+
+	t.Run("middleware after routes of a Route sub-router is deferred", func(t *testing.T) {
+		r := setupTestRouter()
+		r.Route("/api", func(r Router) {
+			r.UseHTTP(header("early"))
+			r.Get("/users", handler)
+			r.UseHTTP(header("late"))
+			r.Use(func(next HandleFunc) HandleFunc {
+				return func(c *Ctx) error {
+					c.Response.Header().Add("X-Middleware", "ctx")
+					return next(c)
+				}
+			})
+		})
+		require.NoError(t, r.Validate())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"late", "ctx", "early"}, w.Header().Values("X-Middleware"))
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/missing", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "Route not found", "the parent NotFound handler is kept")
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/users", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+		assert.Equal(t, "/api/users", MatchRoute(r, "GET", "/api/users").Pattern)
+		assert.Len(t, r.RouteList(), 1)
+	})
+
+	t.Run("chi middlewares in a nested Route", func(t *testing.T) {
+		r := setupTestRouter()
+		r.Route("/api", func(r Router) {
+			r.UseHTTP(chimiddleware.StripSlashes)
+			r.Route("/v1", func(r Router) {
+				r.Get("/users", handler)
+				r.UseHTTP(chimiddleware.Heartbeat("/api/v1/ping"))
+			})
+		})
+
+		tests := []struct {
+			desc   string
+			path   string
+			status int
+			body   string
+		}{
+			{desc: "route", path: "/api/v1/users", status: http.StatusOK, body: "ok"},
+			{desc: "trailing slash stripped", path: "/api/v1/users/", status: http.StatusOK, body: "ok"},
+			{desc: "heartbeat", path: "/api/v1/ping", status: http.StatusOK, body: "."},
+		}
+		for _, tt := range tests {
+			t.Run(tt.desc, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, tt.body, w.Body.String())
+			})
+		}
+	})
+
+	t.Run("middleware after the mount of a Route sub-router panics", func(t *testing.T) {
+		r := setupTestRouter()
+		sub := r.Route("/api", func(r Router) {
+			r.Get("/users", handler)
+		})
+		assert.Panics(t, func() { sub.UseHTTP(header("late")) })
+	})
+	t.Run("middleware after a mount panics", func(t *testing.T) {
+		tests := []struct {
+			desc  string
+			mount func(r Router)
+			route string
+		}{
+			{desc: "Route", mount: func(r Router) { r.Route("/api", func(r Router) { r.Get("/users", handler) }) }, route: "mount /api"},
+			{desc: "Mount", mount: func(r Router) { r.Mount("/static", http.NotFoundHandler()) }, route: "mount /static"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.desc, func(t *testing.T) {
+				r := setupTestRouter()
+				tt.mount(r)
+
+				var orderErr *MiddlewareOrderError
+				func() {
+					defer func() {
+						err, _ := recover().(error)
+						require.ErrorAs(t, err, &orderErr)
+					}()
+					r.UseHTTP(header("late"))
+				}()
+				assert.Equal(t, tt.route, orderErr.Route)
+			})
+		}
+	})
+
+	t.Run("middleware after a mount within a Route sub-router is deferred", func(t *testing.T) {
+		r := setupTestRouter()
+		r.Route("/api", func(r Router) {
+			r.Mount("/v1", http.NotFoundHandler())
+			r.Get("/users", handler)
+			r.UseHTTP(header("late"))
+		})
+		require.NoError(t, r.Validate())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+		assert.Equal(t, []string{"late"}, w.Header().Values("X-Middleware"))
+	})
+}
@@ -0,0 +1,59 @@
+package glib
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/middleware"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reportedError struct {
+	err  error
+	meta map[string]any
+}
+
+func TestErrorReporter(t *testing.T) {
+	var reports []reportedError
+	config := DefaultRouterOptions()
+	config.ErrorReporter = errors.ReporterFunc(func(ctx context.Context, err error, meta map[string]any) {
+		reports = append(reports, reportedError{err: err, meta: meta})
+	})
+
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	r.Use(func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			c.SetUser("user-42")
+			return next(c)
+		}
+	})
+	r.Get("/users/{id}", func(c *Ctx) error {
+		return stderrors.New("database is down")
+	})
+	r.Get("/missing", func(c *Ctx) error {
+		return errors.NotFound("Not found", nil)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	require.Len(t, reports, 1, "only 5xx errors are reported")
+	assert.EqualError(t, reports[0].err, "database is down")
+	assert.Equal(t, "/users/{id}", reports[0].meta["route"])
+	assert.Equal(t, "user-42", reports[0].meta["user"])
+	assert.Equal(t, middleware.RedactedValue, reports[0].meta["headers"].(map[string]string)["Authorization"])
+}
@@ -0,0 +1,251 @@
+package glib
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/azizndao/glib/httputil"
+)
+
+// ErrResponseTooLarge is returned by the writes of a response body exceeding
+// RouterConfig.MaxResponseBytes, and rendered as a 500 Internal Server Error
+var ErrResponseTooLarge = errors.New("glib: response body exceeds the maximum size")
+
+// responseWriter wraps the http.ResponseWriter of requests handled by glib.
+//
+// For HEAD requests, the body is discarded but its size is counted so the
+// Content-Length header matches the one of the corresponding GET response.
+// The status line is then delayed until the handler returns, see finish.
+//
+// When the body size is limited, the status line is delayed until the first
+// write so that a response exceeding the limit in its first write can still be
+// replaced by an error, see exceed.
+type responseWriter struct {
+	http.ResponseWriter
+	head        bool
+	status      int
+	wroteHeader bool
+	sent        bool // the status line was sent to the wrapped writer
+	size        int64
+	finished    bool
+
+	// maxSize is the maximum size of the body, 0 for none
+	maxSize int64
+	// tooLarge is set once a write exceeded maxSize
+	tooLarge bool
+	// onTooLarge is called once when a write exceeds maxSize, with the size
+	// the body would have had
+	onTooLarge func(size int64)
+
+	// timing holds the Server-Timing entries sent with the header, see Ctx.Timing
+	timing *serverTiming
+
+	// ctx is the Ctx shared by the middlewares and the handler writing the
+	// response, see router.acquireCtx
+	ctx *Ctx
+
+	// trace records the middlewares and the handler run by a traced request,
+	// see TraceConfig
+	trace *requestTrace
+
+	// challenges are sent with the 401 error responses, see Ctx.SetChallenge
+	challenges []httputil.Challenge
+
+	// correlationID is the ID generated for a request without one, see
+	// Ctx.CorrelationID
+	correlationID string
+
+	// defaultHeaders holds the values of the headers set by the default
+	// headers of the routers, see headerDefaults.apply
+	defaultHeaders map[string]string
+}
+
+// newResponseWriter wraps w, unless it is already wrapped
+func newResponseWriter(w http.ResponseWriter, req *http.Request) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{
+		ResponseWriter: w,
+		head:           req.Method == http.MethodHead,
+		status:         http.StatusOK,
+	}
+}
+
+// WriteHeader records the status code and sends it, except for HEAD requests
+// where it is sent by finish, and limited responses where it is sent with the
+// first write. Only the first call has an effect.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+	if !w.head && w.maxSize <= 0 {
+		w.sendHeader()
+	}
+}
+
+// sendHeader sends the status line and headers to the wrapped writer.
+//
+// When trailers are declared (see Ctx.AddTrailer), Content-Length is removed so
+// HTTP/1.1 responses use the chunked transfer encoding, the only one carrying trailers.
+func (w *responseWriter) sendHeader() {
+	w.sent = true
+	if header := w.Header(); len(header["Trailer"]) > 0 {
+		header.Del("Content-Length")
+	}
+	w.setServerTiming()
+	w.setTrace()
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// setServerTiming sets the Server-Timing header from the entries of the response
+func (w *responseWriter) setServerTiming() {
+	if w.timing == nil {
+		return
+	}
+	if value := w.timing.header(); value != "" {
+		w.Header().Set("Server-Timing", value)
+	}
+}
+
+// Write writes the body, or only counts its size for HEAD requests
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.head {
+		w.size += int64(len(b))
+		return len(b), nil
+	}
+	if w.tooLarge || (w.maxSize > 0 && w.size+int64(len(b)) > w.maxSize) {
+		return 0, w.exceed(int64(len(b)))
+	}
+	if !w.sent {
+		w.sendHeader()
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// exceed handles a write of n bytes beyond maxSize. When nothing was sent yet,
+// the write fails with ErrResponseTooLarge and the response is replaced by an
+// error once the handler returns, see discardTooLarge. Otherwise the response
+// is aborted, closing the connection, as a truncated body must not pass for a
+// complete one.
+func (w *responseWriter) exceed(n int64) error {
+	if !w.tooLarge {
+		w.tooLarge = true
+		if w.onTooLarge != nil {
+			w.onTooLarge(w.size + n)
+		}
+	}
+	if w.sent {
+		panic(http.ErrAbortHandler)
+	}
+	return ErrResponseTooLarge
+}
+
+// discardTooLarge resets a response whose body exceeded maxSize before anything
+// was sent, so an error can be rendered instead. It reports whether it did.
+func (w *responseWriter) discardTooLarge() bool {
+	if !w.tooLarge || w.sent {
+		return false
+	}
+	header := w.Header()
+	for _, key := range []string{"Content-Length", "Content-Type", "Content-Encoding", "Content-Disposition", "ETag", "Last-Modified"} {
+		header.Del(key)
+	}
+	w.status, w.wroteHeader, w.size = http.StatusOK, false, 0
+	w.tooLarge, w.maxSize = false, 0
+	return true
+}
+
+// Flush implements http.Flusher. It has no effect for HEAD requests.
+func (w *responseWriter) Flush() {
+	if w.head {
+		return
+	}
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.sent {
+		w.sendHeader()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker for the wrapped writers supporting it, e.g. to
+// upgrade the connection to WebSocket. The response is then left to the caller.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader, w.sent, w.finished = true, true, true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status code of the response
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size returns the number of body bytes written, or that would have been written for HEAD requests
+func (w *responseWriter) Size() int64 {
+	return w.size
+}
+
+// finish completes the response once the handler returned: it sends the status
+// line delayed by the size limit, and for HEAD responses it sets
+// Content-Length to the size of the discarded body, unless the handler set
+// Content-Length or Transfer-Encoding itself, and sends the status line.
+func (w *responseWriter) finish() {
+	if w.finished {
+		return
+	}
+	w.finished = true
+
+	if !w.head {
+		if w.wroteHeader && !w.sent {
+			w.sendHeader()
+		}
+		return
+	}
+
+	header := w.Header()
+	if w.size > 0 && header.Get("Content-Length") == "" && header.Get("Transfer-Encoding") == "" {
+		header.Set("Content-Length", strconv.FormatInt(w.size, 10))
+	}
+	w.sent = true
+	w.setServerTiming()
+	w.setTrace()
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// SetMaxResponseBytes overrides RouterConfig.MaxResponseBytes for the response,
+// e.g. for an export endpoint known to send large bodies. 0 removes the limit.
+// It must be called before the body is written.
+//
+// Example:
+//
+//	r.Get("/export", func(c *glib.Ctx) error {
+//	    c.SetMaxResponseBytes(2 << 30)
+//	    return c.Stream(...)
+//	})
+func (c *Ctx) SetMaxResponseBytes(n int64) {
+	if rw, ok := c.Response.(*responseWriter); ok {
+		rw.maxSize = max(n, 0)
+	}
+}
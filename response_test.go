@@ -0,0 +1,184 @@
+package glib
+
+import (
+	"bytes"
+	"io"
+	stdslog "log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseWriter_HEAD(t *testing.T) {
+	r := setupTestRouter()
+	users := func(c *Ctx) error {
+		return c.JSON(map[string]string{"name": "john"})
+	}
+	r.Get("/users", users)
+	r.Head("/users", users)
+
+	r.Head("/explicit", func(c *Ctx) error {
+		c.Set("Content-Length", "1234")
+		return c.SendString("ignored")
+	})
+	r.Head("/chunked", func(c *Ctx) error {
+		c.Set("Transfer-Encoding", "chunked")
+		return c.SendString("ignored")
+	})
+	r.Head("/created", func(c *Ctx) error {
+		return c.Created(map[string]int{"id": 1})
+	})
+
+	get := httptest.NewRecorder()
+	r.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, `{"name":"john"}`+"\n", get.Body.String())
+
+	cases := []struct {
+		desc          string
+		path          string
+		status        int
+		contentLength string
+	}{
+		{desc: "shared GET handler", path: "/users", status: http.StatusOK, contentLength: strconv.Itoa(get.Body.Len())},
+		{desc: "explicit Content-Length is preserved", path: "/explicit", status: http.StatusOK, contentLength: "1234"},
+		{desc: "Transfer-Encoding disables Content-Length", path: "/chunked", status: http.StatusOK, contentLength: ""},
+		{desc: "status is preserved", path: "/created", status: http.StatusCreated, contentLength: "9"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, tc.path, nil))
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Empty(t, w.Body.Bytes(), "no body bytes are transmitted")
+			assert.Equal(t, tc.contentLength, w.Header().Get("Content-Length"))
+		})
+	}
+}
+
+// counterMetrics records the counters with their labels
+type counterMetrics struct {
+	counters map[string][]string
+}
+
+func (m *counterMetrics) Counter(name string, value float64, labels ...string) {
+	m.counters[name] = append(m.counters[name], labels...)
+}
+func (m *counterMetrics) Gauge(string, float64, ...string)   {}
+func (m *counterMetrics) Observe(string, float64, ...string) {}
+
+func TestResponseWriter_MaxResponseBytes(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(stdslog.NewJSONHandler(&logs, nil))
+	metrics := &counterMetrics{counters: map[string][]string{}}
+	config := DefaultRouterOptions()
+	config.MaxResponseBytes = 100
+	config.Metrics = metrics
+	r := Default(logger, validation.New(validation.DefaultValidatorConfig()), config)
+
+	large := strings.Repeat("a", 200)
+	r.Get("/small", func(c *Ctx) error {
+		return c.SendString("ok")
+	})
+	r.Get("/large/{id}", func(c *Ctx) error {
+		c.Set("ETag", `"large"`)
+		return c.Status(http.StatusCreated).JSON(map[string]string{"data": large})
+	})
+	r.Get("/empty", func(c *Ctx) error {
+		return c.NoContent()
+	})
+	r.Get("/export", func(c *Ctx) error {
+		c.SetMaxResponseBytes(0)
+		return c.SendString(large)
+	})
+	r.Get("/stream", func(c *Ctx) error {
+		for range 4 {
+			if _, err := c.Response.Write([]byte(large[:40])); err != nil {
+				return err
+			}
+			c.Response.(http.Flusher).Flush()
+		}
+		return nil
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("within the limit", func(t *testing.T) {
+		w := serve("/small")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ok", w.Body.String())
+		assert.Equal(t, http.StatusNoContent, serve("/empty").Code, "the status is sent without a body")
+	})
+
+	t.Run("replaced by an error", func(t *testing.T) {
+		logs.Reset()
+		w := serve("/large/1")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+		assert.Contains(t, w.Header().Get("Content-Type"), MIMEApplicationJSON)
+		assert.NotContains(t, w.Body.String(), large)
+
+		assert.Contains(t, logs.String(), `"route":"/large/{id}"`)
+		assert.Contains(t, logs.String(), `"size":212`)
+		assert.Equal(t, []string{"route", "/large/{id}"}, metrics.counters["response_too_large_total"])
+	})
+
+	t.Run("per-route override", func(t *testing.T) {
+		w := serve("/export")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("streamed response is aborted", func(t *testing.T) {
+		logs.Reset()
+		w := httptest.NewRecorder()
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, large[:40]+large[:40], w.Body.String())
+		assert.Contains(t, logs.String(), `"size":120`)
+	})
+}
+
+func TestResponseWriter_Hijack(t *testing.T) {
+	previous := stdslog.Default()
+	t.Cleanup(func() { stdslog.SetDefault(previous) })
+
+	s := New(Config{DisableDotEnv: true, Logger: stdslog.New(stdslog.DiscardHandler)})
+	s.Router().Get("/socket", func(c *Ctx) error {
+		hijacker, ok := c.Response.(http.Hijacker)
+		if !assert.True(t, ok, "the response implements http.Hijacker") {
+			return nil
+		}
+		conn, rw, err := hijacker.Hijack()
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		return rw.Flush()
+	})
+
+	srv := httptest.NewServer(s.Router())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/socket")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hijacked", string(body))
+}
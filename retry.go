@@ -0,0 +1,27 @@
+package glib
+
+import (
+	"time"
+
+	"github.com/azizndao/glib/httputil"
+)
+
+// SetRetryAfter sets the Retry-After header to the delay in seconds, rounded
+// up, e.g. with 429 Too Many Requests or 503 Service Unavailable responses
+func (c *Ctx) SetRetryAfter(d time.Duration) *Ctx {
+	return c.Set("Retry-After", httputil.FormatRetryAfter(d))
+}
+
+// SetRetryAt sets the Retry-After header to the time, as an HTTP-date, e.g.
+// the end of a maintenance window
+func (c *Ctx) SetRetryAt(t time.Time) *Ctx {
+	return c.Set("Retry-After", httputil.FormatRetryAt(t))
+}
+
+// RetryAfter returns the delay of the Retry-After header of the response, set
+// by SetRetryAfter, SetRetryAt or a middleware, parsing both the delta-seconds
+// and HTTP-date forms. Use httputil.ParseRetryAfter for the responses of
+// upstream services.
+func (c *Ctx) RetryAfter() (time.Duration, bool) {
+	return httputil.ParseRetryAfter(c.header().Get("Retry-After"), time.Now())
+}
@@ -0,0 +1,75 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/httputil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCtx_RetryAfter(t *testing.T) {
+	cases := []struct {
+		desc        string
+		set         func(c *Ctx)
+		expectValue string
+		expectDelay time.Duration
+		expectOK    bool
+	}{
+		{
+			desc:        "delay",
+			set:         func(c *Ctx) { c.SetRetryAfter(1500 * time.Millisecond) },
+			expectValue: "2",
+			expectDelay: 2 * time.Second,
+			expectOK:    true,
+		},
+		{
+			desc:        "date",
+			set:         func(c *Ctx) { c.SetRetryAt(time.Now().Add(time.Hour)) },
+			expectDelay: time.Hour,
+			expectOK:    true,
+		},
+		{
+			desc: "not set",
+			set:  func(c *Ctx) {},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := setupTestRouter()
+			var delay time.Duration
+			var ok bool
+			r.Get("/maintenance", func(c *Ctx) error {
+				tc.set(c)
+				delay, ok = c.RetryAfter()
+				return c.NoContent()
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/maintenance", nil))
+
+			if tc.expectValue != "" {
+				assert.Equal(t, tc.expectValue, w.Header().Get("Retry-After"))
+			}
+			assert.Equal(t, tc.expectOK, ok)
+			assert.InDelta(t, tc.expectDelay, delay, float64(time.Second))
+		})
+	}
+}
+
+func TestCtx_AddLink(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/orders", func(c *Ctx) error {
+		c.AddLink(httputil.Link{URL: "/docs/orders", Rel: "describedby"})
+		c.AddLink(httputil.Link{URL: "/docs/orders", Rel: "describedby"}, httputil.Link{URL: "/orders?page=2", Rel: "next"})
+		return c.NoContent()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	assert.Equal(t, []string{`</docs/orders>; rel="describedby", </orders?page=2>; rel="next"`}, w.Header().Values("Link"))
+}
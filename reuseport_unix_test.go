@@ -0,0 +1,26 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package glib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ReusePort(t *testing.T) {
+	addr := freeAddr(t)
+	first := &Server{reusePort: true}
+	ln, err := first.bind(addr)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	second := &Server{reusePort: true}
+	ln2, err := second.bind(addr)
+	require.NoError(t, err, "a server with ReusePort binds the same address")
+	defer ln2.Close()
+
+	_, err = (&Server{}).bind(addr)
+	assert.Error(t, err, "a server without ReusePort can't bind the address")
+}
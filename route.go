@@ -0,0 +1,253 @@
+package glib
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/azizndao/glib/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// Route holds the metadata of a registered route, used to document the API.
+// It is returned by the route registration methods:
+//
+//	r.Get("/users/{id}", getUser).
+//	    Describe("Fetches a user").
+//	    Example(nil, User{ID: 1, Name: "John"})
+type Route struct {
+	// Method is the HTTP method of the route, "*" for routes matching all methods
+	Method string
+
+	// Pattern is the routing pattern. In RouteList it includes the prefix of the sub-routers.
+	Pattern string
+
+	Description string
+	Query       []string
+	Examples    []RouteExample
+
+	// Metadata holds the values set with Meta
+	Metadata map[string]any
+}
+
+// RawBodyMeta is the route metadata key making Ctx.RawBody return the request
+// body as received, before the Decompress middleware:
+//
+//	r.Post("/webhooks/stripe", handleStripe).Meta(glib.RawBodyMeta, true)
+const RawBodyMeta = "raw-body"
+
+// Log4xxMeta is the route metadata key setting the level of the request log of
+// the 4xx responses of the route, e.g. "info" for the expected 404 of a lookup
+// route, see middleware.LoggerConfig:
+//
+//	r.Get("/users/{id}", getUser).Meta(glib.Log4xxMeta, "info")
+const Log4xxMeta = "log-4xx"
+
+// RouteExample is an example request and response payload of a route
+type RouteExample struct {
+	Request  any
+	Response any
+}
+
+// Describe sets the description of the route
+func (rt *Route) Describe(description string) *Route {
+	rt.Description = description
+	return rt
+}
+
+// Example adds an example request and response payload. Use nil for a missing payload.
+func (rt *Route) Example(request, response any) *Route {
+	rt.Examples = append(rt.Examples, RouteExample{Request: request, Response: response})
+	return rt
+}
+
+// Queries documents the query parameters accepted by the route
+func (rt *Route) Queries(names ...string) *Route {
+	rt.Query = append(rt.Query, names...)
+	return rt
+}
+
+// Meta sets a metadata value of the route, e.g. RawBodyMeta
+func (rt *Route) Meta(key string, value any) *Route {
+	if rt.Metadata == nil {
+		rt.Metadata = make(map[string]any)
+	}
+	rt.Metadata[key] = value
+	return rt
+}
+
+// log4xxLevel returns the level of the Log4xxMeta metadata, an slog.Level or
+// its name
+func (rt *Route) log4xxLevel() (slog.Level, bool) {
+	switch value := rt.Metadata[Log4xxMeta].(type) {
+	case slog.Level:
+		return value, true
+	case string:
+		var level slog.Level
+		return level, level.UnmarshalText([]byte(value)) == nil
+	}
+	return 0, false
+}
+
+// Params returns the names of the path parameters of the route, inferred from
+// its pattern. A trailing wildcard is returned as "*".
+func (rt Route) Params() []string {
+	var params []string
+	for segment := range strings.SplitSeq(rt.Pattern, "/") {
+		for segment != "" {
+			start := strings.IndexByte(segment, '{')
+			if start < 0 {
+				if strings.HasSuffix(segment, "*") {
+					params = append(params, "*")
+				}
+				break
+			}
+			end := strings.IndexByte(segment[start:], '}')
+			if end < 0 {
+				break
+			}
+			name, _, _ := strings.Cut(segment[start+1:start+end], ":")
+			params = append(params, name)
+			segment = segment[start+end+1:]
+		}
+	}
+	return params
+}
+
+// routeHandler is the handler registered in chi for a route, giving access to
+// the route metadata when walking the routing tree
+type routeHandler struct {
+	route   *Route
+	handler http.HandlerFunc
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if keep, _ := h.route.Metadata[RawBodyMeta].(bool); keep {
+		middleware.KeepRawBody(req)
+	}
+	if level, ok := h.route.log4xxLevel(); ok {
+		middleware.SetClientErrorLevel(req, level)
+	}
+	h.handler(w, req)
+}
+
+// handle registers the handler for the method and pattern. An empty method matches all methods.
+func (r *router) handle(method, pattern string, h HandleFunc) *Route {
+	pattern = r.routePattern(pattern)
+	route := &Route{Method: method, Pattern: pattern}
+	handler := &routeHandler{route: route, handler: r.wrapHandler(h)}
+
+	if method == "" {
+		route.Method = "*"
+	}
+	r.register(route.Method, pattern, callSite(2))
+
+	if method == "" {
+		r.chi.Handle(pattern, handler)
+	} else {
+		r.chi.Method(method, pattern, handler)
+	}
+	return route
+}
+
+// RouteList returns the routes registered with a HandleFunc on the router and
+// its sub-routers, sorted by pattern and method, with their full pattern
+func (r *router) RouteList() []Route {
+	var routes []Route
+	seen := make(map[*Route]map[string]bool)
+
+	_ = chi.Walk(r.chi, func(method, pattern string, handler http.Handler, _ ...func(http.Handler) http.Handler) error {
+		rh, ok := handler.(*routeHandler)
+		if !ok {
+			return nil
+		}
+		if rh.route.Method == "*" {
+			// Routes matching all methods are walked once per method
+			if seen[rh.route][pattern] {
+				return nil
+			}
+			if seen[rh.route] == nil {
+				seen[rh.route] = make(map[string]bool)
+			}
+			seen[rh.route][pattern] = true
+			method = "*"
+		}
+
+		route := *rh.route
+		route.Method = method
+		route.Pattern = pattern
+		routes = append(routes, route)
+		return nil
+	})
+
+	slices.SortFunc(routes, func(a, b Route) int {
+		if c := strings.Compare(a.Pattern, b.Pattern); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return routes
+}
+
+// RoutePattern returns the pattern of the route matching the request, including
+// the prefix of the sub-routers, e.g. "/api/users/{id}" for "/api/users/42".
+// Returns "" when no route matches the request.
+//
+// The pattern is known once the request is routed: in handlers, in the
+// middlewares of With and Group, and in global middlewares once next returned.
+// Before that, e.g. in global and sub-router middlewares before calling next,
+// it is resolved by looking up the route tree. Middlewares recording the route
+// at completion, like metrics and access logs, get the route actually served:
+//
+//	r.Use(func(next glib.HandleFunc) glib.HandleFunc {
+//	    return func(c *glib.Ctx) error {
+//	        start := time.Now()
+//	        err := next(c)
+//	        latency.Observe(c.RoutePattern(), time.Since(start))
+//	        return err
+//	    }
+//	})
+func (c *Ctx) RoutePattern() string {
+	rctx := chi.RouteContext(c.Context())
+	if rctx == nil {
+		return ""
+	}
+
+	// A trailing wildcard may be the pattern of a mount whose routing is not done
+	pattern := rctx.RoutePattern()
+	if pattern != "" && !strings.HasSuffix(pattern, "*") {
+		return pattern
+	}
+	if found := c.findRoute(rctx); found != "" {
+		return found
+	}
+	return pattern
+}
+
+// findRoute looks up the route tree for the pattern matching the request
+func (c *Ctx) findRoute(rctx *chi.Context) string {
+	if rctx.Routes == nil {
+		return ""
+	}
+
+	path := c.Request.URL.RawPath
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	if c.config != nil && c.config.CaseInsensitiveRouting {
+		path = foldCase(path)
+	}
+
+	methods := []string{c.Request.Method}
+	if c.Request.Method == http.MethodHead {
+		// HEAD requests are served by the GET routes
+		methods = append(methods, http.MethodGet)
+	}
+	for _, method := range methods {
+		if pattern := rctx.Routes.Find(chi.NewRouteContext(), method, path); pattern != "" {
+			return pattern
+		}
+	}
+	return ""
+}
@@ -0,0 +1,237 @@
+package glib
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_RouteList(t *testing.T) {
+	r := setupTestRouter()
+	handler := func(c *Ctx) error { return c.NoContent() }
+
+	r.Get("/health", handler)
+	r.Route("/users", func(r Router) {
+		r.Get("/", handler).Describe("Lists users").Queries("page", "limit")
+		r.With(func(next HandleFunc) HandleFunc { return next }).
+			Get("/{id:[0-9]+}", handler).
+			Describe("Fetches a user").
+			Example(nil, map[string]any{"id": 1})
+	})
+	r.HandleFunc("/any", handler)
+
+	routes := r.RouteList()
+	assert.Equal(t, []Route{
+		{Method: "*", Pattern: "/any"},
+		{Method: http.MethodGet, Pattern: "/health"},
+		{Method: http.MethodGet, Pattern: "/users/", Description: "Lists users", Query: []string{"page", "limit"}},
+		{Method: http.MethodGet, Pattern: "/users/{id:[0-9]+}", Description: "Fetches a user", Examples: []RouteExample{{Response: map[string]any{"id": 1}}}},
+	}, routes)
+
+	// Metadata doesn't change routing
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestRoute_Params(t *testing.T) {
+	cases := []struct {
+		desc     string
+		pattern  string
+		expected []string
+	}{
+		{desc: "no params", pattern: "/users", expected: nil},
+		{desc: "params", pattern: "/users/{id}/posts/{postID}", expected: []string{"id", "postID"}},
+		{desc: "regexp", pattern: "/users/{id:[0-9]+}", expected: []string{"id"}},
+		{desc: "several in a segment", pattern: "/files/{name}.{ext}", expected: []string{"name", "ext"}},
+		{desc: "wildcard", pattern: "/static/*", expected: []string{"*"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Route{Pattern: tc.pattern}.Params())
+		})
+	}
+}
+
+func TestRoute_Log4xxMeta(t *testing.T) {
+	tests := []struct {
+		desc        string
+		meta        any
+		expectLevel string
+	}{
+		{desc: "without metadata", expectLevel: "WARN"},
+		{desc: "level name", meta: "info", expectLevel: "INFO"},
+		{desc: "slog level", meta: slog.LevelDebug, expectLevel: "DEBUG"},
+		{desc: "invalid level", meta: "quiet", expectLevel: "WARN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := middleware.DefaultLoggerConfig()
+			cfg.Logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			r := setupTestRouter()
+			r.UseHTTP(middleware.Logger(cfg))
+			route := r.Get("/users/{id}", func(c *Ctx) error {
+				return errors.NotFound("User not found", nil)
+			})
+			if tt.meta != nil {
+				route.Meta(Log4xxMeta, tt.meta)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			var record struct {
+				Level string `json:"level"`
+			}
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+			assert.Equal(t, tt.expectLevel, record.Level)
+		})
+	}
+}
+
+func TestCtx_RoutePattern(t *testing.T) {
+	var before, after, group, sub, handler string
+	r := setupTestRouter()
+	r.Use(func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			before = c.RoutePattern()
+			err := next(c)
+			after = c.RoutePattern()
+			return err
+		}
+	})
+	record := func(target *string) Middleware {
+		return func(next HandleFunc) HandleFunc {
+			return func(c *Ctx) error {
+				*target = c.RoutePattern()
+				return next(c)
+			}
+		}
+	}
+	ok := func(c *Ctx) error {
+		handler = c.RoutePattern()
+		return c.NoContent()
+	}
+
+	r.With(record(&group)).Get("/users/{id}", ok)
+	r.Route("/api", func(api Router) {
+		api.Use(record(&sub))
+		api.Get("/orders/{id:[0-9]+}", ok)
+		api.Get("/files/*", ok)
+	})
+
+	cases := []struct {
+		desc     string
+		method   string
+		path     string
+		expected string
+		target   *string
+	}{
+		{desc: "group middleware", method: http.MethodGet, path: "/users/42", expected: "/users/{id}", target: &group},
+		{desc: "sub-router middleware", method: http.MethodGet, path: "/api/orders/7", expected: "/api/orders/{id:[0-9]+}", target: &sub},
+		{desc: "wildcard", method: http.MethodGet, path: "/api/files/a/b.txt", expected: "/api/files/*", target: &sub},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			before, after, group, sub, handler = "", "", "", "", ""
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tc.expected, before, "global middleware before next")
+			assert.Equal(t, tc.expected, after, "global middleware after next")
+			assert.Equal(t, tc.expected, *tc.target)
+			assert.Equal(t, tc.expected, handler)
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		before, after = "x", "x"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, before)
+		assert.Empty(t, after)
+	})
+}
+
+func TestServer_EnableDocs(t *testing.T) {
+	s := &Server{router: setupTestRouter()}
+	s.router.Get("/users/{id}", func(c *Ctx) error { return c.NoContent() }).
+		Describe("Fetches a user").
+		Example(nil, map[string]any{"name": "john"})
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "docs are disabled by default")
+
+	s.EnableDocs("/docs")
+
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "/users/{id}")
+	assert.Contains(t, body, "Fetches a user")
+	assert.Contains(t, body, "<code>id</code>")
+	assert.Contains(t, body, "{\n  &#34;name&#34;: &#34;john&#34;\n}")
+	assert.NotContains(t, body, `<span class="pattern">/docs</span>`)
+}
+
+func TestCtx_Wildcard(t *testing.T) {
+	files := func(c *Ctx) error {
+		return c.SendString(c.Wildcard())
+	}
+
+	r := setupTestRouter()
+	r.Get("/files/*", files)
+	r.Get("/users/{id}", files)
+	r.Route("/api", func(api Router) {
+		api.Route("/v1", func(v1 Router) {
+			v1.Get("/files/*", files)
+		})
+	})
+	mounted := setupTestRouter()
+	mounted.Get("/files/*", files)
+	r.Mount("/storage", mounted)
+
+	cases := []struct {
+		desc     string
+		path     string
+		expected string
+	}{
+		{desc: "root", path: "/files/docs/readme.md", expected: "docs/readme.md"},
+		{desc: "empty remainder", path: "/files/", expected: ""},
+		{desc: "nested routes", path: "/api/v1/files/docs/readme.md", expected: "docs/readme.md"},
+		{desc: "mounted router", path: "/storage/files/docs/readme.md", expected: "docs/readme.md"},
+		{desc: "encoded characters", path: "/api/v1/files/my%20docs/r%C3%A9sum%C3%A9.pdf", expected: "my docs/résumé.pdf"},
+		{desc: "encoded slash", path: "/storage/files/a%2Fb/c", expected: "a/b/c"},
+		{desc: "no wildcard", path: "/users/42", expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.expected, w.Body.String())
+		})
+	}
+}
@@ -2,9 +2,15 @@
 package glib
 
 import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html"
 	"net/http"
+	"strings"
 
 	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/middleware"
 	"github.com/azizndao/glib/slog"
 	"github.com/azizndao/glib/validation"
 	"github.com/go-chi/chi/v5"
@@ -16,12 +22,35 @@ type router struct {
 	config    RouterConfig
 	logger    *slog.Logger
 	validator *validation.Validator
+	headers   *headerDefaults
+	registry  *routeRegistry
+	prefix    string  // pattern prefix of the sub-router
+	mounts    []int64 // mounts the sub-router is nested in
+	deferred  *deferredMiddlewares
+	services  *services
+}
+
+// Messages of the default 404 and 405 handlers, see RouterConfig.NotFoundMessage
+// and RouterConfig.MethodNotAllowedMessage
+const (
+	DefaultNotFoundMessage         = "Route not found"
+	DefaultMethodNotAllowedMessage = "Method not allowed"
+)
+
+// defaultMessage returns the message of a default error handler as a catalog
+// key, translated when the message catalog defines it
+func defaultMessage(message, fallback string) errors.Message {
+	if message == "" {
+		message = fallback
+	}
+	return errors.T(message)
 }
 
 // DefaultRouterOptions returns sensible default options
 func DefaultRouterOptions() RouterConfig {
 	return RouterConfig{
 		AutoHEAD:              true,
+		AutoOPTIONS:           true,
 		TrailingSlashRedirect: true,
 	}
 }
@@ -40,23 +69,109 @@ func Default(logger *slog.Logger, validator *validation.Validator, options ...Ro
 		config:    opts,
 		logger:    logger,
 		validator: validator,
+		headers:   &headerDefaults{},
+		registry:  &routeRegistry{},
+		services:  &services{},
+	}
+
+	if opts.CaseInsensitiveRouting {
+		chiRouter.Use(r.caseInsensitive)
 	}
 
 	// Custom 404 handler using Ctx
 	chiRouter.NotFound(r.wrapHandler(func(c *Ctx) error {
-		return errors.NotFound("Route not found", nil)
+		return errors.NotFound(defaultMessage(opts.NotFoundMessage, DefaultNotFoundMessage), nil)
 	}))
 
-	// Custom 405 handler using Ctx
+	// Custom 405 handler using Ctx, listing the allowed methods
 	chiRouter.MethodNotAllowed(r.wrapHandler(func(c *Ctx) error {
-		return errors.MethodNotAllowed("Method not allowed", nil)
+		allowed := r.allowedMethods(c.Request)
+		c.Set("Allow", strings.Join(allowed, ", "))
+		if opts.AutoOPTIONS && c.Request.Method == http.MethodOptions {
+			return c.NoContent()
+		}
+		return errors.MethodNotAllowed(map[string]any{
+			"message":         defaultMessage(opts.MethodNotAllowedMessage, DefaultMethodNotAllowedMessage),
+			"allowed_methods": allowed,
+		}, nil)
 	}))
 
 	return r
 }
 
-// ServeHTTP implements http.Handler
+// standardMethods are the methods routed by chi, in the order of the Allow header
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// allowedMethods returns the methods of the route matching the request path,
+// OPTIONS included with RouterConfig.AutoOPTIONS. chi doesn't expose the
+// methods it found, so each one is matched again from the top-level router,
+// covering the mounted sub-routers with the full path.
+func (r *router) allowedMethods(req *http.Request) []string {
+	var routes chi.Routes = r.chi
+	if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.Routes != nil {
+		routes = rctx.Routes
+	}
+	path := cmp.Or(req.URL.RawPath, req.URL.Path, "/")
+	if r.config.CaseInsensitiveRouting {
+		path = foldCase(path)
+	}
+
+	var allowed []string
+	for _, method := range standardMethods {
+		if routeHandles(routes, method, path) || method == http.MethodOptions && r.config.AutoOPTIONS {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// routeHandles reports whether the routes handle the method at the path. Unlike
+// chi's Find, the prefix of a mounted sub-router ("/api" for "/api/*") only
+// matches the methods of the "/" route of the sub-router, as when routing.
+func routeHandles(routes chi.Routes, method, path string) bool {
+	rctx := chi.NewRouteContext()
+	if routes.Find(rctx, method, path) == "" {
+		return false
+	}
+
+	// Follow the patterns matched by each router of the mounts
+	for _, pattern := range rctx.RoutePatterns {
+		route, ok := findRoute(routes, pattern)
+		if ok && route.SubRoutes != nil {
+			routes = route.SubRoutes
+			continue
+		}
+		if ok && (route.Handlers[method] != nil || route.Handlers["*"] != nil) {
+			return true
+		}
+		mount, ok := findRoute(routes, strings.TrimSuffix(pattern, "/")+"/*")
+		return ok && mount.SubRoutes != nil && routeHandles(mount.SubRoutes, method, "/")
+	}
+	return true
+}
+
+// findRoute returns the route of the router registered with the pattern
+func findRoute(routes chi.Routes, pattern string) (chi.Route, bool) {
+	for _, route := range routes.Routes() {
+		if route.Pattern == pattern {
+			return route, true
+		}
+	}
+	return chi.Route{}, false
+}
+
+// ServeHTTP implements http.Handler. The latencies of the requests are recorded
+// by the top-level router once the stats are enabled, see Server.EnableStats.
 func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if stats := r.config.stats; stats != nil && stats.enabled.Load() && chi.RouteContext(req.Context()) == nil {
+		if mux, ok := r.chi.(*chi.Mux); ok {
+			stats.serve(mux, w, req)
+			return
+		}
+	}
 	r.chi.ServeHTTP(w, req)
 }
 
@@ -80,6 +195,75 @@ func (r *router) Find(rctx *chi.Context, method, path string) string {
 	return r.chi.Find(rctx, method, path)
 }
 
+// derive creates a router sharing this router's configuration on top of the given chi router
+func (r *router) derive(chiRouter chi.Router) *router {
+	return &router{
+		chi:       chiRouter,
+		config:    r.config,
+		logger:    r.logger,
+		validator: r.validator,
+		headers:   r.headers.child(),
+		registry:  r.registry,
+		prefix:    r.prefix,
+		mounts:    r.mounts,
+		services:  r.services,
+	}
+}
+
+// sub creates a sub-router mounted along the pattern on top of the given chi router
+func (r *router) sub(chiRouter chi.Router, pattern string, mount int64) *router {
+	sub := r.derive(chiRouter)
+	sub.prefix = r.prefix + strings.TrimSuffix(pattern, "/")
+	sub.mounts = append(append([]int64(nil), r.mounts...), mount)
+	return sub
+}
+
+// newCtx creates a Ctx for the request carrying the router's configuration.
+// The request logger, including the correlation ID as request_id (see
+// Ctx.CorrelationID), is stored in the request context (see slog.FromContext).
+func (r *router) newCtx(w http.ResponseWriter, req *http.Request) *Ctx {
+	ctx := newCtx(w, req, r.logger, r.validator)
+	if _, ok := slog.ContextLogger(req.Context()); !ok && r.logger != nil {
+		logger := r.logger.With("request_id", ctx.CorrelationID())
+		ctx.Request = req.WithContext(slog.NewContext(req.Context(), logger))
+	}
+
+	ctx.config = &r.config
+	ctx.services = r.services
+	ctx.scoped = scopedServices(req.Context())
+	return ctx
+}
+
+// acquireCtx returns the Ctx of the request, shared by the middlewares and the
+// handler writing to the response: the first of them creates it, the next ones
+// bind it to their router and request, restoring the returned binding once
+// they are done. owner reports whether the Ctx was created, its owner cleaning
+// it up once the request is handled. The router's default response headers are
+// set on the response.
+func (r *router) acquireCtx(rw *responseWriter, req *http.Request) (ctx *Ctx, prev ctxBinding, owner bool) {
+	r.headers.apply(rw)
+	if rw.ctx == nil {
+		rw.ctx = r.newCtx(rw, req)
+		return rw.ctx, ctxBinding{}, true
+	}
+
+	ctx = rw.ctx
+	prev = ctx.binding()
+	// a status set with Ctx.Status by a middleware that didn't respond is not
+	// carried over to the next middleware or handler
+	ctx.statusCode = rw.status
+	ctx.bind(ctxBinding{
+		request:   req,
+		response:  rw,
+		logger:    r.logger,
+		validator: r.validator,
+		config:    &r.config,
+		services:  r.services,
+		scoped:    scopedServices(req.Context()),
+	})
+	return ctx, prev, false
+}
+
 // Logger returns the logger instance for the router
 func (r *router) Logger() *slog.Logger {
 	return r.logger
@@ -87,9 +271,11 @@ func (r *router) Logger() *slog.Logger {
 
 // Use appends one or more middlewares onto the Router stack
 func (r *router) Use(middlewares ...Middleware) {
-	for _, mw := range middlewares {
-		r.chi.Use(r.convertMiddleware(mw))
+	converted := make([]func(http.Handler) http.Handler, len(middlewares))
+	for i, mw := range middlewares {
+		converted[i] = r.convertMiddleware(mw)
 	}
+	r.useHTTP(callSite(1), converted)
 }
 
 // With adds inline middlewares for an endpoint handler
@@ -99,129 +285,156 @@ func (r *router) With(middlewares ...Middleware) Router {
 		chiRouter = chiRouter.With(r.convertMiddleware(mw))
 	}
 
-	return &router{
-		chi:       chiRouter,
-		config:    r.config,
-		logger:    r.logger,
-		validator: r.validator,
-	}
+	return r.derive(chiRouter)
 }
 
 // Group adds a new inline-Router along the current routing path
 func (r *router) Group(fn func(r Router)) Router {
 	chiRouter := r.chi.Group(func(chiRouter chi.Router) {
-		router := &router{
-			chi:       chiRouter,
-			config:    r.config,
-			logger:    r.logger,
-			validator: r.validator,
-		}
-		fn(router)
+		fn(r.derive(chiRouter))
 	})
-	return &router{
-		chi:       chiRouter,
-		config:    r.config,
-		logger:    r.logger,
-		validator: r.validator,
-	}
+	return r.derive(chiRouter)
 }
 
-// Route mounts a sub-Router along a pattern string
+// Route mounts a sub-Router along a pattern string. Middlewares added to the
+// sub-router after its routes, within fn, are applied around the sub-router
+// when it is mounted, before the middlewares added ahead of the routes.
 func (r *router) Route(pattern string, fn func(r Router)) Router {
-	chiRouter := r.chi.Route(pattern, func(chiRouter chi.Router) {
-		subRouter := &router{
-			chi:       chiRouter,
-			config:    r.config,
-			logger:    r.logger,
-			validator: r.validator,
+	pattern = r.mountPattern(pattern)
+	mount := r.registerMount(pattern, callSite(1))
+	sub := r.sub(chi.NewRouter(), pattern, mount)
+	sub.deferred = &deferredMiddlewares{}
+	fn(sub)
+	sub.deferred.mounted = true
+
+	var handler http.Handler = sub.chi
+	if middlewares := sub.deferred.middlewares; len(middlewares) > 0 {
+		// chi only propagates the parent handlers to a mounted *chi.Mux
+		if parent, ok := r.chi.(*chi.Mux); ok {
+			if !sub.deferred.notFound {
+				sub.chi.NotFound(parent.NotFoundHandler())
+			}
+			if !sub.deferred.methodNotAllowed {
+				sub.chi.MethodNotAllowed(parent.MethodNotAllowedHandler())
+			}
 		}
-		fn(subRouter)
-	})
-	return &router{
-		chi:       chiRouter,
-		config:    r.config,
-		logger:    r.logger,
-		validator: r.validator,
+		handler = &deferredRouter{Router: sub.chi, handler: chi.Chain(middlewares...).Handler(sub.chi)}
 	}
+	r.chi.Mount(pattern, handler)
+	return sub
 }
 
 // Mount attaches another http.Handler along ./pattern/*
 func (r *router) Mount(pattern string, h http.Handler) {
+	pattern = r.mountPattern(pattern)
+	mount := r.registerMount(pattern, callSite(1))
+	if sub, ok := h.(*router); ok {
+		sub.services.mu.Lock()
+		sub.services.parent = r.services
+		sub.services.mu.Unlock()
+		sub.registry.link(registryLink{
+			registry: r.registry,
+			prefix:   r.prefix + strings.TrimSuffix(pattern, "/"),
+			within:   append(append([]int64(nil), r.mounts...), mount),
+		})
+	}
 	r.chi.Mount(pattern, h)
 }
 
+// mountPattern returns the pattern sub-routers are mounted with. With
+// RouterConfig.TrailingSlashRedirect, a trailing slash is removed so that both
+// "/api" and "/api/" dispatch to the "/" route of a sub-router mounted at
+// "/api/", like they do for a sub-router mounted at "/api".
+func (r *router) mountPattern(pattern string) string {
+	pattern = r.routePattern(pattern)
+	if r.config.TrailingSlashRedirect && len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
 // Handle adds routes for pattern that matches all HTTP methods
 func (r *router) Handle(pattern string, h http.Handler) {
+	pattern = r.routePattern(pattern)
+	r.register("*", pattern, callSite(1))
 	r.chi.Handle(pattern, h)
 }
 
 // HandleFunc adds routes for pattern that matches all HTTP methods
-func (r *router) HandleFunc(pattern string, h HandleFunc) {
-	r.chi.HandleFunc(pattern, r.wrapHandler(h))
+func (r *router) HandleFunc(pattern string, h HandleFunc) *Route {
+	return r.handle("", pattern, h)
 }
 
 // Method adds routes for pattern that matches the method HTTP method
 func (r *router) Method(method, pattern string, h http.Handler) {
+	pattern = r.routePattern(pattern)
+	r.register(strings.ToUpper(method), pattern, callSite(1))
 	r.chi.Method(method, pattern, h)
 }
 
 // MethodFunc adds routes for pattern that matches the method HTTP method
-func (r *router) MethodFunc(method, pattern string, h HandleFunc) {
-	r.chi.MethodFunc(method, pattern, r.wrapHandler(h))
+func (r *router) MethodFunc(method, pattern string, h HandleFunc) *Route {
+	return r.handle(method, pattern, h)
 }
 
 // Connect adds a CONNECT route
-func (r *router) Connect(pattern string, h HandleFunc) {
-	r.chi.Connect(pattern, r.wrapHandler(h))
+func (r *router) Connect(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodConnect, pattern, h)
 }
 
 // Delete adds a DELETE route
-func (r *router) Delete(pattern string, h HandleFunc) {
-	r.chi.Delete(pattern, r.wrapHandler(h))
+func (r *router) Delete(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodDelete, pattern, h)
 }
 
 // Get adds a GET route
-func (r *router) Get(pattern string, h HandleFunc) {
-	r.chi.Get(pattern, r.wrapHandler(h))
+func (r *router) Get(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodGet, pattern, h)
 }
 
 // Head adds a HEAD route
-func (r *router) Head(pattern string, h HandleFunc) {
-	r.chi.Head(pattern, r.wrapHandler(h))
+func (r *router) Head(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodHead, pattern, h)
 }
 
 // Options adds an OPTIONS route
-func (r *router) Options(pattern string, h HandleFunc) {
-	r.chi.Options(pattern, r.wrapHandler(h))
+func (r *router) Options(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodOptions, pattern, h)
 }
 
 // Patch adds a PATCH route
-func (r *router) Patch(pattern string, h HandleFunc) {
-	r.chi.Patch(pattern, r.wrapHandler(h))
+func (r *router) Patch(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodPatch, pattern, h)
 }
 
 // Post adds a POST route
-func (r *router) Post(pattern string, h HandleFunc) {
-	r.chi.Post(pattern, r.wrapHandler(h))
+func (r *router) Post(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodPost, pattern, h)
 }
 
 // Put adds a PUT route
-func (r *router) Put(pattern string, h HandleFunc) {
-	r.chi.Put(pattern, r.wrapHandler(h))
+func (r *router) Put(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodPut, pattern, h)
 }
 
 // Trace adds a TRACE route
-func (r *router) Trace(pattern string, h HandleFunc) {
-	r.chi.Trace(pattern, r.wrapHandler(h))
+func (r *router) Trace(pattern string, h HandleFunc) *Route {
+	return r.handle(http.MethodTrace, pattern, h)
 }
 
 // NotFound defines a handler to respond whenever a route could not be found
 func (r *router) NotFound(h HandleFunc) {
+	if r.deferred != nil {
+		r.deferred.notFound = true
+	}
 	r.chi.NotFound(r.wrapHandler(h))
 }
 
 // MethodNotAllowed defines a handler to respond whenever a method is not allowed
 func (r *router) MethodNotAllowed(h HandleFunc) {
+	if r.deferred != nil {
+		r.deferred.methodNotAllowed = true
+	}
 	r.chi.MethodNotAllowed(r.wrapHandler(h))
 }
 
@@ -229,81 +442,223 @@ func (r *router) MethodNotAllowed(h HandleFunc) {
 // This is the bridge between your Ctx abstraction and Chi's http.Handler
 func (r *router) wrapHandler(handler HandleFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		// Create Ctx wrapper for this request
-		ctx := newCtx(w, req, r.logger, r.validator)
+		// Share the Ctx of the middlewares handling the request
+		rw := newResponseWriter(w, req)
+		ctx, prev, owner := r.acquireCtx(rw, req)
+		r.limitResponse(rw, ctx)
+		r.startTiming(rw)
+		if owner {
+			defer r.startTrace(rw, ctx)()
+			defer ctx.removeTempFiles()
+			defer ctx.endStream()
+		} else {
+			defer ctx.bind(prev)
+		}
 
 		// Execute the handler with Ctx
-		if err := handler(ctx); err != nil {
-			var glibErr *errors.ApiError
-
-			switch t := err.(type) {
-			case *errors.ApiError:
-				glibErr = t
-			default:
-				glibErr = errors.InternalServerError("Server Error", err)
-			}
+		exit := ctx.traceSpan("handler")
+		err := handler(ctx)
+		exit()
+		if rw.discardTooLarge() {
+			err = ErrResponseTooLarge
+		}
+		if err != nil {
+			r.renderError(ctx, errors.FromHandler(err), "Server Error")
+		}
 
-			// Set default data if nil
-			data := glibErr.Data
-			if data == nil {
-				data = http.StatusText(glibErr.Code)
-			}
+		rw.finish()
+	}
+}
 
-			// Send error response using Ctx
-			ctx.Status(glibErr.Code).JSON(glibErr)
+// limitResponse applies RouterConfig.MaxResponseBytes to the response, unless
+// it was already done by a middleware handling the request. Exceeding the limit
+// is logged with the route pattern and counted in the "response_too_large_total"
+// metric.
+func (r *router) limitResponse(rw *responseWriter, ctx *Ctx) {
+	if rw.onTooLarge != nil {
+		return
+	}
+	rw.maxSize = r.config.MaxResponseBytes
+	rw.onTooLarge = func(size int64) {
+		route := ctx.RoutePattern()
+		ctx.Logger().ErrorCtx(ctx.Context(), ErrResponseTooLarge,
+			"route", route,
+			"size", size,
+			"max_size", rw.maxSize,
+		)
+		if r.config.Metrics != nil {
+			r.config.Metrics.Counter("response_too_large_total", 1, "route", route)
 		}
 	}
 }
 
 // convertMiddleware converts a Ctx-based Middleware to Chi middleware
-// This allows your existing middleware to work seamlessly with Chi
+// This allows your existing middleware to work seamlessly with Chi.
+// The middleware is composed once with the next handler of the chain, and the
+// Ctx is shared with the next middlewares and the handler (see acquireCtx), so
+// that a request allocates a single Ctx whatever the number of middlewares.
 func (r *router) convertMiddleware(mw Middleware) func(http.Handler) http.Handler {
+	name := middlewareName(mw)
 	return func(next http.Handler) http.Handler {
+		h := mw(func(c *Ctx) error {
+			// Execute next middleware/handler in the chain
+			next.ServeHTTP(c.Response, c.Request)
+			return nil
+		})
+
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			// Create Ctx wrapper
-			ctx := newCtx(w, req, r.logger, r.validator)
-
-			// Wrap the next handler as a Ctx Handler
-			nextHandler := func(c *Ctx) error {
-				// Execute next middleware/handler in the chain
-				next.ServeHTTP(c.Response, c.Request)
-				return nil
+			// Share the response writer with the handler to know whether it responded
+			_, wrapped := w.(*responseWriter)
+			rw := newResponseWriter(w, req)
+
+			ctx, prev, owner := r.acquireCtx(rw, req)
+			r.limitResponse(rw, ctx)
+			r.startTiming(rw)
+			if owner {
+				defer r.startTrace(rw, ctx)()
+				defer ctx.removeTempFiles()
+				defer ctx.endStream()
+			} else {
+				defer ctx.bind(prev)
 			}
 
 			// Execute middleware with Ctx
-			if err := mw(nextHandler)(ctx); err != nil {
-				// Handle middleware error
-				var glibErr *errors.ApiError
-
-				switch t := err.(type) {
-				case *errors.ApiError:
-					glibErr = t
-				default:
-					glibErr = errors.InternalServerError("Middleware Error", err)
-				}
-
-				data := glibErr.Data
-				if data == nil {
-					data = http.StatusText(glibErr.Code)
-				}
-
-				ctx.Status(glibErr.Code).JSON(glibErr)
+			exit := ctx.traceSpan(name)
+			err := h(ctx)
+			exit()
+			if err != nil {
+				r.renderError(ctx, errors.FromMiddleware(name, err), "Middleware Error")
+			}
+
+			if !wrapped {
+				rw.finish()
 			}
 		})
 	}
 }
 
+// renderError sends the error as a JSON ApiError response. Errors that are not
+// ApiErrors are rendered as 500 with the given message. 5xx errors are sent to
+// the error reporter with the origin of the error (handler or middleware name).
+// Nothing is rendered when the response was already started, so that an error
+// returned after responding doesn't corrupt the response.
+func (r *router) renderError(ctx *Ctx, err error, message string) {
+	var glibErr *errors.ApiError
+
+	origin := errors.OriginOf(err)
+	cause := err
+	if origin != nil {
+		cause = origin.Err
+	}
+
+	switch t := cause.(type) {
+	case *errors.ApiError:
+		if t == nil {
+			// Typed nil (e.g. an empty Collector's Result), nothing to render
+			return
+		}
+		glibErr = t
+	default:
+		glibErr = errors.InternalServerError(message, cause)
+	}
+
+	if r.config.ErrorHandler != nil {
+		r.config.ErrorHandler(ctx, err)
+	}
+
+	if glibErr.Code >= http.StatusInternalServerError && r.config.ErrorReporter != nil {
+		meta := middleware.RequestMeta(ctx.Request)
+		if origin != nil {
+			meta["origin"] = origin.Origin()
+		}
+		r.config.ErrorReporter.Report(ctx.Context(), glibErr, meta)
+	}
+
+	if rw, ok := ctx.Response.(*responseWriter); ok && rw.wroteHeader {
+		return
+	}
+
+	// Set default data if nil
+	data := glibErr.Data
+	if data == nil {
+		data = http.StatusText(glibErr.Code)
+	}
+
+	// Send error response using Ctx, resolving translatable messages
+	ctx.Status(glibErr.Code)
+	ctx.writeChallenges(glibErr.Code)
+	if !r.config.JSONErrors && !ctx.WantsJSON() {
+		ctx.HTML(errorPage(glibErr.Code, ctx.localize(data)))
+		return
+	}
+	// Server errors carry the correlation ID, for users to quote to support
+	var requestID string
+	if glibErr.Code >= http.StatusInternalServerError {
+		requestID = ctx.CorrelationID()
+	}
+	if r.config.ErrorMediaType == MIMEProblemJSON {
+		problem := errors.NewProblem(glibErr.Code, ctx.localize(data), ctx.Path())
+		problem.RequestID = requestID
+		r.writeErrorJSON(ctx, MIMEProblemJSON, problem, glibErr)
+		return
+	}
+	body := errors.NewApi(glibErr.Code, ctx.localize(data), glibErr)
+	body.RequestID = requestID
+	r.writeErrorJSON(ctx, MIMEApplicationJSON+"; charset=utf-8", errors.Body(body), glibErr)
+}
+
+// writeErrorJSON sends the error payload. When it can't be marshaled, e.g. its
+// data has a failing json.Marshaler, the failure is logged with the original
+// error and a minimal 500 payload is sent instead, so the client never gets an
+// empty response.
+func (r *router) writeErrorJSON(ctx *Ctx, contentType string, payload any, original *errors.ApiError) {
+	encoded, err := marshalJSON(r.config.JSON, payload)
+	if err != nil {
+		ctx.Logger().ErrorCtx(ctx.Context(), errors.Errorf("failed to marshal error response: %w", err),
+			"status", original.Code,
+			"original_error", original.Error(),
+		)
+
+		code := http.StatusInternalServerError
+		if contentType == MIMEProblemJSON {
+			payload = errors.NewProblem(code, http.StatusText(code), ctx.Path())
+		} else {
+			payload = errors.Body(errors.NewApi(code, http.StatusText(code), nil))
+		}
+		encoded, _ = json.Marshal(payload)
+		ctx.Status(code)
+	}
+
+	ctx.Set("Content-Type", contentType)
+	ctx.Response.WriteHeader(ctx.statusCode)
+	_, _ = ctx.Response.Write(append(encoded, '\n'))
+}
+
+// errorPage renders an error as a minimal HTML page, for the clients preferring
+// HTML such as browsers. Data other than a message is replaced by the status text.
+func errorPage(code int, data any) []byte {
+	title := fmt.Sprintf("%d %s", code, http.StatusText(code))
+	var message string
+	switch d := data.(type) {
+	case string:
+		message = d
+	case fmt.Stringer:
+		message = d.String()
+	default:
+		message = http.StatusText(code)
+	}
+
+	return fmt.Appendf(nil, "<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>%s</title></head>\n<body>\n<h1>%s</h1>\n<p>%s</p>\n</body>\n</html>\n",
+		html.EscapeString(title), html.EscapeString(title), html.EscapeString(message))
+}
+
 // UseHTTP is a convenience method to add Chi middleware directly to the router.
 // It converts the Chi middleware to router.Middleware automatically.
 //
 // Example usage:
 //
-//	import chimiddleware "github.com/go-chi/chi/v5/middleware"
-//
 //	router.UseHTTP(chimiddleware.StripSlashes)
-//	router.UseHTTP(chimiddleware.Heartbeat("/ping"))
+//	router.UseHTTP(chimiddleware.CleanPath)
 func (r *router) UseHTTP(chiMiddlewares ...func(http.Handler) http.Handler) {
-	for _, chiMw := range chiMiddlewares {
-		r.chi.Use(chiMw)
-	}
+	r.useHTTP(callSite(1), chiMiddlewares)
 }
@@ -2,15 +2,19 @@ package glib
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	stdslog "log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/i18n"
 	"github.com/azizndao/glib/slog"
 	"github.com/azizndao/glib/validation"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -266,6 +270,121 @@ func TestRouter_Middleware(t *testing.T) {
 		assert.False(t, handlerCalled, "handler should not be called")
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
+
+	t.Run("middleware composed once and Ctx shared", func(t *testing.T) {
+		r := setupTestRouter()
+		composed := 0
+		var ctxs []*Ctx
+
+		readBody := func(next HandleFunc) HandleFunc {
+			composed++
+			return func(c *Ctx) error {
+				ctxs = append(ctxs, c)
+				body, err := c.Body()
+				require.NoError(t, err)
+				assert.Equal(t, `{"name":"Jane"}`, string(body))
+				return next(c)
+			}
+		}
+
+		r.Use(readBody, readBody)
+		r.Post("/users", func(c *Ctx) error {
+			ctxs = append(ctxs, c)
+			body, err := c.Body()
+			require.NoError(t, err)
+			return c.Status(http.StatusCreated).SendString(string(body))
+		})
+
+		for range 2 {
+			ctxs = nil
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"Jane"}`)))
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, `{"name":"Jane"}`, w.Body.String(), "the body read by the middlewares is available to the handler")
+			require.Len(t, ctxs, 3)
+			assert.Same(t, ctxs[0], ctxs[1])
+			assert.Same(t, ctxs[0], ctxs[2])
+		}
+		assert.Equal(t, 2, composed, "middlewares are composed when the routes are registered")
+	})
+
+	t.Run("Ctx binding restored after next", func(t *testing.T) {
+		r := setupTestRouter()
+		type key struct{}
+
+		r.Use(func(next HandleFunc) HandleFunc {
+			return func(c *Ctx) error {
+				req := c.Request
+				err := next(c)
+				assert.Same(t, req, c.Request, "the request of the middleware is restored")
+				return err
+			}
+		})
+		r.UseHTTP(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), key{}, "value")))
+			})
+		})
+		r.Get("/", func(c *Ctx) error {
+			return c.SendString(c.Context().Value(key{}).(string))
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "value", w.Body.String(), "the handler gets the request of the native middleware")
+	})
+
+	t.Run("status of a middleware not carried over", func(t *testing.T) {
+		r := setupTestRouter()
+		r.Use(func(next HandleFunc) HandleFunc {
+			return func(c *Ctx) error {
+				c.Status(http.StatusTeapot).Set("X-Middleware", "tea")
+				err := next(c)
+				assert.True(t, c.IsSuccess(), "the status of the response is seen after next")
+				return err
+			}
+		})
+		r.Get("/", func(c *Ctx) error {
+			return c.SendString("ok")
+		})
+		r.Get("/created", func(c *Ctx) error {
+			return c.Status(http.StatusCreated).SendString("created")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "tea", w.Header().Get("X-Middleware"), "the headers are shared")
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/created", nil))
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func BenchmarkRouter_Middleware(b *testing.B) {
+	r := setupTestRouter()
+	for range 5 {
+		r.Use(func(next HandleFunc) HandleFunc {
+			return func(c *Ctx) error {
+				return next(c)
+			}
+		})
+	}
+	r.Get("/users/{id}", func(c *Ctx) error {
+		return c.NoContent()
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-Request-ID", "bench")
+	w := &discardResponseWriter{header: http.Header{}}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		clear(w.header)
+		r.ServeHTTP(w, req)
+	}
 }
 
 func TestRouter_SubRouter(t *testing.T) {
@@ -426,6 +545,66 @@ func TestRouter_ErrorHandling(t *testing.T) {
 	})
 }
 
+func TestRouter_ErrorFieldNames(t *testing.T) {
+	errors.SetFieldNames("status", "details")
+	errors.SetMessageField("message")
+	t.Cleanup(func() {
+		errors.SetFieldNames("", "")
+		errors.SetMessageField("")
+	})
+
+	r := setupTestRouter()
+	r.Get("/", func(c *Ctx) error {
+		return errors.NotFound("User not found", nil)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"status":404,"details":"User not found","message":"User not found"}`, w.Body.String())
+}
+
+// failingMarshaler is error data whose MarshalJSON fails
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("marshal failed")
+}
+
+func TestRouter_ErrorMarshalFallback(t *testing.T) {
+	cases := []struct {
+		desc      string
+		data      any
+		mediaType string
+		expected  string
+	}{
+		{desc: "failing marshaler", data: failingMarshaler{}, expected: `{"code":500,"data":"Internal Server Error"}`},
+		{desc: "channel", data: map[string]any{"ch": make(chan int)}, expected: `{"code":500,"data":"Internal Server Error"}`},
+		{desc: "problem details", data: failingMarshaler{}, mediaType: MIMEProblemJSON, expected: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"Internal Server Error","instance":"/error"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			config := DefaultRouterOptions()
+			config.ErrorMediaType = tc.mediaType
+			r := Default(slog.New(stdslog.NewJSONHandler(buf, nil)), validation.New(validation.DefaultValidatorConfig()), config)
+			r.Get("/error", func(c *Ctx) error {
+				return errors.NewApi(http.StatusConflict, tc.data, errors.New("duplicate user"))
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/error", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.JSONEq(t, tc.expected, w.Body.String())
+			assert.Contains(t, buf.String(), "failed to marshal error response")
+			assert.Contains(t, buf.String(), `"original_error":"duplicate user"`)
+		})
+	}
+}
+
 func TestRouter_AutoHEAD(t *testing.T) {
 	t.Run("explicit HEAD route", func(t *testing.T) {
 		r := setupTestRouter()
@@ -539,6 +718,37 @@ func TestRouter_ContextIntegration(t *testing.T) {
 	})
 }
 
+func TestRouter_RequestLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(stdslog.NewJSONHandler(buf, nil))
+	r := Default(logger, validation.New(validation.DefaultValidatorConfig()))
+	r.UseHTTP(chimiddleware.RequestID)
+
+	r.Use(func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			slog.With(c.Context(), "tenant", "acme")
+			return next(c)
+		}
+	})
+	r.Get("/", func(c *Ctx) error {
+		ctx := c.Context()
+		slog.FromContext(ctx).InfoContext(ctx, "from service")
+		c.Logger().Info("from handler")
+		return c.NoContent()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(chimiddleware.RequestIDHeader, "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Contains(t, line, `"request_id":"req-1"`)
+		assert.Contains(t, line, `"tenant":"acme"`)
+	}
+}
+
 func TestRouter_WildcardRoutes(t *testing.T) {
 	r := setupTestRouter()
 
@@ -578,6 +788,71 @@ func TestRouter_Route(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "standard handler")
 }
 
+func TestRouter_MountRoot(t *testing.T) {
+	newRouter := func(trailingSlashRedirect bool) Router {
+		config := DefaultRouterOptions()
+		config.TrailingSlashRedirect = trailingSlashRedirect
+		r := Default(slog.DiscardLogger(), nil, config)
+		r.NotFound(func(c *Ctx) error {
+			return c.Status(http.StatusNotFound).SendString("not found")
+		})
+
+		r.Route("/api", func(r Router) {
+			r.Get("/", func(c *Ctx) error { return c.SendString("api") })
+			r.Route("/v1/", func(r Router) {
+				r.Get("/", func(c *Ctx) error { return c.SendString("v1") })
+			})
+		})
+		r.Route("/docs/", func(r Router) {
+			r.Get("/", func(c *Ctx) error { return c.SendString("docs") })
+		})
+		r.Route("/shop", func(r Router) {
+			r.NotFound(func(c *Ctx) error {
+				return c.Status(http.StatusNotFound).SendString("shop not found")
+			})
+			r.Get("/cart", func(c *Ctx) error { return c.SendString("cart") })
+		})
+
+		admin := Default(slog.DiscardLogger(), nil, config)
+		admin.Get("/", func(c *Ctx) error { return c.SendString("admin") })
+		r.Mount("/admin/", admin)
+		return r
+	}
+
+	tests := []struct {
+		desc                  string
+		trailingSlashRedirect bool
+		path                  string
+		code                  int
+		body                  string
+	}{
+		{desc: "prefix", trailingSlashRedirect: true, path: "/api", code: http.StatusOK, body: "api"},
+		{desc: "prefix with slash", trailingSlashRedirect: true, path: "/api/", code: http.StatusOK, body: "api"},
+		{desc: "nested prefix", trailingSlashRedirect: true, path: "/api/v1", code: http.StatusOK, body: "v1"},
+		{desc: "nested prefix with slash", trailingSlashRedirect: true, path: "/api/v1/", code: http.StatusOK, body: "v1"},
+		{desc: "registered with slash", trailingSlashRedirect: true, path: "/docs", code: http.StatusOK, body: "docs"},
+		{desc: "registered with slash, requested with slash", trailingSlashRedirect: true, path: "/docs/", code: http.StatusOK, body: "docs"},
+		{desc: "mounted router", trailingSlashRedirect: true, path: "/admin", code: http.StatusOK, body: "admin"},
+		{desc: "mounted router with slash", trailingSlashRedirect: true, path: "/admin/", code: http.StatusOK, body: "admin"},
+		{desc: "without root route", trailingSlashRedirect: true, path: "/shop", code: http.StatusNotFound, body: "shop not found"},
+		{desc: "without root route with slash", trailingSlashRedirect: true, path: "/shop/", code: http.StatusNotFound, body: "shop not found"},
+		{desc: "disabled: registered with slash", path: "/docs", code: http.StatusNotFound, body: "not found"},
+		{desc: "disabled: registered with slash, requested with slash", path: "/docs/", code: http.StatusOK, body: "docs"},
+		{desc: "disabled: prefix", path: "/api", code: http.StatusOK, body: "api"},
+	}
+
+	routers := map[bool]Router{true: newRouter(true), false: newRouter(false)}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			routers[tt.trailingSlashRedirect].ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
+
 func TestRouter_ComplexScenario(t *testing.T) {
 	r := setupTestRouter()
 	var calls []string
@@ -669,3 +944,235 @@ func TestRouter_ComplexScenario(t *testing.T) {
 		assert.Equal(t, "123", resp["id"])
 	})
 }
+
+func TestRouter_LocalizedErrors(t *testing.T) {
+	catalog := i18n.New()
+	catalog.Add("en", "user.not_found", "User {id} not found")
+	catalog.Add("fr", "user.not_found", "Utilisateur {id} introuvable")
+
+	opts := DefaultRouterOptions()
+	opts.MessageCatalog = catalog
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), opts)
+
+	r.Get("/users/{id}", func(c *Ctx) error {
+		return errors.NotFound(errors.T("user.not_found", "id", c.PathValue("id")), nil)
+	})
+	r.Get("/unknown", func(c *Ctx) error {
+		return errors.BadRequest(errors.T("unknown.key"), nil)
+	})
+
+	cases := []struct {
+		desc     string
+		path     string
+		lang     string
+		expected string
+	}{
+		{desc: "default locale", path: "/users/5", expected: "User 5 not found"},
+		{desc: "negotiated locale", path: "/users/5", lang: "fr-FR,fr;q=0.9", expected: "Utilisateur 5 introuvable"},
+		{desc: "unknown key", path: "/unknown", lang: "fr", expected: "unknown.key"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			if tc.lang != "" {
+				req.Header.Set("Accept-Language", tc.lang)
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			var resp map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expected, resp["data"])
+		})
+	}
+}
+
+func TestRouter_DefaultErrorMessages(t *testing.T) {
+	catalog := i18n.New()
+	catalog.Add("fr", "errors.not_found", "Page introuvable")
+
+	cases := []struct {
+		desc     string
+		config   RouterConfig
+		custom   bool
+		method   string
+		path     string
+		lang     string
+		expected string
+	}{
+		{desc: "default 404", method: http.MethodGet, path: "/missing", expected: DefaultNotFoundMessage},
+		{desc: "default 405", method: http.MethodPost, path: "/users", expected: DefaultMethodNotAllowedMessage},
+		{
+			desc:     "custom 404",
+			config:   RouterConfig{NotFoundMessage: "Nothing here"},
+			method:   http.MethodGet,
+			path:     "/missing",
+			expected: "Nothing here",
+		},
+		{
+			desc:     "custom 405",
+			config:   RouterConfig{MethodNotAllowedMessage: "Nope"},
+			method:   http.MethodPost,
+			path:     "/users",
+			expected: "Nope",
+		},
+		{
+			desc:     "translated message",
+			config:   RouterConfig{NotFoundMessage: "errors.not_found", MessageCatalog: catalog},
+			method:   http.MethodGet,
+			path:     "/missing",
+			lang:     "fr",
+			expected: "Page introuvable",
+		},
+		{
+			desc:     "registered handler takes precedence",
+			config:   RouterConfig{NotFoundMessage: "Nothing here"},
+			custom:   true,
+			method:   http.MethodGet,
+			path:     "/missing",
+			expected: "custom",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), tc.config)
+			if tc.custom {
+				r.NotFound(func(c *Ctx) error {
+					return errors.NotFound("custom", nil)
+				})
+			}
+			r.Get("/users", func(c *Ctx) error { return c.NoContent() })
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.lang != "" {
+				req.Header.Set("Accept-Language", tc.lang)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var resp map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			data := resp["data"]
+			if w.Code == http.StatusMethodNotAllowed {
+				data = data.(map[string]any)["message"]
+			}
+			assert.Equal(t, tc.expected, data)
+		})
+	}
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	cases := []struct {
+		desc        string
+		config      RouterConfig
+		method      string
+		path        string
+		expectCode  int
+		expectAllow string
+	}{
+		{
+			desc:        "one method",
+			method:      http.MethodPost,
+			path:        "/health",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "GET",
+		},
+		{
+			desc:        "several methods",
+			method:      http.MethodPatch,
+			path:        "/users/5",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "GET, PUT, DELETE",
+		},
+		{
+			desc:        "mounted sub-router",
+			method:      http.MethodGet,
+			path:        "/api/v1/orders",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "POST",
+		},
+		{
+			desc:        "nested sub-router",
+			method:      http.MethodPost,
+			path:        "/api/v1/orders/7/items",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "GET, DELETE",
+		},
+		{
+			desc:        "case-insensitive routing",
+			config:      RouterConfig{CaseInsensitiveRouting: true},
+			method:      http.MethodPost,
+			path:        "/Users/5",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "GET, PUT, DELETE",
+		},
+		{
+			desc:        "auto OPTIONS listed",
+			config:      RouterConfig{AutoOPTIONS: true},
+			method:      http.MethodPost,
+			path:        "/users/5",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "GET, PUT, DELETE, OPTIONS",
+		},
+		{
+			desc:        "OPTIONS with auto OPTIONS",
+			config:      RouterConfig{AutoOPTIONS: true},
+			method:      http.MethodOptions,
+			path:        "/api/v1/orders/7/items",
+			expectCode:  http.StatusNoContent,
+			expectAllow: "GET, DELETE, OPTIONS",
+		},
+		{
+			desc:        "OPTIONS without auto OPTIONS",
+			method:      http.MethodOptions,
+			path:        "/users/5",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "GET, PUT, DELETE",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), tc.config)
+			ok := func(c *Ctx) error { return c.NoContent() }
+			r.Get("/health", ok)
+			r.Get("/users/{id}", ok)
+			r.Put("/users/{id}", ok)
+			r.Delete("/users/{id}", ok)
+
+			api := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), tc.config)
+			api.Route("/v1/orders", func(r Router) {
+				r.Post("/", ok)
+				r.Route("/{id}/items", func(r Router) {
+					r.Get("/", ok)
+					r.Delete("/", ok)
+				})
+			})
+			r.Mount("/api", api)
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectCode, w.Code)
+			assert.Equal(t, tc.expectAllow, w.Header().Get("Allow"))
+			if tc.expectCode != http.StatusMethodNotAllowed {
+				assert.Empty(t, w.Body.String())
+				return
+			}
+
+			var resp struct {
+				Data struct {
+					Message        string   `json:"message"`
+					AllowedMethods []string `json:"allowed_methods"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, DefaultMethodNotAllowedMessage, resp.Data.Message)
+			assert.Equal(t, strings.Split(tc.expectAllow, ", "), resp.Data.AllowedMethods)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package glib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrNotProvided is returned by TryResolve when no value of the type was provided
+var ErrNotProvided = errors.New("glib: service not provided")
+
+// serviceKey identifies the services by type without reflection
+type serviceKey[T any] struct{}
+
+// services holds the values provided to a router with Provide, by type. The
+// services of a router mounted on another one fall back to the parent ones.
+type services struct {
+	mu     sync.RWMutex
+	values map[any]any
+	parent *services
+}
+
+func (s *services) get(key any) (any, bool) {
+	for s != nil {
+		s.mu.RLock()
+		value, ok := s.values[key]
+		parent := s.parent
+		s.mu.RUnlock()
+		if ok {
+			return value, true
+		}
+		s = parent
+	}
+	return nil, false
+}
+
+func (s *services) set(key, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[any]any)
+	}
+	s.values[key] = value
+}
+
+// scopedServicesKey is the request context key of the values provided with ProvideScoped
+type scopedServicesKey struct{}
+
+// scopedServices returns the values provided for the request with ProvideScoped
+func scopedServices(ctx context.Context) map[any]any {
+	scoped, _ := ctx.Value(scopedServicesKey{}).(map[any]any)
+	return scoped
+}
+
+// Provide registers a value resolved by type in the handlers of the server with
+// Resolve. Providing another value of the same type replaces it.
+//
+// Example:
+//
+//	glib.Provide(server, userRepository)
+//	glib.Provide[Mailer](server, smtpMailer) // resolved as the Mailer interface
+func Provide[T any](s *Server, value T) {
+	if r, ok := s.router.(*router); ok {
+		r.services.set(serviceKey[T]{}, value)
+	}
+}
+
+// ProvideScoped provides a value for the rest of the request, shadowing the one
+// provided to the server. Call it from a middleware, e.g. to provide a
+// transaction or the authenticated user.
+//
+// Example:
+//
+//	func WithTx(next glib.HandleFunc) glib.HandleFunc {
+//	    return func(c *glib.Ctx) error {
+//	        tx := db.Begin()
+//	        defer tx.Rollback()
+//	        glib.ProvideScoped(c, tx)
+//	        return next(c)
+//	    }
+//	}
+func ProvideScoped[T any](c *Ctx, value T) {
+	scoped := make(map[any]any, len(c.scoped)+1)
+	for key, v := range c.scoped {
+		scoped[key] = v
+	}
+	scoped[serviceKey[T]{}] = value
+
+	c.scoped = scoped
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), scopedServicesKey{}, scoped))
+}
+
+// TryResolve returns the value of type T provided for the request with
+// ProvideScoped or to the server with Provide, or ErrNotProvided
+func TryResolve[T any](c *Ctx) (T, error) {
+	key := serviceKey[T]{}
+	if value, ok := c.scoped[key]; ok {
+		return value.(T), nil
+	}
+	if value, ok := c.services.get(key); ok {
+		return value.(T), nil
+	}
+
+	var zero T
+	return zero, fmt.Errorf("%w: %s", ErrNotProvided, reflect.TypeFor[T]())
+}
+
+// Resolve returns the value of type T provided for the request or to the
+// server, or the zero value of T if none was provided
+//
+// Example:
+//
+//	users := glib.Resolve[*UserRepository](c)
+func Resolve[T any](c *Ctx) T {
+	value, _ := TryResolve[T](c)
+	return value
+}
+
+// MustResolve is like Resolve but panics if no value of type T was provided
+func MustResolve[T any](c *Ctx) T {
+	value, err := TryResolve[T](c)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
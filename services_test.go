@@ -0,0 +1,82 @@
+package glib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testGreeter interface {
+	Greet() string
+}
+
+type testGreeterFunc func() string
+
+func (f testGreeterFunc) Greet() string { return f() }
+
+func TestServices(t *testing.T) {
+	s := &Server{router: setupTestRouter()}
+	Provide[testGreeter](s, testGreeterFunc(func() string { return "hello" }))
+	Provide(s, 42)
+
+	s.router.Get("/server", func(c *Ctx) error {
+		assert.Equal(t, 42, MustResolve[int](c))
+		return c.SendString(Resolve[testGreeter](c).Greet())
+	})
+	s.router.With(func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			ProvideScoped[testGreeter](c, testGreeterFunc(func() string { return "scoped" }))
+			return next(c)
+		}
+	}).Get("/scoped", func(c *Ctx) error {
+		assert.Equal(t, 42, MustResolve[int](c), "server values remain available")
+		return c.SendString(Resolve[testGreeter](c).Greet())
+	})
+	s.router.Get("/missing", func(c *Ctx) error {
+		_, err := TryResolve[string](c)
+		require.ErrorIs(t, err, ErrNotProvided)
+		assert.Contains(t, err.Error(), "string")
+		assert.Empty(t, Resolve[string](c))
+		assert.Panics(t, func() { MustResolve[string](c) })
+		return c.NoContent()
+	})
+
+	sub := setupTestRouter()
+	sub.Get("/greet", func(c *Ctx) error {
+		return c.SendString(Resolve[testGreeter](c).Greet())
+	})
+	s.router.Mount("/sub", sub)
+
+	tests := []struct {
+		path   string
+		expect string
+	}{
+		{path: "/server", expect: "hello"},
+		{path: "/scoped", expect: "scoped"},
+		{path: "/sub/greet", expect: "hello"},
+		{path: "/missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			assert.Less(t, w.Code, http.StatusBadRequest)
+			assert.Equal(t, tt.expect, w.Body.String())
+		})
+	}
+}
+
+func BenchmarkResolve(b *testing.B) {
+	r := setupTestRouter()
+	s := &Server{router: r}
+	Provide(s, 42)
+	c := r.(*router).newCtx(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = Resolve[int](c)
+	}
+}
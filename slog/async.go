@@ -0,0 +1,221 @@
+package slog
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/azizndao/glib/util"
+)
+
+// DropPolicy selects the log lines dropped by an AsyncWriter when its buffer is full
+type DropPolicy string
+
+const (
+	// DropOldest drops the oldest buffered line to make room for the new one (default)
+	DropOldest DropPolicy = "oldest"
+	// DropNewest drops the new line
+	DropNewest DropPolicy = "newest"
+)
+
+const (
+	// DefaultAsyncBufferSize is the default number of lines buffered by an AsyncWriter
+	DefaultAsyncBufferSize = 1024
+	// DefaultAsyncFlushInterval is the default interval between the flushes of an AsyncWriter
+	DefaultAsyncFlushInterval = time.Second
+
+	// asyncBatchSize is the size of the batches written by an AsyncWriter
+	asyncBatchSize = 64 * 1024
+)
+
+// AsyncConfig holds configuration for asynchronous logging
+type AsyncConfig struct {
+	// BufferSize is the number of lines buffered before dropping (default: 1024)
+	BufferSize int
+
+	// FlushInterval is the maximum time a line stays in the batch before being
+	// written (default: 1s)
+	FlushInterval time.Duration
+
+	// DropPolicy selects the lines dropped when the buffer is full (default: DropOldest)
+	DropPolicy DropPolicy
+}
+
+// LoggerConfig holds configuration for the server logger
+type LoggerConfig struct {
+	// Debug uses the debug level and the DevMode handler instead of the JSON one
+	Debug bool
+
+	// Async writes the logs from a background goroutine, nil writes them synchronously
+	Async *AsyncConfig
+}
+
+// LoadLoggerConfig loads LoggerConfig from environment variables
+// Environment variables:
+//   - IS_DEBUG (bool): debug level and DevMode handler (default: false)
+//   - LOG_ASYNC (bool): write logs from a background goroutine (default: false)
+//   - LOG_BUFFER_SIZE (int): number of buffered lines in async mode (default: 1024)
+//   - LOG_FLUSH_INTERVAL (duration): interval between flushes in async mode (default: 1s)
+//   - LOG_DROP_POLICY (string): lines dropped when the buffer is full, "oldest" or "newest" (default: oldest)
+func LoadLoggerConfig() LoggerConfig {
+	cfg := LoggerConfig{Debug: util.GetEnvBool("IS_DEBUG", false)}
+	if util.GetEnvBool("LOG_ASYNC", false) {
+		cfg.Async = &AsyncConfig{
+			BufferSize:    util.GetEnvInt("LOG_BUFFER_SIZE", DefaultAsyncBufferSize),
+			FlushInterval: util.GetEnvDuration("LOG_FLUSH_INTERVAL", DefaultAsyncFlushInterval),
+			DropPolicy:    DropPolicy(util.GetEnv("LOG_DROP_POLICY", string(DropOldest))),
+		}
+	}
+	return cfg
+}
+
+// AsyncWriter writes to an io.Writer from a background goroutine, in batches
+// flushed periodically, so logging never blocks the request. Lines are dropped
+// according to the DropPolicy when the buffer is full.
+//
+//	w := slog.NewAsyncWriter(os.Stdout, slog.AsyncConfig{})
+//	defer w.Close(context.Background())
+//	logger := slog.CreateWithHandler(slog.NewHandler(false, w))
+type AsyncWriter struct {
+	out       io.Writer
+	lines     chan []byte
+	flushes   chan chan struct{}
+	policy    DropPolicy
+	interval  time.Duration
+	dropped   atomic.Uint64
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewAsyncWriter starts a background goroutine writing to w
+func NewAsyncWriter(w io.Writer, config AsyncConfig) *AsyncWriter {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultAsyncBufferSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultAsyncFlushInterval
+	}
+	if config.DropPolicy != DropNewest {
+		config.DropPolicy = DropOldest
+	}
+
+	a := &AsyncWriter{
+		out:      w,
+		lines:    make(chan []byte, config.BufferSize),
+		flushes:  make(chan chan struct{}),
+		policy:   config.DropPolicy,
+		interval: config.FlushInterval,
+		done:     make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	batch := bufio.NewWriterSize(a.out, asyncBatchSize)
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line, ok := <-a.lines:
+			if !ok {
+				_ = batch.Flush()
+				return
+			}
+			_, _ = batch.Write(line)
+		case <-ticker.C:
+			_ = batch.Flush()
+		case flushed := <-a.flushes:
+			a.drain(batch)
+			_ = batch.Flush()
+			close(flushed)
+		}
+	}
+}
+
+// drain writes the buffered lines to the batch
+func (a *AsyncWriter) drain(batch *bufio.Writer) {
+	for {
+		select {
+		case line, ok := <-a.lines:
+			if !ok {
+				return
+			}
+			_, _ = batch.Write(line)
+		default:
+			return
+		}
+	}
+}
+
+// Write buffers a copy of p without blocking. It always succeeds, the line is
+// dropped if the buffer is full (see DropPolicy) or the writer is closed.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	defer func() {
+		// Writing after Close drops the line
+		if recover() != nil {
+			a.dropped.Add(1)
+		}
+	}()
+
+	line := append([]byte(nil), p...)
+	for {
+		select {
+		case a.lines <- line:
+			return len(p), nil
+		default:
+		}
+
+		if a.policy == DropNewest {
+			a.dropped.Add(1)
+			return len(p), nil
+		}
+		select {
+		case <-a.lines:
+			a.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Dropped returns the number of lines dropped because the buffer was full
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Flush writes the buffered lines, waiting until they are written or the context is done
+func (a *AsyncWriter) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	select {
+	case a.flushes <- flushed:
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting lines and waits until the buffered ones are written or the context is done
+func (a *AsyncWriter) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() { close(a.lines) })
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
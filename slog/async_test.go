@@ -0,0 +1,130 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingWriter blocks the writes until it is released
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	writes  atomic.Int32
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.writes.Add(1)
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Run("flush", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewAsyncWriter(&buf, AsyncConfig{FlushInterval: time.Hour})
+		defer w.Close(t.Context())
+
+		logger := New(NewHandler(false, w))
+		logger.Info("first")
+		logger.Info("second")
+
+		require.NoError(t, w.Flush(t.Context()))
+		assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+		assert.Contains(t, buf.String(), `"msg":"second"`)
+	})
+
+	t.Run("periodic flush", func(t *testing.T) {
+		out := &blockingWriter{release: make(chan struct{})}
+		close(out.release)
+		w := NewAsyncWriter(out, AsyncConfig{FlushInterval: 10 * time.Millisecond})
+		defer w.Close(t.Context())
+
+		_, _ = w.Write([]byte("line\n"))
+		assert.Eventually(t, func() bool { return out.String() == "line\n" }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("close writes buffered lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewAsyncWriter(&buf, AsyncConfig{FlushInterval: time.Hour})
+		_, _ = w.Write([]byte("line\n"))
+
+		require.NoError(t, w.Close(t.Context()))
+		assert.Equal(t, "line\n", buf.String())
+
+		_, err := w.Write([]byte("after close\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), w.Dropped())
+	})
+
+	tests := []struct {
+		desc   string
+		policy DropPolicy
+		expect string
+	}{
+		{desc: "drop oldest", policy: DropOldest, expect: "0\n3\n4\n"},
+		{desc: "drop newest", policy: DropNewest, expect: "0\n1\n2\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			out := &blockingWriter{release: make(chan struct{})}
+			w := NewAsyncWriter(out, AsyncConfig{BufferSize: 2, DropPolicy: test.policy, FlushInterval: time.Hour})
+
+			// The writer goroutine flushes the first line, blocked by the output
+			_, _ = w.Write([]byte("0\n"))
+			go w.Flush(t.Context())
+			assert.Eventually(t, func() bool { return out.writes.Load() == 1 }, time.Second, time.Millisecond)
+			for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+				_, _ = w.Write([]byte(line))
+			}
+			assert.Equal(t, uint64(2), w.Dropped())
+
+			close(out.release)
+			require.NoError(t, w.Close(t.Context()))
+			assert.Equal(t, test.expect, out.String())
+		})
+	}
+}
+
+func TestLoadLoggerConfig(t *testing.T) {
+	t.Run("sync by default", func(t *testing.T) {
+		assert.Nil(t, LoadLoggerConfig().Async)
+	})
+
+	t.Run("async", func(t *testing.T) {
+		t.Setenv("LOG_ASYNC", "true")
+		t.Setenv("LOG_BUFFER_SIZE", "16")
+		t.Setenv("LOG_FLUSH_INTERVAL", "100ms")
+		t.Setenv("LOG_DROP_POLICY", "newest")
+
+		assert.Equal(t, &AsyncConfig{BufferSize: 16, FlushInterval: 100 * time.Millisecond, DropPolicy: DropNewest}, LoadLoggerConfig().Async)
+	})
+}
+
+func BenchmarkAsyncWriter(b *testing.B) {
+	w := NewAsyncWriter(&bytes.Buffer{}, AsyncConfig{})
+	defer w.Close(context.Background())
+	logger := slog.New(slog.NewJSONHandler(w, nil))
+
+	for b.Loop() {
+		logger.Info("message", "key", "value")
+	}
+}
@@ -0,0 +1,81 @@
+package slog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/go-chi/httplog/v3"
+)
+
+type loggerKey struct{}
+
+// contextLogger holds the logger of a request, shared by the contexts derived
+// from the request context so that the fields added with With are seen by the
+// rest of the request
+type contextLogger struct {
+	mu     sync.Mutex
+	logger *Logger
+}
+
+// NewContext returns a copy of ctx carrying the logger returned by FromContext.
+// The router stores the request logger, including the request ID, this way.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, &contextLogger{logger: l})
+}
+
+// FromContext returns the logger carried by ctx with the fields added with With,
+// or the default logger. Use it in code that doesn't see the glib.Ctx:
+//
+//	func (s *UserService) Create(ctx context.Context, user User) error {
+//	    slog.FromContext(ctx).InfoContext(ctx, "Creating user", "email", user.Email)
+//	    ...
+//	}
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ContextLogger(ctx); ok {
+		return l
+	}
+	return &Logger{Logger: slog.Default()}
+}
+
+// ContextLogger returns the logger carried by ctx, if any
+func ContextLogger(ctx context.Context) (*Logger, bool) {
+	cl, ok := ctx.Value(loggerKey{}).(*contextLogger)
+	if !ok {
+		return nil, false
+	}
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.logger, true
+}
+
+// With adds fields to the logger carried by ctx: the loggers returned by
+// FromContext for the rest of the request include them, as well as the access
+// log line of the request. The returned context carries a logger when ctx has none.
+//
+//	ctx = slog.With(ctx, "tenant", tenantID)
+func With(ctx context.Context, args ...any) context.Context {
+	httplog.SetAttrs(ctx, argsToAttrs(args)...)
+
+	if cl, ok := ctx.Value(loggerKey{}).(*contextLogger); ok {
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		cl.logger = cl.logger.With(args...)
+		return ctx
+	}
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
+
+// argsToAttrs converts key-value pairs and Attrs to Attrs, like Logger.Log
+func argsToAttrs(args []any) []slog.Attr {
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "", 0)
+	r.Add(args...)
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return attrs
+}
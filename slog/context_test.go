@@ -0,0 +1,62 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/httplog/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Run("fallback", func(t *testing.T) {
+		logger := FromContext(context.Background())
+		assert.Equal(t, slog.Default(), logger.Logger)
+
+		_, ok := ContextLogger(context.Background())
+		assert.False(t, ok)
+	})
+
+	t.Run("stored", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := New(slog.NewJSONHandler(buf, nil)).With("request_id", "abc")
+		ctx := NewContext(context.Background(), logger)
+
+		FromContext(ctx).Info("message")
+		assert.Contains(t, buf.String(), `"request_id":"abc"`)
+	})
+}
+
+func TestWith(t *testing.T) {
+	t.Run("visible to derived contexts", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		ctx := NewContext(context.Background(), New(slog.NewJSONHandler(buf, nil)))
+		child, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		assert.Equal(t, child, With(child, "tenant", "acme"))
+		FromContext(ctx).Info("message")
+		assert.Contains(t, buf.String(), `"tenant":"acme"`)
+	})
+
+	t.Run("without logger", func(t *testing.T) {
+		ctx := With(context.Background(), "tenant", "acme")
+		_, ok := ContextLogger(ctx)
+		assert.True(t, ok)
+	})
+
+	t.Run("access log", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(buf, nil))
+		handler := httplog.RequestLogger(logger, &httplog.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			With(r.Context(), "tenant", "acme")
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Contains(t, buf.String(), `"tenant":"acme"`)
+	})
+}
@@ -39,6 +39,9 @@ type DevModeHandlerOptions struct {
 	// The handler calls `Level.Level()` for each record processed;
 	// to adjust the minimum level dynamically, use a `slog.LevelVar`.
 	Level slog.Leveler
+
+	// TrimSource shortens the source file path with `TrimSourcePath`.
+	TrimSource bool
 }
 
 // DevModeHandler is a `slog.Handler` that writes Records to an io.Writer.
@@ -55,11 +58,12 @@ type DevModeHandler struct {
 
 // NewHandler creates a new `slog.Handler` with default options.
 // If `devMode` is true, a `*DevModeHandler` is returned, else a `*slog.JSONHandler`.
+// Source file paths are shortened with `TrimSourcePath`.
 func NewHandler(devMode bool, w io.Writer) slog.Handler {
 	if devMode {
-		return NewDevModeHandler(w, &DevModeHandlerOptions{Level: slog.LevelDebug})
+		return NewDevModeHandler(w, &DevModeHandlerOptions{Level: slog.LevelDebug, TrimSource: true})
 	}
-	return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo, AddSource: true})
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo, AddSource: true, ReplaceAttr: TrimSourceAttr})
 }
 
 // NewDevModeHandler creates a new `DevModeHandler` that writes to w, using the given options.
@@ -94,15 +98,21 @@ func (h *DevModeHandler) Handle(_ context.Context, r slog.Record) error {
 	buf.WriteByte(' ')
 
 	buf.WriteString(r.Time.Format("2006/01/02 15:04:05.999999"))
-	fs := runtime.CallersFrames([]uintptr{r.PC})
-	f, _ := fs.Next()
-	buf.WriteString(Gray)
-	buf.WriteString(" (")
-	buf.WriteString(f.File)
-	buf.WriteByte(':')
-	buf.WriteString(strconv.Itoa(f.Line))
-	buf.WriteString(")")
-	buf.WriteString(Reset)
+	if r.PC != 0 {
+		fs := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := fs.Next()
+		file := f.File
+		if h.opts.TrimSource {
+			file = TrimSourcePath(file)
+		}
+		buf.WriteString(Gray)
+		buf.WriteString(" (")
+		buf.WriteString(file)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(f.Line))
+		buf.WriteString(")")
+		buf.WriteString(Reset)
+	}
 	buf.WriteByte('\n')
 	buf.WriteString(messageColor(r.Level))
 	buf.WriteString(r.Message)
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"log/slog"
 	"runtime"
 	"sync"
@@ -22,28 +21,20 @@ func (t testLogValuer) LogValue() slog.Value {
 }
 
 func TestNewHandler(t *testing.T) {
-	cases := []struct {
-		want    any
-		w       io.Writer
-		devMode bool
-	}{
-		{
-			devMode: true,
-			w:       bytes.NewBuffer(make([]byte, 0, 10)),
-			want:    &DevModeHandler{w: bytes.NewBuffer(make([]byte, 0, 10)), mu: &sync.Mutex{}, opts: &DevModeHandlerOptions{Level: slog.LevelDebug}},
-		},
-		{
-			devMode: false,
-			w:       bytes.NewBuffer(make([]byte, 0, 10)),
-			want:    slog.NewJSONHandler(bytes.NewBuffer(make([]byte, 0, 10)), &slog.HandlerOptions{Level: slog.LevelInfo, AddSource: true}),
-		},
-	}
+	t.Run("devMode_true", func(t *testing.T) {
+		want := &DevModeHandler{w: bytes.NewBuffer(make([]byte, 0, 10)), mu: &sync.Mutex{}, opts: &DevModeHandlerOptions{Level: slog.LevelDebug, TrimSource: true}}
+		assert.Equal(t, want, NewHandler(true, bytes.NewBuffer(make([]byte, 0, 10))))
+	})
 
-	for _, c := range cases {
-		t.Run(fmt.Sprintf("devMode_%v", c.devMode), func(t *testing.T) {
-			assert.Equal(t, c.want, NewHandler(c.devMode, c.w))
-		})
-	}
+	t.Run("devMode_false", func(t *testing.T) {
+		buf := bytes.NewBuffer(make([]byte, 0, 1024))
+		handler := NewHandler(false, buf)
+		assert.IsType(t, &slog.JSONHandler{}, handler)
+		assert.False(t, handler.Enabled(context.Background(), slog.LevelDebug))
+
+		slog.New(handler).Info("message")
+		assert.Contains(t, buf.String(), `"file":"slog/handler_test.go"`)
+	})
 }
 
 func TestDevModeHandler(t *testing.T) {
@@ -440,3 +431,72 @@ func TestDevModeHandlerFormat(t *testing.T) {
 		}
 	})
 }
+
+func TestMultiHandler(t *testing.T) {
+	debugBuf := bytes.NewBuffer(make([]byte, 0, 1024))
+	infoBuf := bytes.NewBuffer(make([]byte, 0, 1024))
+	handler := MultiHandler(
+		slog.NewJSONHandler(debugBuf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewJSONHandler(infoBuf, &slog.HandlerOptions{Level: slog.LevelInfo}),
+	)
+
+	assert.True(t, handler.Enabled(context.Background(), slog.LevelDebug))
+
+	logger := slog.New(handler).With("attr", "val").WithGroup("group")
+	logger.Debug("debug message", "key", 1)
+	logger.Info("info message", "key", 2)
+
+	assert.Contains(t, debugBuf.String(), `"msg":"debug message","attr":"val","group":{"key":1}`)
+	assert.Contains(t, debugBuf.String(), `"msg":"info message","attr":"val","group":{"key":2}`)
+	assert.NotContains(t, infoBuf.String(), "debug message")
+	assert.Contains(t, infoBuf.String(), `"msg":"info message","attr":"val","group":{"key":2}`)
+}
+
+func TestTrimSourcePath(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	cases := []struct {
+		desc string
+		file string
+		want string
+	}{
+		{desc: "module_cache", file: "/home/user/go/pkg/mod/github.com/go-chi/chi/v5@v5.2.3/mux.go", want: "github.com/go-chi/chi/v5@v5.2.3/mux.go"},
+		{desc: "main_module", file: file, want: "slog/handler_test.go"},
+		{desc: "other", file: "/usr/local/go/src/net/http/server.go", want: "/usr/local/go/src/net/http/server.go"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.want, TrimSourcePath(c.file))
+		})
+	}
+}
+
+func TestFrameModuleRoot(t *testing.T) {
+	module, pkg := mainModule, mainPackage
+	mainModule, mainPackage = "github.com/acme/api", "github.com/acme/api/cmd/server"
+	t.Cleanup(func() { mainModule, mainPackage = module, pkg })
+
+	cases := []struct {
+		desc     string
+		function string
+		file     string
+		want     string
+		wantOK   bool
+	}{
+		{desc: "package", function: "github.com/acme/api/handlers.(*Users).Get", file: "/src/api/handlers/users.go", want: "/src/api", wantOK: true},
+		{desc: "module_root_package", function: "github.com/acme/api.Run", file: "/src/api/api.go", want: "/src/api", wantOK: true},
+		{desc: "main_package", function: "main.main", file: "/src/api/cmd/server/main.go", want: "/src/api", wantOK: true},
+		{desc: "trimpath", function: "github.com/acme/api/handlers.list.func1", file: "github.com/acme/api/handlers/users.go", want: "github.com/acme/api", wantOK: true},
+		{desc: "other_module", function: "github.com/acme/apikit.Run", file: "/src/apikit/run.go"},
+		{desc: "dependency", function: "github.com/go-chi/chi/v5.(*Mux).ServeHTTP", file: "/go/pkg/mod/github.com/go-chi/chi/v5@v5.2.3/mux.go"},
+		{desc: "mismatched_directory", function: "github.com/acme/api/handlers.Get", file: "/src/api/other/users.go"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			root, ok := frameModuleRoot(c.function, c.file)
+			assert.Equal(t, c.wantOK, ok)
+			assert.Equal(t, c.want, root)
+		})
+	}
+}
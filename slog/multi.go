@@ -0,0 +1,62 @@
+package slog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// multiHandler is a `slog.Handler` forwarding records to several handlers.
+type multiHandler struct {
+	handlers []slog.Handler
+}
+
+// MultiHandler returns a `slog.Handler` forwarding each record to all the given handlers,
+// for example to write JSON logs to stdout and send them to an exporter at the same time.
+// Each handler keeps its own level: a record is only passed to the handlers enabled for its level.
+func MultiHandler(handlers ...slog.Handler) slog.Handler {
+	return &multiHandler{handlers: handlers}
+}
+
+// Enabled reports whether at least one of the handlers handles records at the given level.
+func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes a clone of the record to each enabled handler. All handlers are called
+// even if one of them fails, the returned error joins their errors.
+func (h *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs returns a new `multiHandler` whose handlers all have the given attributes.
+func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithAttrs(attrs))
+	}
+	return &multiHandler{handlers: handlers}
+}
+
+// WithGroup returns a new `multiHandler` whose handlers all have the given group.
+func (h *multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithGroup(name))
+	}
+	return &multiHandler{handlers: handlers}
+}
@@ -34,9 +34,19 @@ func Create() *Logger {
 	isDebug := util.GetEnvBool("IS_DEBUG", false)
 
 	// Create handler based on debug mode
-	var handler slog.Handler = NewHandler(isDebug, os.Stdout)
+	return CreateWithHandler(NewHandler(isDebug, os.Stdout))
+}
 
-	return New(handler)
+// CreateWithHandler creates a Logger writing to the given handler, bypassing
+// the environment configuration. Combine it with `MultiHandler` to send the
+// logs to several destinations.
+//
+//	logger := slog.CreateWithHandler(slog.MultiHandler(
+//	    slog.NewHandler(false, os.Stdout),
+//	    otelHandler,
+//	))
+func CreateWithHandler(h slog.Handler) *Logger {
+	return New(h)
 }
 
 // New creates a new Logger with the given non-nil Handler and a nil context.
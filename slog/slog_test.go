@@ -116,7 +116,7 @@ func TestLogger(t *testing.T) {
 			{
 				desc: "errors.Error_empty",
 				f:    func() { l.Error(&errors.Error{}, slog.String("attr", "val")) },
-				want: regexp.MustCompile(fmt.Sprintf(`\n%s ERROR %s \d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{1,6}%s \(%s\)%s\n%sgoyave.dev/goyave/util/errors\.Error: the Error doesn't wrap any reason \(empty reasons slice\)%s\n%sattr: %sval\n%strace: %s\n`, regexp.QuoteMeta(BGRed+WhiteBold), regexp.QuoteMeta(Reset), regexp.QuoteMeta(Gray), expectedSource, regexp.QuoteMeta(Reset), regexp.QuoteMeta(Red), regexp.QuoteMeta(Reset), regexp.QuoteMeta(WhiteBold), regexp.QuoteMeta(Reset), regexp.QuoteMeta(WhiteBold), regexp.QuoteMeta(Reset))),
+				want: regexp.MustCompile(fmt.Sprintf(`\n%s ERROR %s \d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{1,6}%s \(%s\)%s\n%sgithub.com/azizndao/glib/errors\.Error: the Error doesn't wrap any reason \(empty reasons slice\)%s\n%sattr: %sval\n%strace: %s\n`, regexp.QuoteMeta(BGRed+WhiteBold), regexp.QuoteMeta(Reset), regexp.QuoteMeta(Gray), expectedSource, regexp.QuoteMeta(Reset), regexp.QuoteMeta(Red), regexp.QuoteMeta(Reset), regexp.QuoteMeta(WhiteBold), regexp.QuoteMeta(Reset), regexp.QuoteMeta(WhiteBold), regexp.QuoteMeta(Reset))),
 			},
 			{
 				desc: "errors.Error_multiple", // We expect three separated messages to be printed
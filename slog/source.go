@@ -0,0 +1,107 @@
+package slog
+
+import (
+	"log/slog"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+)
+
+// mainModule and mainPackage are the paths of the main module and of the main
+// package, read from the build info
+var mainModule, mainPackage = mainPaths()
+
+// moduleRoot is the directory of the main module, once found by findModuleRoot
+var moduleRoot atomic.Pointer[string]
+
+func mainPaths() (module, pkg string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", ""
+	}
+	// The main package of a test binary is the tested package with a .test suffix
+	return info.Main.Path, strings.TrimSuffix(info.Path, ".test")
+}
+
+// findModuleRoot returns the directory of the main module, derived from the
+// source file of a function of the main module on the stack of the caller,
+// e.g. "/src/api" for the function of the package "github.com/acme/api/handlers"
+// compiled from "/src/api/handlers/user.go" in the module "github.com/acme/api".
+// It doesn't depend on the working directory, and returns the module path for
+// binaries built with -trimpath. Empty until such a function is found.
+func findModuleRoot() string {
+	if root := moduleRoot.Load(); root != nil {
+		return *root
+	}
+	if mainModule == "" {
+		return ""
+	}
+
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if root, ok := frameModuleRoot(frame.Function, frame.File); ok {
+			moduleRoot.Store(&root)
+			return root
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// frameModuleRoot returns the directory of the main module derived from the
+// source file of a function, if the function belongs to the main module
+func frameModuleRoot(function, file string) (string, bool) {
+	pkg := function
+	slash := strings.LastIndex(pkg, "/")
+	if dot := strings.Index(pkg[slash+1:], "."); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	if pkg == "main" {
+		pkg = mainPackage
+	}
+
+	rel, ok := strings.CutPrefix(pkg, mainModule)
+	if !ok || (rel != "" && rel[0] != '/') {
+		return "", false
+	}
+	root, ok := strings.CutSuffix(path.Dir(file), rel)
+	if !ok {
+		return "", false
+	}
+	return root, true
+}
+
+// TrimSourcePath shortens the path of a source file for logging:
+//   - files of the main module are made relative to the module root ("handlers/user.go")
+//   - files from the module cache keep their module path ("github.com/go-chi/chi/v5@v5.2.3/mux.go")
+//
+// Other paths are returned unchanged.
+func TrimSourcePath(file string) string {
+	if i := strings.LastIndex(file, "/pkg/mod/"); i >= 0 {
+		return file[i+len("/pkg/mod/"):]
+	}
+	if root := findModuleRoot(); root != "" {
+		if rel, ok := strings.CutPrefix(file, root+"/"); ok {
+			return rel
+		}
+	}
+	return file
+}
+
+// TrimSourceAttr is a `slog.HandlerOptions.ReplaceAttr` function shortening the
+// file of the source attribute with `TrimSourcePath`.
+func TrimSourceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.SourceKey {
+		if source, ok := a.Value.Any().(*slog.Source); ok {
+			trimmed := *source
+			trimmed.File = TrimSourcePath(source.File)
+			a.Value = slog.AnyValue(&trimmed)
+		}
+	}
+	return a
+}
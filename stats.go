@@ -0,0 +1,327 @@
+package glib
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/azizndao/glib/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// DefaultStatsMaxRoutes is the maximum number of routes tracked by the route
+	// stats, the requests of the other ones being counted in StatsOtherRoute
+	DefaultStatsMaxRoutes = 200
+
+	// StatsOtherRoute is the route of the requests beyond DefaultStatsMaxRoutes
+	StatsOtherRoute = "other"
+
+	// StatsUnmatchedRoute is the route of the requests that didn't match any route
+	StatsUnmatchedRoute = "unmatched"
+
+	// statsRecentMinutes is the period of the recent stats
+	statsRecentMinutes = 5
+
+	// statsMinLatency is the upper bound of the first latency bucket, and
+	// statsBucketGrowth the ratio between the bounds of two consecutive buckets,
+	// giving quantiles within 10% up to statsMaxLatency
+	statsMinLatency   = 100 * time.Microsecond
+	statsBucketGrowth = 1.1
+	statsMaxLatency   = 5 * time.Minute
+)
+
+// statsBuckets is the number of latency buckets, the last one counting the
+// latencies above statsMaxLatency
+var statsBuckets = int(math.Ceil(math.Log(float64(statsMaxLatency)/float64(statsMinLatency))/math.Log(statsBucketGrowth))) + 1
+
+// StatsReport is the response of the stats endpoint, see Server.EnableStats
+type StatsReport struct {
+	// Since is the time the stats started to be collected
+	Since  time.Time   `json:"since"`
+	Routes []RouteStat `json:"routes"`
+}
+
+// RouteStat holds the stats of a route pattern and method
+type RouteStat struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+
+	// Total covers the requests since the stats are enabled, Recent the ones
+	// of the last 5 minutes
+	Total  StatsWindow `json:"total"`
+	Recent StatsWindow `json:"recent"`
+}
+
+// StatsWindow holds the request count, error rate (5xx responses) and latency
+// quantiles of a route over a period. Quantiles are approximated within 10%,
+// latencies are in milliseconds.
+type StatsWindow struct {
+	Count     int64   `json:"count"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P50       float64 `json:"p50_ms"`
+	P95       float64 `json:"p95_ms"`
+	P99       float64 `json:"p99_ms"`
+	Max       float64 `json:"max_ms"`
+}
+
+// routeStats collects the latencies of the requests per route pattern and
+// method, once enabled by Server.EnableStats. Its memory is bounded: latencies
+// are counted in fixed log-scale buckets, and the number of routes is capped.
+type routeStats struct {
+	enabled   atomic.Bool
+	maxRoutes int
+	now       func() time.Time
+
+	mu     sync.RWMutex
+	since  time.Time
+	routes map[routeStatsKey]*routeHistogram
+}
+
+type routeStatsKey struct {
+	method string
+	route  string
+}
+
+// latencyHistogram counts the latencies of a period per bucket
+type latencyHistogram struct {
+	count   int64
+	errors  int64
+	max     time.Duration
+	buckets []int64
+}
+
+// routeHistogram holds the histogram of a route since the start, and the
+// histograms of its last minutes in a ring indexed by minute
+type routeHistogram struct {
+	mu      sync.Mutex
+	total   latencyHistogram
+	minutes [statsRecentMinutes]latencyHistogram
+	// minuteOf is the Unix minute of each histogram of the ring
+	minuteOf [statsRecentMinutes]int64
+}
+
+func newRouteStats() *routeStats {
+	return &routeStats{
+		maxRoutes: DefaultStatsMaxRoutes,
+		now:       time.Now,
+		routes:    make(map[routeStatsKey]*routeHistogram),
+	}
+}
+
+// enable starts collecting the stats
+func (s *routeStats) enable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled.Load() {
+		s.since = s.now()
+		s.enabled.Store(true)
+	}
+}
+
+// serve serves the request with next, recording its latency under its route
+// pattern. The routing context is created here so that the pattern can be
+// read once the request is served.
+func (s *routeStats) serve(mux *chi.Mux, w http.ResponseWriter, req *http.Request) {
+	rctx := chi.NewRouteContext()
+	rctx.Routes = mux
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+
+	start := s.now()
+	mux.ServeHTTP(ww, req)
+	s.record(req.Method, rctx.RoutePattern(), cmp.Or(ww.Status(), http.StatusOK), s.now().Sub(start))
+}
+
+// record adds a request to the stats of its route
+func (s *routeStats) record(method, route string, status int, latency time.Duration) {
+	if route == "" {
+		route = StatsUnmatchedRoute
+	}
+	// The other methods are grouped so that clients can't create routes
+	if !slices.Contains(standardMethods, method) {
+		method = "OTHER"
+	}
+	key := routeStatsKey{method: method, route: route}
+
+	s.mu.RLock()
+	histogram := s.routes[key]
+	s.mu.RUnlock()
+	if histogram == nil {
+		s.mu.Lock()
+		if histogram = s.routes[key]; histogram == nil {
+			if len(s.routes) >= s.maxRoutes {
+				key = routeStatsKey{method: "*", route: StatsOtherRoute}
+			}
+			histogram = s.routes[key]
+			if histogram == nil {
+				histogram = newRouteHistogram()
+				s.routes[key] = histogram
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	histogram.add(s.now().Unix()/60, status >= http.StatusInternalServerError, latency)
+}
+
+// report returns the stats of the routes, sorted by route and method
+func (s *routeStats) report() StatsReport {
+	minute := s.now().Unix() / 60
+
+	s.mu.RLock()
+	report := StatsReport{Since: s.since, Routes: make([]RouteStat, 0, len(s.routes))}
+	for key, histogram := range s.routes {
+		total, recent := histogram.windows(minute)
+		report.Routes = append(report.Routes, RouteStat{Method: key.method, Route: key.route, Total: total, Recent: recent})
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(report.Routes, func(a, b RouteStat) int {
+		return cmp.Or(cmp.Compare(a.Route, b.Route), cmp.Compare(a.Method, b.Method))
+	})
+	return report
+}
+
+func newRouteHistogram() *routeHistogram {
+	h := &routeHistogram{total: latencyHistogram{buckets: make([]int64, statsBuckets)}}
+	for i := range h.minutes {
+		h.minutes[i].buckets = make([]int64, statsBuckets)
+	}
+	return h
+}
+
+// add records a latency in the histograms of the route
+func (h *routeHistogram) add(minute int64, failed bool, latency time.Duration) {
+	bucket := latencyBucket(latency)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := minute % statsRecentMinutes
+	if h.minuteOf[i] != minute {
+		h.minuteOf[i] = minute
+		h.minutes[i].reset()
+	}
+	h.total.add(bucket, failed, latency)
+	h.minutes[i].add(bucket, failed, latency)
+}
+
+// windows returns the stats since the start and over the recent minutes
+func (h *routeHistogram) windows(minute int64) (StatsWindow, StatsWindow) {
+	recent := latencyHistogram{buckets: make([]int64, statsBuckets)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.minutes {
+		if minute-h.minuteOf[i] < statsRecentMinutes {
+			recent.merge(&h.minutes[i])
+		}
+	}
+	return h.total.window(), recent.window()
+}
+
+func (l *latencyHistogram) add(bucket int, failed bool, latency time.Duration) {
+	l.count++
+	if failed {
+		l.errors++
+	}
+	l.max = max(l.max, latency)
+	l.buckets[bucket]++
+}
+
+func (l *latencyHistogram) merge(other *latencyHistogram) {
+	l.count += other.count
+	l.errors += other.errors
+	l.max = max(l.max, other.max)
+	for i, n := range other.buckets {
+		l.buckets[i] += n
+	}
+}
+
+func (l *latencyHistogram) reset() {
+	l.count, l.errors, l.max = 0, 0, 0
+	clear(l.buckets)
+}
+
+// window computes the stats of the histogram
+func (l *latencyHistogram) window() StatsWindow {
+	if l.count == 0 {
+		return StatsWindow{}
+	}
+	return StatsWindow{
+		Count:     l.count,
+		Errors:    l.errors,
+		ErrorRate: float64(l.errors) / float64(l.count),
+		P50:       milliseconds(l.quantile(0.50)),
+		P95:       milliseconds(l.quantile(0.95)),
+		P99:       milliseconds(l.quantile(0.99)),
+		Max:       milliseconds(l.max),
+	}
+}
+
+// quantile returns the upper bound of the bucket of the quantile, capped by
+// the maximum latency
+func (l *latencyHistogram) quantile(q float64) time.Duration {
+	rank := int64(math.Ceil(q * float64(l.count)))
+	var seen int64
+	for i, n := range l.buckets {
+		seen += n
+		if seen >= rank {
+			return min(bucketBound(i), l.max)
+		}
+	}
+	return l.max
+}
+
+// latencyBucket returns the bucket of the latency
+func latencyBucket(latency time.Duration) int {
+	if latency <= statsMinLatency {
+		return 0
+	}
+	i := int(math.Ceil(math.Log(float64(latency)/float64(statsMinLatency)) / math.Log(statsBucketGrowth)))
+	return min(i, statsBuckets-1)
+}
+
+// bucketBound returns the upper bound of the bucket
+func bucketBound(i int) time.Duration {
+	return time.Duration(float64(statsMinLatency) * math.Pow(statsBucketGrowth, float64(i)))
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// EnableStats starts collecting the latencies of the requests per route pattern
+// and method, and registers a GET endpoint at the given path returning them as
+// a StatsReport: count, error rate, p50/p95/p99 and max latency of each route
+// since the stats are enabled and over the last 5 minutes. Latencies are
+// measured around the whole middleware stack. The memory is bounded:
+// quantiles are approximated and at most DefaultStatsMaxRoutes routes are
+// tracked.
+//
+// Like EnableEcho, the endpoint is only registered in debug mode
+// (IS_DEBUG=true), unless ENABLE_STATS=true, as it exposes the routes and
+// their traffic.
+//
+// Example:
+//
+//	server.EnableStats("/debug/stats")
+func (s *Server) EnableStats(path string) {
+	if !util.GetEnvBool("ENABLE_STATS", util.GetEnvBool("IS_DEBUG", false)) || s.routerConfig.stats == nil {
+		return
+	}
+
+	stats := s.routerConfig.stats
+	stats.enable()
+	s.router.Get(path, func(c *Ctx) error {
+		c.NoCache()
+		return c.JSON(stats.report())
+	})
+}
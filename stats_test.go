@@ -0,0 +1,132 @@
+package glib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteStats(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	stats := newRouteStats()
+	stats.now = func() time.Time { return now }
+	stats.enable()
+
+	for i := 1; i <= 100; i++ {
+		status := http.StatusOK
+		if i%10 == 0 {
+			status = http.StatusBadGateway
+		}
+		stats.record(http.MethodGet, "/users/{id}", status, time.Duration(i)*time.Millisecond)
+	}
+	stats.record("PURGE", "", http.StatusMethodNotAllowed, time.Millisecond)
+
+	report := stats.report()
+	assert.Equal(t, now, report.Since)
+	require.Len(t, report.Routes, 2)
+	assert.Equal(t, "OTHER", report.Routes[1].Method)
+	assert.Equal(t, StatsUnmatchedRoute, report.Routes[1].Route)
+
+	users := report.Routes[0]
+	assert.Equal(t, http.MethodGet, users.Method)
+	assert.Equal(t, "/users/{id}", users.Route)
+	assert.Equal(t, int64(100), users.Total.Count)
+	assert.Equal(t, int64(10), users.Total.Errors)
+	assert.Equal(t, 0.1, users.Total.ErrorRate)
+	assert.InEpsilon(t, 50, users.Total.P50, 0.1)
+	assert.InEpsilon(t, 95, users.Total.P95, 0.1)
+	assert.InEpsilon(t, 99, users.Total.P99, 0.1)
+	assert.Equal(t, float64(100), users.Total.Max)
+	assert.Equal(t, users.Total, users.Recent)
+
+	t.Run("recent window", func(t *testing.T) {
+		now = now.Add(3 * time.Minute)
+		stats.record(http.MethodGet, "/users/{id}", http.StatusOK, 2*time.Second)
+		users := stats.report().Routes[0]
+		assert.Equal(t, int64(101), users.Recent.Count)
+		assert.Equal(t, float64(2000), users.Recent.Max)
+
+		now = now.Add(4 * time.Minute)
+		users = stats.report().Routes[0]
+		assert.Equal(t, int64(1), users.Recent.Count, "the requests older than 5 minutes are not recent")
+		assert.InEpsilon(t, 2000, users.Recent.P50, 0.1)
+		assert.Equal(t, int64(101), users.Total.Count)
+	})
+
+	t.Run("bounded routes", func(t *testing.T) {
+		stats := newRouteStats()
+		stats.maxRoutes = 3
+		for i := range 10 {
+			stats.record(http.MethodGet, fmt.Sprintf("/r%d", i), http.StatusOK, time.Millisecond)
+		}
+
+		report := stats.report()
+		require.Len(t, report.Routes, 4)
+		other := report.Routes[3]
+		assert.Equal(t, "*", other.Method)
+		assert.Equal(t, StatsOtherRoute, other.Route)
+		assert.Equal(t, int64(7), other.Total.Count)
+	})
+}
+
+func TestEnableStats(t *testing.T) {
+	newServer := func() *Server {
+		config := DefaultRouterOptions()
+		config.stats = newRouteStats()
+		r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+		r.Route("/users", func(r Router) {
+			r.Get("/{id}", func(c *Ctx) error { return c.NoContent() })
+			r.Delete("/{id}", func(c *Ctx) error { return errors.InternalServerError("boom", nil) })
+		})
+		return &Server{router: r, routerConfig: config}
+	}
+	serve := func(s *Server, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	t.Run("disabled outside debug mode", func(t *testing.T) {
+		t.Setenv("IS_DEBUG", "false")
+		s := newServer()
+		s.EnableStats("/debug/stats")
+		serve(s, http.MethodGet, "/users/1")
+
+		assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/debug/stats").Code)
+		assert.Empty(t, s.routerConfig.stats.report().Routes, "the stats are not collected")
+	})
+
+	t.Run("reports the routes", func(t *testing.T) {
+		t.Setenv("IS_DEBUG", "false")
+		t.Setenv("ENABLE_STATS", "true")
+		s := newServer()
+		s.EnableStats("/debug/stats")
+		serve(s, http.MethodGet, "/users/1")
+		serve(s, http.MethodGet, "/users/2")
+		serve(s, http.MethodDelete, "/users/1")
+		serve(s, http.MethodGet, "/missing")
+
+		w := serve(s, http.MethodGet, "/debug/stats")
+		require.Equal(t, http.StatusOK, w.Code)
+		var report StatsReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+
+		counts := map[string]StatsWindow{}
+		for _, route := range report.Routes {
+			counts[route.Method+" "+route.Route] = route.Total
+		}
+		assert.Equal(t, int64(2), counts["GET /users/{id}"].Count)
+		assert.Equal(t, 1.0, counts["DELETE /users/{id}"].ErrorRate)
+		assert.Equal(t, int64(1), counts["GET "+StatsUnmatchedRoute].Count)
+		assert.Len(t, counts, 3)
+	})
+}
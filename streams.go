@@ -0,0 +1,211 @@
+package glib
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SSEShutdownEvent is the event sent to the SSE streams still open when the
+// server shutdown times out, before they are closed
+const SSEShutdownEvent = "server-shutdown"
+
+// ErrStreamClosed is returned by Ctx.SSE once the stream was closed by the
+// server shutdown
+var ErrStreamClosed = errors.New("glib: stream closed by the server shutdown")
+
+// streamTracker tracks the long-lived connections of a server, SSE streams and
+// hijacked connections, which http.Server.Shutdown doesn't interrupt
+type streamTracker struct {
+	mu      sync.Mutex
+	streams map[*trackedStream]struct{}
+	closed  bool
+}
+
+type trackedStream struct {
+	close func()
+}
+
+// add tracks a stream closed by close on shutdown. The returned function stops
+// tracking it. Streams added once the tracker is closed are closed right away.
+func (t *streamTracker) add(close func()) (remove func()) {
+	if t == nil {
+		return func() {}
+	}
+
+	stream := &trackedStream{close: close}
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		close()
+		return func() {}
+	}
+	if t.streams == nil {
+		t.streams = make(map[*trackedStream]struct{})
+	}
+	t.streams[stream] = struct{}{}
+	t.mu.Unlock()
+
+	return sync.OnceFunc(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.streams, stream)
+	})
+}
+
+// closeAll closes the tracked streams and returns their number
+func (t *streamTracker) closeAll() int {
+	if t == nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	t.closed = true
+	streams := t.streams
+	t.streams = nil
+	t.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for stream := range streams {
+		wg.Go(stream.close)
+	}
+	wg.Wait()
+	return len(streams)
+}
+
+// wait waits for the tracked streams to end, until ctx is done
+func (t *streamTracker) wait(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for t.len() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// len returns the number of tracked streams
+func (t *streamTracker) len() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.streams)
+}
+
+// ActiveStreams returns the number of long-lived connections tracked by the
+// server: the SSE streams (see Ctx.SSE) and the connections registered with
+// Ctx.TrackStream, such as websockets
+func (s *Server) ActiveStreams() int {
+	return s.streams.len()
+}
+
+// TrackStream registers a long-lived connection, typically a websocket, with
+// the server so that it doesn't keep the server alive past the shutdown
+// timeout: close is called when Shutdown times out, and must end the
+// connection, e.g. by sending a close frame and closing it. The returned
+// function stops tracking the connection and must be called when it ends. It
+// has no effect on routers not served by a Server.
+//
+// SSE streams sent with Ctx.SSE are tracked without it.
+//
+// Example:
+//
+//	r.Get("/ws", func(c *glib.Ctx) error {
+//	    conn, err := upgrader.Upgrade(c.Response, c.Request, nil)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    defer c.TrackStream(func() {
+//	        msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown")
+//	        _ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+//	        _ = conn.Close()
+//	    })()
+//	    return serve(conn)
+//	})
+func (c *Ctx) TrackStream(close func()) (done func()) {
+	return c.config.streams.add(close)
+}
+
+// sseStream is the SSE stream of a request, serializing the events sent by the
+// handler with the shutdown event
+type sseStream struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	conn   net.Conn
+	closed bool // closed by the shutdown, or the handler returned
+	done   func()
+}
+
+// sseStream returns the SSE stream of the request, tracked by the server
+func (c *Ctx) sseStream() *sseStream {
+	if c.sse == nil {
+		conn, _ := c.Context().Value(connContextKey{}).(net.Conn)
+		stream := &sseStream{w: c.Response, conn: conn}
+		stream.done = c.config.streams.add(stream.shutdown)
+		c.sse = stream
+	}
+	return c.sse
+}
+
+// send writes an event, unless the stream was closed
+func (s *sseStream) send(write func(w io.Writer) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStreamClosed
+	}
+	if err := write(s.w); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// shutdown sends the SSEShutdownEvent and closes the connection, so that the
+// client reconnects to another server and the handler returns
+func (s *sseStream) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if _, err := io.WriteString(s.w, "event: "+SSEShutdownEvent+"\ndata: shutdown\n\n"); err == nil {
+		if flusher, ok := s.w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+}
+
+// endStream stops tracking the SSE stream once the handler returned
+func (c *Ctx) endStream() {
+	if c.sse == nil {
+		return
+	}
+	c.sse.mu.Lock()
+	c.sse.closed = true
+	c.sse.mu.Unlock()
+	c.sse.done()
+}
+
+// connContextKey is the request context key of the connection of the request
+type connContextKey struct{}
+
+// connContext is the http.Server.ConnContext of the servers, storing the
+// connection in the request context so that SSE streams can be closed on shutdown
+func connContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
@@ -0,0 +1,143 @@
+package glib
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamTestServer starts a server tracking the streams of its routes
+func newStreamTestServer(t *testing.T, register func(r Router)) *Server {
+	t.Setenv("IS_DEBUG", "false")
+	config := DefaultRouterOptions()
+	config.streams = &streamTracker{}
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	register(r)
+
+	s := &Server{
+		router:          r,
+		httpServer:      &http.Server{Addr: freeAddr(t), Handler: r, ConnContext: connContext},
+		logger:          slog.DiscardLogger(),
+		shutdownTimeout: time.Second,
+		streams:         config.streams,
+	}
+	go func() { _ = s.Listen() }()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", s.Address())
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	return s
+}
+
+func TestServer_ShutdownSSE(t *testing.T) {
+	handlerDone := make(chan error, 1)
+	s := newStreamTestServer(t, func(r Router) {
+		r.Get("/events", func(c *Ctx) error {
+			if err := c.SSE("hello", "world"); err != nil {
+				return err
+			}
+			// A stream waiting for events that never come
+			<-c.Done()
+			handlerDone <- c.SSE("late", "event")
+			return nil
+		})
+	})
+
+	resp, err := http.Get("http://" + s.Address() + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: hello\n", line)
+	assert.Equal(t, 1, s.ActiveStreams())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = s.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second, "the stream blocked the shutdown")
+
+	rest, _ := io.ReadAll(reader)
+	assert.Equal(t, "data: world\n\nevent: server-shutdown\ndata: shutdown\n\n", string(rest))
+
+	select {
+	case err := <-handlerDone:
+		assert.ErrorIs(t, err, ErrStreamClosed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the handler was not canceled")
+	}
+	assert.Eventually(t, func() bool { return s.ActiveStreams() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestServer_ShutdownTrackedStream(t *testing.T) {
+	s := newStreamTestServer(t, func(r Router) {
+		r.Get("/ws", func(c *Ctx) error {
+			conn, buf, err := http.NewResponseController(c.Response).Hijack()
+			if err != nil {
+				return err
+			}
+			defer c.TrackStream(func() {
+				_, _ = conn.Write([]byte("going away\n"))
+				_ = conn.Close()
+			})()
+
+			_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+			_ = buf.Flush()
+			// Serve the connection until it is closed
+			_, _ = io.Copy(io.Discard, conn)
+			return nil
+		})
+	})
+
+	conn, err := net.Dial("tcp", s.Address())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+	require.NoError(t, err)
+	reader := bufio.NewReader(conn)
+	status, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(status, "HTTP/1.1 101"))
+	require.Eventually(t, func() bool { return s.ActiveStreams() == 1 }, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	// Hijacked connections aren't tracked by http.Server, Shutdown waits for them until the timeout
+	require.NoError(t, s.Shutdown(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+
+	rest, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "\r\ngoing away\n", string(rest))
+	assert.Eventually(t, func() bool { return s.ActiveStreams() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestCtx_TrackStreamWithoutServer(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/", func(c *Ctx) error {
+		c.TrackStream(func() { t.Error("closed without server") })()
+		return c.SSE("", "ok")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "data: ok\n\n", rec.Body.String())
+}
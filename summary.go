@@ -0,0 +1,65 @@
+package glib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/azizndao/glib/util"
+)
+
+// PrintStartupSummary writes a summary of the server configuration to w: bound
+// addresses, enabled middlewares of the stack, route count of all listeners, the
+// env files loaded (see Config.EnvFiles) and the settings read from environment variables, secrets being masked (names
+// ending with _SECRET, _TOKEN, _KEY or _PASSWORD). It is printed by Listen in
+// debug mode (IS_DEBUG=true).
+func (s *Server) PrintStartupSummary(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Address:\t%s\n", strings.Join(s.Addresses(), ", "))
+
+	middlewares := "none"
+	if len(s.middlewares) > 0 {
+		middlewares = strings.Join(s.middlewares, ", ")
+	}
+	fmt.Fprintf(tw, "Middlewares:\t%s\n", middlewares)
+	routes := 0
+	for _, l := range s.allListeners() {
+		routes += len(l.router.RouteList())
+	}
+	fmt.Fprintf(tw, "Routes:\t%d\n", routes)
+
+	envFiles := "none"
+	if len(s.envFiles) > 0 {
+		envFiles = strings.Join(s.envFiles, ", ")
+	}
+	fmt.Fprintf(tw, "Env files:\t%s\n", envFiles)
+
+	settings := util.EnvSettings()
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(tw, "Settings:\t")
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, settings[name])
+	}
+	_ = tw.Flush()
+}
+
+// started marks the routes as served, routes registered afterwards are warned
+// about, and prints the startup summary in debug mode
+func (s *Server) started() {
+	for _, l := range s.allListeners() {
+		if r, ok := l.router.(*router); ok {
+			r.registry.started.Store(true)
+		}
+	}
+	if util.GetEnvBool("IS_DEBUG", false) {
+		s.PrintStartupSummary(os.Stdout)
+	}
+}
@@ -0,0 +1,65 @@
+package glib
+
+import (
+	"bytes"
+	stdslog "log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/util"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_PrintStartupSummary(t *testing.T) {
+	t.Setenv("HOST", "0.0.0.0")
+	t.Setenv("JWT_SECRET", "s3cr3t")
+	t.Setenv("STRIPE_API_KEY", "sk_live")
+	util.GetEnv("HOST", "")
+	util.GetEnv("JWT_SECRET", "")
+	util.GetEnv("STRIPE_API_KEY", "")
+
+	r := setupTestRouter()
+	r.Get("/users", func(c *Ctx) error { return c.NoContent() })
+	r.Post("/users", func(c *Ctx) error { return c.NoContent() })
+	s := &Server{
+		router:      r,
+		httpServer:  &http.Server{Addr: "0.0.0.0:8080"},
+		middlewares: []string{"RealIP", "RequestID", "Recovery"},
+		envFiles:    []string{".env.local", ".env"},
+	}
+
+	var buf bytes.Buffer
+	s.PrintStartupSummary(&buf)
+	summary := buf.String()
+
+	assert.Regexp(t, `Address:\s+0\.0\.0\.0:8080`, summary)
+	assert.Regexp(t, `Middlewares:\s+RealIP, RequestID, Recovery`, summary)
+	assert.Regexp(t, `Routes:\s+2`, summary)
+	assert.Regexp(t, `Env files:\s+\.env\.local, \.env`, summary)
+	assert.Regexp(t, `HOST\s+0\.0\.0\.0`, summary)
+	assert.Regexp(t, `JWT_SECRET\s+\*\*\*\*`, summary)
+	assert.Regexp(t, `STRIPE_API_KEY\s+\*\*\*\*`, summary)
+	assert.NotContains(t, summary, "s3cr3t")
+	assert.NotContains(t, summary, "sk_live")
+}
+
+func TestRouter_RouteAfterStart(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.CreateWithHandler(stdslog.NewTextHandler(&logs, nil))
+	r := Default(logger, validation.New(validation.DefaultValidatorConfig()))
+	r.Get("/before", func(c *Ctx) error { return c.NoContent() })
+
+	s := &Server{router: r, httpServer: &http.Server{}}
+	t.Setenv("IS_DEBUG", "false")
+	s.started()
+	assert.Empty(t, logs.String())
+
+	r.Route("/api", func(r Router) {
+		r.Get("/after", func(c *Ctx) error { return c.NoContent() })
+	})
+	assert.Contains(t, logs.String(), "Route registered after the server started")
+	assert.Contains(t, logs.String(), "mount /api")
+	assert.Contains(t, logs.String(), "route GET /api/after")
+}
@@ -0,0 +1,148 @@
+package glib
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// tempFiles are the temporary files created for a request, removed once it is
+// answered unless kept, see Ctx.TempFile
+type tempFiles struct {
+	mu    sync.Mutex
+	paths []string
+	forms []*multipart.Form
+	kept  map[string]bool
+}
+
+// TempFile creates a temporary file in the default directory for temporary
+// files like os.CreateTemp, removed once the response is written whether the
+// handler succeeded, failed or panicked. Call KeepFile to keep it, e.g. when
+// the file becomes permanent storage. The caller must close the file.
+//
+// Temporary files of a Ctx copy are not removed, see Copy.
+func (c *Ctx) TempFile(pattern string) (*os.File, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	c.trackTempFile(f.Name())
+	return f, nil
+}
+
+// SaveFile saves an uploaded file to a temporary file removed with the other
+// temporary files of the request, see TempFile, and returns its path. The
+// file keeps the extension of the uploaded file name.
+func (c *Ctx) SaveFile(header *multipart.FileHeader) (string, error) {
+	src, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := c.TempFile("upload-*" + filepath.Ext(filepath.Base(header.Filename)))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	return dst.Name(), dst.Close()
+}
+
+// KeepFile excludes a temporary file of the request from the removal, e.g.
+// after moving it to permanent storage. The path is the name of the file
+// returned by TempFile or SaveFile, or of a multipart file spilled to disk.
+func (c *Ctx) KeepFile(path string) {
+	files := c.tempFiles()
+	files.mu.Lock()
+	defer files.mu.Unlock()
+
+	if files.kept == nil {
+		files.kept = make(map[string]bool)
+	}
+	files.kept[path] = true
+}
+
+func (c *Ctx) tempFiles() *tempFiles {
+	if c.temp == nil {
+		c.temp = &tempFiles{}
+	}
+	return c.temp
+}
+
+func (c *Ctx) trackTempFile(path string) {
+	files := c.tempFiles()
+	files.mu.Lock()
+	defer files.mu.Unlock()
+	files.paths = append(files.paths, path)
+}
+
+// trackMultipartForm registers the parsed multipart form of the request, whose
+// parts larger than the max memory are spilled to temporary files
+func (c *Ctx) trackMultipartForm() {
+	form := c.Request.MultipartForm
+	if form == nil || len(form.File) == 0 {
+		return
+	}
+
+	files := c.tempFiles()
+	files.mu.Lock()
+	defer files.mu.Unlock()
+	for _, tracked := range files.forms {
+		if tracked == form {
+			return
+		}
+	}
+	files.forms = append(files.forms, form)
+}
+
+// removeTempFiles removes the temporary files of the request that were not
+// kept. Failures are logged, the response is already written.
+func (c *Ctx) removeTempFiles() {
+	if c.temp == nil {
+		return
+	}
+	files := c.temp
+	files.mu.Lock()
+	defer files.mu.Unlock()
+
+	paths := files.paths
+	for _, form := range files.forms {
+		paths = append(paths, multipartTempFiles(form)...)
+	}
+
+	for _, path := range paths {
+		if files.kept[path] {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			if logger := c.Logger(); logger != nil {
+				logger.WarnContext(c.Context(), "failed to remove temporary file", "path", path, "error", err)
+			}
+		}
+	}
+	files.paths, files.forms = nil, nil
+}
+
+// multipartTempFiles returns the paths of the multipart files spilled to disk
+func multipartTempFiles(form *multipart.Form) []string {
+	var paths []string
+	for _, headers := range form.File {
+		for _, header := range headers {
+			f, err := header.Open()
+			if err != nil {
+				continue
+			}
+			if file, ok := f.(*os.File); ok {
+				paths = append(paths, file.Name())
+			}
+			f.Close()
+		}
+	}
+	return paths
+}
@@ -0,0 +1,137 @@
+package glib
+
+import (
+	"bytes"
+	stderrors "errors"
+	stdslog "log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azizndao/glib/middleware"
+	"github.com/azizndao/glib/slog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_TempFile(t *testing.T) {
+	cases := []struct {
+		desc    string
+		handler func(c *Ctx, f *os.File) error
+		keep    bool
+		status  int
+	}{
+		{
+			desc:    "removed after success",
+			handler: func(c *Ctx, f *os.File) error { return c.SendString("ok") },
+			status:  http.StatusOK,
+		},
+		{
+			desc:    "removed after error",
+			handler: func(c *Ctx, f *os.File) error { return stderrors.New("boom") },
+			status:  http.StatusInternalServerError,
+		},
+		{
+			desc:    "removed after panic",
+			handler: func(c *Ctx, f *os.File) error { panic("boom") },
+			status:  http.StatusInternalServerError,
+		},
+		{
+			desc: "kept",
+			handler: func(c *Ctx, f *os.File) error {
+				c.KeepFile(f.Name())
+				return c.SendString("ok")
+			},
+			keep:   true,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var path string
+			r := setupTestRouter()
+			r.UseHTTP(middleware.Recovery(middleware.RecoveryConfig{Logger: slog.DiscardLogger().Logger}))
+			r.Get("/", func(c *Ctx) error {
+				f, err := c.TempFile("glib-test-*")
+				require.NoError(t, err)
+				defer f.Close()
+				path = f.Name()
+				return tc.handler(c, f)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.status, w.Code)
+			require.NotEmpty(t, path)
+			if tc.keep {
+				assert.FileExists(t, path)
+				require.NoError(t, os.Remove(path))
+			} else {
+				assert.NoFileExists(t, path)
+			}
+		})
+	}
+}
+
+func TestCtx_SaveFile(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	part, err := mw.CreateFormFile("avatar", "me.png")
+	require.NoError(t, err)
+	_, err = part.Write(bytes.Repeat([]byte("x"), 1024))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	var saved, spilled string
+	r := setupTestRouter()
+	r.Post("/", func(c *Ctx) error {
+		if err := c.ParseMultipartForm(16); err != nil {
+			return err
+		}
+		file, header, err := c.FormFile("avatar")
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		spilled = file.(*os.File).Name()
+
+		if saved, err = c.SaveFile(header); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(saved)
+		if err != nil {
+			return err
+		}
+		assert.Len(t, data, 1024)
+		assert.Equal(t, ".png", filepath.Ext(saved))
+		return c.SendString("ok")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoFileExists(t, saved)
+	assert.NoFileExists(t, spilled)
+}
+
+func TestCtx_RemoveTempFilesLogsFailures(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(path, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "file"), nil, 0o644))
+
+	var logs bytes.Buffer
+	c := newCtx(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), slog.New(stdslog.NewJSONHandler(&logs, nil)), nil)
+	c.trackTempFile(path)
+	c.removeTempFiles()
+
+	assert.Contains(t, logs.String(), "failed to remove temporary file")
+}
@@ -0,0 +1,128 @@
+package glib
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits of the Server-Timing header of a response, the entries beyond them
+// being dropped
+const (
+	maxServerTimingEntries = 32
+	maxServerTimingBytes   = 2048
+)
+
+// serverTiming accumulates the Server-Timing entries of a response until its
+// header is sent, see Ctx.Timing
+type serverTiming struct {
+	start   time.Time
+	auto    bool // add the entries of the built-in phases, see RouterConfig.ServerTiming
+	entries []string
+	size    int
+}
+
+// add appends an entry, unless the limits are reached
+func (t *serverTiming) add(name string, d time.Duration, desc string) {
+	entry := formatServerTiming(name, d, desc)
+	if len(t.entries) >= maxServerTimingEntries || t.size+len(entry)+2 > maxServerTimingBytes {
+		return
+	}
+	t.entries = append(t.entries, entry)
+	t.size += len(entry) + 2
+}
+
+// header returns the value of the Server-Timing header, with the total time
+// since the start of the response when the built-in entries are enabled
+func (t *serverTiming) header() string {
+	if t.auto {
+		t.add("total", time.Since(t.start), "")
+	}
+	return strings.Join(t.entries, ", ")
+}
+
+// formatServerTiming formats an entry of the Server-Timing header: the name is a
+// token whose invalid characters are replaced by "_", the duration is in
+// milliseconds and the description a quoted string
+func formatServerTiming(name string, d time.Duration, desc string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < 0x80 && isTokenChar(byte(r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteByte('_')
+	}
+
+	b.WriteString(";dur=")
+	b.WriteString(strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64))
+
+	if desc != "" {
+		b.WriteString(`;desc="`)
+		for _, r := range desc {
+			switch {
+			case r == '"' || r == '\\':
+				b.WriteByte('\\')
+				b.WriteRune(r)
+			case r == '\t' || (r >= 0x20 && r != 0x7f):
+				b.WriteRune(r)
+			}
+		}
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// isTokenChar reports whether c is a character of an HTTP token (RFC 9110)
+func isTokenChar(c byte) bool {
+	return c > ' ' && c < 0x7f && strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) < 0
+}
+
+// Timing adds an entry to the Server-Timing header of the response, e.g. the
+// duration of a database query, so that clients (such as the browser devtools)
+// can attribute the latency of the request. Entries are sent with the headers,
+// those added once the body started are dropped, as are the entries beyond 32
+// entries or 2 KB.
+//
+// With RouterConfig.ServerTiming (ENABLE_SERVER_TIMING), the durations of the
+// built-in phases are added too: "parse" for ParseBody, "validate" for the
+// validation and "total" for the time until the response started.
+//
+// Example:
+//
+//	start := time.Now()
+//	users, err := db.ListUsers(c.Context())
+//	c.Timing("db", time.Since(start), "List users")
+func (c *Ctx) Timing(name string, d time.Duration, desc string) *Ctx {
+	if rw, ok := c.Response.(*responseWriter); ok && !rw.sent {
+		rw.serverTiming().add(name, d, desc)
+	}
+	return c
+}
+
+// timePhase adds the Server-Timing entry of a built-in phase started at start,
+// when RouterConfig.ServerTiming is enabled
+func (c *Ctx) timePhase(name, desc string, start time.Time) {
+	if c.config.ServerTiming {
+		c.Timing(name, time.Since(start), desc)
+	}
+}
+
+// serverTiming returns the Server-Timing entries of the response
+func (w *responseWriter) serverTiming() *serverTiming {
+	if w.timing == nil {
+		w.timing = &serverTiming{start: time.Now()}
+	}
+	return w.timing
+}
+
+// startTiming starts timing the response for RouterConfig.ServerTiming, unless
+// it was already done by a middleware handling the request
+func (r *router) startTiming(rw *responseWriter) {
+	if r.config.ServerTiming && rw.timing == nil {
+		rw.timing = &serverTiming{start: time.Now(), auto: true}
+	}
+}
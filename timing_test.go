@@ -0,0 +1,142 @@
+package glib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatServerTiming(t *testing.T) {
+	tests := []struct {
+		desc     string
+		name     string
+		duration time.Duration
+		metric   string
+		expected string
+	}{
+		{desc: "duration", name: "db", duration: 12500 * time.Microsecond, expected: "db;dur=12.5"},
+		{desc: "zero", name: "cache", expected: "cache;dur=0"},
+		{desc: "description", name: "db", duration: time.Millisecond, metric: "List users", expected: `db;dur=1;desc="List users"`},
+		{desc: "quoted description", name: "db", metric: `say "hi" \o/`, expected: `db;dur=0;desc="say \"hi\" \\o/"`},
+		{desc: "control characters", name: "db", metric: "a\r\nb", expected: `db;dur=0;desc="ab"`},
+		{desc: "invalid name", name: "db query;x=1", expected: "db_query_x_1;dur=0"},
+		{desc: "non ascii name", name: "réseau", expected: "r_seau;dur=0"},
+		{desc: "empty name", name: "", expected: "_;dur=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatServerTiming(tt.name, tt.duration, tt.metric))
+		})
+	}
+}
+
+func TestCtx_Timing(t *testing.T) {
+	r := setupTestRouter()
+	listUsers := func(c *Ctx) error {
+		c.Timing("db", 12500*time.Microsecond, "List users")
+		return c.Timing("cache", 0, "").JSON([]string{})
+	}
+	r.Get("/users", listUsers)
+	r.Head("/users", listUsers)
+	r.Get("/stream", func(c *Ctx) error {
+		c.Timing("first", time.Millisecond, "")
+		if err := c.SendString("started"); err != nil {
+			return err
+		}
+		c.Timing("late", time.Millisecond, "")
+		return nil
+	})
+	r.Get("/many", func(c *Ctx) error {
+		for i := range 100 {
+			c.Timing(fmt.Sprintf("step%d", i), time.Duration(i)*time.Millisecond, strings.Repeat("x", 20))
+		}
+		return c.NoContent()
+	})
+
+	tests := []struct {
+		desc     string
+		method   string
+		path     string
+		expected string
+	}{
+		{desc: "entries", method: http.MethodGet, path: "/users", expected: `db;dur=12.5;desc="List users", cache;dur=0`},
+		{desc: "head request", method: http.MethodHead, path: "/users", expected: `db;dur=12.5;desc="List users", cache;dur=0`},
+		{desc: "entries after the body started", method: http.MethodGet, path: "/stream", expected: "first;dur=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.expected, w.Header().Get("Server-Timing"))
+		})
+	}
+
+	t.Run("limits", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/many", nil))
+		header := w.Header().Get("Server-Timing")
+		assert.LessOrEqual(t, len(header), maxServerTimingBytes)
+		entries := strings.Split(header, ", ")
+		assert.LessOrEqual(t, len(entries), maxServerTimingEntries)
+		assert.Equal(t, `step0;dur=0;desc="xxxxxxxxxxxxxxxxxxxx"`, entries[0])
+	})
+}
+
+func TestRouter_ServerTiming(t *testing.T) {
+	type createUser struct {
+		Name string `json:"name" validate:"required"`
+	}
+	config := DefaultRouterOptions()
+	config.ServerTiming = true
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), config)
+	r.Use(func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			c.Timing("auth", time.Millisecond, "")
+			return next(c)
+		}
+	})
+	r.Post("/users", func(c *Ctx) error {
+		var user createUser
+		if err := c.ValidateBody(&user); err != nil {
+			return err
+		}
+		return c.Created(user)
+	})
+
+	tests := []struct {
+		desc     string
+		body     string
+		status   int
+		expected []string
+	}{
+		{desc: "valid body", body: `{"name":"John"}`, status: http.StatusCreated, expected: []string{"auth", "parse", "validate", "total"}},
+		{desc: "invalid body", body: `{"name":`, status: http.StatusBadRequest, expected: []string{"auth", "parse", "total"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+
+			var names []string
+			for entry := range strings.SplitSeq(w.Header().Get("Server-Timing"), ", ") {
+				name, _, _ := strings.Cut(entry, ";")
+				names = append(names, name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
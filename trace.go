@@ -0,0 +1,178 @@
+package glib
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TraceHeader is the response header listing the middlewares and the handler
+// run by a traced request, see TraceConfig
+const TraceHeader = "X-Trace"
+
+// TraceTokenHeader is the request header carrying the token of a request traced
+// with the __trace query parameter, see TraceConfig.Token
+const TraceTokenHeader = "X-Trace-Token"
+
+// maxTraceHeaderBytes is the maximum size of the X-Trace header, the trace
+// being truncated beyond it
+const maxTraceHeaderBytes = 1024
+
+// TraceConfig enables the tracing of the middlewares and the handler run by the
+// requests, in debug mode only (RouterConfig.Debug) so that the middleware
+// stack is never exposed in production.
+//
+// A traced request records the entry ("+") and the exit ("-") of each
+// middleware added with Use or With, named with Named or after its function,
+// and of the handler, with the time since the start of the request, e.g.
+// "+auth@0.01ms, +handler@0.02ms, -handler@1.2ms, -auth@1.21ms". The trace
+// recorded until the response started is sent in the X-Trace header (truncated
+// to 1 KB), the full trace is logged at debug level. The native middlewares
+// added with UseHTTP aren't traced.
+type TraceConfig struct {
+	// Enabled traces all the requests
+	Enabled bool
+
+	// Token traces the requests with the ?__trace=1 query parameter and the
+	// token in the X-Trace-Token header. Empty disables the query parameter.
+	Token string
+}
+
+// LoadTraceConfig loads TraceConfig from environment variables
+// Environment variables:
+//   - REQUEST_TRACE (bool): trace all the requests (default: false)
+//   - REQUEST_TRACE_TOKEN (string): token of the requests traced with ?__trace=1 (default: none)
+func LoadTraceConfig() TraceConfig {
+	cfg := struct {
+		Enabled bool   `env:"REQUEST_TRACE"`
+		Token   string `env:"REQUEST_TRACE_TOKEN"`
+	}{}
+	loadEnv(&cfg)
+	return TraceConfig(cfg)
+}
+
+// traces reports whether the request is traced
+func (t TraceConfig) traces(req *http.Request) bool {
+	if t.Enabled {
+		return true
+	}
+	if t.Token == "" || req.URL.Query().Get("__trace") != "1" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(req.Header.Get(TraceTokenHeader)), []byte(t.Token)) == 1
+}
+
+// requestTrace records the entries and exits of the middlewares and the handler
+// run by a request. It is held by the response writer shared by all of them.
+type requestTrace struct {
+	start  time.Time
+	events []traceEvent
+}
+
+// traceEvent is the entry or the exit of a middleware or handler
+type traceEvent struct {
+	name  string
+	enter bool
+	at    time.Duration
+}
+
+// enter records the entry of a middleware or handler, returning its index
+func (t *requestTrace) enter(name string) int {
+	t.events = append(t.events, traceEvent{name: name, enter: true, at: time.Since(t.start)})
+	return len(t.events) - 1
+}
+
+// exit records the exit of the middleware or handler entered at index i
+func (t *requestTrace) exit(i int) {
+	t.events = append(t.events, traceEvent{name: t.events[i].name, at: time.Since(t.start)})
+}
+
+// entries formats the events, e.g. "+auth@0.012ms"
+func (t *requestTrace) entries() []string {
+	entries := make([]string, len(t.events))
+	for i, event := range t.events {
+		sign := "-"
+		if event.enter {
+			sign = "+"
+		}
+		entries[i] = sign + event.name + "@" + strconv.FormatFloat(float64(event.at.Microseconds())/1000, 'f', -1, 64) + "ms"
+	}
+	return entries
+}
+
+// header returns the value of the X-Trace header, truncated with "..."
+func (t *requestTrace) header() string {
+	var b strings.Builder
+	for _, entry := range t.entries() {
+		if b.Len()+len(entry)+len(", , ...") > maxTraceHeaderBytes {
+			b.WriteString(", ...")
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteString(", ")
+		}
+		for _, r := range entry {
+			// Keep the header value printable ASCII
+			if r < 0x20 || r >= 0x7f {
+				r = '_'
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// setTrace sets the X-Trace header from the trace of the request
+func (w *responseWriter) setTrace() {
+	if w.trace != nil {
+		w.Header().Set(TraceHeader, w.trace.header())
+	}
+}
+
+// startTrace starts the trace of the request when it is traced, unless it was
+// already done by a middleware handling it. It returns the function logging
+// the trace once the request is handled, for the caller that started it.
+func (r *router) startTrace(rw *responseWriter, ctx *Ctx) func() {
+	if rw.trace != nil || !r.config.Debug || !r.config.Trace.traces(ctx.Request) {
+		return func() {}
+	}
+	rw.trace = &requestTrace{start: time.Now()}
+	return func() {
+		ctx.Logger().DebugContext(ctx.Context(), "Request trace",
+			"method", ctx.Method(),
+			"path", ctx.Path(),
+			"status", rw.status,
+			"trace", rw.trace.entries(),
+		)
+	}
+}
+
+// traceSpan records the entry of a middleware or handler in the trace of the
+// request, returning the function recording its exit
+func (c *Ctx) traceSpan(name string) func() {
+	rw, ok := c.Response.(*responseWriter)
+	if !ok || rw.trace == nil {
+		return func() {}
+	}
+	i := rw.trace.enter(name)
+	c.traceIndex = i + 1
+	return func() { rw.trace.exit(i) }
+}
+
+// traceNamed names the middleware wrapped by Named in the trace: the span of
+// the middleware is renamed when it was just entered, otherwise a nested span
+// is recorded, e.g. for a named middleware within Chain
+func (c *Ctx) traceNamed(name string) func() {
+	rw, ok := c.Response.(*responseWriter)
+	if !ok || rw.trace == nil {
+		return func() {}
+	}
+	if i := c.traceIndex - 1; i >= 0 && i == len(rw.trace.events)-1 {
+		rw.trace.events[i].name = name
+		c.traceIndex = 0
+		return func() {}
+	}
+	return c.traceSpan(name)
+}
@@ -0,0 +1,141 @@
+package glib
+
+import (
+	"bytes"
+	"encoding/json"
+	stdslog "log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/slog"
+	"github.com/azizndao/glib/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// traceTimes matches the times of the trace entries
+var traceTimes = regexp.MustCompile(`@[0-9.]+ms`)
+
+func traceMiddleware(next HandleFunc) HandleFunc {
+	return func(c *Ctx) error {
+		return next(c)
+	}
+}
+
+func TestRouter_Trace(t *testing.T) {
+	tests := []struct {
+		desc        string
+		debug       bool
+		trace       TraceConfig
+		url         string
+		token       string
+		expectTrace string
+	}{
+		{
+			desc:        "all requests",
+			debug:       true,
+			trace:       TraceConfig{Enabled: true},
+			url:         "/users",
+			expectTrace: "+auth, +glib.traceMiddleware, +handler, -handler, -glib.traceMiddleware, -auth",
+		},
+		{
+			desc:        "query parameter with token",
+			debug:       true,
+			trace:       TraceConfig{Token: "secret"},
+			url:         "/users?__trace=1",
+			token:       "secret",
+			expectTrace: "+auth, +glib.traceMiddleware, +handler, -handler, -glib.traceMiddleware, -auth",
+		},
+		{
+			desc:  "query parameter with invalid token",
+			debug: true,
+			trace: TraceConfig{Token: "secret"},
+			url:   "/users?__trace=1",
+			token: "guess",
+		},
+		{
+			desc:  "query parameter without token configured",
+			debug: true,
+			url:   "/users?__trace=1",
+		},
+		{
+			desc:  "production",
+			trace: TraceConfig{Enabled: true, Token: "secret"},
+			url:   "/users?__trace=1",
+			token: "secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(stdslog.NewJSONHandler(&logs, &stdslog.HandlerOptions{Level: stdslog.LevelDebug}))
+			r := Default(logger, validation.New(validation.DefaultValidatorConfig()), RouterConfig{Debug: tt.debug, Trace: tt.trace})
+			r.Use(Named("auth", traceMiddleware), traceMiddleware)
+			r.Get("/users", func(c *Ctx) error {
+				return c.JSON(map[string]any{})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.token != "" {
+				req.Header.Set(TraceTokenHeader, tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			if tt.expectTrace == "" {
+				assert.Empty(t, w.Header().Values(TraceHeader))
+				assert.NotContains(t, logs.String(), "Request trace")
+				return
+			}
+
+			// The header is sent when the handler responds
+			assert.Equal(t, "+auth, +glib.traceMiddleware, +handler", traceTimes.ReplaceAllString(w.Header().Get(TraceHeader), ""))
+
+			var record struct {
+				Msg    string   `json:"msg"`
+				Status int      `json:"status"`
+				Trace  []string `json:"trace"`
+			}
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+			assert.Equal(t, "Request trace", record.Msg)
+			assert.Equal(t, http.StatusOK, record.Status)
+			for i := range record.Trace {
+				record.Trace[i] = traceTimes.ReplaceAllString(record.Trace[i], "")
+			}
+			assert.Equal(t, tt.expectTrace, strings.Join(record.Trace, ", "))
+		})
+	}
+}
+
+func TestRouter_TraceError(t *testing.T) {
+	r := Default(slog.DiscardLogger(), validation.New(validation.DefaultValidatorConfig()), RouterConfig{Debug: true, Trace: TraceConfig{Enabled: true}})
+	r.Use(Named("auth", func(next HandleFunc) HandleFunc {
+		return func(c *Ctx) error {
+			return errors.Unauthorized("Missing token", nil)
+		}
+	}))
+	r.Get("/users", func(c *Ctx) error { return c.NoContent() })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "+auth, -auth", traceTimes.ReplaceAllString(w.Header().Get(TraceHeader), ""))
+}
+
+func TestRequestTrace_Header(t *testing.T) {
+	trace := &requestTrace{}
+	for range 100 {
+		trace.exit(trace.enter("middleware"))
+	}
+
+	header := trace.header()
+	assert.LessOrEqual(t, len(header), maxTraceHeaderBytes)
+	assert.Regexp(t, `^\+middleware@[0-9.]+ms, .*, \.\.\.$`, header)
+}
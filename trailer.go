@@ -0,0 +1,35 @@
+package glib
+
+import "net/http"
+
+// AddTrailer declares a trailer: a header sent after the body, whose value is
+// set with SetTrailer once known (e.g. a checksum or a row count computed while
+// streaming). It must be called before the first write, later declarations are
+// ignored by net/http.
+//
+// Declaring a trailer removes the Content-Length header when the response is
+// sent, since HTTP/1.1 only sends trailers with the chunked transfer encoding.
+// HTTP/2 sends them in a final HEADERS frame, whatever the body length. Note
+// that HTTP/1.0 clients and some proxies drop trailers.
+//
+// Example:
+//
+//	c.AddTrailer("X-Checksum")
+//	return c.Stream(func(w io.Writer) error {
+//	    h := sha256.New()
+//	    _, err := io.Copy(io.MultiWriter(w, h), rows)
+//	    c.SetTrailer("X-Checksum", hex.EncodeToString(h.Sum(nil)))
+//	    return err
+//	})
+func (c *Ctx) AddTrailer(key string) *Ctx {
+	c.header().Add("Trailer", http.CanonicalHeaderKey(key))
+	return c
+}
+
+// SetTrailer sets the value of a trailer, at any time before the handler
+// returns. Trailers not declared with AddTrailer are still sent by net/http,
+// but HTTP/1.1 clients only expect the declared ones.
+func (c *Ctx) SetTrailer(key, value string) *Ctx {
+	c.header().Set(http.TrailerPrefix+key, value)
+	return c
+}
@@ -0,0 +1,104 @@
+package glib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/azizndao/glib/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checksumHandler streams a body with its SHA-256 in the X-Checksum trailer
+func checksumHandler(c *Ctx) error {
+	c.AddTrailer("X-Checksum").AddTrailer("X-Rows")
+	return c.Stream(func(w io.Writer) error {
+		h := sha256.New()
+		for range 3 {
+			if _, err := io.MultiWriter(w, h).Write([]byte("row\n")); err != nil {
+				return err
+			}
+		}
+		c.SetTrailer("X-Checksum", hex.EncodeToString(h.Sum(nil)))
+		c.SetTrailer("X-Rows", "3")
+		return nil
+	})
+}
+
+func expectedChecksum() string {
+	sum := sha256.Sum256([]byte(strings.Repeat("row\n", 3)))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCtx_Trailers(t *testing.T) {
+	r := setupTestRouter()
+	r.Get("/export", checksumHandler)
+	r.Get("/length", func(c *Ctx) error {
+		c.AddTrailer("X-Rows")
+		c.SetTrailer("X-Rows", "1")
+		return c.JSONBytes([]byte(`{"rows":1}`))
+	})
+
+	t.Run("recorder", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+
+		res := rec.Result()
+		assert.Equal(t, []string{"X-Checksum", "X-Rows"}, res.Header.Values("Trailer"))
+		assert.Equal(t, expectedChecksum(), res.Trailer.Get("X-Checksum"))
+		assert.Equal(t, "3", res.Trailer.Get("X-Rows"))
+	})
+
+	t.Run("content length removed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/length", nil))
+
+		assert.Empty(t, rec.Header().Get("Content-Length"))
+		assert.Equal(t, "1", rec.Result().Trailer.Get("X-Rows"))
+	})
+}
+
+func TestCtx_TrailersOverNetwork(t *testing.T) {
+	r := setupTestRouter()
+	// Wrapping middleware must not strip the trailers
+	r.UseHTTP(middleware.Compress(middleware.DefaultCompressConfig()))
+	r.Get("/export", checksumHandler)
+
+	tests := []struct {
+		desc  string
+		http2 bool
+	}{
+		{desc: "HTTP/1.1 chunked"},
+		{desc: "HTTP/2", http2: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(r)
+			if test.http2 {
+				server.EnableHTTP2 = true
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			defer server.Close()
+
+			res, err := server.Client().Get(server.URL + "/export")
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, strings.Repeat("row\n", 3), string(body))
+			assert.Equal(t, test.http2, res.ProtoMajor == 2)
+			// Trailers are available once the body has been read
+			assert.Equal(t, expectedChecksum(), res.Trailer.Get("X-Checksum"))
+			assert.Equal(t, "3", res.Trailer.Get("X-Rows"))
+		})
+	}
+}
@@ -3,6 +3,9 @@ package glib
 import (
 	"net/http"
 
+	"github.com/azizndao/glib/errors"
+	"github.com/azizndao/glib/i18n"
+	"github.com/azizndao/glib/middleware"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -12,16 +15,33 @@ type Router interface {
 	http.Handler
 	chi.Routes
 
-	// Use appends one or more middlewares onto the Router stack.
+	// Use appends one or more middlewares onto the Router stack. Middlewares must
+	// be added before the routes, except in Route callbacks where they are applied
+	// when the sub-router is mounted. Misordered middlewares panic.
+	// Middlewares are composed once with the routes, and share the Ctx of the
+	// request with the next middlewares and the handler.
 	Use(middlewares ...Middleware)
 
 	// UseHTTP appends Chi's native middleware directly onto the Router stack.
-	// This allows using Chi's built-in middleware without conversion.
+	// This allows using Chi's built-in middleware without conversion, at any
+	// router level. The ordering rules of Use apply.
 	UseHTTP(chiMiddlewares ...func(http.Handler) http.Handler)
 
 	// With adds inline middlewares for an endpoint handler.
 	With(middlewares ...Middleware) Router
 
+	// Gated returns an inline-Router whose routes respond with 503 Service
+	// Unavailable until the gate is opened.
+	Gated(gate *Gate) Router
+
+	// Headers returns an inline-Router adding default response headers to its routes.
+	Headers(headers map[string]string) Router
+
+	// SetDefaultHeaders sets default response headers for the routes of this
+	// router and its sub-routers. Handlers can override them and sub-routers
+	// can remove them by setting an empty value.
+	SetDefaultHeaders(headers map[string]string)
+
 	// Group adds a new inline-Router along the current routing
 	// path, with a fresh middleware stack for the inline-Router.
 	Group(fn func(r Router)) Router
@@ -35,23 +55,34 @@ type Router interface {
 	// Handle and HandleFunc adds routes for `pattern` that matches
 	// all HTTP methods.
 	Handle(pattern string, h http.Handler)
-	HandleFunc(pattern string, h HandleFunc)
+	HandleFunc(pattern string, h HandleFunc) *Route
 
 	// Method and MethodFunc adds routes for `pattern` that matches
 	// the `method` HTTP method.
 	Method(method, pattern string, h http.Handler)
-	MethodFunc(method, pattern string, h HandleFunc)
-
-	// HTTP-method routing along `pattern`
-	Connect(pattern string, h HandleFunc)
-	Delete(pattern string, h HandleFunc)
-	Get(pattern string, h HandleFunc)
-	Head(pattern string, h HandleFunc)
-	Options(pattern string, h HandleFunc)
-	Patch(pattern string, h HandleFunc)
-	Post(pattern string, h HandleFunc)
-	Put(pattern string, h HandleFunc)
-	Trace(pattern string, h HandleFunc)
+	MethodFunc(method, pattern string, h HandleFunc) *Route
+
+	// HTTP-method routing along `pattern`, returning the route metadata
+	Connect(pattern string, h HandleFunc) *Route
+	Delete(pattern string, h HandleFunc) *Route
+	Get(pattern string, h HandleFunc) *Route
+	Head(pattern string, h HandleFunc) *Route
+	Options(pattern string, h HandleFunc) *Route
+	Patch(pattern string, h HandleFunc) *Route
+	Post(pattern string, h HandleFunc) *Route
+	Put(pattern string, h HandleFunc) *Route
+	Trace(pattern string, h HandleFunc) *Route
+
+	// Alias serves the routes of newPattern at the deprecated oldPattern, or
+	// redirects to them, until the sunset date of the options
+	Alias(oldPattern, newPattern string, opts AliasOptions) *Route
+
+	// Validate returns the conflicts between the routes registered on the router
+	// and its sub-routers, naming the call site of each registration
+	Validate() error
+
+	// RouteList returns the routes of the router and its sub-routers with their metadata
+	RouteList() []Route
 
 	// NotFound defines a handler to respond whenever a route could
 	// not be found.
@@ -67,6 +98,11 @@ type RouterBlock func(block func(Router))
 // Deprecated: use Router instead of RouteGroup
 type RouteGroup = Router
 
+// Middleware wraps the handlers of the routes. The middlewares and the handler
+// of a request share its Ctx: the headers set by a middleware are sent with the
+// response, like with net/http, but the status set with Ctx.Status only applies
+// to the response of the middleware itself, the next ones starting with the
+// status of the response (200 until it is sent).
 type Middleware func(HandleFunc) HandleFunc
 
 // HandleFunc is the function signature for route handlers that can return errors
@@ -75,5 +111,139 @@ type HandleFunc func(*Ctx) error
 type RouterConfig struct {
 	AutoHEAD bool
 
+	// AutoOPTIONS answers the OPTIONS requests to a route without OPTIONS handler
+	// with 204 No Content and the Allow header, instead of 405 Method Not Allowed.
+	// The CORS preflight requests are answered before by the CORS middleware.
+	AutoOPTIONS bool
+
+	// Debug enables development checks, such as validating the payloads of
+	// Ctx.JSONBytes. Set from IS_DEBUG by New.
+	Debug bool
+
+	// TrailingSlashRedirect redirects the requests with a trailing slash to the
+	// route without it when the path only matches without the slash. Applied with
+	// CaseInsensitiveRouting, the trailing slash is significant otherwise. It also
+	// makes the requests to the prefix of a sub-router (Route, Mount) dispatch to
+	// its "/" route with or without a trailing slash, whether the prefix was
+	// registered with one or not.
 	TrailingSlashRedirect bool
+
+	// CaseInsensitiveRouting matches the request paths regardless of case, e.g.
+	// "/Users/5" matches "/users/{id}". The static parts of the route patterns are
+	// lowercased when registered, while the path parameters keep the case of the
+	// request path. Letters whose lowercase form has a different UTF-8 length are
+	// matched as is.
+	CaseInsensitiveRouting bool
+
+	// ParamPatterns are the shorthands of path parameter constraints, rewritten
+	// into their regular expression when routes are registered, e.g. with
+	// {"isbn": `[0-9]{13}`} the pattern "/books/{id:isbn}" is registered as
+	// "/books/{id:[0-9]{13}}". They add to DefaultParamPatterns (uuid, int and
+	// slug), overriding them for the same name. Requests whose parameters don't
+	// match get a 404 Not Found.
+	ParamPatterns map[string]string
+
+	// NotFoundMessage is the error message of the default 404 handler, used as a
+	// key of the MessageCatalog. Default: DefaultNotFoundMessage ("Route not found").
+	// Handlers registered with Router.NotFound take precedence.
+	NotFoundMessage string
+
+	// MethodNotAllowedMessage is the error message of the default 405 handler, used
+	// as a key of the MessageCatalog. Default: DefaultMethodNotAllowedMessage
+	// ("Method not allowed"). Handlers registered with Router.MethodNotAllowed take precedence.
+	MethodNotAllowedMessage string
+
+	// Trace enables the tracing of the middlewares and the handler run by the
+	// requests, in debug mode only. Set from REQUEST_TRACE and
+	// REQUEST_TRACE_TOKEN by New when IS_DEBUG=true.
+	Trace TraceConfig
+
+	// TrustedProxies are the proxies whose forwarding headers are trusted by
+	// Ctx.ClientIPNet. Set from TRUSTED_PROXIES by New.
+	TrustedProxies middleware.TrustedProxies
+
+	// Cookies holds the default attributes of the cookies set by Ctx, see
+	// CookiePolicy. Set from the COOKIE_ variables by New.
+	Cookies CookiePolicy
+
+	// MessageCatalog resolves errors.T markers in API error data using the request locale.
+	// When nil, markers are rendered as their key.
+	MessageCatalog *i18n.Catalog
+
+	// StrictSparseFields makes Ctx.JSONFields and Ctx.JSONSparse reject requested
+	// fields that don't exist in the response with a 400 Bad Request instead of ignoring them.
+	StrictSparseFields bool
+
+	// ETagCacheControl is the Cache-Control header of the responses sent by
+	// Ctx.JSONWithETag. Default: DefaultETagCacheControl ("private")
+	ETagCacheControl string
+
+	// IfMatchOptional makes Ctx.RequireIfMatch accept requests without an If-Match
+	// header instead of returning 428 Precondition Required.
+	IfMatchOptional bool
+
+	// ErrorReporter receives the errors rendered as 5xx responses, with the
+	// request metadata (route pattern, request ID, user, sanitized headers).
+	ErrorReporter ErrorReporter
+
+	// ErrorHandler is called with the errors returned by handlers and middlewares
+	// before they are rendered, e.g. to log them. Errors are tagged with their
+	// origin, see errors.OriginOf.
+	ErrorHandler func(c *Ctx, err error)
+
+	// ErrorClassifiers map errors to response statuses in Ctx.Fail, tried in order.
+	// When nil, DefaultErrorClassifiers is used.
+	ErrorClassifiers []ErrorClassifier
+
+	// JSONMediaTypes are the request media types accepted by Ctx.ParseBody.
+	// Entries starting with "+" match a structured syntax suffix, e.g. "+json".
+	// When nil, DefaultJSONMediaTypes is used.
+	JSONMediaTypes []string
+
+	// ErrorMediaType is the media type of error responses. MIMEProblemJSON renders
+	// errors as RFC 9457 problem details (type, title, status, detail, instance).
+	// Default: application/json, rendering the ApiError as is.
+	ErrorMediaType string
+
+	// JSONErrors renders the errors as JSON for all the clients, instead of an
+	// HTML page for the ones preferring HTML such as browsers
+	JSONErrors bool
+
+	// Decoders are the request body decoders by media type, see RegisterDecoder
+	Decoders map[string]DecodeFunc
+
+	// JSON holds the time and number encoding conventions of JSON responses and
+	// request bodies
+	JSON JSONConfig
+
+	// MaxResponseBytes is the maximum size of response bodies, guarding against
+	// handlers accidentally sending huge payloads. A response exceeding it in its
+	// first write is replaced by a 500 error, a response already being sent (e.g.
+	// streamed) is aborted by closing the connection. Either way an error is
+	// logged with the route pattern and size. Use Ctx.SetMaxResponseBytes for
+	// known large responses such as exports. Set from MAX_RESPONSE_BYTES by New.
+	// Default: 0, no limit.
+	MaxResponseBytes int64
+
+	// ServerTiming adds the durations of the built-in phases to the Server-Timing
+	// header of the responses: body parsing, validation and the total time until
+	// the response started, see Ctx.Timing. Set from ENABLE_SERVER_TIMING by New.
+	ServerTiming bool
+
+	// Metrics receives the metrics published by the router, such as the
+	// "response_too_large_total" counter labeled by route
+	Metrics MetricsCollector
+
+	// streams tracks the long-lived connections of the server serving the
+	// router, closed when its shutdown times out
+	streams *streamTracker
+
+	// stats collects the latencies of the routes, see Server.EnableStats
+	stats *routeStats
 }
+
+// ErrorReporter sends errors to an error tracking service. See errors.Reporter.
+type ErrorReporter = errors.Reporter
+
+// MetricsCollector receives the metrics published by glib, see middleware.MetricsCollector
+type MetricsCollector = middleware.MetricsCollector
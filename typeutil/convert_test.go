@@ -0,0 +1,151 @@
+package typeutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City string `json:"city" mapstructure:"city_name" validate:"required"`
+}
+
+type testBase struct {
+	ID int `json:"id" mapstructure:"id"`
+}
+
+type testClaims struct {
+	testBase
+	Subject   string        `json:"subject" mapstructure:"sub" validate:"required,email"`
+	Roles     []string      `json:"roles" mapstructure:"roles"`
+	Address   *testAddress  `json:"address" mapstructure:"address"`
+	Previous  []testAddress `json:"previous" mapstructure:"previous"`
+	ExpiresAt time.Time     `json:"expires_at" mapstructure:"exp"`
+	Ignored   string        `json:"-" mapstructure:"-"`
+}
+
+func TestConvertWithOptions(t *testing.T) {
+	exp := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := map[string]any{
+		"id":       7,
+		"sub":      "john@example.com",
+		"roles":    []any{"admin"},
+		"address":  map[string]any{"city_name": "Dakar"},
+		"previous": []any{map[string]any{"city_name": "Paris"}},
+		"exp":      exp,
+	}
+
+	t.Run("custom tag name", func(t *testing.T) {
+		claims, err := ConvertWithOptions[testClaims](source, ConvertOptions{TagName: "mapstructure", ErrorUnused: true, ErrorMissing: true})
+		require.NoError(t, err)
+		assert.Equal(t, testClaims{
+			testBase:  testBase{ID: 7},
+			Subject:   "john@example.com",
+			Roles:     []string{"admin"},
+			Address:   &testAddress{City: "Dakar"},
+			Previous:  []testAddress{{City: "Paris"}},
+			ExpiresAt: exp,
+		}, claims)
+	})
+
+	cases := []struct {
+		desc     string
+		source   any
+		opts     ConvertOptions
+		expected string
+	}{
+		{
+			desc:     "unknown fields",
+			source:   map[string]any{"subject": "a", "admin": true, "address": map[string]any{"city": "Dakar", "zip": "1"}},
+			opts:     ConvertOptions{ErrorUnused: true},
+			expected: "typeutil: unknown fields: address.zip, admin",
+		},
+		{
+			desc:     "missing required fields",
+			source:   map[string]any{"previous": []any{map[string]any{}}},
+			opts:     ConvertOptions{ErrorMissing: true},
+			expected: "typeutil: missing required fields: previous[0].city, subject",
+		},
+		{
+			desc:     "exact case by default",
+			source:   map[string]any{"Subject": "a"},
+			opts:     ConvertOptions{ErrorUnused: true, ErrorMissing: true},
+			expected: "typeutil: unknown fields: Subject; missing required fields: subject",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ConvertWithOptions[testClaims](tc.source, tc.opts)
+			var convertErr *ConvertError
+			require.ErrorAs(t, err, &convertErr)
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+
+	t.Run("case-insensitive", func(t *testing.T) {
+		claims, err := ConvertWithOptions[testClaims](map[string]any{"SUBJECT": "a", "Address": map[string]any{"CITY": "Dakar"}}, ConvertOptions{CaseInsensitive: true, ErrorUnused: true, ErrorMissing: true})
+		require.NoError(t, err)
+		assert.Equal(t, "a", claims.Subject)
+		assert.Equal(t, &testAddress{City: "Dakar"}, claims.Address)
+	})
+
+	t.Run("struct source", func(t *testing.T) {
+		claims, err := ConvertWithOptions[testClaims](struct {
+			Subject string `json:"subject"`
+		}{"a"}, ConvertOptions{ErrorMissing: true})
+		require.NoError(t, err)
+		assert.Equal(t, "a", claims.Subject)
+	})
+}
+
+func TestConvertSlice(t *testing.T) {
+	addresses, err := ConvertSlice[testAddress]([]any{map[string]any{"city": "Dakar"}, map[string]any{"city": "Paris"}})
+	require.NoError(t, err)
+	assert.Equal(t, []testAddress{{City: "Dakar"}, {City: "Paris"}}, addresses)
+
+	_, err = ConvertSlice[testAddress]([]any{map[string]any{"city": "Dakar"}, "Paris"})
+	assert.ErrorContains(t, err, "element 1")
+}
+
+var benchSource = map[string]any{
+	"id":      7,
+	"subject": "john@example.com",
+	"roles":   []any{"admin", "billing"},
+	"address": map[string]any{"city": "Dakar"},
+}
+
+func BenchmarkConvert(b *testing.B) {
+	for b.Loop() {
+		if _, err := Convert[testClaims](benchSource); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertWithOptions(b *testing.B) {
+	opts := ConvertOptions{ErrorUnused: true, ErrorMissing: true}
+	for b.Loop() {
+		if _, err := ConvertWithOptions[testClaims](benchSource, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkConvertManual is the hand-written baseline of the conversions above
+func BenchmarkConvertManual(b *testing.B) {
+	for b.Loop() {
+		roles := benchSource["roles"].([]any)
+		claims := testClaims{
+			testBase: testBase{ID: benchSource["id"].(int)},
+			Subject:  benchSource["subject"].(string),
+			Roles:    make([]string, len(roles)),
+			Address:  &testAddress{City: benchSource["address"].(map[string]any)["city"].(string)},
+		}
+		for i, role := range roles {
+			claims.Roles[i] = role.(string)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package typeutil
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ConvertOptions configures ConvertWithOptions
+type ConvertOptions struct {
+	// TagName is the struct tag naming the fields in the source, e.g.
+	// "mapstructure" (default: "json")
+	TagName string
+
+	// CaseInsensitive matches the source keys with the field names ignoring case
+	CaseInsensitive bool
+
+	// ErrorUnused reports the source keys matching no field
+	ErrorUnused bool
+
+	// ErrorMissing reports the fields tagged `validate:"required"` missing from the source
+	ErrorMissing bool
+}
+
+// ConvertError reports the fields not matching between the source and the
+// destination type. Nested fields are named with their path, e.g. "address.city"
+// or "items[0].id".
+type ConvertError struct {
+	// Unused are the source keys matching no field (ConvertOptions.ErrorUnused)
+	Unused []string
+	// Missing are the required fields missing from the source (ConvertOptions.ErrorMissing)
+	Missing []string
+}
+
+// Error implements the error interface
+func (e *ConvertError) Error() string {
+	var parts []string
+	if len(e.Unused) > 0 {
+		parts = append(parts, "unknown fields: "+strings.Join(e.Unused, ", "))
+	}
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required fields: "+strings.Join(e.Missing, ", "))
+	}
+	return "typeutil: " + strings.Join(parts, "; ")
+}
+
+// ConvertWithOptions converts data into the desired type like Convert, matching
+// the source keys with the struct fields according to the options, including
+// in nested structs, slices and maps. Unlike Convert, source keys must match
+// the field names exactly unless CaseInsensitive is set.
+//
+// Example:
+//
+//	type Claims struct {
+//	    Subject string   `mapstructure:"sub" json:"subject" validate:"required"`
+//	    Roles   []string `mapstructure:"roles" json:"roles"`
+//	}
+//	claims, err := typeutil.ConvertWithOptions[Claims](token.Claims, typeutil.ConvertOptions{
+//	    TagName:      "mapstructure",
+//	    ErrorUnused:  true,
+//	    ErrorMissing: true,
+//	})
+func ConvertWithOptions[T any](data any, opts ConvertOptions) (T, error) {
+	if opts.TagName == "" {
+		opts.TagName = "json"
+	}
+
+	source, err := toGeneric(data)
+	if err != nil {
+		var result T
+		return result, err
+	}
+
+	var convertErr ConvertError
+	mapped := remap(source, reflect.TypeFor[T](), opts, "", &convertErr)
+	if len(convertErr.Unused) > 0 || len(convertErr.Missing) > 0 {
+		slices.Sort(convertErr.Unused)
+		slices.Sort(convertErr.Missing)
+		var result T
+		return result, &convertErr
+	}
+	return Convert[T](mapped)
+}
+
+// ConvertSlice converts each element of data into the desired type with Convert
+func ConvertSlice[T any](data []any) ([]T, error) {
+	result := make([]T, len(data))
+	for i, item := range data {
+		v, err := Convert[T](item)
+		if err != nil {
+			return nil, fmt.Errorf("typeutil: element %d: %w", i, err)
+		}
+		result[i] = v
+	}
+	return result, nil
+}
+
+// toGeneric returns data as decoded by encoding/json into an any value
+func toGeneric(data any) (any, error) {
+	switch data.(type) {
+	case map[string]any, []any, nil:
+		return data, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	err = json.Unmarshal(encoded, &generic)
+	return generic, err
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// remap renames the keys of the objects of value to the JSON names of the
+// fields of t they match, recording the unused keys and missing fields
+func remap(value any, t reflect.Type, opts ConvertOptions, path string, convertErr *ConvertError) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			return remapStruct(v, t, opts, path, convertErr)
+		case reflect.Map:
+			out := make(map[string]any, len(v))
+			for key, item := range v {
+				out[key] = remap(item, t.Elem(), opts, joinPath(path, key), convertErr)
+			}
+			return out
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			out := make([]any, len(v))
+			for i, item := range v {
+				out[i] = remap(item, t.Elem(), opts, fmt.Sprintf("%s[%d]", path, i), convertErr)
+			}
+			return out
+		}
+	}
+	return value
+}
+
+func remapStruct(source map[string]any, t reflect.Type, opts ConvertOptions, path string, convertErr *ConvertError) map[string]any {
+	fields := fieldsOf(t, opts.TagName)
+	out := make(map[string]any, len(source))
+	used := make(map[string]bool, len(source))
+
+	for _, f := range fields {
+		key, ok := f.name, false
+		if _, ok = source[key]; !ok && opts.CaseInsensitive {
+			for k := range source {
+				if strings.EqualFold(k, f.name) {
+					key, ok = k, true
+					break
+				}
+			}
+		}
+		if !ok {
+			if f.required && opts.ErrorMissing {
+				convertErr.Missing = append(convertErr.Missing, joinPath(path, f.name))
+			}
+			continue
+		}
+		used[key] = true
+		out[f.jsonName] = remap(source[key], f.typ, opts, joinPath(path, f.name), convertErr)
+	}
+
+	if opts.ErrorUnused {
+		for key := range source {
+			if !used[key] {
+				convertErr.Unused = append(convertErr.Unused, joinPath(path, key))
+			}
+		}
+	}
+	return out
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// convertField is a struct field matched by ConvertWithOptions
+type convertField struct {
+	// name is the key of the field in the source, from the tag of the options
+	name string
+	// jsonName is the key decoded into the field by encoding/json
+	jsonName string
+	typ      reflect.Type
+	required bool
+}
+
+type fieldsKey struct {
+	t       reflect.Type
+	tagName string
+}
+
+// fieldsCache caches the fields of the struct types by tag name
+var fieldsCache sync.Map
+
+// fieldsOf returns the fields of the struct type, those of embedded structs included
+func fieldsOf(t reflect.Type, tagName string) []convertField {
+	key := fieldsKey{t: t, tagName: tagName}
+	if cached, ok := fieldsCache.Load(key); ok {
+		return cached.([]convertField)
+	}
+
+	var fields []convertField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(sf.Tag.Get(tagName), ",")
+		jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" || jsonName == "-" {
+			continue
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && jsonName == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, fieldsOf(ft, tagName)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		if jsonName == "" {
+			jsonName = sf.Name
+		}
+		fields = append(fields, convertField{
+			name:     name,
+			jsonName: jsonName,
+			typ:      sf.Type,
+			required: isRequired(sf.Tag.Get("validate")),
+		})
+	}
+
+	fieldsCache.Store(key, fields)
+	return fields
+}
+
+// isRequired reports whether the validate tag has the "required" rule
+func isRequired(tag string) bool {
+	for rule := range strings.SplitSeq(tag, ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package glib
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	gerrors "github.com/azizndao/glib/errors"
+)
+
+const (
+	// envUpgradeListeners lists the addresses of the listeners inherited by the
+	// child process of an upgrade, passed as the file descriptors 3, 4, ...
+	envUpgradeListeners = "GLIB_UPGRADE_LISTENERS"
+	// envUpgradeReady is the file descriptor of the pipe the child closes once ready
+	envUpgradeReady = "GLIB_UPGRADE_READY_FD"
+)
+
+// upgradeCommand returns the command starting the new process of an upgrade
+var upgradeCommand = defaultUpgradeCommand
+
+// defaultUpgradeCommand runs the current executable with the same arguments
+func defaultUpgradeCommand() (*exec.Cmd, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd, nil
+}
+
+// bind returns the listener of the address: the one inherited from the parent
+// process after an upgrade, or a new one, with SO_REUSEPORT if enabled
+func (s *Server) bind(addr string) (net.Listener, error) {
+	s.listenersMu.Lock()
+	ln, ok := s.inherited[addr]
+	delete(s.inherited, addr)
+	s.listenersMu.Unlock()
+	if ok {
+		return ln, nil
+	}
+
+	var lc net.ListenConfig
+	if s.reusePort {
+		lc.Control = reusePortControl
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+// inheritListeners takes the listeners passed by the parent process of an
+// upgrade, and the pipe to close once the server is ready. Returns nil if the
+// process was not started by an upgrade.
+func (s *Server) inheritListeners() (*os.File, error) {
+	addrs := os.Getenv(envUpgradeListeners)
+	if addrs == "" {
+		return nil, nil
+	}
+	readyFD, err := strconv.Atoi(os.Getenv(envUpgradeReady))
+	if err != nil {
+		return nil, gerrors.Errorf("invalid %s: %w", envUpgradeReady, err)
+	}
+	// The next upgrade passes its own listeners
+	_ = os.Unsetenv(envUpgradeListeners)
+	_ = os.Unsetenv(envUpgradeReady)
+
+	inherited := make(map[string]net.Listener)
+	for i, addr := range strings.Split(addrs, ",") {
+		f := os.NewFile(uintptr(3+i), addr)
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, gerrors.Errorf("failed to inherit listener %s: %w", addr, err)
+		}
+		inherited[addr] = ln
+	}
+
+	s.listenersMu.Lock()
+	s.inherited = inherited
+	s.listenersMu.Unlock()
+	return os.NewFile(uintptr(readyFD), "ready"), nil
+}
+
+// upgrade starts a new process of the server inheriting its listeners, and
+// waits until it is ready to serve requests or timeout elapses
+func (s *Server) upgrade(timeout time.Duration) error {
+	s.listenersMu.Lock()
+	bound := s.bound
+	s.listenersMu.Unlock()
+	if len(bound) == 0 {
+		return gerrors.Errorf("upgrade failed: the server is not listening")
+	}
+
+	files := make([]*os.File, 0, len(bound)+1)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	addrs := make([]string, len(bound))
+	for i, b := range bound {
+		filer, ok := b.listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return gerrors.Errorf("upgrade failed: listener %s can't be passed", b.addr)
+		}
+		f, err := filer.File()
+		if err != nil {
+			return gerrors.Errorf("upgrade failed: %w", err)
+		}
+		files = append(files, f)
+		addrs[i] = b.addr
+	}
+
+	ready, readyWriter, err := os.Pipe()
+	if err != nil {
+		return gerrors.Errorf("upgrade failed: %w", err)
+	}
+	defer ready.Close()
+	files = append(files, readyWriter)
+
+	cmd, err := upgradeCommand()
+	if err != nil {
+		return gerrors.Errorf("upgrade failed: %w", err)
+	}
+	cmd.ExtraFiles = files
+	cmd.Env = append(cmd.Environ(),
+		envUpgradeListeners+"="+strings.Join(addrs, ","),
+		fmt.Sprintf("%s=%d", envUpgradeReady, 3+len(addrs)),
+	)
+	if err := cmd.Start(); err != nil {
+		return gerrors.Errorf("upgrade failed: %w", err)
+	}
+	// Only the child holds the write end, reading returns when it closes it or exits
+	_ = readyWriter.Close()
+	files = files[:len(files)-1]
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	signaled := make(chan bool, 1)
+	go func() {
+		var b [1]byte
+		n, _ := ready.Read(b[:])
+		signaled <- n == 1
+	}()
+
+	select {
+	case ok := <-signaled:
+		if ok {
+			return nil
+		}
+		_ = cmd.Process.Kill()
+		return gerrors.Errorf("upgrade failed: the new process exited before being ready: %v", <-exited)
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		return gerrors.Errorf("upgrade failed: the new process was not ready after %s", timeout)
+	}
+}
+
+// notifyReady signals the parent process of an upgrade once the server is ready
+func (s *Server) notifyReady(ready *os.File) {
+	if err := s.WaitReady(context.Background()); err == nil {
+		_, _ = ready.Write([]byte{1})
+	}
+	_ = ready.Close()
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package glib
+
+import gerrors "github.com/azizndao/glib/errors"
+
+// ListenUpgradeable is only supported on Unix systems, use ListenWithGracefulShutdown
+func (s *Server) ListenUpgradeable() error {
+	return gerrors.Errorf("upgradeable listeners are not supported on this platform")
+}
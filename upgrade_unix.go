@@ -0,0 +1,69 @@
+//go:build unix
+
+package glib
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	gerrors "github.com/azizndao/glib/errors"
+)
+
+// ListenUpgradeable starts the server like ListenWithGracefulShutdown, and
+// upgrades it without downtime on SIGUSR2: a new process of the current
+// executable is started with the listeners of the server, and once it is ready
+// to serve requests (see MarkReady), this one drains its connections and
+// exits. If the new process fails to get ready within the shutdown timeout, it
+// is killed and this one keeps serving.
+//
+// Deploy by replacing the executable, then sending SIGUSR2 to the process:
+//
+//	cp ./app-v2 /usr/local/bin/app && kill -USR2 $(pidof app)
+//
+// With a process supervisor (systemd, Docker...), the new process is not its
+// child: prefer Config.ReusePort and starting the new version before stopping the old.
+func (s *Server) ListenUpgradeable() error {
+	ready, err := s.inheritListeners()
+	if err != nil {
+		return err
+	}
+	if ready != nil {
+		go s.notifyReady(ready)
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR2)
+	defer signal.Stop(quit)
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- s.Listen()
+	}()
+
+	for {
+		select {
+		case err := <-serverErrors:
+			return gerrors.Errorf("server error: %w", err)
+		case sig := <-quit:
+			if sig == syscall.SIGUSR2 {
+				s.logger.InfoContext(context.Background(), "Received upgrade signal, starting the new process")
+				if err := s.upgrade(s.shutdownTimeout); err != nil {
+					s.logger.Error(err)
+					continue
+				}
+				s.logger.InfoContext(context.Background(), "New process ready, draining connections")
+			} else {
+				s.logger.InfoContext(context.Background(), "Received shutdown signal", "signal", sig.String())
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+			defer cancel()
+			if err := s.Shutdown(ctx); err != nil {
+				return gerrors.Errorf("graceful shutdown failed: %w", err)
+			}
+			return nil
+		}
+	}
+}
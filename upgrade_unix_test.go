@@ -0,0 +1,73 @@
+//go:build unix
+
+package glib
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpgradeChild is the new process started by TestServer_Upgrade
+func TestUpgradeChild(t *testing.T) {
+	if os.Getenv("GLIB_TEST_UPGRADE_ADDR") == "" {
+		t.Skip("helper process of TestServer_Upgrade")
+	}
+
+	s := newListenerTestServer(t)
+	s.httpServer.Addr = os.Getenv("GLIB_TEST_UPGRADE_ADDR")
+	s.router.Get("/version", func(c *Ctx) error { return c.SendString("child") })
+	s.router.Get("/exit", func(c *Ctx) error {
+		go func() { _ = s.Shutdown(t.Context()) }()
+		return c.NoContent()
+	})
+
+	ready, err := s.inheritListeners()
+	require.NoError(t, err)
+	require.NotNil(t, ready)
+	go s.notifyReady(ready)
+
+	require.NoError(t, s.Listen())
+}
+
+func TestServer_Upgrade(t *testing.T) {
+	s := newListenerTestServer(t)
+	s.router.Get("/version", func(c *Ctx) error { return c.SendString("parent") })
+	addr := s.Address()
+
+	done := make(chan error, 1)
+	go func() { done <- s.Listen() }()
+	require.NoError(t, s.WaitReady(t.Context()))
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) string {
+		resp, err := client.Get("http://" + addr + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	assert.Equal(t, "parent", get("/version"))
+
+	upgradeCommand = func() (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestUpgradeChild$")
+		cmd.Env = append(os.Environ(), "GLIB_TEST_UPGRADE_ADDR="+addr)
+		return cmd, nil
+	}
+	t.Cleanup(func() { upgradeCommand = defaultUpgradeCommand })
+
+	require.NoError(t, s.upgrade(10*time.Second))
+
+	// The parent drains, the child keeps serving on the same listener
+	require.NoError(t, s.Shutdown(t.Context()))
+	require.NoError(t, <-done)
+	assert.Equal(t, "child", get("/version"))
+	get("/exit")
+}
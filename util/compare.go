@@ -0,0 +1,16 @@
+package util
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// SecureCompare reports whether a and b are equal in constant time, including
+// when their lengths differ, for comparing secrets such as passwords or API
+// tokens without leaking timing information
+func SecureCompare(a, b string) bool {
+	// Hashing first makes the comparison independent of the lengths
+	hashA := sha256.Sum256([]byte(a))
+	hashB := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(hashA[:], hashB[:]) == 1
+}
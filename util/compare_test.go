@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureCompare(t *testing.T) {
+	cases := []struct {
+		desc     string
+		a, b     string
+		expected bool
+	}{
+		{desc: "equal", a: "s3cret", b: "s3cret", expected: true},
+		{desc: "empty", a: "", b: "", expected: true},
+		{desc: "different", a: "s3cret", b: "s3creT"},
+		{desc: "prefix", a: "s3cret", b: "s3cre"},
+		{desc: "one empty", a: "s3cret", b: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SecureCompare(tc.a, tc.b))
+		})
+	}
+}
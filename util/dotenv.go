@@ -0,0 +1,180 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// DefaultEnvFiles returns the env files loaded by glib.New, by decreasing
+// priority: .env.local, .env.$APP_ENV (e.g. .env.staging, skipped when APP_ENV
+// is not set) and .env
+func DefaultEnvFiles() []string {
+	return []string{".env.local", ".env.$APP_ENV", ".env"}
+}
+
+// LoadEnvFiles sets the environment variables defined in the env files, by
+// decreasing priority: the first value found for a variable wins, and variables
+// already set in the environment are never overridden. Missing files are
+// skipped, and $VAR references in the file names are expanded with the
+// environment as loaded so far (e.g. APP_ENV can be set in .env.local). It
+// returns the files that were loaded.
+//
+// Files contain KEY=VALUE lines, optionally prefixed with "export". Values can
+// be single-quoted (literal) or double-quoted (supporting \n, \t, \" and \\
+// escapes). Empty lines and lines starting with # are ignored, as are comments
+// after unquoted values (" # comment"). Malformed lines are skipped and
+// reported in the returned error.
+func LoadEnvFiles(files ...string) ([]string, error) {
+	var loaded []string
+	var errs []error
+	for _, name := range files {
+		name, ok := expandEnvFile(name)
+		if !ok {
+			continue
+		}
+
+		f, err := os.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			var info fs.FileInfo
+			if info, err = f.Stat(); err == nil && info.IsDir() {
+				err = fmt.Errorf("%s: is a directory", name)
+			}
+			if err != nil {
+				f.Close()
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		vars, err := parseEnvFile(name, f)
+		f.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		for _, v := range vars {
+			if _, set := os.LookupEnv(v.key); !set {
+				_ = os.Setenv(v.key, v.value)
+			}
+		}
+		loaded = append(loaded, name)
+	}
+	return loaded, errors.Join(errs...)
+}
+
+// expandEnvFile expands the $VAR references of an env file name. It reports
+// false when a referenced variable is not set.
+func expandEnvFile(name string) (string, bool) {
+	ok := true
+	expanded := os.Expand(name, func(key string) string {
+		value := os.Getenv(key)
+		if value == "" {
+			ok = false
+		}
+		return value
+	})
+	return expanded, ok
+}
+
+type envVar struct {
+	key, value string
+}
+
+// parseEnvFile parses the variables of an env file, in order. Malformed lines
+// are reported in the error, with the file name and line number.
+func parseEnvFile(name string, r io.Reader) ([]envVar, error) {
+	var vars []envVar
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		key, value, found := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !found || !isEnvName(key) {
+			errs = append(errs, fmt.Errorf("%s:%d: expected KEY=VALUE", name, line))
+			continue
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", name, line, err))
+			continue
+		}
+		vars = append(vars, envVar{key: key, value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return vars, errors.Join(errs...)
+}
+
+// parseEnvValue parses a quoted or unquoted env file value
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch quote := value[0]; quote {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		return value[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double-quoted value")
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
+
+// isEnvName reports whether key is a valid environment variable name
+func isEnvName(key string) bool {
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unsetEnv unsets the variables for the test, restoring them afterwards
+func unsetEnv(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+}
+
+func TestLoadEnvFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	unsetEnv(t, "DOTENV_PORT", "DOTENV_HOST", "DOTENV_NAME", "DOTENV_DB", "DOTENV_REAL", "APP_ENV")
+	t.Setenv("DOTENV_REAL", "from the environment")
+
+	files := map[string]string{
+		".env.local": "DOTENV_PORT=9000\nAPP_ENV=staging\n",
+		".env.staging": strings.Join([]string{
+			"# Staging",
+			"export DOTENV_HOST=staging.local",
+			"DOTENV_PORT=8000",
+			`DOTENV_DB="postgres://db\nhost"`,
+		}, "\n"),
+		".env": strings.Join([]string{
+			"DOTENV_PORT=8080",
+			"DOTENV_HOST=localhost",
+			"DOTENV_NAME='glib # app' # the app name",
+			"DOTENV_REAL=from the file",
+		}, "\n"),
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+
+	loaded, err := LoadEnvFiles(append(DefaultEnvFiles(), ".env.missing")...)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{".env.local", ".env.staging", ".env"}, loaded)
+	assert.Equal(t, "9000", os.Getenv("DOTENV_PORT"), "the first file wins")
+	assert.Equal(t, "staging.local", os.Getenv("DOTENV_HOST"), "APP_ENV set by .env.local")
+	assert.Equal(t, "postgres://db\nhost", os.Getenv("DOTENV_DB"))
+	assert.Equal(t, "glib # app", os.Getenv("DOTENV_NAME"))
+	assert.Equal(t, "from the environment", os.Getenv("DOTENV_REAL"), "the environment wins")
+}
+
+func TestLoadEnvFiles_WithoutAppEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	unsetEnv(t, "APP_ENV", "DOTENV_PORT")
+	require.NoError(t, os.WriteFile(".env.", []byte("DOTENV_PORT=1\n"), 0o600))
+
+	loaded, err := LoadEnvFiles(DefaultEnvFiles()...)
+	require.NoError(t, err)
+	assert.Empty(t, loaded)
+	assert.Empty(t, os.Getenv("DOTENV_PORT"))
+}
+
+func TestParseEnvFile(t *testing.T) {
+	tests := []struct {
+		desc      string
+		content   string
+		expected  []envVar
+		expectErr string
+	}{
+		{desc: "unquoted", content: "KEY=value", expected: []envVar{{"KEY", "value"}}},
+		{desc: "spaces around", content: "  KEY = value  ", expected: []envVar{{"KEY", "value"}}},
+		{desc: "empty", content: "KEY=", expected: []envVar{{"KEY", ""}}},
+		{desc: "inline comment", content: "KEY=value # comment", expected: []envVar{{"KEY", "value"}}},
+		{desc: "hash in value", content: "KEY=a#b", expected: []envVar{{"KEY", "a#b"}}},
+		{desc: "single quotes are literal", content: `KEY='a\nb'`, expected: []envVar{{"KEY", `a\nb`}}},
+		{desc: "double quote escapes", content: `KEY="a\n\"b\"\\"`, expected: []envVar{{"KEY", "a\n\"b\"\\"}}},
+		{desc: "equals in value", content: "KEY=a=b", expected: []envVar{{"KEY", "a=b"}}},
+		{desc: "export", content: "export KEY=value", expected: []envVar{{"KEY", "value"}}},
+		{
+			desc:      "malformed lines skipped",
+			content:   "KEY=value\nnot a variable\n1KEY=x\nQUOTED=\"open\nOTHER=ok",
+			expected:  []envVar{{"KEY", "value"}, {"OTHER", "ok"}},
+			expectErr: ".env:2: expected KEY=VALUE\n.env:3: expected KEY=VALUE\n.env:4: unterminated double-quoted value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			vars, err := parseEnvFile(".env", strings.NewReader(tt.content))
+			assert.Equal(t, tt.expected, vars)
+			if tt.expectErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectErr)
+			}
+		})
+	}
+}
+
+func TestLoadEnvFiles_Directory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+
+	loaded, err := LoadEnvFiles(dir)
+	assert.EqualError(t, err, dir+": is a directory")
+	assert.Empty(t, loaded)
+}
@@ -4,12 +4,54 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// MaskedValue replaces the value of secret environment variables in EnvSettings
+const MaskedValue = "****"
+
+// readKeys records the environment variables read by the GetEnv functions
+var readKeys sync.Map
+
+// getenv returns the environment variable value, recording the key for EnvSettings
+func getenv(key string) string {
+	readKeys.Store(key, struct{}{})
+	return os.Getenv(key)
+}
+
+// EnvSettings returns the environment variables read by the GetEnv functions
+// that are set, with their values. Secret values are masked (see IsSecretEnv).
+func EnvSettings() map[string]string {
+	settings := make(map[string]string)
+	readKeys.Range(func(key, _ any) bool {
+		name := key.(string)
+		if value := os.Getenv(name); value != "" {
+			if IsSecretEnv(name) {
+				value = MaskedValue
+			}
+			settings[name] = value
+		}
+		return true
+	})
+	return settings
+}
+
+// IsSecretEnv reports whether the environment variable holds a secret, its name
+// ending with _SECRET, _TOKEN, _KEY or _PASSWORD
+func IsSecretEnv(key string) bool {
+	key = strings.ToUpper(key)
+	for _, suffix := range []string{"SECRET", "TOKEN", "KEY", "PASSWORD"} {
+		if key == suffix || strings.HasSuffix(key, "_"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetEnv returns the environment variable value or the default if not set
 func GetEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		return strings.TrimSpace(value)
 	}
 	return defaultValue
@@ -17,20 +59,33 @@ func GetEnv(key, defaultValue string) string {
 
 // GetEnvInt returns the environment variable value as int or the default if not set or invalid
 func GetEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
 			return intVal
 		}
+		recordInvalid(key, value, EnvInt)
 	}
 	return defaultValue
 }
 
 // GetEnvInt64 returns the environment variable value as int64 or the default if not set or invalid
 func GetEnvInt64(key string, defaultValue int64) int64 {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
 			return intVal
 		}
+		recordInvalid(key, value, EnvInt)
+	}
+	return defaultValue
+}
+
+// GetEnvFloat64 returns the environment variable value as float64 or the default if not set or invalid
+func GetEnvFloat64(key string, defaultValue float64) float64 {
+	if value := getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+		recordInvalid(key, value, EnvFloat)
 	}
 	return defaultValue
 }
@@ -38,23 +93,33 @@ func GetEnvInt64(key string, defaultValue int64) int64 {
 // GetEnvBool returns the environment variable value as bool or the default if not set or invalid
 // Accepts: true/false, 1/0, yes/no, on/off (case insensitive)
 func GetEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		switch value {
-		case "true", "1", "yes", "on", "True", "TRUE", "YES", "ON":
-			return true
-		case "false", "0", "no", "off", "False", "FALSE", "NO", "OFF":
-			return false
+	if value := getenv(key); value != "" {
+		if boolVal, ok := parseBool(value); ok {
+			return boolVal
 		}
+		recordInvalid(key, value, EnvBool)
 	}
 	return defaultValue
 }
 
+// parseBool parses the boolean values accepted by GetEnvBool
+func parseBool(value string) (bool, bool) {
+	switch value {
+	case "true", "1", "yes", "on", "True", "TRUE", "YES", "ON":
+		return true, true
+	case "false", "0", "no", "off", "False", "FALSE", "NO", "OFF":
+		return false, true
+	}
+	return false, false
+}
+
 // GetEnvDuration returns the environment variable value as time.Duration or the default if not set or invalid
 func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
+		recordInvalid(key, value, EnvDuration)
 	}
 	return defaultValue
 }
@@ -63,33 +128,34 @@ func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
 // Values should be comma-separated. Whitespace around each value is trimmed.
 // Example: "value1,value2,value3" or "value1, value2, value3"
 func GetEnvStringSlice(key string, defaultValue []string) []string {
-	value := os.Getenv(key)
+	value := getenv(key)
 	if value == "" {
 		return defaultValue
 	}
 
-	// Split by comma and trim whitespace
-	parts := strings.Split(value, ",")
-	result := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-
-	// Return default if no valid values found
+	result := splitList(value)
 	if len(result) == 0 {
+		// Return default if no valid values found
 		return defaultValue
 	}
+	return result
+}
 
+// splitList splits a comma-separated value, trimming whitespace and skipping empty values
+func splitList(value string) []string {
+	var result []string
+	for part := range strings.SplitSeq(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
 	return result
 }
 
 // GetEnvLogFormat returns the environment variable value as a log format string or the default if not set or invalid
 // Accepts: default, combined, short, tiny (case insensitive)
 func GetEnvLogFormat(key string, defaultValue string) string {
-	value := os.Getenv(key)
+	value := getenv(key)
 	if value == "" {
 		return defaultValue
 	}
@@ -102,6 +168,24 @@ func GetEnvLogFormat(key string, defaultValue string) string {
 	case "default", "combined", "short", "tiny":
 		return normalized
 	default:
+		invalidEnv.Store(key, &EnvError{Name: key, Value: value, Expected: "one of default, combined, short, tiny"})
 		return defaultValue
 	}
 }
+
+// GetEnvOneOf returns the environment variable value if it is one of the
+// accepted values, case-insensitively, or the default if not set or invalid
+func GetEnvOneOf(key string, defaultValue string, values ...string) string {
+	value := strings.TrimSpace(getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+
+	for _, accepted := range values {
+		if strings.EqualFold(accepted, value) {
+			return accepted
+		}
+	}
+	invalidEnv.Store(key, newEnvError(key, value, "one of "+strings.Join(values, ", ")))
+	return defaultValue
+}
@@ -0,0 +1,193 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationType is the type of the duration fields, parsed with time.ParseDuration
+var durationType = reflect.TypeFor[time.Duration]()
+
+// LoadEnv populates the fields of the struct pointed by out from environment
+// variables, so that a configuration struct documents its variables in one
+// place. Fields are mapped with struct tags:
+//
+//   - env:"PORT": the name of the variable, prefixed by prefix
+//   - default:"8080": the value used when the variable is not set or invalid,
+//     otherwise the current value of the field is kept
+//   - required:"true": the variable must be set
+//   - oneof:"default,combined,short,tiny": the accepted values, case-insensitively
+//     (e.g. log formats)
+//
+// Supported types are string, bool, ints, floats, time.Duration and []string
+// (comma-separated), parsed like the GetEnv functions, and their named types.
+// Struct fields without env tag are loaded recursively.
+//
+// All the missing and invalid variables are reported in the returned error,
+// the invalid values being also recorded for InvalidEnv.
+//
+// Example:
+//
+//	type Config struct {
+//	    Port        int           `env:"PORT" default:"8080"`
+//	    DatabaseURL string        `env:"DATABASE_URL" required:"true"`
+//	    Timeout     time.Duration `env:"TIMEOUT" default:"10s"`
+//	}
+//
+//	var cfg Config
+//	if err := util.LoadEnv("APP_", &cfg); err != nil {
+//	    log.Fatal(err)
+//	}
+func LoadEnv(prefix string, out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("util.LoadEnv: expected a pointer to a struct, got %T", out)
+	}
+
+	var errs []*EnvError
+	if err := loadEnvStruct(prefix, v.Elem(), &errs); err != nil {
+		return err
+	}
+	return joinEnvErrors(errs)
+}
+
+// loadEnvStruct loads the fields of the struct, appending the invalid
+// variables to errs. Returns an error for the invalid struct definitions.
+func loadEnvStruct(prefix string, v reflect.Value, errs *[]*EnvError) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				if err := loadEnvStruct(prefix, v.Field(i), errs); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		key := prefix + name
+		if err := loadEnvField(key, field, v.Field(i), errs); err != nil {
+			return fmt.Errorf("util.LoadEnv: field %s (%s): %w", field.Name, key, err)
+		}
+	}
+	return nil
+}
+
+// loadEnvField sets the field from the variable key, or from its default
+func loadEnvField(key string, field reflect.StructField, v reflect.Value, errs *[]*EnvError) error {
+	oneOf := splitList(field.Tag.Get("oneof"))
+	expected, err := envExpected(field.Type, oneOf)
+	if err != nil {
+		return err
+	}
+	defaultValue, hasDefault := field.Tag.Lookup("default")
+	setDefault := func() error {
+		if !hasDefault {
+			return nil
+		}
+		if !setEnvValue(v, defaultValue, oneOf) {
+			return fmt.Errorf("invalid default %q, expected %s", defaultValue, expected)
+		}
+		return nil
+	}
+
+	value := strings.TrimSpace(getenv(key))
+	if field.Type.Kind() == reflect.Slice && len(splitList(value)) == 0 {
+		value = ""
+	}
+	if value == "" {
+		if field.Tag.Get("required") == "true" {
+			*errs = append(*errs, &EnvError{Name: key, Expected: expected})
+		}
+		return setDefault()
+	}
+
+	if !setEnvValue(v, value, oneOf) {
+		envErr := newEnvError(key, value, expected)
+		invalidEnv.Store(key, envErr)
+		*errs = append(*errs, envErr)
+		return setDefault()
+	}
+	return nil
+}
+
+// envExpected describes the format of the values of the type, as reported by
+// EnvError. Returns an error if the type is not supported.
+func envExpected(t reflect.Type, oneOf []string) (string, error) {
+	if t == durationType {
+		return EnvDuration.String(), nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		if len(oneOf) > 0 {
+			return "one of " + strings.Join(oneOf, ", "), nil
+		}
+		return EnvString.String(), nil
+	case reflect.Bool:
+		return EnvBool.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return EnvInt.String(), nil
+	case reflect.Float32, reflect.Float64:
+		return EnvFloat.String(), nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return "a comma-separated list", nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// setEnvValue parses the value into v, reporting whether it is valid
+func setEnvValue(v reflect.Value, value string, oneOf []string) bool {
+	if v.Type() == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return false
+		}
+		v.SetInt(int64(duration))
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if len(oneOf) > 0 {
+			i := slices.IndexFunc(oneOf, func(accepted string) bool { return strings.EqualFold(accepted, value) })
+			if i < 0 {
+				return false
+			}
+			value = oneOf[i]
+		}
+		v.SetString(value)
+	case reflect.Bool:
+		b, ok := parseBool(value)
+		if !ok {
+			return false
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return false
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return false
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(value)).Convert(v.Type()))
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLevel string
+
+type testEnvConfig struct {
+	Host      string        `env:"HOST" default:"localhost"`
+	Port      int           `env:"PORT" default:"8080"`
+	MaxBytes  int64         `env:"MAX_BYTES"`
+	Ratio     float64       `env:"RATIO" default:"0.5"`
+	Debug     bool          `env:"DEBUG"`
+	Timeout   time.Duration `env:"TIMEOUT" default:"10s"`
+	Origins   []string      `env:"ORIGINS"`
+	LogFormat string        `env:"LOG_FORMAT" oneof:"default,combined,short,tiny" default:"default"`
+	Level     testLevel     `env:"LEVEL"`
+	Kept      string        `env:"KEPT"`
+	Limits    struct {
+		Max int `env:"LIMIT_MAX"`
+	}
+	ignored string `env:"IGNORED"`
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("APP_PORT", "9090")
+	t.Setenv("APP_MAX_BYTES", "1048576")
+	t.Setenv("APP_DEBUG", "yes")
+	t.Setenv("APP_TIMEOUT", " 1m30s ")
+	t.Setenv("APP_ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("APP_LOG_FORMAT", "Combined")
+	t.Setenv("APP_LEVEL", "warn")
+	t.Setenv("APP_LIMIT_MAX", "3")
+	t.Setenv("APP_IGNORED", "x")
+
+	cfg := testEnvConfig{Kept: "preset"}
+	require.NoError(t, LoadEnv("APP_", &cfg))
+
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, int64(1<<20), cfg.MaxBytes)
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, 90*time.Second, cfg.Timeout)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.Origins)
+	assert.Equal(t, "combined", cfg.LogFormat)
+	assert.Equal(t, testLevel("warn"), cfg.Level)
+	assert.Equal(t, "preset", cfg.Kept, "fields without variable nor default are kept")
+	assert.Equal(t, 3, cfg.Limits.Max)
+	assert.Empty(t, cfg.ignored)
+	assert.Contains(t, EnvSettings(), "APP_PORT")
+}
+
+func TestLoadEnv_Errors(t *testing.T) {
+	t.Setenv("ENVTEST_PORT", "eighty")
+	t.Setenv("ENVTEST_LOG_FORMAT", "json")
+	t.Setenv("ENVTEST_API_KEY", "abc")
+
+	var cfg struct {
+		Port      int    `env:"PORT" default:"8080"`
+		LogFormat string `env:"LOG_FORMAT" oneof:"default,combined,short,tiny"`
+		Database  string `env:"DATABASE_URL" required:"true"`
+		APIKey    int    `env:"API_KEY"`
+	}
+	err := LoadEnv("ENVTEST_", &cfg)
+	require.Error(t, err)
+	assert.Equal(t, "invalid environment: "+
+		`ENVTEST_API_KEY="`+MaskedValue+`": expected an integer`+"\n"+
+		"ENVTEST_DATABASE_URL: required, expected a string\n"+
+		`ENVTEST_LOG_FORMAT="json": expected one of default, combined, short, tiny`+"\n"+
+		`ENVTEST_PORT="eighty": expected an integer`, err.Error())
+	assert.Equal(t, 8080, cfg.Port, "invalid values are replaced by their default")
+	assert.Contains(t, InvalidEnv().Error(), "ENVTEST_PORT", "invalid values are recorded")
+
+	t.Run("invalid definitions", func(t *testing.T) {
+		var unsupported struct {
+			Ports []int `env:"PORTS"`
+		}
+		assert.ErrorContains(t, LoadEnv("", &unsupported), "field Ports (PORTS): unsupported type []int")
+
+		var badDefault struct {
+			Port int `env:"PORT" default:"http"`
+		}
+		assert.ErrorContains(t, LoadEnv("ENVTEST_", &badDefault), `invalid default "http", expected an integer`)
+
+		assert.ErrorContains(t, LoadEnv("", cfg), "expected a pointer to a struct")
+	})
+}
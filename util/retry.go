@@ -0,0 +1,161 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy configures Retry
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of calls, including the first one. Default: 3
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry. Default: 100ms
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between two attempts. Default: 10s
+	MaxBackoff time.Duration
+
+	// Multiplier is the growth factor of the backoff between retries. Default: 2
+	Multiplier float64
+
+	// Jitter is the fraction of each backoff randomly removed, from 0 to 1, so
+	// that clients failing together don't retry together. Default: 0.2
+	Jitter float64
+
+	// Retryable reports whether an error is worth retrying. Default: all errors
+	// but the context ones
+	Retryable func(err error) bool
+
+	// OnRetry is called before waiting for a retry, with the number of the
+	// attempt that failed (starting at 1), its error and the backoff, e.g. to log
+	// it with the request logger
+	OnRetry func(attempt int, err error, backoff time.Duration)
+
+	// now, sleep and rand are replaced by the tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+	rand  func() float64
+}
+
+// DefaultRetryPolicy returns the default policy of Retry: 3 attempts, with an
+// exponential backoff from 100ms to 10s and 20% of jitter
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Retry calls fn until it succeeds, returns an error that isn't retryable or the
+// attempts of the policy are exhausted, waiting with an exponential backoff
+// between the attempts. It returns the error of the last attempt.
+//
+// Retries are aborted when ctx is done, typically the context of the request,
+// or when its deadline would pass before the next attempt: the error of the
+// last attempt is then returned, joined with the context error when ctx is done.
+//
+// Example:
+//
+//	err := util.Retry(c.Context(), util.RetryPolicy{
+//	    MaxAttempts: 4,
+//	    Retryable:   isTemporary,
+//	    OnRetry: func(attempt int, err error, backoff time.Duration) {
+//	        c.Logger().Warn("Payment provider call failed, retrying", "attempt", attempt, "error", err, "backoff", backoff)
+//	    },
+//	}, func(ctx context.Context) error {
+//	    return provider.Charge(ctx, payment)
+//	})
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	policy = policy.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.MaxAttempts || !policy.Retryable(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return errors.Join(err, ctx.Err())
+		}
+
+		backoff := policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && deadline.Sub(policy.now()) < backoff {
+			return err
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, backoff)
+		}
+		if sleepErr := policy.sleep(ctx, backoff); sleepErr != nil {
+			return errors.Join(err, sleepErr)
+		}
+	}
+}
+
+// withDefaults returns the policy with the defaults of its unset fields
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaults.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaults.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaults.Multiplier
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	if p.Retryable == nil {
+		p.Retryable = func(err error) bool {
+			return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+		}
+	}
+	if p.now == nil {
+		p.now = time.Now
+	}
+	if p.sleep == nil {
+		p.sleep = sleepContext
+	}
+	if p.rand == nil {
+		p.rand = rand.Float64
+	}
+	return p
+}
+
+// backoff returns the wait after the failed attempt, capped by MaxBackoff
+// without overflowing for large attempt numbers, then jittered
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if math.IsInf(backoff, 0) || math.IsNaN(backoff) || backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	backoff -= backoff * p.Jitter * p.rand()
+	// float64(math.MaxInt64) rounds up, past the range of time.Duration
+	if backoff >= float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+// sleepContext waits for d, or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
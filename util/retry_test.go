@@ -0,0 +1,202 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock advanced by the sleeps of Retry
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// policy returns the policy using the clock, without jitter unless set
+func (c *fakeClock) policy(policy RetryPolicy) RetryPolicy {
+	policy.now = func() time.Time { return c.now }
+	policy.sleep = func(ctx context.Context, d time.Duration) error {
+		c.sleeps = append(c.sleeps, d)
+		c.now = c.now.Add(d)
+		return ctx.Err()
+	}
+	policy.rand = func() float64 { return 1 }
+	return policy
+}
+
+var errFlaky = errors.New("flaky")
+
+// failing returns a function failing n times with err before succeeding, and
+// counting its calls
+func failing(n int, err error, calls *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*calls++
+		if *calls <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestRetry(t *testing.T) {
+	permanent := errors.New("permanent")
+
+	tests := []struct {
+		desc           string
+		policy         RetryPolicy
+		failures       int
+		err            error
+		expectErr      error
+		expectCalls    int
+		expectSleeps   []time.Duration
+		expectAttempts []int
+	}{
+		{
+			desc:           "succeeds after retries",
+			policy:         RetryPolicy{MaxAttempts: 4, InitialBackoff: 100 * time.Millisecond},
+			failures:       2,
+			err:            errFlaky,
+			expectCalls:    3,
+			expectSleeps:   []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+			expectAttempts: []int{1, 2},
+		},
+		{
+			desc:           "attempts exhausted",
+			policy:         RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond},
+			failures:       5,
+			err:            errFlaky,
+			expectErr:      errFlaky,
+			expectCalls:    3,
+			expectSleeps:   []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+			expectAttempts: []int{1, 2},
+		},
+		{
+			desc:        "not retryable",
+			policy:      RetryPolicy{Retryable: func(err error) bool { return !errors.Is(err, permanent) }},
+			failures:    5,
+			err:         permanent,
+			expectErr:   permanent,
+			expectCalls: 1,
+		},
+		{
+			desc:        "context errors are not retried by default",
+			failures:    5,
+			err:         context.DeadlineExceeded,
+			expectErr:   context.DeadlineExceeded,
+			expectCalls: 1,
+		},
+		{
+			desc: "capped by the max backoff",
+			policy: RetryPolicy{
+				MaxAttempts:    5,
+				InitialBackoff: time.Second,
+				MaxBackoff:     5 * time.Second,
+				Multiplier:     3,
+			},
+			failures:       4,
+			err:            errFlaky,
+			expectCalls:    5,
+			expectSleeps:   []time.Duration{time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second},
+			expectAttempts: []int{1, 2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			var attempts []int
+			policy := tt.policy
+			policy.OnRetry = func(attempt int, err error, backoff time.Duration) {
+				assert.ErrorIs(t, err, tt.err)
+				attempts = append(attempts, attempt)
+			}
+
+			calls := 0
+			err := Retry(t.Context(), clock.policy(policy), failing(tt.failures, tt.err, &calls))
+
+			if tt.expectErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectErr)
+			}
+			assert.Equal(t, tt.expectCalls, calls)
+			assert.Equal(t, tt.expectSleeps, clock.sleeps)
+			assert.Equal(t, tt.expectAttempts, attempts)
+		})
+	}
+}
+
+func TestRetry_Deadline(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	ctx, cancel := context.WithDeadline(t.Context(), clock.now.Add(time.Second))
+	defer cancel()
+
+	calls := 0
+	policy := clock.policy(RetryPolicy{MaxAttempts: 10, InitialBackoff: 300 * time.Millisecond})
+	err := Retry(ctx, policy, failing(10, errFlaky, &calls))
+
+	// 300ms then 600ms fit in the second, the next 1.2s backoff doesn't
+	assert.Equal(t, errFlaky, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{300 * time.Millisecond, 600 * time.Millisecond}, clock.sleeps)
+}
+
+func TestRetry_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	calls := 0
+	err := Retry(ctx, clock.policy(RetryPolicy{MaxAttempts: 5}), func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errFlaky
+	})
+
+	assert.ErrorIs(t, err, errFlaky)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, clock.sleeps)
+}
+
+func TestRetry_CanceledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	calls := 0
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}
+	policy.OnRetry = func(int, error, time.Duration) { cancel() }
+
+	err := Retry(ctx, policy, failing(5, errFlaky, &calls))
+
+	assert.ErrorIs(t, err, errFlaky)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Duration(math.MaxInt64),
+		Multiplier:     10,
+		Jitter:         0.5,
+	}.withDefaults()
+
+	policy.rand = func() float64 { return 0 }
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 100*time.Second, policy.backoff(3))
+
+	policy.rand = func() float64 { return 1 }
+	assert.Equal(t, 50*time.Second, policy.backoff(3), "jitter removes up to half")
+
+	for _, random := range []float64{0, 1} {
+		policy.rand = func() float64 { return random }
+		for _, attempt := range []int{20, 1000, math.MaxInt} {
+			backoff := policy.backoff(attempt)
+			require.Positive(t, backoff, "attempt %d overflowed", attempt)
+			assert.LessOrEqual(t, backoff, policy.MaxBackoff)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvType is the expected format of an environment variable
+type EnvType int
+
+const (
+	EnvString EnvType = iota
+	EnvInt
+	EnvFloat
+	EnvBool
+	EnvDuration
+)
+
+// String describes the format, as reported by EnvError
+func (t EnvType) String() string {
+	switch t {
+	case EnvInt:
+		return "an integer"
+	case EnvFloat:
+		return "a number"
+	case EnvBool:
+		return "a boolean (true/false, 1/0, yes/no, on/off)"
+	case EnvDuration:
+		return "a duration (e.g. 10s, 1m30s)"
+	default:
+		return "a string"
+	}
+}
+
+// EnvError reports an environment variable with an invalid value
+type EnvError struct {
+	Name string
+	// Value is the raw value, masked for secrets (see IsSecretEnv)
+	Value    string
+	Expected string
+}
+
+// Error implements the error interface
+func (e *EnvError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s: required, expected %s", e.Name, e.Expected)
+	}
+	return fmt.Sprintf("%s=%q: expected %s", e.Name, e.Value, e.Expected)
+}
+
+// invalidEnv records the invalid values read by the GetEnv functions, replaced by their default
+var invalidEnv sync.Map
+
+// recordInvalid records an invalid value read by a GetEnv function
+func recordInvalid(key, value string, t EnvType) {
+	invalidEnv.Store(key, newEnvError(key, value, t.String()))
+}
+
+func newEnvError(key, value, expected string) *EnvError {
+	if IsSecretEnv(key) {
+		value = MaskedValue
+	}
+	return &EnvError{Name: key, Value: value, Expected: expected}
+}
+
+// InvalidEnv returns the invalid values read so far by the GetEnv functions,
+// which silently use their default instead, joined in an error listing the
+// variables with their value and expected format. Returns nil if all values are valid.
+func InvalidEnv() error {
+	var errs []*EnvError
+	invalidEnv.Range(func(_, value any) bool {
+		errs = append(errs, value.(*EnvError))
+		return true
+	})
+	return joinEnvErrors(errs)
+}
+
+// EnvSpec declares an environment variable of the application for ValidateEnv
+type EnvSpec struct {
+	Name     string
+	Type     EnvType
+	Required bool
+
+	// OneOf lists the accepted values, case-insensitively (optional)
+	OneOf []string
+}
+
+// ValidateEnv checks the environment variables of the application at startup:
+// required variables must be set and values must have the expected type. All
+// the invalid variables are reported in the returned error.
+//
+// Example:
+//
+//	if err := util.ValidateEnv([]util.EnvSpec{
+//	    {Name: "DATABASE_URL", Required: true},
+//	    {Name: "WORKERS", Type: util.EnvInt},
+//	    {Name: "STORAGE", OneOf: []string{"s3", "disk"}},
+//	}); err != nil {
+//	    log.Fatal(err)
+//	}
+func ValidateEnv(spec []EnvSpec) error {
+	var errs []*EnvError
+	for _, s := range spec {
+		value := strings.TrimSpace(getenv(s.Name))
+		if value == "" {
+			if s.Required {
+				errs = append(errs, &EnvError{Name: s.Name, Expected: s.Type.String()})
+			}
+			continue
+		}
+
+		if !validEnvValue(value, s.Type) {
+			errs = append(errs, newEnvError(s.Name, value, s.Type.String()))
+			continue
+		}
+		if len(s.OneOf) > 0 && !slices.ContainsFunc(s.OneOf, func(v string) bool { return strings.EqualFold(v, value) }) {
+			errs = append(errs, newEnvError(s.Name, value, "one of "+strings.Join(s.OneOf, ", ")))
+		}
+	}
+	return joinEnvErrors(errs)
+}
+
+// validEnvValue reports whether the value has the format of the type, as parsed
+// by the GetEnv functions
+func validEnvValue(value string, t EnvType) bool {
+	var err error
+	switch t {
+	case EnvInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case EnvFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case EnvBool:
+		_, ok := parseBool(value)
+		return ok
+	case EnvDuration:
+		_, err = time.ParseDuration(value)
+	}
+	return err == nil
+}
+
+// joinEnvErrors joins the errors sorted by variable name, nil if there are none
+func joinEnvErrors(errs []*EnvError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Name < errs[j].Name })
+	joined := make([]error, len(errs))
+	for i, err := range errs {
+		joined[i] = err
+	}
+	return fmt.Errorf("invalid environment: %w", errors.Join(joined...))
+}
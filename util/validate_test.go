@@ -0,0 +1,63 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEnv(t *testing.T) {
+	t.Setenv("TEST_WORKERS", "four")
+	t.Setenv("TEST_TIMEOUT", "10seconds")
+	t.Setenv("TEST_STORAGE", "S3")
+	t.Setenv("TEST_API_SECRET", "abc")
+	t.Setenv("TEST_VERBOSE", "on")
+
+	tests := []struct {
+		desc     string
+		spec     EnvSpec
+		expected string
+	}{
+		{desc: "valid int", spec: EnvSpec{Name: "TEST_MISSING", Type: EnvInt}},
+		{desc: "valid bool", spec: EnvSpec{Name: "TEST_VERBOSE", Type: EnvBool}},
+		{desc: "one of is case-insensitive", spec: EnvSpec{Name: "TEST_STORAGE", OneOf: []string{"s3", "disk"}}},
+		{desc: "required", spec: EnvSpec{Name: "TEST_MISSING", Required: true}, expected: "TEST_MISSING: required, expected a string"},
+		{desc: "invalid int", spec: EnvSpec{Name: "TEST_WORKERS", Type: EnvInt}, expected: `TEST_WORKERS="four": expected an integer`},
+		{desc: "invalid duration", spec: EnvSpec{Name: "TEST_TIMEOUT", Type: EnvDuration}, expected: `TEST_TIMEOUT="10seconds": expected a duration (e.g. 10s, 1m30s)`},
+		{desc: "not one of", spec: EnvSpec{Name: "TEST_STORAGE", OneOf: []string{"gcs", "disk"}}, expected: `TEST_STORAGE="S3": expected one of gcs, disk`},
+		{desc: "masks secrets", spec: EnvSpec{Name: "TEST_API_SECRET", Type: EnvInt}, expected: `TEST_API_SECRET="` + MaskedValue + `": expected an integer`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			err := ValidateEnv([]EnvSpec{test.spec})
+			if test.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestValidateEnv_ReportsAll(t *testing.T) {
+	t.Setenv("TEST_B", "x")
+	t.Setenv("TEST_A", "y")
+
+	err := ValidateEnv([]EnvSpec{{Name: "TEST_B", Type: EnvFloat}, {Name: "TEST_A", Type: EnvBool}})
+
+	require.Error(t, err)
+	assert.Equal(t, "invalid environment: TEST_A=\"y\": expected a boolean (true/false, 1/0, yes/no, on/off)\nTEST_B=\"x\": expected a number", err.Error())
+}
+
+func TestInvalidEnv(t *testing.T) {
+	t.Setenv("TEST_INVALID_PORT", "80a")
+
+	assert.Equal(t, 8080, GetEnvInt("TEST_INVALID_PORT", 8080))
+
+	err := InvalidEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `TEST_INVALID_PORT="80a": expected an integer`)
+}
@@ -0,0 +1,150 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// Values are the request values the validation rules depend on, such as query
+// flags, passed to the rules with the validation context, see ValidateCtx
+type Values map[string]any
+
+type valuesKey struct{}
+
+// WithValues returns a context carrying the values for the validation rules,
+// merged with the values ctx already carries
+func WithValues(ctx context.Context, values Values) context.Context {
+	if len(values) == 0 {
+		return ctx
+	}
+	merged := make(Values, len(values))
+	for key, value := range ValuesFrom(ctx) {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, valuesKey{}, merged)
+}
+
+// ValuesFrom returns the values of the validation context, for the rules
+// registered with RegisterRule
+func ValuesFrom(ctx context.Context) Values {
+	values, _ := ctx.Value(valuesKey{}).(Values)
+	return values
+}
+
+// RegisterRule registers a validation rule for the tag, whose function gets
+// the validation context (see ValidateCtx and ValuesFrom). The rule is called
+// for empty fields too, use omitempty to skip them. Without a message
+// registered with RegisterMessage, its errors are reported with the generic
+// message of the validator.
+//
+// Example:
+//
+//	v.RegisterRule("max_if_trial", func(ctx context.Context, fl validator.FieldLevel) bool {
+//	    return validation.ValuesFrom(ctx)["plan"] != "trial" || fl.Field().Int() <= 5
+//	})
+//	v.RegisterMessage("en", "max_if_trial", "{0} is limited to 5 on trial accounts")
+func (v *Validator) RegisterRule(tag string, fn validator.FuncCtx) error {
+	return v.validate.RegisterValidationCtx(tag, fn, true)
+}
+
+// RegisterMessage registers the message of the errors of a rule for the
+// locale, "{0}" being replaced by the field name and "{1}" by the rule
+// parameter, e.g. "{0} is required for trial accounts"
+func (v *Validator) RegisterMessage(locale, tag, message string) error {
+	v.loadLocale(locale)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	trans, ok := v.uni.GetTranslator(locale)
+	if !ok {
+		return fmt.Errorf("validation: locale %q is not registered", locale)
+	}
+	return v.validate.RegisterTranslation(tag, trans,
+		func(trans ut.Translator) error { return trans.Add(tag, message, true) },
+		func(trans ut.Translator, fe validator.FieldError) string {
+			msg, err := trans.T(tag, fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+			return msg
+		},
+	)
+}
+
+// conditionalRules are the built-in rules depending on the values of the
+// validation context, by tag
+var conditionalRules = map[string]func(values Values, params []string) bool{
+	// required_if_ctx=key value [key value...]: required when all the values match
+	"required_if_ctx": func(values Values, params []string) bool { return matchValues(values, params) },
+	// required_unless_ctx=key value [key value...]: required unless all the values match
+	"required_unless_ctx": func(values Values, params []string) bool { return !matchValues(values, params) },
+}
+
+// registerConditionalRules registers the conditional rules, with the message
+// of the required rule in every locale
+func (v *Validator) registerConditionalRules() {
+	for tag, required := range conditionalRules {
+		_ = v.validate.RegisterValidationCtx(tag, func(ctx context.Context, fl validator.FieldLevel) bool {
+			params := strings.Fields(fl.Param())
+			if len(params) == 0 || len(params)%2 != 0 {
+				panic(fmt.Sprintf("validation: %s expects key value pairs, got %q", tag, fl.Param()))
+			}
+			return !required(ValuesFrom(ctx), params) || hasValue(fl.Field())
+		}, true)
+	}
+
+	for _, locale := range v.locales {
+		if trans, ok := v.uni.GetTranslator(locale); ok {
+			v.registerConditionalMessages(trans)
+		}
+	}
+}
+
+// registerConditionalMessages registers the messages of the conditional rules
+// for the translator, the message of the required rule
+func (v *Validator) registerConditionalMessages(trans ut.Translator) {
+	for tag := range conditionalRules {
+		_ = v.validate.RegisterTranslation(tag, trans,
+			func(ut.Translator) error { return nil },
+			func(trans ut.Translator, fe validator.FieldError) string {
+				msg, err := trans.T("required", fe.Field())
+				if err != nil {
+					return fe.Error()
+				}
+				return msg
+			},
+		)
+	}
+}
+
+// matchValues reports whether the values match all the key value pairs,
+// compared as strings
+func matchValues(values Values, params []string) bool {
+	for i := 0; i < len(params); i += 2 {
+		value, ok := values[params[i]]
+		if !ok || fmt.Sprint(value) != params[i+1] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasValue reports whether a field is set, like the required rule
+func hasValue(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Pointer, reflect.Interface, reflect.Chan, reflect.Func:
+		return !field.IsNil()
+	case reflect.Invalid:
+		return false
+	default:
+		return !field.IsZero()
+	}
+}
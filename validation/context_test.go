@@ -0,0 +1,122 @@
+package validation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/validator/v10"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDeletion struct {
+	Reason   string   `json:"reason" validate:"required_if_ctx=force true"`
+	Approver string   `json:"approver" validate:"required_unless_ctx=role admin"`
+	Notify   []string `json:"notify,omitempty" validate:"required_if_ctx=force true role member"`
+}
+
+// validationErrors returns the messages of a validation error by field
+func validationErrors(t *testing.T, err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+	var apiErr *errors.ApiError
+	require.ErrorAs(t, err, &apiErr)
+	data, ok := apiErr.Data.(map[string]string)
+	require.True(t, ok)
+	return data
+}
+
+func TestValidator_ValidateCtx(t *testing.T) {
+	v := New(Config{
+		DefaultLocale:     "en",
+		UseJSONFieldNames: true,
+		Locales:           []LocaleConfig{Locale(fr.New(), fr_translations.RegisterDefaultTranslations)},
+	})
+
+	cases := []struct {
+		desc     string
+		data     testDeletion
+		values   Values
+		locale   string
+		expected map[string]string
+	}{
+		{
+			desc:   "conditions not met",
+			values: Values{"force": false, "role": "admin"},
+			locale: "en",
+		},
+		{
+			desc:     "without values",
+			locale:   "en",
+			expected: map[string]string{"approver": "approver is a required field"},
+		},
+		{
+			desc:     "required if",
+			values:   Values{"force": true, "role": "admin"},
+			locale:   "en",
+			expected: map[string]string{"reason": "reason is a required field"},
+		},
+		{
+			desc:     "all pairs matched",
+			values:   Values{"force": "true", "role": "member"},
+			locale:   "en",
+			expected: map[string]string{"reason": "reason is a required field", "approver": "approver is a required field", "notify": "notify is a required field"},
+		},
+		{
+			desc:   "set fields",
+			data:   testDeletion{Reason: "duplicate", Approver: "jane", Notify: []string{"ops"}},
+			values: Values{"force": true, "role": "member"},
+			locale: "en",
+		},
+		{
+			desc:     "translated",
+			values:   Values{"force": true, "role": "admin"},
+			locale:   "fr",
+			expected: map[string]string{"reason": "reason est un champ obligatoire"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := v.ValidateCtx(WithValues(context.Background(), tc.values), tc.data, tc.locale)
+			assert.Equal(t, tc.expected, validationErrors(t, err))
+		})
+	}
+}
+
+func TestValidator_ValidateCtxInvalidParams(t *testing.T) {
+	type invalid struct {
+		Reason string `validate:"required_if_ctx=force"`
+	}
+	v := New(DefaultValidatorConfig())
+	assert.Panics(t, func() { _ = v.ValidateCtx(context.Background(), invalid{}, "en") })
+}
+
+func TestWithValues(t *testing.T) {
+	ctx := WithValues(context.Background(), Values{"force": true, "role": "admin"})
+	ctx = WithValues(ctx, Values{"role": "member"})
+	assert.Equal(t, Values{"force": true, "role": "member"}, ValuesFrom(ctx))
+	assert.Nil(t, ValuesFrom(context.Background()))
+}
+
+func TestValidator_RegisterRule(t *testing.T) {
+	type project struct {
+		Members int `json:"members" validate:"max_if_trial"`
+	}
+	v := New(DefaultValidatorConfig())
+	require.NoError(t, v.RegisterRule("max_if_trial", func(ctx context.Context, fl validator.FieldLevel) bool {
+		return ValuesFrom(ctx)["plan"] != "trial" || fl.Field().Int() <= 5
+	}))
+	require.NoError(t, v.RegisterMessage("en", "max_if_trial", "{0} is limited to 5 on trial accounts"))
+	assert.Error(t, v.RegisterMessage("de", "max_if_trial", "{0}"))
+
+	trial := WithValues(context.Background(), Values{"plan": "trial"})
+	assert.NoError(t, v.ValidateCtx(trial, project{Members: 5}, "en"))
+	assert.NoError(t, v.ValidateCtx(context.Background(), project{Members: 10}, "en"))
+	err := v.ValidateCtx(trial, project{Members: 10}, "en")
+	assert.Equal(t, map[string]string{"members": "members is limited to 5 on trial accounts"}, validationErrors(t, err))
+}
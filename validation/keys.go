@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// namespacePart is a field of a validator namespace with the slice indexes or
+// map keys following it, e.g. "items[0]" or "attributes[color]"
+type namespacePart struct {
+	name string
+	keys []string
+}
+
+// fieldKey returns the error key of the field: its path relative to the
+// validated struct with the configured field names, indexes and map keys
+// separated by dots (e.g. "items.0.name", "attributes.color"). Embedded
+// structs without a JSON name are flattened like encoding/json does.
+func fieldKey(root reflect.Type, fieldError validator.FieldError) string {
+	names := splitNamespace(fieldError.Namespace())
+	fields := splitNamespace(fieldError.StructNamespace())
+	if len(names) < 2 || len(names) != len(fields) {
+		return fieldError.Field()
+	}
+
+	var path []string
+	t := root
+	for i := 1; i < len(names); i++ {
+		var field reflect.StructField
+		var found bool
+		if t = indirect(t); t != nil && t.Kind() == reflect.Struct {
+			field, found = t.FieldByName(fields[i].name)
+		}
+
+		if !found || !isFlattened(field) {
+			path = append(path, names[i].name)
+		}
+		path = append(path, names[i].keys...)
+
+		t = nil
+		if found {
+			t = field.Type
+			for range names[i].keys {
+				if t = indirect(t); t != nil {
+					switch t.Kind() {
+					case reflect.Slice, reflect.Array, reflect.Map:
+						t = t.Elem()
+					default:
+						t = nil
+					}
+				}
+			}
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+// isFlattened reports whether the field is an embedded struct without a JSON
+// name, whose fields are promoted to the parent in JSON
+func isFlattened(field reflect.StructField) bool {
+	if !field.Anonymous {
+		return false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name == ""
+}
+
+// indirect dereferences pointer types, nil for a nil type
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// splitNamespace splits a validator namespace on dots outside of brackets,
+// e.g. "Order.items[0].attributes[a.b]" into Order, items [0] and attributes [a.b]
+func splitNamespace(namespace string) []namespacePart {
+	var parts []namespacePart
+	var current namespacePart
+	start, depth := 0, 0
+
+	for i := 0; i < len(namespace); i++ {
+		switch namespace[i] {
+		case '[':
+			if depth == 0 {
+				if current.keys == nil {
+					current.name = namespace[start:i]
+				}
+				start = i + 1
+			}
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				current.keys = append(current.keys, namespace[start:i])
+				start = i + 1
+			}
+		case '.':
+			if depth == 0 {
+				if current.keys == nil {
+					current.name = namespace[start:i]
+				}
+				parts = append(parts, current)
+				current = namespacePart{}
+				start = i + 1
+			}
+		}
+	}
+	if current.keys == nil {
+		current.name = namespace[start:]
+	}
+	return append(parts, current)
+}
@@ -0,0 +1,74 @@
+package validation
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/go-playground/locales"
+)
+
+// lazyLocale is a locale registered on first use
+type lazyLocale struct {
+	load func() (locales.Translator, TranslationRegistrar)
+	once sync.Once
+}
+
+// LazyLocale creates the configuration of a locale, code being the locale of
+// its translator (e.g. "fr" or "pt_BR"), constructed and registered on its
+// first use, when a request is validated in this locale, instead of
+// when the validator is created. Registering all the translations of the
+// validator eagerly costs startup time and memory for the locales most
+// processes never see. The locale is still listed by Validator.Locales
+// before it is loaded.
+//
+// Example:
+//
+//	validation.LazyLocale("fr", func() (locales.Translator, validation.TranslationRegistrar) {
+//	    return fr.New(), fr_translations.RegisterDefaultTranslations
+//	})
+func LazyLocale(code string, load func() (locales.Translator, TranslationRegistrar)) LocaleConfig {
+	return LocaleConfig{Code: code, load: sync.OnceValues(load)}
+}
+
+// Translator returns the translator of the locale, constructing it if the
+// locale is lazy, e.g. for the plural rules of a message catalog
+func (l LocaleConfig) Translator() locales.Translator {
+	if l.load != nil {
+		translator, _ := l.load()
+		return translator
+	}
+	return l.Locale
+}
+
+// Locales returns the codes of the supported locales, English first, including
+// the lazy locales not loaded yet
+func (v *Validator) Locales() []string {
+	codes := slices.Clone(v.locales)
+	for code := range v.lazy {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes[1:])
+	return codes
+}
+
+// loadLocale registers the lazy locale on its first use, nothing is done for
+// the other locales
+func (v *Validator) loadLocale(code string) {
+	lazy, ok := v.lazy[code]
+	if !ok {
+		return
+	}
+
+	lazy.once.Do(func() {
+		translator, registrar := lazy.load()
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		_ = v.uni.AddTranslator(translator, true)
+		trans, _ := v.uni.GetTranslator(translator.Locale())
+		if err := registrar(v.validate, trans); err != nil && v.logger != nil {
+			v.logger.Error(err, "locale", code)
+		}
+		v.registerConditionalMessages(trans)
+	})
+}
@@ -0,0 +1,166 @@
+package validation
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/ar"
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fa"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/id"
+	"github.com/go-playground/locales/it"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/ko"
+	"github.com/go-playground/locales/lv"
+	"github.com/go-playground/locales/nl"
+	"github.com/go-playground/locales/pl"
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/locales/pt_BR"
+	"github.com/go-playground/locales/ru"
+	"github.com/go-playground/locales/th"
+	"github.com/go-playground/locales/tr"
+	"github.com/go-playground/locales/uk"
+	"github.com/go-playground/locales/vi"
+	"github.com/go-playground/locales/zh"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	ar_translations "github.com/go-playground/validator/v10/translations/ar"
+	de_translations "github.com/go-playground/validator/v10/translations/de"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fa_translations "github.com/go-playground/validator/v10/translations/fa"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+	it_translations "github.com/go-playground/validator/v10/translations/it"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+	ko_translations "github.com/go-playground/validator/v10/translations/ko"
+	lv_translations "github.com/go-playground/validator/v10/translations/lv"
+	nl_translations "github.com/go-playground/validator/v10/translations/nl"
+	pl_translations "github.com/go-playground/validator/v10/translations/pl"
+	pt_translations "github.com/go-playground/validator/v10/translations/pt"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+	th_translations "github.com/go-playground/validator/v10/translations/th"
+	tr_translations "github.com/go-playground/validator/v10/translations/tr"
+	uk_translations "github.com/go-playground/validator/v10/translations/uk"
+	vi_translations "github.com/go-playground/validator/v10/translations/vi"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+	zh_tw_translations "github.com/go-playground/validator/v10/translations/zh_tw"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// officialLocales are the locales of all the official validator translations
+var officialLocales = []struct {
+	code      string
+	locale    func() locales.Translator
+	registrar TranslationRegistrar
+}{
+	{"ar", ar.New, ar_translations.RegisterDefaultTranslations},
+	{"de", de.New, de_translations.RegisterDefaultTranslations},
+	{"es", es.New, es_translations.RegisterDefaultTranslations},
+	{"fa", fa.New, fa_translations.RegisterDefaultTranslations},
+	{"fr", fr.New, fr_translations.RegisterDefaultTranslations},
+	{"id", id.New, id_translations.RegisterDefaultTranslations},
+	{"it", it.New, it_translations.RegisterDefaultTranslations},
+	{"ja", ja.New, ja_translations.RegisterDefaultTranslations},
+	{"ko", ko.New, ko_translations.RegisterDefaultTranslations},
+	{"lv", lv.New, lv_translations.RegisterDefaultTranslations},
+	{"nl", nl.New, nl_translations.RegisterDefaultTranslations},
+	{"pl", pl.New, pl_translations.RegisterDefaultTranslations},
+	{"pt", pt.New, pt_translations.RegisterDefaultTranslations},
+	{"pt_BR", pt_BR.New, pt_BR_translations.RegisterDefaultTranslations},
+	{"ru", ru.New, ru_translations.RegisterDefaultTranslations},
+	{"th", th.New, th_translations.RegisterDefaultTranslations},
+	{"tr", tr.New, tr_translations.RegisterDefaultTranslations},
+	{"uk", uk.New, uk_translations.RegisterDefaultTranslations},
+	{"vi", vi.New, vi_translations.RegisterDefaultTranslations},
+	{"zh", zh.New, zh_translations.RegisterDefaultTranslations},
+	{"zh_Hant_TW", zh_Hant_TW.New, zh_tw_translations.RegisterDefaultTranslations},
+}
+
+func TestLazyLocale(t *testing.T) {
+	loads := 0
+	v := New(Config{
+		DefaultLocale:     "en",
+		UseJSONFieldNames: true,
+		Locales: []LocaleConfig{
+			LazyLocale("fr", func() (locales.Translator, TranslationRegistrar) {
+				loads++
+				return fr.New(), fr_translations.RegisterDefaultTranslations
+			}),
+			Locale(es.New(), es_translations.RegisterDefaultTranslations),
+		},
+	})
+
+	assert.Equal(t, []string{"en", "es", "fr"}, v.Locales(), "lazy locales are listed before they are loaded")
+	assert.Equal(t, 0, loads)
+
+	err := v.Validate(testItem{Quantity: 1}, "en")
+	assert.Equal(t, map[string]string{"name": "name is a required field"}, validationErrors(t, err))
+	assert.Equal(t, 0, loads, "the locale is not loaded for the other locales")
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			err := v.ValidateCtx(WithValues(context.Background(), Values{"force": true, "role": "admin"}), testDeletion{}, "fr")
+			assert.Equal(t, map[string]string{"reason": "reason est un champ obligatoire"}, validationErrors(t, err))
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 1, loads)
+}
+
+func TestLazyLocale_RegisterMessage(t *testing.T) {
+	type project struct {
+		Members int `json:"members" validate:"max=5"`
+	}
+	v := New(Config{
+		DefaultLocale:     "en",
+		UseJSONFieldNames: true,
+		Locales: []LocaleConfig{LazyLocale("fr", func() (locales.Translator, TranslationRegistrar) {
+			return fr.New(), fr_translations.RegisterDefaultTranslations
+		})},
+	})
+
+	require.NoError(t, v.RegisterMessage("fr", "max", "{0} est limité à {1}"))
+	err := v.Validate(project{Members: 10}, "fr")
+	assert.Equal(t, map[string]string{"members": "members est limité à 5"}, validationErrors(t, err))
+}
+
+func TestLocaleConfig_Translator(t *testing.T) {
+	lazy := LazyLocale("fr", func() (locales.Translator, TranslationRegistrar) {
+		return fr.New(), fr_translations.RegisterDefaultTranslations
+	})
+	assert.Equal(t, "fr", lazy.Translator().Locale())
+	assert.Same(t, lazy.Translator(), lazy.Translator(), "the translator is constructed once")
+	assert.Equal(t, "es", Locale(es.New(), es_translations.RegisterDefaultTranslations).Translator().Locale())
+}
+
+// BenchmarkNew_EagerLocales measures the creation of a validator registering
+// all the official translations
+func BenchmarkNew_EagerLocales(b *testing.B) {
+	for b.Loop() {
+		configs := make([]LocaleConfig, len(officialLocales))
+		for i, l := range officialLocales {
+			configs[i] = Locale(l.locale(), l.registrar)
+		}
+		New(Config{DefaultLocale: "en", Locales: configs})
+	}
+}
+
+// BenchmarkNew_LazyLocales measures the creation of a validator with all the
+// official translations registered lazily
+func BenchmarkNew_LazyLocales(b *testing.B) {
+	for b.Loop() {
+		configs := make([]LocaleConfig, len(officialLocales))
+		for i, l := range officialLocales {
+			configs[i] = LazyLocale(l.code, func() (locales.Translator, TranslationRegistrar) {
+				return l.locale(), l.registrar
+			})
+		}
+		New(Config{DefaultLocale: "en", Locales: configs})
+	}
+}
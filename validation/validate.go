@@ -2,9 +2,14 @@
 package validation
 
 import (
+	"context"
+	stderrors "errors"
+	"fmt"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/azizndao/glib/errors"
 	"github.com/azizndao/glib/slog"
@@ -21,6 +26,13 @@ type Validator struct {
 	logger        *slog.Logger
 	validate      *validator.Validate
 	uni           *ut.UniversalTranslator
+	locales       []string // registered locales, English first
+
+	// lazy are the locales registered on first use, see LazyLocale
+	lazy map[string]*lazyLocale
+	// mu guards the translators and translations, registered by lazy locales
+	// while requests are translated
+	mu sync.RWMutex
 }
 
 // Config holds configuration for the validator
@@ -41,6 +53,11 @@ type TranslationRegistrar func(v *validator.Validate, trans ut.Translator) error
 type LocaleConfig struct {
 	Locale    locales.Translator
 	Registrar TranslationRegistrar
+
+	// Code is the code of a lazy locale, e.g. "fr", see LazyLocale
+	Code string
+	// load constructs the translator and registrar of a lazy locale, once
+	load func() (locales.Translator, TranslationRegistrar)
 }
 
 // Locale creates a new locale configuration
@@ -69,6 +86,9 @@ func New(cfg Config) *Validator {
 	uni := ut.New(english, english)
 
 	for _, locale := range cfg.Locales {
+		if locale.load != nil {
+			continue
+		}
 		uni.AddTranslator(locale.Locale, true)
 		trans, ok := uni.GetTranslator(locale.Locale.Locale())
 		if !ok {
@@ -104,35 +124,148 @@ func New(cfg Config) *Validator {
 		_ = en_translations.RegisterDefaultTranslations(v, trans)
 	}
 
+	validator.locales = []string{"en"}
+	for _, locale := range cfg.Locales {
+		if locale.load != nil {
+			if validator.lazy == nil {
+				validator.lazy = make(map[string]*lazyLocale)
+			}
+			validator.lazy[locale.Code] = &lazyLocale{load: locale.load}
+			continue
+		}
+		validator.locales = append(validator.locales, locale.Locale.Locale())
+	}
+	validator.registerConditionalRules()
+
 	return validator
 }
 
-// Validate validates a struct and returns formatted errors
+// Validate validates a struct, or each struct of a slice, array or map, and
+// returns formatted errors keyed by the path of the field relative to the
+// validated value, e.g. "items.0.name" or "0.name" for a slice
 func (v *Validator) Validate(data any, locale string) error {
-	if err := v.validate.Struct(data); err != nil {
-		return v.formatValidationErrors(err, locale)
+	return v.ValidateCtx(context.Background(), data, locale)
+}
+
+// ValidateCtx is like Validate with a context passed to the rules, typically
+// the request context with the values of WithValues. The conditional rules
+// required_if_ctx and required_unless_ctx make a field required depending on
+// these values:
+//
+//	type DeleteRequest struct {
+//	    // Required when validated with Values{"force": true}
+//	    Reason string `json:"reason" validate:"required_if_ctx=force true"`
+//	}
+func (v *Validator) ValidateCtx(ctx context.Context, data any, locale string) error {
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.validateElements(ctx, value, locale)
+	}
+
+	if err := v.validate.StructCtx(ctx, data); err != nil {
+		return v.formatValidationErrors(err, reflect.TypeOf(data), locale)
 	}
 	return nil
 }
 
-// formatValidationErrors formats validation errors using the translator
-func (v *Validator) formatValidationErrors(err error, locale string) error {
+// validateElements validates the struct elements of a slice, array or map,
+// prefixing the error keys with the index or key of the element
+func (v *Validator) validateElements(ctx context.Context, value reflect.Value, locale string) error {
+	trans := v.translator(locale)
+	errs := make(map[string]string)
+	var failed []error
+
+	validateElement := func(key string, elem reflect.Value) error {
+		for elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Interface {
+			if elem.IsNil() {
+				return nil
+			}
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return nil
+		}
+
+		err := v.validate.StructCtx(ctx, elem.Interface())
+		if err == nil {
+			return nil
+		}
+		validationErrors, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return errors.BadRequest("Validation failed", err)
+		}
+		v.mu.RLock()
+		translateErrors(validationErrors, elem.Type(), trans, key, errs)
+		v.mu.RUnlock()
+		failed = append(failed, err)
+		return nil
+	}
+
+	if value.Kind() == reflect.Map {
+		iter := value.MapRange()
+		for iter.Next() {
+			if err := validateElement(fmt.Sprint(iter.Key().Interface()), iter.Value()); err != nil {
+				return err
+			}
+		}
+	} else {
+		for i := range value.Len() {
+			if err := validateElement(strconv.Itoa(i), value.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.UnprocessableEntity(errs, stderrors.Join(failed...))
+}
+
+// formatValidationErrors formats validation errors of a value of the root type
+// using the translator
+func (v *Validator) formatValidationErrors(err error, root reflect.Type, locale string) error {
 	validationErrors, ok := err.(validator.ValidationErrors)
 	if !ok {
 		return errors.BadRequest("Validation failed", err)
 	}
 
+	errs := make(map[string]string)
+	trans := v.translator(locale)
+	v.mu.RLock()
+	translateErrors(validationErrors, root, trans, "", errs)
+	v.mu.RUnlock()
+	return errors.UnprocessableEntity(errs, err)
+}
+
+// translator returns the translator of the locale, English if not registered,
+// registering the lazy locale on first use
+func (v *Validator) translator(locale string) ut.Translator {
+	v.loadLocale(locale)
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 	trans, ok := v.uni.GetTranslator(locale)
 	if !ok {
 		// Fallback to English if locale not found
 		trans, _ = v.uni.GetTranslator("en")
 	}
+	return trans
+}
 
-	errs := make(map[string]string)
+// translateErrors adds the user-friendly messages of the errors keyed by their
+// field path, see fieldKey, prefixed with the given prefix
+func translateErrors(validationErrors validator.ValidationErrors, root reflect.Type, trans ut.Translator, prefix string, errs map[string]string) {
 	for _, fieldError := range validationErrors {
-		// Use the translator for user-friendly messages
-		errs[fieldError.Field()] = fieldError.Translate(trans)
+		key := fieldKey(root, fieldError)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		errs[key] = fieldError.Translate(trans)
 	}
-
-	return errors.UnprocessableEntity(errs, err)
 }
@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/azizndao/glib/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name     string `json:"name" validate:"required"`
+	Quantity int    `json:"quantity" validate:"min=1"`
+}
+
+type testAttribute struct {
+	Value string `json:"value" validate:"required"`
+}
+
+type testAudit struct {
+	CreatedBy string `validate:"required"`
+}
+
+type testOrder struct {
+	testAudit
+	Customer   *testItem                `json:"customer" validate:"required"`
+	Items      []testItem               `json:"items" validate:"required,dive"`
+	Groups     [][]testItem             `json:"groups" validate:"dive,dive"`
+	Tags       map[string]string        `json:"tags" validate:"dive,keys,min=2,endkeys,required"`
+	Attributes map[string]testAttribute `json:"attributes" validate:"dive"`
+}
+
+func TestValidator_Validate(t *testing.T) {
+	v := New(DefaultValidatorConfig())
+
+	valid := testOrder{
+		testAudit: testAudit{CreatedBy: "admin"},
+		Customer:  &testItem{Name: "john", Quantity: 1},
+		Items:     []testItem{{Name: "pen", Quantity: 1}},
+	}
+
+	cases := []struct {
+		desc     string
+		data     any
+		expected []string
+	}{
+		{
+			desc: "valid",
+			data: valid,
+		},
+		{
+			desc: "slice of structs in a struct",
+			data: func() testOrder {
+				order := valid
+				order.Items = []testItem{{Quantity: 1}, {Name: "pen"}, {}}
+				return order
+			}(),
+			expected: []string{"items.0.name", "items.1.quantity", "items.2.name", "items.2.quantity"},
+		},
+		{
+			desc: "nested slices",
+			data: func() testOrder {
+				order := valid
+				order.Groups = [][]testItem{{{Name: "pen", Quantity: 1}}, {{}}}
+				return order
+			}(),
+			expected: []string{"groups.1.0.name", "groups.1.0.quantity"},
+		},
+		{
+			desc: "map values",
+			data: func() testOrder {
+				order := valid
+				order.Tags = map[string]string{"color": "", "x": "y"}
+				order.Attributes = map[string]testAttribute{"size": {}, "weight.kg": {Value: "2"}}
+				return order
+			}(),
+			expected: []string{"tags.color", "tags.x", "attributes.size.value"},
+		},
+		{
+			desc:     "embedded struct and pointer",
+			data:     &testOrder{Items: valid.Items, Customer: &testItem{Quantity: 1}},
+			expected: []string{"CreatedBy", "customer.name"},
+		},
+		{
+			desc:     "slice of structs",
+			data:     []testItem{{Name: "pen", Quantity: 1}, {Name: "book"}},
+			expected: []string{"1.quantity"},
+		},
+		{
+			desc:     "map of structs",
+			data:     map[string]*testItem{"pen": {Name: "pen", Quantity: 1}, "book": {}, "nil": nil},
+			expected: []string{"book.name", "book.quantity"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := v.Validate(tc.data, "en")
+			if tc.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var apiErr *errors.ApiError
+			require.ErrorAs(t, err, &apiErr)
+			data, ok := apiErr.Data.(map[string]string)
+			require.True(t, ok)
+
+			keys := make([]string, 0, len(data))
+			for key := range data {
+				keys = append(keys, key)
+			}
+			assert.ElementsMatch(t, tc.expected, keys)
+		})
+	}
+}
+
+func TestSplitNamespace(t *testing.T) {
+	parts := splitNamespace("Order.items[0].attributes[a.b][c]")
+	assert.Equal(t, []namespacePart{
+		{name: "Order"},
+		{name: "items", keys: []string{"0"}},
+		{name: "attributes", keys: []string{"a.b", "c"}},
+	}, parts)
+}